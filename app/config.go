@@ -3,6 +3,7 @@ package app
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/state"
 )
 
 //go:embed default-config.yaml
@@ -60,9 +62,50 @@ func LoadOrCreateConfig(forceDefaultConfig bool) (config.RuleSet, error) {
 		return nil, fmt.Errorf("Invalid configuration: %s\n%s", errMsg, helpMsg)
 	}
 
+	for _, w := range ruleSet.Warnings() {
+		log.Printf("Config warning: %s\n", w)
+	}
+
 	return ruleSet, nil
 }
 
+// EnableConfigReload allows the user to reload the config file with a menu command.
+// Changes to the config are applied to the open document without reloading it.
+func (e *Editor) EnableConfigReload() {
+	state.SetConfigLoader(e.editorState, func() (config.RuleSet, error) {
+		return LoadOrCreateConfig(false)
+	})
+}
+
+// DumpConfig writes the effective configuration for a path as YAML, preceded by comments
+// listing the rules that matched the path. This helps debug why a rule did or did not apply.
+func DumpConfig(path string, configRuleSet config.RuleSet, out io.Writer) error {
+	path = effectivePath(path)
+	fmt.Fprintf(out, "# Effective configuration for %s\n", path)
+
+	matchingRules := configRuleSet.MatchingRules(path)
+	if len(matchingRules) == 0 {
+		fmt.Fprintf(out, "# No rules matched, so all settings have default values.\n")
+	} else {
+		fmt.Fprintf(out, "# Rules applied, in order:\n")
+		for _, rule := range matchingRules {
+			fmt.Fprintf(out, "#   - %s (pattern %q)\n", rule.Name, rule.Pattern)
+		}
+	}
+
+	for _, w := range configRuleSet.Warnings() {
+		fmt.Fprintf(out, "# Warning: %s\n", w)
+	}
+
+	cfg := configRuleSet.ConfigForPath(path)
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.ToUntypedMap()); err != nil {
+		return fmt.Errorf("yaml.Encode: %w", err)
+	}
+	return encoder.Close()
+}
+
 func unmarshalRuleSet(data []byte) (config.RuleSet, error) {
 	var rules []config.Rule
 	if err := yaml.Unmarshal(data, &rules); err != nil {
@@ -1,10 +1,13 @@
 package app
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
 )
 
 func TestDefaultConfigYamlValid(t *testing.T) {
@@ -12,6 +15,7 @@ func TestDefaultConfigYamlValid(t *testing.T) {
 	require.NoError(t, err)
 	assert.Greater(t, len(rs), 1)
 	require.NoError(t, rs.Validate())
+	assert.Empty(t, rs.Warnings())
 
 	c := rs.ConfigForPath("test.go")
 	assert.Equal(t, "go", c.SyntaxLanguage)
@@ -19,3 +23,22 @@ func TestDefaultConfigYamlValid(t *testing.T) {
 	assert.True(t, c.AutoIndent)
 	assert.Equal(t, "olive", c.Styles["lineNum"].Color)
 }
+
+func TestDumpConfig(t *testing.T) {
+	ruleSet := config.RuleSet{
+		{Name: "default", Pattern: "**", Config: map[string]any{"tabSize": 4}},
+		{Name: "go", Pattern: "**/*.go", Config: map[string]any{"tabSize": 8, "tabexpand": true}},
+		{Name: "json", Pattern: "**/*.json", Config: map[string]any{"tabSize": 2}},
+	}
+
+	var out strings.Builder
+	err := DumpConfig("/test/main.go", ruleSet, &out)
+	require.NoError(t, err)
+
+	s := out.String()
+	assert.Contains(t, s, "# Effective configuration for /test/main.go\n")
+	assert.Contains(t, s, "#   - default (pattern \"**\")\n#   - go (pattern \"**/*.go\")\n")
+	assert.NotContains(t, s, "json")
+	assert.Contains(t, s, "# Warning: Rule \"go\": Unknown config key \"tabexpand\" (did you mean \"tabExpand\"?)\n")
+	assert.Contains(t, s, "\ntabSize: 8\n")
+}
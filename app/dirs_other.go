@@ -0,0 +1,33 @@
+//go:build !windows
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// userStateDir returns the directory for data that should persist between sessions,
+// but is less important than config.
+func userStateDir() (string, error) {
+	return xdgBaseDir("XDG_STATE_HOME", ".local", "state")
+}
+
+// userDataDir returns the directory for user-specific data files, such as the trash.
+func userDataDir() (string, error) {
+	return xdgBaseDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// userRuntimeDir returns the directory for files that only matter while the user is logged in, such as file locks.
+// If $XDG_RUNTIME_DIR is not set, this falls back to a directory in the system temp dir named for the user.
+func userRuntimeDir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return "", errors.New("path in $XDG_RUNTIME_DIR is relative")
+		}
+		return dir, nil
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("aretext-%d", os.Getuid())), nil
+}
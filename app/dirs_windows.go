@@ -0,0 +1,32 @@
+//go:build windows
+
+package app
+
+import (
+	"errors"
+	"os"
+)
+
+// userStateDir returns the directory for data that should persist between sessions,
+// but is less important than config. On Windows, this is %LocalAppData%, which is not synced between machines.
+func userStateDir() (string, error) {
+	return localAppDataDir()
+}
+
+// userDataDir returns the directory for user-specific data files, such as the trash.
+func userDataDir() (string, error) {
+	return localAppDataDir()
+}
+
+// userRuntimeDir returns the directory for files that only matter while the user is logged in, such as file locks.
+func userRuntimeDir() (string, error) {
+	return localAppDataDir()
+}
+
+func localAppDataDir() (string, error) {
+	dir := os.Getenv("LocalAppData")
+	if dir == "" {
+		return "", errors.New("%LocalAppData% is not defined")
+	}
+	return dir, nil
+}
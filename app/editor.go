@@ -3,6 +3,7 @@ package app
 import (
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -10,24 +11,45 @@ import (
 
 	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/display"
+	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/input"
 	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/nondet"
 	"github.com/aretext/aretext/state"
+	"github.com/aretext/aretext/syntax"
 )
 
 // Editor is a terminal-based text editing program.
 type Editor struct {
 	inputInterpreter  *input.Interpreter
 	editorState       *state.EditorState
+	configRuleSet     config.RuleSet
 	screen            tcell.Screen
 	palette           *display.Palette
 	documentLoadCount int
+	configApplyCount  int
+	paletteLanguage   syntax.Language // Syntax language of the palette, used to detect when the user changes the language.
+	bellCount         int
+	bellFlashChan     <-chan time.Time // Receives when the visual bell should stop flashing, or nil if not flashing.
+	scrollbarChan     <-chan time.Time // Receives when the scrollbar should be redrawn with more search matches, or nil if complete.
+	redrawChan        <-chan time.Time // Receives when a throttled redraw is due, or nil if no redraw is pending.
+	redrawThrottle    *redrawThrottle
 	termEventChan     chan tcell.Event
 	quitChan          chan struct{}
+	startupProfile    *StartupProfile // Nil unless profiling startup.
+	startupKeys       []*tcell.EventKey
 }
 
+// visualBellDuration is how long the status bar flashes for the visual bell.
+const visualBellDuration = 150 * time.Millisecond
+
+// scrollbarRedrawInterval is how long to wait between drawing batches of search matches in the scrollbar.
+// The delay gives the editor a chance to process input while the matches are found in a large document.
+const scrollbarRedrawInterval = 10 * time.Millisecond
+
 // NewEditor instantiates a new editor that uses the provided screen.
-func NewEditor(screen tcell.Screen, path string, lineNum uint64, configRuleSet config.RuleSet) *Editor {
+// If startupProfile is non-nil, the editor records the duration of each startup phase.
+func NewEditor(screen tcell.Screen, path string, lineNum uint64, configRuleSet config.RuleSet, pluginRegistry *state.PluginRegistry, logPath string, startupProfile *StartupProfile) *Editor {
 	screenWidth, screenHeight := screen.Size()
 	editorState := state.NewEditorState(
 		uint64(screenWidth),
@@ -35,19 +57,87 @@ func NewEditor(screen tcell.Screen, path string, lineNum uint64, configRuleSet c
 		configRuleSet,
 		suspendScreenFunc(screen),
 	)
+	if pluginRegistry != nil {
+		state.SetPluginRegistry(editorState, pluginRegistry)
+	}
+	if logPath != "" {
+		state.SetLogPath(editorState, effectivePath(logPath))
+	}
+	if configPath, err := ConfigPath(); err != nil {
+		log.Printf("Could not determine config path: %v\n", err)
+	} else {
+		state.SetConfigPath(editorState, configPath)
+	}
+	if frecencyStore, err := newFrecencyStore(); err != nil {
+		log.Printf("Could not create frecency store: %v\n", err)
+	} else {
+		state.SetFrecencyStore(editorState, frecencyStore)
+	}
+	if bookmarkStore, err := newBookmarkStore(); err != nil {
+		log.Printf("Could not create bookmark store: %v\n", err)
+	} else {
+		state.SetBookmarkStore(editorState, bookmarkStore)
+	}
+	if textFormatStore, err := newTextFormatStore(); err != nil {
+		log.Printf("Could not create text format store: %v\n", err)
+	} else {
+		state.SetTextFormatStore(editorState, textFormatStore)
+	}
+	if backupStore, err := newBackupStore(); err != nil {
+		log.Printf("Could not create backup store: %v\n", err)
+	} else {
+		state.SetBackupStore(editorState, backupStore)
+	}
+	if scratchStore, err := newScratchStore(); err != nil {
+		log.Printf("Could not create scratch store: %v\n", err)
+	} else {
+		state.SetScratchStore(editorState, scratchStore)
+	}
+	if trash, err := newTrash(); err != nil {
+		log.Printf("Could not create trash: %v\n", err)
+	} else {
+		state.SetTrash(editorState, trash)
+	}
+	if lockStore, err := newLockStore(); err != nil {
+		log.Printf("Could not create lock store: %v\n", err)
+	} else {
+		state.SetLockStore(editorState, lockStore)
+	}
+	startupProfile.Mark("editor state init")
 	inputInterpreter := input.NewInterpreter()
+	startupProfile.Mark("state machine deserialize")
 	palette := display.NewPalette()
 	documentLoadCount := editorState.DocumentLoadCount()
+	configApplyCount := editorState.ConfigApplyCount()
+	bellCount := editorState.BellCount()
 	termEventChan := make(chan tcell.Event, 1)
 	quitChan := make(chan struct{}, 1)
 	editor := &Editor{
 		inputInterpreter,
 		editorState,
+		configRuleSet,
 		screen,
 		palette,
 		documentLoadCount,
+		configApplyCount,
+		syntax.LanguagePlaintext,
+		bellCount,
+		nil,
+		nil,
+		nil,
+		newRedrawThrottle(maxRedrawsPerSecond),
 		termEventChan,
 		quitChan,
+		startupProfile,
+		nil,
+	}
+
+	// If the path is a directory, start with an untitled document
+	// and browse the directory in the file explorer.
+	var explorerDir string
+	if info, err := os.Stat(path); path != "" && err == nil && info.IsDir() {
+		explorerDir = path
+		path = ""
 	}
 
 	// Attempt to load the file.
@@ -61,6 +151,35 @@ func NewEditor(screen tcell.Screen, path string, lineNum uint64, configRuleSet c
 			return locate.StartOfLineNum(p.TextTree, lineNum)
 		},
 	)
+	startupProfile.Mark("file load")
+
+	if explorerDir != "" {
+		state.ShowFileExplorer(editorState, explorerDir)
+	}
+
+	// Terminals without bracketed paste send pasted text as keypresses,
+	// which triggers auto-indent, so suggest paste mode instead.
+	// Avoid replacing an error message from loading the document.
+	if !terminalSupportsBracketedPaste(os.Getenv("TERM")) && editorState.StatusMsg().Style != state.StatusMsgStyleError {
+		log.Printf("Terminal may not support bracketed paste\n")
+		state.SetStatusMsg(editorState, state.StatusMsg{
+			Style: state.StatusMsgStyleSuccess,
+			Text:  pasteModeHintMsg,
+		})
+	}
+
+	// Warn about config keys that may be typos, since these settings are silently ignored.
+	// Avoid replacing an error message from loading the document.
+	if warnings := configRuleSet.Warnings(); len(warnings) > 0 && editorState.StatusMsg().Style != state.StatusMsgStyleError {
+		msg := fmt.Sprintf("Config warning: %s", warnings[0])
+		if len(warnings) > 1 {
+			msg += fmt.Sprintf(" (and %d more)", len(warnings)-1)
+		}
+		state.SetStatusMsg(editorState, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  msg,
+		})
+	}
 
 	return editor
 }
@@ -69,7 +188,7 @@ func effectivePath(path string) string {
 	if path == "" {
 		// If no path is specified, set a default that is probably unique.
 		// The user can treat this as a scratchpad or discard it and open another file.
-		path = fmt.Sprintf("untitled-%d.txt", time.Now().Unix())
+		path = fmt.Sprintf("untitled-%s.txt", nondet.ID())
 	}
 
 	absPath, err := filepath.Abs(path)
@@ -84,8 +203,12 @@ func effectivePath(path string) string {
 // RunEventLoop processes events and draws to the screen, blocking until the user exits the program.
 func (e *Editor) RunEventLoop() {
 	e.redraw(true)
+	e.startupProfile.Mark("first render")
 	go e.screen.ChannelEvents(e.termEventChan, e.quitChan)
-	e.runMainEventLoop()
+	e.processStartupKeys()
+	if !e.editorState.QuitFlag() {
+		e.runMainEventLoop()
+	}
 	e.shutdown()
 }
 
@@ -103,11 +226,39 @@ func (e *Editor) runMainEventLoop() {
 			log.Printf("Task completed, executing resulting action...\n")
 			actionFunc(e.editorState)
 
+		case actionFunc := <-e.editorState.PendingLoadResultChan():
+			log.Printf("Background load completed, executing resulting action...\n")
+			actionFunc(e.editorState)
+
+		case actionFunc := <-e.editorState.LineChangeResultChan():
+			actionFunc(e.editorState)
+
+		case actionFunc := <-e.editorState.BlameResultChan():
+			log.Printf("Git blame loaded, executing resulting action...\n")
+			actionFunc(e.editorState)
+
+		case actionFunc := <-e.editorState.OutputCmdResultChan():
+			actionFunc(e.editorState)
+
 		case <-e.editorState.FileWatcher().ChangedChan():
 			e.handleFileChanged()
+
+		case <-e.bellFlashChan:
+			e.bellFlashChan = nil
+
+		case <-e.scrollbarChan:
+			e.scrollbarChan = nil
+
+		case <-e.redrawChan:
+			e.redrawChan = nil
 		}
 
 		e.handleIfDocumentLoaded()
+		e.handleIfConfigApplied()
+		e.handleIfSyntaxLanguageChanged()
+		state.ScheduleLineChangeUpdate(e.editorState)
+		state.LoadBlameIfNeeded(e.editorState)
+		state.SyncFileLocks(e.editorState)
 
 		if e.editorState.QuitFlag() {
 			log.Printf("Quit flag set, exiting event loop...\n")
@@ -119,20 +270,66 @@ func (e *Editor) runMainEventLoop() {
 		// This helps avoid the overhead of redrawing after every keypress
 		// if the user pastes a lot of text into the terminal emulator.
 		if len(e.termEventChan) == 0 && !inBracketedPaste {
-			e.redraw(false)
+			e.throttledRedraw()
+		}
+
+		// Ring the bell after redrawing, so the visual bell flashes the latest status message.
+		e.handleIfBellRung()
+
+		// Redraw the scrollbar again after a short delay if it hasn't found every search match yet.
+		if e.scrollbarChan == nil && e.editorState.DocumentBuffer().ScrollbarPending() {
+			e.scrollbarChan = time.After(scrollbarRedrawInterval)
 		}
 	}
 }
 
+// throttledRedraw redraws the screen, unless it was redrawn recently.
+// In that case, it schedules a redraw for later so the final state is always drawn.
+func (e *Editor) throttledRedraw() {
+	if e.redrawChan != nil {
+		// A redraw is already scheduled.
+		return
+	}
+
+	if ready, wait := e.redrawThrottle.Request(); !ready {
+		e.redrawChan = wait
+		return
+	}
+
+	e.redraw(false)
+	e.redrawThrottle.Redrawn()
+}
+
 func (e *Editor) handleTermEvent(event tcell.Event) {
+	if action, ok := e.pluginKeyBindingAction(event); ok {
+		action(e.editorState)
+		return
+	}
+
 	inputCtx := input.ContextFromEditorState(e.editorState)
 	actionFunc := e.inputInterpreter.ProcessEvent(event, inputCtx)
 	actionFunc(e.editorState)
 }
 
+// pluginKeyBindingAction returns the action for a key bound by a plugin.
+// Plugin key bindings are ignored while the user is typing a multi-key command.
+func (e *Editor) pluginKeyBindingAction(event tcell.Event) (func(*state.EditorState), bool) {
+	keyEvent, ok := event.(*tcell.EventKey)
+	if !ok {
+		return nil, false
+	}
+
+	inputMode := e.editorState.InputMode()
+	if e.inputInterpreter.InputBufferString(inputMode) != "" {
+		return nil, false
+	}
+
+	return state.PluginKeyBindingAction(e.editorState, keyEvent.Name())
+}
+
 func (e *Editor) handleFileChanged() {
 	log.Printf("File change detected, reloading file...\n")
-	state.AbortIfUnsavedChanges(e.editorState, "", state.ReloadDocument)
+	state.HandleFileChangedOnDisk(e.editorState)
 }
 
 func (e *Editor) handleIfDocumentLoaded() {
@@ -144,15 +341,57 @@ func (e *Editor) handleIfDocumentLoaded() {
 		e.inputInterpreter = input.NewInterpreter()
 
 		// Update palette, since the configuration might have changed.
-		styles := e.editorState.Styles()
-		e.palette = display.NewPaletteFromConfigStyles(styles)
+		e.updatePalette()
 
 		// Store the new document load count so we know when the next document loads.
 		e.documentLoadCount = documentLoadCount
 	}
 }
 
+func (e *Editor) handleIfConfigApplied() {
+	configApplyCount := e.editorState.ConfigApplyCount()
+	if configApplyCount != e.configApplyCount {
+		log.Printf("Detected config applied, updating palette")
+		e.updatePalette()
+		e.configApplyCount = configApplyCount
+	}
+}
+
+func (e *Editor) handleIfSyntaxLanguageChanged() {
+	if e.editorState.DocumentBuffer().SyntaxLanguage() != e.paletteLanguage {
+		log.Printf("Detected syntax language changed, updating palette")
+		e.updatePalette()
+	}
+}
+
+func (e *Editor) handleIfBellRung() {
+	bellCount := e.editorState.BellCount()
+	if bellCount == e.bellCount {
+		return
+	}
+	e.bellCount = bellCount
+
+	switch e.editorState.Bell() {
+	case config.BellAudible:
+		if err := e.screen.Beep(); err != nil {
+			log.Printf("Error ringing terminal bell: %v\n", err)
+		}
+	case config.BellVisual:
+		display.FlashStatusBar(e.screen)
+		e.screen.Show()
+		e.bellFlashChan = time.After(visualBellDuration)
+	}
+}
+
+func (e *Editor) updatePalette() {
+	styles := e.editorState.Styles()
+	language := e.editorState.DocumentBuffer().SyntaxLanguage()
+	e.palette = display.NewPaletteFromConfigStyles(styles, language)
+	e.paletteLanguage = language
+}
+
 func (e *Editor) shutdown() {
+	state.ReleaseFileLocks(e.editorState)
 	e.editorState.FileWatcher().Stop()
 	e.quitChan <- struct{}{}
 }
@@ -186,3 +425,86 @@ func suspendScreenFunc(screen tcell.Screen) state.SuspendScreenFunc {
 		return f()
 	}
 }
+
+// newFrecencyStore returns a store for ranking files in the file menu, saved in the user's cache directory.
+func newFrecencyStore() (*file.FrecencyStore, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve user cache directory: %w", err)
+	}
+	return file.NewFrecencyStore(filepath.Join(dir, "aretext", "frecency")), nil
+}
+
+// newBookmarkStore returns a store for bookmarks, saved in the user's config directory
+// since, unlike a cache, bookmark notes cannot be recreated if they are deleted.
+func newBookmarkStore() (*file.BookmarkStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve user config directory: %w", err)
+	}
+	return file.NewBookmarkStore(filepath.Join(dir, "aretext", "bookmarks")), nil
+}
+
+// newTextFormatStore returns a store for the line endings of each project, saved in the user's config directory
+// alongside bookmarks, since the format may have been chosen by the user.
+func newTextFormatStore() (*file.TextFormatStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve user config directory: %w", err)
+	}
+	return file.NewTextFormatStore(filepath.Join(dir, "aretext", "textformat")), nil
+}
+
+// newBackupStore returns a store for copies of files saved before they are overwritten.
+func newBackupStore() (*file.BackupStore, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve user cache directory: %w", err)
+	}
+	return file.NewBackupStore(filepath.Join(dir, "aretext", "backups")), nil
+}
+
+// newScratchStore returns a store for named scratch buffers, saved in the user's state directory.
+// Scratch buffers are only written to the store if the persistScratch config option is enabled.
+func newScratchStore() (*file.ScratchStore, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve user state directory: %w", err)
+	}
+	return file.NewScratchStore(filepath.Join(dir, "aretext", "scratch")), nil
+}
+
+// newTrash returns the user's trash, where deleted files can be restored by file managers.
+func newTrash() (*file.Trash, error) {
+	dir, err := userDataDir()
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve user data directory: %w", err)
+	}
+	return file.NewTrash(filepath.Join(dir, "Trash")), nil
+}
+
+// newLockStore returns a store for locks on files with unsaved changes, shared with other instances run by the user.
+func newLockStore() (*file.LockStore, error) {
+	dir, err := userRuntimeDir()
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve user runtime directory: %w", err)
+	}
+	return file.NewLockStore(filepath.Join(dir, "aretext", "locks"), os.Getpid()), nil
+}
+
+// xdgBaseDir returns the directory in the environment variable, or the default path relative to the home directory.
+// This follows the XDG base directory specification, which the standard library supports only for cache and config.
+func xdgBaseDir(envVar string, defaultPathInHome ...string) (string, error) {
+	if dir := os.Getenv(envVar); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return "", fmt.Errorf("path in $%s is relative", envVar)
+		}
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{home}, defaultPathInHome...)...), nil
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+func TestEffectivePathUntitled(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	restore := nondet.SetSource(nondet.NewDeterministicSource(start, time.Second, 0))
+	defer restore()
+
+	path := effectivePath("")
+	assert.Equal(t, "untitled-78fc2ffac2fd9401.txt", filepath.Base(path))
+	assert.True(t, filepath.IsAbs(path))
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingLogFile is a log file that is rotated when it exceeds a maximum size.
+// When rotated, the current log is renamed with a ".1" suffix (replacing any previous rotated log),
+// and a new, empty log file is created at the original path.
+type RotatingLogFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	size    int64
+	f       *os.File
+}
+
+// NewRotatingLogFile creates or truncates a log file at the given path.
+// If maxSize is zero, the log is never rotated.
+func NewRotatingLogFile(path string, maxSize int64) (*RotatingLogFile, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &RotatingLogFile{path: path, maxSize: maxSize, f: f}, nil
+}
+
+// Write implements io.Writer.
+func (lf *RotatingLogFile) Write(p []byte) (int, error) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.maxSize > 0 && lf.size > 0 && lf.size+int64(len(p)) > lf.maxSize {
+		if err := lf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := lf.f.Write(p)
+	lf.size += int64(n)
+	return n, err
+}
+
+// Close implements io.Closer.
+func (lf *RotatingLogFile) Close() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	return lf.f.Close()
+}
+
+func (lf *RotatingLogFile) rotate() error {
+	if err := lf.f.Close(); err != nil {
+		return fmt.Errorf("os.File.Close: %w", err)
+	}
+
+	if err := os.Rename(lf.path, lf.path+".1"); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	f, err := os.Create(lf.path)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+
+	lf.f = f
+	lf.size = 0
+	return nil
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	lf, err := NewRotatingLogFile(path, 8)
+	require.NoError(t, err)
+	defer lf.Close()
+
+	_, err = lf.Write([]byte("abcd\n"))
+	require.NoError(t, err)
+	_, err = lf.Write([]byte("efg\n"))
+	require.NoError(t, err)
+	_, err = lf.Write([]byte("hijklmnop\n"))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "hijklmnop\n", string(data))
+
+	data, err = os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, "efg\n", string(data))
+}
+
+func TestRotatingLogFileNoMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	lf, err := NewRotatingLogFile(path, 0)
+	require.NoError(t, err)
+	defer lf.Close()
+
+	_, err = lf.Write([]byte("abcd\n"))
+	require.NoError(t, err)
+	_, err = lf.Write([]byte("efgh\n"))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "abcd\nefgh\n", string(data))
+	assert.NoFileExists(t, path+".1")
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aretext/aretext/config"
+)
+
+// CheckNewFilePath applies the configured behavior for opening a path that does not exist.
+// This should be called before the terminal screen is initialized, since it may prompt the user for confirmation.
+// It returns an error if the editor should not open the path.
+func CheckNewFilePath(path string, configRuleSet config.RuleSet, in io.Reader, out io.Writer) error {
+	if path == "" {
+		// The editor will choose a new, untitled path.
+		return nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(absPath); !errors.Is(err, fs.ErrNotExist) {
+		// Either the file exists or there's some other error that the editor will report after loading.
+		return nil
+	}
+
+	cfg := configRuleSet.ConfigForPath(absPath)
+	switch cfg.NewFileBehavior {
+	case config.NewFileBehaviorError:
+		return fmt.Errorf("File does not exist: %s", path)
+	case config.NewFileBehaviorConfirm:
+		fmt.Fprintf(out, "File does not exist: %s\nCreate it? [y/N] ", path)
+		answer, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return fmt.Errorf("Did not create %s", path)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aretext/aretext/config"
+)
+
+func TestCheckNewFilePath(t *testing.T) {
+	tmpDir := t.TempDir()
+	existingPath := filepath.Join(tmpDir, "existing.txt")
+	err := os.WriteFile(existingPath, []byte("foo"), 0644)
+	assert.NoError(t, err)
+	newPath := filepath.Join(tmpDir, "new.txt")
+
+	testCases := []struct {
+		name         string
+		path         string
+		behavior     string
+		input        string
+		expectErrMsg string
+	}{
+		{
+			name:     "empty path",
+			path:     "",
+			behavior: config.NewFileBehaviorError,
+		},
+		{
+			name:     "existing path",
+			path:     existingPath,
+			behavior: config.NewFileBehaviorError,
+		},
+		{
+			name:     "new path, create",
+			path:     newPath,
+			behavior: config.NewFileBehaviorCreate,
+		},
+		{
+			name:         "new path, error",
+			path:         newPath,
+			behavior:     config.NewFileBehaviorError,
+			expectErrMsg: "File does not exist: " + newPath,
+		},
+		{
+			name:     "new path, confirm yes",
+			path:     newPath,
+			behavior: config.NewFileBehaviorConfirm,
+			input:    "y\n",
+		},
+		{
+			name:         "new path, confirm no",
+			path:         newPath,
+			behavior:     config.NewFileBehaviorConfirm,
+			input:        "n\n",
+			expectErrMsg: "Did not create " + newPath,
+		},
+		{
+			name:         "new path, confirm no input",
+			path:         newPath,
+			behavior:     config.NewFileBehaviorConfirm,
+			input:        "",
+			expectErrMsg: "Did not create " + newPath,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ruleSet := config.RuleSet{
+				{
+					Name:    "test",
+					Pattern: "**",
+					Config:  map[string]any{"newFileBehavior": tc.behavior},
+				},
+			}
+			var out strings.Builder
+			err := CheckNewFilePath(tc.path, ruleSet, strings.NewReader(tc.input), &out)
+			if tc.expectErrMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectErrMsg)
+			}
+		})
+	}
+}
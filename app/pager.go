@@ -0,0 +1,141 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/state"
+)
+
+// pagerStdinName is displayed in place of the document path when paging text from stdin.
+const pagerStdinName = "stdin"
+
+// PagerInput is text to display in pager mode that did not come from a file.
+// The text may contain formatting, such as color escape sequences, which is removed when it is displayed.
+type PagerInput struct {
+	Name string
+	Text string
+}
+
+// CheckPagerPath returns an error if the path cannot be displayed in pager mode.
+// Unlike the editor, the pager never creates a new file or browses a directory.
+func CheckPagerPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("File does not exist: %s", path)
+		}
+		return err
+	} else if info.IsDir() {
+		return fmt.Errorf("Cannot view a directory in pager mode: %s", path)
+	}
+	return nil
+}
+
+// ReadPagerInput reads text to display in pager mode from stdin, such as the output of git or man.
+// This should be called before the terminal screen is initialized.
+// It returns an error if stdin is a terminal, since then there is nothing to page.
+func ReadPagerInput(stdin *os.File) (*PagerInput, error) {
+	info, err := stdin.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return nil, errors.New("Pager mode requires a path or input from stdin")
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PagerInput{
+		Name: pagerStdinName,
+		Text: string(data),
+	}, nil
+}
+
+// cleanPagerText removes formatting that programs like git and man send to pagers.
+// Terminal escape sequences are removed, and backspace overstrikes
+// (used by man for bold and underlined text) are replaced by the overstruck character.
+// If keepColors is true, escape sequences that set colors are kept so they can be displayed.
+// Invalid UTF-8 is replaced by the Unicode replacement character.
+func cleanPagerText(s string, keepColors bool) string {
+	s = strings.ToValidUTF8(s, "�")
+	runes := make([]rune, 0, len(s))
+	input := []rune(s)
+	for i := 0; i < len(input); i++ {
+		switch r := input[i]; r {
+		case '\x1b':
+			j := skipEscapeSequence(input, i)
+			if keepColors && isColorEscapeSequence(input[i:j+1]) {
+				runes = append(runes, input[i:j+1]...)
+			}
+			i = j
+		case '\b':
+			// "x\bx" means bold "x", and "_\bx" means underlined "x".
+			// Either way, display the character after the backspace.
+			if len(runes) > 0 {
+				runes = runes[:len(runes)-1]
+			}
+		default:
+			runes = append(runes, r)
+		}
+	}
+	return string(runes)
+}
+
+// skipEscapeSequence returns the index of the last rune in the escape sequence starting at i.
+func skipEscapeSequence(input []rune, i int) int {
+	if i+1 >= len(input) {
+		return i
+	}
+
+	switch input[i+1] {
+	case '[':
+		// Control sequence: parameter and intermediate bytes, then a final byte in the range 0x40-0x7E.
+		for j := i + 2; j < len(input); j++ {
+			if input[j] >= 0x40 && input[j] <= 0x7E {
+				return j
+			}
+		}
+		return len(input) - 1
+	case ']':
+		// Operating system command: terminated by BEL or ESC \.
+		for j := i + 2; j < len(input); j++ {
+			if input[j] == '\a' {
+				return j
+			}
+			if input[j] == '\x1b' && j+1 < len(input) && input[j+1] == '\\' {
+				return j + 1
+			}
+		}
+		return len(input) - 1
+	default:
+		// Two-character escape sequence.
+		return i + 1
+	}
+}
+
+// isColorEscapeSequence returns whether an escape sequence sets colors or other text attributes, like "\x1b[31m".
+func isColorEscapeSequence(seq []rune) bool {
+	return len(seq) >= 3 && seq[1] == '[' && seq[len(seq)-1] == 'm'
+}
+
+// EnablePagerMode makes the editor act like a pager such as "less".
+// If input is not nil, the editor displays it instead of the document loaded from the path.
+func (e *Editor) EnablePagerMode(input *PagerInput, lineNum uint64) {
+	if input != nil {
+		name := effectivePath(input.Name)
+		keepColors := e.configRuleSet.ConfigForPath(name).ShowAnsiColors
+		state.LoadPagerText(e.editorState, name, cleanPagerText(input.Text, keepColors), func(p state.LocatorParams) uint64 {
+			return locate.StartOfLineNum(p.TextTree, lineNum)
+		})
+	}
+	state.EnablePagerMode(e.editorState)
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanPagerText(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		keepColors bool
+		expected   string
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: "",
+		},
+		{
+			name:     "plain text",
+			input:    "foo\nbar\n",
+			expected: "foo\nbar\n",
+		},
+		{
+			name:     "color escape sequences",
+			input:    "\x1b[1;32m+added\x1b[m\n\x1b[31m-removed\x1b[0m\n",
+			expected: "+added\n-removed\n",
+		},
+		{
+			name:       "keep color escape sequences",
+			input:      "\x1b[1;32m+added\x1b[m\n\x1b[31m-removed\x1b[0m\n",
+			keepColors: true,
+			expected:   "\x1b[1;32m+added\x1b[m\n\x1b[31m-removed\x1b[0m\n",
+		},
+		{
+			name:       "keep colors but remove other escape sequences",
+			input:      "\x1b]0;title\x1b\\\x1b[2K\x1b[31mfoo\x1b[31",
+			keepColors: true,
+			expected:   "\x1b[31mfoo",
+		},
+		{
+			name:     "operating system command terminated by BEL",
+			input:    "\x1b]8;;https://aretext.org\afoo\x1b]8;;\abar",
+			expected: "foobar",
+		},
+		{
+			name:     "operating system command terminated by ESC backslash",
+			input:    "\x1b]0;title\x1b\\foo",
+			expected: "foo",
+		},
+		{
+			name:     "two character escape sequence",
+			input:    "foo\x1b=bar",
+			expected: "foobar",
+		},
+		{
+			name:     "unterminated escape sequence",
+			input:    "foo\x1b[31",
+			expected: "foo",
+		},
+		{
+			name:     "overstrike bold",
+			input:    "N\bNA\bAM\bME\bE",
+			expected: "NAME",
+		},
+		{
+			name:     "overstrike underline",
+			input:    "_\bf_\bo_\bo bar",
+			expected: "foo bar",
+		},
+		{
+			name:     "invalid utf-8",
+			input:    "foo\xffbar",
+			expected: "foo�bar",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, cleanPagerText(tc.input, tc.keepColors))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+
+	"github.com/aretext/aretext/pluginapi"
+	"github.com/aretext/aretext/state"
+)
+
+// PluginDir returns the path to the directory containing plugins.
+func PluginDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("Could not retrieve user config directory: %w", err)
+	}
+	return filepath.Join(dir, "aretext", "plugins"), nil
+}
+
+// LoadPlugins loads every plugin (*.so file) in the plugin directory.
+// It is not an error for the plugin directory not to exist.
+func LoadPlugins(disablePlugins bool) (*state.PluginRegistry, error) {
+	registry := state.NewPluginRegistry()
+	if disablePlugins {
+		log.Printf("Plugins disabled\n")
+		return registry, nil
+	}
+
+	dir, err := PluginDir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := pluginPathsInDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		log.Printf("Loading plugin from %q\n", path)
+		initFunc, err := lookupPluginInitFunc(path)
+		if err != nil {
+			return nil, pluginLoadError(path, err)
+		}
+
+		if err := initPlugin(registry, initFunc); err != nil {
+			return nil, pluginLoadError(path, err)
+		}
+	}
+
+	return registry, nil
+}
+
+func pluginLoadError(path string, err error) error {
+	helpMsg := "To start without plugins, try\n\taretext -noplugins"
+	return fmt.Errorf("Error loading plugin %q: %w\n%s", path, err, helpMsg)
+}
+
+func pluginPathsInDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Plugin directory %q does not exist\n", dir)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Error reading plugin directory %q: %w", dir, err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".so" {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	// Load plugins in a consistent order, so later plugins override earlier ones.
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func lookupPluginInitFunc(path string) (pluginapi.InitFunc, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, err := p.Lookup(pluginapi.InitSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	initFunc, ok := sym.(func(pluginapi.Registry) error)
+	if !ok {
+		return nil, fmt.Errorf("%s has type %T, expected func(pluginapi.Registry) error", pluginapi.InitSymbol, sym)
+	}
+
+	return initFunc, nil
+}
+
+// initPlugin calls the plugin's init function and adds its registrations to the registry.
+// If the init function fails, none of the plugin's registrations are added.
+func initPlugin(registry *state.PluginRegistry, initFunc pluginapi.InitFunc) error {
+	pluginRegistry := state.NewPluginRegistry()
+	if err := initFunc(pluginRegistry); err != nil {
+		return err
+	}
+	log.Printf(
+		"Plugin registered %d menu command(s), %d key binding(s), and %d pre-save filter(s)\n",
+		pluginRegistry.NumMenuCommands(),
+		pluginRegistry.NumKeyBindings(),
+		pluginRegistry.NumPreSaveFilters(),
+	)
+	registry.Merge(pluginRegistry)
+	return nil
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/pluginapi"
+	"github.com/aretext/aretext/state"
+)
+
+func TestPluginPathsInDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.so", "a.so", "readme.txt"} {
+		err := os.WriteFile(filepath.Join(dir, name), nil, 0644)
+		require.NoError(t, err)
+	}
+	err := os.Mkdir(filepath.Join(dir, "subdir.so"), 0755)
+	require.NoError(t, err)
+
+	paths, err := pluginPathsInDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a.so"), filepath.Join(dir, "b.so")}, paths)
+
+	paths, err = pluginPathsInDir(filepath.Join(dir, "doesnotexist"))
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestInitPlugin(t *testing.T) {
+	registry := state.NewPluginRegistry()
+
+	err := initPlugin(registry, func(r pluginapi.Registry) error {
+		r.RegisterMenuCommand("foo", func(pluginapi.Editor) {})
+		r.RegisterKeyBinding("F5", func(pluginapi.Editor) {})
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = initPlugin(registry, func(r pluginapi.Registry) error {
+		r.RegisterMenuCommand("bar", func(pluginapi.Editor) {})
+		return errors.New("init failed")
+	})
+	assert.EqualError(t, err, "init failed")
+
+	// Registrations from the failed plugin are discarded.
+	assert.Equal(t, 1, registry.NumMenuCommands())
+	assert.Equal(t, 1, registry.NumKeyBindings())
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"time"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+// maxRedrawsPerSecond limits how often the editor repaints the screen.
+// Bursts of input, such as a paste into a terminal without bracketed paste,
+// would otherwise redraw the screen after every key.
+const maxRedrawsPerSecond = 60
+
+// redrawThrottle coalesces redraws so the screen repaints at most once per interval.
+type redrawThrottle struct {
+	interval   time.Duration
+	lastRedraw time.Time
+	now        func() time.Time
+	after      func(time.Duration) <-chan time.Time
+}
+
+func newRedrawThrottle(maxPerSecond int) *redrawThrottle {
+	return &redrawThrottle{
+		interval: time.Second / time.Duration(maxPerSecond),
+		now:      nondet.Now,
+		after:    time.After,
+	}
+}
+
+// Request checks whether the screen can be redrawn now.
+// If not, it returns a channel that receives when the redraw is due.
+func (t *redrawThrottle) Request() (ready bool, wait <-chan time.Time) {
+	if delay := t.delay(t.now()); delay > 0 {
+		return false, t.after(delay)
+	}
+	return true, nil
+}
+
+// Redrawn records that the screen was redrawn.
+func (t *redrawThrottle) Redrawn() {
+	t.lastRedraw = t.now()
+}
+
+// delay returns how long to wait before the next redraw, or zero if the screen can be redrawn now.
+func (t *redrawThrottle) delay(now time.Time) time.Duration {
+	elapsed := now.Sub(t.lastRedraw)
+	if elapsed < 0 || elapsed >= t.interval {
+		return 0
+	}
+	return t.interval - elapsed
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedrawClock controls the time seen by a redraw throttle and records the timers it starts.
+type fakeRedrawClock struct {
+	now    time.Time
+	timers []time.Duration
+}
+
+func (c *fakeRedrawClock) install(throttle *redrawThrottle) {
+	throttle.now = func() time.Time { return c.now }
+	throttle.after = func(d time.Duration) <-chan time.Time {
+		c.timers = append(c.timers, d)
+		return make(chan time.Time)
+	}
+}
+
+func TestRedrawThrottle(t *testing.T) {
+	clock := &fakeRedrawClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	throttle := newRedrawThrottle(10)
+	clock.install(throttle)
+
+	// The first redraw is never delayed.
+	ready, wait := throttle.Request()
+	assert.True(t, ready)
+	assert.Nil(t, wait)
+	throttle.Redrawn()
+
+	// Redraws within the interval wait for the rest of the interval.
+	ready, wait = throttle.Request()
+	assert.False(t, ready)
+	assert.NotNil(t, wait)
+	clock.now = clock.now.Add(40 * time.Millisecond)
+	ready, _ = throttle.Request()
+	assert.False(t, ready)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 60 * time.Millisecond}, clock.timers)
+
+	// Redraws after the interval can happen immediately.
+	clock.now = clock.now.Add(60 * time.Millisecond)
+	ready, _ = throttle.Request()
+	assert.True(t, ready)
+	throttle.Redrawn()
+
+	// If the clock goes backwards, redraw immediately rather than waiting.
+	clock.now = clock.now.Add(-time.Second)
+	ready, _ = throttle.Request()
+	assert.True(t, ready)
+	assert.Equal(t, 2, len(clock.timers))
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"github.com/aretext/aretext/session"
+	"github.com/aretext/aretext/state"
+)
+
+// OpenSession opens the named session in the user's session directory.
+func OpenSession(name string) (*session.Session, error) {
+	dir, err := session.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return session.Open(dir, name)
+}
+
+// JoinSession shares named clipboard pages and search history with other editor instances in the session.
+func (e *Editor) JoinSession(s *session.Session) {
+	state.JoinSession(e.editorState, s)
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+// StartupProfile records how long each phase of startup takes.
+// This helps diagnose slow startup, for example when the config directory is on a network filesystem.
+// All methods are safe to call on a nil profile, in which case they do nothing.
+type StartupProfile struct {
+	start  time.Time
+	last   time.Time
+	phases []startupPhase
+}
+
+type startupPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// NewStartupProfile starts a profile at the given time, usually when the program starts.
+func NewStartupProfile(start time.Time) *StartupProfile {
+	return &StartupProfile{start: start, last: start}
+}
+
+// Mark records the end of a startup phase, which began when the previous phase ended.
+func (p *StartupProfile) Mark(name string) {
+	p.markAt(name, nondet.Now())
+}
+
+func (p *StartupProfile) markAt(name string, t time.Time) {
+	if p == nil {
+		return
+	}
+	phase := startupPhase{name: name, duration: t.Sub(p.last)}
+	p.phases = append(p.phases, phase)
+	p.last = t
+	log.Printf("Startup phase %q took %s\n", phase.name, phase.duration)
+}
+
+// WriteSummary writes the duration of each startup phase and the total startup time.
+func (p *StartupProfile) WriteSummary(w io.Writer) {
+	if p == nil {
+		return
+	}
+
+	width := len("total")
+	for _, phase := range p.phases {
+		width = max(width, len(phase.name))
+	}
+
+	fmt.Fprintf(w, "Startup profile:\n")
+	for _, phase := range p.phases {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, phase.name, formatPhaseDuration(phase.duration))
+	}
+	fmt.Fprintf(w, "  %-*s  %s\n", width, "total", formatPhaseDuration(p.last.Sub(p.start)))
+}
+
+func formatPhaseDuration(d time.Duration) string {
+	return fmt.Sprintf("%8.2fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartupProfileSummary(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewStartupProfile(start)
+	p.markAt("config load", start.Add(1500*time.Microsecond))
+	p.markAt("file load", start.Add(12*time.Millisecond))
+
+	var sb strings.Builder
+	p.WriteSummary(&sb)
+	expected := "Startup profile:\n" +
+		"  config load      1.50ms\n" +
+		"  file load       10.50ms\n" +
+		"  total           12.00ms\n"
+	assert.Equal(t, expected, sb.String())
+}
+
+func TestStartupProfileNil(t *testing.T) {
+	var p *StartupProfile
+	p.Mark("config load")
+
+	var sb strings.Builder
+	p.WriteSummary(&sb)
+	assert.Equal(t, "", sb.String())
+}
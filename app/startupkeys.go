@@ -0,0 +1,40 @@
+package app
+
+import (
+	"log"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// SendStartupKeys queues key events to process as if the user typed them when the editor starts.
+// This allows scripted demos, reproducing bug reports, and simple automation.
+func (e *Editor) SendStartupKeys(keys []*tcell.EventKey) {
+	e.startupKeys = keys
+}
+
+// processStartupKeys processes the queued startup keys, stopping early if a key quits the editor.
+func (e *Editor) processStartupKeys() {
+	if len(e.startupKeys) == 0 {
+		return
+	}
+
+	// Wait for the document to finish loading, since edits are rejected while loading.
+	for actionChan := e.editorState.PendingLoadResultChan(); actionChan != nil; actionChan = e.editorState.PendingLoadResultChan() {
+		actionFunc := <-actionChan
+		actionFunc(e.editorState)
+	}
+	e.handleIfDocumentLoaded()
+
+	log.Printf("Processing %d startup keys\n", len(e.startupKeys))
+	for _, event := range e.startupKeys {
+		e.handleTermEvent(event)
+		e.handleIfDocumentLoaded()
+		e.handleIfConfigApplied()
+		if e.editorState.QuitFlag() {
+			log.Printf("Quit flag set by startup keys\n")
+			return
+		}
+	}
+	e.startupKeys = nil
+	e.redraw(false)
+}
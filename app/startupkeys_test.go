@@ -0,0 +1,37 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/input"
+)
+
+func TestProcessStartupKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(path, []byte("foo\nbar\n"), 0644))
+
+	screen := tcell.NewSimulationScreen("")
+	require.NoError(t, screen.Init())
+	defer screen.Fini()
+	screen.SetSize(80, 24)
+
+	editor := NewEditor(screen, path, 0, nil, nil, "", nil)
+	defer editor.editorState.FileWatcher().Stop()
+
+	keys, err := input.ParseKeys("ddihello <escape>:force save document<enter>:force quit<enter>ibaz")
+	require.NoError(t, err)
+	editor.SendStartupKeys(keys)
+	editor.processStartupKeys()
+
+	// Keys after the editor quits are ignored.
+	assert.True(t, editor.editorState.QuitFlag())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "hello bar\n", string(data))
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"log"
+
+	"github.com/gdamore/tcell/v2/terminfo"
+)
+
+// pasteModeHintMsg tells the user how to avoid mangled pastes when bracketed paste is unavailable.
+const pasteModeHintMsg = `Terminal may not support bracketed paste. Use "toggle paste mode" before pasting`
+
+// terminalSupportsBracketedPaste guesses whether the terminal reports pasted text
+// as a bracketed paste rather than a sequence of keypresses.
+// This uses the same heuristic as tcell: a terminal supports bracketed paste
+// if its terminfo entry declares it or if the terminal supports mouse input.
+func terminalSupportsBracketedPaste(term string) bool {
+	ti, err := terminfo.LookupTerminfo(term)
+	if err != nil {
+		log.Printf("Could not find terminfo for %q: %v\n", term, err)
+		return false
+	}
+	return ti.EnablePaste != "" || ti.Mouse != ""
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalSupportsBracketedPaste(t *testing.T) {
+	testCases := []struct {
+		term     string
+		expected bool
+	}{
+		{term: "xterm-256color", expected: true},
+		{term: "screen", expected: true},
+		{term: "vt100", expected: false},
+		{term: "", expected: false},
+		{term: "not-a-real-terminal", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.term, func(t *testing.T) {
+			assert.Equal(t, tc.expected, terminalSupportsBracketedPaste(tc.term))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Package arith evaluates simple arithmetic expressions.
+//
+// The grammar is intentionally small:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("+" | "-") unary | primary
+//	primary = number | "(" expr ")"
+//	number  = digit { digit } [ "." digit { digit } ]
+//
+// Results are computed exactly using rational numbers, so "0.1 + 0.2" is "0.3".
+package arith
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// maxDecimalDigits is the number of digits after the decimal point for results that are not integers.
+const maxDecimalDigits = 10
+
+// Eval evaluates an arithmetic expression and formats the result.
+// Integer results have no decimal point, and other results are rounded
+// to at most 10 digits after the decimal point, without trailing zeros.
+func Eval(expr string) (string, error) {
+	p := &parser{input: []rune(expr)}
+	result, err := p.parseExpr()
+	if err != nil {
+		return "", err
+	}
+
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return "", fmt.Errorf("Unexpected %q in expression", p.input[p.pos])
+	}
+
+	return formatRat(result), nil
+}
+
+func formatRat(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	s := r.FloatString(maxDecimalDigits)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+type parser struct {
+	input []rune
+	pos   int
+}
+
+func (p *parser) parseExpr() (*big.Rat, error) {
+	result, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.acceptOp("+-")
+		if !ok {
+			return result, nil
+		}
+
+		operand, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		if op == '+' {
+			result.Add(result, operand)
+		} else {
+			result.Sub(result, operand)
+		}
+	}
+}
+
+func (p *parser) parseTerm() (*big.Rat, error) {
+	result, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.acceptOp("*/%")
+		if !ok {
+			return result, nil
+		}
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case '*':
+			result.Mul(result, operand)
+		case '/':
+			if operand.Sign() == 0 {
+				return nil, errors.New("Division by zero")
+			}
+			result.Quo(result, operand)
+		case '%':
+			if !result.IsInt() || !operand.IsInt() {
+				return nil, errors.New("Remainder requires integers")
+			}
+			if operand.Sign() == 0 {
+				return nil, errors.New("Division by zero")
+			}
+			rem := new(big.Int).Rem(result.Num(), operand.Num())
+			result.SetInt(rem)
+		}
+	}
+}
+
+func (p *parser) parseUnary() (*big.Rat, error) {
+	if op, ok := p.acceptOp("+-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == '-' {
+			operand.Neg(operand)
+		}
+		return operand, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (*big.Rat, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, errors.New("Unexpected end of expression")
+	}
+
+	if p.input[p.pos] == '(' {
+		p.pos++
+		result, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.acceptOp(")"); !ok {
+			return nil, errors.New("Missing closing parenthesis")
+		}
+		return result, nil
+	}
+
+	return p.parseNumber()
+}
+
+func (p *parser) parseNumber() (*big.Rat, error) {
+	start := p.pos
+	p.skipDigits()
+	if p.pos == start {
+		return nil, fmt.Errorf("Unexpected %q in expression", p.input[p.pos])
+	}
+
+	if p.pos < len(p.input) && p.input[p.pos] == '.' {
+		p.pos++
+		fracStart := p.pos
+		p.skipDigits()
+		if p.pos == fracStart {
+			return nil, errors.New("Expected digits after decimal point")
+		}
+	}
+
+	result, ok := new(big.Rat).SetString(string(p.input[start:p.pos]))
+	if !ok {
+		return nil, fmt.Errorf("Invalid number %q", string(p.input[start:p.pos]))
+	}
+	return result, nil
+}
+
+// acceptOp consumes the next non-space rune if it is one of the given operators.
+func (p *parser) acceptOp(ops string) (rune, bool) {
+	p.skipSpaces()
+	if p.pos < len(p.input) && strings.ContainsRune(ops, p.input[p.pos]) {
+		op := p.input[p.pos]
+		p.pos++
+		return op, true
+	}
+	return 0, false
+}
+
+func (p *parser) skipDigits() {
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
@@ -0,0 +1,65 @@
+package arith
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: "42", expected: "42"},
+		{expr: "1 + 2", expected: "3"},
+		{expr: "10-4-3", expected: "3"},
+		{expr: "2 + 3 * 4", expected: "14"},
+		{expr: "(2 + 3) * 4", expected: "20"},
+		{expr: "-5 + 2", expected: "-3"},
+		{expr: "--5", expected: "5"},
+		{expr: "10 / 4", expected: "2.5"},
+		{expr: "10 / 3", expected: "3.3333333333"},
+		{expr: "2 / 3", expected: "0.6666666667"},
+		{expr: "0.1 + 0.2", expected: "0.3"},
+		{expr: "1.5 * 2", expected: "3"},
+		{expr: "17 % 5", expected: "2"},
+		{expr: "-17 % 5", expected: "-2"},
+		{expr: "  ( 1 + ( 2 * 3 ) )  ", expected: "7"},
+		{expr: "12345678901234567890 * 10", expected: "123456789012345678900"},
+		{expr: "-0.00000000001", expected: "0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			result, err := Eval(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	testCases := []struct {
+		expr        string
+		expectedErr string
+	}{
+		{expr: "", expectedErr: "Unexpected end of expression"},
+		{expr: "1 +", expectedErr: "Unexpected end of expression"},
+		{expr: "1 / 0", expectedErr: "Division by zero"},
+		{expr: "5 % 0", expectedErr: "Division by zero"},
+		{expr: "1.5 % 2", expectedErr: "Remainder requires integers"},
+		{expr: "(1 + 2", expectedErr: "Missing closing parenthesis"},
+		{expr: "1 2", expectedErr: "Unexpected '2' in expression"},
+		{expr: "1.", expectedErr: "Expected digits after decimal point"},
+		{expr: "abc", expectedErr: "Unexpected 'a' in expression"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Eval(tc.expr)
+			assert.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
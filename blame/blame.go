@@ -0,0 +1,168 @@
+// Package blame finds the git commit that last changed each line of a file.
+//
+// It runs "git blame" in the directory containing the file, so the file must be
+// tracked in a git repository and the git executable must be installed.
+package blame
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit describes the commit that last changed a line.
+type Commit struct {
+	Hash       string
+	Author     string
+	AuthorTime time.Time
+	Summary    string // First line of the commit message.
+}
+
+// ShortHash returns an abbreviated commit hash, like "git log --oneline".
+func (c *Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// Uncommitted returns whether the line has changes in the working tree that have not been committed.
+// Git reports these lines with a hash of all zeros.
+func (c *Commit) Uncommitted() bool {
+	return strings.Trim(c.Hash, "0") == ""
+}
+
+// File runs "git blame" on a file and returns the commit for each line, indexed by zero-based line number.
+// Lines that share a commit share the same *Commit.
+func File(ctx context.Context, path string) ([]*Commit, error) {
+	out, err := runGit(ctx, filepath.Dir(path), "blame", "--porcelain", "--", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bytes.NewReader(out))
+}
+
+// CommitMessage returns the author, date, and full message of a commit,
+// formatted like "git show" without the diff.
+func CommitMessage(ctx context.Context, dir string, hash string) (string, error) {
+	out, err := runGit(ctx, dir, "show", "--no-patch", "--no-color", "--format=medium", hash)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Git explains most failures, like a file outside a repository, in the first line of stderr.
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Parse reads the output of "git blame --porcelain" and returns the commit for each line,
+// indexed by zero-based line number.
+func Parse(r io.Reader) ([]*Commit, error) {
+	var lines []*Commit
+	commits := make(map[string]*Commit)
+	var current *Commit
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24) // Allow long lines in the file content.
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\t") {
+			// The content of the line ends each entry.
+			if current == nil {
+				return nil, fmt.Errorf("Missing commit header before line %d", len(lines)+1)
+			}
+			lines = append(lines, current)
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			// Each entry starts with "<hash> <orig line> <final line> [<num lines>]".
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("Invalid commit header %q", line)
+			}
+			hash := fields[0]
+			c, ok := commits[hash]
+			if !ok {
+				c = &Commit{Hash: hash}
+				commits[hash] = c
+			}
+			current = c
+			continue
+		}
+
+		// Information about a commit appears only in the first entry for the commit.
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-time":
+			secs, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid author time %q", value)
+			}
+			current.AuthorTime = time.Unix(secs, 0)
+		case "summary":
+			current.Summary = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// Annotation describes a commit in one line, with the short hash, author, and age of the commit.
+func Annotation(c *Commit, now time.Time) string {
+	if c.Uncommitted() {
+		return "Not committed yet"
+	}
+	return fmt.Sprintf("%s %s, %s", c.ShortHash(), c.Author, Age(c.AuthorTime, now))
+}
+
+// Age describes how long ago a time was, rounded down to the largest unit, like "3 days ago".
+func Age(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return pluralAge(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return pluralAge(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return pluralAge(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return pluralAge(int(d/(30*24*time.Hour)), "month")
+	default:
+		return pluralAge(int(d/(365*24*time.Hour)), "year")
+	}
+}
+
+func pluralAge(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s ago", unit)
+	}
+	return fmt.Sprintf("%d %ss ago", n, unit)
+}
@@ -0,0 +1,138 @@
+package blame
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const porcelainOutput = `1111111111111111111111111111111111111111 1 1 2
+author Alice Example
+author-mail <alice@example.com>
+author-time 1700000000
+author-tz +0000
+committer Alice Example
+committer-mail <alice@example.com>
+committer-time 1700000000
+committer-tz +0000
+summary Add greeting
+boundary
+filename hello.txt
+	hello
+1111111111111111111111111111111111111111 2 2
+	world
+0000000000000000000000000000000000000000 3 3 1
+author Not Committed Yet
+author-mail <not.committed.yet>
+author-time 1700000500
+author-tz +0000
+committer Not Committed Yet
+committer-mail <not.committed.yet>
+committer-time 1700000500
+committer-tz +0000
+summary Version of hello.txt from hello.txt
+previous 1111111111111111111111111111111111111111 hello.txt
+filename hello.txt
+		indented
+`
+
+func TestParse(t *testing.T) {
+	lines, err := Parse(strings.NewReader(porcelainOutput))
+	require.NoError(t, err)
+	require.Equal(t, 3, len(lines))
+
+	assert.Equal(t, "1111111", lines[0].ShortHash())
+	assert.Equal(t, "Alice Example", lines[0].Author)
+	assert.Equal(t, "Add greeting", lines[0].Summary)
+	assert.Equal(t, int64(1700000000), lines[0].AuthorTime.Unix())
+	assert.False(t, lines[0].Uncommitted())
+	assert.Same(t, lines[0], lines[1])
+	assert.True(t, lines[2].Uncommitted())
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse(strings.NewReader("abc\n\thello\n"))
+	assert.EqualError(t, err, `Invalid commit header "abc"`)
+}
+
+func TestAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		ago      time.Duration
+		expected string
+	}{
+		{ago: 30 * time.Second, expected: "just now"},
+		{ago: time.Minute, expected: "1 minute ago"},
+		{ago: 45 * time.Minute, expected: "45 minutes ago"},
+		{ago: 5 * time.Hour, expected: "5 hours ago"},
+		{ago: 3 * 24 * time.Hour, expected: "3 days ago"},
+		{ago: 65 * 24 * time.Hour, expected: "2 months ago"},
+		{ago: 800 * 24 * time.Hour, expected: "2 years ago"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Age(now.Add(-tc.ago), now))
+		})
+	}
+}
+
+func TestAnnotation(t *testing.T) {
+	now := time.Unix(1700000000, 0).Add(48 * time.Hour)
+	c := &Commit{Hash: "abcdef0123456789", Author: "Alice", AuthorTime: time.Unix(1700000000, 0)}
+	assert.Equal(t, "abcdef0 Alice, 2 days ago", Annotation(c, now))
+
+	uncommitted := &Commit{Hash: "0000000000000000"}
+	assert.Equal(t, "Not committed yet", Annotation(uncommitted, now))
+}
+
+func TestFileAndCommitMessage(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	path := filepath.Join(dir, "hello.txt")
+	git("init", "-q")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0644))
+	git("add", "hello.txt")
+	git("commit", "-q", "-m", "Add greeting\n\nThe greeting is friendly.")
+	require.NoError(t, os.WriteFile(path, []byte("hello\nworld\n"), 0644))
+
+	lines, err := File(context.Background(), path)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(lines))
+	assert.Equal(t, "Alice", lines[0].Author)
+	assert.Equal(t, "Add greeting", lines[0].Summary)
+	assert.True(t, lines[1].Uncommitted())
+
+	msg, err := CommitMessage(context.Background(), dir, lines[0].Hash)
+	require.NoError(t, err)
+	assert.Contains(t, msg, "Author: Alice <alice@example.com>")
+	assert.Contains(t, msg, "The greeting is friendly.")
+}
+
+func TestFileNotInRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0644))
+	_, err := File(context.Background(), path)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "git blame: "))
+}
@@ -1,5 +1,10 @@
 package clipboard
 
+import (
+	"log"
+	"strings"
+)
+
 // PageId represents a page in the clipboard.
 // This is equivalent to what vim calls a "register".
 type PageId int
@@ -41,6 +46,54 @@ const (
 	PageLetterX
 	PageLetterY
 	PageLetterZ
+
+	// The yank page "0" stores the contents of the most recent yank to the default page.
+	PageYank
+
+	// Numbered pages "1" through "9" store the contents of recent deletes to the default page
+	// that span multiple lines. Page 1 has the most recent delete, page 2 the one before, and so on.
+	PageNumbered1
+	PageNumbered2
+	PageNumbered3
+	PageNumbered4
+	PageNumbered5
+	PageNumbered6
+	PageNumbered7
+	PageNumbered8
+	PageNumbered9
+
+	// The small delete page "-" stores the contents of the most recent delete
+	// to the default page within a single line.
+	PageSmallDelete
+
+	// Append pages "A" through "Z" append text to the named pages "a" through "z".
+	// Getting the contents of an append page returns the contents of the named page.
+	PageAppendLetterA
+	PageAppendLetterB
+	PageAppendLetterC
+	PageAppendLetterD
+	PageAppendLetterE
+	PageAppendLetterF
+	PageAppendLetterG
+	PageAppendLetterH
+	PageAppendLetterI
+	PageAppendLetterJ
+	PageAppendLetterK
+	PageAppendLetterL
+	PageAppendLetterM
+	PageAppendLetterN
+	PageAppendLetterO
+	PageAppendLetterP
+	PageAppendLetterQ
+	PageAppendLetterR
+	PageAppendLetterS
+	PageAppendLetterT
+	PageAppendLetterU
+	PageAppendLetterV
+	PageAppendLetterW
+	PageAppendLetterX
+	PageAppendLetterY
+	PageAppendLetterZ
 )
 
 // PageIdForLetter returns the page named by a letter "a" to "z".
@@ -53,6 +106,27 @@ func PageIdForLetter(r rune) PageId {
 	return PageId(rune(PageLetterA) + offset)
 }
 
+// PageIdForName returns the page named by a rune: "a" to "z" for the named pages,
+// "A" to "Z" for the append pages, "0" for the yank page, "1" to "9" for the numbered pages,
+// and "-" for the small delete page.
+// If the rune does not name a page, this returns the null page.
+func PageIdForName(r rune) PageId {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return PageIdForLetter(r)
+	case r >= 'A' && r <= 'Z':
+		return PageId(rune(PageAppendLetterA) + r - 'A')
+	case r == '0':
+		return PageYank
+	case r >= '1' && r <= '9':
+		return PageId(rune(PageNumbered1) + r - '1')
+	case r == '-':
+		return PageSmallDelete
+	default:
+		return PageNull
+	}
+}
+
 // PageContent represents the content of a page in the clipboard.
 type PageContent struct {
 	Text     string
@@ -62,24 +136,134 @@ type PageContent struct {
 // C represents a clipboard.
 // The clipboard consists of distinct pages, each of which can store string content.
 type C struct {
-	pages map[PageId]PageContent
+	pages  map[PageId]PageContent
+	shared SharedPages
+}
+
+// SharedPages stores pages shared with other editor instances.
+type SharedPages interface {
+	// LoadPage returns the latest content of a page, or false if the page is empty or not shared.
+	LoadPage(p PageId) (PageContent, bool, error)
+
+	// StorePage stores the content of a page. Pages that are not shared are ignored.
+	StorePage(p PageId, pc PageContent) error
 }
 
 // New constructs a new, empty clipboard.
 func New() *C {
 	pages := make(map[PageId]PageContent, 0)
-	return &C{pages}
+	return &C{pages: pages}
+}
+
+// Share causes the clipboard to read and write pages from shared storage,
+// so other editor instances can paste text yanked in this instance and vice versa.
+func (c *C) Share(shared SharedPages) {
+	c.shared = shared
 }
 
 // Set stores a string in a page, replacing the prior contents.
+// For an append page, this appends to the contents of the named page instead.
 func (c *C) Set(p PageId, pc PageContent) {
 	if p == PageNull {
 		return
 	}
+
+	if namedPage, ok := namedPageForAppendPage(p); ok {
+		c.Set(namedPage, appendPageContent(c.Get(namedPage), pc))
+		return
+	}
+
 	c.pages[p] = pc
+
+	if c.shared != nil {
+		if err := c.shared.StorePage(p, pc); err != nil {
+			log.Printf("Error storing shared clipboard page: %v\n", err)
+		}
+	}
+}
+
+// SetYanked stores yanked (copied) text in a page, replacing the prior contents.
+// Text yanked to the default page is also stored in the yank page,
+// so it remains available after later deletes replace the default page.
+// The yank page is read-only, so text yanked to it goes to the default page instead.
+func (c *C) SetYanked(p PageId, pc PageContent) {
+	p = writablePage(p)
+	c.Set(p, pc)
+	if p == PageDefault {
+		c.Set(PageYank, pc)
+	}
+}
+
+// SetDeleted stores deleted text in a page, replacing the prior contents.
+// Text deleted to the default page is also stored in the small delete page
+// if it is within a single line. Otherwise, the contents of each numbered page
+// shift to the next numbered page (discarding page 9), and the text is stored in page 1.
+// As with SetYanked, text deleted to the yank page goes to the default page instead.
+func (c *C) SetDeleted(p PageId, pc PageContent) {
+	p = writablePage(p)
+	c.Set(p, pc)
+	if p != PageDefault {
+		return
+	}
+
+	if !pc.Linewise && !strings.Contains(pc.Text, "\n") {
+		c.Set(PageSmallDelete, pc)
+		return
+	}
+
+	for n := PageNumbered9; n > PageNumbered1; n-- {
+		c.Set(n, c.Get(n-1))
+	}
+	c.Set(PageNumbered1, pc)
 }
 
 // Get retrieves the contents of a page.
+// If the page is shared, this returns the latest content stored by any editor instance.
 func (c *C) Get(p PageId) PageContent {
+	if namedPage, ok := namedPageForAppendPage(p); ok {
+		p = namedPage
+	}
+
+	if c.shared != nil {
+		pc, ok, err := c.shared.LoadPage(p)
+		if err != nil {
+			log.Printf("Error loading shared clipboard page: %v\n", err)
+		} else if ok {
+			c.pages[p] = pc
+		}
+	}
 	return c.pages[p]
 }
+
+// writablePage returns the page that yanks and deletes to a page should write to.
+func writablePage(p PageId) PageId {
+	if p == PageYank {
+		return PageDefault
+	}
+	return p
+}
+
+// namedPageForAppendPage returns the named page "a" to "z" that an append page "A" to "Z" appends to.
+func namedPageForAppendPage(p PageId) (PageId, bool) {
+	if p < PageAppendLetterA || p > PageAppendLetterZ {
+		return PageNull, false
+	}
+	return PageLetterA + (p - PageAppendLetterA), true
+}
+
+// appendPageContent appends content to the existing content of a page.
+// If either is linewise, the result is linewise, with the appended content on a new line.
+func appendPageContent(existing PageContent, appended PageContent) PageContent {
+	if existing.Text == "" && !existing.Linewise {
+		return appended
+	}
+
+	if existing.Linewise || appended.Linewise {
+		return PageContent{
+			Text:     existing.Text + "\n" + appended.Text,
+			Linewise: true,
+		}
+	}
+
+	return PageContent{Text: existing.Text + appended.Text}
+}
@@ -1,6 +1,7 @@
 package clipboard
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -60,3 +61,175 @@ func TestPageIdForLetter(t *testing.T) {
 		})
 	}
 }
+
+func TestPageIdForName(t *testing.T) {
+	testCases := []struct {
+		name         string
+		r            rune
+		expectedPage PageId
+	}{
+		{name: "letter a", r: 'a', expectedPage: PageLetterA},
+		{name: "letter z", r: 'z', expectedPage: PageLetterZ},
+		{name: "yank page", r: '0', expectedPage: PageYank},
+		{name: "numbered page 1", r: '1', expectedPage: PageNumbered1},
+		{name: "numbered page 5", r: '5', expectedPage: PageNumbered5},
+		{name: "numbered page 9", r: '9', expectedPage: PageNumbered9},
+		{name: "small delete page", r: '-', expectedPage: PageSmallDelete},
+		{name: "append page A", r: 'A', expectedPage: PageAppendLetterA},
+		{name: "append page Z", r: 'Z', expectedPage: PageAppendLetterZ},
+		{name: "other", r: '!', expectedPage: PageNull},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedPage, PageIdForName(tc.r))
+		})
+	}
+}
+
+func TestClipboardSetYanked(t *testing.T) {
+	c := New()
+	c.SetYanked(PageDefault, PageContent{Text: "abc"})
+	assert.Equal(t, PageContent{Text: "abc"}, c.Get(PageDefault))
+	assert.Equal(t, PageContent{Text: "abc"}, c.Get(PageYank))
+
+	// Yanking to a named page does not change the yank page.
+	c.SetYanked(PageLetterA, PageContent{Text: "xyz"})
+	assert.Equal(t, PageContent{Text: "xyz"}, c.Get(PageLetterA))
+	assert.Equal(t, PageContent{Text: "abc"}, c.Get(PageDefault))
+	assert.Equal(t, PageContent{Text: "abc"}, c.Get(PageYank))
+}
+
+func TestClipboardSetDeletedSmall(t *testing.T) {
+	c := New()
+	c.SetYanked(PageDefault, PageContent{Text: "line", Linewise: true})
+	c.SetDeleted(PageDefault, PageContent{Text: "x"})
+	assert.Equal(t, PageContent{Text: "x"}, c.Get(PageDefault))
+	assert.Equal(t, PageContent{Text: "x"}, c.Get(PageSmallDelete))
+	assert.Equal(t, PageContent{}, c.Get(PageNumbered1))
+
+	// The yank page still has the yanked text.
+	assert.Equal(t, PageContent{Text: "line", Linewise: true}, c.Get(PageYank))
+}
+
+func TestClipboardSetDeletedRotatesNumberedPages(t *testing.T) {
+	c := New()
+	for i := 1; i <= 10; i++ {
+		c.SetDeleted(PageDefault, PageContent{Text: strconv.Itoa(i), Linewise: true})
+	}
+
+	// Page 1 has the most recent delete, and the oldest delete was discarded.
+	assert.Equal(t, PageContent{Text: "10", Linewise: true}, c.Get(PageDefault))
+	assert.Equal(t, PageContent{Text: "10", Linewise: true}, c.Get(PageNumbered1))
+	assert.Equal(t, PageContent{Text: "9", Linewise: true}, c.Get(PageNumbered2))
+	assert.Equal(t, PageContent{Text: "2", Linewise: true}, c.Get(PageNumbered9))
+	assert.Equal(t, PageContent{}, c.Get(PageSmallDelete))
+
+	// Charwise deletes that span multiple lines also rotate the numbered pages.
+	c.SetDeleted(PageDefault, PageContent{Text: "a\nb"})
+	assert.Equal(t, PageContent{Text: "a\nb"}, c.Get(PageNumbered1))
+	assert.Equal(t, PageContent{Text: "10", Linewise: true}, c.Get(PageNumbered2))
+}
+
+func TestClipboardSetDeletedNamedPage(t *testing.T) {
+	c := New()
+	c.SetDeleted(PageLetterB, PageContent{Text: "x"})
+	c.SetDeleted(PageLetterB, PageContent{Text: "line", Linewise: true})
+	assert.Equal(t, PageContent{Text: "line", Linewise: true}, c.Get(PageLetterB))
+	assert.Equal(t, PageContent{}, c.Get(PageDefault))
+	assert.Equal(t, PageContent{}, c.Get(PageSmallDelete))
+	assert.Equal(t, PageContent{}, c.Get(PageNumbered1))
+}
+
+type fakeSharedPages map[PageId]PageContent
+
+func (f fakeSharedPages) LoadPage(p PageId) (PageContent, bool, error) {
+	pc, ok := f[p]
+	return pc, ok, nil
+}
+
+func (f fakeSharedPages) StorePage(p PageId, pc PageContent) error {
+	if p >= PageLetterA && p <= PageLetterZ {
+		f[p] = pc
+	}
+	return nil
+}
+
+func TestClipboardShare(t *testing.T) {
+	shared := make(fakeSharedPages)
+	c1, c2 := New(), New()
+	c1.Share(shared)
+	c2.Share(shared)
+
+	// Yank to a shared page in one clipboard, then get it from the other.
+	c1.SetYanked(PageLetterA, PageContent{Text: "foo"})
+	assert.Equal(t, PageContent{Text: "foo"}, c2.Get(PageLetterA))
+
+	// Pages that are not shared remain separate.
+	c1.SetYanked(PageDefault, PageContent{Text: "bar"})
+	assert.Equal(t, PageContent{Text: "bar"}, c1.Get(PageDefault))
+	assert.Equal(t, PageContent{}, c2.Get(PageDefault))
+}
+
+func TestClipboardAppendPage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing PageContent
+		appended PageContent
+		expected PageContent
+	}{
+		{
+			name:     "append to empty page",
+			appended: PageContent{Text: "abc"},
+			expected: PageContent{Text: "abc"},
+		},
+		{
+			name:     "append charwise to charwise",
+			existing: PageContent{Text: "abc"},
+			appended: PageContent{Text: "def"},
+			expected: PageContent{Text: "abcdef"},
+		},
+		{
+			name:     "append linewise to linewise",
+			existing: PageContent{Text: "abc", Linewise: true},
+			appended: PageContent{Text: "def", Linewise: true},
+			expected: PageContent{Text: "abc\ndef", Linewise: true},
+		},
+		{
+			name:     "append linewise to charwise",
+			existing: PageContent{Text: "abc"},
+			appended: PageContent{Text: "def", Linewise: true},
+			expected: PageContent{Text: "abc\ndef", Linewise: true},
+		},
+		{
+			name:     "append charwise to linewise",
+			existing: PageContent{Text: "abc", Linewise: true},
+			appended: PageContent{Text: "def"},
+			expected: PageContent{Text: "abc\ndef", Linewise: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New()
+			c.Set(PageLetterC, tc.existing)
+			c.SetYanked(PageAppendLetterC, tc.appended)
+			assert.Equal(t, tc.expected, c.Get(PageLetterC))
+			assert.Equal(t, tc.expected, c.Get(PageAppendLetterC))
+			assert.Equal(t, PageContent{}, c.Get(PageDefault))
+		})
+	}
+}
+
+func TestClipboardYankPageReadOnly(t *testing.T) {
+	c := New()
+	c.SetYanked(PageYank, PageContent{Text: "abc"})
+	assert.Equal(t, PageContent{Text: "abc"}, c.Get(PageDefault))
+	assert.Equal(t, PageContent{Text: "abc"}, c.Get(PageYank))
+
+	// Deleting to the yank page deletes to the default page, leaving the yank page unchanged.
+	c.SetDeleted(PageYank, PageContent{Text: "x"})
+	assert.Equal(t, PageContent{Text: "x"}, c.Get(PageDefault))
+	assert.Equal(t, PageContent{Text: "x"}, c.Get(PageSmallDelete))
+	assert.Equal(t, PageContent{Text: "abc"}, c.Get(PageYank))
+}
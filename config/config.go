@@ -4,23 +4,51 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/syntax"
 )
 
 const DefaultSyntaxLanguage = "plaintext"
+const DefaultSyntaxBackend = syntax.BackendBuiltin
 const DefaultTabSize = 4
 const DefaultTabExpand = false
 const DefaultShowTabs = false
 const DefaultShowSpaces = false
 const DefaultAutoIndent = false
 const DefaultShowLineNumbers = false
+const DefaultShowRuler = false
+const DefaultShowDiffGutter = false
+const DefaultShowScrollbar = false
+const DefaultShowMatchingBracket = true
+const DefaultShowAnsiColors = false
+const DefaultShowKeyHints = false
+const DefaultRtlVisualOrder = false
+const DefaultInsertModeSelection = false
+const DefaultMatchPasteIndent = false
 const DefaultLineWrap = LineWrapCharacter
 const DefaultLineNumberMode = LineNumberModeAbsolute
+const DefaultNewFileBehavior = NewFileBehaviorCreate
+const DefaultCreateParentDirs = false
+const DefaultSymlinkSave = SymlinkSaveTarget
+const DefaultLongLineThreshold = 100000
+const DefaultFileWatchInterval = 1000
+const DefaultFileWatchDebounce = 200
+const DefaultLigatureBreaker = LigatureBreakerNone
+const DefaultEventHook = ""
+const DefaultBell = BellNone
+const DefaultPersistScratch = false
+const DefaultKeyboardLayout = KeyboardLayoutQwerty
+const DefaultTimestampFormat = "2006-01-02T15:04:05Z07:00" // ISO 8601
 
 // Config is a configuration for the editor.
 type Config struct {
 	// Language used for syntax highlighting.
 	SyntaxLanguage string
 
+	// Parser used for syntax highlighting, either the built-in parser or a tree-sitter grammar.
+	SyntaxBackend string
+
 	// Size of a tab character in columns.
 	TabSize int
 
@@ -42,15 +70,97 @@ type Config struct {
 	// Display mode for line numbers (relative or absolute)
 	LineNumberMode string
 
+	// If enabled, show the cursor position and the code points under the cursor in the status bar.
+	ShowRuler bool
+
+	// If enabled, show markers in the left margin for lines changed since the document was last saved.
+	ShowDiffGutter bool
+
+	// If enabled, show a scrollbar at the right edge with markers for search matches, flagged items, and changed lines.
+	ShowScrollbar bool
+
+	// If enabled, highlight the bracket, paren, or brace matching the one at or just before the cursor.
+	ShowMatchingBracket bool
+
+	// If enabled, show colors from ANSI escape sequences in read-only documents, such as colored log files, instead of the escape characters.
+	ShowAnsiColors bool
+
+	// If enabled, show a status message when a key is not bound to any command.
+	ShowKeyHints bool
+
 	// LineWrap controls how lines are soft-wrapped.
 	LineWrap string
 
+	// If enabled, display right-to-left text (such as Hebrew or Arabic) in visual order.
+	RtlVisualOrder bool
+
+	// If enabled, shift+arrow keys select text in insert mode,
+	// and ctrl-c, ctrl-x, and ctrl-v copy, cut, and paste.
+	InsertModeSelection bool
+
+	// If enabled, re-indent text pasted in insert mode to match the current line.
+	MatchPasteIndent bool
+
+	// NewFileBehavior controls what happens when opening a path that does not exist.
+	NewFileBehavior string
+
+	// If enabled, create missing parent directories when saving a document.
+	CreateParentDirs bool
+
+	// SymlinkSave controls what happens when saving a document opened through a symlink.
+	SymlinkSave string
+
+	// Show a warning when opening a document with lines longer than this many characters.
+	// Zero disables the warning.
+	LongLineThreshold int
+
+	// Interval in milliseconds between checks for changes to the file on disk.
+	FileWatchInterval int
+
+	// Time in milliseconds that the file on disk must remain unchanged before reloading it.
+	// This batches rapid successive writes into a single reload.  Zero disables the delay.
+	FileWatchDebounce int
+
+	// LigatureBreaker controls whether the editor draws an invisible character
+	// after each character in the document to prevent the terminal from rendering ligatures.
+	LigatureBreaker string
+
+	// Shell command to run when an event occurs, such as saving the document.
+	// The command receives the event in the environment variable $EVENT.
+	// Empty disables the hook.
+	EventHook string
+
+	// Bell controls the feedback when a key is not bound to any command or a count is too large.
+	Bell string
+
+	// If true, save the contents of named scratch buffers between sessions.
+	PersistScratch bool
+
+	// KeyboardLayout selects a preset that remaps keys in normal and visual mode,
+	// so the navigation keys stay on the home row for non-QWERTY layouts.
+	KeyboardLayout string
+
 	// User-defined commands to include in the menu.
 	MenuCommands []MenuCommandConfig
 
 	// Glob patterns for files or directories to exclude from file search.
 	HidePatterns []string
 
+	// Formats for the "insert timestamp" command, using Go time layouts.
+	// The first format is used by the insert mode key binding.
+	TimestampFormats []string
+
+	// Names of menu commands to show at the top of the command menu, in order, before the user types a query.
+	MenuPinnedCommands []string
+
+	// Names of menu commands to hide from the command menu's search results.
+	// A hidden command can still be selected by typing one of its aliases.
+	MenuHiddenCommands []string
+
+	// Keys to remap in normal and visual mode, from the key typed to the key a command expects.
+	// These override the remappings from the keyboard layout preset.
+	KeyRemap map[string]string
+
 	// (DEPRECATED) Glob patterns for directories to exclude from file search.
 	HideDirectories []string
 
@@ -63,6 +173,35 @@ const (
 	LineWrapWord      = "word"      // Break lines only between words.
 )
 
+const (
+	SymlinkSaveTarget  = "target"  // Write to the symlink's target, preserving the link.
+	SymlinkSaveReplace = "replace" // Replace the symlink with a regular file.
+)
+
+const (
+	LigatureBreakerNone = "none" // Draw characters without modification.
+	LigatureBreakerZwnj = "zwnj" // Draw a zero-width non-joiner (U+200C) after each character.
+	LigatureBreakerZwsp = "zwsp" // Draw a zero-width space (U+200B) after each character.
+)
+
+const (
+	BellNone    = "none"    // No bell.
+	BellAudible = "audible" // Ask the terminal to ring its bell.
+	BellVisual  = "visual"  // Briefly flash the status bar.
+)
+
+const (
+	KeyboardLayoutQwerty  = "qwerty"  // Use the default key bindings.
+	KeyboardLayoutColemak = "colemak" // Navigate with "hnei" instead of "hjkl".
+	KeyboardLayoutDvorak  = "dvorak"  // Navigate with "dhtn" instead of "hjkl".
+)
+
+const (
+	NewFileBehaviorCreate  = "create"  // Open an empty document that will be created on save.
+	NewFileBehaviorConfirm = "confirm" // Ask the user before opening an empty document.
+	NewFileBehaviorError   = "error"   // Exit with an error.
+)
+
 const (
 	CmdModeSilent        = "silent"        // accepts no input and any output is discarded.
 	CmdModeTerminal      = "terminal"      // takes control of the terminal.
@@ -70,6 +209,9 @@ const (
 	CmdModeInsertChoice  = "insertChoice"  // user can select one line from the output to insert into the document.
 	CmdModeFileLocations = "fileLocations" // output is interpreted as a list of file locations that can be opened in the editor.
 	CmdModeWorkingDir    = "workingDir"    // output is interpreted as a list of directories to set as the current working directory.
+	CmdModeScratch       = "scratch"       // output is opened in a scratch buffer that is not backed by a file.
+	CmdModeOutput        = "output"        // runs in the background, streaming output to a read-only scratch buffer.
+	CmdModeSubmenu       = "submenu"       // user can select one line from the output to pass to the submenu shell command.
 )
 
 type LineNumberMode string
@@ -92,6 +234,13 @@ type MenuCommandConfig struct {
 
 	// Save controls whether the document will be saved before running the command.
 	Save bool
+
+	// SubmenuShellCmd is the shell command to execute when the user selects a line
+	// from the output of ShellCmd. This is used only if Mode is CmdModeSubmenu.
+	SubmenuShellCmd string
+
+	// SubmenuMode controls how the submenu command's input and output are handled.
+	SubmenuMode string
 }
 
 // Names of styles that can be overridden by configuration.
@@ -150,37 +299,188 @@ type StyleConfig struct {
 // ConfigFromUntypedMap constructs a configuration from an untyped map.
 func ConfigFromUntypedMap(m map[string]any) Config {
 	return Config{
-		SyntaxLanguage:  stringOrDefault(m, "syntaxLanguage", DefaultSyntaxLanguage),
-		TabSize:         intOrDefault(m, "tabSize", DefaultTabSize),
-		TabExpand:       boolOrDefault(m, "tabExpand", DefaultTabExpand),
-		ShowTabs:        boolOrDefault(m, "showTabs", DefaultShowTabs),
-		ShowSpaces:      boolOrDefault(m, "showSpaces", DefaultShowSpaces),
-		AutoIndent:      boolOrDefault(m, "autoIndent", DefaultAutoIndent),
-		ShowLineNumbers: boolOrDefault(m, "showLineNumbers", DefaultShowLineNumbers),
-		LineNumberMode:  stringOrDefault(m, "lineNumberMode", string(DefaultLineNumberMode)),
-		LineWrap:        stringOrDefault(m, "lineWrap", DefaultLineWrap),
-		MenuCommands:    menuCommandsFromSlice(sliceOrNil(m, "menuCommands")),
-		HidePatterns:    stringSliceOrNil(m, "hidePatterns"),
-		HideDirectories: stringSliceOrNil(m, "hideDirectories"), // Deprecated by HidePatterns
-		Styles:          stylesFromMap(mapOrNil(m, "styles")),
+		SyntaxLanguage:      stringOrDefault(m, "syntaxLanguage", DefaultSyntaxLanguage),
+		SyntaxBackend:       stringOrDefault(m, "syntaxBackend", string(DefaultSyntaxBackend)),
+		TabSize:             intOrDefault(m, "tabSize", DefaultTabSize),
+		TabExpand:           boolOrDefault(m, "tabExpand", DefaultTabExpand),
+		ShowTabs:            boolOrDefault(m, "showTabs", DefaultShowTabs),
+		ShowSpaces:          boolOrDefault(m, "showSpaces", DefaultShowSpaces),
+		AutoIndent:          boolOrDefault(m, "autoIndent", DefaultAutoIndent),
+		ShowLineNumbers:     boolOrDefault(m, "showLineNumbers", DefaultShowLineNumbers),
+		LineNumberMode:      stringOrDefault(m, "lineNumberMode", string(DefaultLineNumberMode)),
+		ShowRuler:           boolOrDefault(m, "showRuler", DefaultShowRuler),
+		ShowDiffGutter:      boolOrDefault(m, "showDiffGutter", DefaultShowDiffGutter),
+		ShowScrollbar:       boolOrDefault(m, "showScrollbar", DefaultShowScrollbar),
+		ShowMatchingBracket: boolOrDefault(m, "showMatchingBracket", DefaultShowMatchingBracket),
+		ShowAnsiColors:      boolOrDefault(m, "showAnsiColors", DefaultShowAnsiColors),
+		ShowKeyHints:        boolOrDefault(m, "showKeyHints", DefaultShowKeyHints),
+		LineWrap:            stringOrDefault(m, "lineWrap", DefaultLineWrap),
+		RtlVisualOrder:      boolOrDefault(m, "rtlVisualOrder", DefaultRtlVisualOrder),
+		InsertModeSelection: boolOrDefault(m, "insertModeSelection", DefaultInsertModeSelection),
+		MatchPasteIndent:    boolOrDefault(m, "matchPasteIndent", DefaultMatchPasteIndent),
+		NewFileBehavior:     stringOrDefault(m, "newFileBehavior", DefaultNewFileBehavior),
+		CreateParentDirs:    boolOrDefault(m, "createParentDirs", DefaultCreateParentDirs),
+		SymlinkSave:         stringOrDefault(m, "symlinkSave", DefaultSymlinkSave),
+		LongLineThreshold:   intOrDefault(m, "longLineThreshold", DefaultLongLineThreshold),
+		FileWatchInterval:   intOrDefault(m, "fileWatchInterval", DefaultFileWatchInterval),
+		FileWatchDebounce:   intOrDefault(m, "fileWatchDebounce", DefaultFileWatchDebounce),
+		LigatureBreaker:     stringOrDefault(m, "ligatureBreaker", DefaultLigatureBreaker),
+		EventHook:           stringOrDefault(m, "eventHook", DefaultEventHook),
+		Bell:                stringOrDefault(m, "bell", DefaultBell),
+		PersistScratch:      boolOrDefault(m, "persistScratch", DefaultPersistScratch),
+		KeyboardLayout:      stringOrDefault(m, "keyboardLayout", DefaultKeyboardLayout),
+		MenuCommands:        menuCommandsFromSlice(sliceOrNil(m, "menuCommands")),
+		HidePatterns:        stringSliceOrNil(m, "hidePatterns"),
+		HideDirectories:     stringSliceOrNil(m, "hideDirectories"), // Deprecated by HidePatterns
+		TimestampFormats:    stringSliceOrNil(m, "timestampFormats"),
+		MenuPinnedCommands:  stringSliceOrNil(m, "menuPinnedCommands"),
+		MenuHiddenCommands:  stringSliceOrNil(m, "menuHiddenCommands"),
+		KeyRemap:            stringMapFromMap(mapOrNil(m, "keyRemap")),
+		Styles:              stylesFromMap(mapOrNil(m, "styles")),
 	}
 }
 
+// ToUntypedMap converts the configuration to an untyped map with the same keys as the config file.
+// This is the inverse of ConfigFromUntypedMap, except that empty deprecated keys are omitted.
+func (c Config) ToUntypedMap() map[string]any {
+	m := map[string]any{
+		"syntaxLanguage":      c.SyntaxLanguage,
+		"syntaxBackend":       c.SyntaxBackend,
+		"tabSize":             c.TabSize,
+		"tabExpand":           c.TabExpand,
+		"showTabs":            c.ShowTabs,
+		"showSpaces":          c.ShowSpaces,
+		"autoIndent":          c.AutoIndent,
+		"showLineNumbers":     c.ShowLineNumbers,
+		"lineNumberMode":      c.LineNumberMode,
+		"showRuler":           c.ShowRuler,
+		"showDiffGutter":      c.ShowDiffGutter,
+		"showScrollbar":       c.ShowScrollbar,
+		"showMatchingBracket": c.ShowMatchingBracket,
+		"showAnsiColors":      c.ShowAnsiColors,
+		"showKeyHints":        c.ShowKeyHints,
+		"lineWrap":            c.LineWrap,
+		"rtlVisualOrder":      c.RtlVisualOrder,
+		"insertModeSelection": c.InsertModeSelection,
+		"matchPasteIndent":    c.MatchPasteIndent,
+		"newFileBehavior":     c.NewFileBehavior,
+		"createParentDirs":    c.CreateParentDirs,
+		"symlinkSave":         c.SymlinkSave,
+		"longLineThreshold":   c.LongLineThreshold,
+		"fileWatchInterval":   c.FileWatchInterval,
+		"fileWatchDebounce":   c.FileWatchDebounce,
+		"ligatureBreaker":     c.LigatureBreaker,
+		"eventHook":           c.EventHook,
+		"bell":                c.Bell,
+		"persistScratch":      c.PersistScratch,
+		"keyboardLayout":      c.KeyboardLayout,
+		"menuCommands":        menuCommandsToSlice(c.MenuCommands),
+		"hidePatterns":        stringSliceToSlice(c.HidePatterns),
+		"timestampFormats":    stringSliceToSlice(c.TimestampFormats),
+		"menuPinnedCommands":  stringSliceToSlice(c.MenuPinnedCommands),
+		"menuHiddenCommands":  stringSliceToSlice(c.MenuHiddenCommands),
+		"keyRemap":            stringMapToMap(c.KeyRemap),
+		"styles":              stylesToMap(c.Styles),
+	}
+
+	if len(c.HideDirectories) > 0 {
+		m["hideDirectories"] = stringSliceToSlice(c.HideDirectories)
+	}
+
+	return m
+}
+
 // Validate checks that the values in the configuration are valid.
 func (c Config) Validate() error {
 	if c.TabSize < 1 {
 		return errors.New("TabSize must be greater than zero")
 	}
 
+	if c.LongLineThreshold < 0 {
+		return errors.New("LongLineThreshold must be greater than or equal to zero")
+	}
+
+	if c.FileWatchInterval <= 0 {
+		return errors.New("FileWatchInterval must be greater than zero")
+	}
+
+	if c.FileWatchDebounce < 0 {
+		return errors.New("FileWatchDebounce must be greater than or equal to zero")
+	}
+
 	if c.LineWrap != LineWrapCharacter && c.LineWrap != LineWrapWord {
 		return fmt.Errorf("LineWrap must be either %q or %q", LineWrapCharacter, LineWrapWord)
 	}
 
+	if c.SymlinkSave != SymlinkSaveTarget && c.SymlinkSave != SymlinkSaveReplace {
+		return fmt.Errorf("SymlinkSave must be either %q or %q", SymlinkSaveTarget, SymlinkSaveReplace)
+	}
+
+	switch c.LigatureBreaker {
+	case LigatureBreakerNone, LigatureBreakerZwnj, LigatureBreakerZwsp:
+	default:
+		return fmt.Errorf("LigatureBreaker must be %q, %q, or %q", LigatureBreakerNone, LigatureBreakerZwnj, LigatureBreakerZwsp)
+	}
+
+	switch c.Bell {
+	case BellNone, BellAudible, BellVisual:
+	default:
+		return fmt.Errorf("Bell must be %q, %q, or %q", BellNone, BellAudible, BellVisual)
+	}
+
+	switch c.KeyboardLayout {
+	case KeyboardLayoutQwerty, KeyboardLayoutColemak, KeyboardLayoutDvorak:
+	default:
+		return fmt.Errorf("KeyboardLayout must be %q, %q, or %q", KeyboardLayoutQwerty, KeyboardLayoutColemak, KeyboardLayoutDvorak)
+	}
+
+	for from, to := range c.KeyRemap {
+		if utf8.RuneCountInString(from) != 1 || utf8.RuneCountInString(to) != 1 {
+			return fmt.Errorf("KeyRemap must map single characters, but got %q to %q", from, to)
+		}
+	}
+
+	for _, format := range c.TimestampFormats {
+		if format == "" {
+			return errors.New("TimestampFormats must not contain an empty format")
+		}
+	}
+
+	for _, name := range c.MenuPinnedCommands {
+		if name == "" {
+			return errors.New("MenuPinnedCommands must not contain an empty name")
+		}
+	}
+
+	for _, name := range c.MenuHiddenCommands {
+		if name == "" {
+			return errors.New("MenuHiddenCommands must not contain an empty name")
+		}
+	}
+
+	switch syntax.Backend(c.SyntaxBackend) {
+	case syntax.BackendBuiltin, syntax.BackendTreeSitter:
+	default:
+		return fmt.Errorf("SyntaxBackend must be either %q or %q", syntax.BackendBuiltin, syntax.BackendTreeSitter)
+	}
+
 	lnm := LineNumberMode(c.LineNumberMode)
 	if lnm != LineNumberModeAbsolute && lnm != LineNumberModeRelative {
 		return fmt.Errorf("LineNumberMode must be either %q or %q", LineNumberModeAbsolute, LineNumberModeRelative)
 	}
 
+	switch c.NewFileBehavior {
+	case NewFileBehaviorCreate, NewFileBehaviorConfirm, NewFileBehaviorError:
+	default:
+		return fmt.Errorf("NewFileBehavior must be %q, %q, or %q", NewFileBehaviorCreate, NewFileBehaviorConfirm, NewFileBehaviorError)
+	}
+
+	for name := range c.Styles {
+		if !isGlobalStyleName(name) && !syntax.IsTokenRoleStyleName(name) {
+			return fmt.Errorf("Unrecognized style %q", name)
+		}
+	}
+
 	for _, cmd := range c.MenuCommands {
 		if cmd.Name == "" {
 			return fmt.Errorf("Menu name cannot be empty")
@@ -190,9 +490,9 @@ func (c Config) Validate() error {
 			return fmt.Errorf("Menu command %q shellCmd cannot be empty", cmd.Name)
 		}
 
-		if cmd.Mode != CmdModeSilent && cmd.Mode != CmdModeTerminal && cmd.Mode != CmdModeInsert && cmd.Mode != CmdModeInsertChoice && cmd.Mode != CmdModeFileLocations && cmd.Mode != CmdModeWorkingDir {
+		if !isValidCmdMode(cmd.Mode) && cmd.Mode != CmdModeSubmenu {
 			return fmt.Errorf(
-				"Menu command %q must have mode set to either %q, %q, %q, %q, %q, or %q",
+				"Menu command %q must have mode set to either %q, %q, %q, %q, %q, %q, %q, %q, or %q",
 				cmd.Name,
 				CmdModeSilent,
 				CmdModeTerminal,
@@ -200,13 +500,61 @@ func (c Config) Validate() error {
 				CmdModeInsertChoice,
 				CmdModeFileLocations,
 				CmdModeWorkingDir,
+				CmdModeScratch,
+				CmdModeOutput,
+				CmdModeSubmenu,
 			)
 		}
+
+		if cmd.Mode == CmdModeSubmenu {
+			if cmd.SubmenuShellCmd == "" {
+				return fmt.Errorf("Menu command %q submenuShellCmd cannot be empty", cmd.Name)
+			}
+
+			if !isValidCmdMode(cmd.SubmenuMode) {
+				return fmt.Errorf(
+					"Menu command %q must have submenuMode set to either %q, %q, %q, %q, %q, %q, %q, or %q",
+					cmd.Name,
+					CmdModeSilent,
+					CmdModeTerminal,
+					CmdModeInsert,
+					CmdModeInsertChoice,
+					CmdModeFileLocations,
+					CmdModeWorkingDir,
+					CmdModeScratch,
+					CmdModeOutput,
+				)
+			}
+		}
 	}
 
 	return nil
 }
 
+// isValidCmdMode returns whether the mode can be used to run a shell command.
+// This excludes CmdModeSubmenu, which requires a submenu shell command.
+func isValidCmdMode(mode string) bool {
+	switch mode {
+	case CmdModeSilent, CmdModeTerminal, CmdModeInsert, CmdModeInsertChoice, CmdModeFileLocations, CmdModeWorkingDir, CmdModeScratch, CmdModeOutput:
+		return true
+	default:
+		return false
+	}
+}
+
+func isGlobalStyleName(name string) bool {
+	switch name {
+	case StyleLineNum, StyleTokenOperator, StyleTokenKeyword, StyleTokenNumber, StyleTokenString, StyleTokenComment,
+		StyleTokenCustom1, StyleTokenCustom2, StyleTokenCustom3, StyleTokenCustom4,
+		StyleTokenCustom5, StyleTokenCustom6, StyleTokenCustom7, StyleTokenCustom8,
+		StyleTokenCustom9, StyleTokenCustom10, StyleTokenCustom11, StyleTokenCustom12,
+		StyleTokenCustom13, StyleTokenCustom14, StyleTokenCustom15, StyleTokenCustom16:
+		return true
+	default:
+		return false
+	}
+}
+
 func (c Config) HidePatternsAndHideDirectories() []string {
 	result := make([]string, 0, len(c.HidePatterns)+len(c.HideDirectories))
 	result = append(result, c.HidePatterns...)
@@ -309,6 +657,19 @@ func mapOrNil(m map[string]any, key string) map[string]any {
 	return subMap
 }
 
+func stringMapFromMap(m map[string]any) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			log.Printf("Could not decode string from %v\n", v)
+			continue
+		}
+		result[k] = s
+	}
+	return result
+}
+
 func menuCommandsFromSlice(s []any) []MenuCommandConfig {
 	result := make([]MenuCommandConfig, 0, len(s))
 	for _, m := range s {
@@ -323,6 +684,9 @@ func menuCommandsFromSlice(s []any) []MenuCommandConfig {
 			ShellCmd: stringOrDefault(menuMap, "shellCmd", ""),
 			Mode:     stringOrDefault(menuMap, "mode", CmdModeTerminal),
 			Save:     boolOrDefault(menuMap, "save", false),
+
+			SubmenuShellCmd: stringOrDefault(menuMap, "submenuShellCmd", ""),
+			SubmenuMode:     stringOrDefault(menuMap, "submenuMode", CmdModeSilent),
 		})
 	}
 	return result
@@ -348,3 +712,52 @@ func stylesFromMap(m map[string]any) map[string]StyleConfig {
 	}
 	return result
 }
+
+func stringSliceToSlice(ss []string) []any {
+	result := make([]any, 0, len(ss))
+	for _, s := range ss {
+		result = append(result, s)
+	}
+	return result
+}
+
+func stringMapToMap(sm map[string]string) map[string]any {
+	result := make(map[string]any, len(sm))
+	for k, v := range sm {
+		result[k] = v
+	}
+	return result
+}
+
+func menuCommandsToSlice(cmds []MenuCommandConfig) []any {
+	result := make([]any, 0, len(cmds))
+	for _, cmd := range cmds {
+		m := map[string]any{
+			"name":     cmd.Name,
+			"shellCmd": cmd.ShellCmd,
+			"mode":     cmd.Mode,
+			"save":     cmd.Save,
+		}
+		if cmd.Mode == CmdModeSubmenu {
+			m["submenuShellCmd"] = cmd.SubmenuShellCmd
+			m["submenuMode"] = cmd.SubmenuMode
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+func stylesToMap(styles map[string]StyleConfig) map[string]any {
+	result := make(map[string]any, len(styles))
+	for name, style := range styles {
+		result[name] = map[string]any{
+			"color":           style.Color,
+			"backgroundColor": style.BackgroundColor,
+			"bold":            style.Bold,
+			"italic":          style.Italic,
+			"underline":       style.Underline,
+			"strikethrough":   style.StrikeThrough,
+		}
+	}
+	return result
+}
@@ -16,12 +16,25 @@ func TestConfigFromUntypedMap(t *testing.T) {
 			name:  "empty map",
 			input: map[string]any{},
 			expected: Config{
-				SyntaxLanguage: "plaintext",
-				TabSize:        4,
-				LineWrap:       "character",
-				MenuCommands:   []MenuCommandConfig{},
-				Styles:         map[string]StyleConfig{},
-				LineNumberMode: "absolute",
+				SyntaxLanguage:      "plaintext",
+				SyntaxBackend:       "builtin",
+				TabSize:             4,
+				LineWrap:            "character",
+				NewFileBehavior:     "create",
+				SymlinkSave:         "target",
+				LongLineThreshold:   100000,
+				ShowMatchingBracket: true,
+				FileWatchInterval:   1000,
+				FileWatchDebounce:   200,
+				LigatureBreaker:     "none",
+				EventHook:           "",
+				Bell:                "none",
+				PersistScratch:      false,
+				KeyboardLayout:      "qwerty",
+				MenuCommands:        []MenuCommandConfig{},
+				KeyRemap:            map[string]string{},
+				Styles:              map[string]StyleConfig{},
+				LineNumberMode:      "absolute",
 			},
 		},
 		{
@@ -51,11 +64,24 @@ func TestConfigFromUntypedMap(t *testing.T) {
 				},
 			},
 			expected: Config{
-				SyntaxLanguage: "customLang",
-				TabSize:        4,
-				LineWrap:       "character",
-				MenuCommands:   []MenuCommandConfig{},
-				LineNumberMode: "absolute",
+				SyntaxLanguage:      "customLang",
+				SyntaxBackend:       "builtin",
+				TabSize:             4,
+				LineWrap:            "character",
+				NewFileBehavior:     "create",
+				SymlinkSave:         "target",
+				LongLineThreshold:   100000,
+				ShowMatchingBracket: true,
+				FileWatchInterval:   1000,
+				FileWatchDebounce:   200,
+				LigatureBreaker:     "none",
+				EventHook:           "",
+				Bell:                "none",
+				PersistScratch:      false,
+				KeyboardLayout:      "qwerty",
+				MenuCommands:        []MenuCommandConfig{},
+				KeyRemap:            map[string]string{},
+				LineNumberMode:      "absolute",
 				Styles: map[string]StyleConfig{
 					"lineNum": {
 						Color: "olive",
@@ -79,6 +105,37 @@ func TestConfigFromUntypedMap(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "keyboard layout and key remap",
+			input: map[string]any{
+				"keyboardLayout": "colemak",
+				"keyRemap": map[string]any{
+					"j": "n",
+					"k": 5,
+				},
+			},
+			expected: Config{
+				SyntaxLanguage:      "plaintext",
+				SyntaxBackend:       "builtin",
+				TabSize:             4,
+				LineWrap:            "character",
+				NewFileBehavior:     "create",
+				SymlinkSave:         "target",
+				LongLineThreshold:   100000,
+				ShowMatchingBracket: true,
+				FileWatchInterval:   1000,
+				FileWatchDebounce:   200,
+				LigatureBreaker:     "none",
+				EventHook:           "",
+				Bell:                "none",
+				PersistScratch:      false,
+				KeyboardLayout:      "colemak",
+				MenuCommands:        []MenuCommandConfig{},
+				KeyRemap:            map[string]string{"j": "n"},
+				Styles:              map[string]StyleConfig{},
+				LineNumberMode:      "absolute",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -107,6 +164,27 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErrMsg: "TabSize must be greater than zero",
 		},
+		{
+			name: "longLineThreshold negative is invalid",
+			updateFunc: func(c *Config) {
+				c.LongLineThreshold = -1
+			},
+			expectErrMsg: "LongLineThreshold must be greater than or equal to zero",
+		},
+		{
+			name: "fileWatchInterval zero is invalid",
+			updateFunc: func(c *Config) {
+				c.FileWatchInterval = 0
+			},
+			expectErrMsg: "FileWatchInterval must be greater than zero",
+		},
+		{
+			name: "fileWatchDebounce negative is invalid",
+			updateFunc: func(c *Config) {
+				c.FileWatchDebounce = -1
+			},
+			expectErrMsg: "FileWatchDebounce must be greater than or equal to zero",
+		},
 		{
 			name: "lineWrap is invalid",
 			updateFunc: func(c *Config) {
@@ -114,6 +192,83 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErrMsg: `LineWrap must be either "character" or "word"`,
 		},
+		{
+			name: "symlinkSave is invalid",
+			updateFunc: func(c *Config) {
+				c.SymlinkSave = "invalid"
+			},
+			expectErrMsg: `SymlinkSave must be either "target" or "replace"`,
+		},
+		{
+			name: "ligatureBreaker is invalid",
+			updateFunc: func(c *Config) {
+				c.LigatureBreaker = "invalid"
+			},
+			expectErrMsg: `LigatureBreaker must be "none", "zwnj", or "zwsp"`,
+		},
+		{
+			name: "bell is invalid",
+			updateFunc: func(c *Config) {
+				c.Bell = "invalid"
+			},
+			expectErrMsg: `Bell must be "none", "audible", or "visual"`,
+		},
+		{
+			name: "keyboardLayout is invalid",
+			updateFunc: func(c *Config) {
+				c.KeyboardLayout = "invalid"
+			},
+			expectErrMsg: `KeyboardLayout must be "qwerty", "colemak", or "dvorak"`,
+		},
+		{
+			name: "keyRemap with single characters is valid",
+			updateFunc: func(c *Config) {
+				c.KeyRemap = map[string]string{"j": "n", "ö": ";"}
+			},
+			expectErrMsg: "",
+		},
+		{
+			name: "keyRemap with multiple characters is invalid",
+			updateFunc: func(c *Config) {
+				c.KeyRemap = map[string]string{"j": "gg"}
+			},
+			expectErrMsg: `KeyRemap must map single characters, but got "j" to "gg"`,
+		},
+		{
+			name: "timestampFormats has empty format",
+			updateFunc: func(c *Config) {
+				c.TimestampFormats = []string{"2006-01-02", ""}
+			},
+			expectErrMsg: "TimestampFormats must not contain an empty format",
+		},
+		{
+			name: "menuPinnedCommands has empty name",
+			updateFunc: func(c *Config) {
+				c.MenuPinnedCommands = []string{"quit", ""}
+			},
+			expectErrMsg: "MenuPinnedCommands must not contain an empty name",
+		},
+		{
+			name: "menuHiddenCommands has empty name",
+			updateFunc: func(c *Config) {
+				c.MenuHiddenCommands = []string{""}
+			},
+			expectErrMsg: "MenuHiddenCommands must not contain an empty name",
+		},
+		{
+			name: "syntaxBackend is treesitter",
+			updateFunc: func(c *Config) {
+				c.SyntaxBackend = "treesitter"
+			},
+			expectErrMsg: "",
+		},
+		{
+			name: "syntaxBackend is invalid",
+			updateFunc: func(c *Config) {
+				c.SyntaxBackend = "invalid"
+			},
+			expectErrMsg: `SyntaxBackend must be either "builtin" or "treesitter"`,
+		},
 		{
 			name: "lineNumberMode is invalid",
 			updateFunc: func(c *Config) {
@@ -121,6 +276,34 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErrMsg: `LineNumberMode must be either "absolute" or "relative"`,
 		},
+		{
+			name: "newFileBehavior is invalid",
+			updateFunc: func(c *Config) {
+				c.NewFileBehavior = "invalid"
+			},
+			expectErrMsg: `NewFileBehavior must be "create", "confirm", or "error"`,
+		},
+		{
+			name: "global style name is valid",
+			updateFunc: func(c *Config) {
+				c.Styles = map[string]StyleConfig{StyleTokenKeyword: {Bold: true}}
+			},
+			expectErrMsg: "",
+		},
+		{
+			name: "language-specific style name is valid",
+			updateFunc: func(c *Config) {
+				c.Styles = map[string]StyleConfig{"tokenKey": {Bold: true}}
+			},
+			expectErrMsg: "",
+		},
+		{
+			name: "unrecognized style name is invalid",
+			updateFunc: func(c *Config) {
+				c.Styles = map[string]StyleConfig{"tokenInvalid": {Bold: true}}
+			},
+			expectErrMsg: `Unrecognized style "tokenInvalid"`,
+		},
 		{
 			name: "menu name is empty",
 			updateFunc: func(c *Config) {
@@ -152,7 +335,44 @@ func TestValidateConfig(t *testing.T) {
 					Mode:     "invalid",
 				})
 			},
-			expectErrMsg: `Menu command "testcmd" must have mode set to either "silent", "terminal", "insert", "insertChoice", "fileLocations", or "workingDir"`,
+			expectErrMsg: `Menu command "testcmd" must have mode set to either "silent", "terminal", "insert", "insertChoice", "fileLocations", "workingDir", "scratch", "output", or "submenu"`,
+		},
+		{
+			name: "submenu shell cmd is empty",
+			updateFunc: func(c *Config) {
+				c.MenuCommands = append(c.MenuCommands, MenuCommandConfig{
+					Name:        "testcmd",
+					ShellCmd:    "echo 'hello'",
+					Mode:        "submenu",
+					SubmenuMode: "silent",
+				})
+			},
+			expectErrMsg: `Menu command "testcmd" submenuShellCmd cannot be empty`,
+		},
+		{
+			name: "submenu mode is invalid",
+			updateFunc: func(c *Config) {
+				c.MenuCommands = append(c.MenuCommands, MenuCommandConfig{
+					Name:            "testcmd",
+					ShellCmd:        "echo 'hello'",
+					Mode:            "submenu",
+					SubmenuShellCmd: "echo $ITEM",
+					SubmenuMode:     "submenu",
+				})
+			},
+			expectErrMsg: `Menu command "testcmd" must have submenuMode set to either "silent", "terminal", "insert", "insertChoice", "fileLocations", "workingDir", "scratch", or "output"`,
+		},
+		{
+			name: "submenu is valid",
+			updateFunc: func(c *Config) {
+				c.MenuCommands = append(c.MenuCommands, MenuCommandConfig{
+					Name:            "testcmd",
+					ShellCmd:        "echo 'hello'",
+					Mode:            "submenu",
+					SubmenuShellCmd: "echo $ITEM",
+					SubmenuMode:     "insert",
+				})
+			},
 		},
 	}
 
@@ -173,6 +393,32 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+func TestConfigToUntypedMapRoundTrip(t *testing.T) {
+	m := map[string]any{
+		"syntaxLanguage":     "go",
+		"syntaxBackend":      "treesitter",
+		"tabSize":            2,
+		"tabExpand":          true,
+		"showLineNumbers":    true,
+		"lineNumberMode":     "relative",
+		"lineWrap":           "word",
+		"hidePatterns":       []any{"**/.git"},
+		"timestampFormats":   []any{"2006-01-02", "15:04"},
+		"menuPinnedCommands": []any{"save document", "quit"},
+		"menuHiddenCommands": []any{"toggle ruler"},
+		"menuCommands": []any{
+			map[string]any{"name": "build", "shellCmd": "make", "mode": "terminal", "save": true},
+			map[string]any{"name": "checkout", "shellCmd": "git branch", "mode": "submenu", "submenuShellCmd": "git checkout $ITEM", "submenuMode": "silent"},
+		},
+		"styles": map[string]any{
+			"lineNum": map[string]any{"color": "olive", "bold": true},
+		},
+	}
+	c := ConfigFromUntypedMap(m)
+	assert.Equal(t, c, ConfigFromUntypedMap(c.ToUntypedMap()))
+	assert.Empty(t, checkConfigSchema(c.ToUntypedMap()))
+}
+
 func TestHidePatternsAndHideDirectories(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/aretext/aretext/file"
@@ -22,24 +23,50 @@ type RuleSet []Rule
 // Rules that match the file path are applied in order to produce the configuration.
 func (rs RuleSet) ConfigForPath(path string) Config {
 	c := make(map[string]any, 0)
+	for _, rule := range rs.MatchingRules(path) {
+		log.Printf("Applying config rule %q with pattern %q for path %q\n", rule.Name, rule.Pattern, path)
+		c = MergeRecursive(c, rule.Config).(map[string]any)
+	}
+	log.Printf("Resolved config for path %q: %#v\n", path, c)
+	return ConfigFromUntypedMap(c)
+}
+
+// MatchingRules returns the rules whose pattern matches the file path, in the order they are applied.
+func (rs RuleSet) MatchingRules(path string) []Rule {
+	var matched []Rule
 	for _, rule := range rs {
 		if file.GlobMatch(rule.Pattern, path) {
-			log.Printf("Applying config rule %q with pattern %q for path %q\n", rule.Name, rule.Pattern, path)
-			c = MergeRecursive(c, rule.Config).(map[string]any)
+			matched = append(matched, rule)
 		}
 	}
-	log.Printf("Resolved config for path %q: %#v\n", path, c)
-	return ConfigFromUntypedMap(c)
+	return matched
 }
 
 // Validate checks whether every rule in the set has a valid configuration.
+// Values with the wrong type are reported as errors, but unknown keys are not (see Warnings).
 func (rs RuleSet) Validate() error {
 	for _, r := range rs {
+		if result := checkConfigSchema(r.Config); len(result.errors) > 0 {
+			return fmt.Errorf("Rule %q: %s", r.Name, result.errors[0])
+		}
+
 		c := ConfigFromUntypedMap(r.Config)
 		err := c.Validate()
 		if err != nil {
-			return err
+			return fmt.Errorf("Rule %q: %w", r.Name, err)
 		}
 	}
 	return nil
 }
+
+// Warnings returns messages for config keys that are unknown or deprecated.
+// These usually indicate a typo that causes a setting to be ignored.
+func (rs RuleSet) Warnings() []string {
+	var warnings []string
+	for _, r := range rs {
+		for _, w := range checkConfigSchema(r.Config).warnings {
+			warnings = append(warnings, fmt.Sprintf("Rule %q: %s", r.Name, w))
+		}
+	}
+	return warnings
+}
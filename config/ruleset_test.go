@@ -18,14 +18,27 @@ func TestConfigForPath(t *testing.T) {
 			ruleSet: nil,
 			path:    "test.go",
 			expectedConfig: Config{
-				SyntaxLanguage: DefaultSyntaxLanguage,
-				TabSize:        DefaultTabSize,
-				TabExpand:      DefaultTabExpand,
-				AutoIndent:     DefaultAutoIndent,
-				LineWrap:       DefaultLineWrap,
-				LineNumberMode: string(DefaultLineNumberMode),
-				MenuCommands:   []MenuCommandConfig{},
-				Styles:         map[string]StyleConfig{},
+				SyntaxLanguage:      DefaultSyntaxLanguage,
+				SyntaxBackend:       string(DefaultSyntaxBackend),
+				TabSize:             DefaultTabSize,
+				TabExpand:           DefaultTabExpand,
+				AutoIndent:          DefaultAutoIndent,
+				LineWrap:            DefaultLineWrap,
+				NewFileBehavior:     DefaultNewFileBehavior,
+				SymlinkSave:         DefaultSymlinkSave,
+				LongLineThreshold:   DefaultLongLineThreshold,
+				ShowMatchingBracket: DefaultShowMatchingBracket,
+				FileWatchInterval:   DefaultFileWatchInterval,
+				FileWatchDebounce:   DefaultFileWatchDebounce,
+				LigatureBreaker:     DefaultLigatureBreaker,
+				EventHook:           DefaultEventHook,
+				Bell:                DefaultBell,
+				PersistScratch:      DefaultPersistScratch,
+				KeyboardLayout:      DefaultKeyboardLayout,
+				KeyRemap:            map[string]string{},
+				LineNumberMode:      string(DefaultLineNumberMode),
+				MenuCommands:        []MenuCommandConfig{},
+				Styles:              map[string]StyleConfig{},
 			},
 		},
 		{
@@ -48,14 +61,27 @@ func TestConfigForPath(t *testing.T) {
 			},
 			path: "test.json",
 			expectedConfig: Config{
-				SyntaxLanguage: "json",
-				TabSize:        DefaultTabSize,
-				TabExpand:      DefaultTabExpand,
-				LineWrap:       DefaultLineWrap,
-				AutoIndent:     DefaultAutoIndent,
-				LineNumberMode: string(DefaultLineNumberMode),
-				MenuCommands:   []MenuCommandConfig{},
-				Styles:         map[string]StyleConfig{},
+				SyntaxLanguage:      "json",
+				SyntaxBackend:       string(DefaultSyntaxBackend),
+				TabSize:             DefaultTabSize,
+				TabExpand:           DefaultTabExpand,
+				LineWrap:            DefaultLineWrap,
+				NewFileBehavior:     DefaultNewFileBehavior,
+				SymlinkSave:         DefaultSymlinkSave,
+				LongLineThreshold:   DefaultLongLineThreshold,
+				ShowMatchingBracket: DefaultShowMatchingBracket,
+				FileWatchInterval:   DefaultFileWatchInterval,
+				FileWatchDebounce:   DefaultFileWatchDebounce,
+				LigatureBreaker:     DefaultLigatureBreaker,
+				EventHook:           DefaultEventHook,
+				Bell:                DefaultBell,
+				PersistScratch:      DefaultPersistScratch,
+				KeyboardLayout:      DefaultKeyboardLayout,
+				KeyRemap:            map[string]string{},
+				AutoIndent:          DefaultAutoIndent,
+				LineNumberMode:      string(DefaultLineNumberMode),
+				MenuCommands:        []MenuCommandConfig{},
+				Styles:              map[string]StyleConfig{},
 			},
 		},
 	}
@@ -67,3 +93,69 @@ func TestConfigForPath(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleSetValidate(t *testing.T) {
+	testCases := []struct {
+		name         string
+		ruleSet      RuleSet
+		expectErrMsg string
+	}{
+		{
+			name: "valid",
+			ruleSet: []Rule{
+				{Name: "default", Pattern: "**", Config: map[string]any{"tabSize": 4}},
+			},
+		},
+		{
+			name: "invalid value",
+			ruleSet: []Rule{
+				{Name: "default", Pattern: "**", Config: map[string]any{"tabSize": 4}},
+				{Name: "go", Pattern: "**/*.go", Config: map[string]any{"tabSize": 0}},
+			},
+			expectErrMsg: `Rule "go": TabSize must be greater than zero`,
+		},
+		{
+			name: "wrong type",
+			ruleSet: []Rule{
+				{Name: "go", Pattern: "**/*.go", Config: map[string]any{"tabExpand": "no"}},
+			},
+			expectErrMsg: `Rule "go": Config key "tabExpand" must be a boolean (true or false), but got "no"`,
+		},
+		{
+			name: "unknown key is valid",
+			ruleSet: []Rule{
+				{Name: "go", Pattern: "**/*.go", Config: map[string]any{"tabexpand": true}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.ruleSet.Validate()
+			if tc.expectErrMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectErrMsg)
+			}
+		})
+	}
+}
+
+func TestRuleSetWarnings(t *testing.T) {
+	ruleSet := RuleSet{
+		{Name: "default", Pattern: "**", Config: map[string]any{"tabSize": 4}},
+		{Name: "go", Pattern: "**/*.go", Config: map[string]any{"tabexpand": true, "autoIndent": true}},
+	}
+	expected := []string{`Rule "go": Unknown config key "tabexpand" (did you mean "tabExpand"?)`}
+	assert.Equal(t, expected, ruleSet.Warnings())
+}
+
+func TestMatchingRules(t *testing.T) {
+	ruleSet := RuleSet{
+		{Name: "default", Pattern: "**"},
+		{Name: "go", Pattern: "**/*.go"},
+		{Name: "json", Pattern: "**/*.json"},
+	}
+	matched := ruleSet.MatchingRules("/foo/bar.go")
+	assert.Equal(t, []Rule{ruleSet[0], ruleSet[1]}, matched)
+}
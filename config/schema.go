@@ -0,0 +1,285 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// valueKind is the expected type of a configuration value.
+type valueKind int
+
+const (
+	kindString = valueKind(iota)
+	kindInt
+	kindBool
+	kindStringSlice
+	kindStringMap
+	kindMenuCommands
+	kindStyles
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindString:
+		return "a string"
+	case kindInt:
+		return "an integer"
+	case kindBool:
+		return "a boolean (true or false)"
+	case kindStringSlice:
+		return "a list of strings"
+	case kindStringMap:
+		return "a map from strings to strings"
+	case kindMenuCommands:
+		return "a list of menu commands"
+	case kindStyles:
+		return "a map from style names to styles"
+	default:
+		panic("invalid value kind")
+	}
+}
+
+// configSchema lists every key recognized in a rule's config.
+var configSchema = map[string]valueKind{
+	"syntaxLanguage":      kindString,
+	"syntaxBackend":       kindString,
+	"tabSize":             kindInt,
+	"tabExpand":           kindBool,
+	"showTabs":            kindBool,
+	"showSpaces":          kindBool,
+	"autoIndent":          kindBool,
+	"showLineNumbers":     kindBool,
+	"lineNumberMode":      kindString,
+	"showRuler":           kindBool,
+	"showDiffGutter":      kindBool,
+	"showScrollbar":       kindBool,
+	"showMatchingBracket": kindBool,
+	"showAnsiColors":      kindBool,
+	"showKeyHints":        kindBool,
+	"lineWrap":            kindString,
+	"rtlVisualOrder":      kindBool,
+	"insertModeSelection": kindBool,
+	"matchPasteIndent":    kindBool,
+	"newFileBehavior":     kindString,
+	"createParentDirs":    kindBool,
+	"symlinkSave":         kindString,
+	"longLineThreshold":   kindInt,
+	"fileWatchInterval":   kindInt,
+	"fileWatchDebounce":   kindInt,
+	"ligatureBreaker":     kindString,
+	"eventHook":           kindString,
+	"bell":                kindString,
+	"persistScratch":      kindBool,
+	"keyboardLayout":      kindString,
+	"menuCommands":        kindMenuCommands,
+	"hidePatterns":        kindStringSlice,
+	"hideDirectories":     kindStringSlice,
+	"timestampFormats":    kindStringSlice,
+	"menuPinnedCommands":  kindStringSlice,
+	"menuHiddenCommands":  kindStringSlice,
+	"keyRemap":            kindStringMap,
+	"styles":              kindStyles,
+}
+
+// deprecatedConfigKeys maps deprecated keys to the keys that replace them.
+var deprecatedConfigKeys = map[string]string{
+	"hideDirectories": "hidePatterns",
+}
+
+var menuCommandSchema = map[string]valueKind{
+	"name":            kindString,
+	"shellCmd":        kindString,
+	"mode":            kindString,
+	"save":            kindBool,
+	"submenuShellCmd": kindString,
+	"submenuMode":     kindString,
+}
+
+var styleSchema = map[string]valueKind{
+	"color":           kindString,
+	"backgroundColor": kindString,
+	"bold":            kindBool,
+	"italic":          kindBool,
+	"underline":       kindBool,
+	"strikethrough":   kindBool,
+}
+
+// schemaResult collects the problems found when checking a config against the schema.
+// Values with the wrong type are errors, since the editor would silently ignore them.
+// Unknown and deprecated keys are warnings, since they don't prevent the editor from loading.
+type schemaResult struct {
+	errors   []string
+	warnings []string
+}
+
+// checkConfigSchema checks the keys and value types of an untyped config map.
+func checkConfigSchema(m map[string]any) schemaResult {
+	var result schemaResult
+	checkMapSchema(m, configSchema, "", &result)
+	for _, key := range sortedKeys(m) {
+		if replacement, ok := deprecatedConfigKeys[key]; ok {
+			result.warnings = append(result.warnings, fmt.Sprintf("Config key %q is deprecated, use %q instead", key, replacement))
+		}
+	}
+	return result
+}
+
+func checkMapSchema(m map[string]any, schema map[string]valueKind, prefix string, result *schemaResult) {
+	for _, key := range sortedKeys(m) {
+		fullKey := prefix + key
+		kind, ok := schema[key]
+		if !ok {
+			msg := fmt.Sprintf("Unknown config key %q", fullKey)
+			if suggestion := suggestKey(key, schema); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", prefix+suggestion)
+			}
+			result.warnings = append(result.warnings, msg)
+			continue
+		}
+		checkValueSchema(m[key], kind, fullKey, result)
+	}
+}
+
+func checkValueSchema(v any, kind valueKind, key string, result *schemaResult) {
+	addTypeError := func() {
+		result.errors = append(result.errors, fmt.Sprintf("Config key %q must be %s, but got %s", key, kind, describeValue(v)))
+	}
+
+	switch kind {
+	case kindString:
+		if _, ok := v.(string); !ok {
+			addTypeError()
+		}
+
+	case kindInt:
+		switch v := v.(type) {
+		case int:
+		case float64:
+			if v != float64(int(v)) {
+				addTypeError()
+			}
+		default:
+			addTypeError()
+		}
+
+	case kindBool:
+		if _, ok := v.(bool); !ok {
+			addTypeError()
+		}
+
+	case kindStringSlice:
+		slice, ok := v.([]any)
+		if !ok {
+			addTypeError()
+			return
+		}
+		for i, item := range slice {
+			checkValueSchema(item, kindString, fmt.Sprintf("%s[%d]", key, i), result)
+		}
+
+	case kindStringMap:
+		stringMap, ok := v.(map[string]any)
+		if !ok {
+			addTypeError()
+			return
+		}
+		for _, k := range sortedKeys(stringMap) {
+			checkValueSchema(stringMap[k], kindString, fmt.Sprintf("%s.%s", key, k), result)
+		}
+
+	case kindMenuCommands:
+		slice, ok := v.([]any)
+		if !ok {
+			addTypeError()
+			return
+		}
+		for i, item := range slice {
+			itemKey := fmt.Sprintf("%s[%d]", key, i)
+			itemMap, ok := item.(map[string]any)
+			if !ok {
+				result.errors = append(result.errors, fmt.Sprintf("Config key %q must be a menu command, but got %s", itemKey, describeValue(item)))
+				continue
+			}
+			checkMapSchema(itemMap, menuCommandSchema, itemKey+".", result)
+		}
+
+	case kindStyles:
+		stylesMap, ok := v.(map[string]any)
+		if !ok {
+			addTypeError()
+			return
+		}
+		for _, name := range sortedKeys(stylesMap) {
+			styleKey := fmt.Sprintf("%s.%s", key, name)
+			styleMap, ok := stylesMap[name].(map[string]any)
+			if !ok {
+				result.errors = append(result.errors, fmt.Sprintf("Config key %q must be a style, but got %s", styleKey, describeValue(stylesMap[name])))
+				continue
+			}
+			checkMapSchema(styleMap, styleSchema, styleKey+".", result)
+		}
+	}
+}
+
+func describeValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "an empty value"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "a map"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// suggestKey returns the known key most similar to an unknown key, or an empty string if none are close.
+func suggestKey(key string, schema map[string]valueKind) string {
+	const maxDistance = 2
+	bestKey, bestDistance := "", maxDistance+1
+	for knownKey := range schema {
+		if strings.EqualFold(key, knownKey) {
+			return knownKey
+		}
+		d := editDistance(strings.ToLower(key), strings.ToLower(knownKey))
+		if d < bestDistance || (d == bestDistance && knownKey < bestKey) {
+			bestKey, bestDistance = knownKey, d
+		}
+	}
+	return bestKey
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
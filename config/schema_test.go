@@ -0,0 +1,148 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConfigSchema(t *testing.T) {
+	testCases := []struct {
+		name             string
+		config           map[string]any
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name:   "empty config",
+			config: map[string]any{},
+		},
+		{
+			name: "valid config",
+			config: map[string]any{
+				"syntaxLanguage": "go",
+				"tabSize":        4,
+				"autoIndent":     true,
+				"hidePatterns":   []any{"**/.git"},
+				"menuCommands": []any{
+					map[string]any{"name": "test", "shellCmd": "make", "save": true},
+				},
+				"styles": map[string]any{
+					"lineNum": map[string]any{"color": "olive", "bold": true},
+				},
+			},
+		},
+		{
+			name:   "integer decoded as float",
+			config: map[string]any{"tabSize": float64(2)},
+		},
+		{
+			name:           "wrong type for string",
+			config:         map[string]any{"syntaxLanguage": 123},
+			expectedErrors: []string{`Config key "syntaxLanguage" must be a string, but got 123`},
+		},
+		{
+			name:           "wrong type for int",
+			config:         map[string]any{"tabSize": "four"},
+			expectedErrors: []string{`Config key "tabSize" must be an integer, but got "four"`},
+		},
+		{
+			name:           "fractional int",
+			config:         map[string]any{"tabSize": 2.5},
+			expectedErrors: []string{`Config key "tabSize" must be an integer, but got 2.5`},
+		},
+		{
+			name:           "wrong type for bool",
+			config:         map[string]any{"autoIndent": "yes"},
+			expectedErrors: []string{`Config key "autoIndent" must be a boolean (true or false), but got "yes"`},
+		},
+		{
+			name:           "empty value",
+			config:         map[string]any{"tabExpand": nil},
+			expectedErrors: []string{`Config key "tabExpand" must be a boolean (true or false), but got an empty value`},
+		},
+		{
+			name:           "wrong type in string slice",
+			config:         map[string]any{"hidePatterns": []any{"**/.git", 5}},
+			expectedErrors: []string{`Config key "hidePatterns[1]" must be a string, but got 5`},
+		},
+		{
+			name:           "wrong type in string map",
+			config:         map[string]any{"keyRemap": map[string]any{"j": "n", "k": 1}},
+			expectedErrors: []string{`Config key "keyRemap.k" must be a string, but got 1`},
+		},
+		{
+			name:           "wrong type for string map",
+			config:         map[string]any{"keyRemap": []any{"j"}},
+			expectedErrors: []string{`Config key "keyRemap" must be a map from strings to strings, but got a list`},
+		},
+		{
+			name: "wrong type in menu command",
+			config: map[string]any{
+				"menuCommands": []any{
+					map[string]any{"name": "test", "save": "true"},
+					"make",
+				},
+			},
+			expectedErrors: []string{
+				`Config key "menuCommands[0].save" must be a boolean (true or false), but got "true"`,
+				`Config key "menuCommands[1]" must be a menu command, but got "make"`,
+			},
+		},
+		{
+			name: "wrong type in style",
+			config: map[string]any{
+				"styles": map[string]any{
+					"lineNum": map[string]any{"italic": 1},
+				},
+			},
+			expectedErrors: []string{`Config key "styles.lineNum.italic" must be a boolean (true or false), but got 1`},
+		},
+		{
+			name:             "unknown key with different case",
+			config:           map[string]any{"tabsize": 4},
+			expectedWarnings: []string{`Unknown config key "tabsize" (did you mean "tabSize"?)`},
+		},
+		{
+			name:             "unknown key with typo",
+			config:           map[string]any{"autoIndnet": true},
+			expectedWarnings: []string{`Unknown config key "autoIndnet" (did you mean "autoIndent"?)`},
+		},
+		{
+			name:             "unknown key without suggestion",
+			config:           map[string]any{"fooBarBaz": true},
+			expectedWarnings: []string{`Unknown config key "fooBarBaz"`},
+		},
+		{
+			name: "unknown key in menu command",
+			config: map[string]any{
+				"menuCommands": []any{
+					map[string]any{"name": "test", "shellcmd": "make"},
+				},
+			},
+			expectedWarnings: []string{`Unknown config key "menuCommands[0].shellcmd" (did you mean "menuCommands[0].shellCmd"?)`},
+		},
+		{
+			name: "unknown key in style",
+			config: map[string]any{
+				"styles": map[string]any{
+					"lineNum": map[string]any{"colour": "red"},
+				},
+			},
+			expectedWarnings: []string{`Unknown config key "styles.lineNum.colour" (did you mean "styles.lineNum.color"?)`},
+		},
+		{
+			name:             "deprecated key",
+			config:           map[string]any{"hideDirectories": []any{"**/.git"}},
+			expectedWarnings: []string{`Config key "hideDirectories" is deprecated, use "hidePatterns" instead`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := checkConfigSchema(tc.config)
+			assert.Equal(t, tc.expectedErrors, result.errors)
+			assert.Equal(t, tc.expectedWarnings, result.warnings)
+		})
+	}
+}
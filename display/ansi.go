@@ -0,0 +1,188 @@
+package display
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/text"
+)
+
+type ansiParserState int
+
+const (
+	ansiParserStateText = ansiParserState(iota)
+	ansiParserStateEscape
+	ansiParserStateControlSeq
+)
+
+// ansiParser interprets ANSI escape sequences in text one rune at a time.
+// Select graphic rendition (SGR) sequences like "\x1b[31m" change the style of the following text.
+// Other escape sequences are hidden without changing the style.
+type ansiParser struct {
+	state  ansiParserState
+	params strings.Builder
+	style  tcell.Style
+}
+
+// Reset clears the style and any partial escape sequence, as at the start of a line.
+func (p *ansiParser) Reset() {
+	p.state = ansiParserStateText
+	p.params.Reset()
+	p.style = tcell.StyleDefault
+}
+
+// Style returns the style set by the escape sequences processed so far.
+func (p *ansiParser) Style() tcell.Style {
+	return p.style
+}
+
+// ProcessRune updates the parser with the next rune in the text.
+// It returns true if the rune is part of an escape sequence and should be hidden.
+func (p *ansiParser) ProcessRune(r rune) bool {
+	switch p.state {
+	case ansiParserStateText:
+		if r == '\x1b' {
+			p.state = ansiParserStateEscape
+			return true
+		}
+		return false
+
+	case ansiParserStateEscape:
+		if r == '[' {
+			p.state = ansiParserStateControlSeq
+			p.params.Reset()
+			return true
+		}
+		// Other escape sequences, like "\x1b(B", have no effect on the style.
+		// They end at the first rune that is not an intermediate byte.
+		if r >= 0x20 && r <= 0x2f {
+			return true
+		}
+		p.state = ansiParserStateText
+		return r != '\n'
+
+	case ansiParserStateControlSeq:
+		if r >= 0x20 && r <= 0x3f {
+			// Parameter and intermediate bytes.
+			p.params.WriteRune(r)
+			return true
+		} else if r >= 0x40 && r <= 0x7e {
+			// Final byte.
+			if r == 'm' {
+				p.style = applySgrParams(p.style, p.params.String())
+			}
+			p.state = ansiParserStateText
+			return true
+		}
+		// Malformed sequence, so show the rest of the text as-is.
+		p.state = ansiParserStateText
+		return false
+
+	default:
+		panic("Unrecognized ANSI parser state")
+	}
+}
+
+// applySgrParams updates a style using the semicolon-separated parameters of an SGR escape sequence.
+func applySgrParams(style tcell.Style, params string) tcell.Style {
+	var codes []int
+	for _, s := range strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' }) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return style
+		}
+		codes = append(codes, n)
+	}
+
+	if len(codes) == 0 {
+		// "\x1b[m" is the same as "\x1b[0m".
+		return tcell.StyleDefault
+	}
+
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		switch {
+		case code == 0:
+			style = tcell.StyleDefault
+		case code == 1:
+			style = style.Bold(true)
+		case code == 2:
+			style = style.Dim(true)
+		case code == 3:
+			style = style.Italic(true)
+		case code == 4:
+			style = style.Underline(true)
+		case code == 5:
+			style = style.Blink(true)
+		case code == 7:
+			style = style.Reverse(true)
+		case code == 9:
+			style = style.StrikeThrough(true)
+		case code == 22:
+			style = style.Bold(false).Dim(false)
+		case code == 23:
+			style = style.Italic(false)
+		case code == 24:
+			style = style.Underline(false)
+		case code == 25:
+			style = style.Blink(false)
+		case code == 27:
+			style = style.Reverse(false)
+		case code == 29:
+			style = style.StrikeThrough(false)
+		case code >= 30 && code <= 37:
+			style = style.Foreground(tcell.PaletteColor(code - 30))
+		case code == 38:
+			color, n := sgrExtendedColor(codes[i+1:])
+			style = style.Foreground(color)
+			i += n
+		case code == 39:
+			style = style.Foreground(tcell.ColorDefault)
+		case code >= 40 && code <= 47:
+			style = style.Background(tcell.PaletteColor(code - 40))
+		case code == 48:
+			color, n := sgrExtendedColor(codes[i+1:])
+			style = style.Background(color)
+			i += n
+		case code == 49:
+			style = style.Background(tcell.ColorDefault)
+		case code >= 90 && code <= 97:
+			style = style.Foreground(tcell.PaletteColor(code - 90 + 8))
+		case code >= 100 && code <= 107:
+			style = style.Background(tcell.PaletteColor(code - 100 + 8))
+		}
+	}
+
+	return style
+}
+
+// sgrExtendedColor parses the parameters after a 38 or 48 code,
+// either "5;n" for a 256-color palette index or "2;r;g;b" for an RGB color.
+// It returns the color and the number of parameters consumed.
+func sgrExtendedColor(codes []int) (tcell.Color, int) {
+	if len(codes) >= 2 && codes[0] == 5 {
+		return tcell.PaletteColor(codes[1]), 2
+	} else if len(codes) >= 4 && codes[0] == 2 {
+		return tcell.NewRGBColor(int32(codes[1]), int32(codes[2]), int32(codes[3])), 4
+	}
+	return tcell.ColorDefault, len(codes)
+}
+
+// ansiParserAtPosition returns a parser with the style in effect at a position.
+// Styles do not carry over from one line to the next, so this processes only the text
+// from the start of the line to the position.
+func ansiParserAtPosition(textTree *text.Tree, pos uint64) *ansiParser {
+	p := &ansiParser{}
+	lineStartPos := textTree.LineStartPosition(textTree.LineNumForPosition(pos))
+	reader := textTree.ReaderAtPosition(lineStartPos)
+	for i := lineStartPos; i < pos; i++ {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			break
+		}
+		p.ProcessRune(r)
+	}
+	return p
+}
@@ -0,0 +1,48 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySgrParams(t *testing.T) {
+	s := tcell.StyleDefault
+	testCases := []struct {
+		name     string
+		style    tcell.Style
+		params   string
+		expected tcell.Style
+	}{
+		{name: "empty resets", style: s.Bold(true), params: "", expected: s},
+		{name: "zero resets", style: s.Bold(true), params: "0", expected: s},
+		{name: "bold and color", style: s, params: "1;32", expected: s.Bold(true).Foreground(tcell.PaletteColor(2))},
+		{name: "bright foreground", style: s, params: "91", expected: s.Foreground(tcell.PaletteColor(9))},
+		{name: "background", style: s, params: "44", expected: s.Background(tcell.PaletteColor(4))},
+		{name: "256 colors", style: s, params: "38;5;208", expected: s.Foreground(tcell.PaletteColor(208))},
+		{name: "rgb color", style: s, params: "48;2;10;20;30", expected: s.Background(tcell.NewRGBColor(10, 20, 30))},
+		{name: "rgb color then bold", style: s, params: "38;2;10;20;30;1", expected: s.Foreground(tcell.NewRGBColor(10, 20, 30)).Bold(true)},
+		{name: "default foreground", style: s.Foreground(tcell.ColorRed).Italic(true), params: "39", expected: s.Foreground(tcell.ColorDefault).Italic(true)},
+		{name: "normal intensity", style: s.Bold(true).Dim(true), params: "22", expected: s},
+		{name: "invalid params", style: s.Bold(true), params: "1;x", expected: s.Bold(true)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, applySgrParams(tc.style, tc.params))
+		})
+	}
+}
+
+func TestAnsiParserHidesEscapeSequences(t *testing.T) {
+	var p ansiParser
+	var visible []rune
+	for _, r := range "a\x1b[1;31mb\x1b(Bc\x1b[2Kd" {
+		if !p.ProcessRune(r) {
+			visible = append(visible, r)
+		}
+	}
+	assert.Equal(t, "abcd", string(visible))
+	assert.Equal(t, tcell.StyleDefault.Bold(true).Foreground(tcell.PaletteColor(1)), p.Style())
+}
@@ -0,0 +1,81 @@
+package display
+
+import (
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/cellwidth"
+	"github.com/aretext/aretext/state"
+)
+
+// maxAutocompletePopupHeight is the maximum number of suggested words visible at once.
+const maxAutocompletePopupHeight = 8
+
+// cursorTrackingScreen records where the cursor was last shown,
+// so a popup can be drawn next to the cursor in the document.
+type cursorTrackingScreen struct {
+	tcell.Screen
+	cursorCol, cursorRow int
+}
+
+func (s *cursorTrackingScreen) ShowCursor(x, y int) {
+	s.cursorCol, s.cursorRow = x, y
+	s.Screen.ShowCursor(x, y)
+}
+
+// DrawAutocomplete draws a popup with the suggested words below the cursor,
+// or above the cursor if there isn't enough space below.
+// The popup is aligned with the start of the word being completed.
+func DrawAutocomplete(screen tcell.Screen, palette *Palette, autocomplete *state.AutocompleteState, cursorCol, cursorRow int) {
+	screenWidth, screenHeight := screen.Size()
+	if autocomplete == nil || cursorCol < 0 || cursorRow < 0 {
+		return
+	}
+
+	candidates, selectedIdx := autocomplete.Candidates()
+
+	// Leave one line at the bottom for the status bar.
+	height := min(len(candidates), maxAutocompletePopupHeight)
+	row := cursorRow + 1
+	if row+height > screenHeight-1 {
+		if cursorRow >= height {
+			row = cursorRow - height
+		} else {
+			height = max(0, screenHeight-1-row)
+		}
+	}
+	if height == 0 {
+		return
+	}
+
+	// One column of padding on each side of the words.
+	width := 0
+	for _, c := range candidates {
+		width = max(width, stringWidth(c)+2)
+	}
+	width = min(width, screenWidth)
+	col := max(0, cursorCol-stringWidth(autocomplete.Prefix())-1)
+	col = max(0, min(col, screenWidth-width))
+
+	// Scroll so the selected word is visible.
+	offset := 0
+	if selectedIdx >= height {
+		offset = selectedIdx - height + 1
+	}
+
+	for i := 0; i < height; i++ {
+		idx := offset + i
+		style := palette.StyleForAutocompleteItem(idx == selectedIdx)
+		sr := NewScreenRegion(screen, col, row+i, width, 1)
+		sr.Fill(' ', style)
+		drawStringNoWrap(sr, candidates[idx], 1, 0, style)
+	}
+}
+
+// stringWidth returns the number of cells needed to display a string on a single line.
+func stringWidth(s string) int {
+	var width int
+	for _, r := range s {
+		width += int(cellwidth.RuneWidth(r))
+	}
+	return width
+}
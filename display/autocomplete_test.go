@@ -0,0 +1,74 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/state"
+)
+
+func TestDrawAutocomplete(t *testing.T) {
+	testCases := []struct {
+		name             string
+		cursorCol        int
+		cursorRow        int
+		expectedContents [][]rune
+	}{
+		{
+			name:      "below cursor",
+			cursorCol: 3,
+			cursorRow: 0,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', 'a', 'b', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', 'a', 'c', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:      "above cursor near bottom of screen",
+			cursorCol: 3,
+			cursorRow: 3,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', 'a', 'b', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', 'a', 'c', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:      "shifted left at edge of screen",
+			cursorCol: 9,
+			cursorRow: 0,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', 'a', 'b', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', 'a', 'c', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			editorState := state.NewEditorState(100, 100, nil, nil)
+			state.EnterInsertMode(editorState)
+			state.InsertText(editorState, "ab ac a")
+			state.StartAutocomplete(editorState, true)
+			require.NotNil(t, editorState.Autocomplete())
+
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(10, 5)
+				palette := NewPalette()
+				DrawAutocomplete(s, palette, editorState.Autocomplete(), tc.cursorCol, tc.cursorRow)
+				s.Sync()
+				assertCellContents(t, s, tc.expectedContents)
+			})
+		})
+	}
+}
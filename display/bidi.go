@@ -0,0 +1,103 @@
+package display
+
+import (
+	"unicode"
+
+	"github.com/aretext/aretext/text/segment"
+)
+
+// rtlScripts are the scripts written right-to-left.
+var rtlScripts = []*unicode.RangeTable{
+	unicode.Hebrew,
+	unicode.Arabic,
+	unicode.Syriac,
+	unicode.Thaana,
+	unicode.Nko,
+	unicode.Samaritan,
+	unicode.Mandaic,
+}
+
+type bidiClass int
+
+const (
+	bidiClassNeutral = bidiClass(iota)
+	bidiClassLeftToRight
+	bidiClassRightToLeft
+)
+
+func bidiClassForGraphemeCluster(gc []rune) bidiClass {
+	r := gc[0]
+	if unicode.In(r, rtlScripts...) {
+		return bidiClassRightToLeft
+	} else if r == '\n' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return bidiClassLeftToRight
+	} else {
+		return bidiClassNeutral
+	}
+}
+
+type bidiGraphemeCluster struct {
+	runeIdx int
+	offset  uint64
+	width   uint64
+	class   bidiClass
+}
+
+// rtlVisualOffsets returns the cell offset at which each grapheme cluster in a line should be drawn
+// so that right-to-left runs appear in visual order, keyed by the index of the cluster's first rune.
+// A run starts and ends with a right-to-left character and may contain neutral characters such as spaces
+// and punctuation, but not left-to-right letters, digits, or line feeds.
+// This returns nil if the line does not contain any right-to-left characters.
+func rtlVisualOffsets(runes []rune, gcWidthFunc segment.GraphemeClusterWidthFunc) map[int]uint64 {
+	var gcs []bidiGraphemeCluster
+	var hasRtl bool
+	var gcBreaker segment.GraphemeClusterBreaker
+	var offset uint64
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && !(gcBreaker.ProcessRune(runes[i]) && i > start) {
+			continue
+		}
+		if i > start {
+			gc := runes[start:i]
+			width := gcWidthFunc(gc, offset)
+			class := bidiClassForGraphemeCluster(gc)
+			hasRtl = hasRtl || class == bidiClassRightToLeft
+			gcs = append(gcs, bidiGraphemeCluster{runeIdx: start, offset: offset, width: width, class: class})
+			offset += width
+		}
+		start = i
+	}
+
+	if !hasRtl {
+		return nil
+	}
+
+	visualOffsets := make(map[int]uint64, len(gcs))
+	for i := 0; i < len(gcs); {
+		if gcs[i].class != bidiClassRightToLeft {
+			visualOffsets[gcs[i].runeIdx] = gcs[i].offset
+			i++
+			continue
+		}
+
+		// Find the last right-to-left cluster in the run.
+		runStart, runEnd := i, i
+		for j := i + 1; j < len(gcs) && gcs[j].class != bidiClassLeftToRight; j++ {
+			if gcs[j].class == bidiClassRightToLeft {
+				runEnd = j
+			}
+		}
+
+		// Mirror each cluster within the run.
+		runStartOffset := gcs[runStart].offset
+		runEndOffset := gcs[runEnd].offset + gcs[runEnd].width
+		for j := runStart; j <= runEnd; j++ {
+			gc := gcs[j]
+			visualOffsets[gc.runeIdx] = runStartOffset + (runEndOffset - (gc.offset + gc.width))
+		}
+		i = runEnd + 1
+	}
+
+	return visualOffsets
+}
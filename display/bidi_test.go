@@ -0,0 +1,109 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aretext/aretext/cellwidth"
+	"github.com/aretext/aretext/state"
+)
+
+func TestRtlVisualOffsets(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected map[int]uint64
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: nil,
+		},
+		{
+			name:     "left-to-right only",
+			input:    "abc def",
+			expected: nil,
+		},
+		{
+			name:  "right-to-left only",
+			input: "אבג",
+			expected: map[int]uint64{
+				0: 2,
+				1: 1,
+				2: 0,
+			},
+		},
+		{
+			name:  "right-to-left run with spaces",
+			input: "ab אב גד.",
+			expected: map[int]uint64{
+				0: 0,
+				1: 1,
+				2: 2,
+				3: 7,
+				4: 6,
+				5: 5,
+				6: 4,
+				7: 3,
+				8: 8,
+			},
+		},
+		{
+			name:  "digits end right-to-left run",
+			input: "אב 12 גד",
+			expected: map[int]uint64{
+				0: 1,
+				1: 0,
+				2: 2,
+				3: 3,
+				4: 4,
+				5: 5,
+				6: 7,
+				7: 6,
+			},
+		},
+		{
+			name:  "line feed ends right-to-left run",
+			input: "אב\n",
+			expected: map[int]uint64{
+				0: 1,
+				1: 0,
+				2: 2,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gcWidthFunc := func(gc []rune, offset uint64) uint64 {
+				return cellwidth.GraphemeClusterWidth(gc, offset, 4)
+			}
+			offsets := rtlVisualOffsets([]rune(tc.input), gcWidthFunc)
+			assert.Equal(t, tc.expected, offsets)
+		})
+	}
+}
+
+func TestDrawBufferRtlVisualOrder(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(8, 1)
+		drawBuffer(t, s, func(editorState *state.EditorState) {
+			for _, r := range "ab אבג" {
+				state.InsertRune(editorState, r)
+			}
+			state.ToggleRtlVisualOrder(editorState)
+			state.MoveCursor(editorState, func(state.LocatorParams) uint64 {
+				return 3
+			})
+		})
+		assertCellContents(t, s, [][]rune{
+			{'a', 'b', ' ', 'ג', 'ב', 'א', ' ', ' '},
+		})
+		cursorCol, cursorRow, cursorVisible := s.GetCursor()
+		assert.True(t, cursorVisible)
+		assert.Equal(t, 5, cursorCol)
+		assert.Equal(t, 0, cursorRow)
+	})
+}
@@ -3,6 +3,7 @@ package display
 import (
 	"io"
 	"log"
+	"slices"
 	"strconv"
 
 	"github.com/gdamore/tcell/v2"
@@ -17,7 +18,14 @@ import (
 // DrawBuffer draws text buffer in the screen.
 func DrawBuffer(screen tcell.Screen, palette *Palette, buffer *state.BufferState, inputMode state.InputMode) {
 	width, height := viewSize(buffer)
-	sr := NewScreenRegion(screen, 0, 0, width, height)
+	gutterWidth := int(buffer.DiffGutterWidth())   // Zero if the diff gutter is disabled.
+	scrollbarWidth := int(buffer.ScrollbarWidth()) // Zero if the scrollbar is disabled.
+	gutterSr := NewScreenRegion(screen, 0, 0, gutterWidth, height)
+	sr := NewScreenRegion(screen, gutterWidth, 0, width-gutterWidth-scrollbarWidth, height)
+	if scrollbarWidth > 0 {
+		scrollbarSr := NewScreenRegion(screen, width-scrollbarWidth, 0, scrollbarWidth, height)
+		drawScrollbar(scrollbarSr, palette, buffer, height)
+	}
 	textTree := buffer.TextTree()
 	cursorPos := buffer.CursorPosition()
 	selectedRegion := buffer.SelectedRegion()
@@ -32,6 +40,20 @@ func DrawBuffer(screen tcell.Screen, palette *Palette, buffer *state.BufferState
 	wrappedLineIter := segment.NewWrappedLineIter(wrapConfig, textTree, pos)
 	wrappedLine := segment.Empty()
 	searchMatch := buffer.SearchMatch()
+	undoPreviewRegion := buffer.UndoPreviewRegion()
+	var matchingBrackets []uint64
+	if bracketPos, matchPos, ok := buffer.MatchingBracketPositions(); ok {
+		matchingBrackets = []uint64{bracketPos, matchPos}
+	}
+	var ansi *ansiParser // Nil unless showing ANSI colors.
+	if buffer.ShowAnsiColors() {
+		ansi = ansiParserAtPosition(textTree, viewTextOrigin)
+	}
+	rtlVisualOrder := buffer.RtlVisualOrder()
+	leftCol := int(buffer.ViewLeftCol())
+	isLineBookmarked := buffer.IsLineBookmarked
+	ligatureBreaker := buffer.LigatureBreaker()
+	showBlame := buffer.ShowBlame()
 
 	sr.HideCursor()
 
@@ -45,36 +67,55 @@ func DrawBuffer(screen tcell.Screen, palette *Palette, buffer *state.BufferState
 
 		lineNum := textTree.LineNumForPosition(pos)
 		lineStartPos := textTree.LineStartPosition(lineNum)
+		if gutterWidth > 0 && pos == lineStartPos {
+			drawDiffMarker(gutterSr, palette, row, buffer.LineChange(lineNum))
+		}
+		if ansi != nil && pos == lineStartPos {
+			ansi.Reset()
+		}
 		wrappedLineRunes := wrappedLine.Runes()
 		syntaxTokens := buffer.SyntaxTokensIntersectingRange(pos, pos+uint64(len(wrappedLineRunes)))
-		drawLineAndSetCursor(
+		lineEndCol := drawLineAndSetCursor(
 			sr,
 			palette,
 			inputMode,
 			pos,
 			row,
 			int(wrapConfig.MaxLineWidth),
+			leftCol,
 			lineNum,
 			lineNumMargin,
 			lineStartPos,
 			lineNumberMode,
 			cursorLine,
+			isLineBookmarked,
 			wrappedLineRunes,
 			syntaxTokens,
 			cursorPos,
 			selectedRegion,
 			searchMatch,
+			undoPreviewRegion,
+			matchingBrackets,
+			ansi,
 			wrapConfig.WidthFunc,
 			showTabs,
 			showSpaces,
+			rtlVisualOrder,
+			ligatureBreaker,
 		)
 		pos += wrappedLine.NumRunes()
+
+		// Annotate the last row of each line, after any soft wraps.
+		endsLine := len(wrappedLineRunes) > 0 && wrappedLineRunes[len(wrappedLineRunes)-1] == '\n'
+		if showBlame && lineEndCol >= 0 && (endsLine || pos == textTree.NumChars()) {
+			drawBlameAnnotation(sr, palette, row, max(lineEndCol, int(lineNumMargin)), buffer.BlameAnnotation(lineNum))
+		}
 	}
 
 	// Text view is empty, with cursor positioned in the first cell.
 	if pos-viewTextOrigin == 0 && pos == cursorPos {
 		showCursorInBuffer(sr, int(lineNumMargin), 0, palette, inputMode)
-		drawLineNumIfNecessary(sr, palette, 0, 0, lineNumMargin, lineNumberMode, cursorLine)
+		drawLineNumIfNecessary(sr, palette, 0, 0, lineNumMargin, lineNumberMode, cursorLine, isLineBookmarked(0))
 	}
 }
 
@@ -83,6 +124,8 @@ func viewSize(buffer *state.BufferState) (int, int) {
 	return int(width), int(height)
 }
 
+// drawLineAndSetCursor draws a wrapped line and returns the column after the end of its text,
+// or -1 if there isn't enough space to show the line.
 func drawLineAndSetCursor(
 	sr *ScreenRegion,
 	palette *Palette,
@@ -90,20 +133,27 @@ func drawLineAndSetCursor(
 	pos uint64,
 	row int,
 	maxLineWidth int,
+	leftCol int,
 	lineNum uint64,
 	lineNumMargin uint64,
 	lineStartPos uint64,
 	lineNumberMode config.LineNumberMode,
 	cursorLine uint64,
+	isLineBookmarked func(uint64) bool,
 	wrappedLineRunes []rune,
 	syntaxTokens []parser.Token,
 	cursorPos uint64,
 	selectedRegion selection.Region,
 	searchMatch *state.SearchMatch,
+	undoPreviewRegion selection.Region,
+	matchingBrackets []uint64,
+	ansi *ansiParser,
 	gcWidthFunc segment.GraphemeClusterWidthFunc,
 	showTabs bool,
 	showSpaces bool,
-) {
+	rtlVisualOrder bool,
+	ligatureBreaker rune,
+) int {
 	startPos := pos
 	gcRunes := []rune{'\x00', '\x00', '\x00', '\x00'}[:0] // Stack-allocate runes for the last grapheme cluster.
 	totalWidth := uint64(0)
@@ -112,10 +162,15 @@ func drawLineAndSetCursor(
 	var lastGcWasNewline bool
 
 	if startPos == lineStartPos {
-		drawLineNumIfNecessary(sr, palette, row, lineNum, lineNumMargin, lineNumberMode, cursorLine)
+		drawLineNumIfNecessary(sr, palette, row, lineNum, lineNumMargin, lineNumberMode, cursorLine, isLineBookmarked(lineNum))
 	}
 	col += int(lineNumMargin)
 
+	var visualOffsets map[int]uint64
+	if rtlVisualOrder {
+		visualOffsets = rtlVisualOffsets(wrappedLineRunes, gcWidthFunc)
+	}
+
 	var i int
 	for i < len(wrappedLineRunes) || len(gcRunes) > 0 {
 		for _, r := range wrappedLineRunes[i:] {
@@ -131,7 +186,19 @@ func drawLineAndSetCursor(
 
 		if totalWidth > uint64(maxLineWidth) {
 			// If there isn't enough space to show the line, skip it.
-			return
+			return -1
+		}
+
+		// Escape sequences are hidden when showing ANSI colors.
+		// They still count towards the total width, since that determines where the line wraps.
+		var hidden bool
+		if ansi != nil {
+			for _, r := range gcRunes {
+				hidden = ansi.ProcessRune(r) || hidden
+			}
+			if hidden {
+				gcWidth = 0
+			}
 		}
 
 		style := tcell.StyleDefault
@@ -139,6 +206,12 @@ func drawLineAndSetCursor(
 			style = palette.StyleForSelection()
 		} else if searchMatch.ContainsPosition(pos) {
 			style = palette.StyleForSearchMatch()
+		} else if undoPreviewRegion.ContainsPosition(pos) {
+			style = palette.StyleForUndoPreview()
+		} else if slices.Contains(matchingBrackets, pos) {
+			style = palette.StyleForMatchingBracket()
+		} else if ansi != nil && ansi.Style() != tcell.StyleDefault {
+			style = ansi.Style()
 		} else {
 			for len(syntaxTokens) > 0 {
 				token := syntaxTokens[0]
@@ -152,15 +225,26 @@ func drawLineAndSetCursor(
 			}
 		}
 
-		drawGraphemeCluster(sr, col, row, gcRunes, int(gcWidth), style, showTabs, showSpaces)
+		drawCol := col
+		if visualOffset, ok := visualOffsets[i]; ok {
+			drawCol = int(lineNumMargin) + int(visualOffset)
+		}
+
+		// When line wrap is disabled, skip cells scrolled past the left edge of the view.
+		drawCol -= leftCol
+		isVisible := drawCol >= int(lineNumMargin)
+
+		if isVisible && !hidden {
+			drawGraphemeCluster(sr, drawCol, row, gcRunes, int(gcWidth), style, showTabs, showSpaces, ligatureBreaker)
+		}
 
 		if pos-startPos == uint64(maxLineWidth) {
 			// This occurs when there are maxLineWidth characters followed by a line feed.
 			break
 		}
 
-		if pos == cursorPos {
-			showCursorInBuffer(sr, col, row, palette, inputMode)
+		if pos == cursorPos && isVisible {
+			showCursorInBuffer(sr, drawCol, row, palette, inputMode)
 		}
 
 		i += len(gcRunes)
@@ -171,21 +255,23 @@ func drawLineAndSetCursor(
 
 	if lastGcWasNewline {
 		// Draw line number for an empty final line.
-		drawLineNumIfNecessary(sr, palette, row+1, lineNum+1, lineNumMargin, lineNumberMode, cursorLine)
+		drawLineNumIfNecessary(sr, palette, row+1, lineNum+1, lineNumMargin, lineNumberMode, cursorLine, isLineBookmarked(lineNum+1))
 	}
 
 	if pos == cursorPos {
 		if lastGcWasNewline || (pos-startPos) == uint64(maxLineWidth) {
 			// If the line ended on a newline or soft-wrapped line, show the cursor at the start of the next line.
 			showCursorInBuffer(sr, int(lineNumMargin), row+1, palette, inputMode)
-		} else if pos == cursorPos {
+		} else if pos == cursorPos && col-leftCol >= int(lineNumMargin) {
 			// Otherwise, show the cursor at the end of the current line.
-			showCursorInBuffer(sr, col, row, palette, inputMode)
+			showCursorInBuffer(sr, col-leftCol, row, palette, inputMode)
 		}
 	}
+
+	return col - leftCol
 }
 
-func drawLineNumIfNecessary(sr *ScreenRegion, palette *Palette, row int, lineNum uint64, lineNumMargin uint64, lineNumberMode config.LineNumberMode, cursorLine uint64) {
+func drawLineNumIfNecessary(sr *ScreenRegion, palette *Palette, row int, lineNum uint64, lineNumMargin uint64, lineNumberMode config.LineNumberMode, cursorLine uint64, bookmarked bool) {
 	if lineNumMargin == 0 {
 		return
 	}
@@ -199,6 +285,55 @@ func drawLineNumIfNecessary(sr *ScreenRegion, palette *Palette, row int, lineNum
 		sr.SetContent(col, row, r, nil, style)
 		col++
 	}
+
+	// Bookmarked lines have an indicator in the padding.
+	if bookmarked {
+		sr.SetContent(col, row, '*', nil, palette.StyleForBookmark())
+	}
+}
+
+// drawBlameAnnotation draws a git blame annotation after the end of a line, separated from the text by a gap.
+// The annotation is truncated at the edge of the view.
+func drawBlameAnnotation(sr *ScreenRegion, palette *Palette, row int, col int, annotation string) {
+	const gap = 2
+	if annotation == "" {
+		return
+	}
+	drawStringNoWrap(sr, annotation, col+gap, row, palette.StyleForBlame())
+}
+
+// drawDiffMarker draws a marker in the diff gutter for a line changed since the document was last saved.
+func drawDiffMarker(sr *ScreenRegion, palette *Palette, row int, change state.LineChange) {
+	var r rune
+	switch change {
+	case state.LineChangeAdded:
+		r = '+'
+	case state.LineChangeModified:
+		r = '~'
+	case state.LineChangeDeleted:
+		r = '-'
+	default:
+		return
+	}
+	sr.SetContent(0, row, r, nil, palette.StyleForLineChange(change))
+}
+
+// drawScrollbar draws a column with the lines in view highlighted and markers for lines of interest elsewhere in the document.
+func drawScrollbar(sr *ScreenRegion, palette *Palette, buffer *state.BufferState, height int) {
+	thumbStart, thumbEnd := buffer.ScrollbarThumb(uint64(height))
+	for row, marker := range buffer.ScrollbarMarkers(uint64(height)) {
+		r := ' '
+		switch marker {
+		case state.ScrollbarMarkerLineChange:
+			r = '~'
+		case state.ScrollbarMarkerFlag:
+			r = '!'
+		case state.ScrollbarMarkerSearchMatch:
+			r = '='
+		}
+		inThumb := uint64(row) >= thumbStart && uint64(row) < thumbEnd
+		sr.SetContent(0, row, r, nil, palette.StyleForScrollbar(marker, inThumb))
+	}
 }
 
 func showCursorInBuffer(sr *ScreenRegion, col int, row int, palette *Palette, inputMode state.InputMode) {
@@ -216,10 +351,13 @@ func displayLineNum(lineNumberMode config.LineNumberMode, lineNum uint64, cursor
 	case config.LineNumberModeAbsolute:
 		return lineNum + 1
 	case config.LineNumberModeRelative:
+		// Show the absolute line number on the cursor line, so the user knows where they are in the document.
 		if lineNum < cursorLine {
 			return cursorLine - lineNum
-		} else {
+		} else if lineNum > cursorLine {
 			return lineNum - cursorLine
+		} else {
+			return lineNum + 1
 		}
 	default:
 		panic("Unrecognized line number mode")
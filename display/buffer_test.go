@@ -1,12 +1,20 @@
 package display
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/nondet"
 	"github.com/aretext/aretext/selection"
 	"github.com/aretext/aretext/state"
 	"github.com/aretext/aretext/syntax"
@@ -439,6 +447,30 @@ func TestDrawBufferCursor(t *testing.T) {
 			expectedCursorCol:     0,
 			expectedCursorRow:     1,
 		},
+		{
+			name:                  "after wide characters",
+			inputString:           "日本",
+			cursorPosition:        2,
+			expectedCursorVisible: true,
+			expectedCursorCol:     4,
+			expectedCursorRow:     0,
+		},
+		{
+			name:                  "on hangul syllable",
+			inputString:           "한국어",
+			cursorPosition:        1,
+			expectedCursorVisible: true,
+			expectedCursorCol:     2,
+			expectedCursorRow:     0,
+		},
+		{
+			name:                  "wide characters wrapped to next line",
+			inputString:           "中文中文中",
+			cursorPosition:        2,
+			expectedCursorVisible: true,
+			expectedCursorCol:     0,
+			expectedCursorRow:     1,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -838,7 +870,7 @@ func TestLineNumberMode(t *testing.T) {
 			lineNumMode:    config.LineNumberModeRelative,
 			showLineNum:    true,
 			expectedContents: [][]rune{
-				{' ', '0', ' ', ' ', ' '},
+				{' ', '1', ' ', ' ', ' '},
 				{' ', ' ', ' ', ' ', ' '},
 				{' ', ' ', ' ', ' ', ' '},
 				{' ', ' ', ' ', ' ', ' '},
@@ -853,7 +885,7 @@ func TestLineNumberMode(t *testing.T) {
 			lineNumMode:    config.LineNumberModeRelative,
 			showLineNum:    true,
 			expectedContents: [][]rune{
-				{' ', '0', ' ', 'a', 'b'},
+				{' ', '1', ' ', 'a', 'b'},
 				{' ', '1', ' ', 'c', ' '},
 				{' ', '2', ' ', 'd', 'e'},
 				{' ', ' ', ' ', ' ', ' '},
@@ -869,7 +901,7 @@ func TestLineNumberMode(t *testing.T) {
 			showLineNum:    true,
 			expectedContents: [][]rune{
 				{' ', '1', ' ', 'a', 'b'},
-				{' ', '0', ' ', 'c', ' '},
+				{' ', '2', ' ', 'c', ' '},
 				{' ', '1', ' ', 'd', 'e'},
 				{' ', '2', ' ', 'f', 'g'},
 				{' ', '3', ' ', 'h', 'i'},
@@ -885,7 +917,7 @@ func TestLineNumberMode(t *testing.T) {
 			expectedContents: [][]rune{
 				{' ', '2', ' ', 'a', 'b'},
 				{' ', '1', ' ', 'c', ' '},
-				{' ', '0', ' ', 'd', 'e'},
+				{' ', '3', ' ', 'd', 'e'},
 				{' ', '1', ' ', 'f', 'g'},
 				{' ', '2', ' ', 'h', 'i'},
 			},
@@ -924,7 +956,7 @@ func TestLineNumberMode(t *testing.T) {
 				{' ', '3', ' ', 'l', ' '},
 				{' ', '2', ' ', 'm', ' '},
 				{' ', '1', ' ', 'n', ' '},
-				{' ', '0', ' ', 'o', ' '},
+				{'1', '2', ' ', 'o', ' '},
 				{' ', '1', ' ', 'p', ' '},
 				{' ', '2', ' ', 'q', ' '},
 				{' ', '3', ' ', 'r', ' '},
@@ -1051,6 +1083,120 @@ func TestShowTabsWithSelectionStyle(t *testing.T) {
 	})
 }
 
+func TestDrawMatchingBracket(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(8, 1)
+		drawBuffer(t, s, func(editorState *state.EditorState) {
+			for _, r := range "f(a[b])" {
+				state.InsertRune(editorState, r)
+			}
+			state.MoveCursor(editorState, func(state.LocatorParams) uint64 {
+				return 3
+			})
+		})
+		assertCellContents(t, s, [][]rune{
+			{'f', '(', 'a', '[', 'b', ']', ')', ' '},
+		})
+		assertCellStyles(t, s, [][]tcell.Style{
+			{
+				tcell.StyleDefault,
+				tcell.StyleDefault,
+				tcell.StyleDefault,
+				tcell.StyleDefault.Underline(true),
+				tcell.StyleDefault,
+				tcell.StyleDefault.Underline(true),
+				tcell.StyleDefault,
+				tcell.StyleDefault,
+			},
+		})
+	})
+}
+
+func TestDrawAnsiColors(t *testing.T) {
+	d := tcell.StyleDefault
+	red := tcell.StyleDefault.Foreground(tcell.PaletteColor(1))
+	testCases := []struct {
+		name             string
+		readOnly         bool
+		inputString      string
+		expectedContents [][]rune
+		expectedStyles   [][]tcell.Style
+	}{
+		{
+			name:        "read-only shows colors",
+			readOnly:    true,
+			inputString: "a\x1b[31mbc\x1b[0md",
+			expectedContents: [][]rune{
+				{'a', 'b', 'c', 'd', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+			expectedStyles: [][]tcell.Style{
+				{d, red, red, d, d, d, d, d, d, d, d, d},
+				{d, d, d, d, d, d, d, d, d, d, d, d},
+			},
+		},
+		{
+			name:        "color resets at start of line",
+			readOnly:    true,
+			inputString: "\x1b[31ma\nb",
+			expectedContents: [][]rune{
+				{'a', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'b', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+			expectedStyles: [][]tcell.Style{
+				{red, red, d, d, d, d, d, d, d, d, d, d},
+				{d, d, d, d, d, d, d, d, d, d, d, d},
+			},
+		},
+		{
+			name:        "incomplete escape sequence",
+			readOnly:    true,
+			inputString: "a\x1b[1",
+			expectedContents: [][]rune{
+				{'a', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+			expectedStyles: [][]tcell.Style{
+				{d, d, d, d, d, d, d, d, d, d, d, d},
+				{d, d, d, d, d, d, d, d, d, d, d, d},
+			},
+		},
+		{
+			name:        "editable shows raw text",
+			readOnly:    false,
+			inputString: "\x1b[31ma",
+			expectedContents: [][]rune{
+				{'[', '3', '1', 'm', 'a', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+			expectedStyles: [][]tcell.Style{
+				{d, d, d, d, d, d, d, d, d, d, d, d},
+				{d, d, d, d, d, d, d, d, d, d, d, d},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(12, 2)
+				drawBuffer(t, s, func(editorState *state.EditorState) {
+					if tc.readOnly {
+						state.LoadPagerText(editorState, "log", tc.inputString, func(state.LocatorParams) uint64 { return 0 })
+					} else {
+						for _, r := range tc.inputString {
+							state.InsertRune(editorState, r)
+						}
+					}
+					state.ToggleShowAnsiColors(editorState)
+				})
+				assertCellContents(t, s, tc.expectedContents)
+				assertCellStyles(t, s, tc.expectedStyles)
+			})
+		})
+	}
+}
+
 func TestShowSpaces(t *testing.T) {
 	testCases := []struct {
 		name             string
@@ -1100,3 +1246,220 @@ func TestShowSpaces(t *testing.T) {
 		})
 	}
 }
+
+func TestLigatureBreaker(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(8, 1)
+		drawBuffer(t, s, func(editorState *state.EditorState) {
+			for _, r := range "a->\u0E04\u0E49 b" {
+				state.InsertRune(editorState, r)
+			}
+			state.CycleLigatureBreaker(editorState)
+		})
+		contents, _, _ := s.GetContents()
+		assert.Equal(t, []rune{'a', '\u200c'}, contents[0].Runes)
+		assert.Equal(t, []rune{'-', '\u200c'}, contents[1].Runes)
+		assert.Equal(t, []rune{'>', '\u200c'}, contents[2].Runes)
+		assert.Equal(t, []rune{'\u0E04', '\u0E49', '\u200c'}, contents[3].Runes)
+		assert.Equal(t, []rune{' '}, contents[4].Runes)
+		assert.Equal(t, []rune{'b', '\u200c'}, contents[5].Runes)
+	})
+}
+
+func TestLineWrapDisabled(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputString      string
+		cursorPos        uint64
+		showLineNumbers  bool
+		expectedContents [][]rune
+	}{
+		{
+			name:        "cursor at start of long line",
+			inputString: "abcdefgh\nij",
+			cursorPos:   0,
+			expectedContents: [][]rune{
+				{'a', 'b', 'c', 'd', 'e'},
+				{'i', 'j', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "cursor at end of long line",
+			inputString: "abcdefgh\nij",
+			cursorPos:   7,
+			expectedContents: [][]rune{
+				{'d', 'e', 'f', 'g', 'h'},
+				{' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:            "cursor at end of long line with line numbers",
+			inputString:     "abcdefgh\nij",
+			cursorPos:       7,
+			showLineNumbers: true,
+			expectedContents: [][]rune{
+				{' ', '1', ' ', 'g', 'h'},
+				{' ', '2', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' '},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(5, 3)
+				drawBuffer(t, s, func(editorState *state.EditorState) {
+					for _, r := range tc.inputString {
+						state.InsertRune(editorState, r)
+					}
+					if tc.showLineNumbers {
+						state.ToggleShowLineNumbers(editorState)
+					}
+					state.ToggleLineWrap(editorState)
+					state.MoveCursor(editorState, func(state.LocatorParams) uint64 { return tc.cursorPos })
+					state.ScrollViewToCursor(editorState)
+				})
+				assertCellContents(t, s, tc.expectedContents)
+			})
+		})
+	}
+}
+
+func TestDiffGutter(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputString      string
+		showLineNumbers  bool
+		expectedContents [][]rune
+	}{
+		{
+			name:        "added lines",
+			inputString: "ab\ncdefg",
+			expectedContents: [][]rune{
+				{'+', 'a', 'b', ' ', ' '},
+				{'+', 'c', 'd', 'e', 'f'},
+				{' ', 'g', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:            "added lines with line numbers",
+			inputString:     "ab\nc",
+			showLineNumbers: true,
+			expectedContents: [][]rune{
+				{'+', ' ', '1', ' ', 'a'},
+				{' ', ' ', ' ', ' ', 'b'},
+				{'+', ' ', '2', ' ', 'c'},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(5, 3)
+				drawBuffer(t, s, func(editorState *state.EditorState) {
+					for _, r := range tc.inputString {
+						state.InsertRune(editorState, r)
+					}
+					if tc.showLineNumbers {
+						state.ToggleShowLineNumbers(editorState)
+					}
+					state.ToggleShowDiffGutter(editorState)
+
+					// Wait for the comparison with the saved document, which runs after the user stops typing.
+					state.ScheduleLineChangeUpdate(editorState)
+					for editorState.LineChangeResultChan() != nil {
+						action := <-editorState.LineChangeResultChan()
+						action(editorState)
+					}
+				})
+				assertCellContents(t, s, tc.expectedContents)
+			})
+		})
+	}
+}
+
+func TestBlameAnnotation(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	commitTime := time.Unix(1700000000, 0)
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=@1700000000 +0000", "GIT_COMMITTER_DATE=@1700000000 +0000")
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(out))
+	}
+
+	path := filepath.Join(dir, "test.txt")
+	git("init", "-q")
+	require.NoError(t, os.WriteFile(path, []byte("ab\ncd\n"), 0644))
+	git("add", "test.txt")
+	git("commit", "-q", "-m", "Add test file")
+	hash := git("rev-parse", "--short=7", "HEAD")
+	require.NoError(t, os.WriteFile(path, []byte("ab\ncd\nef"), 0644))
+
+	restore := nondet.SetSource(nondet.NewDeterministicSource(commitTime.Add(48*time.Hour), 0, 0))
+	defer restore()
+
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(32, 4)
+		drawBuffer(t, s, func(editorState *state.EditorState) {
+			state.LoadDocument(editorState, path, true, func(state.LocatorParams) uint64 { return 0 })
+			state.ToggleShowBlame(editorState)
+			action := <-editorState.BlameResultChan()
+			action(editorState)
+		})
+
+		expectedRows := []string{
+			"ab  " + hash + " Alice, 2 days ago",
+			"cd  " + hash + " Alice, 2 days ago",
+			"ef  Not committed yet",
+			"",
+		}
+		expectedContents := make([][]rune, 0, len(expectedRows))
+		for _, row := range expectedRows {
+			expectedContents = append(expectedContents, []rune(fmt.Sprintf("%-32s", row)))
+		}
+		assertCellContents(t, s, expectedContents)
+	})
+}
+
+func TestScrollbar(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(10, 4)
+		drawBuffer(t, s, func(editorState *state.EditorState) {
+			state.InsertText(editorState, "a\nb\nc\nd\ne\nTODO\ng\nh")
+			state.MoveCursor(editorState, func(state.LocatorParams) uint64 { return 0 })
+			state.ToggleShowScrollbar(editorState)
+
+			// Wait for the comparison with the saved document, which runs after the user stops typing.
+			state.ScheduleLineChangeUpdate(editorState)
+			for editorState.LineChangeResultChan() != nil {
+				action := <-editorState.LineChangeResultChan()
+				action(editorState)
+			}
+		})
+
+		// Each row of the scrollbar represents two lines, all of them unsaved.
+		assertCellContents(t, s, [][]rune{
+			[]rune("a        ~"),
+			[]rune("b        ~"),
+			[]rune("c        !"),
+			[]rune("d        ~"),
+		})
+
+		// The first two rows represent the lines in view.
+		cells, width, _ := s.GetContents()
+		palette := NewPalette()
+		assert.Equal(t, palette.StyleForScrollbar(state.ScrollbarMarkerLineChange, true), cells[width-1].Style)
+		assert.Equal(t, palette.StyleForScrollbar(state.ScrollbarMarkerLineChange, true), cells[2*width-1].Style)
+		assert.Equal(t, palette.StyleForScrollbar(state.ScrollbarMarkerFlag, false), cells[3*width-1].Style)
+	})
+}
@@ -6,11 +6,40 @@ import (
 	"github.com/aretext/aretext/state"
 )
 
+// minScreenWidth and minScreenHeight are the smallest screen size that can display the editor.
+// Terminals can report tiny sizes while resizing (for example, when tmux rearranges panes),
+// so the editor draws a placeholder until the screen is large enough again.
+// Three rows is enough for the menu search bar, one menu item, and the status bar.
+const (
+	minScreenWidth  = 10
+	minScreenHeight = 3
+)
+
+const screenTooSmallMsg = "Terminal too small"
+
 // DrawEditor draws the editor in the screen.
 func DrawEditor(screen tcell.Screen, palette *Palette, editorState *state.EditorState, inputBufferString string) {
 	screen.Fill(' ', tcell.StyleDefault)
 
-	DrawBuffer(screen, palette, editorState.DocumentBuffer(), editorState.InputMode())
+	if screenWidth, screenHeight := screen.Size(); screenWidth < minScreenWidth || screenHeight < minScreenHeight {
+		drawScreenTooSmall(screen, screenWidth, screenHeight)
+		return
+	}
+
+	// Record where the cursor is in the document, so the autocomplete popup can be drawn next to it.
+	bufferScreen := &cursorTrackingScreen{Screen: screen, cursorCol: -1, cursorRow: -1}
+	if splits := editorState.SplitViews(); len(splits) > 0 {
+		DrawSplits(bufferScreen, palette, splits, editorState.InputMode())
+	} else if editorState.InputMode() == state.InputModeChanges {
+		DrawBuffer(bufferScreen, palette, editorState.ChangesViewBuffer(), editorState.InputMode())
+	} else {
+		DrawBuffer(bufferScreen, palette, editorState.DocumentBuffer(), editorState.InputMode())
+	}
+
+	// The file explorer covers the document, including while a text field prompts for a file path.
+	if explorer := editorState.FileExplorer(); explorer != nil {
+		DrawFileExplorer(screen, palette, explorer)
+	}
 
 	DrawStatusBar(
 		screen,
@@ -19,7 +48,12 @@ func DrawEditor(screen tcell.Screen, palette *Palette, editorState *state.Editor
 		editorState.InputMode(),
 		inputBufferString,
 		editorState.IsRecordingUserMacro(),
+		editorState.DocumentBuffer().PasteMode(),
 		editorState.FileWatcher().Path(),
+		editorState.FileWatcher().IsNewFile(),
+		editorState.DocumentBuffer().Loading(),
+		editorState.DocumentBuffer().FlagSummary(),
+		rulerText(editorState.DocumentBuffer()),
 	)
 
 	switch editorState.InputMode() {
@@ -30,5 +64,18 @@ func DrawEditor(screen tcell.Screen, palette *Palette, editorState *state.Editor
 		DrawSearchQuery(screen, palette, searchQuery, searchDirection)
 	case state.InputModeTextField:
 		DrawTextField(screen, palette, editorState.TextField())
+	case state.InputModeAutocomplete:
+		DrawAutocomplete(screen, palette, editorState.Autocomplete(), bufferScreen.cursorCol, bufferScreen.cursorRow)
+	}
+}
+
+// drawScreenTooSmall draws a placeholder in the middle row of a screen too small to display the editor.
+// The message is truncated to fit the screen width.
+func drawScreenTooSmall(screen tcell.Screen, screenWidth, screenHeight int) {
+	screen.HideCursor()
+	if screenWidth <= 0 || screenHeight <= 0 {
+		return
 	}
+	sr := NewScreenRegion(screen, 0, screenHeight/2, screenWidth, 1)
+	drawStringNoWrap(sr, screenTooSmallMsg, 0, 0, tcell.StyleDefault)
 }
@@ -32,7 +32,7 @@ func TestDrawEditor(t *testing.T) {
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
-				{'t', 'e', 's', 't', '.', 't', 'x', 't', ' ', ' '},
+				{'t', 'e', 's', 't', '.', 't', 'x', 't', ' ', '['},
 			},
 		},
 		{
@@ -52,7 +52,7 @@ func TestDrawEditor(t *testing.T) {
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
-				{'t', 'e', 's', 't', '.', 't', 'x', 't', ' ', ' '},
+				{'t', 'e', 's', 't', '.', 't', 'x', 't', ' ', '['},
 			},
 		},
 		{
@@ -93,7 +93,7 @@ func TestDrawEditor(t *testing.T) {
 				{'─', '─', '─', '─', '─', '─', '─', '─', '─', '─'},
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
-				{'t', 'e', 's', 't', '.', 't', 'x', 't', ' ', ' '},
+				{'t', 'e', 's', 't', '.', 't', 'x', 't', ' ', '['},
 			},
 		},
 	}
@@ -124,3 +124,88 @@ func newEditorStateWithPath(path string) (*state.EditorState, error) {
 	state.SetStatusMsg(s, state.StatusMsg{})
 	return s, nil
 }
+
+func TestDrawEditorScreenTooSmall(t *testing.T) {
+	testCases := []struct {
+		name             string
+		width, height    int
+		expectedContents [][]rune
+	}{
+		{
+			name:   "one row",
+			width:  10,
+			height: 1,
+			expectedContents: [][]rune{
+				{'T', 'e', 'r', 'm', 'i', 'n', 'a', 'l', ' ', 't'},
+			},
+		},
+		{
+			name:   "two rows",
+			width:  10,
+			height: 2,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'T', 'e', 'r', 'm', 'i', 'n', 'a', 'l', ' ', 't'},
+			},
+		},
+		{
+			name:   "narrow",
+			width:  3,
+			height: 3,
+			expectedContents: [][]rune{
+				{' ', ' ', ' '},
+				{'T', 'e', 'r'},
+				{' ', ' ', ' '},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				editorState, err := newEditorStateWithPath("test.txt")
+				require.NoError(t, err)
+				state.InsertText(editorState, "abc\ndef")
+				state.ResizeView(editorState, uint64(tc.width), uint64(tc.height))
+				state.ScrollViewToCursor(editorState)
+				s.SetSize(tc.width, tc.height)
+				DrawEditor(s, NewPalette(), editorState, "")
+				s.Sync()
+				assertCellContents(t, s, tc.expectedContents)
+			})
+		})
+	}
+}
+
+func TestDrawEditorRecoversFromZeroSize(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		editorState, err := newEditorStateWithPath("test.txt")
+		require.NoError(t, err)
+		state.ShowMenu(editorState, state.MenuStyleCommand, nil)
+		state.AppendRuneToMenuSearch(editorState, 'a')
+
+		// Terminals can report zero columns while a pane is being rearranged.
+		state.ResizeView(editorState, 0, 0)
+		state.ScrollViewToCursor(editorState)
+		s.SetSize(0, 0)
+		DrawEditor(s, NewPalette(), editorState, "")
+		s.Sync()
+
+		// Once the size recovers, the editor draws normally.
+		state.HideMenu(editorState)
+		state.InsertText(editorState, "abc")
+		state.ResizeView(editorState, 10, 6)
+		state.ScrollViewToCursor(editorState)
+		s.SetSize(10, 6)
+		DrawEditor(s, NewPalette(), editorState, "")
+		s.Sync()
+		assertCellContents(t, s, [][]rune{
+			{'a', 'b', 'c', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{'t', 'e', 's', 't', '.', 't', 'x', 't', ' ', '['},
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package display
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/state"
+)
+
+// DrawFileExplorer draws the file explorer over the document.
+// The first line shows the explorer's directory, and the entries below it scroll to keep the selected entry visible.
+func DrawFileExplorer(screen tcell.Screen, palette *Palette, explorer *state.FileExplorerState) {
+	screenWidth, screenHeight := screen.Size()
+
+	// Leave one line at the bottom for the status bar.
+	height := screenHeight - 1
+	if height <= 0 || screenWidth == 0 {
+		return
+	}
+
+	sr := NewScreenRegion(screen, 0, 0, screenWidth, height)
+	sr.Clear()
+	sr.HideCursor()
+
+	rootDir := file.RelativePathCwd(explorer.RootDir())
+	if !strings.HasSuffix(rootDir, string(filepath.Separator)) {
+		rootDir += string(filepath.Separator)
+	}
+	drawStringNoWrap(sr, rootDir, 0, 0, palette.StyleForFileExplorerHeader())
+
+	entries, selectedIdx := explorer.Entries()
+	offset := 0
+	if maxVisible := height - 1; selectedIdx >= maxVisible {
+		offset = selectedIdx - maxVisible + 1
+	}
+
+	for row := 1; row < height && offset+row-1 < len(entries); row++ {
+		idx := offset + row - 1
+		entryRegion := NewScreenRegion(screen, 0, row, screenWidth, 1)
+		drawFileExplorerEntry(entryRegion, palette, entries[idx], idx == selectedIdx)
+	}
+}
+
+func drawFileExplorerEntry(sr *ScreenRegion, palette *Palette, entry state.FileExplorerEntry, selected bool) {
+	style := palette.StyleForFileExplorerEntry(entry.IsDir, selected)
+	if selected {
+		sr.Fill(' ', style)
+	}
+
+	// Indent two columns for each level of the tree, then mark directories as expanded or collapsed.
+	var label string
+	if !entry.IsDir {
+		label = "  " + entry.Name
+	} else if entry.Expanded {
+		label = "- " + entry.Name + string(filepath.Separator)
+	} else {
+		label = "+ " + entry.Name + string(filepath.Separator)
+	}
+	drawStringNoWrap(sr, label, entry.Depth*2, 0, style)
+}
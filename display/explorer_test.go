@@ -0,0 +1,48 @@
+package display
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/state"
+)
+
+func TestDrawFileExplorer(t *testing.T) {
+	// Change the working directory, so the explorer's directory is displayed as "./".
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	dir := t.TempDir()
+	require.NoError(t, os.Chdir(dir))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "dir", "sub"), 0755))
+	for _, p := range []string{"a.txt", "b.txt", filepath.Join("dir", "c.txt")} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, p), nil, 0644))
+	}
+
+	editorState := state.NewEditorState(100, 100, nil, nil)
+	state.ShowFileExplorer(editorState, dir)
+	state.OpenFileExplorerEntry(editorState)
+	state.MoveFileExplorerSelection(editorState, 3)
+	require.NotNil(t, editorState.FileExplorer())
+
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(10, 5)
+		palette := NewPalette()
+		DrawFileExplorer(s, palette, editorState.FileExplorer())
+		s.Sync()
+
+		// The entries scroll to keep the selected file visible.
+		assertCellContents(t, s, [][]rune{
+			{'.', '/', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', '+', ' ', 's', 'u', 'b', '/', ' ', ' '},
+			{' ', ' ', ' ', ' ', 'c', '.', 't', 'x', 't', ' '},
+			{' ', ' ', 'a', '.', 't', 'x', 't', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+		})
+	})
+}
@@ -29,7 +29,7 @@ func drawStringNoWrap(sr *ScreenRegion, s string, col int, row int, style tcell.
 			if uint64(col)+gcWidth > uint64(maxLineWidth) {
 				break
 			}
-			drawGraphemeCluster(sr, col, row, gcRunes, int(gcWidth), style, false, false)
+			drawGraphemeCluster(sr, col, row, gcRunes, int(gcWidth), style, false, false, 0)
 			col += int(gcWidth) // Safe to downcast because there's a limit on the number of cells a grapheme cluster can occupy.
 			gcRunes = gcRunes[:0]
 		}
@@ -50,6 +50,7 @@ func drawGraphemeCluster(
 	style tcell.Style,
 	showTabs bool,
 	showSpaces bool,
+	ligatureBreaker rune,
 ) {
 	startCol := col
 
@@ -99,7 +100,13 @@ func drawGraphemeCluster(
 			}
 			j++
 		}
-		sr.SetContent(col, row, gc[i], gc[i+1:j], style)
+		combc := gc[i+1 : j]
+		if ligatureBreaker != 0 {
+			// The terminal treats the zero-width breaker as part of the cell, so it doesn't affect
+			// the cursor column, but the font can no longer join this character with the next one.
+			combc = append(combc[:len(combc):len(combc)], ligatureBreaker)
+		}
+		sr.SetContent(col, row, gc[i], combc, style)
 		col += int(cellwidth.RuneWidth(gc[i]))
 		i = j
 	}
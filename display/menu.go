@@ -91,6 +91,24 @@ func menuIconForStyle(style state.MenuStyle) string {
 		return "+ "
 	case state.MenuStyleChildDir, state.MenuStyleParentDir, state.MenuStyleWorkingDir:
 		return "§ "
+	case state.MenuStyleKeyBindings:
+		return "? "
+	case state.MenuStyleLongLines:
+		return "! "
+	case state.MenuStyleSubmenu:
+		return "> "
+	case state.MenuStyleOperator:
+		return "g@ "
+	case state.MenuStyleBookmark:
+		return "* "
+	case state.MenuStyleBackup:
+		return "~ "
+	case state.MenuStyleScratch:
+		return "# "
+	case state.MenuStyleBuffer:
+		return "= "
+	case state.MenuStyleConfigDir:
+		return "./"
 	default:
 		panic("Unrecognized menu style")
 	}
@@ -108,6 +126,24 @@ func menuPromptForStyle(style state.MenuStyle) string {
 		return ""
 	case state.MenuStyleChildDir, state.MenuStyleParentDir, state.MenuStyleWorkingDir:
 		return "working directory"
+	case state.MenuStyleKeyBindings:
+		return "key bindings"
+	case state.MenuStyleLongLines:
+		return "long lines"
+	case state.MenuStyleSubmenu:
+		return ""
+	case state.MenuStyleOperator:
+		return "operator"
+	case state.MenuStyleBookmark:
+		return "bookmarks"
+	case state.MenuStyleBackup:
+		return "backups"
+	case state.MenuStyleScratch:
+		return "scratch buffers"
+	case state.MenuStyleBuffer:
+		return "buffers"
+	case state.MenuStyleConfigDir:
+		return "config directory"
 	default:
 		panic("Unrecognized menu style")
 	}
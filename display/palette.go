@@ -7,21 +7,36 @@ import (
 
 	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/state"
+	"github.com/aretext/aretext/syntax"
 	"github.com/aretext/aretext/syntax/parser"
 )
 
 // Palette controls the style of displayed text.
 type Palette struct {
 	lineNumStyle              tcell.Style
+	bookmarkStyle             tcell.Style
+	blameStyle                tcell.Style
+	lineAddedStyle            tcell.Style
+	lineModifiedStyle         tcell.Style
+	lineDeletedStyle          tcell.Style
+	scrollbarThumbStyle       tcell.Style
+	scrollbarFlagStyle        tcell.Style
+	scrollbarSearchMatchStyle tcell.Style
 	selectionStyle            tcell.Style
 	searchMatchStyle          tcell.Style
 	searchCursorStyle         tcell.Style
+	undoPreviewStyle          tcell.Style
+	matchingBracketStyle      tcell.Style
 	statusMsgSuccessStyle     tcell.Style
 	statusMsgErrorStyle       tcell.Style
 	statusInputModeStyle      tcell.Style
 	statusInputBufferStyle    tcell.Style
 	statusRecordingMacroStyle tcell.Style
 	statusFilePathStyle       tcell.Style
+	statusRulerStyle          tcell.Style
+	splitTitleStyle           tcell.Style
+	splitTitleFocusedStyle    tcell.Style
+	splitBorderStyle          tcell.Style
 	menuBorderStyle           tcell.Style
 	menuIconStyle             tcell.Style
 	menuPromptStyle           tcell.Style
@@ -29,6 +44,12 @@ type Palette struct {
 	menuCursorStyle           tcell.Style
 	menuItemSelectedStyle     tcell.Style
 	menuItemUnselectedStyle   tcell.Style
+	autocompleteItemStyle     tcell.Style
+	autocompleteSelectedStyle tcell.Style
+	explorerHeaderStyle       tcell.Style
+	explorerDirStyle          tcell.Style
+	explorerFileStyle         tcell.Style
+	explorerSelectedStyle     tcell.Style
 	textFieldPromptStyle      tcell.Style
 	textFieldInputTextStyle   tcell.Style
 	textFieldBorderStyle      tcell.Style
@@ -41,15 +62,29 @@ func NewPalette() *Palette {
 	s := tcell.StyleDefault
 	return &Palette{
 		lineNumStyle:              s.Foreground(tcell.ColorOlive),
+		bookmarkStyle:             s.Foreground(tcell.ColorTeal).Bold(true),
+		blameStyle:                s.Dim(true).Italic(true),
+		lineAddedStyle:            s.Foreground(tcell.ColorGreen),
+		lineModifiedStyle:         s.Foreground(tcell.ColorOlive),
+		lineDeletedStyle:          s.Foreground(tcell.ColorMaroon),
+		scrollbarThumbStyle:       s.Reverse(true).Dim(true),
+		scrollbarFlagStyle:        s.Foreground(tcell.ColorMaroon).Bold(true),
+		scrollbarSearchMatchStyle: s.Bold(true),
 		selectionStyle:            s.Reverse(true).Dim(true),
 		searchMatchStyle:          s.Reverse(true),
 		searchCursorStyle:         s.Reverse(true).Dim(true),
+		undoPreviewStyle:          s.Underline(true).Bold(true),
+		matchingBracketStyle:      s.Underline(true),
 		statusMsgSuccessStyle:     s.Foreground(tcell.ColorGreen).Bold(true),
 		statusMsgErrorStyle:       s.Background(tcell.ColorMaroon).Foreground(tcell.ColorWhite).Bold(true),
 		statusInputModeStyle:      s.Bold(true),
 		statusInputBufferStyle:    s.Bold(true),
 		statusRecordingMacroStyle: s.Bold(true),
 		statusFilePathStyle:       s.Bold(true),
+		statusRulerStyle:          s.Dim(true),
+		splitTitleStyle:           s.Reverse(true).Dim(true),
+		splitTitleFocusedStyle:    s.Reverse(true).Bold(true),
+		splitBorderStyle:          s.Dim(true),
 		menuBorderStyle:           s.Dim(true),
 		menuIconStyle:             s,
 		menuPromptStyle:           s.Dim(true),
@@ -57,6 +92,12 @@ func NewPalette() *Palette {
 		menuCursorStyle:           s.Bold(true),
 		menuItemSelectedStyle:     s.Underline(true),
 		menuItemUnselectedStyle:   s,
+		autocompleteItemStyle:     s.Reverse(true).Dim(true),
+		autocompleteSelectedStyle: s.Reverse(true),
+		explorerHeaderStyle:       s.Dim(true),
+		explorerDirStyle:          s.Bold(true),
+		explorerFileStyle:         s,
+		explorerSelectedStyle:     s.Reverse(true),
 		textFieldPromptStyle:      s.Dim(true),
 		textFieldInputTextStyle:   s,
 		textFieldBorderStyle:      s,
@@ -88,8 +129,13 @@ func NewPalette() *Palette {
 	}
 }
 
-func NewPaletteFromConfigStyles(styles map[string]config.StyleConfig) *Palette {
+// NewPaletteFromConfigStyles constructs a palette with styles overridden by the configuration.
+// Styles for token roles specific to the syntax language (like "tokenKey" for YAML)
+// extend the global style for the same token role, so a style that sets only
+// an attribute like bold keeps the color from the global style.
+func NewPaletteFromConfigStyles(styles map[string]config.StyleConfig, language syntax.Language) *Palette {
 	p := NewPalette()
+	var languageStyleNames []string
 	for k, v := range styles {
 		s := styleFromConfig(v)
 		switch k {
@@ -138,9 +184,19 @@ func NewPaletteFromConfigStyles(styles map[string]config.StyleConfig) *Palette {
 		case config.StyleTokenCustom16:
 			p.tokenRoleStyle[parser.TokenRoleCustom16] = s
 		default:
-			log.Printf("Unrecognized style key: %s\n", k)
+			if _, ok := syntax.TokenRoleForStyleName(language, k); ok {
+				languageStyleNames = append(languageStyleNames, k)
+			} else {
+				log.Printf("Unrecognized style key for language %s: %s\n", language, k)
+			}
 		}
 	}
+
+	for _, k := range languageStyleNames {
+		role, _ := syntax.TokenRoleForStyleName(language, k)
+		p.tokenRoleStyle[role] = extendStyleFromConfig(p.tokenRoleStyle[role], styles[k])
+	}
+
 	return p
 }
 
@@ -148,6 +204,45 @@ func (p *Palette) StyleForLineNum() tcell.Style {
 	return p.lineNumStyle
 }
 
+func (p *Palette) StyleForBookmark() tcell.Style {
+	return p.bookmarkStyle
+}
+
+func (p *Palette) StyleForBlame() tcell.Style {
+	return p.blameStyle
+}
+
+// StyleForScrollbar returns the style of a scrollbar row with a marker.
+// Rows representing the lines in view use the thumb style, so the marker stays visible within the thumb.
+func (p *Palette) StyleForScrollbar(marker state.ScrollbarMarker, inThumb bool) tcell.Style {
+	if inThumb {
+		return p.scrollbarThumbStyle
+	}
+	switch marker {
+	case state.ScrollbarMarkerLineChange:
+		return p.lineModifiedStyle
+	case state.ScrollbarMarkerFlag:
+		return p.scrollbarFlagStyle
+	case state.ScrollbarMarkerSearchMatch:
+		return p.scrollbarSearchMatchStyle
+	default:
+		return tcell.StyleDefault
+	}
+}
+
+func (p *Palette) StyleForLineChange(change state.LineChange) tcell.Style {
+	switch change {
+	case state.LineChangeAdded:
+		return p.lineAddedStyle
+	case state.LineChangeModified:
+		return p.lineModifiedStyle
+	case state.LineChangeDeleted:
+		return p.lineDeletedStyle
+	default:
+		return tcell.StyleDefault
+	}
+}
+
 func (p *Palette) StyleForSelection() tcell.Style {
 	return p.selectionStyle
 }
@@ -160,6 +255,14 @@ func (p *Palette) StyleForSearchCursor() tcell.Style {
 	return p.searchCursorStyle
 }
 
+func (p *Palette) StyleForUndoPreview() tcell.Style {
+	return p.undoPreviewStyle
+}
+
+func (p *Palette) StyleForMatchingBracket() tcell.Style {
+	return p.matchingBracketStyle
+}
+
 func (p *Palette) StyleForStatusInputMode() tcell.Style {
 	return p.statusInputModeStyle
 }
@@ -176,6 +279,21 @@ func (p *Palette) StyleForStatusFilePath() tcell.Style {
 	return p.statusFilePathStyle
 }
 
+func (p *Palette) StyleForStatusRuler() tcell.Style {
+	return p.statusRulerStyle
+}
+
+func (p *Palette) StyleForSplitTitle(focused bool) tcell.Style {
+	if focused {
+		return p.splitTitleFocusedStyle
+	}
+	return p.splitTitleStyle
+}
+
+func (p *Palette) StyleForSplitBorder() tcell.Style {
+	return p.splitBorderStyle
+}
+
 func (p *Palette) StyleForStatusMsg(statusMsgStyle state.StatusMsgStyle) tcell.Style {
 	switch statusMsgStyle {
 	case state.StatusMsgStyleSuccess:
@@ -215,6 +333,28 @@ func (p *Palette) StyleForMenuItem(selected bool) tcell.Style {
 	}
 }
 
+func (p *Palette) StyleForAutocompleteItem(selected bool) tcell.Style {
+	if selected {
+		return p.autocompleteSelectedStyle
+	} else {
+		return p.autocompleteItemStyle
+	}
+}
+
+func (p *Palette) StyleForFileExplorerHeader() tcell.Style {
+	return p.explorerHeaderStyle
+}
+
+func (p *Palette) StyleForFileExplorerEntry(isDir bool, selected bool) tcell.Style {
+	if selected {
+		return p.explorerSelectedStyle
+	} else if isDir {
+		return p.explorerDirStyle
+	} else {
+		return p.explorerFileStyle
+	}
+}
+
 func (p *Palette) StyleForTextFieldPrompt() tcell.Style {
 	return p.textFieldPromptStyle
 }
@@ -240,6 +380,37 @@ func (p *Palette) StyleForTokenRole(tokenRole parser.TokenRole) tcell.Style {
 	return p.tokenRoleStyle[tokenRole]
 }
 
+// extendStyleFromConfig overrides the colors and attributes of a base style that are set in the config.
+func extendStyleFromConfig(base tcell.Style, s config.StyleConfig) tcell.Style {
+	style := base
+
+	if s.Color != "" {
+		style = style.Foreground(tcell.GetColor(s.Color))
+	}
+
+	if s.BackgroundColor != "" {
+		style = style.Background(tcell.GetColor(s.BackgroundColor))
+	}
+
+	if s.Bold {
+		style = style.Bold(true)
+	}
+
+	if s.Italic {
+		style = style.Italic(true)
+	}
+
+	if s.Underline {
+		style = style.Underline(true)
+	}
+
+	if s.StrikeThrough {
+		style = style.StrikeThrough(true)
+	}
+
+	return style
+}
+
 func styleFromConfig(s config.StyleConfig) tcell.Style {
 	c := tcell.GetColor(s.Color)
 	style := tcell.StyleDefault.Foreground(c)
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/syntax"
 	"github.com/aretext/aretext/syntax/parser"
 )
 
@@ -30,20 +31,34 @@ func TestPaletteFromConfigStyles(t *testing.T) {
 		},
 	}
 
-	palette := NewPaletteFromConfigStyles(configStyles)
+	palette := NewPaletteFromConfigStyles(configStyles, syntax.LanguagePlaintext)
 
 	s := tcell.StyleDefault
 	expected := &Palette{
 		lineNumStyle:              s.Foreground(tcell.ColorOlive),
+		bookmarkStyle:             s.Foreground(tcell.ColorTeal).Bold(true),
+		blameStyle:                s.Dim(true).Italic(true),
+		lineAddedStyle:            s.Foreground(tcell.ColorGreen),
+		lineModifiedStyle:         s.Foreground(tcell.ColorOlive),
+		lineDeletedStyle:          s.Foreground(tcell.ColorMaroon),
+		scrollbarThumbStyle:       s.Reverse(true).Dim(true),
+		scrollbarFlagStyle:        s.Foreground(tcell.ColorMaroon).Bold(true),
+		scrollbarSearchMatchStyle: s.Bold(true),
 		selectionStyle:            s.Reverse(true).Dim(true),
 		searchCursorStyle:         s.Reverse(true).Dim(true),
 		searchMatchStyle:          s.Reverse(true),
+		undoPreviewStyle:          s.Underline(true).Bold(true),
+		matchingBracketStyle:      s.Underline(true),
 		statusMsgSuccessStyle:     s.Foreground(tcell.ColorGreen).Bold(true),
 		statusMsgErrorStyle:       s.Background(tcell.ColorMaroon).Foreground(tcell.ColorWhite).Bold(true),
 		statusInputModeStyle:      s.Bold(true),
 		statusInputBufferStyle:    s.Bold(true),
 		statusRecordingMacroStyle: s.Bold(true),
 		statusFilePathStyle:       s.Bold(true),
+		statusRulerStyle:          s.Dim(true),
+		splitTitleStyle:           s.Reverse(true).Dim(true),
+		splitTitleFocusedStyle:    s.Reverse(true).Bold(true),
+		splitBorderStyle:          s.Dim(true),
 		menuBorderStyle:           s.Dim(true),
 		menuIconStyle:             s,
 		menuPromptStyle:           s.Dim(true),
@@ -51,6 +66,12 @@ func TestPaletteFromConfigStyles(t *testing.T) {
 		menuCursorStyle:           s.Bold(true),
 		menuItemSelectedStyle:     s.Underline(true),
 		menuItemUnselectedStyle:   s,
+		autocompleteItemStyle:     s.Reverse(true).Dim(true),
+		autocompleteSelectedStyle: s.Reverse(true),
+		explorerHeaderStyle:       s.Dim(true),
+		explorerDirStyle:          s.Bold(true),
+		explorerFileStyle:         s,
+		explorerSelectedStyle:     s.Reverse(true),
 		textFieldPromptStyle:      s.Dim(true),
 		textFieldInputTextStyle:   s,
 		textFieldBorderStyle:      s,
@@ -83,3 +104,31 @@ func TestPaletteFromConfigStyles(t *testing.T) {
 
 	assert.Equal(t, expected, palette)
 }
+
+func TestPaletteFromConfigStylesForLanguage(t *testing.T) {
+	configStyles := map[string]config.StyleConfig{
+		config.StyleTokenCustom1: {
+			Color: "teal",
+		},
+		config.StyleTokenCustom2: {
+			Color: "red",
+		},
+		"tokenKey": {
+			Bold: true,
+		},
+		"tokenAliasOrAnchor": {
+			Color:  "green",
+			Italic: true,
+		},
+		"tokenBuiltin": {
+			Italic: true,
+		},
+	}
+
+	palette := NewPaletteFromConfigStyles(configStyles, syntax.LanguageYaml)
+
+	s := tcell.StyleDefault
+	assert.Equal(t, s.Foreground(tcell.ColorTeal).Bold(true), palette.StyleForTokenRole(parser.TokenRoleCustom1))
+	assert.Equal(t, s.Foreground(tcell.ColorGreen).Italic(true), palette.StyleForTokenRole(parser.TokenRoleCustom2))
+	assert.Equal(t, s.Foreground(tcell.ColorOlive), palette.StyleForTokenRole(parser.TokenRoleKeyword))
+}
@@ -0,0 +1,86 @@
+package display
+
+import (
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/state"
+)
+
+// DrawSplits draws the document shown in each split, along with the split's title bar and border.
+// The focused split is drawn last, so the terminal cursor is shown only in the focused split.
+func DrawSplits(screen tcell.Screen, palette *Palette, splits []state.SplitView, inputMode state.InputMode) {
+	for _, drawFocused := range []bool{false, true} {
+		for _, split := range splits {
+			if split.Focused != drawFocused {
+				continue
+			}
+
+			splitScreen := &offsetScreen{Screen: screen, x: int(split.X), y: int(split.Y), showCursor: split.Focused}
+			splitInputMode := inputMode
+			if !split.Focused {
+				splitInputMode = state.InputModeNormal
+			}
+			DrawBuffer(splitScreen, palette, split.Buffer, splitInputMode)
+			drawSplitBorder(screen, palette, split)
+			drawSplitTitle(screen, palette, split)
+		}
+	}
+}
+
+// drawSplitBorder draws a border in the last column of a split, if the document view is narrower than the split.
+func drawSplitBorder(screen tcell.Screen, palette *Palette, split state.SplitView) {
+	viewWidth, viewHeight := viewSize(split.Buffer)
+	if viewWidth >= int(split.Width) {
+		return
+	}
+	sr := NewScreenRegion(screen, int(split.X)+viewWidth, int(split.Y), 1, viewHeight)
+	sr.Fill(tcell.RuneVLine, palette.StyleForSplitBorder())
+}
+
+// drawSplitTitle draws the name of the split's buffer in the last row of the split.
+func drawSplitTitle(screen tcell.Screen, palette *Palette, split state.SplitView) {
+	if split.Height == 0 {
+		return
+	}
+	style := palette.StyleForSplitTitle(split.Focused)
+	sr := NewScreenRegion(screen, int(split.X), int(split.Y+split.Height-1), int(split.Width), 1)
+	sr.Fill(' ', style)
+	title := " " + split.Name
+	if split.Zoomed {
+		title += " [zoom]"
+	}
+	drawStringNoWrap(sr, title, 0, 0, style)
+}
+
+// offsetScreen draws to a screen with coordinates relative to the top left corner of a split.
+// If showCursor is false, the terminal cursor is left unchanged.
+type offsetScreen struct {
+	tcell.Screen
+	x, y       int
+	showCursor bool
+}
+
+func (s *offsetScreen) SetContent(x, y int, mainc rune, combc []rune, style tcell.Style) {
+	s.Screen.SetContent(x+s.x, y+s.y, mainc, combc, style)
+}
+
+func (s *offsetScreen) GetContent(x, y int) (rune, []rune, tcell.Style, int) {
+	return s.Screen.GetContent(x+s.x, y+s.y)
+}
+
+func (s *offsetScreen) ShowCursor(x, y int) {
+	if !s.showCursor {
+		return
+	}
+	if x < 0 || y < 0 {
+		s.Screen.ShowCursor(-1, -1)
+		return
+	}
+	s.Screen.ShowCursor(x+s.x, y+s.y)
+}
+
+func (s *offsetScreen) HideCursor() {
+	if s.showCursor {
+		s.Screen.HideCursor()
+	}
+}
@@ -0,0 +1,38 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/state"
+)
+
+func TestDrawSplits(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		editorState := state.NewEditorState(12, 7, nil, nil)
+		state.LoadScratchText(editorState, "a", "abc")
+		state.LoadScratchText(editorState, "b", "def")
+		state.SplitVertical(editorState)
+		state.SetStatusMsg(editorState, state.StatusMsg{})
+
+		s.SetSize(12, 7)
+		DrawEditor(s, NewPalette(), editorState, "")
+		s.Sync()
+		assertCellContents(t, s, [][]rune{
+			{'d', 'e', 'f', ' ', ' ', '│', 'a', 'b', 'c', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', '│', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', '│', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', '│', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', '│', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', '[', 's', 'c', 'r', 'a', ' ', '[', 's', 'c', 'r', 'a'},
+			{'[', 's', 'c', 'r', 'a', 't', 'c', 'h', ']', ' ', 'a', ' '},
+		})
+
+		// The cursor is shown only in the focused split.
+		cursorCol, cursorRow, visible := s.GetCursor()
+		if !visible || cursorCol != 6 || cursorRow != 0 {
+			t.Errorf("Expected cursor at (6, 0), but got (%d, %d) visible=%t", cursorCol, cursorRow, visible)
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package display
 
 import (
+	"fmt"
+
 	"github.com/gdamore/tcell/v2"
 
 	"github.com/aretext/aretext/file"
@@ -15,7 +17,12 @@ func DrawStatusBar(
 	inputMode state.InputMode,
 	inputBufferString string,
 	isRecordingUserMacro bool,
+	pasteMode bool,
 	filePath string,
+	isNewFile bool,
+	isLoading bool,
+	flagSummary string,
+	ruler string,
 ) {
 	screenWidth, screenHeight := screen.Size()
 	if screenHeight == 0 {
@@ -31,8 +38,50 @@ func DrawStatusBar(
 		inputMode,
 		inputBufferString,
 		isRecordingUserMacro,
-		filePath)
-	drawStringNoWrap(sr, text, 0, 0, style)
+		pasteMode,
+		filePath,
+		isNewFile,
+		isLoading,
+		flagSummary)
+	col := drawStringNoWrap(sr, text, 0, 0, style)
+
+	// Right-align the ruler, but only if there is space after the status bar content.
+	// The ruler is always ASCII, so the number of cells is the same as the number of bytes.
+	rulerCol := screenWidth - len(ruler)
+	if len(ruler) > 0 && rulerCol > col {
+		drawStringNoWrap(sr, ruler, rulerCol, 0, palette.StyleForStatusRuler())
+	}
+}
+
+// FlashStatusBar reverses the colors of the status bar for the visual bell.
+// Call this after drawing the editor, then redraw the editor to end the flash.
+func FlashStatusBar(screen tcell.Screen) {
+	screenWidth, screenHeight := screen.Size()
+	if screenHeight == 0 {
+		return
+	}
+
+	row := screenHeight - 1
+	for col := 0; col < screenWidth; {
+		mainc, combc, style, width := screen.GetContent(col, row)
+		screen.SetContent(col, row, mainc, combc, style.Reverse(true))
+		col += max(width, 1) // Skip the second cell of wide characters.
+	}
+}
+
+// rulerText formats the cursor position and code points for display in the status bar.
+// Returns an empty string if the ruler is disabled.
+func rulerText(buffer *state.BufferState) string {
+	if !buffer.ShowRuler() {
+		return ""
+	}
+
+	ci := buffer.CursorInfo()
+	text := fmt.Sprintf("%d:%d  byte %d", ci.LineNum+1, ci.Col+1, ci.ByteOffset)
+	if len(ci.GraphemeCluster) > 0 {
+		text += "  " + ci.CodePointsString()
+	}
+	return text
 }
 
 func statusBarContent(
@@ -41,7 +90,11 @@ func statusBarContent(
 	inputMode state.InputMode,
 	inputBufferString string,
 	isRecordingUserMacro bool,
+	pasteMode bool,
 	filePath string,
+	isNewFile bool,
+	isLoading bool,
+	flagSummary string,
 ) (string, tcell.Style) {
 	if len(inputBufferString) > 0 {
 		return inputBufferString, palette.StyleForStatusInputBuffer()
@@ -56,14 +109,39 @@ func statusBarContent(
 	}
 
 	switch inputMode {
-	case state.InputModeInsert:
+	case state.InputModeInsert, state.InputModeAutocomplete:
+		if pasteMode {
+			return "-- PASTE --", palette.StyleForStatusInputMode()
+		}
 		return "-- INSERT --", palette.StyleForStatusInputMode()
+	case state.InputModeReplace:
+		return "-- REPLACE --", palette.StyleForStatusInputMode()
 	case state.InputModeVisual:
 		return "-- VISUAL --", palette.StyleForStatusInputMode()
+	case state.InputModeUndoPreview:
+		return "-- UNDO PREVIEW --", palette.StyleForStatusInputMode()
+	case state.InputModeChanges:
+		return "-- CHANGES --", palette.StyleForStatusInputMode()
+	case state.InputModeReplaceConfirm:
+		return "-- CONFIRM REPLACE --", palette.StyleForStatusInputMode()
+	case state.InputModeFileExplorer:
+		return "-- FILE EXPLORER --", palette.StyleForStatusInputMode()
 	case state.InputModeTask:
 		return "Running... press ESC to abort", palette.StyleForStatusInputMode()
 	default:
 		relPath := file.RelativePathCwd(filePath)
+		if isNewFile {
+			// Indicate that the file does not exist on disk yet.
+			relPath += " [new]"
+		}
+		if isLoading {
+			// Indicate that the rest of the file is still loading in the background.
+			relPath += " [loading]"
+		}
+		if flagSummary != "" {
+			// Summarize items flagged for attention, such as TODO comments.
+			relPath += " [" + flagSummary + "]"
+		}
 		return relPath, palette.StyleForStatusFilePath()
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/aretext/aretext/state"
@@ -17,7 +18,12 @@ func TestDrawStatusBar(t *testing.T) {
 		inputMode            state.InputMode
 		inputBufferString    string
 		isRecordingUserMacro bool
+		pasteMode            bool
 		filePath             string
+		isNewFile            bool
+		isLoading            bool
+		flagSummary          string
+		ruler                string
 		expectedContents     [][]rune
 	}{
 		{
@@ -29,6 +35,36 @@ func TestDrawStatusBar(t *testing.T) {
 				{'f', 'o', 'o', '/', 'b', 'a', 'r', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 			},
 		},
+		{
+			name:      "normal mode shows new file indicator",
+			inputMode: state.InputModeNormal,
+			filePath:  "./foo",
+			isNewFile: true,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'f', 'o', 'o', ' ', '[', 'n', 'e', 'w', ']', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:      "normal mode shows loading indicator",
+			inputMode: state.InputModeNormal,
+			filePath:  "./foo",
+			isLoading: true,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'f', 'o', 'o', ' ', '[', 'l', 'o', 'a', 'd', 'i', 'n', 'g', ']', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "normal mode shows flag summary",
+			inputMode:   state.InputModeNormal,
+			filePath:    "./foo",
+			flagSummary: "2 TODO",
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'f', 'o', 'o', ' ', '[', '2', ' ', 'T', 'O', 'D', 'O', ']', ' ', ' ', ' ', ' '},
+			},
+		},
 		{
 			name:      "insert mode shows INSERT",
 			inputMode: state.InputModeInsert,
@@ -38,6 +74,16 @@ func TestDrawStatusBar(t *testing.T) {
 				{'-', '-', ' ', 'I', 'N', 'S', 'E', 'R', 'T', ' ', '-', '-', ' ', ' ', ' ', ' '},
 			},
 		},
+		{
+			name:      "insert mode with paste mode shows PASTE",
+			inputMode: state.InputModeInsert,
+			pasteMode: true,
+			filePath:  "./foo/bar",
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'-', '-', ' ', 'P', 'A', 'S', 'T', 'E', ' ', '-', '-', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
 		{
 			name:      "visual mode shows VISUAL",
 			inputMode: state.InputModeVisual,
@@ -95,6 +141,26 @@ func TestDrawStatusBar(t *testing.T) {
 				{'R', 'e', 'c', 'o', 'r', 'd', 'i', 'n', 'g', ' ', 'm', 'a', 'c', 'r', 'o', '.'},
 			},
 		},
+		{
+			name:      "ruler right-aligned",
+			inputMode: state.InputModeNormal,
+			filePath:  "./foo",
+			ruler:     "1:2  U+0061",
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'f', 'o', 'o', ' ', ' ', '1', ':', '2', ' ', ' ', 'U', '+', '0', '0', '6', '1'},
+			},
+		},
+		{
+			name:      "ruler hidden if not enough space",
+			inputMode: state.InputModeNormal,
+			filePath:  "./foobarbaz",
+			ruler:     "1:2  U+0061",
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'f', 'o', 'o', 'b', 'a', 'r', 'b', 'a', 'z', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -112,7 +178,12 @@ func TestDrawStatusBar(t *testing.T) {
 					tc.inputMode,
 					tc.inputBufferString,
 					tc.isRecordingUserMacro,
+					tc.pasteMode,
 					absFilePath,
+					tc.isNewFile,
+					tc.isLoading,
+					tc.flagSummary,
+					tc.ruler,
 				)
 				s.Sync()
 				assertCellContents(t, s, tc.expectedContents)
@@ -120,3 +191,25 @@ func TestDrawStatusBar(t *testing.T) {
 		})
 	}
 }
+
+func TestFlashStatusBar(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(16, 2)
+		palette := NewPalette()
+		statusMsg := state.StatusMsg{Style: state.StatusMsgStyleError, Text: "error"}
+		DrawStatusBar(s, palette, statusMsg, state.InputModeNormal, "", false, false, "", false, false, "", "")
+		FlashStatusBar(s)
+		s.Sync()
+
+		cells, width, _ := s.GetContents()
+		for col := 0; col < width; col++ {
+			_, _, attrs := cells[width+col].Style.Decompose()
+			assert.True(t, attrs&tcell.AttrReverse != 0, "Expected reversed style at column %d", col)
+		}
+		assert.Equal(t, 'e', cells[width].Runes[0])
+
+		// The rest of the screen is unchanged.
+		_, _, attrs := cells[0].Style.Decompose()
+		assert.False(t, attrs&tcell.AttrReverse != 0)
+	})
+}
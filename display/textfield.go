@@ -25,6 +25,13 @@ func DrawTextField(screen tcell.Screen, palette *Palette, textfield *state.TextF
 	promptText := textfield.PromptText()
 	drawStringNoWrap(sr, promptText, 0, 0, palette.StyleForTextFieldPrompt())
 
+	// Secret input, like a passphrase, is not echoed, so the cursor stays at the start of the row.
+	if textfield.Secret() {
+		sr.ShowCursor(0, 1)
+		drawTextFieldBorder(screen, palette, screenWidth, screenHeight)
+		return
+	}
+
 	// Draw the user input on the second row, with the cursor at the end.
 	col := drawStringNoWrap(sr, textfield.InputText(), 0, 1, palette.StyleForTextFieldInputText())
 
@@ -34,7 +41,11 @@ func DrawTextField(screen tcell.Screen, palette *Palette, textfield *state.TextF
 	// Cursor the end of user input + autocomplete suffix.
 	sr.ShowCursor(col, 1)
 
-	// Draw bottom border, unless it would overlap the status bar in last row.
+	drawTextFieldBorder(screen, palette, screenWidth, screenHeight)
+}
+
+// drawTextFieldBorder draws the bottom border, unless it would overlap the status bar in last row.
+func drawTextFieldBorder(screen tcell.Screen, palette *Palette, screenWidth int, screenHeight int) {
 	if screenHeight > 2 {
 		borderRegion := NewScreenRegion(screen, 0, 2, screenWidth, 1)
 		borderRegion.Fill(tcell.RuneHLine, palette.StyleForTextFieldBorder())
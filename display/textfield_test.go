@@ -114,3 +114,31 @@ func TestDrawTextField(t *testing.T) {
 		})
 	}
 }
+
+func TestDrawSecretTextField(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(15, 4)
+		editorState, err := newEditorStateWithPath("test.txt")
+		require.NoError(t, err)
+		emptyAction := func(_ *state.EditorState, _ string) error { return nil }
+		state.ShowSecretTextField(editorState, "Passphrase:", emptyAction)
+		for _, r := range "secret" {
+			state.AppendRuneToTextField(editorState, r)
+		}
+
+		DrawTextField(s, NewPalette(), editorState.TextField())
+		s.Sync()
+
+		// The input is not echoed, and the cursor stays at the start of the input row.
+		assertCellContents(t, s, [][]rune{
+			{'P', 'a', 's', 's', 'p', 'h', 'r', 'a', 's', 'e', ':', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{'─', '─', '─', '─', '─', '─', '─', '─', '─', '─', '─', '─', '─', '─', '─'},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+		})
+		cursorCol, cursorRow, cursorVisible := s.GetCursor()
+		assert.True(t, cursorVisible)
+		assert.Equal(t, 0, cursorCol)
+		assert.Equal(t, 1, cursorRow)
+	})
+}
@@ -0,0 +1,280 @@
+// Package encryption detects and edits files encrypted with a passphrase.
+//
+// It runs "gpg" to decrypt and encrypt gpg files, so the gpg executable must be installed.
+// The passphrase is passed to gpg through a pipe, so it never appears in the command line.
+// Age files are decrypted and encrypted in the editor's process.
+// In both cases, the decrypted text is kept in memory rather than written to a temporary file.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// Format is the format of an encrypted file.
+type Format int
+
+const (
+	FormatNone = Format(iota)
+	FormatGPG
+	FormatAge
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatNone:
+		return "none"
+	case FormatGPG:
+		return "gpg"
+	case FormatAge:
+		return "age"
+	default:
+		panic("Unrecognized format")
+	}
+}
+
+const (
+	gpgArmorHeader = "-----BEGIN PGP MESSAGE-----"
+	ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+)
+
+// ageVersionLine is the first line of the header of an age file.
+const ageVersionLine = "age-encryption.org/v1"
+
+// scryptWorkFactor is the base-two logarithm of the scrypt work factor used to encrypt age files.
+// This is the same as the age command's default. Tests lower it so they run quickly.
+var scryptWorkFactor = 18
+
+// OpenPGP packet tags for the first packet of an encrypted message (RFC 4880 section 4.3).
+const (
+	pgpTagPublicKeyEncryptedSessionKey    = 1
+	pgpTagSymmetricKeyEncryptedSessionKey = 3
+)
+
+// Detect returns the format of an ASCII-armored encrypted file, or FormatNone if the text is not encrypted.
+// It returns an error if the file is encrypted in a way that cannot be decrypted with a passphrase.
+func Detect(text string) (Format, error) {
+	text = strings.TrimLeft(text, " \t\r\n")
+	switch {
+	case strings.HasPrefix(text, gpgArmorHeader):
+		return FormatGPG, checkGPGSymmetric(text)
+	case strings.HasPrefix(text, ageArmorHeader):
+		return FormatAge, checkAgeScrypt(text)
+	default:
+		return FormatNone, nil
+	}
+}
+
+// checkGPGSymmetric checks that an armored gpg message was encrypted with a passphrase, not a public key.
+// Messages encrypted to a public key are decrypted with a private key managed by gpg-agent, not a passphrase.
+func checkGPGSymmetric(text string) error {
+	tag, ok := firstPGPPacketTag(text)
+	if !ok {
+		return errors.New("Could not read gpg message header")
+	} else if tag == pgpTagPublicKeyEncryptedSessionKey {
+		return errors.New("Files encrypted to a gpg public key are not supported")
+	} else if tag != pgpTagSymmetricKeyEncryptedSessionKey {
+		return errors.New("Unrecognized gpg message format")
+	}
+	return nil
+}
+
+// checkAgeScrypt checks that an armored age file was encrypted with a passphrase, not to a recipient's public key.
+func checkAgeScrypt(text string) error {
+	// Age armor has no headers, so the second line is the start of the base64-encoded file.
+	// Its 48 bytes include the version line and the type of the first recipient stanza.
+	lines := strings.SplitN(text, "\n", 3)
+	if len(lines) < 2 {
+		return errors.New("Could not read age header")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return errors.New("Could not read age header")
+	}
+
+	stanza, ok := strings.CutPrefix(string(data), ageVersionLine+"\n")
+	if !ok {
+		return errors.New("Could not read age header")
+	}
+
+	// Each stanza starts with "-> " followed by its type, like "-> scrypt <salt> <work factor>".
+	fields := strings.Fields(strings.SplitN(stanza, "\n", 2)[0])
+	if len(fields) < 2 || fields[0] != "->" {
+		return errors.New("Could not read age header")
+	} else if fields[1] != "scrypt" {
+		return errors.New("Files encrypted to an age recipient are not supported")
+	}
+	return nil
+}
+
+// firstPGPPacketTag decodes the start of the armored message body and returns the tag of its first packet.
+func firstPGPPacketTag(text string) (int, bool) {
+	lines := strings.Split(text, "\n")
+	i := 1
+
+	// Skip armor headers (like "Version: ..."), which end with a blank line.
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		if !strings.Contains(lines[i], ":") {
+			// Some encoders omit the blank line if there are no headers.
+			break
+		}
+		i++
+	}
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i >= len(lines) {
+		return 0, false
+	}
+
+	// The first line of base64 has more than enough bytes to read the packet header.
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[i]))
+	if err != nil || len(data) == 0 || data[0]&0x80 == 0 {
+		return 0, false
+	}
+
+	b := data[0]
+	if b&0x40 != 0 {
+		// New packet format.
+		return int(b & 0x3F), true
+	}
+	// Old packet format.
+	return int((b >> 2) & 0x0F), true
+}
+
+// Decrypt decrypts a file's contents with a passphrase.
+func Decrypt(ctx context.Context, format Format, ciphertext []byte, passphrase string) ([]byte, error) {
+	switch format {
+	case FormatGPG:
+		return runGPG(ctx, ciphertext, passphrase, "--decrypt")
+	case FormatAge:
+		return decryptAge(ciphertext, passphrase)
+	default:
+		return nil, fmt.Errorf("Cannot decrypt format %s", format)
+	}
+}
+
+// Encrypt encrypts data with a passphrase, producing ASCII-armored output.
+func Encrypt(ctx context.Context, format Format, plaintext []byte, passphrase string) ([]byte, error) {
+	switch format {
+	case FormatGPG:
+		return runGPG(ctx, plaintext, passphrase, "--symmetric", "--armor")
+	case FormatAge:
+		return encryptAge(plaintext, passphrase)
+	default:
+		return nil, fmt.Errorf("Cannot encrypt format %s", format)
+	}
+}
+
+func decryptAge(ciphertext []byte, passphrase string) ([]byte, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("age.NewScryptIdentity: %w", err)
+	}
+
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(ciphertext)), identity)
+	var noMatchErr *age.NoIdentityMatchError
+	if errors.As(err, &noMatchErr) {
+		return nil, errors.New("Incorrect passphrase")
+	} else if err != nil {
+		return nil, fmt.Errorf("age.Decrypt: %w", err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("age.Decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func encryptAge(plaintext []byte, passphrase string) ([]byte, error) {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("age.NewScryptRecipient: %w", err)
+	}
+	recipient.SetWorkFactor(scryptWorkFactor)
+
+	var buf bytes.Buffer
+	armorWriter := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armorWriter, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("age.Encrypt: %w", err)
+	}
+
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("age.Encrypt: %w", err)
+	} else if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("age.Encrypt: %w", err)
+	} else if err := armorWriter.Close(); err != nil {
+		return nil, fmt.Errorf("armor.Writer.Close: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func runGPG(ctx context.Context, input []byte, passphrase string, args ...string) ([]byte, error) {
+	// The passphrase is written to a pipe that the child process reads as file descriptor 3,
+	// the first of cmd.ExtraFiles. Loopback pinentry stops gpg from prompting on the terminal,
+	// and disabling the cache stops gpg-agent from remembering the passphrase.
+	passphraseReader, passphraseWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer passphraseReader.Close()
+	defer passphraseWriter.Close()
+
+	gpgArgs := []string{
+		"--batch",
+		"--quiet",
+		"--yes",
+		"--no-symkey-cache",
+		"--pinentry-mode", "loopback",
+		"--passphrase-fd", "3",
+	}
+	gpgArgs = append(gpgArgs, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gpg", gpgArgs...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.ExtraFiles = []*os.File{passphraseReader}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("gpg: %w", err)
+	}
+
+	// The passphrase is much smaller than the pipe buffer, so this won't block.
+	_, writeErr := passphraseWriter.Write([]byte(passphrase + "\n"))
+	passphraseWriter.Close()
+
+	if err := cmd.Wait(); err != nil {
+		// Gpg explains the failure, like a bad passphrase, in the last line of stderr.
+		// Earlier lines may be unrelated warnings.
+		if msg := lastLine(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, fmt.Errorf("gpg: %w", err)
+	} else if writeErr != nil {
+		return nil, fmt.Errorf("gpg: %w", writeErr)
+	}
+	return stdout.Bytes(), nil
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[i+1:])
+	}
+	return s
+}
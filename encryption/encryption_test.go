@@ -0,0 +1,175 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func armoredMessage(header string, body []byte) string {
+	return header + "\n\n" + base64.StdEncoding.EncodeToString(body) + "\n=abcd\n-----END PGP MESSAGE-----\n"
+}
+
+func ageArmoredFile(header string) string {
+	return ageArmorHeader + "\n" + base64.StdEncoding.EncodeToString([]byte(header)) + "\n-----END AGE ENCRYPTED FILE-----\n"
+}
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		name           string
+		text           string
+		expectedFormat Format
+		expectedErr    string
+	}{
+		{
+			name:           "plain text",
+			text:           "hello world",
+			expectedFormat: FormatNone,
+		},
+		{
+			name:           "empty",
+			text:           "",
+			expectedFormat: FormatNone,
+		},
+		{
+			name:           "gpg symmetric, new packet format",
+			text:           armoredMessage(gpgArmorHeader, []byte{0xC3, 0x0D, 0x04, 0x09}),
+			expectedFormat: FormatGPG,
+		},
+		{
+			name:           "gpg symmetric, old packet format",
+			text:           armoredMessage(gpgArmorHeader, []byte{0x8C, 0x0D, 0x04, 0x09}),
+			expectedFormat: FormatGPG,
+		},
+		{
+			name:           "gpg symmetric with armor headers",
+			text:           gpgArmorHeader + "\nVersion: GnuPG\nComment: test\n\n" + base64.StdEncoding.EncodeToString([]byte{0x8C, 0x0D, 0x04}) + "\n",
+			expectedFormat: FormatGPG,
+		},
+		{
+			name:           "gpg public key",
+			text:           armoredMessage(gpgArmorHeader, []byte{0x84, 0x5E, 0x03}),
+			expectedFormat: FormatGPG,
+			expectedErr:    "Files encrypted to a gpg public key are not supported",
+		},
+		{
+			name:           "gpg invalid body",
+			text:           gpgArmorHeader + "\n\n!!!\n",
+			expectedFormat: FormatGPG,
+			expectedErr:    "Could not read gpg message header",
+		},
+		{
+			name:           "age scrypt",
+			text:           ageArmoredFile("age-encryption.org/v1\n-> scrypt c2FsdA 18\n"),
+			expectedFormat: FormatAge,
+		},
+		{
+			name:           "age recipient",
+			text:           ageArmoredFile("age-encryption.org/v1\n-> X25519 a2V5\n"),
+			expectedFormat: FormatAge,
+			expectedErr:    "Files encrypted to an age recipient are not supported",
+		},
+		{
+			name:           "age invalid version",
+			text:           ageArmoredFile("age-encryption.org/v2\n-> scrypt c2FsdA 18\n"),
+			expectedFormat: FormatAge,
+			expectedErr:    "Could not read age header",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := Detect(tc.text)
+			assert.Equal(t, tc.expectedFormat, format)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func setupGPGTest(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+	// Use an empty home directory so the test doesn't read or modify the user's keyring.
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0700))
+	t.Setenv("GNUPGHOME", dir)
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	setupGPGTest(t)
+	ctx := context.Background()
+
+	ciphertext, err := Encrypt(ctx, FormatGPG, []byte("secret text\n"), "passphrase")
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "secret text")
+
+	format, err := Detect(string(ciphertext))
+	require.NoError(t, err)
+	assert.Equal(t, FormatGPG, format)
+
+	plaintext, err := Decrypt(ctx, format, ciphertext, "passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, "secret text\n", string(plaintext))
+}
+
+func TestDecryptWrongPassphrase(t *testing.T) {
+	setupGPGTest(t)
+	ctx := context.Background()
+
+	ciphertext, err := Encrypt(ctx, FormatGPG, []byte("secret text"), "passphrase")
+	require.NoError(t, err)
+
+	_, err = Decrypt(ctx, FormatGPG, ciphertext, "wrong")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gpg: decryption failed")
+}
+
+func setupAgeTest(t *testing.T) {
+	// Use the minimum work factor so the test doesn't spend seconds deriving keys.
+	defaultWorkFactor := scryptWorkFactor
+	scryptWorkFactor = 1
+	t.Cleanup(func() { scryptWorkFactor = defaultWorkFactor })
+}
+
+func TestEncryptDecryptAgeRoundTrip(t *testing.T) {
+	setupAgeTest(t)
+	ctx := context.Background()
+
+	ciphertext, err := Encrypt(ctx, FormatAge, []byte("secret text\n"), "passphrase")
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "secret text")
+
+	format, err := Detect(string(ciphertext))
+	require.NoError(t, err)
+	assert.Equal(t, FormatAge, format)
+
+	plaintext, err := Decrypt(ctx, format, ciphertext, "passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, "secret text\n", string(plaintext))
+}
+
+func TestDecryptAgeWrongPassphrase(t *testing.T) {
+	setupAgeTest(t)
+	ctx := context.Background()
+
+	ciphertext, err := Encrypt(ctx, FormatAge, []byte("secret text"), "passphrase")
+	require.NoError(t, err)
+
+	_, err = Decrypt(ctx, FormatAge, ciphertext, "wrong")
+	assert.EqualError(t, err, "Incorrect passphrase")
+}
+
+func TestDecryptUnsupportedFormat(t *testing.T) {
+	_, err := Decrypt(context.Background(), FormatNone, []byte("data"), "passphrase")
+	assert.EqualError(t, err, "Cannot decrypt format none")
+}
@@ -0,0 +1,83 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNotAppended indicates that a file changed other than by appending data to the end.
+// For example, this happens if the file was truncated or rewritten.
+var ErrNotAppended = errors.New("File changed other than by appending data")
+
+// LoadAppended reads data appended to a file since the watcher's checksum was calculated.
+// It returns the text to append to the previously loaded document, accounting for the
+// POSIX end-of-file indicator, along with a new watcher for the updated file.
+// If the file was not appended to, it returns ErrNotAppended, and the caller should reload the entire file.
+func LoadAppended(w *Watcher, watcherCfg WatcherConfig) (string, *Watcher, error) {
+	if w.isNewFile || w.path == "" {
+		return "", nil, ErrNotAppended
+	}
+
+	f, err := os.Open(w.path)
+	if err != nil {
+		return "", nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+
+	lastModifiedTime, size, err := lastModifiedTimeAndSize(f)
+	if err != nil {
+		return "", nil, fmt.Errorf("lastModifiedTime: %w", err)
+	}
+
+	if size < w.size {
+		return "", nil, ErrNotAppended
+	}
+
+	// Check that the start of the file matches the previously loaded contents.
+	checksummer := NewChecksummer()
+	var lastByte lastByteWriter
+	n, err := io.Copy(io.MultiWriter(checksummer, &lastByte), io.LimitReader(f, w.size))
+	if err != nil {
+		return "", nil, fmt.Errorf("io.Copy: %w", err)
+	}
+	if n < w.size || checksummer.Checksum() != w.checksum {
+		return "", nil, ErrNotAppended
+	}
+
+	data, err := io.ReadAll(io.TeeReader(f, checksummer))
+	if err != nil {
+		return "", nil, fmt.Errorf("io.ReadAll: %w", err)
+	}
+	if !utf8.Valid(data) {
+		return "", nil, errors.New("invalid UTF-8")
+	}
+
+	// The previously loaded document excluded the POSIX end-of-file indicator,
+	// so add it back before the appended data, then remove the new one.
+	s := string(data)
+	if w.size > 0 && lastByte.b == '\n' {
+		s = "\n" + s
+	}
+	s = strings.TrimSuffix(s, "\n")
+
+	newSize := w.size + int64(len(data))
+	watcher := NewWatcherForExistingFile(watcherCfg, w.path, lastModifiedTime, newSize, checksummer.Checksum())
+	return s, watcher, nil
+}
+
+// lastByteWriter records the last byte written to it.
+type lastByteWriter struct {
+	b byte
+}
+
+// Write implements io.Writer#Write()
+func (w *lastByteWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.b = p[len(p)-1]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,122 @@
+package file
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAppended(t *testing.T) {
+	testCases := []struct {
+		name             string
+		initialContents  string
+		finalContents    string
+		expectedAppended string
+	}{
+		{
+			name:             "no change",
+			initialContents:  "abc\n",
+			finalContents:    "abc\n",
+			expectedAppended: "",
+		},
+		{
+			name:             "append to empty file",
+			initialContents:  "",
+			finalContents:    "abc\n",
+			expectedAppended: "abc",
+		},
+		{
+			name:             "append line after POSIX eof",
+			initialContents:  "abc\n",
+			finalContents:    "abc\ndef\n",
+			expectedAppended: "\ndef",
+		},
+		{
+			name:             "append to line without POSIX eof",
+			initialContents:  "abc",
+			finalContents:    "abcdef",
+			expectedAppended: "def",
+		},
+		{
+			name:             "append line feed only",
+			initialContents:  "abc",
+			finalContents:    "abc\n",
+			expectedAppended: "",
+		},
+		{
+			name:             "append multiple lines",
+			initialContents:  "abc\n",
+			finalContents:    "abc\ndef\nghi\n\n",
+			expectedAppended: "\ndef\nghi\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := createTestFile(t, tc.initialContents)
+			tree, watcher, err := Load(filePath, testWatcherConfig)
+			require.NoError(t, err)
+			defer watcher.Stop()
+
+			err = os.WriteFile(filePath, []byte(tc.finalContents), 0644)
+			require.NoError(t, err)
+
+			appended, newWatcher, err := LoadAppended(watcher, testWatcherConfig)
+			require.NoError(t, err)
+			defer newWatcher.Stop()
+			assert.Equal(t, tc.expectedAppended, appended)
+
+			// Appending to the original tree should produce the same text as reloading the file.
+			reloadedTree, reloadedWatcher, err := Load(filePath, testWatcherConfig)
+			require.NoError(t, err)
+			defer reloadedWatcher.Stop()
+			assert.Equal(t, reloadedTree.String(), tree.String()+appended)
+
+			// The new watcher should have the checksum of the updated file.
+			changed, err := newWatcher.CheckFileContentsChanged()
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
+
+func TestLoadAppendedNotAppended(t *testing.T) {
+	testCases := []struct {
+		name            string
+		initialContents string
+		finalContents   string
+	}{
+		{
+			name:            "truncated",
+			initialContents: "abc\ndef\n",
+			finalContents:   "abc\n",
+		},
+		{
+			name:            "truncated and rewritten",
+			initialContents: "abc\ndef\n",
+			finalContents:   "xyz\ndef\nghi\n",
+		},
+		{
+			name:            "modified before end",
+			initialContents: "abc\n",
+			finalContents:   "abd\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := createTestFile(t, tc.initialContents)
+			_, watcher, err := Load(filePath, testWatcherConfig)
+			require.NoError(t, err)
+			defer watcher.Stop()
+
+			err = os.WriteFile(filePath, []byte(tc.finalContents), 0644)
+			require.NoError(t, err)
+
+			_, _, err = LoadAppended(watcher, testWatcherConfig)
+			assert.ErrorIs(t, err, ErrNotAppended)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+)
+
+// fileAttrs are attributes of an existing file to preserve when saving replaces the file.
+type fileAttrs struct {
+	mode     fs.FileMode // permission bits plus setuid, setgid, and sticky bits.
+	uid, gid int
+	hasOwner bool
+	xattrs   map[string][]byte
+}
+
+// readFileAttrs reads the attributes of the file at path.
+// If the file does not exist, it returns nil.
+func readFileAttrs(path string) (*fileAttrs, error) {
+	fileInfo, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("os.Stat: %w", err)
+	}
+
+	if !fileInfo.Mode().IsRegular() {
+		return nil, nil
+	}
+
+	attrs := &fileAttrs{
+		mode: fileInfo.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky),
+	}
+
+	attrs.uid, attrs.gid, attrs.hasOwner = fileOwner(fileInfo)
+
+	attrs.xattrs, err = readXattrs(path)
+	if err != nil {
+		// Some filesystems do not support extended attributes, so continue without them.
+		log.Printf("Could not read extended attributes for %q: %v\n", path, err)
+	}
+
+	return attrs, nil
+}
+
+// applyTo sets the attributes on a newly written file.
+// Ownership and extended attributes are preserved only if the user has permission to set them,
+// since (for example) only root can give a file to another user.
+func (a *fileAttrs) applyTo(f *os.File) error {
+	// Change ownership before the mode, because chown can clear the setuid and setgid bits.
+	if a.hasOwner {
+		if err := f.Chown(a.uid, a.gid); err != nil {
+			if !errors.Is(err, fs.ErrPermission) {
+				return fmt.Errorf("f.Chown: %w", err)
+			}
+			log.Printf("Could not preserve ownership of %q: %v\n", f.Name(), err)
+		}
+	}
+
+	if err := f.Chmod(a.mode); err != nil {
+		return fmt.Errorf("f.Chmod: %w", err)
+	}
+
+	for name, value := range a.xattrs {
+		if err := setXattr(f, name, value); err != nil {
+			log.Printf("Could not preserve extended attribute %q of %q: %v\n", name, f.Name(), err)
+		}
+	}
+
+	return nil
+}
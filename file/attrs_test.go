@@ -0,0 +1,66 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestSavePreservesModeBits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sh")
+	err := os.WriteFile(path, []byte("test"), 0755)
+	require.NoError(t, err)
+
+	mode := 0750 | os.ModeSetgid
+	err = os.Chmod(path, mode)
+	require.NoError(t, err)
+	fileInfo, err := os.Stat(path)
+	require.NoError(t, err)
+	if fileInfo.Mode()&os.ModeSetgid == 0 {
+		// The OS may clear the setgid bit if the user is not a member of the file's group.
+		t.Skip("Could not set setgid bit")
+	}
+
+	saveAndAssertContents(t, path, "new contents", 0750)
+
+	fileInfo, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, mode, fileInfo.Mode()&(os.ModePerm|os.ModeSetgid|os.ModeSetuid|os.ModeSticky))
+}
+
+func TestSavePreservesOwnership(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Changing file ownership requires root")
+	}
+
+	path := filepath.Join(t.TempDir(), "test.txt")
+	err := os.WriteFile(path, []byte("test"), 0644)
+	require.NoError(t, err)
+	err = os.Chown(path, 1234, 5678)
+	require.NoError(t, err)
+
+	tree, err := text.NewTreeFromString("new contents")
+	require.NoError(t, err)
+	watcher, result, err := Save(path, tree, DefaultTextFormat, false, testWatcherConfig)
+	require.NoError(t, err)
+	defer watcher.Stop()
+	assert.Equal(t, SaveMethodRename, result.Method)
+
+	fileInfo, err := os.Stat(path)
+	require.NoError(t, err)
+	uid, gid, ok := fileOwner(fileInfo)
+	require.True(t, ok)
+	assert.Equal(t, 1234, uid)
+	assert.Equal(t, 5678, gid)
+}
+
+func TestReadFileAttrsNewFile(t *testing.T) {
+	attrs, err := readFileAttrs(filepath.Join(t.TempDir(), "doesnotexist.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, attrs)
+}
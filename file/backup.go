@@ -0,0 +1,154 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBackupsPerFile is the maximum number of backups kept for each file.
+// When a new backup is saved, the oldest backups are deleted.
+const maxBackupsPerFile = 10
+
+// maxBackupSize is the size in bytes of the largest file that will be backed up.
+const maxBackupSize = 16 * 1024 * 1024
+
+const backupSuffix = ".bak"
+
+// Backup is a copy of a file's contents saved before the file was overwritten.
+type Backup struct {
+	Time time.Time
+	path string
+}
+
+// Read returns the contents of the backup.
+// Like Load, this removes the POSIX end-of-file indicator, so the text can be compared to the document.
+func (b Backup) Read() (string, error) {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return "", fmt.Errorf("os.ReadFile: %w", err)
+	}
+	return string(bytes.TrimSuffix(data, []byte{'\n'})), nil
+}
+
+// BackupStore saves copies of files before they are overwritten.
+// Backups for each file are stored in a separate subdirectory.
+type BackupStore struct {
+	dir string
+}
+
+// NewBackupStore returns a store that saves backups in the given directory.
+func NewBackupStore(dir string) *BackupStore {
+	return &BackupStore{dir: dir}
+}
+
+// BackupFile copies the current contents of the file at path into the store.
+// If the file does not exist, is too large, or matches the most recent backup, it does nothing.
+func (s *BackupStore) BackupFile(path string, now time.Time) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("os.Stat: %w", err)
+	} else if !info.Mode().IsRegular() || info.Size() > maxBackupSize {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	backups, err := s.Backups(path)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) > 0 {
+		latest, err := os.ReadFile(backups[0].path)
+		if err == nil && bytes.Equal(latest, data) {
+			return nil
+		}
+	}
+
+	dir, err := s.dirForPath(path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	backupPath := filepath.Join(dir, strconv.FormatInt(now.UnixNano(), 10)+backupSuffix)
+	if err := writeFileViaRename(dir, "backup-tmp-", backupPath, data); err != nil {
+		return err
+	}
+
+	// Delete the oldest backups. The new backup is not included in the list, so keep one fewer.
+	for i := maxBackupsPerFile - 1; i < len(backups); i++ {
+		if err := os.Remove(backups[i].path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("os.Remove: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Backups returns the backups of the file at path, most recent first.
+func (s *BackupStore) Backups(path string) ([]Backup, error) {
+	dir, err := s.dirForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("os.ReadDir: %w", err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), backupSuffix)
+		if !ok {
+			continue
+		}
+
+		nanos, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{
+			Time: time.Unix(0, nanos),
+			path: filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// dirForPath returns the directory storing backups for the file at path.
+func (s *BackupStore) dirForPath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("filepath.Abs: %w", err)
+	}
+	h := sha256.Sum256([]byte(absPath))
+	return filepath.Join(s.dir, hex.EncodeToString(h[:8])), nil
+}
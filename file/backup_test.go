@@ -0,0 +1,70 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupStoreBackupFile(t *testing.T) {
+	store := NewBackupStore(t.TempDir())
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	now := time.Unix(1700000000, 0)
+
+	// Nothing to back up if the file doesn't exist yet.
+	require.NoError(t, store.BackupFile(path, now))
+	backups, err := store.Backups(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(backups))
+
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0644))
+	require.NoError(t, store.BackupFile(path, now))
+
+	// Unchanged contents are not backed up again.
+	require.NoError(t, store.BackupFile(path, now.Add(time.Second)))
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0644))
+	require.NoError(t, store.BackupFile(path, now.Add(2*time.Second)))
+
+	backups, err = store.Backups(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(backups))
+	assert.Equal(t, now.Add(2*time.Second), backups[0].Time)
+	assert.Equal(t, now, backups[1].Time)
+
+	text, err := backups[0].Read()
+	require.NoError(t, err)
+	assert.Equal(t, "second", text)
+
+	text, err = backups[1].Read()
+	require.NoError(t, err)
+	assert.Equal(t, "first", text)
+}
+
+func TestBackupStoreDeletesOldestBackups(t *testing.T) {
+	store := NewBackupStore(t.TempDir())
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < maxBackupsPerFile+3; i++ {
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf("version %d", i)), 0644))
+		require.NoError(t, store.BackupFile(path, now.Add(time.Duration(i)*time.Second)))
+	}
+
+	backups, err := store.Backups(path)
+	require.NoError(t, err)
+	require.Equal(t, maxBackupsPerFile, len(backups))
+
+	text, err := backups[0].Read()
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("version %d", maxBackupsPerFile+2), text)
+
+	text, err = backups[len(backups)-1].Read()
+	require.NoError(t, err)
+	assert.Equal(t, "version 3", text)
+}
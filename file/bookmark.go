@@ -0,0 +1,167 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// maxBookmarkNoteLen is the maximum length in bytes of a bookmark note.
+// Notes are meant to be short reminders, not documents.
+const maxBookmarkNoteLen = 1024
+
+// Bookmark marks a line in a file, with an optional note.
+type Bookmark struct {
+	Path    string `json:"path"`    // Relative to the project directory.
+	LineNum uint64 `json:"lineNum"` // Zero-indexed.
+	Note    string `json:"note,omitempty"`
+}
+
+// AbsPath returns the absolute path of the bookmarked file.
+func (b Bookmark) AbsPath(projectDir string) string {
+	return filepath.Join(projectDir, b.Path)
+}
+
+// BookmarkStore saves bookmarks for each project.
+// A project is identified by its root directory; bookmarks for each project are stored in a separate file.
+type BookmarkStore struct {
+	dir string
+}
+
+// bookmarkData is the contents of a project's bookmark file.
+type bookmarkData struct {
+	ProjectDir string     `json:"projectDir"`
+	Bookmarks  []Bookmark `json:"bookmarks"`
+}
+
+// NewBookmarkStore returns a store that saves bookmarks in the given directory.
+func NewBookmarkStore(dir string) *BookmarkStore {
+	return &BookmarkStore{dir: dir}
+}
+
+// Bookmarks returns the bookmarks in the project, sorted by path and line number.
+func (s *BookmarkStore) Bookmarks(projectDir string) ([]Bookmark, error) {
+	data, err := s.load(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return data.Bookmarks, nil
+}
+
+// BookmarksForPath returns the bookmarks in a file within the project, keyed by line number.
+func (s *BookmarkStore) BookmarksForPath(projectDir string, path string) (map[uint64]Bookmark, error) {
+	relPath, ok := pathInProject(projectDir, path)
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := s.load(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint64]Bookmark)
+	for _, b := range data.Bookmarks {
+		if b.Path == relPath {
+			result[b.LineNum] = b
+		}
+	}
+	return result, nil
+}
+
+// SetBookmark bookmarks a line in a file within the project, replacing the note of any existing bookmark on the line.
+func (s *BookmarkStore) SetBookmark(projectDir string, path string, lineNum uint64, note string) error {
+	if len(note) > maxBookmarkNoteLen {
+		return fmt.Errorf("Bookmark note cannot be longer than %d bytes", maxBookmarkNoteLen)
+	}
+
+	relPath, ok := pathInProject(projectDir, path)
+	if !ok {
+		return errors.New("Cannot bookmark a file outside the working directory")
+	}
+
+	data, err := s.load(projectDir)
+	if err != nil {
+		return err
+	}
+
+	data.removeBookmark(relPath, lineNum)
+	data.Bookmarks = append(data.Bookmarks, Bookmark{Path: relPath, LineNum: lineNum, Note: note})
+	data.sortBookmarks()
+	return s.save(data)
+}
+
+// RemoveBookmark removes the bookmark on a line, if any.
+// It returns whether a bookmark was removed.
+func (s *BookmarkStore) RemoveBookmark(projectDir string, path string, lineNum uint64) (bool, error) {
+	relPath, ok := pathInProject(projectDir, path)
+	if !ok {
+		return false, nil
+	}
+
+	data, err := s.load(projectDir)
+	if err != nil {
+		return false, err
+	}
+
+	if !data.removeBookmark(relPath, lineNum) {
+		return false, nil
+	}
+	return true, s.save(data)
+}
+
+func (d *bookmarkData) removeBookmark(relPath string, lineNum uint64) bool {
+	for i, b := range d.Bookmarks {
+		if b.Path == relPath && b.LineNum == lineNum {
+			d.Bookmarks = append(d.Bookmarks[:i], d.Bookmarks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (d *bookmarkData) sortBookmarks() {
+	sort.Slice(d.Bookmarks, func(i, j int) bool {
+		bi, bj := d.Bookmarks[i], d.Bookmarks[j]
+		if bi.Path != bj.Path {
+			return bi.Path < bj.Path
+		}
+		return bi.LineNum < bj.LineNum
+	})
+}
+
+func (s *BookmarkStore) load(projectDir string) (*bookmarkData, error) {
+	data := &bookmarkData{ProjectDir: projectDir}
+
+	b, err := os.ReadFile(projectDataPath(s.dir, projectDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return data, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	if err := json.Unmarshal(b, data); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	data.sortBookmarks()
+	return data, nil
+}
+
+func (s *BookmarkStore) save(data *bookmarkData) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	path := projectDataPath(s.dir, data.ProjectDir)
+	return writeFileViaRename(s.dir, "bookmarks-tmp-", path, b)
+}
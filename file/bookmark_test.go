@@ -0,0 +1,72 @@
+package file
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookmarkStoreSetAndRemove(t *testing.T) {
+	store := NewBookmarkStore(t.TempDir())
+	projectDir := t.TempDir()
+	fooPath := filepath.Join(projectDir, "foo.txt")
+	barPath := filepath.Join(projectDir, "a", "bar.txt")
+
+	require.NoError(t, store.SetBookmark(projectDir, fooPath, 10, "check this"))
+	require.NoError(t, store.SetBookmark(projectDir, barPath, 2, ""))
+	require.NoError(t, store.SetBookmark(projectDir, fooPath, 3, "first"))
+
+	// Setting a bookmark on the same line replaces the note.
+	require.NoError(t, store.SetBookmark(projectDir, fooPath, 10, "updated"))
+
+	bookmarks, err := store.Bookmarks(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, []Bookmark{
+		{Path: filepath.Join("a", "bar.txt"), LineNum: 2},
+		{Path: "foo.txt", LineNum: 3, Note: "first"},
+		{Path: "foo.txt", LineNum: 10, Note: "updated"},
+	}, bookmarks)
+	assert.Equal(t, fooPath, bookmarks[1].AbsPath(projectDir))
+
+	forPath, err := store.BookmarksForPath(projectDir, fooPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64]Bookmark{
+		3:  {Path: "foo.txt", LineNum: 3, Note: "first"},
+		10: {Path: "foo.txt", LineNum: 10, Note: "updated"},
+	}, forPath)
+
+	removed, err := store.RemoveBookmark(projectDir, fooPath, 3)
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = store.RemoveBookmark(projectDir, fooPath, 3)
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	bookmarks, err = store.Bookmarks(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(bookmarks))
+}
+
+func TestBookmarkStoreOutsideProject(t *testing.T) {
+	store := NewBookmarkStore(t.TempDir())
+	projectDir, otherDir := t.TempDir(), t.TempDir()
+
+	err := store.SetBookmark(projectDir, filepath.Join(otherDir, "foo.txt"), 1, "")
+	assert.EqualError(t, err, "Cannot bookmark a file outside the working directory")
+
+	forPath, err := store.BookmarksForPath(projectDir, filepath.Join(otherDir, "foo.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(forPath))
+}
+
+func TestBookmarkStoreNoteTooLong(t *testing.T) {
+	store := NewBookmarkStore(t.TempDir())
+	projectDir := t.TempDir()
+	note := strings.Repeat("x", maxBookmarkNoteLen+1)
+	err := store.SetBookmark(projectDir, filepath.Join(projectDir, "foo.txt"), 1, note)
+	assert.EqualError(t, err, "Bookmark note cannot be longer than 1024 bytes")
+}
@@ -0,0 +1,210 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+// maxFrecencyEntries is the maximum number of files remembered for each project.
+const maxFrecencyEntries = 1000
+
+// FrecencyStore records how frequently and recently files in each project were opened.
+// A project is identified by its root directory; data for each project is stored in a separate file.
+type FrecencyStore struct {
+	dir string
+}
+
+// frecencyData is the contents of a project's frecency file.
+type frecencyData struct {
+	ProjectDir string                   `json:"projectDir"`
+	Files      map[string]frecencyEntry `json:"files"`
+}
+
+type frecencyEntry struct {
+	Count      int   `json:"count"`
+	LastOpened int64 `json:"lastOpened"` // Unix time in seconds.
+}
+
+// NewFrecencyStore returns a store that saves data in the given directory.
+func NewFrecencyStore(dir string) *FrecencyStore {
+	return &FrecencyStore{dir: dir}
+}
+
+// RecordOpen records that the file at path was opened in the project.
+// Files outside the project directory are ignored.
+func (s *FrecencyStore) RecordOpen(projectDir string, path string, now time.Time) error {
+	relPath, ok := pathInProject(projectDir, path)
+	if !ok {
+		return nil
+	}
+
+	data, err := s.load(projectDir)
+	if err != nil {
+		return err
+	}
+
+	entry := data.Files[relPath]
+	entry.Count++
+	entry.LastOpened = now.Unix()
+	data.Files[relPath] = entry
+
+	if len(data.Files) > maxFrecencyEntries {
+		data.removeLowestScores(len(data.Files)-maxFrecencyEntries, now)
+	}
+
+	return s.save(data)
+}
+
+// Scores returns the frecency score of each file opened in the project, keyed by path relative to the project directory.
+// Files opened more often have higher scores, and each open counts for less as time passes.
+func (s *FrecencyStore) Scores(projectDir string, now time.Time) (map[string]float64, error) {
+	data, err := s.load(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(data.Files))
+	for relPath, entry := range data.Files {
+		scores[relPath] = entry.score(now)
+	}
+	return scores, nil
+}
+
+// score weights the number of opens by how recently the file was last opened.
+func (e frecencyEntry) score(now time.Time) float64 {
+	age := now.Sub(time.Unix(e.LastOpened, 0))
+	var weight float64
+	switch {
+	case age < time.Hour:
+		weight = 4
+	case age < 24*time.Hour:
+		weight = 2
+	case age < 7*24*time.Hour:
+		weight = 0.5
+	default:
+		weight = 0.25
+	}
+	return float64(e.Count) * weight
+}
+
+func (d *frecencyData) removeLowestScores(n int, now time.Time) {
+	relPaths := make([]string, 0, len(d.Files))
+	for relPath := range d.Files {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Slice(relPaths, func(i, j int) bool {
+		si, sj := d.Files[relPaths[i]].score(now), d.Files[relPaths[j]].score(now)
+		if si != sj {
+			return si < sj
+		}
+		return relPaths[i] < relPaths[j]
+	})
+	for _, relPath := range relPaths[:n] {
+		delete(d.Files, relPath)
+	}
+}
+
+func (s *FrecencyStore) load(projectDir string) (*frecencyData, error) {
+	data := &frecencyData{
+		ProjectDir: projectDir,
+		Files:      make(map[string]frecencyEntry),
+	}
+
+	b, err := os.ReadFile(s.pathForProject(projectDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return data, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	if err := json.Unmarshal(b, data); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	if data.Files == nil {
+		data.Files = make(map[string]frecencyEntry)
+	}
+	return data, nil
+}
+
+func (s *FrecencyStore) save(data *frecencyData) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return writeFileViaRename(s.dir, "frecency-tmp-", s.pathForProject(data.ProjectDir), b)
+}
+
+// writeFileViaRename writes data to a temporary file in dir, then renames it to path
+// so that readers never see a partially written file.
+// The temporary file name starts with tmpPrefix.
+func writeFileViaRename(dir string, tmpPrefix string, path string, data []byte) error {
+	tmpFile, err := nondet.CreateTemp(dir, tmpPrefix)
+	if err != nil {
+		return fmt.Errorf("nondet.CreateTemp: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("tmpFile.Write: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("tmpFile.Close: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return nil
+}
+
+// pathForProject returns the path to the file storing data for a project.
+func (s *FrecencyStore) pathForProject(projectDir string) string {
+	return projectDataPath(s.dir, projectDir)
+}
+
+// projectDataPath returns the path to a file in dir storing data for a project.
+func projectDataPath(dir string, projectDir string) string {
+	h := sha256.Sum256([]byte(projectDir))
+	return filepath.Join(dir, hex.EncodeToString(h[:8])+".json")
+}
+
+// IsPathInProject returns whether the path is within the project directory.
+func IsPathInProject(projectDir string, path string) bool {
+	_, ok := pathInProject(projectDir, path)
+	return ok
+}
+
+// pathInProject returns the path relative to the project directory,
+// or false if the path is not within the project directory.
+func pathInProject(projectDir string, path string) (string, bool) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+
+	relPath, err := filepath.Rel(projectDir, path)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return relPath, true
+}
@@ -0,0 +1,93 @@
+package file
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrecencyStoreScores(t *testing.T) {
+	store := NewFrecencyStore(t.TempDir())
+	projectDir := t.TempDir()
+	now := time.Now()
+
+	// Open one file three times a week ago, and another once just now.
+	for i := 0; i < 3; i++ {
+		err := store.RecordOpen(projectDir, filepath.Join(projectDir, "old.txt"), now.Add(-8*24*time.Hour))
+		require.NoError(t, err)
+	}
+	err := store.RecordOpen(projectDir, filepath.Join(projectDir, "a", "new.txt"), now)
+	require.NoError(t, err)
+
+	scores, err := store.Scores(projectDir, now)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{
+		"old.txt":                     0.75,
+		filepath.Join("a", "new.txt"): 4,
+	}, scores)
+}
+
+func TestFrecencyStoreSeparateProjects(t *testing.T) {
+	store := NewFrecencyStore(t.TempDir())
+	projectDir1, projectDir2 := t.TempDir(), t.TempDir()
+	now := time.Now()
+
+	err := store.RecordOpen(projectDir1, filepath.Join(projectDir1, "foo.txt"), now)
+	require.NoError(t, err)
+
+	// Files outside the project are ignored.
+	err = store.RecordOpen(projectDir1, filepath.Join(projectDir2, "bar.txt"), now)
+	require.NoError(t, err)
+
+	scores, err := store.Scores(projectDir1, now)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"foo.txt": 4}, scores)
+
+	scores, err = store.Scores(projectDir2, now)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{}, scores)
+}
+
+func TestFrecencyEntryScoreDecays(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		age           time.Duration
+		expectedScore float64
+	}{
+		{age: time.Minute, expectedScore: 8},
+		{age: 2 * time.Hour, expectedScore: 4},
+		{age: 2 * 24 * time.Hour, expectedScore: 1},
+		{age: 30 * 24 * time.Hour, expectedScore: 0.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.age.String(), func(t *testing.T) {
+			entry := frecencyEntry{Count: 2, LastOpened: now.Add(-tc.age).Unix()}
+			assert.Equal(t, tc.expectedScore, entry.score(now))
+		})
+	}
+}
+
+func TestFrecencyDataRemoveLowestScores(t *testing.T) {
+	now := time.Now()
+	data := &frecencyData{
+		Files: map[string]frecencyEntry{
+			"a": {Count: 1, LastOpened: now.Unix()},
+			"b": {Count: 5, LastOpened: now.Unix()},
+			"c": {Count: 1, LastOpened: now.Add(-30 * 24 * time.Hour).Unix()},
+		},
+	}
+	data.removeLowestScores(2, now)
+	assert.Equal(t, []string{"b"}, keys(data.Files))
+}
+
+func keys(m map[string]frecencyEntry) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -1,18 +1,20 @@
 package file
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"time"
+	"unicode/utf8"
 
 	"github.com/aretext/aretext/text"
 )
 
 // Load reads a file from disk and starts a watcher to detect changes.
 // This will remove the POSIX end-of-file indicator (line feed at end of file).
-func Load(path string, watcherPollInterval time.Duration) (*text.Tree, *Watcher, error) {
+func Load(path string, watcherCfg WatcherConfig) (*text.Tree, *Watcher, error) {
 	path, err := filepath.Abs(path)
 	if err != nil {
 		return nil, nil, fmt.Errorf("filepath.Abs: %w", err)
@@ -38,11 +40,48 @@ func Load(path string, watcherPollInterval time.Duration) (*text.Tree, *Watcher,
 	// We remove it from the tree to simplify editor operations; we'll add it back when saving the file.
 	removePosixEof(tree)
 
-	watcher := NewWatcherForExistingFile(watcherPollInterval, path, lastModifiedTime, size, checksum)
+	watcher := NewWatcherForExistingFile(watcherCfg, path, lastModifiedTime, size, checksum)
 
 	return tree, watcher, nil
 }
 
+// LoadPreview reads up to maxBytes from the start of a file.
+// The preview ends at the last complete line, if any, so it is a prefix of the text returned by Load.
+// This allows the editor to display the start of a file that takes a long time to load.
+func LoadPreview(path string, maxBytes int) (*text.Tree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, maxBytes)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("io.ReadFull: %w", err)
+	}
+	buf = buf[:n]
+
+	if n < maxBytes {
+		// Read the entire file, so exclude only the POSIX end-of-file indicator.
+		buf = bytes.TrimSuffix(buf, []byte{'\n'})
+	} else if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		// Exclude the line feed, since it might be the POSIX end-of-file indicator.
+		buf = buf[:i]
+	} else {
+		// Avoid splitting a multi-byte UTF-8 sequence at the end of the buffer.
+		for len(buf) > 0 && !utf8.Valid(buf) {
+			buf = buf[:len(buf)-1]
+		}
+	}
+
+	tree, err := text.NewTreeFromString(string(buf))
+	if err != nil {
+		return nil, fmt.Errorf("text.NewTreeFromString: %w", err)
+	}
+	return tree, nil
+}
+
 func readContentsAndChecksum(f *os.File) (*text.Tree, string, error) {
 	checksummer := NewChecksummer()
 	r := io.TeeReader(f, checksummer)
@@ -2,7 +2,6 @@ package file
 
 import (
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -35,7 +34,7 @@ func TestLoad(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			filePath := createTestFile(t, tc.fileContents)
 
-			tree, watcher, err := Load(filePath, time.Second)
+			tree, watcher, err := Load(filePath, DefaultWatcherConfig)
 			require.NoError(t, err)
 			defer watcher.Stop()
 
@@ -43,3 +42,58 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadPreview(t *testing.T) {
+	testCases := []struct {
+		name            string
+		fileContents    string
+		maxBytes        int
+		expectedPreview string
+	}{
+		{
+			name:            "empty",
+			fileContents:    "",
+			maxBytes:        10,
+			expectedPreview: "",
+		},
+		{
+			name:            "shorter than max bytes",
+			fileContents:    "ab\ncd",
+			maxBytes:        10,
+			expectedPreview: "ab\ncd",
+		},
+		{
+			name:            "end at last complete line",
+			fileContents:    "ab\ncd\nefgh\n",
+			maxBytes:        8,
+			expectedPreview: "ab\ncd",
+		},
+		{
+			name:            "POSIX eof",
+			fileContents:    "abcd\n",
+			maxBytes:        10,
+			expectedPreview: "abcd",
+		},
+		{
+			name:            "no line feed",
+			fileContents:    "abcdefgh",
+			maxBytes:        4,
+			expectedPreview: "abcd",
+		},
+		{
+			name:            "no line feed, split multi-byte character",
+			fileContents:    "abécd",
+			maxBytes:        3,
+			expectedPreview: "ab",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := createTestFile(t, tc.fileContents)
+			tree, err := LoadPreview(filePath, tc.maxBytes)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPreview, tree.String())
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LockStore records which files have unsaved changes in each aretext instance,
+// so an instance can warn the user before overwriting changes made in another instance.
+// Each lock is a file named by a hash of the locked path and the process ID of the instance.
+// Locks are advisory: they don't prevent writing the file, and locks left by instances that exited are ignored.
+type LockStore struct {
+	dir string
+	pid int
+}
+
+// NewLockStore returns a store that saves locks for the process in the given directory,
+// usually $XDG_RUNTIME_DIR/aretext/locks.
+func NewLockStore(dir string, pid int) *LockStore {
+	return &LockStore{dir: dir, pid: pid}
+}
+
+// Lock records that this process has unsaved changes to the file at path.
+func (s *LockStore) Lock(path string) error {
+	absPath, prefix, err := s.lockPrefixForPath(path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	lockPath := filepath.Join(s.dir, prefix+strconv.Itoa(s.pid))
+	return writeFileViaRename(s.dir, "lock-*.tmp", lockPath, []byte(absPath))
+}
+
+// Unlock removes the lock on the file at path, if this process holds one.
+func (s *LockStore) Unlock(path string) error {
+	_, prefix, err := s.lockPrefixForPath(path)
+	if err != nil {
+		return err
+	}
+
+	lockPath := filepath.Join(s.dir, prefix+strconv.Itoa(s.pid))
+	if err := os.Remove(lockPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("os.Remove: %w", err)
+	}
+	return nil
+}
+
+// OtherLockPids returns the process IDs of other running instances with unsaved changes to the file at path, in ascending order.
+// Locks from processes that are no longer running are deleted.
+func (s *LockStore) OtherLockPids(path string) ([]int, error) {
+	absPath, prefix, err := s.lockPrefixForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("os.ReadDir: %w", err)
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		pidStr, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok {
+			continue
+		}
+
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil || pid == s.pid {
+			continue
+		}
+
+		lockPath := filepath.Join(s.dir, entry.Name())
+		if !processExists(pid) {
+			// The instance exited without removing its lock, probably because it crashed.
+			if err := os.Remove(lockPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("os.Remove: %w", err)
+			}
+			continue
+		}
+
+		// Check the path in case two paths have the same hash.
+		data, err := os.ReadFile(lockPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("os.ReadFile: %w", err)
+		} else if string(data) != absPath {
+			continue
+		}
+
+		pids = append(pids, pid)
+	}
+
+	sort.Ints(pids)
+	return pids, nil
+}
+
+// lockPrefixForPath returns the absolute path and the prefix of the lock file names for the file at path.
+func (s *LockStore) lockPrefixForPath(path string) (string, string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", fmt.Errorf("filepath.Abs: %w", err)
+	}
+	h := sha256.Sum256([]byte(absPath))
+	return absPath, hex.EncodeToString(h[:8]) + ".", nil
+}
@@ -0,0 +1,61 @@
+package file
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	store := NewLockStore(dir, os.Getpid())
+	otherStore := NewLockStore(dir, os.Getppid())
+
+	// A process doesn't report its own lock.
+	require.NoError(t, store.Lock(path))
+	pids, err := store.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(pids))
+
+	pids, err = otherStore.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, []int{os.Getpid()}, pids)
+
+	// Locks on other paths are ignored.
+	pids, err = otherStore.OtherLockPids(filepath.Join(filepath.Dir(path), "bar.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(pids))
+
+	require.NoError(t, store.Unlock(path))
+	pids, err = otherStore.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(pids))
+
+	// Unlocking a file that isn't locked does nothing.
+	require.NoError(t, store.Unlock(path))
+}
+
+func TestLockStoreRemovesLocksFromExitedProcess(t *testing.T) {
+	// Run a process that exits immediately, so its process ID no longer exists.
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+
+	dir := t.TempDir()
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	exitedStore := NewLockStore(dir, cmd.Process.Pid)
+	require.NoError(t, exitedStore.Lock(path))
+
+	store := NewLockStore(dir, os.Getpid())
+	pids, err := store.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(pids))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(entries))
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package file
+
+import "os"
+
+// processExists returns whether a process with the given ID is running.
+// On Windows, finding the process fails if it has exited. On other platforms,
+// finding a process always succeeds, so every process is assumed to be running.
+func processExists(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package file
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processExists returns whether a process with the given ID is running.
+// Sending signal zero checks the process without affecting it, and fails with EPERM
+// if the process exists but belongs to another user.
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -1,137 +1,232 @@
 package file
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
-	"syscall"
-	"time"
-
-	"github.com/google/renameio/v2"
 
 	"github.com/aretext/aretext/text"
 )
 
 const defaultPermForNewFile fs.FileMode = 0644
 
+// maxSymlinkDepth is the maximum number of symlinks to follow when resolving the target of a save.
+const maxSymlinkDepth = 40
+
+// SaveMethod describes how a file was written to disk.
+type SaveMethod int
+
+const (
+	// SaveMethodRename writes a temporary file, then atomically renames it over the target file.
+	SaveMethodRename = SaveMethod(iota)
+
+	// SaveMethodInPlace truncates and overwrites the target file.
+	// This is used for files with multiple hard links, since renaming a new file
+	// over the target would silently break the links.
+	SaveMethodInPlace
+)
+
+// SaveResult describes what happened when saving a file.
+type SaveResult struct {
+	// Method is how the file was written.
+	Method SaveMethod
+
+	// TargetPath is the path of the file that was written.
+	// If the saved path is a symlink, this is the path of the symlink's target,
+	// unless the symlink was replaced.
+	TargetPath string
+
+	// ReplacedSymlink is true if the saved path was a symlink that was replaced by a regular file.
+	ReplacedSymlink bool
+}
+
 // Save writes the text to disk and starts a new watcher to detect subsequent changes.
-// This adds the POSIX end-of-file indicator (line feed at the end of the file).
-func Save(path string, tree *text.Tree, watcherPollInterval time.Duration) (*Watcher, error) {
+// The format controls whether line feeds are written as CRLF and whether to add
+// the POSIX end-of-file indicator (line ending at the end of the file).
+// If the path is a symlink, this writes to the symlink's target, preserving the link,
+// unless replaceSymlink is true, in which case the symlink is replaced by a regular file.
+func Save(path string, tree *text.Tree, format TextFormat, replaceSymlink bool, watcherCfg WatcherConfig) (*Watcher, SaveResult, error) {
+	return SaveWithFilter(path, tree, format, replaceSymlink, watcherCfg, nil)
+}
+
+// SaveFilter transforms the bytes of a file before they are written to disk.
+// If it returns an error, the save is aborted and the file is left unchanged.
+type SaveFilter func(data []byte) ([]byte, error)
+
+// SaveWithFilter is like Save, except that the filter (if not nil) transforms the bytes before they are written.
+// The filter receives the text after line feeds are converted to CRLF and the POSIX end-of-file indicator is added.
+// The new watcher's checksum covers the filtered bytes, since those are the bytes on disk.
+func SaveWithFilter(path string, tree *text.Tree, format TextFormat, replaceSymlink bool, watcherCfg WatcherConfig, filter SaveFilter) (*Watcher, SaveResult, error) {
 	// Compose a reader that calculates the checksum and appends the POSIX EOF indicator.
 	checksummer := NewChecksummer()
-	textReader := tree.ReaderAtPosition(0)
-	posixEofReader := strings.NewReader("\n")
-	r := io.TeeReader(io.MultiReader(&textReader, posixEofReader), checksummer)
+	var textReader io.Reader
+	if format.CRLF {
+		s := tree.String()
+		if format.FinalNewline {
+			s += "\n"
+		}
+		textReader = strings.NewReader(crlfLineEndings(s))
+	} else {
+		treeReader := tree.ReaderAtPosition(0)
+		textReader = &treeReader
+		if format.FinalNewline {
+			textReader = io.MultiReader(textReader, strings.NewReader("\n"))
+		}
+	}
+	if filter != nil {
+		data, err := io.ReadAll(textReader)
+		if err != nil {
+			return nil, SaveResult{}, err
+		}
+		data, err = filter(data)
+		if err != nil {
+			return nil, SaveResult{}, err
+		}
+		textReader = bytes.NewReader(data)
+	}
+	r := io.TeeReader(textReader, checksummer)
 
-	// Check if the path is a hardlink. If so, we need to save directly to this path
-	// (not tmpfile / rename) to avoid changing the inode.
-	isHardLink, err := checkIfPathIsHardLink(path)
+	result, err := saveResultForPath(path, replaceSymlink)
 	if err != nil {
-		return nil, err
+		return nil, SaveResult{}, err
 	}
 
 	// Save the file.
-	if isHardLink {
-		err = saveDirectly(path, r)
+	log.Printf("Saving file at target path %q using %s\n", result.TargetPath, result.Method)
+	if result.Method == SaveMethodInPlace {
+		err = saveDirectly(result.TargetPath, r)
 	} else {
-		err = saveWithTmpFileRename(path, r)
+		err = saveWithTmpFileRename(result.TargetPath, r)
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, SaveResult{}, err
 	}
 
 	// Start a new watcher for subsequent changes to the file.
 	fileInfo, err := os.Stat(path)
 	if err != nil {
-		return nil, fmt.Errorf("os.Stat: %w", err)
+		return nil, SaveResult{}, fmt.Errorf("os.Stat: %w", err)
 	}
-	watcher := NewWatcherForExistingFile(watcherPollInterval, path, fileInfo.ModTime(), fileInfo.Size(), checksummer.Checksum())
+	watcher := NewWatcherForExistingFile(watcherCfg, path, fileInfo.ModTime(), fileInfo.Size(), checksummer.Checksum())
 
-	return watcher, nil
+	return watcher, result, nil
 }
 
-func saveDirectly(path string, r io.Reader) error {
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, defaultPermForNewFile)
-	if err != nil {
-		return fmt.Errorf("os.OpenFile: %w", err)
+// String returns a description of the save method for logging.
+func (m SaveMethod) String() string {
+	switch m {
+	case SaveMethodRename:
+		return "tmpfile rename"
+	case SaveMethodInPlace:
+		return "in-place write"
+	default:
+		return "unknown"
 	}
-	defer f.Close()
+}
 
-	// Write to the file.
-	_, err = io.Copy(f, r)
+// saveResultForPath decides which file to write and how to write it.
+func saveResultForPath(path string, replaceSymlink bool) (SaveResult, error) {
+	isSymlink, err := checkIfPathIsSymlink(path)
 	if err != nil {
-		return fmt.Errorf("io.Copy: %w", err)
+		return SaveResult{}, err
 	}
 
-	// Sync the file to disk so the watcher calculates the checksum correctly later.
-	err = f.Sync()
-	if err != nil {
-		return fmt.Errorf("file.Sync: %w", err)
+	if isSymlink && replaceSymlink {
+		// Replacing the symlink never modifies the target file,
+		// so there is no risk of breaking hard links to the target.
+		log.Printf("Replacing symlink %q with a regular file\n", path)
+		return SaveResult{
+			Method:          SaveMethodRename,
+			TargetPath:      path,
+			ReplacedSymlink: true,
+		}, nil
 	}
 
-	return nil
-}
-
-func saveWithTmpFileRename(path string, r io.Reader) error {
 	// If the path is a symlink, this will return the symlink target so we save
 	// over the target file instead of overwriting the symlink itself.
 	targetPath, err := targetPathForSave(path)
 	if err != nil {
-		return err
+		return SaveResult{}, err
 	}
-	log.Printf("Saving file at target path %s", targetPath)
 
-	// Use renameio to write the file to a temporary directory, then rename it to the target file.
-	// This should reduce the risk of data corruption if the editor crashes mid-write,
-	// but is probably not 100% reliable (see http://danluu.com/deconstruct-files/).
-	// There is a good discussion of the Go libraries solving this problem in
-	// this GitHub issue comment: https://github.com/golang/go/issues/22397#issuecomment-380831736
-	pf, err := renameio.NewPendingFile(targetPath, renameio.WithPermissions(defaultPermForNewFile), renameio.WithExistingPermissions())
+	// Check if the path is a hardlink. If so, we need to save directly to this path
+	// (not tmpfile / rename) to avoid changing the inode.
+	isHardLink, err := checkIfPathIsHardLink(targetPath)
 	if err != nil {
-		return fmt.Errorf("renamio.TempFile: %w", err)
+		return SaveResult{}, err
+	}
+
+	method := SaveMethodRename
+	if isHardLink {
+		log.Printf("File %q has multiple hard links, so it will be overwritten in place\n", targetPath)
+		method = SaveMethodInPlace
 	}
-	defer pf.Cleanup()
+
+	return SaveResult{Method: method, TargetPath: targetPath}, nil
+}
+
+func saveDirectly(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, defaultPermForNewFile)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+	defer f.Close()
 
 	// Write to the file.
-	_, err = io.Copy(pf, r)
+	_, err = io.Copy(f, r)
 	if err != nil {
 		return fmt.Errorf("io.Copy: %w", err)
 	}
 
 	// Sync the file to disk so the watcher calculates the checksum correctly later.
-	err = pf.CloseAtomicallyReplace()
+	err = f.Sync()
 	if err != nil {
-		return fmt.Errorf("renamio.CloseAtomicallyReplace: %w", err)
+		return fmt.Errorf("file.Sync: %w", err)
 	}
 
 	return nil
 }
 
 func targetPathForSave(path string) (string, error) {
-	fileInfo, err := os.Lstat(path)
-	if os.IsNotExist(err) {
-		// New file, return the original path.
-		return path, nil
-	} else if err != nil {
-		return "", fmt.Errorf("os.Lstat: %w", err)
-	}
+	// Follow chains of symlinks, up to a limit to avoid infinite loops.
+	for i := 0; i < maxSymlinkDepth; i++ {
+		isSymlink, err := checkIfPathIsSymlink(path)
+		if err != nil {
+			return "", err
+		} else if !isSymlink {
+			return path, nil
+		}
 
-	if fileInfo.Mode()&os.ModeSymlink == 0 {
-		// Not a symlink, so return the original path.
-		return path, nil
-	} else {
 		// Symlink, so lookup the target.
+		// Relative targets are interpreted relative to the directory containing the symlink.
 		target, err := os.Readlink(path)
 		if err != nil {
 			return "", fmt.Errorf("os.Readlink: %w", err)
 		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
 		log.Printf("Resolved symlink target %s -> %s", path, target)
-		return target, nil
+		path = target
 	}
+
+	return "", fmt.Errorf("Too many levels of symbolic links")
+}
+
+func checkIfPathIsSymlink(path string) (bool, error) {
+	fileInfo, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return false, nil // new file
+	} else if err != nil {
+		return false, fmt.Errorf("os.Lstat: %w", err)
+	}
+	return fileInfo.Mode()&os.ModeSymlink != 0, nil
 }
 
 func checkIfPathIsHardLink(path string) (bool, error) {
@@ -142,11 +237,5 @@ func checkIfPathIsHardLink(path string) (bool, error) {
 		return false, fmt.Errorf("os.Stat: %w", err)
 	}
 
-	if sys := fileInfo.Sys(); sys != nil {
-		if stat, ok := sys.(*syscall.Stat_t); ok {
-			return stat.Nlink > 1, nil
-		}
-	}
-
-	return false, nil
+	return numHardLinks(fileInfo) > 1, nil
 }
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package file
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/google/renameio/v2"
+)
+
+func saveWithTmpFileRename(targetPath string, r io.Reader) error {
+	// Use renameio to write the file to a temporary directory, then rename it to the target file.
+	// This should reduce the risk of data corruption if the editor crashes mid-write,
+	// but is probably not 100% reliable (see http://danluu.com/deconstruct-files/).
+	// There is a good discussion of the Go libraries solving this problem in
+	// this GitHub issue comment: https://github.com/golang/go/issues/22397#issuecomment-380831736
+	// The renamed file replaces the original, so copy the original file's mode bits,
+	// ownership, and extended attributes (such as an SELinux security context).
+	attrs, err := readFileAttrs(targetPath)
+	if err != nil {
+		return err
+	}
+
+	pf, err := renameio.NewPendingFile(targetPath, renameio.WithPermissions(defaultPermForNewFile), renameio.WithExistingPermissions())
+	if err != nil {
+		return fmt.Errorf("renamio.TempFile: %w", err)
+	}
+	defer pf.Cleanup()
+
+	// Write to the file.
+	_, err = io.Copy(pf, r)
+	if err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+
+	if attrs != nil {
+		if err := attrs.applyTo(pf.File); err != nil {
+			return err
+		}
+	}
+
+	// Sync the file to disk so the watcher calculates the checksum correctly later.
+	err = pf.CloseAtomicallyReplace()
+	if err != nil {
+		return fmt.Errorf("renamio.CloseAtomicallyReplace: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build windows
+
+package file
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+func saveWithTmpFileRename(targetPath string, r io.Reader) error {
+	// renameio does not support Windows, so write a temporary file in the same directory,
+	// then rename it to the target file. On Windows, os.Rename replaces an existing file.
+	attrs, err := readFileAttrs(targetPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := nondet.CreateTemp(filepath.Dir(targetPath), "."+filepath.Base(targetPath))
+	if err != nil {
+		return fmt.Errorf("nondet.CreateTemp: %w", err)
+	}
+	tmpPath := f.Name()
+	defer func() {
+		if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Could not remove temporary file %q: %v\n", tmpPath, err)
+		}
+	}()
+	defer f.Close()
+
+	// Write to the file.
+	_, err = io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+
+	if attrs != nil {
+		if err := attrs.applyTo(f); err != nil {
+			return err
+		}
+	}
+
+	// Sync the file to disk so the watcher calculates the checksum correctly later.
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("file.Sync: %w", err)
+	}
+
+	// Windows cannot rename a file that is still open.
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("file.Close: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, targetPath); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return nil
+}
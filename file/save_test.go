@@ -1,6 +1,8 @@
 package file
 
 import (
+	"bytes"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -87,11 +89,137 @@ func TestSavePathToHardLink(t *testing.T) {
 	assert.Equal(t, "new contents\n", string(fileBytes))
 }
 
+func TestSavePathToRelativeSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	targetPath := filepath.Join(tmpDir, "test.txt")
+	symlinkDir := filepath.Join(tmpDir, "subdir")
+	symlinkPath := filepath.Join(symlinkDir, "testsymlink")
+
+	err := os.WriteFile(targetPath, []byte("test"), 0644)
+	require.NoError(t, err)
+	err = os.Mkdir(symlinkDir, 0755)
+	require.NoError(t, err)
+
+	// The symlink target is relative to the directory containing the symlink.
+	err = os.Symlink(filepath.Join("..", "test.txt"), symlinkPath)
+	require.NoError(t, err)
+
+	tree, err := text.NewTreeFromString("new contents")
+	require.NoError(t, err)
+	watcher, result, err := Save(symlinkPath, tree, DefaultTextFormat, false, testWatcherConfig)
+	require.NoError(t, err)
+	defer watcher.Stop()
+	assert.Equal(t, targetPath, result.TargetPath)
+	assert.Equal(t, SaveMethodRename, result.Method)
+	assert.False(t, result.ReplacedSymlink)
+
+	fileBytes, err := os.ReadFile(targetPath)
+	require.NoError(t, err)
+	assert.Equal(t, "new contents\n", string(fileBytes))
+}
+
+func TestSaveReplaceSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	targetPath := filepath.Join(tmpDir, "test.txt")
+	symlinkPath := filepath.Join(tmpDir, "testsymlink")
+
+	err := os.WriteFile(targetPath, []byte("test"), 0644)
+	require.NoError(t, err)
+	err = os.Symlink(targetPath, symlinkPath)
+	require.NoError(t, err)
+
+	tree, err := text.NewTreeFromString("new contents")
+	require.NoError(t, err)
+	watcher, result, err := Save(symlinkPath, tree, DefaultTextFormat, true, testWatcherConfig)
+	require.NoError(t, err)
+	defer watcher.Stop()
+	assert.Equal(t, symlinkPath, result.TargetPath)
+	assert.True(t, result.ReplacedSymlink)
+
+	// Verify that the symlink was replaced by a regular file.
+	fileInfo, err := os.Lstat(symlinkPath)
+	require.NoError(t, err)
+	assert.True(t, fileInfo.Mode().IsRegular())
+	fileBytes, err := os.ReadFile(symlinkPath)
+	require.NoError(t, err)
+	assert.Equal(t, "new contents\n", string(fileBytes))
+
+	// Verify that the original target was not modified.
+	fileBytes, err = os.ReadFile(targetPath)
+	require.NoError(t, err)
+	assert.Equal(t, "test", string(fileBytes))
+}
+
+func TestSaveSymlinkToHardLink(t *testing.T) {
+	tmpDir := t.TempDir()
+	targetPath := filepath.Join(tmpDir, "test.txt")
+	hardlinkPath := filepath.Join(tmpDir, "testhardlink")
+	symlinkPath := filepath.Join(tmpDir, "testsymlink")
+
+	err := os.WriteFile(targetPath, []byte("test"), 0644)
+	require.NoError(t, err)
+	err = os.Link(targetPath, hardlinkPath)
+	require.NoError(t, err)
+	err = os.Symlink(targetPath, symlinkPath)
+	require.NoError(t, err)
+
+	tree, err := text.NewTreeFromString("new contents")
+	require.NoError(t, err)
+	watcher, result, err := Save(symlinkPath, tree, DefaultTextFormat, false, testWatcherConfig)
+	require.NoError(t, err)
+	defer watcher.Stop()
+	assert.Equal(t, targetPath, result.TargetPath)
+	assert.Equal(t, SaveMethodInPlace, result.Method)
+
+	// Verify that the hard link still refers to the same file.
+	fileBytes, err := os.ReadFile(hardlinkPath)
+	require.NoError(t, err)
+	assert.Equal(t, "new contents\n", string(fileBytes))
+}
+
+func TestSaveWithFilter(t *testing.T) {
+	path := createTestFile(t, "old contents")
+	tree, err := text.NewTreeFromString("abc")
+	require.NoError(t, err)
+
+	filter := func(data []byte) ([]byte, error) {
+		return bytes.ToUpper(data), nil
+	}
+	watcher, _, err := SaveWithFilter(path, tree, DefaultTextFormat, false, testWatcherConfig, filter)
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	fileBytes, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ABC\n", string(fileBytes))
+
+	// The watcher's checksum matches the filtered bytes on disk, so the file is not reported as changed.
+	changed, err := watcher.CheckFileContentsChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestSaveWithFilterError(t *testing.T) {
+	path := createTestFile(t, "old contents")
+	tree, err := text.NewTreeFromString("new contents")
+	require.NoError(t, err)
+
+	filter := func(data []byte) ([]byte, error) {
+		return nil, errors.New("vetoed")
+	}
+	_, _, err = SaveWithFilter(path, tree, DefaultTextFormat, false, testWatcherConfig, filter)
+	assert.EqualError(t, err, "vetoed")
+
+	fileBytes, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old contents", string(fileBytes))
+}
+
 func saveAndAssertContents(t *testing.T, path string, contents string, perms os.FileMode) {
 	tree, err := text.NewTreeFromString(contents)
 	require.NoError(t, err)
 
-	watcher, err := Save(path, tree, testWatcherPollInterval)
+	watcher, _, err := Save(path, tree, DefaultTextFormat, false, testWatcherConfig)
 	require.NoError(t, err)
 	assert.Equal(t, path, watcher.Path())
 	defer watcher.Stop()
@@ -0,0 +1,109 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const scratchSuffix = ".txt"
+
+var validScratchNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidateScratchName returns an error if the name cannot be used for a scratch buffer.
+// Names become file names in the store, so they may contain only letters, digits, underscores, and hyphens.
+func ValidateScratchName(name string) error {
+	if name == "" {
+		return errors.New("Scratch buffer name is empty")
+	} else if !validScratchNameRegexp.MatchString(name) {
+		return fmt.Errorf("Invalid scratch buffer name %q: use only letters, digits, underscores, and hyphens", name)
+	}
+	return nil
+}
+
+// ScratchStore saves the contents of named scratch buffers between sessions.
+// Each scratch buffer is stored in a separate file.
+type ScratchStore struct {
+	dir string
+}
+
+// NewScratchStore returns a store that saves scratch buffers in the given directory.
+func NewScratchStore(dir string) *ScratchStore {
+	return &ScratchStore{dir: dir}
+}
+
+// Names returns the names of the scratch buffers in the store, sorted alphabetically.
+func (s *ScratchStore) Names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("os.ReadDir: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), scratchSuffix)
+		if ok && ValidateScratchName(name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load returns the contents of a scratch buffer.
+// Like Load, this removes the POSIX end-of-file indicator.
+// If the store has no scratch buffer with the name, the error wraps fs.ErrNotExist.
+func (s *ScratchStore) Load(name string) (string, error) {
+	path, err := s.pathForName(name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("os.ReadFile: %w", err)
+	}
+	return string(bytes.TrimSuffix(data, []byte{'\n'})), nil
+}
+
+// Save replaces the contents of a scratch buffer, creating the directory if necessary.
+func (s *ScratchStore) Save(name string, text string) error {
+	path, err := s.pathForName(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	return writeFileViaRename(s.dir, "scratch-tmp-", path, []byte(text+"\n"))
+}
+
+// Delete removes a scratch buffer from the store. It does nothing if the scratch buffer does not exist.
+func (s *ScratchStore) Delete(name string) error {
+	path, err := s.pathForName(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("os.Remove: %w", err)
+	}
+	return nil
+}
+
+func (s *ScratchStore) pathForName(name string) (string, error) {
+	if err := ValidateScratchName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, name+scratchSuffix), nil
+}
@@ -0,0 +1,62 @@
+package file
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScratchStore(t *testing.T) {
+	store := NewScratchStore(t.TempDir())
+
+	names, err := store.Names()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(names))
+
+	_, err = store.Load("notes")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, store.Save("notes", "foo\nbar"))
+	require.NoError(t, store.Save("todo", ""))
+
+	names, err = store.Names()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes", "todo"}, names)
+
+	text, err := store.Load("notes")
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar", text)
+
+	require.NoError(t, store.Delete("notes"))
+	require.NoError(t, store.Delete("notes"))
+
+	names, err = store.Names()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"todo"}, names)
+}
+
+func TestValidateScratchName(t *testing.T) {
+	testCases := []struct {
+		name           string
+		expectedErrMsg string
+	}{
+		{name: "notes"},
+		{name: "my_notes-2"},
+		{name: "", expectedErrMsg: "Scratch buffer name is empty"},
+		{name: "../notes", expectedErrMsg: `Invalid scratch buffer name "../notes": use only letters, digits, underscores, and hyphens`},
+		{name: "my notes", expectedErrMsg: `Invalid scratch buffer name "my notes": use only letters, digits, underscores, and hyphens`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateScratchName(tc.name)
+			if tc.expectedErrMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErrMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package file
+
+import "io/fs"
+
+// fileOwner returns no owner on platforms without unix user and group IDs, such as Windows.
+func fileOwner(fileInfo fs.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
+
+// numHardLinks returns zero on platforms where the standard library does not report the number of hard links.
+func numHardLinks(fileInfo fs.FileInfo) uint64 {
+	return 0
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package file
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the user and group that own a file.
+func fileOwner(fileInfo fs.FileInfo) (uid, gid int, ok bool) {
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		return int(stat.Uid), int(stat.Gid), true
+	}
+	return 0, 0, false
+}
+
+// numHardLinks returns the number of hard links to a file, or zero if it is unknown.
+func numHardLinks(fileInfo fs.FileInfo) uint64 {
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Nlink)
+	}
+	return 0
+}
@@ -0,0 +1,165 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTextFormatSampleFiles is the maximum number of files examined to detect a project's text format.
+const maxTextFormatSampleFiles = 100
+
+// maxTextFormatSampleSize is the maximum size of a file examined to detect a project's text format.
+// Larger files are more likely to be generated data than source code.
+const maxTextFormatSampleSize = 1 << 20
+
+// TextFormat controls how line endings are written when a document is saved.
+type TextFormat struct {
+	// CRLF writes each line feed as a carriage return followed by a line feed.
+	CRLF bool `json:"crlf"`
+
+	// FinalNewline ends the file with a line ending (the POSIX end-of-file indicator).
+	FinalNewline bool `json:"finalNewline"`
+}
+
+// DefaultTextFormat is the format for files in projects without a stored format.
+var DefaultTextFormat = TextFormat{FinalNewline: true}
+
+// String describes the format for display, like "CRLF without final newline".
+func (f TextFormat) String() string {
+	lineEnding := "LF"
+	if f.CRLF {
+		lineEnding = "CRLF"
+	}
+	if f.FinalNewline {
+		return lineEnding + " with final newline"
+	}
+	return lineEnding + " without final newline"
+}
+
+// crlfLineEndings converts line feeds to carriage return and line feed.
+// Line feeds already preceded by a carriage return are unchanged.
+func crlfLineEndings(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + strings.Count(s, "\n"))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' && (i == 0 || s[i-1] != '\r') {
+			sb.WriteByte('\r')
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// DetectTextFormat examines files in a project directory to find the text format most of them use.
+// Hidden files and directories, binary files, and large files are skipped.
+// It returns false if the project has no files with line endings to examine.
+func DetectTextFormat(projectDir string) (TextFormat, bool) {
+	var numFiles, numCRLF, numFinalNewline int
+	filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable files and directories.
+		}
+
+		if path != projectDir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if info, err := d.Info(); err != nil || info.Size() > maxTextFormatSampleSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || !bytes.Contains(data, []byte{'\n'}) || !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			return nil
+		}
+
+		numFiles++
+		if bytes.Contains(data, []byte("\r\n")) {
+			numCRLF++
+		}
+		if data[len(data)-1] == '\n' {
+			numFinalNewline++
+		}
+
+		if numFiles >= maxTextFormatSampleFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+
+	if numFiles == 0 {
+		return TextFormat{}, false
+	}
+
+	// Ties follow the default format.
+	return TextFormat{
+		CRLF:         numCRLF > numFiles-numCRLF,
+		FinalNewline: numFinalNewline >= numFiles-numFinalNewline,
+	}, true
+}
+
+// TextFormatStore saves the text format for each project.
+// A project is identified by its root directory; the format for each project is stored in a separate file.
+type TextFormatStore struct {
+	dir string
+}
+
+// textFormatData is the contents of a project's text format file.
+type textFormatData struct {
+	ProjectDir string     `json:"projectDir"`
+	TextFormat TextFormat `json:"textFormat"`
+}
+
+// NewTextFormatStore returns a store that saves text formats in the given directory.
+func NewTextFormatStore(dir string) *TextFormatStore {
+	return &TextFormatStore{dir: dir}
+}
+
+// TextFormatForPath returns the text format of the project containing a path.
+// It returns false if the path is outside the project or the project has no stored format.
+func (s *TextFormatStore) TextFormatForPath(projectDir string, path string) (TextFormat, bool, error) {
+	if _, ok := pathInProject(projectDir, path); !ok {
+		return TextFormat{}, false, nil
+	}
+
+	b, err := os.ReadFile(projectDataPath(s.dir, projectDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return TextFormat{}, false, nil
+	} else if err != nil {
+		return TextFormat{}, false, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var data textFormatData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return TextFormat{}, false, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return data.TextFormat, true, nil
+}
+
+// SetTextFormat stores the text format of a project, replacing any previous format.
+func (s *TextFormatStore) SetTextFormat(projectDir string, format TextFormat) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	b, err := json.Marshal(textFormatData{ProjectDir: projectDir, TextFormat: format})
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return writeFileViaRename(s.dir, "textformat-*.tmp", projectDataPath(s.dir, projectDir), b)
+}
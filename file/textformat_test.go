@@ -0,0 +1,169 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestSaveWithTextFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		format   TextFormat
+		expected string
+	}{
+		{
+			name:     "LF with final newline",
+			contents: "foo\nbar",
+			format:   TextFormat{FinalNewline: true},
+			expected: "foo\nbar\n",
+		},
+		{
+			name:     "LF without final newline",
+			contents: "foo\nbar",
+			format:   TextFormat{},
+			expected: "foo\nbar",
+		},
+		{
+			name:     "CRLF with final newline",
+			contents: "foo\nbar",
+			format:   TextFormat{CRLF: true, FinalNewline: true},
+			expected: "foo\r\nbar\r\n",
+		},
+		{
+			name:     "CRLF without final newline",
+			contents: "foo\nbar",
+			format:   TextFormat{CRLF: true},
+			expected: "foo\r\nbar",
+		},
+		{
+			name:     "CRLF with existing carriage returns",
+			contents: "foo\r\nbar\nbaz\r",
+			format:   TextFormat{CRLF: true, FinalNewline: true},
+			expected: "foo\r\nbar\r\nbaz\r\n",
+		},
+		{
+			name:     "leading line feed",
+			contents: "\nfoo",
+			format:   TextFormat{CRLF: true},
+			expected: "\r\nfoo",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.txt")
+			tree, err := text.NewTreeFromString(tc.contents)
+			require.NoError(t, err)
+
+			watcher, _, err := Save(path, tree, tc.format, false, testWatcherConfig)
+			require.NoError(t, err)
+			defer watcher.Stop()
+
+			fileBytes, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(fileBytes))
+
+			// The watcher's checksum matches the file on disk, so the save isn't mistaken for a change by another program.
+			changed, err := watcher.CheckFileContentsChanged()
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
+
+func TestDetectTextFormat(t *testing.T) {
+	testCases := []struct {
+		name       string
+		files      map[string]string
+		expected   TextFormat
+		expectedOk bool
+	}{
+		{
+			name:       "empty project",
+			files:      map[string]string{},
+			expectedOk: false,
+		},
+		{
+			name: "LF with final newline",
+			files: map[string]string{
+				"a.txt":     "foo\nbar\n",
+				"sub/b.txt": "baz\n",
+			},
+			expected:   TextFormat{FinalNewline: true},
+			expectedOk: true,
+		},
+		{
+			name: "mostly CRLF without final newline",
+			files: map[string]string{
+				"a.txt":     "foo\r\nbar",
+				"sub/b.txt": "baz\r\nqux",
+				"c.txt":     "lf\n",
+			},
+			expected:   TextFormat{CRLF: true, FinalNewline: false},
+			expectedOk: true,
+		},
+		{
+			name: "skip hidden, binary, and single-line files",
+			files: map[string]string{
+				"a.txt":        "foo\nbar\n",
+				".git/config":  "x\r\ny",
+				".hidden.txt":  "x\r\ny",
+				"binary.dat":   "x\r\n\x00y",
+				"oneline.txt":  "no line ending",
+				"another.txt":  "baz\n",
+				"sub/.env.txt": "x\r\ny",
+			},
+			expected:   TextFormat{FinalNewline: true},
+			expectedOk: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, contents := range tc.files {
+				path := filepath.Join(dir, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+			}
+
+			format, ok := DetectTextFormat(dir)
+			assert.Equal(t, tc.expectedOk, ok)
+			assert.Equal(t, tc.expected, format)
+		})
+	}
+}
+
+func TestTextFormatStore(t *testing.T) {
+	store := NewTextFormatStore(filepath.Join(t.TempDir(), "textformat"))
+	projectDir := t.TempDir()
+	path := filepath.Join(projectDir, "a", "new.txt")
+
+	_, ok, err := store.TextFormatForPath(projectDir, path)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	format := TextFormat{CRLF: true}
+	require.NoError(t, store.SetTextFormat(projectDir, format))
+	stored, ok, err := store.TextFormatForPath(projectDir, path)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, format, stored)
+
+	// Paths outside the project don't use the project's format.
+	_, ok, err = store.TextFormatForPath(projectDir, filepath.Join(t.TempDir(), "other.txt"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestTextFormatString(t *testing.T) {
+	assert.Equal(t, "LF with final newline", DefaultTextFormat.String())
+	assert.Equal(t, "CRLF without final newline", TextFormat{CRLF: true}.String())
+}
@@ -38,21 +38,27 @@ func (t *Timeline) TransitionFrom(fromState TimelineState) {
 }
 
 // TransitionBackwardFrom moves the timeline backward to the previous state.
+// If fromState is empty (for example, a document not backed by a file), it is not recorded.
 func (t *Timeline) TransitionBackwardFrom(fromState TimelineState) {
 	if len(t.pastStates) == 0 {
 		return
 	}
 	t.pastStates = t.pastStates[:len(t.pastStates)-1]
-	t.futureStates = append(t.futureStates, fromState)
+	if !fromState.Empty() {
+		t.futureStates = append(t.futureStates, fromState)
+	}
 }
 
 // TransitionForwardFrom moves the timeline forward to the next state.
+// If fromState is empty, it is not recorded.
 func (t *Timeline) TransitionForwardFrom(fromState TimelineState) {
 	if len(t.futureStates) == 0 {
 		return
 	}
 	t.futureStates = t.futureStates[:len(t.futureStates)-1]
-	t.pastStates = append(t.pastStates, fromState)
+	if !fromState.Empty() {
+		t.pastStates = append(t.pastStates, fromState)
+	}
 }
 
 // PeekBackward returns the state immediately before the current state.
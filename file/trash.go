@@ -0,0 +1,135 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// maxTrashNameAttempts limits how many names to try when the trash already contains files with the same name.
+const maxTrashNameAttempts = 1000
+
+const trashInfoSuffix = ".trashinfo"
+
+// TrashedFile is a file that was moved to the trash.
+type TrashedFile struct {
+	OrigPath  string // Absolute path of the file before it was moved to the trash.
+	trashPath string
+	infoPath  string
+}
+
+// Trash moves files to a trash directory that follows the FreeDesktop.org trash specification,
+// so file managers can list and restore them.
+type Trash struct {
+	dir string
+}
+
+// NewTrash returns a trash that stores files in the given directory, usually $XDG_DATA_HOME/Trash.
+func NewTrash(dir string) *Trash {
+	return &Trash{dir: dir}
+}
+
+// MoveToTrash moves the file at path to the trash.
+// The trash must be on the same file system as the file, since the file is moved by renaming it.
+func (t *Trash) MoveToTrash(path string, now time.Time) (TrashedFile, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return TrashedFile{}, fmt.Errorf("filepath.Abs: %w", err)
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return TrashedFile{}, fmt.Errorf("os.Lstat: %w", err)
+	} else if info.IsDir() {
+		return TrashedFile{}, fmt.Errorf("Cannot move a directory to the trash: %s", absPath)
+	}
+
+	filesDir, infoDir := filepath.Join(t.dir, "files"), filepath.Join(t.dir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return TrashedFile{}, fmt.Errorf("os.MkdirAll: %w", err)
+		}
+	}
+
+	// Reserve a unique name by creating the info file first, as the specification requires.
+	infoPath, trashName, err := t.createInfoFile(infoDir, absPath, now)
+	if err != nil {
+		return TrashedFile{}, err
+	}
+
+	trashPath := filepath.Join(filesDir, trashName)
+	if err := os.Rename(absPath, trashPath); err != nil {
+		os.Remove(infoPath)
+		return TrashedFile{}, fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return TrashedFile{
+		OrigPath:  absPath,
+		trashPath: trashPath,
+		infoPath:  infoPath,
+	}, nil
+}
+
+// Restore moves a trashed file back to its original path.
+// It returns an error if another file now exists at the original path.
+func (t *Trash) Restore(f TrashedFile) error {
+	if _, err := os.Lstat(f.OrigPath); err == nil {
+		return fmt.Errorf("File already exists at %s", f.OrigPath)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("os.Lstat: %w", err)
+	}
+
+	if err := os.Rename(f.trashPath, f.OrigPath); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	if err := os.Remove(f.infoPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("os.Remove: %w", err)
+	}
+
+	return nil
+}
+
+// createInfoFile creates the .trashinfo file for a path, choosing a name not already used in the trash.
+// It returns the path of the info file and the name for the file in the trash.
+func (t *Trash) createInfoFile(infoDir string, absPath string, now time.Time) (string, string, error) {
+	contents := fmt.Sprintf(
+		"[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: absPath}).EscapedPath(),
+		now.Local().Format("2006-01-02T15:04:05"),
+	)
+
+	base := filepath.Base(absPath)
+	for i := 1; i <= maxTrashNameAttempts; i++ {
+		name := base
+		if i > 1 {
+			name = base + "." + strconv.Itoa(i)
+		}
+
+		infoPath := filepath.Join(infoDir, name+trashInfoSuffix)
+		f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		} else if err != nil {
+			return "", "", fmt.Errorf("os.OpenFile: %w", err)
+		}
+
+		_, err = f.WriteString(contents)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return "", "", fmt.Errorf("Error writing trash info file: %w", err)
+		}
+
+		return infoPath, name, nil
+	}
+
+	return "", "", fmt.Errorf("Too many files named %q in the trash", base)
+}
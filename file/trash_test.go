@@ -0,0 +1,83 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashMoveAndRestore(t *testing.T) {
+	trashDir := filepath.Join(t.TempDir(), "Trash")
+	trash := NewTrash(trashDir)
+	path := filepath.Join(t.TempDir(), "foo bar.txt")
+	require.NoError(t, os.WriteFile(path, []byte("abc"), 0644))
+
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.Local)
+	f, err := trash.MoveToTrash(path, now)
+	require.NoError(t, err)
+	assert.Equal(t, path, f.OrigPath)
+
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	data, err := os.ReadFile(filepath.Join(trashDir, "files", "foo bar.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(data))
+
+	info, err := os.ReadFile(filepath.Join(trashDir, "info", "foo bar.txt.trashinfo"))
+	require.NoError(t, err)
+	expectedPath := filepath.ToSlash(filepath.Dir(path)) + "/foo%20bar.txt"
+	assert.Equal(t, "[Trash Info]\nPath="+expectedPath+"\nDeletionDate=2024-03-01T12:30:00\n", string(info))
+
+	err = trash.Restore(f)
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(data))
+
+	_, err = os.Stat(filepath.Join(trashDir, "info", "foo bar.txt.trashinfo"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTrashNameCollision(t *testing.T) {
+	trashDir := t.TempDir()
+	trash := NewTrash(trashDir)
+	now := time.Now()
+
+	var trashed []TrashedFile
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(t.TempDir(), "foo.txt")
+		require.NoError(t, os.WriteFile(path, []byte{byte('a' + i)}, 0644))
+		f, err := trash.MoveToTrash(path, now)
+		require.NoError(t, err)
+		trashed = append(trashed, f)
+	}
+
+	for _, name := range []string{"foo.txt", "foo.txt.2", "foo.txt.3"} {
+		_, err := os.Stat(filepath.Join(trashDir, "files", name))
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(trashDir, "info", name+".trashinfo"))
+		assert.NoError(t, err)
+	}
+
+	require.NoError(t, trash.Restore(trashed[2]))
+	data, err := os.ReadFile(trashed[2].OrigPath)
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(data))
+}
+
+func TestTrashRestoreFileExists(t *testing.T) {
+	trash := NewTrash(t.TempDir())
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+	f, err := trash.MoveToTrash(path, time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("new"), 0644))
+	err = trash.Restore(f)
+	assert.EqualError(t, err, "File already exists at "+path)
+}
@@ -11,7 +11,27 @@ import (
 	"time"
 )
 
-const DefaultPollInterval = time.Second
+const (
+	DefaultPollInterval = time.Second
+	DefaultDebounce     = 200 * time.Millisecond
+)
+
+// WatcherConfig controls how a watcher checks for changes.
+type WatcherConfig struct {
+	// PollInterval is how often the watcher checks whether the file changed.
+	PollInterval time.Duration
+
+	// Debounce is how long the file must remain unchanged before the watcher reports a change.
+	// This batches rapid successive writes (for example, a formatter rewriting the file
+	// immediately after it was saved) into a single change.  Zero disables debouncing.
+	Debounce time.Duration
+}
+
+// DefaultWatcherConfig is the watcher configuration used if none is specified.
+var DefaultWatcherConfig = WatcherConfig{
+	PollInterval: DefaultPollInterval,
+	Debounce:     DefaultDebounce,
+}
 
 // Watcher checks if a file's contents have changed.
 type Watcher struct {
@@ -31,14 +51,14 @@ type Watcher struct {
 }
 
 // NewWatcherForNewFile returns a watcher for a file that does not yet exist on disk.
-func NewWatcherForNewFile(pollInterval time.Duration, path string) *Watcher {
+func NewWatcherForNewFile(cfg WatcherConfig, path string) *Watcher {
 	w := &Watcher{
 		path:        path,
 		isNewFile:   true,
 		changedChan: make(chan struct{}),
 		quitChan:    make(chan struct{}),
 	}
-	go w.checkFileLoop(pollInterval)
+	go w.checkFileLoop(cfg)
 	return w
 }
 
@@ -46,7 +66,7 @@ func NewWatcherForNewFile(pollInterval time.Duration, path string) *Watcher {
 // lastModified is the time the file was last modified, as reported when the file was loaded.
 // size is the size in bytes of the file when it was loaded.
 // checksum is an MD5 hash of the file's contents when it was loaded.
-func NewWatcherForExistingFile(pollInterval time.Duration, path string, lastModified time.Time, size int64, checksum string) *Watcher {
+func NewWatcherForExistingFile(cfg WatcherConfig, path string, lastModified time.Time, size int64, checksum string) *Watcher {
 	w := &Watcher{
 		path:         path,
 		size:         size,
@@ -55,7 +75,7 @@ func NewWatcherForExistingFile(pollInterval time.Duration, path string, lastModi
 		changedChan:  make(chan struct{}),
 		quitChan:     make(chan struct{}),
 	}
-	go w.checkFileLoop(pollInterval)
+	go w.checkFileLoop(cfg)
 	return w
 }
 
@@ -64,11 +84,22 @@ func NewEmptyWatcher() *Watcher {
 	return &Watcher{changedChan: make(chan struct{})}
 }
 
+// NewInactiveWatcher returns a watcher for the path that never triggers.
+// This is used while a file is still loading, before its checksum is known.
+func NewInactiveWatcher(path string) *Watcher {
+	return &Watcher{path: path, changedChan: make(chan struct{})}
+}
+
 // Path returns the path to the file being watched.
 func (w *Watcher) Path() string {
 	return w.path
 }
 
+// IsNewFile returns whether the file did not exist on disk when the watcher was created.
+func (w *Watcher) IsNewFile() bool {
+	return w.isNewFile
+}
+
 // Stop stops the watcher from checking for changes.
 func (w *Watcher) Stop() {
 	w.stopOnce.Do(func() {
@@ -102,6 +133,21 @@ func (w *Watcher) CheckFileMovedOrDeleted() (bool, error) {
 	return false, nil
 }
 
+// CheckFileTruncated checks whether the file is now smaller than when it was loaded,
+// for example because a log rotation tool truncated it.
+// If the file no longer exists, this will return an error.
+func (w *Watcher) CheckFileTruncated() (bool, error) {
+	if w.isNewFile {
+		return false, nil
+	}
+
+	fileInfo, err := os.Stat(w.path)
+	if err != nil {
+		return false, fmt.Errorf("os.Stat: %w", err)
+	}
+	return fileInfo.Size() < w.size, nil
+}
+
 // CheckFileContentsChanged checks whether the file's checksum has changed.
 // If the file no longer exists, this will return an error.
 func (w *Watcher) CheckFileContentsChanged() (bool, error) {
@@ -121,18 +167,34 @@ func (w *Watcher) ChangedChan() chan struct{} {
 	return w.changedChan
 }
 
-func (w *Watcher) checkFileLoop(pollInterval time.Duration) {
+func (w *Watcher) checkFileLoop(cfg WatcherConfig) {
 	log.Printf("Started file watcher for %s\n", w.path)
-	ticker := time.NewTicker(pollInterval)
+	ticker := time.NewTicker(cfg.PollInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			if w.checkFileChanged() {
-				log.Printf("File change detected in %s\n", w.path)
-				w.changedChan <- struct{}{}
-				return
+			fileInfo, ok := w.statFile()
+			if !ok || !w.checkFileChanged(fileInfo) {
+				continue
 			}
+
+			if cfg.Debounce > 0 {
+				log.Printf("File change detected in %s, waiting for writes to finish\n", w.path)
+				if !w.waitForFileUnchanged(cfg.Debounce, fileInfo) {
+					return
+				}
+
+				// The file may have been restored to its original contents
+				// (for example, if a formatter made no changes).
+				if checksum, err := w.calculateChecksum(); err != nil || checksum == w.checksum {
+					continue
+				}
+			}
+
+			log.Printf("File change detected in %s\n", w.path)
+			w.changedChan <- struct{}{}
+			return
 		case <-w.quitChan:
 			log.Printf("Quit channel closed, exiting check file loop for %s\n", w.path)
 			return
@@ -140,15 +202,43 @@ func (w *Watcher) checkFileLoop(pollInterval time.Duration) {
 	}
 }
 
-func (w *Watcher) checkFileChanged() bool {
+// waitForFileUnchanged waits until the file's mtime and size remain the same for the debounce duration.
+// It returns false if the watcher was stopped while waiting.
+func (w *Watcher) waitForFileUnchanged(debounce time.Duration, fileInfo fs.FileInfo) bool {
+	for {
+		select {
+		case <-time.After(debounce):
+			newFileInfo, ok := w.statFile()
+			if !ok {
+				// The file is being replaced, so wait for it to reappear.
+				continue
+			}
+
+			if newFileInfo.ModTime().Equal(fileInfo.ModTime()) && newFileInfo.Size() == fileInfo.Size() {
+				return true
+			}
+
+			w.lastModified = newFileInfo.ModTime()
+			fileInfo = newFileInfo
+		case <-w.quitChan:
+			log.Printf("Quit channel closed while waiting for writes to %s\n", w.path)
+			return false
+		}
+	}
+}
+
+func (w *Watcher) statFile() (fs.FileInfo, bool) {
 	fileInfo, err := os.Stat(w.path)
 	if err != nil {
 		if !errors.Is(err, fs.ErrNotExist) {
 			log.Printf("Error retrieving file info: %v\n", err)
 		}
-		return false
+		return nil, false
 	}
+	return fileInfo, true
+}
 
+func (w *Watcher) checkFileChanged(fileInfo fs.FileInfo) bool {
 	// If neither mtime or size changed since the last check or file load, the contents probably haven't changed.
 	// This check could produce a false negative if someone modifies the file immediately after loading it (within mtime granularity)
 	// and replaces bytes without changing the size, but it's so much cheaper than calculating the md5 checksum that we do it anyway.
@@ -14,12 +14,14 @@ import (
 
 const testWatcherPollInterval time.Duration = time.Millisecond * 50
 
+var testWatcherConfig = WatcherConfig{PollInterval: testWatcherPollInterval}
+
 func TestWatcherNewFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	filePath := filepath.Join(tmpDir, "test.txt")
 
 	// Start a watcher for a new (non-existent) file.
-	watcher := NewWatcherForNewFile(testWatcherPollInterval, filePath)
+	watcher := NewWatcherForNewFile(testWatcherConfig, filePath)
 	defer watcher.Stop()
 
 	// Initially there should be no changes.
@@ -62,7 +64,7 @@ func TestWatcherFromLoadExistingFile(t *testing.T) {
 	filePath := createTestFile(t, "abcd")
 
 	// Load the file and start a watcher.
-	_, watcher, err := Load(filePath, testWatcherPollInterval)
+	_, watcher, err := Load(filePath, testWatcherConfig)
 	require.NoError(t, err)
 	defer watcher.Stop()
 
@@ -103,3 +105,83 @@ func TestWatcherFromLoadExistingFile(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, movedOrDeleted)
 }
+
+func TestWatcherDebounceRapidWrites(t *testing.T) {
+	filePath := createTestFile(t, "abcd")
+
+	cfg := WatcherConfig{
+		PollInterval: testWatcherPollInterval,
+		Debounce:     testWatcherPollInterval * 4,
+	}
+	_, watcher, err := Load(filePath, cfg)
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	// Simulate a series of rapid writes, such as a formatter rewriting the file after save.
+	// Each write is within the debounce duration of the last, so the watcher should wait.
+	for i := 0; i < 5; i++ {
+		appendToTestFile(t, filePath, "x")
+		select {
+		case <-watcher.ChangedChan():
+			require.Fail(t, "Change reported before writes finished")
+		case <-time.After(testWatcherPollInterval * 2):
+		}
+	}
+
+	// After the writes stop, the watcher should report a single change.
+	select {
+	case <-watcher.ChangedChan():
+		changed, err := watcher.CheckFileContentsChanged()
+		assert.NoError(t, err)
+		assert.True(t, changed)
+	case <-time.After(testWatcherPollInterval * 20):
+		assert.Fail(t, "Timed out waiting for change")
+	}
+}
+
+func TestWatcherDebounceContentsRestored(t *testing.T) {
+	filePath := createTestFile(t, "abcd")
+
+	cfg := WatcherConfig{
+		PollInterval: testWatcherPollInterval,
+		Debounce:     testWatcherPollInterval * 2,
+	}
+	_, watcher, err := Load(filePath, cfg)
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	// Modify the file, then restore the original contents before the debounce duration elapses.
+	err = os.WriteFile(filePath, []byte("abcdxyz"), 0644)
+	require.NoError(t, err)
+	time.Sleep(testWatcherPollInterval)
+	err = os.WriteFile(filePath, []byte("abcd"), 0644)
+	require.NoError(t, err)
+
+	// The watcher should not report a change, since the contents match what was loaded.
+	select {
+	case <-watcher.ChangedChan():
+		assert.Fail(t, "Unexpected change reported")
+	case <-time.After(testWatcherPollInterval * 10):
+	}
+}
+
+func TestWatcherCheckFileTruncated(t *testing.T) {
+	filePath := createTestFile(t, "abcd\nefgh\n")
+	_, watcher, err := Load(filePath, testWatcherConfig)
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	truncated, err := watcher.CheckFileTruncated()
+	require.NoError(t, err)
+	assert.False(t, truncated)
+
+	appendToTestFile(t, filePath, "xyz")
+	truncated, err = watcher.CheckFileTruncated()
+	require.NoError(t, err)
+	assert.False(t, truncated)
+
+	require.NoError(t, os.Truncate(filePath, 0))
+	truncated, err = watcher.CheckFileTruncated()
+	require.NoError(t, err)
+	assert.True(t, truncated)
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package file
+
+import "os"
+
+// readXattrs returns no extended attributes on platforms where they are not supported.
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func setXattr(f *os.File, name string, value []byte) error {
+	return nil
+}
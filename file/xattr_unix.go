@@ -0,0 +1,90 @@
+//go:build linux || darwin
+
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs returns the extended attributes of a file, such as an SELinux security context.
+func readXattrs(path string) (map[string][]byte, error) {
+	names, err := listXattrNames(path)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string][]byte, len(names))
+	for _, name := range names {
+		value, err := getXattr(path, name)
+		if err != nil {
+			return nil, err
+		}
+		xattrs[name] = value
+	}
+	return xattrs, nil
+}
+
+func listXattrNames(path string) ([]string, error) {
+	buf, err := readXattrBuffer(func(dest []byte) (int, error) {
+		return unix.Listxattr(path, dest)
+	})
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unix.Listxattr: %w", err)
+	}
+
+	// The names are null-terminated strings.
+	var names []string
+	for _, name := range bytes.Split(buf, []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
+func getXattr(path string, name string) ([]byte, error) {
+	value, err := readXattrBuffer(func(dest []byte) (int, error) {
+		return unix.Getxattr(path, name, dest)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unix.Getxattr: %w", err)
+	}
+	return value, nil
+}
+
+// readXattrBuffer calls f with a buffer large enough to hold the result.
+// Calling f with an empty buffer returns the required size, but the size can change
+// before the next call, so retry if the buffer turns out to be too small.
+func readXattrBuffer(f func(dest []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := f(nil)
+		if err != nil {
+			return nil, err
+		} else if size == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size)
+		n, err := f(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+func setXattr(f *os.File, name string, value []byte) error {
+	if err := unix.Fsetxattr(int(f.Fd()), name, value, 0); err != nil {
+		return fmt.Errorf("unix.Fsetxattr: %w", err)
+	}
+	return nil
+}
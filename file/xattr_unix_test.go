@@ -0,0 +1,33 @@
+//go:build linux || darwin
+
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestSavePreservesExtendedAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	err := os.WriteFile(path, []byte("test"), 0644)
+	require.NoError(t, err)
+
+	const name = "user.aretext.test"
+	err = unix.Setxattr(path, name, []byte("foo"), 0)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EPERM) {
+		t.Skip("Filesystem does not support user extended attributes")
+	}
+	require.NoError(t, err)
+
+	saveAndAssertContents(t, path, "new contents", 0644)
+
+	xattrs, err := readXattrs(path)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo"), xattrs[name])
+}
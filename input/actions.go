@@ -1,9 +1,15 @@
 package input
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/aretext/aretext/clipboard"
 	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/menu"
 	"github.com/aretext/aretext/selection"
 	"github.com/aretext/aretext/state"
 )
@@ -209,6 +215,18 @@ func CursorStartOfLastLine(s *state.EditorState) {
 	})
 }
 
+func CursorStartOfDocument(s *state.EditorState) {
+	state.MoveCursor(s, func(params state.LocatorParams) uint64 {
+		return 0
+	})
+}
+
+func CursorEndOfDocument(s *state.EditorState) {
+	state.MoveCursor(s, func(params state.LocatorParams) uint64 {
+		return params.TextTree.NumChars()
+	})
+}
+
 func CursorMatchingCodeBlockDelimiter(s *state.EditorState) {
 	state.MoveCursor(s, func(params state.LocatorParams) uint64 {
 		matchPos, hasMatch := locate.MatchingCodeBlockDelimiter(params.TextTree, params.SyntaxParser, params.CursorPos)
@@ -374,6 +392,93 @@ func ReturnToNormalModeAfterInsert(s *state.EditorState) {
 	state.CommitUndoEntry(s)
 }
 
+func EnterReplaceMode(s *state.EditorState) {
+	state.EnterReplaceMode(s)
+}
+
+func ReturnToNormalModeAfterReplace(s *state.EditorState) {
+	state.MoveCursor(s, func(params state.LocatorParams) uint64 {
+		return locate.PrevCharInLine(params.TextTree, 1, false, params.CursorPos)
+	})
+	state.EnterNormalMode(s)
+
+	// Undo entry began in normal mode before we entered replace mode.
+	// Commit the entry so the next undo reverts every character replaced in replace mode.
+	state.CommitUndoEntry(s)
+}
+
+func ReplaceModeInsertText(text string) Action {
+	return func(s *state.EditorState) {
+		state.ReplaceModeInsertText(s, text)
+	}
+}
+
+// SelectInInsertMode moves the cursor while selecting text in insert mode.
+func SelectInInsertMode(moveAction Action) Action {
+	return func(s *state.EditorState) {
+		state.StartInsertModeSelection(s)
+		moveAction(s)
+	}
+}
+
+// ClearInsertModeSelectionThen clears the text selected in insert mode, if any, then performs the action.
+func ClearInsertModeSelectionThen(action Action) Action {
+	return func(s *state.EditorState) {
+		state.ClearInsertModeSelection(s)
+		action(s)
+	}
+}
+
+// ReplaceInsertModeSelection deletes the text selected in insert mode, if any, then performs the action.
+func ReplaceInsertModeSelection(action Action) Action {
+	return func(s *state.EditorState) {
+		state.DeleteInsertModeSelection(s)
+		action(s)
+	}
+}
+
+// DeleteInsertModeSelectionOr deletes the text selected in insert mode or,
+// if no text is selected, performs the action.
+func DeleteInsertModeSelectionOr(action Action) Action {
+	return func(s *state.EditorState) {
+		if !state.DeleteInsertModeSelection(s) {
+			action(s)
+		}
+	}
+}
+
+func StartAutocomplete(forward bool) Action {
+	return func(s *state.EditorState) {
+		state.StartAutocomplete(s, forward)
+	}
+}
+
+func MoveAutocompleteSelection(delta int) Action {
+	return func(s *state.EditorState) {
+		state.MoveAutocompleteSelection(s, delta)
+	}
+}
+
+// AcceptAutocompleteThen keeps the autocompleted word, then performs the action in insert mode.
+func AcceptAutocompleteThen(action Action) Action {
+	return func(s *state.EditorState) {
+		state.AcceptAutocomplete(s)
+		action(s)
+	}
+}
+
+func CopyInsertModeSelection(s *state.EditorState) {
+	state.CopyInsertModeSelection(s)
+}
+
+func CutInsertModeSelection(s *state.EditorState) {
+	state.CutInsertModeSelection(s)
+}
+
+func PasteInInsertMode(s *state.EditorState) {
+	state.PasteInInsertMode(s)
+}
+
 func InsertRune(r rune) Action {
 	return func(s *state.EditorState) {
 		state.InsertRune(s, r)
@@ -391,6 +496,10 @@ func InsertTab(s *state.EditorState) {
 	state.InsertTab(s)
 }
 
+func InsertTimestamp(s *state.EditorState) {
+	state.InsertTimestamp(s)
+}
+
 func DeletePrevChar(clipboardPage clipboard.PageId) Action {
 	return func(s *state.EditorState) {
 		state.DeleteToPos(s, func(params state.LocatorParams) uint64 {
@@ -409,6 +518,28 @@ func DeletePrevChar(clipboardPage clipboard.PageId) Action {
 	}
 }
 
+func DeletePrevWordInLine(clipboardPage clipboard.PageId) Action {
+	return func(s *state.EditorState) {
+		state.DeleteToPos(s, func(params state.LocatorParams) uint64 {
+			lineStartPos := locate.StartOfLineAtPos(params.TextTree, params.CursorPos)
+			if params.CursorPos == lineStartPos {
+				return locate.PrevChar(params.TextTree, 1, params.CursorPos)
+			}
+			prevWordStartPos := locate.PrevWordStart(params.TextTree, params.CursorPos, 1, true)
+			if prevWordStartPos < lineStartPos {
+				return lineStartPos
+			}
+			return prevWordStartPos
+		}, clipboardPage)
+	}
+}
+
+func DeleteToInsertStartOrLineStart(clipboardPage clipboard.PageId) Action {
+	return func(s *state.EditorState) {
+		state.DeleteToInsertStartOrLineStart(s, clipboardPage)
+	}
+}
+
 func BeginNewLineBelow(s *state.EditorState) {
 	CursorLineEndIncludeEndOfLineOrFile(s)
 	state.InsertNewline(s)
@@ -505,6 +636,29 @@ func DeleteUp(clipboardPage clipboard.PageId) Action {
 	}
 }
 
+func DeleteToMatchingCodeBlockDelimiter(clipboardPage clipboard.PageId) Action {
+	return func(s *state.EditorState) {
+		state.DeleteRange(s, matchingCodeBlockDelimiterRange, clipboardPage)
+		state.MoveCursor(s, func(params state.LocatorParams) uint64 {
+			return locate.ClosestCharOnLine(params.TextTree, params.CursorPos)
+		})
+	}
+}
+
+// matchingCodeBlockDelimiterRange returns the range from the cursor through the matching delimiter, inclusive.
+// If the cursor is not on a delimiter with a match, the range is empty.
+func matchingCodeBlockDelimiterRange(params state.LocatorParams) (uint64, uint64) {
+	matchPos, hasMatch := locate.MatchingCodeBlockDelimiter(params.TextTree, params.SyntaxParser, params.CursorPos)
+	if !hasMatch {
+		return params.CursorPos, params.CursorPos
+	}
+
+	if matchPos < params.CursorPos {
+		return matchPos, params.CursorPos + 1
+	}
+	return params.CursorPos, matchPos + 1
+}
+
 func DeleteToEndOfLine(clipboardPage clipboard.PageId) Action {
 	return func(s *state.EditorState) {
 		state.DeleteToPos(s, func(params state.LocatorParams) uint64 {
@@ -639,9 +793,16 @@ func ChangeToPrevMatchingChar(char rune, count uint64, clipboardPage clipboard.P
 	}
 }
 
-func ReplaceCharacter(newChar rune) Action {
+func ChangeToMatchingCodeBlockDelimiter(clipboardPage clipboard.PageId) Action {
 	return func(s *state.EditorState) {
-		state.ReplaceChar(s, newChar)
+		state.DeleteRange(s, matchingCodeBlockDelimiterRange, clipboardPage)
+		EnterInsertMode(s)
+	}
+}
+
+func ReplaceCharacter(newChar rune, count uint64) Action {
+	return func(s *state.EditorState) {
+		state.ReplaceChar(s, newChar, count)
 	}
 }
 
@@ -649,6 +810,18 @@ func ToggleCaseAtCursor(s *state.EditorState) {
 	state.ToggleCaseAtCursor(s)
 }
 
+func ResizeSplitHeight(delta int) Action {
+	return func(s *state.EditorState) {
+		state.ResizeSplitHeight(s, delta)
+	}
+}
+
+func ResizeSplitWidth(delta int) Action {
+	return func(s *state.EditorState) {
+		state.ResizeSplitWidth(s, delta)
+	}
+}
+
 func IndentLine(count uint64) Action {
 	return func(s *state.EditorState) {
 		targetLineLoc := func(p state.LocatorParams) uint64 {
@@ -739,6 +912,12 @@ func CopyToPrevMatchingChar(char rune, count uint64, clipboardPage clipboard.Pag
 	}
 }
 
+func CopyToMatchingCodeBlockDelimiter(clipboardPage clipboard.PageId) Action {
+	return func(s *state.EditorState) {
+		state.CopyRange(s, clipboardPage, matchingCodeBlockDelimiterRange)
+	}
+}
+
 func PasteAfterCursor(clipboardPage clipboard.PageId) Action {
 	return func(s *state.EditorState) {
 		state.PasteAfterCursor(s, clipboardPage)
@@ -754,7 +933,19 @@ func PasteBeforeCursor(clipboardPage clipboard.PageId) Action {
 func InsertFromBracketedPaste(text string) Action {
 	return func(s *state.EditorState) {
 		wrappedAction := func(s *state.EditorState) {
-			state.InsertText(s, text)
+			state.DeleteInsertModeSelection(s)
+			state.InsertPastedText(s, text)
+			state.ScrollViewToCursor(s)
+		}
+		wrappedAction(s)
+		state.AddToRecordingUserMacro(s, state.MacroAction(wrappedAction))
+	}
+}
+
+func ReplaceFromBracketedPaste(text string) Action {
+	return func(s *state.EditorState) {
+		wrappedAction := func(s *state.EditorState) {
+			state.ReplaceModeInsertText(s, text)
 			state.ScrollViewToCursor(s)
 		}
 		wrappedAction(s)
@@ -793,6 +984,167 @@ func BracketedPasteIntoSearchQuery(text string) Action {
 	}
 }
 
+// ShowOperatorMenu shows a menu of user-defined operators to apply to the range selected by loc.
+func ShowOperatorMenu(loc state.RangeLocator) Action {
+	return func(s *state.EditorState) {
+		// This sets the input mode to menu.
+		state.ShowOperatorMenu(s, loc)
+	}
+}
+
+func ShowOperatorMenuForSelection(s *state.EditorState) {
+	state.ShowOperatorMenuForSelection(s)
+}
+
+// operatorTarget is a motion or text object that selects the range for a user-defined operator.
+type operatorTarget struct {
+	name      string   // Description of the range, used in the command name.
+	objects   []string // Key sequences that select the range after the operator.
+	withCount bool
+	buildLoc  func(count uint64) state.RangeLocator
+}
+
+// operatorTargets lists the ranges that user-defined operators can be applied to.
+func operatorTargets() []operatorTarget {
+	delimitedBlock := func(pair locate.DelimiterPair, includeDelimiters bool) func(uint64) state.RangeLocator {
+		return func(uint64) state.RangeLocator {
+			return func(params state.LocatorParams) (uint64, uint64) {
+				return locate.DelimitedBlock(pair, params.TextTree, params.SyntaxParser, includeDelimiters, params.CursorPos)
+			}
+		}
+	}
+
+	stringObject := func(quoteRune rune, includeQuotes bool) func(uint64) state.RangeLocator {
+		return func(uint64) state.RangeLocator {
+			return func(params state.LocatorParams) (uint64, uint64) {
+				return locate.StringObject(quoteRune, params.TextTree, params.SyntaxParser, includeQuotes, params.CursorPos)
+			}
+		}
+	}
+
+	toNextWordStart := func(withPunctuation bool) func(uint64) state.RangeLocator {
+		return func(count uint64) state.RangeLocator {
+			return func(params state.LocatorParams) (uint64, uint64) {
+				endPos := locate.NextWordStart(params.TextTree, params.CursorPos, count, withPunctuation, true)
+				return params.CursorPos, endPos
+			}
+		}
+	}
+
+	return []operatorTarget{
+		{
+			name:      "to start of next word",
+			objects:   []string{"w"},
+			withCount: true,
+			buildLoc:  toNextWordStart(false),
+		},
+		{
+			name:      "to start of next word - words can contain punctuation",
+			objects:   []string{"W"},
+			withCount: true,
+			buildLoc:  toNextWordStart(true),
+		},
+		{
+			name:      "a word",
+			objects:   []string{"aw"},
+			withCount: true,
+			buildLoc: func(count uint64) state.RangeLocator {
+				return func(params state.LocatorParams) (uint64, uint64) {
+					return locate.WordObject(params.TextTree, params.CursorPos, count)
+				}
+			},
+		},
+		{
+			name:      "inner word",
+			objects:   []string{"iw"},
+			withCount: true,
+			buildLoc: func(count uint64) state.RangeLocator {
+				return func(params state.LocatorParams) (uint64, uint64) {
+					return locate.InnerWordObject(params.TextTree, params.CursorPos, count)
+				}
+			},
+		},
+		{
+			name:    "to end of line",
+			objects: []string{"$"},
+			buildLoc: func(uint64) state.RangeLocator {
+				return func(params state.LocatorParams) (uint64, uint64) {
+					return params.CursorPos, locate.NextLineBoundary(params.TextTree, true, params.CursorPos)
+				}
+			},
+		},
+		{
+			name:    "to start of line",
+			objects: []string{"0"},
+			buildLoc: func(uint64) state.RangeLocator {
+				return func(params state.LocatorParams) (uint64, uint64) {
+					return locate.PrevLineBoundary(params.TextTree, params.CursorPos), params.CursorPos
+				}
+			},
+		},
+		{
+			name:     "a string object with double quotes",
+			objects:  []string{"a\""},
+			buildLoc: stringObject('"', true),
+		},
+		{
+			name:     "inner string object with double quotes",
+			objects:  []string{"i\""},
+			buildLoc: stringObject('"', false),
+		},
+		{
+			name:     "a string object with single quotes",
+			objects:  []string{"a'"},
+			buildLoc: stringObject('\'', true),
+		},
+		{
+			name:     "inner string object with single quotes",
+			objects:  []string{"i'"},
+			buildLoc: stringObject('\'', false),
+		},
+		{
+			name:     "a string object with backtick",
+			objects:  []string{"a`"},
+			buildLoc: stringObject('`', true),
+		},
+		{
+			name:     "inner string object with backtick",
+			objects:  []string{"i`"},
+			buildLoc: stringObject('`', false),
+		},
+		{
+			name:     "a paren block",
+			objects:  []string{"ab", "a(", "a)"},
+			buildLoc: delimitedBlock(locate.ParenPair, true),
+		},
+		{
+			name:     "inner paren block",
+			objects:  []string{"ib", "i(", "i)"},
+			buildLoc: delimitedBlock(locate.ParenPair, false),
+		},
+		{
+			name:     "a brace block",
+			objects:  []string{"aB", "a{", "a}"},
+			buildLoc: delimitedBlock(locate.BracePair, true),
+		},
+		{
+			name:     "inner brace block",
+			objects:  []string{"iB", "i{", "i}"},
+			buildLoc: delimitedBlock(locate.BracePair, false),
+		},
+		{
+			name:     "an angle block",
+			objects:  []string{"a<", "a>"},
+			buildLoc: delimitedBlock(locate.AnglePair, true),
+		},
+		{
+			name:     "inner angle block",
+			objects:  []string{"i<", "i>"},
+			buildLoc: delimitedBlock(locate.AnglePair, false),
+		},
+	}
+}
+
 func ShowCommandMenu(ctx Context) Action {
 	return func(s *state.EditorState) {
 		// This sets the input mode to menu.
@@ -800,6 +1152,70 @@ func ShowCommandMenu(ctx Context) Action {
 	}
 }
 
+// ShowKeyBindingsMenu displays a menu listing the commands available in the current input mode.
+// Each item shows the command's keys, generated from the same expressions as the input
+// state machine, so the list stays in sync with the actual key bindings.
+// Selecting an item runs the command as if its keys were typed without a count,
+// except for commands that need a character typed after their keys, like "f{char}".
+func ShowKeyBindingsMenu(ctx Context) Action {
+	var commands []Command
+	switch ctx.InputMode {
+	case state.InputModeNormal:
+		commands = NormalModeCommands()
+	case state.InputModeVisual:
+		commands = VisualModeCommands()
+	}
+
+	items := make([]menu.Item, 0, len(commands))
+	for _, cmd := range commands {
+		items = append(items, menu.Item{
+			Name:   keyBindingMenuItemName(cmd),
+			Action: keyBindingMenuItemAction(ctx, cmd),
+		})
+	}
+
+	return func(s *state.EditorState) {
+		state.ShowMenu(s, state.MenuStyleKeyBindings, items)
+	}
+}
+
+// keyBindingMenuItemAction returns an action that runs the command with default parameters.
+// The menu restores the input mode before running the action, so the command sees the same
+// context as when the menu was opened.
+// It returns a plain function rather than an Action, since menu items must have the type func(*state.EditorState).
+func keyBindingMenuItemAction(ctx Context, cmd Command) func(*state.EditorState) {
+	if needsCharInput(cmd.BuildExpr()) {
+		return func(s *state.EditorState) {
+			state.SetStatusMsg(s, state.StatusMsg{
+				Style: state.StatusMsgStyleError,
+				Text:  fmt.Sprintf("Type the keys for %q to run it, since it needs a character", cmd.Name),
+			})
+		}
+	}
+
+	return func(s *state.EditorState) {
+		cmd.BuildAction(ctx, capturesToCommandParams(nil))(s)
+	}
+}
+
+// keyBindingMenuItemName formats a command name followed by its keys, like "delete line (dd)".
+// Many command names already end with their keys in parentheses, which are replaced by
+// the generated keys in case they are missing or out of date.
+func keyBindingMenuItemName(cmd Command) string {
+	name := cmd.Name
+	if strings.HasSuffix(name, ")") {
+		if i := strings.LastIndex(name, " ("); i >= 0 {
+			name = name[:i]
+		}
+	}
+
+	keys := describeKeys(cmd.BuildExpr())
+	if keys == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, keys)
+}
+
 func ShowFileMenu(ctx Context) Action {
 	return func(s *state.EditorState) {
 		state.ShowFileMenu(s, ctx.HidePatterns)
@@ -824,6 +1240,37 @@ func MenuSelectionDown(s *state.EditorState) {
 	state.MoveMenuSelection(s, 1)
 }
 
+func MenuSelectionFirst(s *state.EditorState) {
+	state.MoveMenuSelectionToFirst(s)
+}
+
+func MenuSelectionLast(s *state.EditorState) {
+	state.MoveMenuSelectionToLast(s)
+}
+
+func MenuSelectionPageUp(ctx Context) Action {
+	pageSize := menuPageSize(ctx)
+	return func(s *state.EditorState) {
+		state.MoveMenuSelectionWithoutWraparound(s, -pageSize)
+	}
+}
+
+func MenuSelectionPageDown(ctx Context) Action {
+	pageSize := menuPageSize(ctx)
+	return func(s *state.EditorState) {
+		state.MoveMenuSelectionWithoutWraparound(s, pageSize)
+	}
+}
+
+func menuPageSize(ctx Context) int {
+	// Leave space for the search input, bottom border, and status bar.
+	pageSize := int(ctx.ScrollLines) - 3
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	return pageSize
+}
+
 func AppendRuneToMenuSearch(r rune) Action {
 	return func(s *state.EditorState) {
 		state.AppendRuneToMenuSearch(s, r)
@@ -906,13 +1353,32 @@ func SearchWordUnderCursor(direction state.SearchDirection, count uint64) Action
 	}
 }
 
+func SearchWordUnderCursorForDelete(direction state.SearchDirection, count uint64, clipboardPage clipboard.PageId) Action {
+	return func(s *state.EditorState) {
+		completeAction := state.SearchCompleteDeleteToMatch(clipboardPage)
+		state.SearchWordUnderCursor(s, direction, completeAction, count)
+	}
+}
+
+func SearchWordUnderCursorForChange(direction state.SearchDirection, count uint64, clipboardPage clipboard.PageId) Action {
+	return func(s *state.EditorState) {
+		completeAction := state.SearchCompleteChangeToMatch(clipboardPage)
+		state.SearchWordUnderCursor(s, direction, completeAction, count)
+	}
+}
+
+func SearchWordUnderCursorForCopy(direction state.SearchDirection, count uint64, clipboardPage clipboard.PageId) Action {
+	return func(s *state.EditorState) {
+		completeAction := state.SearchCompleteCopyToMatch(clipboardPage)
+		state.SearchWordUnderCursor(s, direction, completeAction, count)
+	}
+}
+
 func ShowNewDocumentTextField(s *state.EditorState) {
-	state.AbortIfUnsavedChanges(s, state.DefaultUnsavedChangesAbortMsg, func(s *state.EditorState) {
-		state.ShowTextField(s,
-			"New document file path:",
-			state.NewDocument,
-			file.AutocompleteDirectory)
-	})
+	state.ShowTextField(s,
+		"New document file path:",
+		state.NewDocument,
+		file.AutocompleteDirectory)
 }
 
 func ShowMoveOrRenameDocumentTextField(s *state.EditorState) {
@@ -924,6 +1390,437 @@ func ShowMoveOrRenameDocumentTextField(s *state.EditorState) {
 	})
 }
 
+func ShowOpenScratchBufferTextField(s *state.EditorState) {
+	state.ShowTextField(s,
+		"Scratch buffer name:",
+		func(s *state.EditorState, inputText string) error {
+			return state.OpenScratchBuffer(s, strings.TrimSpace(inputText))
+		},
+		nil)
+}
+
+func ShowSaveScratchBufferAsTextField(s *state.EditorState) {
+	if !s.DocumentBuffer().IsScratch() {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  "Document is not a scratch buffer",
+		})
+		return
+	}
+
+	state.ShowTextField(s,
+		"Save scratch buffer to file path:",
+		state.SaveScratchBufferAs,
+		file.AutocompleteDirectory)
+}
+
+func ShowMoveToTrashTextField(s *state.EditorState) {
+	if s.DocumentBuffer().IsScratch() {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  "Scratch buffers are not backed by a file",
+		})
+		return
+	}
+
+	path := file.RelativePathCwd(s.FileWatcher().Path())
+	state.ShowTextField(s,
+		fmt.Sprintf("Move %s to trash, discarding unsaved changes? [y/N]", path),
+		func(s *state.EditorState, inputText string) error {
+			switch strings.ToLower(strings.TrimSpace(inputText)) {
+			case "y", "yes":
+				return state.MoveDocumentToTrash(s)
+			default:
+				state.SetStatusMsg(s, state.StatusMsg{
+					Style: state.StatusMsgStyleSuccess,
+					Text:  "Canceled deleting the file",
+				})
+				return nil
+			}
+		},
+		nil)
+}
+
+func RestoreLastTrashedFile(s *state.EditorState) {
+	if err := state.RestoreLastTrashedFile(s); err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+	}
+}
+
+func MoveFileExplorerSelection(delta int) Action {
+	return func(s *state.EditorState) {
+		state.MoveFileExplorerSelection(s, delta)
+	}
+}
+
+func ShowFileExplorerCreateTextField(s *state.EditorState) {
+	state.ShowTextField(s,
+		"New file path (end with / for a directory):",
+		state.CreateFileExplorerPath,
+		nil)
+}
+
+func ShowFileExplorerRenameTextField(s *state.EditorState) {
+	entry, ok := s.FileExplorer().SelectedEntry()
+	if !ok {
+		return
+	}
+	state.ShowTextField(s,
+		fmt.Sprintf("Rename %s to:", entry.Name),
+		state.RenameFileExplorerEntry,
+		nil)
+}
+
+func ShowFileExplorerDeleteTextField(s *state.EditorState) {
+	entry, ok := s.FileExplorer().SelectedEntry()
+	if !ok {
+		return
+	}
+
+	prompt := fmt.Sprintf("Move %s to trash? [y/N]", file.RelativePathCwd(entry.Path))
+	if entry.IsDir {
+		prompt = fmt.Sprintf("Delete empty directory %s? [y/N]", file.RelativePathCwd(entry.Path))
+	}
+
+	state.ShowTextField(s,
+		prompt,
+		func(s *state.EditorState, inputText string) error {
+			switch strings.ToLower(strings.TrimSpace(inputText)) {
+			case "y", "yes":
+				return state.DeleteFileExplorerEntry(s)
+			default:
+				state.SetStatusMsg(s, state.StatusMsg{
+					Style: state.StatusMsgStyleSuccess,
+					Text:  "Canceled deleting the file",
+				})
+				return nil
+			}
+		},
+		nil)
+}
+
+func ShowWrapDocumentTextField(s *state.EditorState) {
+	state.ShowTextField(s,
+		"Wrap document at column:",
+		func(s *state.EditorState, inputText string) error {
+			maxColumns, err := strconv.ParseUint(strings.TrimSpace(inputText), 10, 64)
+			if err != nil || maxColumns == 0 {
+				return fmt.Errorf("Invalid column %q", inputText)
+			}
+			state.WrapDocument(s, maxColumns)
+			return nil
+		},
+		nil)
+}
+
+func ShowTabDisplaySizeTextField(s *state.EditorState) {
+	state.ShowTextField(s,
+		"Display tabs with width (empty for tab size):",
+		func(s *state.EditorState, inputText string) error {
+			width, err := parseDisplayWidth(inputText)
+			if err != nil {
+				return err
+			}
+			state.SetTabDisplaySize(s, width)
+			return nil
+		},
+		nil)
+}
+
+func ShowLineNumMarginWidthTextField(s *state.EditorState) {
+	state.ShowTextField(s,
+		"Minimum line number margin width (empty for default):",
+		func(s *state.EditorState, inputText string) error {
+			width, err := parseDisplayWidth(inputText)
+			if err != nil {
+				return err
+			}
+			state.SetMinLineNumMarginWidth(s, width)
+			return nil
+		},
+		nil)
+}
+
+// maxDisplayWidth is the largest tab or margin width accepted from the user.
+// Wider values would leave no room for the document on most screens.
+const maxDisplayWidth = 64
+
+// parseDisplayWidth parses a width in columns entered in a text field.
+// Empty input means zero, which restores the default.
+func parseDisplayWidth(inputText string) (uint64, error) {
+	inputText = strings.TrimSpace(inputText)
+	if inputText == "" {
+		return 0, nil
+	}
+	width, err := strconv.ParseUint(inputText, 10, 64)
+	if err != nil || width > maxDisplayWidth {
+		return 0, fmt.Errorf("Invalid width %q", inputText)
+	}
+	return width, nil
+}
+
+func ShowRevertChangesTextField(s *state.EditorState) {
+	state.ShowTextField(s,
+		"Revert changes from the last N minutes:",
+		func(s *state.EditorState, inputText string) error {
+			minutes, err := strconv.ParseUint(strings.TrimSpace(inputText), 10, 64)
+			if err != nil || minutes == 0 {
+				return fmt.Errorf("Invalid number of minutes %q", inputText)
+			}
+			state.RevertChangesSince(s, minutes)
+			return nil
+		},
+		nil)
+}
+
+func ShowAlignSelectionTextField(s *state.EditorState) {
+	// Remember the selected lines, then return to normal mode so the
+	// text field doesn't go back to visual mode after aligning the lines.
+	buffer := s.DocumentBuffer()
+	region := buffer.SelectedRegion()
+	startLineNum := buffer.TextTree().LineNumForPosition(region.StartPos)
+	endLineNum := startLineNum
+	if region.EndPos > region.StartPos {
+		endLineNum = buffer.TextTree().LineNumForPosition(region.EndPos - 1)
+	}
+	ReturnToNormalMode(s)
+
+	state.ShowTextField(s,
+		"Align selection on:",
+		func(s *state.EditorState, inputText string) error {
+			return state.AlignLines(s, startLineNum, endLineNum, strings.TrimSpace(inputText))
+		},
+		nil)
+}
+
+func ShowFilterSelectionTextField(s *state.EditorState) {
+	// Remember the selection, then return to normal mode so the
+	// text field doesn't go back to visual mode after filtering.
+	buffer := s.DocumentBuffer()
+	selectionMode := buffer.SelectionMode()
+	region := buffer.SelectedRegion()
+	lineRange := state.LineRange{StartLine: buffer.TextTree().LineNumForPosition(region.StartPos)}
+	lineRange.EndLine = lineRange.StartLine
+	if region.EndPos > region.StartPos {
+		lineRange.EndLine = buffer.TextTree().LineNumForPosition(region.EndPos - 1)
+	}
+	ReturnToNormalMode(s)
+
+	state.ShowTextField(s,
+		"Filter selection through shell command:",
+		func(s *state.EditorState, inputText string) error {
+			shellCmd := strings.TrimSpace(inputText)
+			if shellCmd == "" {
+				return errors.New("Missing shell command to filter selection")
+			}
+
+			// Hide the text field before starting the task, so the task returns to normal mode.
+			state.HideTextField(s)
+			if selectionMode == selection.ModeLine {
+				state.FilterLineRange(s, lineRange, shellCmd)
+			} else {
+				state.FilterRegion(s, region, shellCmd)
+			}
+			return nil
+		},
+		nil)
+}
+
+func ShowSplitLineTextField(s *state.EditorState) {
+	// In visual mode, split the selection instead of the cursor's line.
+	buffer := s.DocumentBuffer()
+	hasSelection := buffer.SelectionMode() != selection.ModeNone
+	region := buffer.SelectedRegion()
+	ReturnToNormalMode(s)
+
+	state.ShowTextField(s,
+		"Split line on delimiter:",
+		func(s *state.EditorState, delim string) error {
+			if hasSelection {
+				return state.SplitRegionOnDelimiter(s, region, delim)
+			}
+			return state.SplitLineOnDelimiter(s, delim)
+		},
+		nil)
+}
+
+func ShowReplaceAllTextField(preserveCase bool) func(*state.EditorState) {
+	return func(s *state.EditorState) {
+		showReplaceTextFields(s, "Replace:", func(s *state.EditorState, query, replacement string) error {
+			return state.ReplaceAll(s, query, replacement, preserveCase)
+		})
+	}
+}
+
+func ShowReplaceWithConfirmationTextField(s *state.EditorState) {
+	showReplaceTextFields(s, "Replace:", func(s *state.EditorState, query, replacement string) error {
+		region := selection.Region{StartPos: 0, EndPos: s.DocumentBuffer().TextTree().NumChars()}
+		return startReplaceWithConfirmation(s, query, replacement, region)
+	})
+}
+
+func ShowReplaceInSelectionTextField(confirm bool) func(*state.EditorState) {
+	return func(s *state.EditorState) {
+		// Remember the selected region, then return to normal mode so the
+		// text field doesn't go back to visual mode after replacing.
+		region := s.DocumentBuffer().SelectedRegion()
+		ReturnToNormalMode(s)
+
+		showReplaceTextFields(s, "Replace in selection:", func(s *state.EditorState, query, replacement string) error {
+			if confirm {
+				return startReplaceWithConfirmation(s, query, replacement, region)
+			}
+			return state.ReplaceInRegion(s, query, replacement, false, region)
+		})
+	}
+}
+
+// showReplaceTextFields prompts for the search text, then for the replacement text.
+func showReplaceTextFields(s *state.EditorState, prompt string, replace func(s *state.EditorState, query, replacement string) error) {
+	state.ShowTextField(s,
+		prompt,
+		func(s *state.EditorState, query string) error {
+			if query == "" {
+				return errors.New("Search text cannot be empty")
+			}
+			// Prompt for the replacement text in a second text field.
+			state.ShowTextField(s,
+				fmt.Sprintf("Replace %q with:", query),
+				func(s *state.EditorState, replacement string) error {
+					return replace(s, query, replacement)
+				},
+				nil)
+			return nil
+		},
+		nil)
+}
+
+func startReplaceWithConfirmation(s *state.EditorState, query, replacement string, region selection.Region) error {
+	// Hide the text field first, so it doesn't return to the previous
+	// input mode after entering the mode to confirm each replacement.
+	state.HideTextField(s)
+	return state.StartReplaceWithConfirmation(s, query, replacement, false, region)
+}
+
+func ShowRenameInBlockTextField(s *state.EditorState) {
+	identifier, count, err := state.CountIdentifierInBlock(s)
+	if err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+		return
+	}
+
+	state.ShowTextField(s,
+		fmt.Sprintf("Rename %d occurrence(s) of %q in block to:", count, identifier),
+		func(s *state.EditorState, newName string) error {
+			return state.RenameIdentifierInBlock(s, newName)
+		},
+		nil)
+}
+
+func ShowArithmeticOnNumberTextField(s *state.EditorState) {
+	num, err := state.NumberUnderCursor(s)
+	if err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+		return
+	}
+
+	state.ShowTextField(s,
+		fmt.Sprintf("Apply to %s (for example, +1 or *2):", num),
+		func(s *state.EditorState, operand string) error {
+			return state.ApplyArithmeticToNumberUnderCursor(s, operand)
+		},
+		nil)
+}
+
+func EvaluateSelection(s *state.EditorState) {
+	if err := state.EvaluateSelection(s); err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+	}
+}
+
+func FormatSelectionAsJSON(s *state.EditorState) {
+	if err := state.FormatSelectionAsJSON(s); err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+	}
+}
+
+func FormatSelectionAsXML(s *state.EditorState) {
+	if err := state.FormatSelectionAsXML(s); err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+	}
+}
+
+func ShowBookmarkTextField(s *state.EditorState) {
+	note, lineNum, hasBookmark := state.BookmarkNoteOnCursorLine(s)
+	state.ShowTextField(s,
+		fmt.Sprintf("Bookmark line %d with note (optional):", lineNum+1),
+		func(s *state.EditorState, note string) error {
+			return state.BookmarkCursorLine(s, note)
+		},
+		nil)
+
+	// Start with the existing note so the user can edit it.
+	if hasBookmark {
+		for _, r := range note {
+			state.AppendRuneToTextField(s, r)
+		}
+	}
+}
+
+func RemoveBookmark(s *state.EditorState) {
+	if err := state.RemoveBookmarkOnCursorLine(s); err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+	}
+}
+
+func ShowBookmarkMenu(s *state.EditorState) {
+	if err := state.ShowBookmarkMenu(s); err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+	}
+}
+
+func ShowScratchBufferMenu(s *state.EditorState) {
+	if err := state.ShowScratchBufferMenu(s); err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+	}
+}
+
+func ShowBackupMenu(s *state.EditorState) {
+	if err := state.ShowBackupMenu(s); err != nil {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+	}
+}
+
 func AppendRuneToTextField(r rune) Action {
 	return func(s *state.EditorState) {
 		state.AppendRuneToTextField(s, r)
@@ -938,6 +1835,84 @@ func Redo(s *state.EditorState) {
 	state.Redo(s)
 }
 
+func MoveToOlderUndoState(count uint64) Action {
+	return func(s *state.EditorState) {
+		state.MoveToOlderUndoState(s, count)
+	}
+}
+
+func MoveToNewerUndoState(count uint64) Action {
+	return func(s *state.EditorState) {
+		state.MoveToNewerUndoState(s, count)
+	}
+}
+
+func RevertToUndoCheckpoint(s *state.EditorState) {
+	state.RevertToUndoCheckpoint(s)
+}
+
+func EnterUndoPreviewMode(count uint64) Action {
+	return func(s *state.EditorState) {
+		state.EnterUndoPreviewMode(s, count)
+	}
+}
+
+func PreviewEarlierChange(count uint64) Action {
+	return func(s *state.EditorState) {
+		state.PreviewEarlierChange(s, count)
+	}
+}
+
+func PreviewLaterChange(count uint64) Action {
+	return func(s *state.EditorState) {
+		state.PreviewLaterChange(s, count)
+	}
+}
+
+func ChangesViewCursorUp(count uint64) Action {
+	return func(s *state.EditorState) {
+		state.MoveChangesViewCursor(s, func(params state.LocatorParams) uint64 {
+			return locate.StartOfLineAbove(params.TextTree, count, params.CursorPos)
+		})
+	}
+}
+
+func ChangesViewCursorDown(count uint64) Action {
+	return func(s *state.EditorState) {
+		state.MoveChangesViewCursor(s, func(params state.LocatorParams) uint64 {
+			return locate.StartOfLineBelow(params.TextTree, count, params.CursorPos)
+		})
+	}
+}
+
+func ChangesViewCursorStartOfDocument(s *state.EditorState) {
+	state.MoveChangesViewCursor(s, func(params state.LocatorParams) uint64 {
+		return 0
+	})
+}
+
+func ChangesViewCursorStartOfLastLine(s *state.EditorState) {
+	state.MoveChangesViewCursor(s, func(params state.LocatorParams) uint64 {
+		return locate.StartOfLastLine(params.TextTree)
+	})
+}
+
+func ChangesViewScrollUp(ctx Context, half bool) Action {
+	scrollLines := ctx.ScrollLines
+	if half {
+		scrollLines /= 2
+	}
+	return ChangesViewCursorUp(max(scrollLines, 1))
+}
+
+func ChangesViewScrollDown(ctx Context, half bool) Action {
+	scrollLines := ctx.ScrollLines
+	if half {
+		scrollLines /= 2
+	}
+	return ChangesViewCursorDown(max(scrollLines, 1))
+}
+
 func ToggleVisualModeCharwise(s *state.EditorState) {
 	state.ToggleVisualMode(s, selection.ModeChar)
 }
@@ -973,6 +1948,22 @@ func ToggleCaseInSelectionAndReturnToNormalMode(selectionEndLoc state.Locator) A
 	}
 }
 
+func UpperCaseInSelectionAndReturnToNormalMode(selectionEndLoc state.Locator) Action {
+	return func(s *state.EditorState) {
+		state.MoveCursorToStartOfSelection(s)
+		state.UpperCaseInSelection(s, selectionEndLoc)
+		ReturnToNormalMode(s)
+	}
+}
+
+func LowerCaseInSelectionAndReturnToNormalMode(selectionEndLoc state.Locator) Action {
+	return func(s *state.EditorState) {
+		state.MoveCursorToStartOfSelection(s)
+		state.LowerCaseInSelection(s, selectionEndLoc)
+		ReturnToNormalMode(s)
+	}
+}
+
 func IndentSelectionAndReturnToNormalMode(selectionEndLoc state.Locator, count uint64) Action {
 	return func(s *state.EditorState) {
 		state.MoveCursorToStartOfSelection(s)
@@ -1052,6 +2043,14 @@ func SelectAngleBlock(includeAngleBrackets bool) Action {
 	}
 }
 
+func SelectNextOccurrence(s *state.EditorState) {
+	state.SelectNextOccurrence(s)
+}
+
+func ShowCursorInfo(s *state.EditorState) {
+	state.ShowCursorInfo(s)
+}
+
 func ReplayLastActionMacro(count uint64) Action {
 	return func(s *state.EditorState) {
 		state.ReplayLastActionMacro(s, count)
@@ -1,7 +1,9 @@
 package input
 
 import (
+	"fmt"
 	"math"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 
@@ -217,9 +219,9 @@ func cursorCommands() []Command {
 			},
 		},
 		{
-			Name: "cursor line start (0)",
+			Name: "cursor line start (0 or home)",
 			BuildExpr: func() engine.Expr {
-				return cmdExpr("0", "", captureOpts{})
+				return altExpr(cmdExpr("0", "", captureOpts{}), keyExpr(tcell.KeyHome))
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorate(CursorLineStart)
@@ -235,9 +237,9 @@ func cursorCommands() []Command {
 			},
 		},
 		{
-			Name: "cursor line end ($)",
+			Name: "cursor line end ($ or end)",
 			BuildExpr: func() engine.Expr {
-				return cmdExpr("$", "", captureOpts{})
+				return altExpr(cmdExpr("$", "", captureOpts{}), keyExpr(tcell.KeyEnd))
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorate(CursorLineEnd)
@@ -256,9 +258,18 @@ func cursorCommands() []Command {
 			},
 		},
 		{
-			Name: "cursor start of last line (G)",
+			Name: "cursor start of first line (ctrl-home)",
 			BuildExpr: func() engine.Expr {
-				return cmdExpr("G", "", captureOpts{})
+				return keyExpr(keyCtrlHome)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(CursorStartOfLineNum(1))
+			},
+		},
+		{
+			Name: "cursor start of last line (G or ctrl-end)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(cmdExpr("G", "", captureOpts{}), keyExpr(keyCtrlEnd))
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorate(CursorStartOfLastLine)
@@ -319,18 +330,18 @@ func cursorCommands() []Command {
 			},
 		},
 		{
-			Name: "scroll forward (ctrl-f)",
+			Name: "scroll forward (ctrl-f or page down)",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyCtrlF)
+				return altExpr(keyExpr(tcell.KeyCtrlF), keyExpr(tcell.KeyPgDn))
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorate(ScrollDown(ctx, false))
 			},
 		},
 		{
-			Name: "scroll back (ctrl-b)",
+			Name: "scroll back (ctrl-b or page up)",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyCtrlB)
+				return altExpr(keyExpr(tcell.KeyCtrlB), keyExpr(tcell.KeyPgUp))
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorate(ScrollUp(ctx, false))
@@ -366,9 +377,9 @@ func decorateNormalOrVisual(action Action, addToMacro addToMacro) Action {
 
 		wrappedAction(s)
 
-		// Commit the undo entry UNLESS in insert or search mode, in which case wait until
+		// Commit the undo entry UNLESS in insert, replace, or search mode, in which case wait until
 		// the transition back to normal mode to commit.
-		if s.InputMode() != state.InputModeInsert && s.InputMode() != state.InputModeSearch {
+		if s.InputMode() != state.InputModeInsert && s.InputMode() != state.InputModeReplace && s.InputMode() != state.InputModeSearch {
 			state.CommitUndoEntry(s)
 		}
 
@@ -399,7 +410,7 @@ func decorateUndoOrRedo(action Action) Action {
 }
 
 func NormalModeCommands() []Command {
-	return append(cursorCommands(), []Command{
+	commands := append(cursorCommands(), []Command{
 		{
 			Name: "enter insert mode (i)",
 			BuildExpr: func() engine.Expr {
@@ -641,6 +652,17 @@ func NormalModeCommands() []Command {
 					addToMacro{lastAction: true, user: true})
 			},
 		},
+		{
+			Name: "delete to matching code block delimiter (d%)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("d", "%", captureOpts{clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					DeleteToMatchingCodeBlockDelimiter(p.ClipboardPage),
+					addToMacro{lastAction: true, user: true})
+			},
+		},
 		{
 			Name: "delete to start of next word (dw)",
 			BuildExpr: func() engine.Expr {
@@ -986,6 +1008,17 @@ func NormalModeCommands() []Command {
 					addToMacro{lastAction: true, user: true})
 			},
 		},
+		{
+			Name: "change to matching code block delimiter (c%)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("c", "%", captureOpts{clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ChangeToMatchingCodeBlockDelimiter(p.ClipboardPage),
+					addToMacro{lastAction: true, user: true})
+			},
+		},
 		{
 			Name: "change inner paren block (cib)",
 			BuildExpr: func() engine.Expr {
@@ -1077,11 +1110,23 @@ func NormalModeCommands() []Command {
 		{
 			Name: "replace character (r)",
 			BuildExpr: func() engine.Expr {
-				return cmdExpr("r", "", captureOpts{replaceChar: true})
+				return cmdExpr("r", "", captureOpts{count: true, replaceChar: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ReplaceCharacter(p.ReplaceChar, p.Count),
+					addToMacro{lastAction: true, user: true})
+			},
+		},
+		{
+			Name: "enter replace mode (R)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("R", "", captureOpts{})
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorateNormalOrVisual(
-					ReplaceCharacter(p.ReplaceChar),
+					EnterReplaceMode,
 					addToMacro{lastAction: true, user: true})
 			},
 		},
@@ -1289,6 +1334,17 @@ func NormalModeCommands() []Command {
 					addToMacro{lastAction: true, user: true})
 			},
 		},
+		{
+			Name: "yank to matching code block delimiter (y%)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("y", "%", captureOpts{clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					CopyToMatchingCodeBlockDelimiter(p.ClipboardPage),
+					addToMacro{lastAction: true, user: true})
+			},
+		},
 		{
 			Name: "put after cursor (p)",
 			BuildExpr: func() engine.Expr {
@@ -1311,6 +1367,27 @@ func NormalModeCommands() []Command {
 					addToMacro{lastAction: true, user: true})
 			},
 		},
+		{
+			Name: "quit pager (q)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('q')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				if !ctx.PagerMode {
+					return EmptyAction
+				}
+				return state.Quit
+			},
+		},
+		{
+			Name: "cancel background shell command (esc)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEscape)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.CancelOutputCmdInActiveBuffer
+			},
+		},
 		{
 			Name: "show command menu (:)",
 			BuildExpr: func() engine.Expr {
@@ -1322,6 +1399,118 @@ func NormalModeCommands() []Command {
 					addToMacro{})
 			},
 		},
+		{
+			Name: "split horizontally (ctrl-w s)",
+			BuildExpr: func() engine.Expr {
+				return splitKeyExpr('s')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(state.SplitHorizontal, addToMacro{})
+			},
+		},
+		{
+			Name: "split vertically (ctrl-w v)",
+			BuildExpr: func() engine.Expr {
+				return splitKeyExpr('v')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(state.SplitVertical, addToMacro{})
+			},
+		},
+		{
+			Name: "focus next split (ctrl-w w or ctrl-w ctrl-w)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(splitKeyExpr('w'), engine.ConcatExpr{Children: []engine.Expr{keyExpr(tcell.KeyCtrlW), keyExpr(tcell.KeyCtrlW)}})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(state.FocusNextSplit, addToMacro{})
+			},
+		},
+		{
+			Name: "focus previous split (ctrl-w W)",
+			BuildExpr: func() engine.Expr {
+				return splitKeyExpr('W')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(state.FocusPrevSplit, addToMacro{})
+			},
+		},
+		{
+			Name: "close split (ctrl-w c)",
+			BuildExpr: func() engine.Expr {
+				return splitKeyExpr('c')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(state.CloseSplit, addToMacro{})
+			},
+		},
+		{
+			Name: "close other splits (ctrl-w o)",
+			BuildExpr: func() engine.Expr {
+				return splitKeyExpr('o')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(state.CloseOtherSplits, addToMacro{})
+			},
+		},
+		{
+			Name: "increase split height (ctrl-w +)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(splitKeyExpr('+'))
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(ResizeSplitHeight(int(p.Count)), addToMacro{})
+			},
+		},
+		{
+			Name: "decrease split height (ctrl-w -)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(splitKeyExpr('-'))
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(ResizeSplitHeight(-int(p.Count)), addToMacro{})
+			},
+		},
+		{
+			Name: "increase split width (ctrl-w >)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(splitKeyExpr('>'))
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(ResizeSplitWidth(int(p.Count)), addToMacro{})
+			},
+		},
+		{
+			Name: "decrease split width (ctrl-w <)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(splitKeyExpr('<'))
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(ResizeSplitWidth(-int(p.Count)), addToMacro{})
+			},
+		},
+		{
+			Name: "toggle split zoom (ctrl-w z)",
+			BuildExpr: func() engine.Expr {
+				return splitKeyExpr('z')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(state.ToggleSplitZoom, addToMacro{})
+			},
+		},
+		{
+			Name: "equalize splits (ctrl-w =)",
+			BuildExpr: func() engine.Expr {
+				return splitKeyExpr('=')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(state.EqualizeSplits, addToMacro{})
+			},
+		},
 		{
 			Name: "start forward search (/)",
 			BuildExpr: func() engine.Expr {
@@ -1454,6 +1643,72 @@ func NormalModeCommands() []Command {
 					addToMacro{user: true})
 			},
 		},
+		{
+			Name: "search forward for word under cursor and delete (d*)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("d", "*", captureOpts{count: true, clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SearchWordUnderCursorForDelete(state.SearchDirectionForward, p.Count, p.ClipboardPage),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "search backward for word under cursor and delete (d#)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("d", "#", captureOpts{count: true, clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SearchWordUnderCursorForDelete(state.SearchDirectionBackward, p.Count, p.ClipboardPage),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "search forward for word under cursor and change (c*)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("c", "*", captureOpts{count: true, clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SearchWordUnderCursorForChange(state.SearchDirectionForward, p.Count, p.ClipboardPage),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "search backward for word under cursor and change (c#)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("c", "#", captureOpts{count: true, clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SearchWordUnderCursorForChange(state.SearchDirectionBackward, p.Count, p.ClipboardPage),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "search forward for word under cursor and yank (y*)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("y", "*", captureOpts{count: true, clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SearchWordUnderCursorForCopy(state.SearchDirectionForward, p.Count, p.ClipboardPage),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "search backward for word under cursor and yank (y#)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("y", "#", captureOpts{count: true, clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SearchWordUnderCursorForCopy(state.SearchDirectionBackward, p.Count, p.ClipboardPage),
+					addToMacro{user: true})
+			},
+		},
 		{
 			Name: "undo (u)",
 			BuildExpr: func() engine.Expr {
@@ -1472,6 +1727,45 @@ func NormalModeCommands() []Command {
 				return decorateUndoOrRedo(Redo)
 			},
 		},
+		{
+			Name: "preview undo (g-)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("g-", "", captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return EnterUndoPreviewMode(p.Count)
+			},
+		},
+		{
+			Name: "move to older undo state (g[)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("g[", "", captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateUndoOrRedo(MoveToOlderUndoState(p.Count))
+			},
+		},
+		{
+			Name: "move to newer undo state (g])",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("g]", "", captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateUndoOrRedo(MoveToNewerUndoState(p.Count))
+			},
+		},
+		{
+			Name: "revert to checkpoint (U)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('U')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateUndoOrRedo(RevertToUndoCheckpoint)
+			},
+		},
 		{
 			Name: "enter visual mode charwise (v)",
 			BuildExpr: func() engine.Expr {
@@ -1506,9 +1800,45 @@ func NormalModeCommands() []Command {
 					addToMacro{})
 			},
 		},
+		{
+			Name: "show cursor info (ga)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("ga", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ShowCursorInfo,
+					addToMacro{})
+			},
+		},
 	}...)
+	return append(commands, operatorCommands()...)
 }
 
+// operatorCommands apply user-defined operators to a motion or text object.
+func operatorCommands() []Command {
+	targets := operatorTargets()
+	commands := make([]Command, 0, len(targets))
+	for _, target := range targets {
+		objectsDesc := strings.Join(target.objects, ", g@")
+		commands = append(commands, Command{
+			Name: fmt.Sprintf("apply operator %s (g@%s)", target.name, objectsDesc),
+			BuildExpr: func() engine.Expr {
+				exprs := make([]engine.Expr, 0, len(target.objects))
+				for _, object := range target.objects {
+					exprs = append(exprs, cmdExpr("g@", object, captureOpts{count: target.withCount}))
+				}
+				return altExpr(exprs...)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ShowOperatorMenu(target.buildLoc(p.Count)),
+					addToMacro{})
+			},
+		})
+	}
+	return commands
+}
 func VisualModeCommands() []Command {
 	return append(cursorCommands(), []Command{
 		{
@@ -1555,6 +1885,17 @@ func VisualModeCommands() []Command {
 					addToMacro{})
 			},
 		},
+		{
+			Name: "apply operator to selection (g@)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("g@", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ShowOperatorMenuForSelection,
+					addToMacro{})
+			},
+		},
 		{
 			Name: "delete selection (x or d)",
 			BuildExpr: func() engine.Expr {
@@ -1612,50 +1953,83 @@ func VisualModeCommands() []Command {
 			},
 		},
 		{
-			Name: "indent selection (>)",
+			Name: "filter selection through shell command (!)",
 			BuildExpr: func() engine.Expr {
-				return cmdExpr(">", "", captureOpts{count: true})
+				return cmdExpr("!", "", captureOpts{})
 			},
-			MaxCount: 32, // Reparsing is expensive, so set this lower.
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorateNormalOrVisual(
-					IndentSelectionAndReturnToNormalMode(ctx.SelectionEndLocator, p.Count),
-					addToMacro{lastAction: true, user: true})
+					ShowFilterSelectionTextField,
+					addToMacro{})
 			},
 		},
 		{
-			Name: "outdent selection (<)",
+			Name: "uppercase selection (U)",
 			BuildExpr: func() engine.Expr {
-				return cmdExpr("<", "", captureOpts{count: true})
+				return cmdExpr("U", "", captureOpts{})
 			},
-			MaxCount: 32, // Reparsing is expensive, so set this lower.
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorateNormalOrVisual(
-					OutdentSelectionAndReturnToNormalMode(ctx.SelectionEndLocator, p.Count),
+					UpperCaseInSelectionAndReturnToNormalMode(ctx.SelectionEndLocator),
 					addToMacro{lastAction: true, user: true})
 			},
 		},
 		{
-			Name: "yank selection (y)",
+			Name: "lowercase selection (u)",
 			BuildExpr: func() engine.Expr {
-				return cmdExpr("y", "", captureOpts{clipboardPage: true})
+				return cmdExpr("u", "", captureOpts{})
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorateNormalOrVisual(
-					CopySelectionAndReturnToNormalMode(p.ClipboardPage),
-					addToMacro{user: true})
+					LowerCaseInSelectionAndReturnToNormalMode(ctx.SelectionEndLocator),
+					addToMacro{lastAction: true, user: true})
 			},
 		},
 		{
-			Name: "select inner word (iw)",
+			Name: "indent selection (>)",
 			BuildExpr: func() engine.Expr {
-				return cmdExpr("iw", "", captureOpts{count: true})
+				return cmdExpr(">", "", captureOpts{count: true})
 			},
+			MaxCount: 32, // Reparsing is expensive, so set this lower.
 			BuildAction: func(ctx Context, p CommandParams) Action {
 				return decorateNormalOrVisual(
-					SelectInnerWord(p.Count),
-					addToMacro{user: true})
-			},
+					IndentSelectionAndReturnToNormalMode(ctx.SelectionEndLocator, p.Count),
+					addToMacro{lastAction: true, user: true})
+			},
+		},
+		{
+			Name: "outdent selection (<)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("<", "", captureOpts{count: true})
+			},
+			MaxCount: 32, // Reparsing is expensive, so set this lower.
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					OutdentSelectionAndReturnToNormalMode(ctx.SelectionEndLocator, p.Count),
+					addToMacro{lastAction: true, user: true})
+			},
+		},
+		{
+			Name: "yank selection (y)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("y", "", captureOpts{clipboardPage: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					CopySelectionAndReturnToNormalMode(p.ClipboardPage),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "select inner word (iw)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("iw", "", captureOpts{count: true})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SelectInnerWord(p.Count),
+					addToMacro{user: true})
+			},
 		},
 		{
 			Name: "select a word (aw)",
@@ -1822,6 +2196,17 @@ func VisualModeCommands() []Command {
 					addToMacro{user: true})
 			},
 		},
+		{
+			Name: "select next occurrence of selection (ctrl-n)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlN)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SelectNextOccurrence,
+					addToMacro{user: true})
+			},
+		},
 	}...)
 }
 
@@ -1845,7 +2230,7 @@ func InsertModeCommands() []Command {
 				return insertExpr
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(InsertRune(p.InsertChar))
+				return decorate(ReplaceInsertModeSelection(InsertRune(p.InsertChar)))
 			},
 		},
 		{
@@ -1854,7 +2239,7 @@ func InsertModeCommands() []Command {
 				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(DeletePrevChar(clipboard.PageNull))
+				return decorate(DeleteInsertModeSelectionOr(DeletePrevChar(clipboard.PageNull)))
 			},
 		},
 		{
@@ -1863,7 +2248,25 @@ func InsertModeCommands() []Command {
 				return keyExpr(tcell.KeyDelete)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(DeleteNextCharInLine(1, clipboard.PageNull))
+				return decorate(DeleteInsertModeSelectionOr(DeleteNextCharInLine(1, clipboard.PageNull)))
+			},
+		},
+		{
+			Name: "delete prev word (ctrl-w)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlW)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(DeleteInsertModeSelectionOr(DeletePrevWordInLine(clipboard.PageNull)))
+			},
+		},
+		{
+			Name: "delete to start of inserted text or line (ctrl-u)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlU)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(DeleteInsertModeSelectionOr(DeleteToInsertStartOrLineStart(clipboard.PageNull)))
 			},
 		},
 		{
@@ -1872,7 +2275,7 @@ func InsertModeCommands() []Command {
 				return keyExpr(tcell.KeyEnter)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(InsertNewlineAndUpdateAutoIndentWhitespace)
+				return decorate(ReplaceInsertModeSelection(InsertNewlineAndUpdateAutoIndentWhitespace))
 			},
 		},
 		{
@@ -1881,7 +2284,34 @@ func InsertModeCommands() []Command {
 				return keyExpr(tcell.KeyTab)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(InsertTab)
+				return decorate(ReplaceInsertModeSelection(InsertTab))
+			},
+		},
+		{
+			Name: "autocomplete next word (ctrl-n)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlN)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(StartAutocomplete(true))
+			},
+		},
+		{
+			Name: "autocomplete previous word (ctrl-p)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlP)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(StartAutocomplete(false))
+			},
+		},
+		{
+			Name: "insert timestamp (ctrl-t)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlT)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(ReplaceInsertModeSelection(InsertTimestamp))
 			},
 		},
 		{
@@ -1890,7 +2320,7 @@ func InsertModeCommands() []Command {
 				return keyExpr(tcell.KeyLeft)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(CursorLeft(1))
+				return decorate(ClearInsertModeSelectionThen(CursorLeft(1)))
 			},
 		},
 		{
@@ -1899,7 +2329,7 @@ func InsertModeCommands() []Command {
 				return keyExpr(tcell.KeyRight)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(CursorRightIncludeEndOfLineOrFile)
+				return decorate(ClearInsertModeSelectionThen(CursorRightIncludeEndOfLineOrFile))
 			},
 		},
 		{
@@ -1908,7 +2338,7 @@ func InsertModeCommands() []Command {
 				return keyExpr(tcell.KeyUp)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(CursorUp(1))
+				return decorate(ClearInsertModeSelectionThen(CursorUp(1)))
 			},
 		},
 		{
@@ -1917,206 +2347,926 @@ func InsertModeCommands() []Command {
 				return keyExpr(tcell.KeyDown)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(CursorDown(1))
+				return decorate(ClearInsertModeSelectionThen(CursorDown(1)))
 			},
 		},
 		{
-			Name: "escape to normal mode",
+			Name: "cursor line start",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyEscape)
+				return keyExpr(tcell.KeyHome)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(ReturnToNormalModeAfterInsert)
+				return decorate(ClearInsertModeSelectionThen(CursorLineStart))
 			},
 		},
-	}
-}
-
-func MenuModeCommands() []Command {
-	return []Command{
 		{
-			Name: "escape to normal mode",
+			Name: "cursor line end",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyEscape)
+				return keyExpr(tcell.KeyEnd)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return HideMenu
+				return decorate(ClearInsertModeSelectionThen(CursorLineEndIncludeEndOfLineOrFile))
 			},
 		},
 		{
-			Name: "execute menu item",
+			Name: "cursor start of document",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyEnter)
+				return keyExpr(keyCtrlHome)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return ExecuteSelectedMenuItem
+				return decorate(ClearInsertModeSelectionThen(CursorStartOfDocument))
 			},
 		},
 		{
-			Name: "move menu selection up",
+			Name: "cursor end of document",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyUp)
+				return keyExpr(keyCtrlEnd)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return MenuSelectionUp
+				return decorate(ClearInsertModeSelectionThen(CursorEndOfDocument))
 			},
 		},
 		{
-			Name: "move menu selection down",
+			Name: "scroll back",
 			BuildExpr: func() engine.Expr {
-				return altExpr(keyExpr(tcell.KeyDown), keyExpr(tcell.KeyTab))
+				return keyExpr(tcell.KeyPgUp)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return MenuSelectionDown
+				return decorate(ClearInsertModeSelectionThen(ScrollUp(ctx, false)))
 			},
 		},
 		{
-			Name: "insert char to menu query",
+			Name: "scroll forward",
 			BuildExpr: func() engine.Expr {
-				return insertExpr
+				return keyExpr(tcell.KeyPgDn)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return AppendRuneToMenuSearch(p.InsertChar)
+				return decorate(ClearInsertModeSelectionThen(ScrollDown(ctx, false)))
 			},
 		},
 		{
-			Name: "delete char from menu query",
+			Name: "select left (shift+left arrow)",
 			BuildExpr: func() engine.Expr {
-				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
+				return keyExpr(keyShiftLeft)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return DeleteRuneFromMenuSearch
+				return decorate(SelectInInsertMode(CursorLeft(1)))
+			},
+		},
+		{
+			Name: "select right (shift+right arrow)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(keyShiftRight)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(SelectInInsertMode(CursorRightIncludeEndOfLineOrFile))
+			},
+		},
+		{
+			Name: "select up (shift+up arrow)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(keyShiftUp)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(SelectInInsertMode(CursorUp(1)))
+			},
+		},
+		{
+			Name: "select down (shift+down arrow)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(keyShiftDown)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(SelectInInsertMode(CursorDown(1)))
+			},
+		},
+		{
+			Name: "select to line start (shift+home)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(keyShiftHome)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(SelectInInsertMode(CursorLineStart))
+			},
+		},
+		{
+			Name: "select to line end (shift+end)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(keyShiftEnd)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(SelectInInsertMode(CursorLineEndIncludeEndOfLineOrFile))
+			},
+		},
+		{
+			Name: "copy selection (ctrl-c)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlC)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				if !ctx.InsertModeSelection {
+					return EmptyAction
+				}
+				return decorate(CopyInsertModeSelection)
+			},
+		},
+		{
+			Name: "cut selection (ctrl-x)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlX)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				if !ctx.InsertModeSelection {
+					return EmptyAction
+				}
+				return decorate(CutInsertModeSelection)
+			},
+		},
+		{
+			Name: "paste (ctrl-v)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlV)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				if !ctx.InsertModeSelection {
+					return EmptyAction
+				}
+				return decorate(PasteInInsertMode)
+			},
+		},
+		{
+			Name: "escape to normal mode",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEscape)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(ReturnToNormalModeAfterInsert)
 			},
 		},
 	}
 }
 
-func SearchModeCommands() []Command {
+func ReplaceModeCommands() []Command {
 	decorate := func(action Action) Action {
 		return func(s *state.EditorState) {
-			action(s)
-			state.AddToRecordingUserMacro(s, state.MacroAction(action))
+			wrappedAction := func(s *state.EditorState) {
+				action(s)
+				state.ScrollViewToCursor(s)
+			}
+			wrappedAction(s)
+			state.AddToLastActionMacro(s, state.MacroAction(wrappedAction))
+			state.AddToRecordingUserMacro(s, state.MacroAction(wrappedAction))
 		}
 	}
 
 	return []Command{
 		{
-			Name: "abort search (esc)",
+			Name: "replace rune",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyEscape)
+				return insertExpr
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(AbortSearch)
+				return decorate(ReplaceModeInsertText(string(p.InsertChar)))
 			},
 		},
 		{
-			Name: "commit search",
+			Name: "restore prev char",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyEnter)
+				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(CompleteSearch)
+				return decorate(state.ReplaceModeDeletePrevChar)
 			},
 		},
 		{
-			Name: "insert char to search query",
+			Name: "delete next char",
 			BuildExpr: func() engine.Expr {
-				return insertExpr
+				return keyExpr(tcell.KeyDelete)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(AppendRuneToSearchQuery(p.InsertChar))
+				return decorate(DeleteNextCharInLine(1, clipboard.PageNull))
 			},
 		},
 		{
-			Name: "delete char from search query",
+			Name: "insert newline",
 			BuildExpr: func() engine.Expr {
-				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
+				return keyExpr(tcell.KeyEnter)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				// This returns the input mode to normal if the search query is empty.
-				return decorate(DeleteRuneFromSearchQuery)
+				return decorate(ReplaceModeInsertText("\n"))
 			},
 		},
 		{
-			Name: "previous search query in history",
+			Name: "replace with tab",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyUp)
+				return keyExpr(tcell.KeyTab)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(state.SetSearchQueryToPrevInHistory)
+				return decorate(ReplaceModeInsertText("\t"))
 			},
 		},
 		{
-			Name: "next search query in history",
+			Name: "cursor left",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyDown)
+				return keyExpr(tcell.KeyLeft)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return decorate(state.SetSearchQueryToNextInHistory)
+				return decorate(CursorLeft(1))
 			},
 		},
-	}
-}
-
-func TaskModeCommands() []Command {
-	return []Command{
 		{
-			Name: "cancel task",
+			Name: "cursor right",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyEscape)
+				return keyExpr(tcell.KeyRight)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return state.CancelTaskIfRunning
+				return decorate(CursorRightIncludeEndOfLineOrFile)
 			},
 		},
-	}
-}
-
-func TextFieldCommands() []Command {
-	return []Command{
 		{
-			Name: "escape",
+			Name: "cursor up",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyEscape)
+				return keyExpr(tcell.KeyUp)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return state.HideTextField
+				return decorate(CursorUp(1))
 			},
 		},
 		{
-			Name: "append char to textfield",
+			Name: "cursor down",
 			BuildExpr: func() engine.Expr {
-				return insertExpr
+				return keyExpr(tcell.KeyDown)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return AppendRuneToTextField(p.InsertChar)
+				return decorate(CursorDown(1))
 			},
 		},
 		{
-			Name: "delete char from textfield",
+			Name: "cursor line start",
 			BuildExpr: func() engine.Expr {
-				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
+				return keyExpr(tcell.KeyHome)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return state.DeleteRuneFromTextField
+				return decorate(CursorLineStart)
 			},
 		},
 		{
-			Name: "execute textfield action",
+			Name: "cursor line end",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyEnter)
+				return keyExpr(tcell.KeyEnd)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return state.ExecuteTextFieldAction
+				return decorate(CursorLineEndIncludeEndOfLineOrFile)
 			},
 		},
 		{
-			Name: "autocomplete",
+			Name: "escape to normal mode",
 			BuildExpr: func() engine.Expr {
-				return keyExpr(tcell.KeyTab)
+				return keyExpr(tcell.KeyEscape)
 			},
 			BuildAction: func(ctx Context, p CommandParams) Action {
-				return state.AutocompleteTextField
+				return decorate(ReturnToNormalModeAfterReplace)
+			},
+		},
+	}
+}
+
+func MenuModeCommands() []Command {
+	return []Command{
+		{
+			Name: "escape to normal mode",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEscape)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return HideMenu
+			},
+		},
+		{
+			Name: "execute menu item",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEnter)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ExecuteSelectedMenuItem
+			},
+		},
+		{
+			Name: "move menu selection up",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyUp)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return MenuSelectionUp
+			},
+		},
+		{
+			Name: "move menu selection down",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyDown), keyExpr(tcell.KeyTab))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return MenuSelectionDown
+			},
+		},
+		{
+			Name: "move menu selection to first item",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyHome), keyExpr(keyCtrlHome))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return MenuSelectionFirst
+			},
+		},
+		{
+			Name: "move menu selection to last item",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyEnd), keyExpr(keyCtrlEnd))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return MenuSelectionLast
+			},
+		},
+		{
+			Name: "move menu selection up one page",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyPgUp)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return MenuSelectionPageUp(ctx)
+			},
+		},
+		{
+			Name: "move menu selection down one page",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyPgDn)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return MenuSelectionPageDown(ctx)
+			},
+		},
+		{
+			Name: "insert char to menu query",
+			BuildExpr: func() engine.Expr {
+				return insertExpr
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return AppendRuneToMenuSearch(p.InsertChar)
+			},
+		},
+		{
+			Name: "delete char from menu query",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return DeleteRuneFromMenuSearch
+			},
+		},
+	}
+}
+
+func SearchModeCommands() []Command {
+	decorate := func(action Action) Action {
+		return func(s *state.EditorState) {
+			action(s)
+			state.AddToRecordingUserMacro(s, state.MacroAction(action))
+		}
+	}
+
+	return []Command{
+		{
+			Name: "abort search (esc)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEscape)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(AbortSearch)
+			},
+		},
+		{
+			Name: "commit search",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEnter)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(CompleteSearch)
+			},
+		},
+		{
+			Name: "insert char to search query",
+			BuildExpr: func() engine.Expr {
+				return insertExpr
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(AppendRuneToSearchQuery(p.InsertChar))
+			},
+		},
+		{
+			Name: "delete char from search query",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				// This returns the input mode to normal if the search query is empty.
+				return decorate(DeleteRuneFromSearchQuery)
+			},
+		},
+		{
+			Name: "previous search query in history",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyUp)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(state.SetSearchQueryToPrevInHistory)
+			},
+		},
+		{
+			Name: "next search query in history",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyDown)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(state.SetSearchQueryToNextInHistory)
+			},
+		},
+	}
+}
+
+func TaskModeCommands() []Command {
+	return []Command{
+		{
+			Name: "cancel task",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEscape)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.CancelTaskIfRunning
+			},
+		},
+	}
+}
+
+func TextFieldCommands() []Command {
+	return []Command{
+		{
+			Name: "escape",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEscape)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.HideTextField
+			},
+		},
+		{
+			Name: "append char to textfield",
+			BuildExpr: func() engine.Expr {
+				return insertExpr
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return AppendRuneToTextField(p.InsertChar)
+			},
+		},
+		{
+			Name: "delete char from textfield",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.DeleteRuneFromTextField
+			},
+		},
+		{
+			Name: "execute textfield action",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEnter)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.ExecuteTextFieldAction
+			},
+		},
+		{
+			Name: "autocomplete",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyTab)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.AutocompleteTextField
+			},
+		},
+	}
+}
+
+func UndoPreviewModeCommands() []Command {
+	decorate := func(action Action) Action {
+		return func(s *state.EditorState) {
+			action(s)
+			state.ScrollViewToCursor(s)
+		}
+	}
+
+	return []Command{
+		{
+			Name: "preview earlier change (u or h or left arrow)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(altExpr(runeExpr('u'), runeExpr('h'), keyExpr(tcell.KeyLeft)))
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return PreviewEarlierChange(p.Count)
+			},
+		},
+		{
+			Name: "preview later change (ctrl-r or l or right arrow)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(altExpr(keyExpr(tcell.KeyCtrlR), runeExpr('l'), keyExpr(tcell.KeyRight)))
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return PreviewLaterChange(p.Count)
+			},
+		},
+		{
+			Name: "cursor up (up arrow or k)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(altExpr(keyExpr(tcell.KeyUp), runeExpr('k')))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(CursorUp(p.Count))
+			},
+		},
+		{
+			Name: "cursor down (down arrow or j)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(altExpr(keyExpr(tcell.KeyDown), runeExpr('j')))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(CursorDown(p.Count))
+			},
+		},
+		{
+			Name: "scroll up (ctrl-u)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlU)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(ScrollUp(ctx, true))
+			},
+		},
+		{
+			Name: "scroll down (ctrl-d)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlD)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(ScrollDown(ctx, true))
+			},
+		},
+		{
+			Name: "scroll forward (ctrl-f or page down)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyCtrlF), keyExpr(tcell.KeyPgDn))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(ScrollDown(ctx, false))
+			},
+		},
+		{
+			Name: "scroll back (ctrl-b or page up)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyCtrlB), keyExpr(tcell.KeyPgUp))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(ScrollUp(ctx, false))
+			},
+		},
+		{
+			Name: "commit undo (enter)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEnter)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.CommitUndoPreview
+			},
+		},
+		{
+			Name: "cancel undo preview (escape)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEscape)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.CancelUndoPreview
+			},
+		},
+	}
+}
+
+func ChangesModeCommands() []Command {
+	return []Command{
+		{
+			Name: "cursor up (up arrow or k)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(altExpr(keyExpr(tcell.KeyUp), runeExpr('k')))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ChangesViewCursorUp(p.Count)
+			},
+		},
+		{
+			Name: "cursor down (down arrow or j)",
+			BuildExpr: func() engine.Expr {
+				return verbCountThenExpr(altExpr(keyExpr(tcell.KeyDown), runeExpr('j')))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ChangesViewCursorDown(p.Count)
+			},
+		},
+		{
+			Name: "cursor to first line (gg)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("gg", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ChangesViewCursorStartOfDocument
+			},
+		},
+		{
+			Name: "cursor to last line (G)",
+			BuildExpr: func() engine.Expr {
+				return cmdExpr("G", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ChangesViewCursorStartOfLastLine
+			},
+		},
+		{
+			Name: "scroll up (ctrl-u)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlU)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ChangesViewScrollUp(ctx, true)
+			},
+		},
+		{
+			Name: "scroll down (ctrl-d)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlD)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ChangesViewScrollDown(ctx, true)
+			},
+		},
+		{
+			Name: "scroll forward (ctrl-f)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlF)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ChangesViewScrollDown(ctx, false)
+			},
+		},
+		{
+			Name: "scroll back (ctrl-b)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlB)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ChangesViewScrollUp(ctx, false)
+			},
+		},
+		{
+			Name: "jump to change (enter)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEnter)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.JumpToChange
+			},
+		},
+		{
+			Name: "close changes (escape or q)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyEscape), runeExpr('q'))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.CloseChangesView
+			},
+		},
+	}
+}
+
+func ReplaceConfirmModeCommands() []Command {
+	return []Command{
+		{
+			Name: "replace match (y)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('y')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.ConfirmReplace
+			},
+		},
+		{
+			Name: "skip match (n)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('n')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.SkipReplace
+			},
+		},
+		{
+			Name: "replace all remaining matches (a)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('a')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.ReplaceAllRemaining
+			},
+		},
+		{
+			Name: "stop replacing (q or escape)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(runeExpr('q'), keyExpr(tcell.KeyEscape))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.StopReplace
+			},
+		},
+	}
+}
+
+func AutocompleteModeCommands() []Command {
+	decorate := func(action Action) Action {
+		return func(s *state.EditorState) {
+			wrappedAction := func(s *state.EditorState) {
+				action(s)
+				state.ScrollViewToCursor(s)
+			}
+			wrappedAction(s)
+			state.AddToLastActionMacro(s, state.MacroAction(wrappedAction))
+			state.AddToRecordingUserMacro(s, state.MacroAction(wrappedAction))
+		}
+	}
+
+	return []Command{
+		{
+			Name: "next completion (ctrl-n or down arrow)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyCtrlN), keyExpr(tcell.KeyDown))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(MoveAutocompleteSelection(1))
+			},
+		},
+		{
+			Name: "previous completion (ctrl-p or up arrow)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyCtrlP), keyExpr(tcell.KeyUp))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(MoveAutocompleteSelection(-1))
+			},
+		},
+		{
+			Name: "accept completion (enter or ctrl-y)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyEnter), keyExpr(tcell.KeyCtrlY))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(state.AcceptAutocomplete)
+			},
+		},
+		{
+			Name: "cancel completion (ctrl-e)",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyCtrlE)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(state.CancelAutocomplete)
+			},
+		},
+		{
+			Name: "accept completion and insert rune",
+			BuildExpr: func() engine.Expr {
+				return insertExpr
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(AcceptAutocompleteThen(InsertRune(p.InsertChar)))
+			},
+		},
+		{
+			Name: "accept completion and insert tab",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyTab)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(AcceptAutocompleteThen(InsertTab))
+			},
+		},
+		{
+			Name: "accept completion and delete prev char",
+			BuildExpr: func() engine.Expr {
+				return altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(AcceptAutocompleteThen(DeletePrevChar(clipboard.PageNull)))
+			},
+		},
+		{
+			Name: "accept completion and escape to normal mode",
+			BuildExpr: func() engine.Expr {
+				return keyExpr(tcell.KeyEscape)
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorate(AcceptAutocompleteThen(ReturnToNormalModeAfterInsert))
+			},
+		},
+	}
+}
+
+func FileExplorerModeCommands() []Command {
+	return []Command{
+		{
+			Name: "file explorer up (k or up arrow)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(runeExpr('k'), keyExpr(tcell.KeyUp))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return MoveFileExplorerSelection(-1)
+			},
+		},
+		{
+			Name: "file explorer down (j or down arrow)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(runeExpr('j'), keyExpr(tcell.KeyDown))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return MoveFileExplorerSelection(1)
+			},
+		},
+		{
+			Name: "file explorer open (l or enter or right arrow)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(runeExpr('l'), keyExpr(tcell.KeyEnter), keyExpr(tcell.KeyRight))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.OpenFileExplorerEntry
+			},
+		},
+		{
+			Name: "file explorer collapse (h or left arrow)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(runeExpr('h'), keyExpr(tcell.KeyLeft))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.CollapseFileExplorerEntry
+			},
+		},
+		{
+			Name: "file explorer parent directory (-)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('-')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.MoveFileExplorerToParentDir
+			},
+		},
+		{
+			Name: "file explorer create (a)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('a')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ShowFileExplorerCreateTextField
+			},
+		},
+		{
+			Name: "file explorer rename (r)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('r')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ShowFileExplorerRenameTextField
+			},
+		},
+		{
+			Name: "file explorer delete (d)",
+			BuildExpr: func() engine.Expr {
+				return runeExpr('d')
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return ShowFileExplorerDeleteTextField
+			},
+		},
+		{
+			Name: "close file explorer (q or escape)",
+			BuildExpr: func() engine.Expr {
+				return altExpr(runeExpr('q'), keyExpr(tcell.KeyEscape))
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return state.HideFileExplorer
 			},
 		},
 	}
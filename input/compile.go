@@ -0,0 +1,68 @@
+package input
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/input/engine"
+	"github.com/aretext/aretext/state"
+)
+
+// This should be long enough for any valid input sequence,
+// but not long enough that count params can overflow uint64.
+const maxInputLen = 64
+
+// CompileCommands compiles the expressions for a set of commands to a state machine.
+// Each command is identified in the state machine by its index in the slice.
+//
+// The built-in modes are compiled ahead of time by input/generate.go, but this can
+// be called at startup to build state machines for user-defined key bindings.
+func CompileCommands(commands []Command) (*engine.StateMachine, error) {
+	cmdExprs := make([]engine.CmdExpr, 0, len(commands))
+	for i, cmd := range commands {
+		cmdExprs = append(cmdExprs, engine.CmdExpr{
+			CmdId: engine.CmdId(i),
+			Expr:  cmd.BuildExpr(),
+		})
+	}
+
+	sm, err := engine.Compile(cmdExprs)
+	if err != nil {
+		return nil, fmt.Errorf("engine.Compile: %w", err)
+	}
+
+	return sm, nil
+}
+
+// SetModeCommands replaces the commands for an input mode.
+// The commands are compiled at runtime, so this is slower than loading
+// the pre-generated state machines for the built-in modes.
+func (inp *Interpreter) SetModeCommands(inputMode state.InputMode, commands []Command) error {
+	m, ok := inp.modes[inputMode]
+	if !ok {
+		return fmt.Errorf("unrecognized input mode %s", inputMode)
+	}
+
+	sm, err := CompileCommands(commands)
+	if err != nil {
+		return err
+	}
+
+	inp.modes[inputMode] = &mode{
+		name:     m.name,
+		commands: commands,
+		runtime:  engine.NewRuntime(sm, maxInputLen),
+	}
+	return nil
+}
+
+// KeyEvent returns the engine event for a key, for use in command expressions.
+func KeyEvent(key tcell.Key) engine.Event {
+	return keyToEngineEvent(key)
+}
+
+// RuneEvent returns the engine event for a rune, for use in command expressions.
+func RuneEvent(r rune) engine.Event {
+	return runeToEngineEvent(r)
+}
@@ -0,0 +1,93 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/input/engine"
+	"github.com/aretext/aretext/state"
+)
+
+func TestCompileCommandsMatchesGenerated(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		commands []Command
+	}{
+		{name: "normal mode", path: NormalModePath, commands: NormalModeCommands()},
+		{name: "insert mode", path: InsertModePath, commands: InsertModeCommands()},
+		{name: "visual mode", path: VisualModePath, commands: VisualModeCommands()},
+		{name: "menu mode", path: MenuModePath, commands: MenuModeCommands()},
+		{name: "search mode", path: SearchModePath, commands: SearchModeCommands()},
+		{name: "task mode", path: TaskModePath, commands: TaskModeCommands()},
+		{name: "textfield mode", path: TextFieldModePath, commands: TextFieldCommands()},
+		{name: "undo preview mode", path: UndoPreviewModePath, commands: UndoPreviewModeCommands()},
+		{name: "changes mode", path: ChangesModePath, commands: ChangesModeCommands()},
+		{name: "autocomplete mode", path: AutocompleteModePath, commands: AutocompleteModeCommands()},
+		{name: "file explorer mode", path: FileExplorerModePath, commands: FileExplorerModeCommands()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sm, err := CompileCommands(tc.commands)
+			require.NoError(t, err)
+			expected, err := generatedFiles.ReadFile(tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, engine.Serialize(sm), "Generated state machine is out-of-date; run `go generate ./...`")
+		})
+	}
+}
+
+func TestSetModeCommands(t *testing.T) {
+	commands := []Command{
+		{
+			Name: "custom cursor left (zz)",
+			BuildExpr: func() engine.Expr {
+				return engine.ConcatExpr{
+					Children: []engine.Expr{
+						engine.EventExpr{Event: RuneEvent('z')},
+						engine.EventExpr{Event: RuneEvent('z')},
+					},
+				}
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return CursorLeft(p.Count)
+			},
+		},
+		{
+			Name: "custom cursor right (ctrl-l)",
+			BuildExpr: func() engine.Expr {
+				return engine.EventExpr{Event: KeyEvent(tcell.KeyCtrlL)}
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return CursorRight(p.Count)
+			},
+		},
+	}
+
+	interpreter := NewInterpreter()
+	err := interpreter.SetModeCommands(state.InputModeNormal, commands)
+	require.NoError(t, err)
+
+	editorState := state.NewEditorState(100, 100, nil, nil)
+	state.InsertText(editorState, "abcd")
+
+	events := []tcell.Event{
+		tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyCtrlL, '\x00', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone), // not bound anymore
+	}
+	for _, event := range events {
+		inputCtx := ContextFromEditorState(editorState)
+		action := interpreter.ProcessEvent(event, inputCtx)
+		action(editorState)
+	}
+
+	assert.Equal(t, uint64(3), editorState.DocumentBuffer().CursorPosition())
+}
@@ -22,6 +22,25 @@ type Context struct {
 	// and the end locator will be nil.
 	SelectionMode       selection.Mode
 	SelectionEndLocator state.Locator
+
+	// ShowKeyHints controls whether to show a status message
+	// when the user presses a key that is not bound to any command.
+	ShowKeyHints bool
+
+	// InsertModeSelection enables selecting text with shift+arrow keys in insert mode,
+	// and copy, cut, and paste with ctrl-c, ctrl-x, and ctrl-v.
+	InsertModeSelection bool
+
+	// KeyRemap maps keys typed in normal and visual mode to the keys that commands expect,
+	// for keyboard layouts other than QWERTY. Nil if no keys are remapped.
+	KeyRemap map[rune]rune
+
+	// PagerMode enables quitting with "q" in normal mode, similar to "less".
+	PagerMode bool
+
+	// SecretTextField is set when the text field input is secret, like a passphrase,
+	// so keys typed into it must not be logged.
+	SecretTextField bool
 }
 
 func ContextFromEditorState(editorState *state.EditorState) Context {
@@ -33,5 +52,10 @@ func ContextFromEditorState(editorState *state.EditorState) Context {
 		HidePatterns:        editorState.HidePatterns(),
 		SelectionMode:       editorState.DocumentBuffer().SelectionMode(),
 		SelectionEndLocator: editorState.DocumentBuffer().SelectionEndLocator(),
+		ShowKeyHints:        editorState.DocumentBuffer().ShowKeyHints(),
+		InsertModeSelection: editorState.DocumentBuffer().InsertModeSelection(),
+		KeyRemap:            editorState.KeyRemap(),
+		PagerMode:           editorState.PagerMode(),
+		SecretTextField:     editorState.InputMode() == state.InputModeTextField && editorState.TextField().Secret(),
 	}
 }
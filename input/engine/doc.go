@@ -0,0 +1,10 @@
+// Package engine compiles and executes state machines that recognize sequences of input events.
+//
+// Each command is described by an expression (see expression.go), and Compile transforms
+// the expressions for all commands into a single state machine. A Runtime then processes
+// events one at a time and decides whether to wait for more input, reject the input,
+// or accept a command.
+//
+// State machines can be serialized so they can be compiled ahead of time,
+// or compiled at startup for key bindings that aren't known until then.
+package engine
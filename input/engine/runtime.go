@@ -16,10 +16,11 @@ type Result struct {
 }
 
 type Runtime struct {
-	sm           *StateMachine
-	currentState stateId
-	inputEvents  []Event
-	maxInputLen  int
+	sm            *StateMachine
+	currentState  stateId
+	inputEvents   []Event
+	captureEvents []Event // Events to record in captures, one for each input event.
+	maxInputLen   int
 }
 
 func NewRuntime(sm *StateMachine, maxInputLen int) *Runtime {
@@ -28,10 +29,11 @@ func NewRuntime(sm *StateMachine, maxInputLen int) *Runtime {
 	}
 
 	return &Runtime{
-		sm:           sm,
-		currentState: sm.startState,
-		inputEvents:  make([]Event, 0, maxInputLen),
-		maxInputLen:  maxInputLen,
+		sm:            sm,
+		currentState:  sm.startState,
+		inputEvents:   make([]Event, 0, maxInputLen),
+		captureEvents: make([]Event, 0, maxInputLen),
+		maxInputLen:   maxInputLen,
 	}
 }
 
@@ -40,7 +42,16 @@ func NewRuntime(sm *StateMachine, maxInputLen int) *Runtime {
 // If all commands reject the input, the runtime resets.
 // Otherwise, the runtime waits for more input before making a decision.
 func (r *Runtime) ProcessEvent(event Event) Result {
+	return r.ProcessEventWithCapture(event, event)
+}
+
+// ProcessEventWithCapture is like ProcessEvent, except that if the event is captured,
+// the captures contain captureEvent instead of the event.
+// This allows a remapped key to select a command, while the command's arguments
+// (such as the character to find) keep the key the user typed.
+func (r *Runtime) ProcessEventWithCapture(event Event, captureEvent Event) Result {
 	r.inputEvents = append(r.inputEvents, event)
+	r.captureEvents = append(r.captureEvents, captureEvent)
 	transition := r.nextTransition(r.currentState, event)
 	if transition == nil {
 		// No transition from this state based on the input event, so reject the input.
@@ -91,20 +102,21 @@ func (r *Runtime) nextTransition(state stateId, event Event) *transition {
 func (r *Runtime) reset() {
 	r.currentState = r.sm.startState
 	r.inputEvents = r.inputEvents[:0]
+	r.captureEvents = r.captureEvents[:0]
 }
 
 func (r *Runtime) findCapturesForCmd(cmdId CmdId) map[CaptureId][]Event {
 	// Replay the events through the state machine to find captures for the accepted command.
 	var captures map[CaptureId][]Event
 	state := stateId(0)
-	for _, event := range r.inputEvents {
+	for i, event := range r.inputEvents {
 		t := r.nextTransition(state, event)
 		id, ok := t.captures[cmdId]
 		if ok {
 			if captures == nil {
 				captures = make(map[CaptureId][]Event)
 			}
-			captures[id] = append(captures[id], event)
+			captures[id] = append(captures[id], r.captureEvents[i])
 		}
 
 		state = t.nextState
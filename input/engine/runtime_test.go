@@ -342,3 +342,35 @@ func TestRuntimeMaxInputLen(t *testing.T) {
 	result := runtime.ProcessEvent(1)
 	assert.Equal(t, DecisionReject, result.Decision)
 }
+
+func TestRuntimeProcessEventWithCapture(t *testing.T) {
+	cmdExprs := []CmdExpr{
+		{
+			CmdId: 0,
+			Expr: ConcatExpr{
+				Children: []Expr{
+					EventExpr{Event: 1},
+					CaptureExpr{
+						CaptureId: 5,
+						Child:     EventRangeExpr{StartEvent: 10, EndEvent: 20},
+					},
+				},
+			},
+		},
+	}
+	sm, err := Compile(cmdExprs)
+	require.NoError(t, err)
+	runtime := NewRuntime(sm, 1024)
+
+	// The first event selects the command, and the capture event is ignored.
+	result := runtime.ProcessEventWithCapture(1, 2)
+	assert.Equal(t, DecisionWait, result.Decision)
+
+	// The second event is captured, so the capture contains the capture event.
+	result = runtime.ProcessEventWithCapture(15, 99)
+	assert.Equal(t, Result{
+		Decision: DecisionAccept,
+		CmdId:    0,
+		Captures: map[CaptureId][]Event{5: {99}},
+	}, result)
+}
@@ -16,25 +16,23 @@ import (
 func main() {
 	generate(input.NormalModePath, input.NormalModeCommands())
 	generate(input.InsertModePath, input.InsertModeCommands())
+	generate(input.ReplaceModePath, input.ReplaceModeCommands())
 	generate(input.VisualModePath, input.VisualModeCommands())
 	generate(input.MenuModePath, input.MenuModeCommands())
 	generate(input.SearchModePath, input.SearchModeCommands())
 	generate(input.TaskModePath, input.TaskModeCommands())
 	generate(input.TextFieldModePath, input.TextFieldCommands())
+	generate(input.UndoPreviewModePath, input.UndoPreviewModeCommands())
+	generate(input.ChangesModePath, input.ChangesModeCommands())
+	generate(input.ReplaceConfirmModePath, input.ReplaceConfirmModeCommands())
+	generate(input.AutocompleteModePath, input.AutocompleteModeCommands())
+	generate(input.FileExplorerModePath, input.FileExplorerModeCommands())
 }
 
 func generate(path string, commands []input.Command) {
 	fmt.Printf("Generating input state machine %s\n", path)
 
-	cmdExprs := make([]engine.CmdExpr, 0, len(commands))
-	for i, cmd := range commands {
-		cmdExprs = append(cmdExprs, engine.CmdExpr{
-			CmdId: engine.CmdId(i),
-			Expr:  cmd.BuildExpr(),
-		})
-	}
-
-	sm, err := engine.Compile(cmdExprs)
+	sm, err := input.CompileCommands(commands)
 	if err != nil {
 		fmt.Printf("Error compiling commands for %s: %s", path, err)
 		os.Exit(1)
@@ -1,6 +1,7 @@
 package input
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -11,14 +12,67 @@ import (
 	"github.com/aretext/aretext/input/engine"
 )
 
+// tcell reports ctrl-home and ctrl-end as the home and end keys with a modifier.
+// The input engine ignores modifiers, so represent these as distinct keys.
+const (
+	keyCtrlHome = tcell.KeyF64 + 1 + iota
+	keyCtrlEnd
+	keyShiftLeft
+	keyShiftRight
+	keyShiftUp
+	keyShiftDown
+	keyShiftHome
+	keyShiftEnd
+)
+
+// shiftKeys are keys that select text in insert mode when pressed with shift.
+var shiftKeys = map[tcell.Key]tcell.Key{
+	tcell.KeyLeft:  keyShiftLeft,
+	tcell.KeyRight: keyShiftRight,
+	tcell.KeyUp:    keyShiftUp,
+	tcell.KeyDown:  keyShiftDown,
+	tcell.KeyHome:  keyShiftHome,
+	tcell.KeyEnd:   keyShiftEnd,
+}
+
+// insertModeSelectionEngineEvent maps shift+arrow keys to distinct events for selecting text in insert mode.
+// Other modes treat shift+arrow the same as the arrow key alone, so this should be used only when
+// insert mode selection is enabled.
+func insertModeSelectionEngineEvent(eventKey *tcell.EventKey) (engine.Event, bool) {
+	if eventKey.Modifiers()&tcell.ModShift == 0 {
+		return 0, false
+	}
+	key, ok := shiftKeys[eventKey.Key()]
+	if !ok {
+		return 0, false
+	}
+	return keyToEngineEvent(key), true
+}
+
 func eventKeyToEngineEvent(eventKey *tcell.EventKey) engine.Event {
-	if eventKey.Key() == tcell.KeyRune {
+	key := eventKey.Key()
+	if key == tcell.KeyRune {
 		return runeToEngineEvent(eventKey.Rune())
+	} else if key == tcell.KeyHome && eventKey.Modifiers()&tcell.ModCtrl != 0 {
+		return keyToEngineEvent(keyCtrlHome)
+	} else if key == tcell.KeyEnd && eventKey.Modifiers()&tcell.ModCtrl != 0 {
+		return keyToEngineEvent(keyCtrlEnd)
 	} else {
-		return keyToEngineEvent(eventKey.Key())
+		return keyToEngineEvent(key)
 	}
 }
 
+// remapEngineEvent replaces a typed rune with the rune it is remapped to, if any.
+func remapEngineEvent(engineEvent engine.Event, keyRemap map[rune]rune) engine.Event {
+	if engineEventToKey(engineEvent) != tcell.KeyRune {
+		return engineEvent
+	}
+	if r, ok := keyRemap[engineEventToRune(engineEvent)]; ok {
+		return runeToEngineEvent(r)
+	}
+	return engineEvent
+}
+
 func keyToEngineEvent(key tcell.Key) engine.Event {
 	return engine.Event(int64(key) << 32)
 }
@@ -96,9 +150,24 @@ func init() {
 				},
 				engine.CaptureExpr{
 					CaptureId: captureIdClipboardPage,
-					Child: engine.EventRangeExpr{
-						StartEvent: runeToEngineEvent('a'),
-						EndEvent:   runeToEngineEvent('z'),
+					Child: engine.AltExpr{
+						Children: []engine.Expr{
+							engine.EventRangeExpr{
+								StartEvent: runeToEngineEvent('a'),
+								EndEvent:   runeToEngineEvent('z'),
+							},
+							engine.EventRangeExpr{
+								StartEvent: runeToEngineEvent('A'),
+								EndEvent:   runeToEngineEvent('Z'),
+							},
+							engine.EventRangeExpr{
+								StartEvent: runeToEngineEvent('0'),
+								EndEvent:   runeToEngineEvent('9'),
+							},
+							engine.EventExpr{
+								Event: runeToEngineEvent('-'),
+							},
+						},
 					},
 				},
 			},
@@ -155,6 +224,11 @@ func verbCountThenExpr(expr engine.Expr) engine.Expr {
 	return engine.ConcatExpr{Children: []engine.Expr{verbCountExpr, expr}}
 }
 
+// splitKeyExpr matches ctrl-w followed by a character, the prefix for commands that control splits.
+func splitKeyExpr(r rune) engine.Expr {
+	return engine.ConcatExpr{Children: []engine.Expr{keyExpr(tcell.KeyCtrlW), runeExpr(r)}}
+}
+
 func runeExpr(r rune) engine.Expr {
 	return engine.EventExpr{Event: runeToEngineEvent(r)}
 }
@@ -206,6 +280,29 @@ func cmdExpr(verb string, object string, opts captureOpts) engine.Expr {
 	return expr
 }
 
+// needsCharInput returns whether an expression captures a character typed after the command's keys, like "f{char}".
+func needsCharInput(expr engine.Expr) bool {
+	switch expr := expr.(type) {
+	case engine.ConcatExpr:
+		return slices.ContainsFunc(expr.Children, needsCharInput)
+	case engine.AltExpr:
+		return slices.ContainsFunc(expr.Children, needsCharInput)
+	case engine.OptionExpr:
+		return needsCharInput(expr.Child)
+	case engine.StarExpr:
+		return needsCharInput(expr.Child)
+	case engine.CaptureExpr:
+		switch expr.CaptureId {
+		case captureIdMatchChar, captureIdReplaceChar, captureIdInsertChar:
+			return true
+		default:
+			return needsCharInput(expr.Child)
+		}
+	default:
+		return false
+	}
+}
+
 func capturesToCommandParams(captures map[engine.CaptureId][]engine.Event) CommandParams {
 	p := CommandParams{
 		Count:         1,
@@ -250,7 +347,7 @@ func eventsToClipboardPage(events []engine.Event) clipboard.PageId {
 	if len(events) != 1 {
 		return clipboard.PageNull
 	}
-	return clipboard.PageIdForLetter(engineEventToRune(events[0]))
+	return clipboard.PageIdForName(engineEventToRune(events[0]))
 }
 
 func eventsToChar(events []engine.Event) rune {
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 
 	"github.com/aretext/aretext/input/engine"
+	"github.com/aretext/aretext/nondet"
 	"github.com/aretext/aretext/state"
 )
 
@@ -37,6 +39,13 @@ func NewInterpreter() *Interpreter {
 				runtime:  runtimeForMode(InsertModePath),
 			},
 
+			// replace mode is used for overwriting characters in the document.
+			state.InputModeReplace: {
+				name:     "replace",
+				commands: ReplaceModeCommands(),
+				runtime:  runtimeForMode(ReplaceModePath),
+			},
+
 			// visual mode is used to visually select a region of the document.
 			state.InputModeVisual: {
 				name:     "visual",
@@ -72,6 +81,41 @@ func NewInterpreter() *Interpreter {
 				commands: TextFieldCommands(),
 				runtime:  runtimeForMode(TextFieldModePath),
 			},
+
+			// undo preview mode shows an earlier document state before committing to the undo.
+			state.InputModeUndoPreview: {
+				name:     "undo preview",
+				commands: UndoPreviewModeCommands(),
+				runtime:  runtimeForMode(UndoPreviewModePath),
+			},
+
+			// changes mode shows changes to the document as a unified diff.
+			state.InputModeChanges: {
+				name:     "changes",
+				commands: ChangesModeCommands(),
+				runtime:  runtimeForMode(ChangesModePath),
+			},
+
+			// replace confirm mode asks the user whether to replace each match in a find and replace.
+			state.InputModeReplaceConfirm: {
+				name:     "replace confirm",
+				commands: ReplaceConfirmModeCommands(),
+				runtime:  runtimeForMode(ReplaceConfirmModePath),
+			},
+
+			// autocomplete mode cycles through words suggested to complete the word before the cursor in insert mode.
+			state.InputModeAutocomplete: {
+				name:     "autocomplete",
+				commands: AutocompleteModeCommands(),
+				runtime:  runtimeForMode(AutocompleteModePath),
+			},
+
+			// file explorer mode browses a directory tree to open, create, rename, and delete files.
+			state.InputModeFileExplorer: {
+				name:     "file explorer",
+				commands: FileExplorerModeCommands(),
+				runtime:  runtimeForMode(FileExplorerModePath),
+			},
 		},
 	}
 }
@@ -99,7 +143,11 @@ func (inp *Interpreter) ProcessEvent(event tcell.Event, ctx Context) Action {
 }
 
 func (inp *Interpreter) processKeyEvent(event *tcell.EventKey, ctx Context) Action {
-	log.Printf("Processing key %s in mode %s\n", event.Name(), ctx.InputMode)
+	if ctx.SecretTextField {
+		log.Printf("Processing key in mode %s\n", ctx.InputMode)
+	} else {
+		log.Printf("Processing key %s in mode %s\n", event.Name(), ctx.InputMode)
+	}
 	mode := inp.modes[ctx.InputMode]
 	return mode.ProcessKeyEvent(event, ctx)
 }
@@ -131,6 +179,10 @@ func (inp *Interpreter) processPasteEnd(ctx Context) Action {
 	switch ctx.InputMode {
 	case state.InputModeInsert:
 		return InsertFromBracketedPaste(text)
+	case state.InputModeAutocomplete:
+		return AcceptAutocompleteThen(InsertFromBracketedPaste(text))
+	case state.InputModeReplace:
+		return ReplaceFromBracketedPaste(text)
 	case state.InputModeNormal, state.InputModeVisual:
 		return ShowStatusMsgBracketedPasteWrongMode
 	case state.InputModeMenu:
@@ -158,13 +210,19 @@ func (inp *Interpreter) InputBufferString(mode state.InputMode) string {
 }
 
 const (
-	NormalModePath    = "generated/normal.bin"
-	InsertModePath    = "generated/insert.bin"
-	VisualModePath    = "generated/visual.bin"
-	MenuModePath      = "generated/menu.bin"
-	SearchModePath    = "generated/search.bin"
-	TaskModePath      = "generated/task.bin"
-	TextFieldModePath = "generated/textfield.bin"
+	NormalModePath         = "generated/normal.bin"
+	InsertModePath         = "generated/insert.bin"
+	ReplaceModePath        = "generated/replace.bin"
+	VisualModePath         = "generated/visual.bin"
+	MenuModePath           = "generated/menu.bin"
+	SearchModePath         = "generated/search.bin"
+	TaskModePath           = "generated/task.bin"
+	TextFieldModePath      = "generated/textfield.bin"
+	UndoPreviewModePath    = "generated/undopreview.bin"
+	ReplaceConfirmModePath = "generated/replaceconfirm.bin"
+	ChangesModePath        = "generated/changes.bin"
+	AutocompleteModePath   = "generated/autocomplete.bin"
+	FileExplorerModePath   = "generated/fileexplorer.bin"
 )
 
 //go:generate go run generate.go
@@ -175,10 +233,6 @@ var generatedFiles embed.FS
 // The state machine is serialized and embedded in the aretext binary.
 // See input/generate.go for the code that compiles the state machines.
 func runtimeForMode(path string) *engine.Runtime {
-	// This should be long enough for any valid input sequence,
-	// but not long enough that count params can overflow uint64.
-	const maxInputLen = 64
-
 	data, err := generatedFiles.ReadFile(path)
 	if err != nil {
 		log.Fatalf("Could not read %s: %s", path, err)
@@ -195,28 +249,49 @@ func runtimeForMode(path string) *engine.Runtime {
 // mode is an editor input mode.
 // Each mode has its own rules for interpreting user input.
 type mode struct {
-	name        string
-	commands    []Command
-	runtime     *engine.Runtime
-	inputBuffer strings.Builder
+	name         string
+	commands     []Command
+	runtime      *engine.Runtime
+	inputBuffer  strings.Builder
+	lastHintTime time.Time
 }
 
+// minKeyHintInterval is the minimum time between hints for unbound keys.
+// This avoids flooding the status bar if the user presses many unbound keys quickly.
+const minKeyHintInterval = time.Second
+
 func (m *mode) ProcessKeyEvent(event *tcell.EventKey, ctx Context) Action {
 	engineEvent := eventKeyToEngineEvent(event)
+	if ctx.InputMode == state.InputModeInsert && ctx.InsertModeSelection {
+		if selectEvent, ok := insertModeSelectionEngineEvent(event); ok {
+			engineEvent = selectEvent
+		}
+	}
+
+	// Commands match the remapped key, but arguments like the character
+	// to find with "f" are captured as typed.
+	captureEvent := engineEvent
+	if ctx.InputMode == state.InputModeNormal || ctx.InputMode == state.InputModeVisual {
+		engineEvent = remapEngineEvent(engineEvent, ctx.KeyRemap)
+	}
 	if event.Key() == tcell.KeyRune {
 		m.inputBuffer.WriteRune(event.Rune())
 	}
 
 	action := EmptyAction
-	result := m.runtime.ProcessEvent(engineEvent)
+	result := m.runtime.ProcessEventWithCapture(engineEvent, captureEvent)
 	if result.Decision == engine.DecisionAccept {
 		command := m.commands[result.CmdId]
 		params := capturesToCommandParams(result.Captures)
-		log.Printf(
-			"%s mode accepted input for command %q with params %+v and ctx %+v\n",
-			m.name, command.Name,
-			params, ctx,
-		)
+		if ctx.SecretTextField {
+			log.Printf("%s mode accepted secret input for command %q\n", m.name, command.Name)
+		} else {
+			log.Printf(
+				"%s mode accepted input for command %q with params %+v and ctx %+v\n",
+				m.name, command.Name,
+				params, ctx,
+			)
+		}
 
 		if err := m.validateParams(command, params); err != nil {
 			action = func(s *state.EditorState) {
@@ -224,12 +299,24 @@ func (m *mode) ProcessKeyEvent(event *tcell.EventKey, ctx Context) Action {
 					Style: state.StatusMsgStyleError,
 					Text:  err.Error(),
 				})
+				state.RingBell(s)
 			}
 		} else {
 			action = command.BuildAction(ctx, params)
 		}
 	}
 
+	if result.Decision == engine.DecisionReject {
+		action = state.RingBell
+		if ctx.ShowKeyHints {
+			hintAction := m.keyHintAction(event)
+			action = func(s *state.EditorState) {
+				hintAction(s)
+				state.RingBell(s)
+			}
+		}
+	}
+
 	if result.Decision != engine.DecisionWait {
 		m.inputBuffer.Reset()
 	}
@@ -237,6 +324,26 @@ func (m *mode) ProcessKeyEvent(event *tcell.EventKey, ctx Context) Action {
 	return action
 }
 
+func (m *mode) keyHintAction(event *tcell.EventKey) Action {
+	now := nondet.Now()
+	if now.Sub(m.lastHintTime) < minKeyHintInterval {
+		return EmptyAction
+	}
+	m.lastHintTime = now
+
+	keys := m.inputBuffer.String()
+	if event.Key() != tcell.KeyRune {
+		keys += event.Name()
+	}
+
+	return func(s *state.EditorState) {
+		state.SetStatusMsg(s, state.StatusMsg{
+			Style: state.StatusMsgStyleError,
+			Text:  fmt.Sprintf("%q is not bound in %s mode", keys, m.name),
+		})
+	}
+}
+
 func (m *mode) validateParams(command Command, params CommandParams) error {
 	if command.MaxCount > 0 && params.Count > command.MaxCount {
 		return fmt.Errorf("count must be less than or equal to %d", command.MaxCount)
@@ -1,17 +1,24 @@
 package input
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/input/engine"
+	"github.com/aretext/aretext/nondet"
+	"github.com/aretext/aretext/selection"
 	"github.com/aretext/aretext/state"
 )
 
@@ -425,6 +432,150 @@ func TestInterpreterStateIntegration(t *testing.T) {
 			expectedCursorPos: 39,
 			expectedText:      `func foo() { fmt.Printf("foo {} bar!") }`,
 		},
+		{
+			name:        "delete to matching code block delimiter forward",
+			initialText: "a (b (c) d) e",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '%', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "a  e",
+		},
+		{
+			name:        "delete to matching code block delimiter backward",
+			initialText: "a (b (c) d) e",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '1', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '0', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '%', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "a  e",
+		},
+		{
+			name:        "delete to matching code block delimiter nested",
+			initialText: "a (b (c) d) e",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '5', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '%', tcell.ModNone),
+			},
+			expectedCursorPos: 5,
+			expectedText:      "a (b  d) e",
+		},
+		{
+			name:        "delete to matching code block delimiter no match",
+			initialText: "a (b c",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '%', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "a (b c",
+		},
+		{
+			name:        "change to matching code block delimiter",
+			initialText: "foo(bar)baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '3', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '%', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEsc, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "fooxbaz",
+		},
+		{
+			name:        "yank to matching code block delimiter",
+			initialText: "{a {b} c} d",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '%', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '$', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+			},
+			expectedCursorPos: 19,
+			expectedText:      "{a {b} c} d{a {b} c}",
+		},
+		{
+			name:        "delete to next occurrence of word under cursor",
+			initialText: "foo bar foo baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '*', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "foo baz",
+		},
+		{
+			name:        "delete to prev occurrence of word under cursor",
+			initialText: "foo bar foo baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '8', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '#', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "foofoo baz",
+		},
+		{
+			name:        "delete to next occurrence of word under cursor, not found",
+			initialText: "foo bar baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '*', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "foo bar baz",
+		},
+		{
+			name:        "change to prev occurrence of word under cursor",
+			initialText: "foo bar foo baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '8', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '#', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEsc, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "fooxfoo baz",
+		},
+		{
+			name:        "yank to next occurrence of word under cursor",
+			initialText: "foo bar foo",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '*', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '$', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+			},
+			expectedCursorPos: 18,
+			expectedText:      "foo bar foofoo bar ",
+		},
+		{
+			name:        "repeat delete to next occurrence of word under cursor",
+			initialText: "a foo b foo c foo d",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '*', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "a foo d",
+		},
 		{
 			name:        "cursor prev unmatched open brace",
 			initialText: `{ { a { b } c } }`,
@@ -508,6 +659,152 @@ func TestInterpreterStateIntegration(t *testing.T) {
 			expectedCursorPos: 2,
 			expectedText:      "foo",
 		},
+		{
+			name:        "insert korean then delete with backspace",
+			initialText: "",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '한', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '국', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '어', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyBackspace2, '\u007f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEsc, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "한국",
+		},
+		{
+			name:        "insert decomposed hangul jamo then delete with backspace",
+			initialText: "",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '\u1112', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '\u1161', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '\u11ab', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '\u1100', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '\u1173', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyBackspace2, '\u007f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEsc, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "\u1112\u1161\u11ab",
+		},
+		{
+			name:        "insert hangul jamo before vowel",
+			initialText: "\u1161x",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '\u1112', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "\u1112\u1161yx",
+		},
+		{
+			name:        "insert japanese then delete with backspace",
+			initialText: "",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'こ', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'ん', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'に', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'ち', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'は', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyBackspace2, '\u007f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyBackspace2, '\u007f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEsc, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "こんに",
+		},
+		{
+			name:        "insert chinese in middle of line then delete with backspace",
+			initialText: "ab",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '中', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '文', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyBackspace2, '\u007f', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "a中b",
+		},
+		{
+			name:        "home and end keys in normal mode",
+			initialText: "foo bar\nbaz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyHome, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "oo ba\nbaz",
+		},
+		{
+			name:        "ctrl-home and ctrl-end keys in normal mode",
+			initialText: "  foo\nbar\n  baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyHome, '\x00', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "  oo\nbar\n  az",
+		},
+		{
+			name:        "home and end keys in insert mode",
+			initialText: "foo bar\nbaz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyHome, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "yfoo barx\nbaz",
+		},
+		{
+			name:        "ctrl-home and ctrl-end keys in insert mode",
+			initialText: "foo\nbar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyHome, '\x00', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "yfoo\nbarx",
+		},
+		{
+			name:        "page down and page up in insert mode",
+			initialText: "a\nb\nc\nd",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyPgDn, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyPgUp, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "ya\nb\nc\nxd",
+		},
+		{
+			name:        "end key in visual mode",
+			initialText: "foo bar\nbaz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "f\nbaz",
+		},
 		{
 			name:        "insert max rune",
 			initialText: "",
@@ -1818,65 +2115,173 @@ func TestInterpreterStateIntegration(t *testing.T) {
 			expectedText:      "Lorem ip\tum dolor\nsit amet consectetur\nadipiscing elit",
 		},
 		{
-			name:        "toggle case",
+			name:        "replace character with count",
 			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
 			events: []tcell.Event{
-				tcell.NewEventKey(tcell.KeyRune, '~', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '3', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
 			},
-			expectedCursorPos: 1,
-			expectedText:      "lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			expectedCursorPos: 2,
+			expectedText:      "xxxem ipsum dolor\nsit amet consectetur\nadipiscing elit",
 		},
 		{
-			name:        "indent line",
-			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			name:        "replace character with count past end of line",
+			initialText: "abc\ndef",
 			events: []tcell.Event{
-				tcell.NewEventKey(tcell.KeyRune, '>', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '>', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '4', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
 			},
-			expectedCursorPos: 1,
-			expectedText:      "\tLorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			expectedCursorPos: 0,
+			expectedText:      "abc\ndef",
 		},
 		{
-			name:        "indent line with count",
-			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			name:        "replace mode overwrites characters",
+			initialText: "abcdef",
 			events: []tcell.Event{
-				tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '>', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '>', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'R', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
 			},
-			expectedCursorPos: 1,
-			expectedText:      "\tLorem ipsum dolor\n\tsit amet consectetur\nadipiscing elit",
+			expectedCursorPos: 2,
+			expectedText:      "axydef",
 		},
 		{
-			name:        "outdent line",
-			initialText: "Lorem ipsum dolor\n\tsit amet consectetur\n\t\tadipiscing\nelit\n\n",
+			name:        "replace mode appends at end of line",
+			initialText: "ab\ncd",
 			events: []tcell.Event{
-				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
 				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'R', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
 			},
-			expectedCursorPos: 18,
-			expectedText:      "Lorem ipsum dolor\nsit amet consectetur\n\t\tadipiscing\nelit\n\n",
+			expectedCursorPos: 3,
+			expectedText:      "axyz\ncd",
 		},
 		{
-			name:        "outdent line with count",
-			initialText: "Lorem ipsum dolor\n\tsit amet consectetur\n\t\tadipiscing\nelit\n\n",
+			name:        "replace mode backspace restores original characters",
+			initialText: "abcdef",
 			events: []tcell.Event{
-				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
 				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'R', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyBackspace, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyBackspace, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "axcdef",
+		},
+		{
+			name:        "replace mode newline",
+			initialText: "abcdef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'R', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "ax\nydef",
+		},
+		{
+			name:        "replace mode undo",
+			initialText: "abcdef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'R', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "abcdef",
+		},
+		{
+			name:        "replace mode repeat last action",
+			initialText: "abcdef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'R', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone),
+			},
+			expectedCursorPos: 4,
+			expectedText:      "xycxyf",
+		},
+		{
+			name:        "toggle case",
+			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '~', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+		},
+		{
+			name:        "indent line",
+			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '>', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '>', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "\tLorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+		},
+		{
+			name:        "indent line with count",
+			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '>', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '>', tcell.ModNone),
+			},
+			expectedCursorPos: 1,
+			expectedText:      "\tLorem ipsum dolor\n\tsit amet consectetur\nadipiscing elit",
+		},
+		{
+			name:        "outdent line",
+			initialText: "Lorem ipsum dolor\n\tsit amet consectetur\n\t\tadipiscing\nelit\n\n",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone),
+			},
+			expectedCursorPos: 18,
+			expectedText:      "Lorem ipsum dolor\nsit amet consectetur\n\t\tadipiscing\nelit\n\n",
+		},
+		{
+			name:        "outdent line with count",
+			initialText: "Lorem ipsum dolor\n\tsit amet consectetur\n\t\tadipiscing\nelit\n\n",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone),
 				tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone),
 			},
 			expectedCursorPos: 18,
@@ -2659,6 +3064,20 @@ func TestInterpreterStateIntegration(t *testing.T) {
 			expectedCursorPos: 2,
 			expectedText:      "LoREM IPSUm dolor\nsit amet consectetur\nadipiscing elit",
 		},
+		{
+			name:        "visual mode select next occurrence, then change",
+			initialText: "foo bar foo baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlN, '\x0e', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 8,
+			expectedText:      "foo bar x baz",
+		},
 		{
 			name:        "visual mode indent with count",
 			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
@@ -2779,6 +3198,41 @@ func TestInterpreterStateIntegration(t *testing.T) {
 			expectedCursorPos: 0,
 			expectedText:      "lOREM ipsum dolor\nsit amet consectetur\nadipiscing elit",
 		},
+		{
+			name:        "visual charwise, then uppercase",
+			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'U', tcell.ModNone),
+			},
+			expectedCursorPos: 6,
+			expectedText:      "Lorem IPSUM dolor\nsit amet consectetur\nadipiscing elit",
+		},
+		{
+			name:        "visual linewise, then lowercase",
+			initialText: "LOREM IPSUM DOLOR\nSIT AMET CONSECTETUR\nADIPISCING ELIT",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'V', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "lorem ipsum dolor\nsit amet consectetur\nADIPISCING ELIT",
+		},
+		{
+			name:        "visual uppercase, then replay last action",
+			initialText: "ab\ncd\nef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'V', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'U', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "AB\nCD\nef",
+		},
 		{
 			name:        "visual mode, then replay last action",
 			initialText: "",
@@ -3068,6 +3522,95 @@ func TestInterpreterStateIntegration(t *testing.T) {
 			expectedCursorPos: 45,
 			expectedText:      "{Lorem ipsum dolor},\n{sit amet consectetur},\n{adipiscing elit},",
 		},
+		{
+			name:        "record and replay user macro with linewise yank and paste",
+			initialText: "foo\nbar\nbaz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+			},
+			expectedCursorPos: 16,
+			expectedText:      "foo\nbar\nfoo\nbaz\nfoo",
+		},
+		{
+			name:        "record and replay user macro with linewise yank and paste to named clipboard page",
+			initialText: "foo\nbar\nbaz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+			},
+			expectedCursorPos: 16,
+			expectedText:      "foo\nbar\nfoo\nbaz\nfoo",
+		},
+		{
+			name:        "repeat linewise paste",
+			initialText: "foo\nbar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone),
+			},
+			expectedCursorPos: 8,
+			expectedText:      "foo\nfoo\nfoo\nbar",
+		},
+		{
+			name:        "repeat linewise paste before cursor after delete",
+			initialText: "foo\nbar\nbaz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'P', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone),
+			},
+			expectedCursorPos: 4,
+			expectedText:      "bar\nfoo\nfoo\nbaz",
+		},
 		{
 			name:        "record and replay user macro with text search",
 			initialText: "foo bar baz bat",
@@ -3100,38 +3643,876 @@ func TestInterpreterStateIntegration(t *testing.T) {
 			expectedText:      "foo ar az bat",
 		},
 		{
-			name:        "bracketed paste in insert mode",
-			initialText: "abc",
+			name:        "find and replace preserving case",
+			initialText: "foo Foo FOO",
 			events: []tcell.Event{
-				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
-				tcell.NewEventPaste(true),
-				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
 				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyTab, '\t', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '1', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, '3', tcell.ModNone),
-				tcell.NewEventPaste(false),
 			},
-			expectedCursorPos: 11,
-			expectedText:      "abcxyz\n\t123",
+			expectedCursorPos: 0,
+			expectedText:      "bar Bar BAR",
 		},
 		{
-			name:        "bracketed paste in search mode",
-			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			name:        "find and replace in selection with confirmation",
+			initialText: "foo foo\nfoo foo\nfoo",
 			events: []tcell.Event{
-				tcell.NewEventKey(tcell.KeyRune, '/', tcell.ModNone),
-				tcell.NewEventPaste(true),
-				tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
-				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
-				tcell.NewEventPaste(false),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'V', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
 				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+			},
+			expectedCursorPos: 12,
+			expectedText:      "foo foo\nfoo x\nfoo",
+		},
+		{
+			name:        "paste from yank page after delete",
+			initialText: "abc\ndef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '0', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'P', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "bc\nabc\ndef",
+		},
+		{
+			name:        "paste from numbered pages",
+			initialText: "abc\ndef\nghi",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '1', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+			},
+			expectedCursorPos: 8,
+			expectedText:      "ghi\nabc\ndef",
+		},
+		{
+			name:        "yank and append to named page",
+			initialText: "abc\ndef\nghi",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+			},
+			expectedCursorPos: 12,
+			expectedText:      "abc\ndef\nghi\nabc\ndef",
+		},
+		{
+			name:        "paste from small delete page",
+			initialText: "abc\ndef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '"', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '-', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'P', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "abc",
+		},
+		{
+			name:        "apply recorded macro as operator to visual selection",
+			initialText: "abc\ndef\nghi",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '!', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEsc, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'V', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '@', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+			},
+			expectedCursorPos: 13,
+			expectedText:      "abc!\ndef!\nghi!",
+		},
+		{
+			name:        "apply recorded macro as operator to motion",
+			initialText: "abc\ndef\nghi",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '!', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEsc, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'V', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '@', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '@', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+			},
+			expectedCursorPos: 4,
+			expectedText:      "abc!!\ndef\nghi",
+		},
+		{
+			name:        "apply operator without any operators",
+			initialText: "abc def",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '@', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "bc def",
+		},
+		{
+			name:        "insert mode ctrl-w deletes prev word",
+			initialText: "abc def",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlW, '\x17', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyCtrlW, '\x17', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "abc ",
+		},
+		{
+			name:        "insert mode ctrl-w at start of line",
+			initialText: "abc\ndef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlW, '\x17', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "abcdef",
+		},
+		{
+			name:        "insert mode ctrl-u deletes inserted text then to line start",
+			initialText: "abc def",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlU, '\x15', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlU, '\x15', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyCtrlU, '\x15', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "",
+		},
+		{
+			name:        "insert mode ctrl-u undo",
+			initialText: "abc def",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlU, '\x15', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyCtrlU, '\x15', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "abc def",
+		},
+		{
+			name:        "insert mode ctrl-w repeat last action",
+			initialText: "abc def\nghi jkl",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlW, '\x17', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone),
+			},
+			expectedCursorPos: 8,
+			expectedText:      "abc \nghi ",
+		},
+		{
+			name:        "insert mode autocomplete accept with enter",
+			initialText: "foobar\nfo",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlN, '\x0e', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 12,
+			expectedText:      "foobar\nfoobar",
+		},
+		{
+			name:        "insert mode autocomplete then insert rune",
+			initialText: "foobar\nfo",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlN, '\x0e', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 13,
+			expectedText:      "foobar\nfoobarx",
+		},
+		{
+			name:        "insert mode autocomplete select previous word",
+			initialText: "foo\nfoobar\nfo",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'G', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlP, '\x10', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyCtrlP, '\x10', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 13,
+			expectedText:      "foo\nfoobar\nfoo",
+		},
+		{
+			name:        "insert mode autocomplete cancel",
+			initialText: "foobar\nfo",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlN, '\x0e', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyCtrlE, '\x05', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 8,
+			expectedText:      "foobar\nfo",
+		},
+		{
+			name:        "insert mode autocomplete undo",
+			initialText: "foobar\nfo",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlN, '\x0e', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone),
+			},
+			expectedCursorPos: 7,
+			expectedText:      "foobar\nfo",
+		},
+		{
+			name:        "insert mode autocomplete repeat last action",
+			initialText: "foobar\nfo\nfo",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlN, '\x0e', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone),
+			},
+			expectedCursorPos: 19,
+			expectedText:      "foobar\nfoobar\nfoobar",
+		},
+		{
+			name:        "bracketed paste in insert mode",
+			initialText: "abc",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventPaste(true),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyTab, '\t', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '1', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '3', tcell.ModNone),
+				tcell.NewEventPaste(false),
+			},
+			expectedCursorPos: 11,
+			expectedText:      "abcxyz\n\t123",
+		},
+		{
+			name:        "bracketed paste in search mode",
+			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '/', tcell.ModNone),
+				tcell.NewEventPaste(true),
+				tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+				tcell.NewEventPaste(false),
+				tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone),
+			},
+			expectedCursorPos: 27,
+			expectedText:      "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interpreter := NewInterpreter()
+			editorState := state.NewEditorState(100, 100, nil, nil)
+
+			// Write the initial text to a temp file, which we will load into the editor.
+			// Append a final "\n" to the contents as the POSIX end-of-file indicator.
+			tmpFile, err := os.CreateTemp(t.TempDir(), "")
+			require.NoError(t, err)
+			path := tmpFile.Name()
+			defer os.Remove(path)
+
+			err = os.WriteFile(path, []byte(tc.initialText+"\n"), 0644)
+			require.NoError(t, err)
+
+			// Load the initial text tempfile.
+			state.LoadDocument(
+				editorState,
+				path,
+				false,
+				func(state.LocatorParams) uint64 { return 0 },
+			)
+
+			// Replay the input events.
+			for _, event := range tc.events {
+				inputCtx := ContextFromEditorState(editorState)
+				action := interpreter.ProcessEvent(event, inputCtx)
+				action(editorState)
+			}
+
+			// Verify that the cursor position and text match what we expect.
+			buffer := editorState.DocumentBuffer()
+			reader := buffer.TextTree().ReaderAtPosition(0)
+			data, err := io.ReadAll(&reader)
+			require.NoError(t, err)
+			text := string(data)
+			assert.Equal(t, tc.expectedCursorPos, buffer.CursorPosition())
+			assert.Equal(t, tc.expectedText, text)
+		})
+	}
+}
+
+func TestInsertModeSelection(t *testing.T) {
+	testCases := []struct {
+		name              string
+		enabled           bool
+		initialText       string
+		events            []tcell.Event
+		expectedCursorPos uint64
+		expectedText      string
+	}{
+		{
+			name:        "select and replace",
+			enabled:     true,
+			initialText: "foo bar baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
 			},
-			expectedCursorPos: 27,
-			expectedText:      "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
+			expectedCursorPos: 5,
+			expectedText:      "foo x baz",
+		},
+		{
+			name:        "select backward and delete with backspace",
+			enabled:     true,
+			initialText: "foo bar baz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyLeft, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyLeft, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyBackspace2, '\u007f', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "fobar baz",
+		},
+		{
+			name:        "select to line end and delete",
+			enabled:     true,
+			initialText: "foo bar\nbaz",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyDelete, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 4,
+			expectedText:      "foo \nbaz",
+		},
+		{
+			name:        "arrow key without shift clears selection",
+			enabled:     true,
+			initialText: "foo bar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+			},
+			expectedCursorPos: 4,
+			expectedText:      "foox bar",
+		},
+		{
+			name:        "copy and paste",
+			enabled:     true,
+			initialText: "foo bar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyCtrlC, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlV, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 10,
+			expectedText:      "foo barfoo",
+		},
+		{
+			name:        "cut and paste",
+			enabled:     true,
+			initialText: "foo bar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyCtrlX, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlV, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 8,
+			expectedText:      "bar foo ",
+		},
+		{
+			name:        "paste replaces selection",
+			enabled:     true,
+			initialText: "foo bar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyCtrlC, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnd, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyLeft, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyLeft, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyLeft, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyCtrlV, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 7,
+			expectedText:      "foo foo",
+		},
+		{
+			name:        "escape clears selection",
+			enabled:     true,
+			initialText: "foo bar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "fxoo bar",
+		},
+		{
+			name:        "disabled, shift arrow moves cursor",
+			enabled:     false,
+			initialText: "foo bar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRight, '\x00', tcell.ModShift),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "foxo bar",
+		},
+		{
+			name:        "disabled, ctrl-v does nothing",
+			enabled:     false,
+			initialText: "foo bar",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlV, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "foo bar",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configRuleSet := config.RuleSet{
+				{
+					Name:    "test",
+					Pattern: "**",
+					Config:  map[string]any{"insertModeSelection": tc.enabled},
+				},
+			}
+			interpreter := NewInterpreter()
+			editorState := state.NewEditorState(100, 100, configRuleSet, nil)
+
+			path := filepath.Join(t.TempDir(), "test.txt")
+			err := os.WriteFile(path, []byte(tc.initialText+"\n"), 0644)
+			require.NoError(t, err)
+			state.LoadDocument(editorState, path, false, func(state.LocatorParams) uint64 { return 0 })
+
+			for _, event := range tc.events {
+				inputCtx := ContextFromEditorState(editorState)
+				action := interpreter.ProcessEvent(event, inputCtx)
+				action(editorState)
+			}
+
+			buffer := editorState.DocumentBuffer()
+			assert.Equal(t, tc.expectedCursorPos, buffer.CursorPosition())
+			assert.Equal(t, tc.expectedText, buffer.TextTree().String())
+		})
+	}
+}
+
+func TestKeyRemap(t *testing.T) {
+	testCases := []struct {
+		name              string
+		keyboardLayout    string
+		keyRemap          map[string]any
+		initialText       string
+		events            []tcell.Event
+		expectedCursorPos uint64
+		expectedText      string
+	}{
+		{
+			name:           "qwerty does not remap keys",
+			keyboardLayout: "qwerty",
+			initialText:    "abc\ndef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+			},
+			expectedCursorPos: 5,
+			expectedText:      "abc\ndef",
+		},
+		{
+			name:           "colemak navigation keys",
+			keyboardLayout: "colemak",
+			initialText:    "abc\ndef\nghi",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone),
+			},
+			expectedCursorPos: 5,
+			expectedText:      "abc\ndef\nghi",
+		},
+		{
+			name:           "colemak displaced key enters insert mode",
+			keyboardLayout: "colemak",
+			initialText:    "abc",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone),
+			},
+			expectedCursorPos: 2,
+			expectedText:      "neabc",
+		},
+		{
+			name:           "colemak find character is not remapped",
+			keyboardLayout: "colemak",
+			initialText:    "abc def jkl",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone),
+			},
+			expectedCursorPos: 5,
+			expectedText:      "abc def jkl",
+		},
+		{
+			name:           "colemak operator with remapped motion",
+			keyboardLayout: "colemak",
+			initialText:    "abc\ndef\nghi",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "ghi",
+		},
+		{
+			name:           "colemak visual mode",
+			keyboardLayout: "colemak",
+			initialText:    "abcdef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "def",
+		},
+		{
+			name:           "dvorak navigation keys",
+			keyboardLayout: "dvorak",
+			initialText:    "abc\ndef\nghi",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+			},
+			expectedCursorPos: 5,
+			expectedText:      "abc\ndef\nghi",
+		},
+		{
+			name:           "dvorak displaced key deletes",
+			keyboardLayout: "dvorak",
+			initialText:    "abc\ndef",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "def",
+		},
+		{
+			name:           "personal remap overrides preset",
+			keyboardLayout: "colemak",
+			keyRemap:       map[string]any{"n": "n", "k": "j"},
+			initialText:    "abc\ndef\nabc",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '*', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'k', tcell.ModNone),
+			},
+			expectedCursorPos: 4,
+			expectedText:      "abc\ndef\nabc",
+		},
+		{
+			name:           "insert mode is not remapped",
+			keyboardLayout: "dvorak",
+			initialText:    "",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone),
+			},
+			expectedCursorPos: 3,
+			expectedText:      "htn",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configRuleSet := config.RuleSet{
+				{
+					Name:    "test",
+					Pattern: "**",
+					Config: map[string]any{
+						"keyboardLayout": tc.keyboardLayout,
+						"keyRemap":       tc.keyRemap,
+					},
+				},
+			}
+			interpreter := NewInterpreter()
+			editorState := state.NewEditorState(100, 100, configRuleSet, nil)
+
+			path := filepath.Join(t.TempDir(), "test.txt")
+			err := os.WriteFile(path, []byte(tc.initialText+"\n"), 0644)
+			require.NoError(t, err)
+			state.LoadDocument(editorState, path, false, func(state.LocatorParams) uint64 { return 0 })
+
+			for _, event := range tc.events {
+				inputCtx := ContextFromEditorState(editorState)
+				action := interpreter.ProcessEvent(event, inputCtx)
+				action(editorState)
+			}
+
+			buffer := editorState.DocumentBuffer()
+			assert.Equal(t, tc.expectedCursorPos, buffer.CursorPosition())
+			assert.Equal(t, tc.expectedText, buffer.TextTree().String())
+		})
+	}
+}
+
+func TestFileExplorerMode(t *testing.T) {
+	keyEvents := func(keys string) []tcell.Event {
+		var events []tcell.Event
+		for _, r := range keys {
+			events = append(events, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+		}
+		return events
+	}
+
+	testCases := []struct {
+		name              string
+		events            []tcell.Event
+		expectedMode      state.InputMode
+		expectedPath      string
+		expectedStatusMsg string
+	}{
+		{
+			name:         "expand directory and open file",
+			events:       append(keyEvents("ljjk"), tcell.NewEventKey(tcell.KeyEnter, '\x00', tcell.ModNone)),
+			expectedMode: state.InputModeNormal,
+			expectedPath: filepath.Join("dir", "a.txt"),
+		},
+		{
+			name:         "close explorer",
+			events:       keyEvents("jq"),
+			expectedMode: state.InputModeNormal,
+		},
+		{
+			name:         "create file",
+			events:       append(keyEvents("anew.txt"), tcell.NewEventKey(tcell.KeyEnter, '\x00', tcell.ModNone)),
+			expectedMode: state.InputModeNormal,
+			expectedPath: filepath.Join("dir", "new.txt"),
+		},
+		{
+			name:              "cancel delete",
+			events:            append(keyEvents("jdn"), tcell.NewEventKey(tcell.KeyEnter, '\x00', tcell.ModNone)),
+			expectedMode:      state.InputModeFileExplorer,
+			expectedStatusMsg: "Canceled deleting the file",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.Mkdir(filepath.Join(dir, "dir"), 0755))
+			for _, p := range []string{filepath.Join("dir", "a.txt"), filepath.Join("dir", "b.txt"), "c.txt"} {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, p), []byte(p), 0644))
+			}
+
+			// The document is outside the explorer's directory, so the explorer starts with the first entry selected.
+			startPath := filepath.Join(t.TempDir(), "start.txt")
+			interpreter := NewInterpreter()
+			editorState := state.NewEditorState(100, 100, nil, nil)
+			state.LoadDocument(editorState, startPath, false, func(state.LocatorParams) uint64 { return 0 })
+			defer func() { editorState.FileWatcher().Stop() }()
+			state.ShowFileExplorer(editorState, dir)
+
+			for _, event := range tc.events {
+				action := interpreter.ProcessEvent(event, ContextFromEditorState(editorState))
+				action(editorState)
+			}
+
+			expectedPath := startPath
+			if tc.expectedPath != "" {
+				expectedPath = filepath.Join(dir, tc.expectedPath)
+			}
+			assert.Equal(t, tc.expectedMode, editorState.InputMode())
+			assert.Equal(t, expectedPath, editorState.FileWatcher().Path())
+			if tc.expectedStatusMsg != "" {
+				assert.Equal(t, tc.expectedStatusMsg, editorState.StatusMsg().Text)
+			}
+		})
+	}
+}
+
+func TestPagerModeQuit(t *testing.T) {
+	testCases := []struct {
+		name         string
+		pagerMode    bool
+		expectedQuit bool
+	}{
+		{
+			name:         "pager mode disabled",
+			pagerMode:    false,
+			expectedQuit: false,
+		},
+		{
+			name:         "pager mode enabled",
+			pagerMode:    true,
+			expectedQuit: true,
 		},
 	}
 
@@ -3139,41 +4520,95 @@ func TestInterpreterStateIntegration(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			interpreter := NewInterpreter()
 			editorState := state.NewEditorState(100, 100, nil, nil)
+			if tc.pagerMode {
+				state.EnablePagerMode(editorState)
+			}
 
-			// Write the initial text to a temp file, which we will load into the editor.
-			// Append a final "\n" to the contents as the POSIX end-of-file indicator.
-			tmpFile, err := os.CreateTemp(t.TempDir(), "")
-			require.NoError(t, err)
-			path := tmpFile.Name()
-			defer os.Remove(path)
+			event := tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone)
+			action := interpreter.ProcessEvent(event, ContextFromEditorState(editorState))
+			action(editorState)
+			assert.Equal(t, tc.expectedQuit, editorState.QuitFlag())
+		})
+	}
+}
 
-			err = os.WriteFile(path, []byte(tc.initialText+"\n"), 0644)
-			require.NoError(t, err)
+func TestSplitKeyBindings(t *testing.T) {
+	interpreter := NewInterpreter()
+	editorState := state.NewEditorState(80, 25, nil, nil)
+	state.LoadScratchText(editorState, "a", "abc")
+	state.LoadScratchText(editorState, "b", "def")
 
-			// Load the initial text tempfile.
-			state.LoadDocument(
-				editorState,
-				path,
-				false,
-				func(state.LocatorParams) uint64 { return 0 },
-			)
+	processEvents := func(events ...*tcell.EventKey) {
+		for _, event := range events {
+			action := interpreter.ProcessEvent(event, ContextFromEditorState(editorState))
+			action(editorState)
+		}
+	}
+	ctrlW := tcell.NewEventKey(tcell.KeyCtrlW, '\x00', tcell.ModNone)
+	runeKey := func(r rune) *tcell.EventKey {
+		return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+	}
+	splitHeights := func() []uint64 {
+		var heights []uint64
+		for _, v := range editorState.SplitViews() {
+			heights = append(heights, v.Height)
+		}
+		return heights
+	}
 
-			// Replay the input events.
-			for _, event := range tc.events {
-				inputCtx := ContextFromEditorState(editorState)
-				action := interpreter.ProcessEvent(event, inputCtx)
-				action(editorState)
-			}
+	processEvents(ctrlW, runeKey('s'))
+	assert.Equal(t, []uint64{12, 12}, splitHeights())
+	assert.Equal(t, "abc", editorState.DocumentBuffer().TextTree().String())
 
-			// Verify that the cursor position and text match what we expect.
-			buffer := editorState.DocumentBuffer()
-			reader := buffer.TextTree().ReaderAtPosition(0)
-			data, err := io.ReadAll(&reader)
-			require.NoError(t, err)
-			text := string(data)
-			assert.Equal(t, tc.expectedCursorPos, buffer.CursorPosition())
-			assert.Equal(t, tc.expectedText, text)
-		})
+	// A count before ctrl-w changes the size by that many rows.
+	processEvents(runeKey('3'), ctrlW, runeKey('+'))
+	assert.Equal(t, []uint64{9, 15}, splitHeights())
+
+	processEvents(ctrlW, runeKey('='))
+	assert.Equal(t, []uint64{12, 12}, splitHeights())
+
+	processEvents(ctrlW, runeKey('z'))
+	assert.Equal(t, []uint64{24}, splitHeights())
+	processEvents(ctrlW, runeKey('z'))
+
+	processEvents(ctrlW, ctrlW)
+	assert.Equal(t, "def", editorState.DocumentBuffer().TextTree().String())
+
+	processEvents(ctrlW, runeKey('c'))
+	assert.Nil(t, editorState.SplitViews())
+}
+
+func TestSecretTextFieldInputNotLogged(t *testing.T) {
+	var logBuf bytes.Buffer
+	logWriter := log.Writer()
+	defer func() {
+		log.SetOutput(logWriter)
+	}()
+	log.SetOutput(&logBuf)
+
+	interpreter := NewInterpreter()
+	editorState := state.NewEditorState(100, 100, nil, nil)
+	var submitted string
+	state.ShowSecretTextField(editorState, "Passphrase:", func(_ *state.EditorState, s string) error {
+		submitted = s
+		return nil
+	})
+
+	for _, r := range "hunter2" {
+		event := tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+		action := interpreter.ProcessEvent(event, ContextFromEditorState(editorState))
+		action(editorState)
+	}
+	event := tcell.NewEventKey(tcell.KeyEnter, '\r', tcell.ModNone)
+	action := interpreter.ProcessEvent(event, ContextFromEditorState(editorState))
+	action(editorState)
+
+	assert.Equal(t, "hunter2", submitted)
+	assert.Equal(t, state.InputModeNormal, editorState.InputMode())
+	assert.Contains(t, logBuf.String(), "accepted secret input")
+	for _, r := range "hunter2" {
+		assert.NotContains(t, logBuf.String(), fmt.Sprintf("Rune[%c]", r))
+		assert.NotContains(t, logBuf.String(), fmt.Sprintf("InsertChar:%d", r))
 	}
 }
 
@@ -3213,6 +4648,48 @@ func TestEnterAndExitVisualModeThenReplayLastAction(t *testing.T) {
 	}
 }
 
+func TestInsertModeTimestampThenReplayLastAction(t *testing.T) {
+	configRuleSet := config.RuleSet{
+		{
+			Name:    "test",
+			Pattern: "**",
+			Config:  map[string]any{"timestampFormats": []any{"15:04"}},
+		},
+	}
+	editorState := state.NewEditorState(100, 100, configRuleSet, nil)
+	path := filepath.Join(t.TempDir(), "test.txt")
+	err := os.WriteFile(path, []byte("\n"), 0644)
+	require.NoError(t, err)
+	state.LoadDocument(editorState, path, false, func(state.LocatorParams) uint64 { return 0 })
+
+	interpreter := NewInterpreter()
+	processEventsAtTime := func(now time.Time, events ...tcell.Event) {
+		restore := nondet.SetSource(nondet.NewDeterministicSource(now, 0, 0))
+		defer restore()
+		for _, event := range events {
+			inputCtx := ContextFromEditorState(editorState)
+			action := interpreter.ProcessEvent(event, inputCtx)
+			action(editorState)
+		}
+	}
+
+	processEventsAtTime(
+		time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC),
+		tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyCtrlT, '\x14', tcell.ModCtrl),
+		tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+	)
+
+	// The repeated action inserts the time of the replay, not the original time.
+	processEventsAtTime(
+		time.Date(2024, 3, 15, 9, 45, 0, 0, time.UTC),
+		tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone),
+	)
+
+	assert.Equal(t, "09:30 09:45 ", editorState.DocumentBuffer().TextTree().String())
+}
+
 func inputEventsForBracketedPaste(s string) []tcell.Event {
 	inputEvents := make([]tcell.Event, 0, len(s)+2)
 	inputEvents = append(inputEvents, tcell.NewEventPaste(true))
@@ -3420,6 +4897,9 @@ func TestLoadGeneratedStateMachines(t *testing.T) {
 		{name: "search mode", path: SearchModePath},
 		{name: "task mode", path: TaskModePath},
 		{name: "textfield mode", path: TextFieldModePath},
+		{name: "undo preview mode", path: UndoPreviewModePath},
+		{name: "changes mode", path: ChangesModePath},
+		{name: "autocomplete mode", path: AutocompleteModePath},
 	}
 
 	for _, tc := range testCases {
@@ -3581,3 +5061,325 @@ func BenchmarkProcessEvent(b *testing.B) {
 		})
 	}
 }
+
+func TestKeyHints(t *testing.T) {
+	testCases := []struct {
+		name            string
+		showKeyHints    bool
+		intervalElapses bool
+		events          []tcell.Event
+		expectedMsg     string
+	}{
+		{
+			name:         "hints disabled",
+			showKeyHints: false,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'Q', tcell.ModNone),
+			},
+			expectedMsg: "",
+		},
+		{
+			name:         "unbound key in normal mode",
+			showKeyHints: true,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'Q', tcell.ModNone),
+			},
+			expectedMsg: `"Q" is not bound in normal mode`,
+		},
+		{
+			name:         "unbound key sequence in normal mode",
+			showKeyHints: true,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone),
+			},
+			expectedMsg: `"dq" is not bound in normal mode`,
+		},
+		{
+			name:         "unbound key in visual mode",
+			showKeyHints: true,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyF5, '\x00', tcell.ModNone),
+			},
+			expectedMsg: `"F5" is not bound in visual mode`,
+		},
+		{
+			name:         "rate limit hints",
+			showKeyHints: true,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'Q', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'Z', tcell.ModNone),
+			},
+			expectedMsg: `"Q" is not bound in normal mode`,
+		},
+		{
+			name:            "hint again after interval",
+			showKeyHints:    true,
+			intervalElapses: true,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'Q', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'Z', tcell.ModNone),
+			},
+			expectedMsg: `"Z" is not bound in normal mode`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interpreter := NewInterpreter()
+			editorState := state.NewEditorState(100, 100, nil, nil)
+			for _, event := range tc.events {
+				inputCtx := ContextFromEditorState(editorState)
+				inputCtx.ShowKeyHints = tc.showKeyHints
+				action := interpreter.ProcessEvent(event, inputCtx)
+				action(editorState)
+				if tc.intervalElapses {
+					for _, m := range interpreter.modes {
+						m.lastHintTime = m.lastHintTime.Add(-minKeyHintInterval)
+					}
+				}
+			}
+			assert.Equal(t, tc.expectedMsg, editorState.StatusMsg().Text)
+		})
+	}
+}
+
+func TestRingBell(t *testing.T) {
+	testCases := []struct {
+		name              string
+		bell              string
+		events            []tcell.Event
+		expectedBellCount int
+	}{
+		{
+			name: "bound key",
+			bell: config.BellVisual,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone),
+			},
+			expectedBellCount: 0,
+		},
+		{
+			name: "unbound key",
+			bell: config.BellVisual,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'Q', tcell.ModNone),
+			},
+			expectedBellCount: 1,
+		},
+		{
+			name: "unbound key sequence",
+			bell: config.BellAudible,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone),
+			},
+			expectedBellCount: 1,
+		},
+		{
+			name: "count too large",
+			bell: config.BellAudible,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, '9', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '9', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '9', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '9', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+			},
+			expectedBellCount: 1,
+		},
+		{
+			name: "bell disabled",
+			bell: config.BellNone,
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'Q', tcell.ModNone),
+			},
+			expectedBellCount: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configRuleSet := config.RuleSet{
+				{
+					Name:    "test",
+					Pattern: "**",
+					Config:  map[string]any{"bell": tc.bell},
+				},
+			}
+			interpreter := NewInterpreter()
+			editorState := state.NewEditorState(100, 100, configRuleSet, nil)
+
+			path := filepath.Join(t.TempDir(), "test.txt")
+			err := os.WriteFile(path, []byte("abc\n"), 0644)
+			require.NoError(t, err)
+			state.LoadDocument(editorState, path, false, func(state.LocatorParams) uint64 { return 0 })
+
+			for _, event := range tc.events {
+				inputCtx := ContextFromEditorState(editorState)
+				action := interpreter.ProcessEvent(event, inputCtx)
+				action(editorState)
+			}
+			assert.Equal(t, tc.expectedBellCount, editorState.BellCount())
+		})
+	}
+}
+
+func TestShowKeyBindingsMenuRunsCommand(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputMode         state.InputMode
+		search            string
+		expectedCursorPos uint64
+		expectedInputMode state.InputMode
+		expectedMsg       string
+	}{
+		{
+			name:              "normal mode command",
+			inputMode:         state.InputModeNormal,
+			search:            "cursor down (",
+			expectedCursorPos: 4,
+			expectedInputMode: state.InputModeNormal,
+		},
+		{
+			name:              "visual mode command",
+			inputMode:         state.InputModeVisual,
+			search:            "cursor right",
+			expectedCursorPos: 1,
+			expectedInputMode: state.InputModeVisual,
+		},
+		{
+			name:              "command that needs a character",
+			inputMode:         state.InputModeNormal,
+			search:            "cursor to next matching char (",
+			expectedCursorPos: 0,
+			expectedInputMode: state.InputModeNormal,
+			expectedMsg:       `Type the keys for "cursor to next matching char (f{char})" to run it, since it needs a character`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			editorState := state.NewEditorState(100, 100, nil, nil)
+			state.InsertText(editorState, "abc\ndef")
+			state.MoveCursor(editorState, func(state.LocatorParams) uint64 { return 0 })
+			if tc.inputMode == state.InputModeVisual {
+				state.ToggleVisualMode(editorState, selection.ModeChar)
+			}
+
+			ShowKeyBindingsMenu(ContextFromEditorState(editorState))(editorState)
+			for _, r := range tc.search {
+				state.AppendRuneToMenuSearch(editorState, r)
+			}
+			state.ExecuteSelectedMenuItem(editorState)
+			assert.Equal(t, tc.expectedInputMode, editorState.InputMode())
+			assert.Equal(t, tc.expectedCursorPos, editorState.DocumentBuffer().CursorPosition())
+			assert.Equal(t, tc.expectedMsg, editorState.StatusMsg().Text)
+		})
+	}
+}
+
+func TestShowKeyBindingsMenu(t *testing.T) {
+	testCases := []struct {
+		name         string
+		inputMode    state.InputMode
+		expectedName string
+	}{
+		{
+			name:         "normal mode",
+			inputMode:    state.InputModeNormal,
+			expectedName: "cursor left (left arrow or h)",
+		},
+		{
+			name:         "visual mode",
+			inputMode:    state.InputModeVisual,
+			expectedName: "cursor left (left arrow or h)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			editorState := state.NewEditorState(100, 100, nil, nil)
+			action := ShowKeyBindingsMenu(Context{InputMode: tc.inputMode})
+			action(editorState)
+			assert.Equal(t, state.InputModeMenu, editorState.InputMode())
+			assert.Equal(t, state.MenuStyleKeyBindings, editorState.Menu().Style())
+			results, _ := editorState.Menu().SearchResults()
+			require.Greater(t, len(results), 0)
+			assert.Equal(t, tc.expectedName, results[0].Name)
+		})
+	}
+}
+
+func TestChangesModeJumpToChange(t *testing.T) {
+	testCases := []struct {
+		name              string
+		events            []*tcell.EventKey
+		expectedCursorPos uint64
+	}{
+		{
+			name: "jump to change on last line",
+			events: []*tcell.EventKey{
+				tcell.NewEventKey(tcell.KeyRune, 'G', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEnter, '\x00', tcell.ModNone),
+			},
+			expectedCursorPos: 4,
+		},
+		{
+			name: "close without jumping",
+			events: []*tcell.EventKey{
+				tcell.NewEventKey(tcell.KeyRune, 'G', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp(t.TempDir(), "")
+			require.NoError(t, err)
+			path := tmpFile.Name()
+			require.NoError(t, tmpFile.Close())
+			require.NoError(t, os.WriteFile(path, []byte("foo\nbar\n"), 0644))
+
+			interpreter := NewInterpreter()
+			editorState := state.NewEditorState(100, 100, nil, nil)
+			state.LoadDocument(editorState, path, true, func(p state.LocatorParams) uint64 { return 0 })
+			defer func() { editorState.FileWatcher().Stop() }()
+
+			events := []*tcell.EventKey{
+				tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyEscape, '\x00', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+			}
+			for _, event := range events {
+				interpreter.ProcessEvent(event, ContextFromEditorState(editorState))(editorState)
+			}
+
+			state.ShowChangesSinceLoad(editorState)
+			require.Equal(t, state.InputModeChanges, editorState.InputMode())
+			for _, event := range tc.events {
+				interpreter.ProcessEvent(event, ContextFromEditorState(editorState))(editorState)
+			}
+			assert.Equal(t, state.InputModeNormal, editorState.InputMode())
+			assert.Equal(t, tc.expectedCursorPos, editorState.DocumentBuffer().CursorPosition())
+		})
+	}
+}
+
+func TestShowKeyBindingsMenuSearch(t *testing.T) {
+	editorState := state.NewEditorState(100, 100, nil, nil)
+	action := ShowKeyBindingsMenu(Context{InputMode: state.InputModeVisual})
+	action(editorState)
+	for _, r := range "show command menu" {
+		state.AppendRuneToMenuSearch(editorState, r)
+	}
+	results, _ := editorState.Menu().SearchResults()
+	require.Greater(t, len(results), 0)
+	assert.Equal(t, "show command menu (:)", results[0].Name)
+}
@@ -0,0 +1,158 @@
+package input
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/input/engine"
+)
+
+// keyNames are the names of keys that tcell does not name, or names that are clearer than tcell's.
+var keyNames = map[tcell.Key]string{
+	tcell.KeyEscape:    "escape",
+	tcell.KeyEnter:     "enter",
+	tcell.KeyTab:       "tab",
+	tcell.KeyBacktab:   "shift-tab",
+	tcell.KeyBackspace: "backspace",
+	tcell.KeyDEL:       "backspace",
+	tcell.KeyPgUp:      "page up",
+	tcell.KeyPgDn:      "page down",
+	tcell.KeyLeft:      "left arrow",
+	tcell.KeyRight:     "right arrow",
+	tcell.KeyUp:        "up arrow",
+	tcell.KeyDown:      "down arrow",
+	keyCtrlHome:        "ctrl-home",
+	keyCtrlEnd:         "ctrl-end",
+	keyShiftLeft:       "shift-left arrow",
+	keyShiftRight:      "shift-right arrow",
+	keyShiftUp:         "shift-up arrow",
+	keyShiftDown:       "shift-down arrow",
+	keyShiftHome:       "shift-home",
+	keyShiftEnd:        "shift-end",
+}
+
+// describeKeys returns a human-readable description of the key sequences matched by a command expression,
+// such as "dd" or "ctrl-d". Alternatives are separated by "or", and placeholders like "{char}"
+// represent any character. Counts and clipboard pages are omitted because most commands accept them.
+// This is generated from the same expressions used to compile the input state machine,
+// so it stays in sync with the actual key bindings.
+func describeKeys(expr engine.Expr) string {
+	return describeExpr(expr, false)
+}
+
+func describeExpr(expr engine.Expr, nested bool) string {
+	switch expr := expr.(type) {
+	case engine.EventExpr:
+		return describeEvent(expr.Event)
+
+	case engine.EventRangeExpr:
+		return "{" + describeEvent(expr.StartEvent) + "-" + describeEvent(expr.EndEvent) + "}"
+
+	case engine.ConcatExpr:
+		if containsClipboardPageCapture(expr) {
+			return ""
+		}
+
+		var children []engine.Expr
+		var parts []string
+		separator := ""
+		for _, child := range expr.Children {
+			part := describeExpr(child, true)
+			if part == "" {
+				continue
+			}
+			if strings.Contains(part, " ") || isNamedKey(child) {
+				separator = " "
+			}
+			children = append(children, child)
+			parts = append(parts, part)
+		}
+
+		if len(parts) == 1 {
+			// Describe the only child as if it were not part of the sequence,
+			// so alternatives don't have unnecessary parentheses.
+			return describeExpr(children[0], nested)
+		}
+		return strings.Join(parts, separator)
+
+	case engine.AltExpr:
+		parts := make([]string, 0, len(expr.Children))
+		for _, child := range expr.Children {
+			if part := describeExpr(child, true); part != "" && !slices.Contains(parts, part) {
+				parts = append(parts, part)
+			}
+		}
+		s := strings.Join(parts, " or ")
+		if nested && len(parts) > 1 {
+			s = "(" + s + ")"
+		}
+		return s
+
+	case engine.OptionExpr:
+		part := describeExpr(expr.Child, true)
+		if part == "" {
+			return ""
+		}
+		return "[" + part + "]"
+
+	case engine.StarExpr:
+		part := describeExpr(expr.Child, true)
+		if part == "" {
+			return ""
+		}
+		return "[" + part + "...]"
+
+	case engine.CaptureExpr:
+		switch expr.CaptureId {
+		case captureIdVerbCount, captureIdObjectCount, captureIdClipboardPage:
+			return ""
+		case captureIdMatchChar, captureIdReplaceChar, captureIdInsertChar:
+			return "{char}"
+		default:
+			return describeExpr(expr.Child, nested)
+		}
+
+	default:
+		return ""
+	}
+}
+
+func describeEvent(event engine.Event) string {
+	key := engineEventToKey(event)
+	if key == tcell.KeyRune {
+		r := engineEventToRune(event)
+		if r == ' ' {
+			return "space"
+		}
+		return string(r)
+	}
+
+	if name, ok := keyNames[key]; ok {
+		return name
+	}
+
+	if name, ok := tcell.KeyNames[key]; ok {
+		return strings.ToLower(name)
+	}
+
+	return "?"
+}
+
+// isNamedKey returns whether an expression matches a key other than a character, such as "ctrl-d".
+// These must be separated from the other keys in a sequence by spaces.
+func isNamedKey(expr engine.Expr) bool {
+	eventExpr, ok := expr.(engine.EventExpr)
+	return ok && engineEventToKey(eventExpr.Event) != tcell.KeyRune
+}
+
+// containsClipboardPageCapture returns whether a sequence selects a clipboard page, like `"a`.
+func containsClipboardPageCapture(expr engine.ConcatExpr) bool {
+	for _, child := range expr.Children {
+		if captureExpr, ok := child.(engine.CaptureExpr); ok && captureExpr.CaptureId == captureIdClipboardPage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aretext/aretext/input/engine"
+)
+
+func TestDescribeKeys(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expr     engine.Expr
+		expected string
+	}{
+		{
+			name:     "single rune",
+			expr:     runeExpr('x'),
+			expected: "x",
+		},
+		{
+			name:     "named key",
+			expr:     keyExpr(tcell.KeyCtrlD),
+			expected: "ctrl-d",
+		},
+		{
+			name:     "space",
+			expr:     runeExpr(' '),
+			expected: "space",
+		},
+		{
+			name:     "rune sequence with count and clipboard page",
+			expr:     cmdExpr("d", "aw", captureOpts{count: true, clipboardPage: true}),
+			expected: "daw",
+		},
+		{
+			name:     "alternatives with count",
+			expr:     verbCountThenExpr(altExpr(keyExpr(tcell.KeyLeft), runeExpr('h'))),
+			expected: "left arrow or h",
+		},
+		{
+			name:     "duplicate alternatives",
+			expr:     altExpr(keyExpr(tcell.KeyBackspace), keyExpr(tcell.KeyBackspace2)),
+			expected: "backspace",
+		},
+		{
+			name:     "match char",
+			expr:     cmdExpr("f", "", captureOpts{matchChar: true}),
+			expected: "f{char}",
+		},
+		{
+			name: "named key in sequence",
+			expr: engine.ConcatExpr{Children: []engine.Expr{
+				keyExpr(tcell.KeyCtrlW),
+				altExpr(runeExpr('h'), runeExpr('l')),
+			}},
+			expected: "ctrl-w (h or l)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, describeKeys(tc.expr))
+		})
+	}
+}
+
+func TestDescribeKeysAllCommands(t *testing.T) {
+	// Every command bound in normal or visual mode should have a description.
+	commands := append(NormalModeCommands(), VisualModeCommands()...)
+	for _, cmd := range commands {
+		assert.NotEmpty(t, describeKeys(cmd.BuildExpr()), cmd.Name)
+	}
+}
@@ -0,0 +1,69 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// namedKey is a key event that has a name, like "escape" or "ctrl-d".
+type namedKey struct {
+	key tcell.Key
+	r   rune
+	mod tcell.ModMask
+}
+
+// namedKeys maps key names to key events, using the same names as key hints.
+var namedKeys map[string]namedKey
+
+func init() {
+	namedKeys = make(map[string]namedKey, len(tcell.KeyNames)+len(keyNames))
+	for key, name := range tcell.KeyNames {
+		namedKeys[strings.ToLower(name)] = namedKey{key: key}
+	}
+
+	for key, name := range keyNames {
+		if key <= tcell.KeyF64 {
+			namedKeys[name] = namedKey{key: key}
+		}
+	}
+
+	// Some names map to more than one key, so choose the key terminals usually send.
+	namedKeys["backspace"] = namedKey{key: tcell.KeyDEL}
+	namedKeys["ctrl-home"] = namedKey{key: tcell.KeyHome, mod: tcell.ModCtrl}
+	namedKeys["ctrl-end"] = namedKey{key: tcell.KeyEnd, mod: tcell.ModCtrl}
+	namedKeys["space"] = namedKey{key: tcell.KeyRune, r: ' '}
+	namedKeys["lt"] = namedKey{key: tcell.KeyRune, r: '<'}
+}
+
+// ParseKeys converts a string to the key events a user would type to produce it.
+// Each character is a key, except for key names in angle brackets, like "<escape>", "<enter>", or "<ctrl-d>".
+// Key names are the same as in key hints. Use "<lt>" for a literal "<".
+func ParseKeys(s string) ([]*tcell.EventKey, error) {
+	var events []*tcell.EventKey
+	for len(s) > 0 {
+		if s[0] != '<' {
+			r, size := utf8.DecodeRuneInString(s)
+			events = append(events, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+			s = s[size:]
+			continue
+		}
+
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			return nil, fmt.Errorf("Missing '>' after key name in %q", s)
+		}
+
+		name := strings.ToLower(s[1:end])
+		nk, ok := namedKeys[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown key name %q", name)
+		}
+
+		events = append(events, tcell.NewEventKey(nk.key, nk.r, nk.mod))
+		s = s[end+1:]
+	}
+	return events, nil
+}
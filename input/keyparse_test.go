@@ -0,0 +1,67 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeys(t *testing.T) {
+	testCases := []struct {
+		name     string
+		s        string
+		expected []string
+	}{
+		{
+			name:     "empty",
+			s:        "",
+			expected: nil,
+		},
+		{
+			name:     "runes",
+			s:        "ggdGü",
+			expected: []string{"g", "g", "d", "G", "ü"},
+		},
+		{
+			name:     "named keys",
+			s:        "ihello<Escape><space>:w<enter><ctrl-d>",
+			expected: []string{"i", "h", "e", "l", "l", "o", "escape", "space", ":", "w", "enter", "ctrl-d"},
+		},
+		{
+			name:     "literal less than",
+			s:        "i<lt>a>",
+			expected: []string{"i", "<", "a", ">"},
+		},
+		{
+			name:     "keys with modifiers",
+			s:        "<ctrl-home><page down><backspace>",
+			expected: []string{"ctrl-home", "page down", "backspace"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			events, err := ParseKeys(tc.s)
+			require.NoError(t, err)
+
+			var names []string
+			for _, event := range events {
+				names = append(names, describeEvent(eventKeyToEngineEvent(event)))
+			}
+			if tc.expected == nil {
+				assert.Empty(t, names)
+			} else {
+				assert.Equal(t, tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestParseKeysErrors(t *testing.T) {
+	_, err := ParseKeys("i<escape")
+	assert.EqualError(t, err, `Missing '>' after key name in "<escape"`)
+
+	_, err = ParseKeys("<hyperspace>")
+	assert.EqualError(t, err, `Unknown key name "hyperspace"`)
+}
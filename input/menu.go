@@ -13,7 +13,9 @@ func menuItems(ctx Context) []menu.Item {
 			Aliases: []string{"q"},
 			Action: func(s *state.EditorState) {
 				abortMsg := `Document has unsaved changes. Either save them ("force save") or quit without saving ("force quit")`
-				state.AbortIfUnsavedChanges(s, abortMsg, state.Quit)
+				state.AbortIfUnsavedChanges(s, abortMsg, func(s *state.EditorState) {
+					state.AbortIfUnsavedChangesInBackground(s, state.Quit)
+				})
 			},
 		},
 		{
@@ -40,9 +42,11 @@ func menuItems(ctx Context) []menu.Item {
 			Name:    "save document and quit",
 			Aliases: []string{"sq", "wq", "x"},
 			Action: func(s *state.EditorState) {
-				state.AbortIfFileChanged(s, func(s *state.EditorState) {
-					state.SaveDocument(s)
-					state.Quit(s)
+				state.AbortIfUnsavedChangesInBackground(s, func(s *state.EditorState) {
+					state.AbortIfFileChanged(s, func(s *state.EditorState) {
+						state.SaveDocument(s)
+						state.Quit(s)
+					})
 				})
 			},
 		},
@@ -67,22 +71,106 @@ func menuItems(ctx Context) []menu.Item {
 		{
 			Name:    "find and open",
 			Aliases: []string{"f"},
-			Action: func(s *state.EditorState) {
-				state.AbortIfUnsavedChanges(s, state.DefaultUnsavedChangesAbortMsg, ShowFileMenu(ctx))
-			},
+			Action:  ShowFileMenu(ctx),
 		},
 		{
 			Name:    "open previous document",
 			Aliases: []string{"p"},
-			Action: func(s *state.EditorState) {
-				state.AbortIfUnsavedChanges(s, state.DefaultUnsavedChangesAbortMsg, state.LoadPrevDocument)
-			},
+			Action:  state.LoadPrevDocument,
 		},
 		{
 			Name:    "open next document",
 			Aliases: []string{"n"},
+			Action:  state.LoadNextDocument,
+		},
+		{
+			Name:    "buffer next",
+			Aliases: []string{"bn"},
+			Action:  state.NextBuffer,
+		},
+		{
+			Name:    "buffer previous",
+			Aliases: []string{"bp"},
+			Action:  state.PrevBuffer,
+		},
+		{
+			Name:    "buffer list",
+			Aliases: []string{"bl", "ls"},
+			Action:  state.ShowBufferListMenu,
+		},
+		{
+			Name:    "buffer close",
+			Aliases: []string{"bd"},
+			Action:  state.CloseBuffer,
+		},
+		{
+			Name:    "split horizontal",
+			Aliases: []string{"hsp"},
+			Action:  state.SplitHorizontal,
+		},
+		{
+			Name:    "split vertical",
+			Aliases: []string{"vsp"},
+			Action:  state.SplitVertical,
+		},
+		{
+			Name:    "split close",
+			Aliases: []string{"close"},
+			Action:  state.CloseSplit,
+		},
+		{
+			Name:    "split only",
+			Aliases: []string{"only"},
+			Action:  state.CloseOtherSplits,
+		},
+		{
+			Name:   "split zoom",
+			Action: state.ToggleSplitZoom,
+		},
+		{
+			Name:   "split equalize",
+			Action: state.EqualizeSplits,
+		},
+		{
+			Name:    "delete current file",
+			Aliases: []string{"rm"},
+			Action:  ShowMoveToTrashTextField,
+		},
+		{
+			Name:    "restore last trashed file",
+			Aliases: []string{"unrm"},
+			Action:  RestoreLastTrashedFile,
+		},
+		{
+			Name:    "decrypt document",
+			Aliases: []string{"decrypt"},
+			Action:  state.DecryptDocument,
+		},
+		{
+			Name:    "encrypt document",
+			Aliases: []string{"encrypt"},
+			Action:  state.EncryptDocument,
+		},
+		{
+			Name:    "open scratch buffer",
+			Aliases: []string{"sc"},
+			Action:  ShowOpenScratchBufferTextField,
+		},
+		{
+			Name:    "show scratch buffers",
+			Aliases: []string{"scs"},
+			Action:  ShowScratchBufferMenu,
+		},
+		{
+			Name:    "save scratch buffer as",
+			Aliases: []string{"sa"},
+			Action:  ShowSaveScratchBufferAsTextField,
+		},
+		{
+			Name:    "file explorer",
+			Aliases: []string{"ex"},
 			Action: func(s *state.EditorState) {
-				state.AbortIfUnsavedChanges(s, state.DefaultUnsavedChangesAbortMsg, state.LoadNextDocument)
+				state.ShowFileExplorer(s, ".")
 			},
 		},
 		{
@@ -122,11 +210,207 @@ func menuItems(ctx Context) []menu.Item {
 			Aliases: []string{"nur"},
 			Action:  state.ToggleLineNumberMode,
 		},
+		{
+			Name:    "toggle ruler",
+			Aliases: []string{"ru"},
+			Action:  state.ToggleShowRuler,
+		},
+		{
+			Name:    "toggle diff gutter",
+			Aliases: []string{"dg"},
+			Action:  state.ToggleShowDiffGutter,
+		},
+		{
+			Name:    "toggle scrollbar",
+			Aliases: []string{"sb"},
+			Action:  state.ToggleShowScrollbar,
+		},
+		{
+			Name:    "toggle matching bracket",
+			Aliases: []string{"mb"},
+			Action:  state.ToggleShowMatchingBracket,
+		},
+		{
+			Name:    "toggle ansi colors",
+			Aliases: []string{"ac"},
+			Action:  state.ToggleShowAnsiColors,
+		},
+		{
+			Name:    "toggle git blame",
+			Aliases: []string{"gb"},
+			Action:  state.ToggleShowBlame,
+		},
+		{
+			Name:    "show git blame commit",
+			Aliases: []string{"gbc"},
+			Action:  state.ShowCommitForCursorLine,
+		},
+		{
+			Name:    "toggle line wrap",
+			Aliases: []string{"lw"},
+			Action:  state.ToggleLineWrap,
+		},
+		{
+			Name:    "toggle right-to-left visual order",
+			Aliases: []string{"rtl"},
+			Action:  state.ToggleRtlVisualOrder,
+		},
+		{
+			Name:    "cycle ligature breaker",
+			Aliases: []string{"lig"},
+			Action:  state.CycleLigatureBreaker,
+		},
+		{
+			Name:    "set tab display width",
+			Aliases: []string{"tw"},
+			Action:  ShowTabDisplaySizeTextField,
+		},
+		{
+			Name:    "set line number margin width",
+			Aliases: []string{"gw"},
+			Action:  ShowLineNumMarginWidthTextField,
+		},
+		{
+			Name:    "insert timestamp",
+			Aliases: []string{"ts"},
+			Action:  state.ShowTimestampMenu,
+		},
+		{
+			Name:    "set syntax language",
+			Aliases: []string{"syn"},
+			Action:  state.ShowSyntaxLanguageMenu,
+		},
 		{
 			Name:    "toggle auto-indent",
 			Aliases: []string{"ai"},
 			Action:  state.ToggleAutoIndent,
 		},
+		{
+			Name:    "next TODO or FIXME",
+			Aliases: []string{"todo"},
+			Action:  state.JumpToNextFlaggedItem,
+		},
+		{
+			Name:    "show changes since load",
+			Aliases: []string{"diff"},
+			Action:  state.ShowChangesSinceLoad,
+		},
+		{
+			Name:    "restore from backup",
+			Aliases: []string{"bak"},
+			Action:  ShowBackupMenu,
+		},
+		{
+			Name:    "preview undo",
+			Aliases: []string{"pu"},
+			Action: func(s *state.EditorState) {
+				state.EnterUndoPreviewMode(s, 1)
+			},
+		},
+		{
+			Name:    "show undo branches",
+			Aliases: []string{"ub"},
+			Action:  state.ShowUndoBranchesMenu,
+		},
+		{
+			Name:    "revert to checkpoint",
+			Aliases: []string{"rcp"},
+			Action:  state.RevertToUndoCheckpoint,
+		},
+		{
+			Name:    "revert changes from last minutes",
+			Aliases: []string{"revert"},
+			Action:  ShowRevertChangesTextField,
+		},
+		{
+			Name:    "show key bindings",
+			Aliases: []string{"kb"},
+			Action: func(s *state.EditorState) {
+				ShowKeyBindingsMenu(ctx)(s)
+			},
+		},
+		{
+			Name:    "toggle paste mode",
+			Aliases: []string{"pm"},
+			Action:  state.TogglePasteMode,
+		},
+		{
+			Name:    "wrap document",
+			Aliases: []string{"wrap"},
+			Action:  ShowWrapDocumentTextField,
+		},
+		{
+			Name:    "convert indentation to spaces",
+			Aliases: []string{"spaces"},
+			Action:  state.ConvertIndentationToSpaces,
+		},
+		{
+			Name:    "convert indentation to tabs",
+			Aliases: []string{"tabs"},
+			Action:  state.ConvertIndentationToTabs,
+		},
+		{
+			Name:    "set project line endings",
+			Aliases: []string{"le"},
+			Action:  state.ShowTextFormatMenu,
+		},
+		{
+			Name:    "split line on delimiter",
+			Aliases: []string{"split"},
+			Action:  ShowSplitLineTextField,
+		},
+		{
+			Name:    "unwrap paragraphs",
+			Aliases: []string{"unwrap"},
+			Action:  state.UnwrapDocument,
+		},
+		{
+			Name:    "toggle follow mode",
+			Aliases: []string{"tail"},
+			Action: func(s *state.EditorState) {
+				state.AbortIfUnsavedChanges(s, state.DefaultUnsavedChangesAbortMsg, state.ToggleFollowTail)
+			},
+		},
+		{
+			Name:    "reload config",
+			Aliases: []string{"rc"},
+			Action:  state.ReloadConfig,
+		},
+		{
+			Name:    "open config file",
+			Aliases: []string{"oc"},
+			Action:  state.OpenConfigFile,
+		},
+		{
+			Name:    "open config directory",
+			Aliases: []string{"ocd"},
+			Action:  state.ShowConfigDirMenu,
+		},
+		{
+			Name:    "bookmark line",
+			Aliases: []string{"bm"},
+			Action:  ShowBookmarkTextField,
+		},
+		{
+			Name:    "remove bookmark",
+			Aliases: []string{"rbm"},
+			Action:  RemoveBookmark,
+		},
+		{
+			Name:    "show bookmarks",
+			Aliases: []string{"bms"},
+			Action:  ShowBookmarkMenu,
+		},
+		{
+			Name:    "open log",
+			Aliases: []string{"log"},
+			Action:  state.OpenLog,
+		},
+		{
+			Name:    "cancel background shell command",
+			Aliases: []string{"cancel"},
+			Action:  state.CancelOutputCmd,
+		},
 	}
 
 	// User-defined macros are available only in normal mode, not visual mode.
@@ -147,5 +431,97 @@ func menuItems(ctx Context) []menu.Item {
 		}...)
 	}
 
+	// Find and replace applies to the entire document, and rename applies to the identifier
+	// under the cursor, so these are available only in normal mode
+	// to avoid suggesting that they replace text only within the selection.
+	if ctx.InputMode == state.InputModeNormal {
+		items = append(items, []menu.Item{
+			{
+				Name:    "find and replace",
+				Aliases: []string{"fr"},
+				Action:  ShowReplaceAllTextField(false),
+			},
+			{
+				Name:    "find and replace preserving case",
+				Aliases: []string{"frc"},
+				Action:  ShowReplaceAllTextField(true),
+			},
+			{
+				Name:    "find and replace with confirmation",
+				Aliases: []string{"fri"},
+				Action:  ShowReplaceWithConfirmationTextField,
+			},
+			{
+				Name:    "rename in block",
+				Aliases: []string{"rb"},
+				Action:  ShowRenameInBlockTextField,
+			},
+			{
+				Name:    "calculate with number under cursor",
+				Aliases: []string{"calc"},
+				Action:  ShowArithmeticOnNumberTextField,
+			},
+		}...)
+	}
+
+	// Filtering replaces the selected text, so it is available only in visual mode.
+	if ctx.InputMode == state.InputModeVisual {
+		items = append(items, menu.Item{
+			Name:    "filter selection through shell command",
+			Aliases: []string{"filter"},
+			Action:  ShowFilterSelectionTextField,
+		})
+	}
+
+	// Alignment applies to the selected lines, so it is available only in visual mode.
+	if ctx.InputMode == state.InputModeVisual {
+		items = append(items, menu.Item{
+			Name:    "align selection",
+			Aliases: []string{"align"},
+			Action:  ShowAlignSelectionTextField,
+		})
+	}
+
+	// In visual mode, the selected text is the expression to calculate.
+	if ctx.InputMode == state.InputModeVisual {
+		items = append(items, menu.Item{
+			Name:    "calculate selection",
+			Aliases: []string{"calc"},
+			Action:  EvaluateSelection,
+		})
+	}
+
+	// Formatting applies to the selected text, so it is available only in visual mode.
+	if ctx.InputMode == state.InputModeVisual {
+		items = append(items, []menu.Item{
+			{
+				Name:    "format selection as JSON",
+				Aliases: []string{"json"},
+				Action:  FormatSelectionAsJSON,
+			},
+			{
+				Name:    "format selection as XML",
+				Aliases: []string{"xml"},
+				Action:  FormatSelectionAsXML,
+			},
+		}...)
+	}
+
+	// Find and replace in visual mode applies only to the selected text.
+	if ctx.InputMode == state.InputModeVisual {
+		items = append(items, []menu.Item{
+			{
+				Name:    "find and replace in selection",
+				Aliases: []string{"fr"},
+				Action:  ShowReplaceInSelectionTextField(false),
+			},
+			{
+				Name:    "find and replace in selection with confirmation",
+				Aliases: []string{"fri"},
+				Action:  ShowReplaceInSelectionTextField(true),
+			},
+		}...)
+	}
+
 	return items
 }
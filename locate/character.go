@@ -64,6 +64,41 @@ func PrevCharInLine(tree *text.Tree, count uint64, includeEndOfLineOrFile bool,
 	return pos - offset
 }
 
+// GraphemeClusterBoundary locates the closest grapheme cluster boundary at or after a position.
+// If the position is in the middle of a grapheme cluster, this returns the end of that cluster.
+// This can happen after inserting a rune that combines with the text after it
+// (for example, a Hangul leading consonant inserted before a vowel).
+func GraphemeClusterBoundary(tree *text.Tree, pos uint64) uint64 {
+	// Start from the beginning of the grapheme cluster before the position,
+	// since the forward iterator needs context to find cluster boundaries.
+	reverseIter := segment.NewReverseGraphemeClusterIter(tree.ReverseReaderAtPosition(pos))
+	seg := segment.Empty()
+	err := reverseIter.NextSegment(seg)
+	if err == io.EOF {
+		return pos
+	} else if err != nil {
+		panic(err)
+	}
+
+	startPos := pos - seg.NumRunes()
+	segmentIter := segment.NewGraphemeClusterIter(tree.ReaderAtPosition(startPos))
+	offset := startPos
+	for offset < pos {
+		err := segmentIter.NextSegment(seg)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+		offset += seg.NumRunes()
+	}
+
+	if offset < pos {
+		return pos
+	}
+	return offset
+}
+
 // PrevChar locates the grapheme cluster before a position, which may be on a previous line.
 func PrevChar(tree *text.Tree, count uint64, pos uint64) uint64 {
 	reader := tree.ReverseReaderAtPosition(pos)
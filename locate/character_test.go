@@ -292,6 +292,73 @@ func TestPrevChar(t *testing.T) {
 	}
 }
 
+func TestGraphemeClusterBoundary(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputString string
+		pos         uint64
+		expectedPos uint64
+	}{
+		{
+			name:        "empty",
+			inputString: "",
+			pos:         0,
+			expectedPos: 0,
+		},
+		{
+			name:        "start of document",
+			inputString: "abc",
+			pos:         0,
+			expectedPos: 0,
+		},
+		{
+			name:        "between ascii chars",
+			inputString: "abc",
+			pos:         2,
+			expectedPos: 2,
+		},
+		{
+			name:        "end of document",
+			inputString: "abc",
+			pos:         3,
+			expectedPos: 3,
+		},
+		{
+			name:        "between wide chars",
+			inputString: "日本語",
+			pos:         1,
+			expectedPos: 1,
+		},
+		{
+			name:        "middle of combining char sequence",
+			inputString: "e\u0301x",
+			pos:         1,
+			expectedPos: 2,
+		},
+		{
+			name:        "middle of hangul jamo sequence",
+			inputString: "\u1112\u1161\u11ab\u1100",
+			pos:         1,
+			expectedPos: 3,
+		},
+		{
+			name:        "after hangul syllable",
+			inputString: "한국",
+			pos:         1,
+			expectedPos: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			actualPos := GraphemeClusterBoundary(textTree, tc.pos)
+			assert.Equal(t, tc.expectedPos, actualPos)
+		})
+	}
+}
+
 func TestNextMatchingCharInLine(t *testing.T) {
 	testCases := []struct {
 		name        string
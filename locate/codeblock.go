@@ -1,6 +1,8 @@
 package locate
 
 import (
+	"math"
+
 	"github.com/aretext/aretext/syntax/parser"
 	"github.com/aretext/aretext/text"
 )
@@ -15,6 +17,9 @@ func (p DelimiterPair) MatchRune(r rune) bool {
 	return r == p.OpenRune || r == p.CloseRune
 }
 
+// noMaxDistance searches for a matching delimiter anywhere in the document.
+const noMaxDistance = math.MaxUint64
+
 var (
 	ParenPair   = DelimiterPair{OpenRune: '(', CloseRune: ')'}
 	BracketPair = DelimiterPair{OpenRune: '[', CloseRune: ']'}
@@ -24,6 +29,12 @@ var (
 
 // MatchingCodeBlockDelimiter locates the matching paren, brace, or bracket at a position, if it exists.
 func MatchingCodeBlockDelimiter(textTree *text.Tree, syntaxParser *parser.P, pos uint64) (uint64, bool) {
+	return MatchingCodeBlockDelimiterWithinDistance(textTree, syntaxParser, pos, noMaxDistance)
+}
+
+// MatchingCodeBlockDelimiterWithinDistance is like MatchingCodeBlockDelimiter, except it gives up if the match
+// is more than maxDistance characters away. This bounds the time to search a large document without a match.
+func MatchingCodeBlockDelimiterWithinDistance(textTree *text.Tree, syntaxParser *parser.P, pos uint64, maxDistance uint64) (uint64, bool) {
 	startToken := stringOrCommentTokenAtPos(syntaxParser, pos)
 	reader := textTree.ReaderAtPosition(pos)
 	r, _, err := reader.ReadRune()
@@ -33,21 +44,21 @@ func MatchingCodeBlockDelimiter(textTree *text.Tree, syntaxParser *parser.P, pos
 
 	switch r {
 	case ParenPair.OpenRune:
-		return searchForwardMatch(ParenPair, textTree, syntaxParser, startToken, pos)
+		return searchForwardMatch(ParenPair, textTree, syntaxParser, startToken, pos, maxDistance)
 	case ParenPair.CloseRune:
-		return searchBackwardMatch(ParenPair, textTree, syntaxParser, startToken, pos)
+		return searchBackwardMatch(ParenPair, textTree, syntaxParser, startToken, pos, maxDistance)
 	case BracePair.OpenRune:
-		return searchForwardMatch(BracePair, textTree, syntaxParser, startToken, pos)
+		return searchForwardMatch(BracePair, textTree, syntaxParser, startToken, pos, maxDistance)
 	case BracePair.CloseRune:
-		return searchBackwardMatch(BracePair, textTree, syntaxParser, startToken, pos)
+		return searchBackwardMatch(BracePair, textTree, syntaxParser, startToken, pos, maxDistance)
 	case BracketPair.OpenRune:
-		return searchForwardMatch(BracketPair, textTree, syntaxParser, startToken, pos)
+		return searchForwardMatch(BracketPair, textTree, syntaxParser, startToken, pos, maxDistance)
 	case BracketPair.CloseRune:
-		return searchBackwardMatch(BracketPair, textTree, syntaxParser, startToken, pos)
+		return searchBackwardMatch(BracketPair, textTree, syntaxParser, startToken, pos, maxDistance)
 	case AnglePair.OpenRune:
-		return searchForwardMatch(AnglePair, textTree, syntaxParser, startToken, pos)
+		return searchForwardMatch(AnglePair, textTree, syntaxParser, startToken, pos, maxDistance)
 	case AnglePair.CloseRune:
-		return searchBackwardMatch(AnglePair, textTree, syntaxParser, startToken, pos)
+		return searchBackwardMatch(AnglePair, textTree, syntaxParser, startToken, pos, maxDistance)
 	default:
 		return 0, false
 	}
@@ -56,10 +67,10 @@ func MatchingCodeBlockDelimiter(textTree *text.Tree, syntaxParser *parser.P, pos
 // PrevUnmatchedOpenDelimiter locates the previous unmatched open delimiter before a position.
 func PrevUnmatchedOpenDelimiter(delimiterPair DelimiterPair, textTree *text.Tree, syntaxParser *parser.P, pos uint64) (uint64, bool) {
 	startToken := stringOrCommentTokenAtPos(syntaxParser, pos)
-	matchPos, ok := searchBackwardMatch(delimiterPair, textTree, syntaxParser, startToken, pos)
+	matchPos, ok := searchBackwardMatch(delimiterPair, textTree, syntaxParser, startToken, pos, noMaxDistance)
 	if !ok && startToken.Role != parser.TokenRoleNone {
 		// If we can't find the delimiter in a comment/string, retry looking outside the comment/string.
-		matchPos, ok = searchBackwardMatch(delimiterPair, textTree, syntaxParser, parser.Token{}, pos)
+		matchPos, ok = searchBackwardMatch(delimiterPair, textTree, syntaxParser, parser.Token{}, pos, noMaxDistance)
 	}
 	return matchPos, ok
 }
@@ -67,10 +78,10 @@ func PrevUnmatchedOpenDelimiter(delimiterPair DelimiterPair, textTree *text.Tree
 // NextUnmatchedCloseDelimiter locates the next unmatched close delimiter after a position.
 func NextUnmatchedCloseDelimiter(delimiterPair DelimiterPair, textTree *text.Tree, syntaxParser *parser.P, pos uint64) (uint64, bool) {
 	startToken := stringOrCommentTokenAtPos(syntaxParser, pos)
-	matchPos, ok := searchForwardMatch(delimiterPair, textTree, syntaxParser, startToken, pos)
+	matchPos, ok := searchForwardMatch(delimiterPair, textTree, syntaxParser, startToken, pos, noMaxDistance)
 	if !ok && startToken.Role != parser.TokenRoleNone {
 		// If we can't find the delimiter in a comment/string, retry looking outside the comment/string.
-		matchPos, ok = searchForwardMatch(delimiterPair, textTree, syntaxParser, parser.Token{}, pos)
+		matchPos, ok = searchForwardMatch(delimiterPair, textTree, syntaxParser, parser.Token{}, pos, noMaxDistance)
 	}
 	return matchPos, ok
 }
@@ -98,14 +109,14 @@ func delimitedBlockMatchSyntaxToken(delimiterPair DelimiterPair, textTree *text.
 	startPos := pos // If we start on an open delimiter, use it.
 	if r != delimiterPair.OpenRune {
 		// Otherwise, search backwards to find the start delimiter.
-		startPos, ok = searchBackwardMatch(delimiterPair, textTree, syntaxParser, matchSyntaxToken, pos)
+		startPos, ok = searchBackwardMatch(delimiterPair, textTree, syntaxParser, matchSyntaxToken, pos, noMaxDistance)
 		if !ok {
 			return pos, pos
 		}
 	}
 
 	// Search forward from the start delimiter to find the matching end delimiter.
-	endPos, ok := searchForwardMatch(delimiterPair, textTree, syntaxParser, matchSyntaxToken, startPos)
+	endPos, ok := searchForwardMatch(delimiterPair, textTree, syntaxParser, matchSyntaxToken, startPos, noMaxDistance)
 	if !ok {
 		return pos, pos
 	}
@@ -129,7 +140,8 @@ func delimitedBlockMatchSyntaxToken(delimiterPair DelimiterPair, textTree *text.
 	return startPos, endPos
 }
 
-func searchForwardMatch(delimiterPair DelimiterPair, textTree *text.Tree, syntaxParser *parser.P, matchSyntaxToken parser.Token, pos uint64) (uint64, bool) {
+func searchForwardMatch(delimiterPair DelimiterPair, textTree *text.Tree, syntaxParser *parser.P, matchSyntaxToken parser.Token, pos uint64, maxDistance uint64) (uint64, bool) {
+	startPos := pos
 	pos++
 	depth := 1
 	reader := textTree.ReaderAtPosition(pos)
@@ -155,6 +167,10 @@ func searchForwardMatch(delimiterPair DelimiterPair, textTree *text.Tree, syntax
 
 		pos++
 
+		if pos-startPos > maxDistance {
+			return 0, false
+		}
+
 		if matchSyntaxToken.Role != parser.TokenRoleNone && pos > matchSyntaxToken.EndPos {
 			// If we're searching for a specific token, and we're past the end of that token, exit early.
 			return 0, false
@@ -162,7 +178,8 @@ func searchForwardMatch(delimiterPair DelimiterPair, textTree *text.Tree, syntax
 	}
 }
 
-func searchBackwardMatch(delimiterPair DelimiterPair, textTree *text.Tree, syntaxParser *parser.P, matchSyntaxToken parser.Token, pos uint64) (uint64, bool) {
+func searchBackwardMatch(delimiterPair DelimiterPair, textTree *text.Tree, syntaxParser *parser.P, matchSyntaxToken parser.Token, pos uint64, maxDistance uint64) (uint64, bool) {
+	startPos := pos
 	depth := 1
 	reader := textTree.ReverseReaderAtPosition(pos)
 	for {
@@ -187,6 +204,10 @@ func searchBackwardMatch(delimiterPair DelimiterPair, textTree *text.Tree, synta
 			return pos, true
 		}
 
+		if startPos-pos >= maxDistance {
+			return 0, false
+		}
+
 		if matchSyntaxToken.Role != parser.TokenRoleNone && pos < matchSyntaxToken.StartPos {
 			// If we're searching for a specific token, and we're before the beginning of that token, exit early.
 			return 0, false
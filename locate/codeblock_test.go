@@ -124,6 +124,29 @@ func TestMatchingCodeBlockDelimiter(t *testing.T) {
 			pos:            5,
 			expectMatch:    false,
 		},
+		{
+			name:           "match ignore brace in C string",
+			inputString:    `int f() { printf("}"); }`,
+			syntaxLanguage: syntax.LanguageC,
+			pos:            8,
+			expectMatch:    true,
+			expectPos:      23,
+		},
+		{
+			name:           "match ignore brace in Rust char literal",
+			inputString:    `fn f() { let c = '}'; }`,
+			syntaxLanguage: syntax.LanguageRust,
+			pos:            7,
+			expectMatch:    true,
+			expectPos:      22,
+		},
+		{
+			name:        "plaintext matches brace in quotes",
+			inputString: `{ printf("}") }`,
+			pos:         0,
+			expectMatch: true,
+			expectPos:   10,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -143,6 +166,22 @@ func TestMatchingCodeBlockDelimiter(t *testing.T) {
 	}
 }
 
+func TestMatchingCodeBlockDelimiterWithinDistance(t *testing.T) {
+	textTree, syntaxParser := textTreeAndSyntaxParser(t, "(abc)", syntax.LanguagePlaintext)
+
+	pos, ok := MatchingCodeBlockDelimiterWithinDistance(textTree, syntaxParser, 0, 4)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(4), pos)
+	pos, ok = MatchingCodeBlockDelimiterWithinDistance(textTree, syntaxParser, 4, 4)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), pos)
+
+	_, ok = MatchingCodeBlockDelimiterWithinDistance(textTree, syntaxParser, 0, 3)
+	assert.False(t, ok)
+	_, ok = MatchingCodeBlockDelimiterWithinDistance(textTree, syntaxParser, 4, 3)
+	assert.False(t, ok)
+}
+
 func TestNextUnmatchedCloseDelimiter(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -252,6 +291,15 @@ abc
 			expectMatch:    true,
 			expectPos:      15,
 		},
+		{
+			name:           "skip close brace in C string",
+			delimiterPair:  BracePair,
+			inputString:    `int f() { x; printf("}"); }`,
+			syntaxLanguage: syntax.LanguageC,
+			pos:            10,
+			expectMatch:    true,
+			expectPos:      26,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -375,6 +423,15 @@ abc
 			expectMatch:    true,
 			expectPos:      0,
 		},
+		{
+			name:           "skip open brace in C string",
+			delimiterPair:  BracePair,
+			inputString:    `int f() { printf("{"); x; }`,
+			syntaxLanguage: syntax.LanguageC,
+			pos:            23,
+			expectMatch:    true,
+			expectPos:      8,
+		},
 	}
 
 	for _, tc := range testCases {
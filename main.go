@@ -14,6 +14,9 @@ import (
 	"github.com/gdamore/tcell/v2"
 
 	"github.com/aretext/aretext/app"
+	"github.com/aretext/aretext/input"
+	"github.com/aretext/aretext/nondet"
+	"github.com/aretext/aretext/session"
 )
 
 // This variable is set automatically as part of the release process.
@@ -50,12 +53,20 @@ func init() {
 
 var line = flag.Int("line", 1, "line number to view after opening the document")
 var logpath = flag.String("log", "", "log to file")
+var logmaxsize = flag.Int64("logmaxsize", 10, "maximum size of the log file in megabytes before rotating, or zero to disable rotation")
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
 var editconfig = flag.Bool("editconfig", false, "open the aretext configuration file")
 var noconfig = flag.Bool("noconfig", false, "force default configuration")
+var dumpconfig = flag.Bool("dumpconfig", false, "print the effective configuration for the path, then exit")
+var noplugins = flag.Bool("noplugins", false, "disable plugins")
+var sessionName = flag.String("session", "", "share named clipboard pages and search history with other instances using the same session name")
+var pager = flag.Bool("pager", false, "view the document read-only like less, reading from stdin if no path is given")
+var profileStartup = flag.Bool("profile-startup", false, "log the duration of each startup phase, then print a summary on exit")
+var keys = flag.String("keys", "", "keys to process after opening the document, as if typed, with key names in angle brackets like <escape>")
 var versionFlag = flag.Bool("version", false, "print version")
 
 func main() {
+	startTime := nondet.Now()
 	flag.Usage = printUsage
 	flag.Parse()
 
@@ -66,7 +77,7 @@ func main() {
 
 	log.SetFlags(log.Ltime | log.Lmicroseconds | log.Lshortfile)
 	if *logpath != "" {
-		logFile, err := os.Create(*logpath)
+		logFile, err := app.NewRotatingLogFile(*logpath, *logmaxsize*1024*1024)
 		if err != nil {
 			exitWithError(err)
 		}
@@ -101,10 +112,49 @@ func main() {
 		path = configPath
 	}
 
-	err := runEditor(path, lineNum)
+	if *dumpconfig {
+		if err := dumpConfig(path); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	var pagerInput *app.PagerInput
+	if *pager {
+		if path == "" || path == "-" {
+			input, err := app.ReadPagerInput(os.Stdin)
+			if err != nil {
+				exitWithError(err)
+			}
+			pagerInput = input
+			path = ""
+		} else if err := app.CheckPagerPath(path); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	var editorSession *session.Session
+	if *sessionName != "" {
+		s, err := app.OpenSession(*sessionName)
+		if err != nil {
+			exitWithError(err)
+		}
+		editorSession = s
+	}
+
+	var startupProfile *app.StartupProfile
+	if *profileStartup {
+		startupProfile = app.NewStartupProfile(startTime)
+		startupProfile.Mark("flags and logging")
+	}
+
+	err := runEditor(path, lineNum, pagerInput, editorSession, startupProfile)
 	if err != nil {
 		exitWithError(err)
 	}
+
+	// Print after the screen is restored, so the summary remains visible in the terminal.
+	startupProfile.WriteSummary(os.Stderr)
 }
 
 func printUsage() {
@@ -113,7 +163,15 @@ func printUsage() {
 	flag.PrintDefaults()
 }
 
-func runEditor(path string, lineNum uint64) error {
+func dumpConfig(path string) error {
+	configRuleSet, err := app.LoadOrCreateConfig(*noconfig)
+	if err != nil {
+		return err
+	}
+	return app.DumpConfig(path, configRuleSet, os.Stdout)
+}
+
+func runEditor(path string, lineNum uint64, pagerInput *app.PagerInput, editorSession *session.Session, startupProfile *app.StartupProfile) error {
 	log.Printf("version: %s\n", version)
 	log.Printf("go version: %s\n", goVersion)
 	log.Printf("vcs.revision: %s\n", vcsRevision)
@@ -121,12 +179,33 @@ func runEditor(path string, lineNum uint64) error {
 	log.Printf("vcs.modified: %t\n", vcsModified)
 	log.Printf("path arg: %q\n", path)
 	log.Printf("lineNum: %d\n", lineNum)
+	log.Printf("pager: %t\n", *pager)
+	log.Printf("session: %q\n", *sessionName)
 	log.Printf("$TERM env var: %q\n", os.Getenv("TERM"))
 
+	startupKeys, err := input.ParseKeys(*keys)
+	if err != nil {
+		return fmt.Errorf("Invalid -keys flag: %w", err)
+	}
+
 	configRuleSet, err := app.LoadOrCreateConfig(*noconfig)
 	if err != nil {
 		return err
 	}
+	startupProfile.Mark("config load")
+
+	pluginRegistry, err := app.LoadPlugins(*noplugins)
+	if err != nil {
+		return err
+	}
+	startupProfile.Mark("plugin load")
+
+	if !*pager {
+		if err := app.CheckNewFilePath(path, configRuleSet, os.Stdin, os.Stdout); err != nil {
+			return err
+		}
+	}
+	startupProfile.Mark("new file check")
 
 	screen, err := tcell.NewScreen()
 	if err != nil {
@@ -139,8 +218,21 @@ func runEditor(path string, lineNum uint64) error {
 	defer screen.Fini()
 
 	screen.EnablePaste()
+	startupProfile.Mark("screen init")
 
-	editor := app.NewEditor(screen, path, uint64(lineNum), configRuleSet)
+	editor := app.NewEditor(screen, path, uint64(lineNum), configRuleSet, pluginRegistry, *logpath, startupProfile)
+	if !*noconfig {
+		editor.EnableConfigReload()
+	}
+	if *pager {
+		editor.EnablePagerMode(pagerInput, lineNum)
+	}
+	if editorSession != nil {
+		editor.JoinSession(editorSession)
+	}
+	if len(startupKeys) > 0 {
+		editor.SendStartupKeys(startupKeys)
+	}
 	editor.RunEventLoop()
 	return nil
 }
@@ -15,6 +15,7 @@ const maxSearchResults = 100
 // for hundreds of thousands of records.
 type Index struct {
 	records     []string
+	boosts      []float64
 	keywordTrie *trie
 }
 
@@ -30,7 +31,16 @@ func NewIndex(records []string) *Index {
 			keywordTrie.insert(keyword, recordId)
 		})
 	}
-	return &Index{records, keywordTrie}
+	return &Index{records: records, keywordTrie: keywordTrie}
+}
+
+// NewIndexWithBoosts constructs a new index where each record's score is increased by its boost.
+// This ranks records with higher boosts above records that match a query equally well.
+// The boosts slice must have the same length as the records slice.
+func NewIndexWithBoosts(records []string, boosts []float64) *Index {
+	idx := NewIndex(records)
+	idx.boosts = boosts
+	return idx
 }
 
 const unicodeNormalForm = norm.NFKC
@@ -80,9 +90,14 @@ func (idx *Index) rankRecords(query string, recordIds *recordIdSet) []int {
 		// We are NOT lowercasing the strings here
 		// so that case-sensitive matches will be ranked higher
 		// than case-insensitive matches.
+		var boost float64
+		if idx.boosts != nil {
+			boost = idx.boosts[recordId]
+		}
 		candidates = append(candidates, candidateRecord{
 			recordId: recordId,
 			record:   unicodeNormalForm.String(idx.records[recordId]),
+			boost:    boost,
 		})
 	})
 	return rankRecords(candidates, unicodeNormalForm.String(query), maxSearchResults)
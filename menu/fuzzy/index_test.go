@@ -147,3 +147,17 @@ func TestLeafNodeMinMaxRecordIdNotSet(t *testing.T) {
 	recordIds := index.Search("yyyyyyyy/allo")
 	require.Equal(t, []int{1}, recordIds)
 }
+
+func TestFuzzySearchIndexWithBoosts(t *testing.T) {
+	records := []string{"foo/main.go", "bar/main.go", "baz/main.go"}
+
+	idx := NewIndex(records)
+	assert.Equal(t, []int{1, 2, 0}, idx.Search("main"))
+
+	// A boosted record ranks above records that match equally well.
+	idx = NewIndexWithBoosts(records, []float64{0, 0, 1.0})
+	assert.Equal(t, []int{2, 1, 0}, idx.Search("main"))
+
+	// A boost does not add records that do not match the query.
+	assert.Equal(t, []int{0}, idx.Search("foo"))
+}
@@ -23,6 +23,7 @@ const (
 type candidateRecord struct {
 	recordId int
 	record   string
+	boost    float64 // added to the record's score after matching the query.
 }
 
 // rankRecords scores records against a query, then returns the IDs of the top records in descending order by score.
@@ -45,6 +46,7 @@ func scoreAllRecords(candidates []candidateRecord, query string) []scoredRecord
 	if numPartitions == 1 {
 		// If the number of records is small, avoid the overhead of starting a separate goroutine.
 		scoreRecordsPartition(scoredRecords, query)
+		addBoosts(scoredRecords, candidates)
 		return scoredRecords
 	}
 
@@ -66,9 +68,16 @@ func scoreAllRecords(candidates []candidateRecord, query string) []scoredRecord
 	}
 	wg.Wait()
 
+	addBoosts(scoredRecords, candidates)
 	return scoredRecords
 }
 
+func addBoosts(scoredRecords []scoredRecord, candidates []candidateRecord) {
+	for i := 0; i < len(scoredRecords); i++ {
+		scoredRecords[i].score += candidates[i].boost
+	}
+}
+
 func numPartitions(numRecords int) int {
 	n := numRecords / minRecordsPerPartition
 	if n < 1 {
@@ -181,7 +181,7 @@ func TestRankRecords(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			var candidates []candidateRecord
 			for recordId, record := range tc.records {
-				candidates = append(candidates, candidateRecord{recordId, record})
+				candidates = append(candidates, candidateRecord{recordId: recordId, record: record})
 			}
 			var result []string
 			for _, recordId := range rankRecords(candidates, tc.query, tc.limit) {
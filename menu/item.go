@@ -9,6 +9,18 @@ type Item struct {
 	// Aliases are a search terms for which this item will always rank first.
 	Aliases []string
 
+	// Boost increases the item's search score, so it ranks above items
+	// that match the query equally well. Zero means no boost.
+	Boost float64
+
+	// Pinned items appear first, in order, when the query is empty,
+	// even in a menu that otherwise shows no items until the user types a query.
+	Pinned bool
+
+	// Hidden items do not appear in search results, but a query matching one of
+	// the item's aliases still selects it.
+	Hidden bool
+
 	// Action is the action to perform when the user selects the menu item.
 	// This should be a function that accepts a single *EditorState arg.
 	Action any
@@ -15,58 +15,61 @@ const (
 type Search struct {
 	emptyQueryShowAll bool
 	fuzzyIndex        *fuzzy.Index
+	fuzzyItemIds      []int // Maps fuzzy index record IDs to item IDs, since hidden items are not indexed.
 	aliasIndex        map[string]int
 	items             []Item
 	results           []Item
 }
 
 func NewSearch(items []Item, emptyQueryShowAll bool) *Search {
-	itemNames := make([]string, len(items))
+	itemNames := make([]string, 0, len(items))
+	itemBoosts := make([]float64, 0, len(items))
+	fuzzyItemIds := make([]int, 0, len(items))
 	aliasIndex := make(map[string]int, 0)
 	for itemId, item := range items {
-		// Truncate long names to avoid perf issues when fuzzy searching.
-		itemNames[itemId] = truncateString(item.Name, maxSearchItemNameLen)
 		for _, alias := range item.Aliases {
 			aliasIndex[alias] = itemId
 		}
-	}
 
-	var results []Item
-	if emptyQueryShowAll {
-		results = append(results, items...)
+		if item.Hidden {
+			continue
+		}
+
+		// Truncate long names to avoid perf issues when fuzzy searching.
+		itemNames = append(itemNames, truncateString(item.Name, maxSearchItemNameLen))
+		itemBoosts = append(itemBoosts, item.Boost)
+		fuzzyItemIds = append(fuzzyItemIds, itemId)
 	}
 
-	return &Search{
+	s := &Search{
 		emptyQueryShowAll: emptyQueryShowAll,
-		fuzzyIndex:        fuzzy.NewIndex(itemNames),
+		fuzzyIndex:        fuzzy.NewIndexWithBoosts(itemNames, itemBoosts),
+		fuzzyItemIds:      fuzzyItemIds,
 		aliasIndex:        aliasIndex,
 		items:             items,
-		results:           results,
 	}
+	s.results = s.emptyQueryResults()
+	return s
 }
 
 // Execute searches for the given query.
 func (s *Search) Execute(q string) {
 	if len(q) == 0 {
-		if s.emptyQueryShowAll {
-			s.results = make([]Item, 0, len(s.items))
-			s.results = append(s.results, s.items...)
-		} else {
-			s.results = nil
-		}
+		s.results = s.emptyQueryResults()
 		return
 	}
 
 	// Truncate long queries to avoid perf issues when fuzzy searching.
 	truncatedQuery := truncateString(q, maxSearchQueryLen)
-	resultItemIds := s.fuzzyIndex.Search(truncatedQuery)
-	results := make([]Item, 0, len(resultItemIds)+1)
+	resultRecordIds := s.fuzzyIndex.Search(truncatedQuery)
+	results := make([]Item, 0, len(resultRecordIds)+1)
 	itemIdMatchingAlias := -1
 	if itemId, ok := s.aliasIndex[strings.ToLower(truncatedQuery)]; ok {
 		itemIdMatchingAlias = itemId
 		results = append(results, s.items[itemId])
 	}
-	for _, itemId := range resultItemIds {
+	for _, recordId := range resultRecordIds {
+		itemId := s.fuzzyItemIds[recordId]
 		if itemId != itemIdMatchingAlias {
 			results = append(results, s.items[itemId])
 		}
@@ -74,6 +77,28 @@ func (s *Search) Execute(q string) {
 	s.results = results
 }
 
+// emptyQueryResults returns the items to show before the user types a query:
+// pinned items first, followed by every other visible item if the menu shows all items for an empty query.
+func (s *Search) emptyQueryResults() []Item {
+	var results []Item
+	if s.emptyQueryShowAll {
+		results = make([]Item, 0, len(s.items))
+	}
+	for _, item := range s.items {
+		if item.Pinned && !item.Hidden {
+			results = append(results, item)
+		}
+	}
+	if s.emptyQueryShowAll {
+		for _, item := range s.items {
+			if !item.Pinned && !item.Hidden {
+				results = append(results, item)
+			}
+		}
+	}
+	return results
+}
+
 // Results returns the menu items matching the current query.
 // Items are sorted descending by relevance to the query,
 // with ties broken by lexicographic ordering.
@@ -122,6 +122,56 @@ func TestSearch(t *testing.T) {
 				{Name: "foo/first.txt"},
 			},
 		},
+		{
+			name:  "pinned items, empty query",
+			query: "",
+			items: []Item{
+				{Name: "a"},
+				{Name: "c", Pinned: true},
+				{Name: "b", Pinned: true},
+			},
+			expected: []Item{
+				{Name: "c", Pinned: true},
+				{Name: "b", Pinned: true},
+			},
+		},
+		{
+			name:              "pinned items, empty query with emptyQueryShowAll true",
+			query:             "",
+			emptyQueryShowAll: true,
+			items: []Item{
+				{Name: "a"},
+				{Name: "c", Pinned: true},
+				{Name: "b", Hidden: true},
+			},
+			expected: []Item{
+				{Name: "c", Pinned: true},
+				{Name: "a"},
+			},
+		},
+		{
+			name:  "hidden item excluded from results",
+			query: "foo",
+			items: []Item{
+				{Name: "foo", Hidden: true},
+				{Name: "foobar"},
+			},
+			expected: []Item{
+				{Name: "foobar"},
+			},
+		},
+		{
+			name:  "hidden item selected by alias",
+			query: "f",
+			items: []Item{
+				{Name: "foo", Aliases: []string{"f"}, Hidden: true},
+				{Name: "fab"},
+			},
+			expected: []Item{
+				{Name: "foo", Aliases: []string{"f"}, Hidden: true},
+				{Name: "fab"},
+			},
+		},
 		{
 			name:  "non-ascii unicode",
 			query: "𝓯𝓸𝓸",
@@ -0,0 +1,119 @@
+// Package nondet provides the current time and random identifiers.
+//
+// Features that depend on the clock or randomness should use this package instead of
+// calling time.Now or math/rand directly, so tests can substitute a deterministic source
+// and produce the same output on every run.
+package nondet
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Source generates timestamps and random numbers.
+type Source interface {
+	Now() time.Time
+	Uint64() uint64
+}
+
+var (
+	mu      sync.Mutex
+	current Source = systemSource{}
+)
+
+// Now returns the current time from the active source.
+func Now() time.Time {
+	mu.Lock()
+	defer mu.Unlock()
+	return current.Now()
+}
+
+// ID returns a random identifier from the active source, formatted as 16 hex digits.
+// This is suitable for naming temporary files and sessions, but not for cryptography.
+func ID() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return fmt.Sprintf("%016x", current.Uint64())
+}
+
+// maxCreateTempAttempts is how many names CreateTemp tries before giving up.
+const maxCreateTempAttempts = 10
+
+// CreateTemp creates a new file in dir named prefix followed by an ID from the active source,
+// opened for reading and writing. If dir is empty, it uses the default directory for temporary files.
+// Unlike os.CreateTemp, tests with a deterministic source create the same file names on every run.
+func CreateTemp(dir string, prefix string) (*os.File, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	for i := 0; ; i++ {
+		path := filepath.Join(dir, prefix+ID())
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			return f, nil
+		} else if !errors.Is(err, fs.ErrExist) || i+1 >= maxCreateTempAttempts {
+			return nil, fmt.Errorf("os.OpenFile: %w", err)
+		}
+	}
+}
+
+// SetSource replaces the active source and returns a function to restore the previous one.
+// This is intended for tests, which should defer the restore function.
+func SetSource(s Source) (restore func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	prev := current
+	current = s
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		current = prev
+	}
+}
+
+// systemSource uses the system clock and a randomly seeded generator.
+type systemSource struct{}
+
+func (systemSource) Now() time.Time {
+	return time.Now()
+}
+
+func (systemSource) Uint64() uint64 {
+	return rand.Uint64()
+}
+
+// DeterministicSource produces the same sequence of timestamps and random numbers every time.
+// The clock starts at a fixed time and advances by a fixed step on each call to Now.
+type DeterministicSource struct {
+	now  time.Time
+	step time.Duration
+	rng  *rand.Rand
+}
+
+// NewDeterministicSource constructs a source with a clock starting at start,
+// advancing by step on each call, and a random number generator seeded with seed.
+func NewDeterministicSource(start time.Time, step time.Duration, seed int64) *DeterministicSource {
+	return &DeterministicSource{
+		now:  start,
+		step: step,
+		rng:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Now returns the source's current time, then advances the clock.
+func (s *DeterministicSource) Now() time.Time {
+	t := s.now
+	s.now = s.now.Add(s.step)
+	return t
+}
+
+// Uint64 returns the next number from the seeded generator.
+func (s *DeterministicSource) Uint64() uint64 {
+	return s.rng.Uint64()
+}
@@ -0,0 +1,61 @@
+package nondet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterministicSource(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	generate := func() ([]time.Time, []string) {
+		restore := SetSource(NewDeterministicSource(start, time.Second, 42))
+		defer restore()
+
+		var times []time.Time
+		var ids []string
+		for i := 0; i < 3; i++ {
+			times = append(times, Now())
+			ids = append(ids, ID())
+		}
+		return times, ids
+	}
+
+	times, ids := generate()
+	assert.Equal(t, []time.Time{start, start.Add(time.Second), start.Add(2 * time.Second)}, times)
+	assert.Len(t, ids[0], 16)
+	assert.NotEqual(t, ids[0], ids[1])
+
+	// The same source produces the same output on every run.
+	repeatTimes, repeatIds := generate()
+	assert.Equal(t, times, repeatTimes)
+	assert.Equal(t, ids, repeatIds)
+}
+
+func TestSetSourceRestore(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	restore := SetSource(NewDeterministicSource(start, 0, 0))
+	assert.Equal(t, start, Now())
+	restore()
+	assert.NotEqual(t, start, Now())
+}
+
+func TestCreateTemp(t *testing.T) {
+	dir := t.TempDir()
+	restore := SetSource(NewDeterministicSource(time.Unix(1700000000, 0), 0, 0))
+	defer restore()
+
+	// Occupy the first name, so the file uses the next ID.
+	takenPath := filepath.Join(dir, "test-78fc2ffac2fd9401")
+	require.NoError(t, os.WriteFile(takenPath, nil, 0600))
+
+	f, err := CreateTemp(dir, "test-")
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, filepath.Join(dir, "test-1f5b0412ffd341c0"), f.Name())
+}
@@ -0,0 +1,76 @@
+// Package pluginapi defines the interface between aretext and plugins.
+//
+// A plugin is a Go shared library built with "go build -buildmode=plugin".
+// It must export a function named Init with the signature of InitFunc.
+// aretext calls Init once at startup, and the plugin uses the provided
+// Registry to add menu commands, key bindings, and pre-save filters.
+package pluginapi
+
+// InitSymbol is the name of the function that each plugin must export.
+const InitSymbol = "Init"
+
+// InitFunc is the signature of the function that each plugin must export.
+// If it returns an error, the plugin's registrations are discarded.
+type InitFunc func(Registry) error
+
+// Registry allows a plugin to register commands and key bindings.
+type Registry interface {
+	// RegisterMenuCommand adds a command to the command menu.
+	// If a plugin registers the same name more than once, the last action wins.
+	RegisterMenuCommand(name string, action Action)
+
+	// RegisterKeyBinding binds a key in normal mode.
+	// The key name uses the same format as tcell, for example "F5", "Ctrl+T", or "Alt+Rune[x]".
+	// Plugin key bindings take priority over built-in commands.
+	RegisterKeyBinding(key string, action Action)
+
+	// RegisterPreSaveFilter adds a filter that runs each time a document is saved.
+	// Filters run in the order they were registered, each receiving the output of the previous filter.
+	// If a plugin registers the same name more than once, the last filter replaces the earlier one.
+	RegisterPreSaveFilter(name string, filter PreSaveFilter)
+}
+
+// PreSaveFilter transforms the contents of a file before it is written to disk,
+// for example to strip secrets or normalize formatting.
+// It receives the absolute path of the file and the bytes that would be written,
+// after line endings are converted, and returns the bytes to write instead.
+// Returning an error vetoes the save: the file is left unchanged and the error is shown to the user.
+// The document in the editor is never modified by a filter.
+type PreSaveFilter func(path string, data []byte) ([]byte, error)
+
+// Action is a function that a plugin executes in response to a menu command or key binding.
+// All edits made by an action are grouped into a single undo entry.
+type Action func(Editor)
+
+// Editor provides access to the document and status bar while an action is running.
+// It must not be used after the action returns.
+type Editor interface {
+	// Path returns the path of the document.
+	Path() string
+
+	// Text returns the full text of the document.
+	Text() string
+
+	// NumChars returns the number of characters (runes) in the document.
+	NumChars() uint64
+
+	// CursorPosition returns the position of the cursor, in characters from the start of the document.
+	CursorPosition() uint64
+
+	// SetCursorPosition moves the cursor.
+	// Positions past the end of the document move the cursor to the end of the document.
+	SetCursorPosition(pos uint64)
+
+	// InsertText inserts text at the specified position.
+	InsertText(pos uint64, text string) error
+
+	// DeleteText deletes count characters starting at the specified position.
+	// It returns the deleted text.
+	DeleteText(pos uint64, count uint64) (string, error)
+
+	// SetStatus displays a message in the status bar.
+	SetStatus(msg string)
+
+	// SetError displays an error message in the status bar.
+	SetError(msg string)
+}
@@ -0,0 +1,22 @@
+package prettyprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// JSON reformats a single JSON value, preserving the order of object keys.
+// Whitespace before and after the value is removed.
+func JSON(src string, prefix string, indent string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(src), prefix, indent); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return "", newSyntaxError(src, syntaxErr.Offset, syntaxErr.Error())
+		}
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), " \t\r\n"), nil
+}
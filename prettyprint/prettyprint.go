@@ -0,0 +1,37 @@
+// Package prettyprint reformats structured text, such as JSON and XML, with consistent indentation.
+//
+// Each function takes a prefix and an indent. Every output line after the first begins with the prefix,
+// followed by one copy of the indent for each level of nesting. The first line has no prefix, so the
+// output can replace text that starts in the middle of an indented line.
+package prettyprint
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError describes invalid input.
+// The line and column are relative to the start of the input and start from one.
+// Columns count characters, not bytes.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// newSyntaxError constructs a SyntaxError for an error found after reading offset bytes of the input.
+// The error position is the last character read.
+func newSyntaxError(src string, offset int64, msg string) *SyntaxError {
+	pos := int(max(min(offset, int64(len(src)))-1, 0))
+	lineStart := strings.LastIndexByte(src[:pos], '\n') + 1
+	return &SyntaxError{
+		Line:   strings.Count(src[:pos], "\n") + 1,
+		Column: utf8.RuneCountInString(src[lineStart:pos]) + 1,
+		Msg:    msg,
+	}
+}
@@ -0,0 +1,156 @@
+package prettyprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		src      string
+		prefix   string
+		indent   string
+		expected string
+	}{
+		{
+			name:     "scalar",
+			src:      " 42\n",
+			indent:   "  ",
+			expected: "42",
+		},
+		{
+			name:     "nested object and array",
+			src:      `{"b": [1, 2], "a": {}, "c": {"d": null}}`,
+			indent:   "  ",
+			expected: "{\n  \"b\": [\n    1,\n    2\n  ],\n  \"a\": {},\n  \"c\": {\n    \"d\": null\n  }\n}",
+		},
+		{
+			name:     "prefix and tab indent",
+			src:      "{\n\"a\":1}\n\n",
+			prefix:   "    ",
+			indent:   "\t",
+			expected: "{\n    \t\"a\": 1\n    }",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := JSON(tc.src, tc.prefix, tc.indent)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestJSONSyntaxError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		src      string
+		expected SyntaxError
+	}{
+		{
+			name:     "invalid character",
+			src:      "{\n  \"a\": 1,\n  \"b\": x\n}",
+			expected: SyntaxError{Line: 3, Column: 8, Msg: "invalid character 'x' looking for beginning of value"},
+		},
+		{
+			name:     "unexpected end of input",
+			src:      "[1, 2",
+			expected: SyntaxError{Line: 1, Column: 5, Msg: "unexpected end of JSON input"},
+		},
+		{
+			name:     "column counts characters",
+			src:      `["ü", ]`,
+			expected: SyntaxError{Line: 1, Column: 7, Msg: "invalid character ']' looking for beginning of value"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := JSON(tc.src, "", "  ")
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tc.expected, *syntaxErr)
+		})
+	}
+}
+
+func TestXML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		src      string
+		prefix   string
+		indent   string
+		expected string
+	}{
+		{
+			name:     "nested elements",
+			src:      `<a><b x="1">text</b><c/><d> </d></a>`,
+			indent:   "  ",
+			expected: "<a>\n  <b x=\"1\">text</b>\n  <c/>\n  <d/>\n</a>",
+		},
+		{
+			name:     "declaration, comment, and namespace prefix",
+			src:      "<?xml version=\"1.0\"?>\n<!-- note -->\n<ns:a xmlns:ns=\"urn:x\">\n\n<ns:b>1 &amp; 2</ns:b></ns:a>\n",
+			indent:   "\t",
+			expected: "<?xml version=\"1.0\"?>\n<!-- note -->\n<ns:a xmlns:ns=\"urn:x\">\n\t<ns:b>1 &amp; 2</ns:b>\n</ns:a>",
+		},
+		{
+			name:     "mixed content",
+			src:      "<p>Hello <b>world</b>!</p>",
+			indent:   "  ",
+			expected: "<p>\n  Hello\n  <b>world</b>\n  !\n</p>",
+		},
+		{
+			name:     "fragment with prefix",
+			src:      "<a>1</a><b><c/></b>",
+			prefix:   "  ",
+			indent:   "  ",
+			expected: "<a>1</a>\n  <b>\n    <c/>\n  </b>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := XML(tc.src, tc.prefix, tc.indent)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestXMLSyntaxError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		src      string
+		expected SyntaxError
+	}{
+		{
+			name:     "mismatched end element",
+			src:      "<a>\n  <b></c>\n</a>",
+			expected: SyntaxError{Line: 2, Column: 9, Msg: "element <b> closed by </c>"},
+		},
+		{
+			name:     "unclosed element",
+			src:      "<a>\n<b/>",
+			expected: SyntaxError{Line: 2, Column: 4, Msg: "unclosed element <a>"},
+		},
+		{
+			name:     "invalid syntax",
+			src:      "<a>\n<b =\"1\"/></a>",
+			expected: SyntaxError{Line: 2, Column: 3, Msg: "expected attribute name in element"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := XML(tc.src, "", "  ")
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tc.expected, *syntaxErr)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package prettyprint
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// XML reformats an XML document or fragment, placing each element, comment, and
+// processing instruction on its own line. Elements containing only text stay on one line.
+// Whitespace between elements is removed, as is whitespace around text.
+func XML(src string, prefix string, indent string) (string, error) {
+	tokens, err := xmlTokens(src)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var depth int
+	writeLine := func(s string) {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(prefix)
+			sb.WriteString(strings.Repeat(indent, depth))
+		}
+		sb.WriteString(s)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tok := tokens[i].(type) {
+		case xml.StartElement:
+			next, nextIdx := nextXMLToken(tokens, i+1)
+			if isXMLEndElement(next) {
+				// Element with no content.
+				writeLine(xmlStartTag(tok, true))
+				i = nextIdx
+				continue
+			}
+			if text, ok := next.(xml.CharData); ok {
+				if end, endIdx := nextXMLToken(tokens, nextIdx+1); isXMLEndElement(end) {
+					// Element with only text content.
+					writeLine(xmlStartTag(tok, false) + xmlTextEscaper.Replace(strings.TrimSpace(string(text))) + xmlEndTag(tok.Name))
+					i = endIdx
+					continue
+				}
+			}
+			writeLine(xmlStartTag(tok, false))
+			depth++
+		case xml.EndElement:
+			depth--
+			writeLine(xmlEndTag(tok.Name))
+		case xml.CharData:
+			if text := strings.TrimSpace(string(tok)); text != "" {
+				writeLine(xmlTextEscaper.Replace(text))
+			}
+		case xml.Comment:
+			writeLine("<!--" + string(tok) + "-->")
+		case xml.ProcInst:
+			if len(tok.Inst) > 0 {
+				writeLine(fmt.Sprintf("<?%s %s?>", tok.Target, tok.Inst))
+			} else {
+				writeLine(fmt.Sprintf("<?%s?>", tok.Target))
+			}
+		case xml.Directive:
+			writeLine("<!" + string(tok) + ">")
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// xmlTokens reads every token from the input, checking that start and end elements match.
+// Namespace prefixes are kept as written.
+func xmlTokens(src string) ([]xml.Token, error) {
+	decoder := xml.NewDecoder(strings.NewReader(src))
+	var tokens []xml.Token
+	var openElements []xml.Name
+	for {
+		tok, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return nil, newSyntaxError(src, decoder.InputOffset(), syntaxErr.Msg)
+			}
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			openElements = append(openElements, tok.Name)
+		case xml.EndElement:
+			if len(openElements) == 0 {
+				msg := fmt.Sprintf("unexpected end element </%s>", xmlName(tok.Name))
+				return nil, newSyntaxError(src, decoder.InputOffset(), msg)
+			}
+			if name := openElements[len(openElements)-1]; name != tok.Name {
+				msg := fmt.Sprintf("element <%s> closed by </%s>", xmlName(name), xmlName(tok.Name))
+				return nil, newSyntaxError(src, decoder.InputOffset(), msg)
+			}
+			openElements = openElements[:len(openElements)-1]
+		}
+
+		tokens = append(tokens, xml.CopyToken(tok))
+	}
+
+	if len(openElements) > 0 {
+		msg := fmt.Sprintf("unclosed element <%s>", xmlName(openElements[len(openElements)-1]))
+		return nil, newSyntaxError(src, int64(len(src)), msg)
+	}
+
+	return tokens, nil
+}
+
+// nextXMLToken returns the token at or after index i, skipping whitespace, and its index.
+// If there are no more tokens, it returns nil.
+func nextXMLToken(tokens []xml.Token, i int) (xml.Token, int) {
+	for ; i < len(tokens); i++ {
+		if text, ok := tokens[i].(xml.CharData); ok && strings.TrimSpace(string(text)) == "" {
+			continue
+		}
+		return tokens[i], i
+	}
+	return nil, i
+}
+
+func isXMLEndElement(tok xml.Token) bool {
+	_, ok := tok.(xml.EndElement)
+	return ok
+}
+
+func xmlStartTag(tok xml.StartElement, selfClosing bool) string {
+	var sb strings.Builder
+	sb.WriteString("<")
+	sb.WriteString(xmlName(tok.Name))
+	for _, attr := range tok.Attr {
+		fmt.Fprintf(&sb, ` %s="%s"`, xmlName(attr.Name), xmlAttrEscaper.Replace(attr.Value))
+	}
+	if selfClosing {
+		sb.WriteString("/")
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+func xmlEndTag(name xml.Name) string {
+	return "</" + xmlName(name) + ">"
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+var xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\n", "&#xA;", "\t", "&#x9;")
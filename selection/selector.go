@@ -12,6 +12,10 @@ const (
 	ModeNone = Mode(iota)
 	ModeChar
 	ModeLine
+
+	// ModeCharExclusive selects characters from the anchor up to, but not including, the cursor.
+	// This is used for selecting text in insert mode, where the cursor is between characters.
+	ModeCharExclusive
 )
 
 // Selector tracks the selected region of the document.
@@ -37,6 +41,11 @@ func (s *Selector) Mode() Mode {
 	return s.mode
 }
 
+// AnchorPos returns the position where the selection started.
+func (s *Selector) AnchorPos() uint64 {
+	return s.anchorPos
+}
+
 // SetMode sets the selection mode.
 func (s *Selector) SetMode(mode Mode) {
 	s.mode = mode
@@ -52,6 +61,8 @@ func (s *Selector) Region(tree *text.Tree, cursorPos uint64) Region {
 		return s.selectedCharsRegion(tree, cursorPos)
 	case ModeLine:
 		return s.selectedLinesRegion(tree, cursorPos)
+	case ModeCharExclusive:
+		return s.selectedCharsExclusiveRegion(tree, cursorPos)
 	default:
 		panic("Unrecognized mode")
 	}
@@ -67,6 +78,16 @@ func (s *Selector) selectedCharsRegion(tree *text.Tree, cursorPos uint64) Region
 	return r.Clip(tree.NumChars())
 }
 
+func (s *Selector) selectedCharsExclusiveRegion(tree *text.Tree, cursorPos uint64) Region {
+	var r Region
+	if cursorPos < s.anchorPos {
+		r.StartPos, r.EndPos = cursorPos, s.anchorPos
+	} else {
+		r.StartPos, r.EndPos = s.anchorPos, cursorPos
+	}
+	return r.Clip(tree.NumChars())
+}
+
 func (s *Selector) selectedLinesRegion(tree *text.Tree, cursorPos uint64) Region {
 	minPos, maxPos := cursorPos, s.anchorPos
 	if minPos > maxPos {
@@ -37,6 +37,39 @@ func TestSelector(t *testing.T) {
 				EndPos:   4,
 			},
 		},
+		{
+			name:             "charwise exclusive, no cursor movement",
+			inputString:      "abcdefghijklmnop",
+			mode:             ModeCharExclusive,
+			initialCursorPos: 3,
+			finalCursorPos:   3,
+			expectedRegion: Region{
+				StartPos: 3,
+				EndPos:   3,
+			},
+		},
+		{
+			name:             "charwise exclusive, cursor movement forward",
+			inputString:      "abcdefghijklmnop",
+			mode:             ModeCharExclusive,
+			initialCursorPos: 3,
+			finalCursorPos:   6,
+			expectedRegion: Region{
+				StartPos: 3,
+				EndPos:   6,
+			},
+		},
+		{
+			name:             "charwise exclusive, cursor movement backward",
+			inputString:      "abcdefghijklmnop",
+			mode:             ModeCharExclusive,
+			initialCursorPos: 6,
+			finalCursorPos:   3,
+			expectedRegion: Region{
+				StartPos: 3,
+				EndPos:   6,
+			},
+		},
 		{
 			name:             "charwise, cursor movement forward",
 			inputString:      "abcdefghijklmnop",
@@ -0,0 +1,219 @@
+// Package session shares state between concurrent editor instances.
+//
+// A session is stored as a JSON file. Every update locks the session,
+// reads the latest contents, applies the change, and atomically replaces the file,
+// so instances never overwrite each other's changes.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/nondet"
+)
+
+// maxSearchHistory is the maximum number of search queries stored in a session.
+const maxSearchHistory = 100
+
+const (
+	lockRetryInterval = 10 * time.Millisecond
+	lockTimeout       = time.Second
+
+	// If a lock file is older than this, assume the instance that created it crashed.
+	staleLockAge = 10 * time.Second
+)
+
+var validNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Session is a named set of clipboard pages and search history shared by editor instances.
+type Session struct {
+	path     string
+	lockPath string
+}
+
+// sessionData is the contents of a session file.
+type sessionData struct {
+	Pages         map[string]pageData `json:"pages,omitempty"`
+	SearchHistory []string            `json:"searchHistory,omitempty"`
+}
+
+type pageData struct {
+	Text     string `json:"text"`
+	Linewise bool   `json:"linewise,omitempty"`
+}
+
+// Dir returns the directory containing session files.
+func Dir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("Could not retrieve user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "aretext", "sessions"), nil
+}
+
+// Open opens the session with the given name in a directory, creating the directory if necessary.
+// The name may contain only letters, digits, underscores, and hyphens.
+func Open(dir string, name string) (*Session, error) {
+	if !validNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("Invalid session name %q: use only letters, digits, underscores, and hyphens", name)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	return &Session{
+		path:     filepath.Join(dir, name+".json"),
+		lockPath: filepath.Join(dir, name+".lock"),
+	}, nil
+}
+
+// IsSharedPage returns whether a clipboard page is shared by the session.
+// Only the named pages "a" through "z" are shared.
+func IsSharedPage(p clipboard.PageId) bool {
+	return p >= clipboard.PageLetterA && p <= clipboard.PageLetterZ
+}
+
+// LoadPage returns the latest content of a shared clipboard page.
+// If no instance has stored content in the page, this returns false.
+func (s *Session) LoadPage(p clipboard.PageId) (clipboard.PageContent, bool, error) {
+	if !IsSharedPage(p) {
+		return clipboard.PageContent{}, false, nil
+	}
+
+	data, err := s.read()
+	if err != nil {
+		return clipboard.PageContent{}, false, err
+	}
+
+	pd, ok := data.Pages[pageName(p)]
+	if !ok {
+		return clipboard.PageContent{}, false, nil
+	}
+	return clipboard.PageContent{Text: pd.Text, Linewise: pd.Linewise}, true, nil
+}
+
+// StorePage stores content in a shared clipboard page.
+// Pages that are not shared are ignored.
+func (s *Session) StorePage(p clipboard.PageId, pc clipboard.PageContent) error {
+	if !IsSharedPage(p) {
+		return nil
+	}
+
+	return s.update(func(data *sessionData) {
+		if data.Pages == nil {
+			data.Pages = make(map[string]pageData, 1)
+		}
+		data.Pages[pageName(p)] = pageData{Text: pc.Text, Linewise: pc.Linewise}
+	})
+}
+
+// SearchHistory returns the search queries in the session, from oldest to newest.
+func (s *Session) SearchHistory() ([]string, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return data.SearchHistory, nil
+}
+
+// AddSearchQuery appends a query to the session's search history.
+// If the query is already the most recent, the history is unchanged.
+func (s *Session) AddSearchQuery(query string) error {
+	return s.update(func(data *sessionData) {
+		n := len(data.SearchHistory)
+		if n > 0 && data.SearchHistory[n-1] == query {
+			return
+		}
+		data.SearchHistory = append(data.SearchHistory, query)
+		if len(data.SearchHistory) > maxSearchHistory {
+			data.SearchHistory = data.SearchHistory[len(data.SearchHistory)-maxSearchHistory:]
+		}
+	})
+}
+
+func pageName(p clipboard.PageId) string {
+	return string(rune('a' + p - clipboard.PageLetterA))
+}
+
+// read loads the session file.
+// Readers do not need the lock, because writers atomically replace the file.
+func (s *Session) read() (sessionData, error) {
+	var data sessionData
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return data, nil
+	} else if err != nil {
+		return data, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &data); err != nil {
+		return data, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return data, nil
+}
+
+// update applies a change to the latest session data while holding the lock.
+func (s *Session) update(f func(*sessionData)) error {
+	if err := s.lock(); err != nil {
+		return err
+	}
+	defer s.unlock()
+
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	f(&data)
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0600); err != nil {
+		return fmt.Errorf("os.WriteFile: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return nil
+}
+
+// lock acquires the session lock by exclusively creating the lock file.
+func (s *Session) lock() error {
+	deadline := nondet.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(s.lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			return f.Close()
+		} else if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("os.OpenFile: %w", err)
+		}
+
+		if info, err := os.Stat(s.lockPath); err == nil && nondet.Now().Sub(info.ModTime()) > staleLockAge {
+			os.Remove(s.lockPath)
+			continue
+		}
+
+		if nondet.Now().After(deadline) {
+			return fmt.Errorf("Timed out waiting for session lock %s", s.lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func (s *Session) unlock() {
+	os.Remove(s.lockPath)
+}
@@ -0,0 +1,159 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/clipboard"
+)
+
+func TestOpenInvalidName(t *testing.T) {
+	testCases := []string{"", "foo/bar", "..", "foo bar"}
+	for _, name := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Open(t.TempDir(), name)
+			assert.ErrorContains(t, err, "Invalid session name")
+		})
+	}
+}
+
+func TestSharedPages(t *testing.T) {
+	dir := t.TempDir()
+	s1, err := Open(dir, "test")
+	require.NoError(t, err)
+	s2, err := Open(dir, "test")
+	require.NoError(t, err)
+
+	_, ok, err := s2.LoadPage(clipboard.PageLetterA)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	pc := clipboard.PageContent{Text: "foo\n", Linewise: true}
+	err = s1.StorePage(clipboard.PageLetterA, pc)
+	require.NoError(t, err)
+
+	loaded, ok, err := s2.LoadPage(clipboard.PageLetterA)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, pc, loaded)
+
+	// Other pages are unaffected.
+	_, ok, err = s2.LoadPage(clipboard.PageLetterB)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestUnsharedPagesIgnored(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, "test")
+	require.NoError(t, err)
+
+	err = s.StorePage(clipboard.PageDefault, clipboard.PageContent{Text: "foo"})
+	require.NoError(t, err)
+
+	_, ok, err := s.LoadPage(clipboard.PageDefault)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = os.Stat(filepath.Join(dir, "test.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSessionsWithDifferentNames(t *testing.T) {
+	dir := t.TempDir()
+	s1, err := Open(dir, "foo")
+	require.NoError(t, err)
+	s2, err := Open(dir, "bar")
+	require.NoError(t, err)
+
+	err = s1.StorePage(clipboard.PageLetterA, clipboard.PageContent{Text: "foo"})
+	require.NoError(t, err)
+
+	_, ok, err := s2.LoadPage(clipboard.PageLetterA)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSearchHistory(t *testing.T) {
+	dir := t.TempDir()
+	s1, err := Open(dir, "test")
+	require.NoError(t, err)
+	s2, err := Open(dir, "test")
+	require.NoError(t, err)
+
+	require.NoError(t, s1.AddSearchQuery("foo"))
+	require.NoError(t, s2.AddSearchQuery("bar"))
+	require.NoError(t, s2.AddSearchQuery("bar"))
+	require.NoError(t, s1.AddSearchQuery("baz"))
+
+	history, err := s2.SearchHistory()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar", "baz"}, history)
+}
+
+func TestSearchHistoryLimit(t *testing.T) {
+	s, err := Open(t.TempDir(), "test")
+	require.NoError(t, err)
+
+	for i := 0; i < maxSearchHistory+5; i++ {
+		require.NoError(t, s.AddSearchQuery(fmt.Sprintf("q%d", i)))
+	}
+
+	history, err := s.SearchHistory()
+	require.NoError(t, err)
+	assert.Equal(t, maxSearchHistory, len(history))
+	assert.Equal(t, "q5", history[0])
+	assert.Equal(t, fmt.Sprintf("q%d", maxSearchHistory+4), history[len(history)-1])
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	dir := t.TempDir()
+
+	const numInstances = 4
+	const numQueries = 10
+	var wg sync.WaitGroup
+	for i := 0; i < numInstances; i++ {
+		s, err := Open(dir, "test")
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < numQueries; j++ {
+				assert.NoError(t, s.AddSearchQuery(fmt.Sprintf("%d-%d", i, j)))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The lock prevents instances from overwriting each other's updates.
+	s, err := Open(dir, "test")
+	require.NoError(t, err)
+	history, err := s.SearchHistory()
+	require.NoError(t, err)
+	assert.Equal(t, numInstances*numQueries, len(history))
+}
+
+func TestStaleLockRemoved(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, "test")
+	require.NoError(t, err)
+
+	// Simulate a lock left behind by an instance that crashed.
+	lockPath := filepath.Join(dir, "test.lock")
+	require.NoError(t, os.WriteFile(lockPath, nil, 0600))
+	staleTime := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(lockPath, staleTime, staleTime))
+
+	require.NoError(t, s.AddSearchQuery("foo"))
+	history, err := s.SearchHistory()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, history)
+}
@@ -8,6 +8,8 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -39,8 +41,31 @@ func RunAndCaptureOutput(ctx context.Context, cmd string, env []string) (string,
 	return buf.String(), nil
 }
 
+// RunFilter runs the command with the input text as its stdin and returns its stdout.
+// If the output is not valid UTF-8 text, this returns an error.
+func RunFilter(ctx context.Context, cmd string, env []string, input string) (string, error) {
+	var buf bytes.Buffer
+	stdin, stdout, stderr := strings.NewReader(input), &buf, io.Writer(nil)
+	err := runInShell(ctx, cmd, env, stdin, stdout, stderr)
+	if err != nil {
+		return "", err
+	}
+
+	if !utf8.Valid(buf.Bytes()) {
+		return "", fmt.Errorf("Shell command output is not valid UTF-8")
+	}
+
+	return buf.String(), nil
+}
+
+// RunWithOutputWriter runs the command and writes its stdout and stderr to w as the output arrives.
+// The output is not validated, so the caller must handle invalid UTF-8.
+func RunWithOutputWriter(ctx context.Context, cmd string, env []string, w io.Writer) error {
+	return runInShell(ctx, cmd, env, nil, w, w)
+}
+
 func clearTerminal(ctx context.Context) {
-	clearCmd := exec.CommandContext(ctx, "clear")
+	clearCmd := clearTerminalCmd(ctx)
 	clearCmd.Stdout = os.Stdout
 	clearCmd.Stderr = os.Stderr
 	if err := clearCmd.Run(); err != nil {
@@ -49,7 +74,8 @@ func clearTerminal(ctx context.Context) {
 }
 
 func runInShell(ctx context.Context, shellCmd string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
-	cmd := exec.CommandContext(ctx, shellProg(), "-c", shellCmd)
+	prog := shellProg()
+	cmd := exec.CommandContext(ctx, prog, shellCmdFlag(prog), shellCmd)
 	cmd.Env = env
 	cmd.Stdin = stdin
 	cmd.Stdout = stdout
@@ -61,16 +87,19 @@ func runInShell(ctx context.Context, shellCmd string, env []string, stdin io.Rea
 	return nil
 }
 
-const defaultShell = "sh"
-
 func shellProg() string {
 	if s := os.Getenv("ARETEXT_SHELL"); s != "" {
 		return s
 	}
+	return defaultShellProg()
+}
 
-	if s := os.Getenv("SHELL"); s != "" {
-		return s
+// shellCmdFlag returns the flag that tells the shell program to run a command string.
+// Windows cmd.exe uses "/C", and other shells (including PowerShell) accept "-c".
+func shellCmdFlag(prog string) string {
+	name := strings.ToLower(filepath.Base(prog))
+	if name == "cmd" || name == "cmd.exe" {
+		return "/C"
 	}
-
-	return defaultShell
+	return "-c"
 }
@@ -0,0 +1,27 @@
+package shellcmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellCmdFlag(t *testing.T) {
+	testCases := []struct {
+		prog     string
+		expected string
+	}{
+		{prog: "sh", expected: "-c"},
+		{prog: "/bin/bash", expected: "-c"},
+		{prog: "/usr/bin/cmdsh", expected: "-c"},
+		{prog: "cmd", expected: "/C"},
+		{prog: "cmd.exe", expected: "/C"},
+		{prog: "CMD.EXE", expected: "/C"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.prog, func(t *testing.T) {
+			assert.Equal(t, tc.expected, shellCmdFlag(tc.prog))
+		})
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package shellcmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// defaultShellProg returns the user's login shell, or "sh" if $SHELL is not set.
+func defaultShellProg() string {
+	if s := os.Getenv("SHELL"); s != "" {
+		return s
+	}
+	return "sh"
+}
+
+func clearTerminalCmd(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(ctx, "clear")
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package shellcmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// defaultShellProg returns the command interpreter in %ComSpec%, or "cmd.exe" if it is not set.
+// This ignores $SHELL, because shells like Git Bash set it to a unix-style path that Windows programs cannot run.
+func defaultShellProg() string {
+	if s := os.Getenv("ComSpec"); s != "" {
+		return s
+	}
+	return "cmd.exe"
+}
+
+func clearTerminalCmd(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd.exe", "/C", "cls")
+}
@@ -0,0 +1,179 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/locate"
+)
+
+// AlignLines pads the lines from startLineNum to endLineNum (inclusive) with spaces
+// so that every occurrence of the delimiter lines up in the same column.
+// Each line keeps its original indentation, and tabs are expanded to the configured
+// tab size when measuring columns. Lines that do not contain the delimiter are unchanged.
+func AlignLines(state *EditorState, startLineNum uint64, endLineNum uint64, delimiter string) error {
+	if strings.TrimSpace(delimiter) == "" {
+		return errors.New("Alignment delimiter cannot be empty")
+	}
+
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return err
+	}
+
+	if startLineNum > endLineNum {
+		startLineNum, endLineNum = endLineNum, startLineNum
+	}
+	endLineNum = locate.ClosestValidLineNum(buffer.textTree, endLineNum)
+
+	lines := make([]string, 0, endLineNum-startLineNum+1)
+	for lineNum := startLineNum; lineNum <= endLineNum; lineNum++ {
+		lines = append(lines, lineTextForAlign(buffer, lineNum))
+	}
+
+	alignedLines := alignLinesOnDelimiter(lines, delimiter, buffer.tabSize)
+
+	var numChanged int
+	BeginUndoEntry(state)
+	for i, alignedLine := range alignedLines {
+		if alignedLine == lines[i] {
+			continue
+		}
+		lineNum := startLineNum + uint64(i)
+		startPos := locate.StartOfLineNum(buffer.textTree, lineNum)
+		numRunes := uint64(utf8.RuneCountInString(lines[i]))
+		if _, err := replaceRunes(state, startPos, numRunes, alignedLine, true); err != nil {
+			log.Printf("Error inserting aligned line: %v\n", err)
+			break
+		}
+		numChanged++
+	}
+	CommitUndoEntry(state)
+
+	startOfFirstLinePos := locate.StartOfLineNum(buffer.textTree, startLineNum)
+	buffer.cursor = cursorState{
+		position: locate.NextNonWhitespaceOrNewline(buffer.textTree, startOfFirstLinePos),
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Aligned %d line(s) on %q", numChanged, delimiter),
+	})
+	return nil
+}
+
+func lineTextForAlign(buffer *BufferState, lineNum uint64) string {
+	startPos := locate.StartOfLineNum(buffer.textTree, lineNum)
+	endPos := locate.NextLineBoundary(buffer.textTree, true, startPos)
+	var sb strings.Builder
+	reader := buffer.textTree.ReaderAtPosition(startPos)
+	for pos := startPos; pos < endPos; pos++ {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// alignLinesOnDelimiter aligns every occurrence of the delimiter in the lines.
+// Each line is split into fields at the delimiter, then field i of every line is padded
+// to the width of the widest field i among lines that have a delimiter after that field.
+//
+// If any line has whitespace before the n-th delimiter, the padding goes before the delimiter
+// (for example, "x = 1"). Otherwise, the padding goes after the delimiter (for example, "x: 1").
+func alignLinesOnDelimiter(lines []string, delimiter string, tabSize uint64) []string {
+	fieldsByLine := make([][]string, len(lines))
+	var maxDelimiters int
+	for i, line := range lines {
+		fields := strings.Split(line, delimiter)
+		fieldsByLine[i] = fields
+		if len(fields)-1 > maxDelimiters {
+			maxDelimiters = len(fields) - 1
+		}
+	}
+
+	spaceBefore := make([]bool, maxDelimiters)
+	for _, fields := range fieldsByLine {
+		for j := 0; j < len(fields)-1; j++ {
+			if strings.TrimRightFunc(fields[j], unicode.IsSpace) != fields[j] {
+				spaceBefore[j] = true
+			}
+		}
+	}
+
+	// Trim whitespace around each field, except the indentation of the first field.
+	for _, fields := range fieldsByLine {
+		for j := range fields {
+			if j > 0 {
+				fields[j] = strings.TrimLeftFunc(fields[j], unicode.IsSpace)
+			}
+			if j < len(fields)-1 {
+				fields[j] = strings.TrimRightFunc(fields[j], unicode.IsSpace)
+			}
+		}
+	}
+
+	// Build the aligned lines one column at a time.
+	// Every line with a delimiter in column j starts field j at the same offset.
+	builders := make([]strings.Builder, len(lines))
+	var offset uint64
+	for j := 0; j < maxDelimiters; j++ {
+		var maxWidth uint64
+		for _, fields := range fieldsByLine {
+			if j < len(fields)-1 {
+				w := stringWidthForWrap(fields[j], offset, tabSize)
+				if w > maxWidth {
+					maxWidth = w
+				}
+			}
+		}
+
+		// If the delimiter starts the line (like a markdown table), don't add space before it.
+		separatorBefore := spaceBefore[j] && maxWidth > 0
+
+		for i, fields := range fieldsByLine {
+			if j >= len(fields)-1 {
+				continue
+			}
+			sb := &builders[i]
+			padding := strings.Repeat(" ", int(maxWidth-stringWidthForWrap(fields[j], offset, tabSize)))
+			sb.WriteString(fields[j])
+			if separatorBefore {
+				sb.WriteString(padding)
+				sb.WriteString(" ")
+				sb.WriteString(delimiter)
+			} else {
+				sb.WriteString(delimiter)
+				sb.WriteString(padding)
+			}
+
+			// Separate the delimiter from the next field, unless the next field is empty
+			// at the end of the line (like the closing delimiter of a markdown table).
+			if j+1 < len(fields)-1 || fields[j+1] != "" {
+				sb.WriteString(" ")
+			}
+		}
+
+		offset += maxWidth + uint64(utf8.RuneCountInString(delimiter)) + 1
+		if separatorBefore {
+			offset++
+		}
+	}
+
+	alignedLines := make([]string, len(lines))
+	for i, fields := range fieldsByLine {
+		if len(fields) == 1 {
+			alignedLines[i] = lines[i]
+			continue
+		}
+		builders[i].WriteString(fields[len(fields)-1])
+		alignedLines[i] = builders[i].String()
+	}
+	return alignedLines
+}
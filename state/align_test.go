@@ -0,0 +1,132 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestAlignLines(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputString    string
+		startLineNum   uint64
+		endLineNum     uint64
+		delimiter      string
+		expectedText   string
+		expectedCursor uint64
+	}{
+		{
+			name:         "empty document",
+			inputString:  "",
+			delimiter:    "=",
+			expectedText: "",
+		},
+		{
+			name:           "align assignments",
+			inputString:    "x = 1\nfoo = 2\nab=3",
+			startLineNum:   0,
+			endLineNum:     2,
+			delimiter:      "=",
+			expectedText:   "x   = 1\nfoo = 2\nab  = 3",
+			expectedCursor: 0,
+		},
+		{
+			name:           "align colons without space before delimiter",
+			inputString:    "\tName: \"foo\",\n\tDescription: \"bar\",",
+			startLineNum:   0,
+			endLineNum:     1,
+			delimiter:      ":",
+			expectedText:   "\tName:        \"foo\",\n\tDescription: \"bar\",",
+			expectedCursor: 1,
+		},
+		{
+			name:           "preserve indentation with tabs",
+			inputString:    "\ta = 1\n        bcd = 2",
+			startLineNum:   0,
+			endLineNum:     1,
+			delimiter:      "=",
+			expectedText:   "\ta       = 1\n        bcd = 2",
+			expectedCursor: 1,
+		},
+		{
+			name:           "skip lines without delimiter",
+			inputString:    "a = 1\n// comment\nbcd = 2",
+			startLineNum:   0,
+			endLineNum:     2,
+			delimiter:      "=",
+			expectedText:   "a   = 1\n// comment\nbcd = 2",
+			expectedCursor: 0,
+		},
+		{
+			name:           "only selected lines",
+			inputString:    "a = 1\nbb = 2\nccc = 3",
+			startLineNum:   0,
+			endLineNum:     1,
+			delimiter:      "=",
+			expectedText:   "a  = 1\nbb = 2\nccc = 3",
+			expectedCursor: 0,
+		},
+		{
+			name:           "markdown table",
+			inputString:    "| a | bcd |\n|---|---|\n| efgh | i |",
+			startLineNum:   0,
+			endLineNum:     2,
+			delimiter:      "|",
+			expectedText:   "| a    | bcd |\n| ---  | --- |\n| efgh | i   |",
+			expectedCursor: 0,
+		},
+		{
+			name:           "multi-character delimiter",
+			inputString:    "x := 1\nfoo := 2",
+			startLineNum:   0,
+			endLineNum:     1,
+			delimiter:      ":=",
+			expectedText:   "x   := 1\nfoo := 2",
+			expectedCursor: 0,
+		},
+		{
+			name:           "wide characters",
+			inputString:    "界 = 1\nabc = 2",
+			startLineNum:   0,
+			endLineNum:     1,
+			delimiter:      "=",
+			expectedText:   "界  = 1\nabc = 2",
+			expectedCursor: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			err = AlignLines(state, tc.startLineNum, tc.endLineNum, tc.delimiter)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor.position)
+		})
+	}
+}
+
+func TestAlignLinesEmptyDelimiter(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	err := AlignLines(state, 0, 0, " ")
+	assert.EqualError(t, err, "Alignment delimiter cannot be empty")
+}
+
+func TestAlignLinesUndo(t *testing.T) {
+	textTree, err := text.NewTreeFromString("a = 1\nbcd = 2")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	err = AlignLines(state, 0, 1, "=")
+	require.NoError(t, err)
+	assert.Equal(t, "a   = 1\nbcd = 2", textTree.String())
+	Undo(state)
+	assert.Equal(t, "a = 1\nbcd = 2", state.documentBuffer.textTree.String())
+}
@@ -0,0 +1,173 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/syntax"
+)
+
+// ConfigLoaderFunc loads the configuration rule set, for example from the user's config file.
+type ConfigLoaderFunc func() (config.RuleSet, error)
+
+// SetConfigLoader sets the function used to load the configuration when the user reloads it.
+func SetConfigLoader(state *EditorState, loader ConfigLoaderFunc) {
+	state.configLoader = loader
+}
+
+// ReloadConfig loads the configuration again and applies any changes to the open document.
+func ReloadConfig(state *EditorState) {
+	if state.configLoader == nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Config reload is not available when using the default config",
+		})
+		return
+	}
+
+	ruleSet, err := state.configLoader()
+	if err != nil {
+		log.Printf("Error reloading config: %v\n", err)
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  firstLine(err.Error()),
+		})
+		return
+	}
+
+	changed := ApplyConfigRuleSet(state, ruleSet)
+	if len(changed) == 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "Reloaded config, no changes for this document",
+		})
+		return
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Reloaded config, changed %s", strings.Join(changed, ", ")),
+	})
+}
+
+// ApplyConfigRuleSet replaces the configuration rule set and applies the new values to the open document.
+// Unlike reloading the document, this preserves the cursor, selection, search, and undo history.
+// Only values that differ from the previous configuration are applied,
+// so settings toggled with menu commands are kept unless the configuration for them changed.
+// It returns the config keys that changed for the document.
+func ApplyConfigRuleSet(state *EditorState, ruleSet config.RuleSet) []string {
+	path := state.fileWatcher.Path()
+	oldCfg := state.configRuleSet.ConfigForPath(path)
+	newCfg := ruleSet.ConfigForPath(path)
+	state.configRuleSet = ruleSet
+	state.configApplyCount++
+
+	buffer := state.documentBuffer
+	var changed []string
+	applyIfChanged := func(key string, isChanged bool, apply func()) {
+		if isChanged {
+			changed = append(changed, key)
+			apply()
+		}
+	}
+
+	applyIfChanged("tabSize", oldCfg.TabSize != newCfg.TabSize, func() {
+		buffer.tabSize = uint64(newCfg.TabSize) // safe b/c we validated the config.
+	})
+	applyIfChanged("tabExpand", oldCfg.TabExpand != newCfg.TabExpand, func() {
+		if buffer.pasteMode.enabled {
+			buffer.pasteMode.disabledTabExpand = newCfg.TabExpand
+		} else {
+			buffer.tabExpand = newCfg.TabExpand
+		}
+	})
+	applyIfChanged("autoIndent", oldCfg.AutoIndent != newCfg.AutoIndent, func() {
+		if buffer.pasteMode.enabled {
+			buffer.pasteMode.disabledAutoIndent = newCfg.AutoIndent
+		} else {
+			buffer.autoIndent = newCfg.AutoIndent
+		}
+	})
+	applyIfChanged("showTabs", oldCfg.ShowTabs != newCfg.ShowTabs, func() {
+		buffer.showTabs = newCfg.ShowTabs
+	})
+	applyIfChanged("showSpaces", oldCfg.ShowSpaces != newCfg.ShowSpaces, func() {
+		buffer.showSpaces = newCfg.ShowSpaces
+	})
+	applyIfChanged("showLineNumbers", oldCfg.ShowLineNumbers != newCfg.ShowLineNumbers, func() {
+		buffer.showLineNum = newCfg.ShowLineNumbers
+	})
+	applyIfChanged("lineNumberMode", oldCfg.LineNumberMode != newCfg.LineNumberMode, func() {
+		buffer.lineNumberMode = config.LineNumberMode(newCfg.LineNumberMode)
+	})
+	applyIfChanged("showRuler", oldCfg.ShowRuler != newCfg.ShowRuler, func() {
+		buffer.showRuler = newCfg.ShowRuler
+	})
+	applyIfChanged("showDiffGutter", oldCfg.ShowDiffGutter != newCfg.ShowDiffGutter, func() {
+		buffer.showDiffGutter = newCfg.ShowDiffGutter
+	})
+	applyIfChanged("showScrollbar", oldCfg.ShowScrollbar != newCfg.ShowScrollbar, func() {
+		buffer.showScrollbar = newCfg.ShowScrollbar
+	})
+	applyIfChanged("showMatchingBracket", oldCfg.ShowMatchingBracket != newCfg.ShowMatchingBracket, func() {
+		buffer.showMatchingBracket = newCfg.ShowMatchingBracket
+	})
+	applyIfChanged("showAnsiColors", oldCfg.ShowAnsiColors != newCfg.ShowAnsiColors, func() {
+		buffer.showAnsiColors = newCfg.ShowAnsiColors
+	})
+	applyIfChanged("showKeyHints", oldCfg.ShowKeyHints != newCfg.ShowKeyHints, func() {
+		buffer.showKeyHints = newCfg.ShowKeyHints
+	})
+	applyIfChanged("lineWrap", oldCfg.LineWrap != newCfg.LineWrap, func() {
+		buffer.lineWrapAllowCharBreaks = bool(newCfg.LineWrap == config.LineWrapCharacter)
+	})
+	applyIfChanged("rtlVisualOrder", oldCfg.RtlVisualOrder != newCfg.RtlVisualOrder, func() {
+		buffer.rtlVisualOrder = newCfg.RtlVisualOrder
+	})
+	applyIfChanged("insertModeSelection", oldCfg.InsertModeSelection != newCfg.InsertModeSelection, func() {
+		buffer.insertModeSelection = newCfg.InsertModeSelection
+	})
+	applyIfChanged("matchPasteIndent", oldCfg.MatchPasteIndent != newCfg.MatchPasteIndent, func() {
+		buffer.matchPasteIndent = newCfg.MatchPasteIndent
+	})
+	applyIfChanged("ligatureBreaker", oldCfg.LigatureBreaker != newCfg.LigatureBreaker, func() {
+		buffer.ligatureBreaker = ligatureBreakerRune(newCfg.LigatureBreaker)
+	})
+	applyIfChanged("syntaxBackend", oldCfg.SyntaxBackend != newCfg.SyntaxBackend, func() {
+		buffer.syntaxBackend = syntax.Backend(newCfg.SyntaxBackend)
+		setSyntaxAndRetokenize(buffer, buffer.syntaxLanguage)
+	})
+	applyIfChanged("syntaxLanguage", oldCfg.SyntaxLanguage != newCfg.SyntaxLanguage, func() {
+		setSyntaxAndRetokenize(buffer, syntax.Language(newCfg.SyntaxLanguage))
+	})
+
+	// These settings cannot be changed with menu commands, so always use the new values.
+	state.customMenuItems = customMenuItems(newCfg)
+	state.hidePatterns = newCfg.HidePatternsAndHideDirectories()
+	state.styles = newCfg.Styles
+	state.createParentDirs = newCfg.CreateParentDirs
+	state.replaceSymlinks = bool(newCfg.SymlinkSave == config.SymlinkSaveReplace)
+	state.longLineThreshold = uint64(newCfg.LongLineThreshold) // safe b/c we validated the config.
+	state.eventHook = newCfg.EventHook
+	state.bell = newCfg.Bell
+	state.timestampFormats = newCfg.TimestampFormats
+	state.menuPinnedCommands = newCfg.MenuPinnedCommands
+	state.menuHiddenCommands = newCfg.MenuHiddenCommands
+	state.keyRemap = keyRemapForConfig(newCfg)
+	state.persistScratch = newCfg.PersistScratch
+
+	// Tab size, line numbers, and line wrap change the layout, so the cursor might have moved off screen.
+	ScrollViewToCursor(state)
+
+	return changed
+}
+
+// firstLine returns the first line of a possibly multi-line message.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/syntax"
+)
+
+func ruleSetWithConfig(cfg map[string]any) config.RuleSet {
+	return config.RuleSet{{Name: "test", Pattern: "**", Config: cfg}}
+}
+
+func TestApplyConfigRuleSet(t *testing.T) {
+	path, cleanup := createTestFile(t, `{"a": 1, "b": 2}`)
+	defer cleanup()
+
+	state := NewEditorState(100, 100, ruleSetWithConfig(map[string]any{"tabSize": 4}), nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	// Set up state that would be reset by reloading the document.
+	ToggleShowSpaces(state)
+	MoveCursor(state, func(LocatorParams) uint64 { return 2 })
+	ToggleVisualMode(state, selection.ModeChar)
+	MoveCursor(state, func(LocatorParams) uint64 { return 4 })
+	state.documentBuffer.search.query = "b"
+	undoLog := state.documentBuffer.undoLog
+
+	changed := ApplyConfigRuleSet(state, ruleSetWithConfig(map[string]any{
+		"tabSize":         2,
+		"syntaxLanguage":  "json",
+		"showLineNumbers": true,
+	}))
+	assert.Equal(t, []string{"tabSize", "showLineNumbers", "syntaxLanguage"}, changed)
+	assert.Equal(t, uint64(2), state.documentBuffer.TabSize())
+	assert.True(t, state.documentBuffer.showLineNum)
+	assert.Equal(t, syntax.LanguageJson, state.documentBuffer.SyntaxLanguage())
+	assert.NotEmpty(t, state.documentBuffer.SyntaxTokensIntersectingRange(0, 16))
+	assert.Equal(t, 1, state.ConfigApplyCount())
+
+	// Settings unchanged in the config keep the values toggled by the user.
+	assert.True(t, state.documentBuffer.showSpaces)
+
+	// Cursor, selection, search, and undo history are preserved.
+	assert.Equal(t, InputModeVisual, state.InputMode())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+	assert.Equal(t, selection.Region{StartPos: 2, EndPos: 5}, state.documentBuffer.SelectedRegion())
+	assert.Equal(t, "b", state.documentBuffer.search.query)
+	assert.Same(t, undoLog, state.documentBuffer.undoLog)
+}
+
+func TestApplyConfigRuleSetDuringPasteMode(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	TogglePasteMode(state)
+
+	changed := ApplyConfigRuleSet(state, ruleSetWithConfig(map[string]any{"autoIndent": true}))
+	assert.Equal(t, []string{"autoIndent"}, changed)
+	assert.False(t, state.documentBuffer.autoIndent)
+
+	TogglePasteMode(state)
+	assert.True(t, state.documentBuffer.autoIndent)
+}
+
+func TestReloadConfig(t *testing.T) {
+	testCases := []struct {
+		name              string
+		loader            ConfigLoaderFunc
+		expectStatusStyle StatusMsgStyle
+		expectStatusText  string
+	}{
+		{
+			name:              "reload not available",
+			loader:            nil,
+			expectStatusStyle: StatusMsgStyleError,
+			expectStatusText:  "Config reload is not available when using the default config",
+		},
+		{
+			name: "invalid config",
+			loader: func() (config.RuleSet, error) {
+				return nil, errors.New("Invalid configuration: TabSize must be greater than zero\nTo edit the config, try...")
+			},
+			expectStatusStyle: StatusMsgStyleError,
+			expectStatusText:  "Invalid configuration: TabSize must be greater than zero",
+		},
+		{
+			name: "no changes",
+			loader: func() (config.RuleSet, error) {
+				return nil, nil
+			},
+			expectStatusStyle: StatusMsgStyleSuccess,
+			expectStatusText:  "Reloaded config, no changes for this document",
+		},
+		{
+			name: "changes",
+			loader: func() (config.RuleSet, error) {
+				return ruleSetWithConfig(map[string]any{"tabSize": 8, "tabExpand": true}), nil
+			},
+			expectStatusStyle: StatusMsgStyleSuccess,
+			expectStatusText:  "Reloaded config, changed tabSize, tabExpand",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := NewEditorState(100, 100, nil, nil)
+			SetConfigLoader(state, tc.loader)
+			ReloadConfig(state)
+			require.Equal(t, tc.expectStatusStyle, state.StatusMsg().Style)
+			assert.Equal(t, tc.expectStatusText, state.StatusMsg().Text)
+		})
+	}
+}
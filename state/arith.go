@@ -0,0 +1,167 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/arith"
+	"github.com/aretext/aretext/locate"
+)
+
+var errNoNumberAtCursor = errors.New("No number under or after the cursor")
+
+// numberAtCursor represents a number in the document, such as "-12" or "1.5".
+type numberAtCursor struct {
+	text     string
+	startPos uint64
+	numRunes uint64
+}
+
+// NumberUnderCursor returns the number under the cursor, or the first number
+// after the cursor on the same line. This allows the user to see which number
+// will change before entering an operand.
+func NumberUnderCursor(state *EditorState) (string, error) {
+	num, err := findNumberAtCursor(state.documentBuffer)
+	if err != nil {
+		return "", err
+	}
+	return num.text, nil
+}
+
+// ApplyArithmeticToNumberUnderCursor replaces the number under the cursor with the result
+// of applying an operand, such as "+5" or "* 2". The operand must start with an operator,
+// and the rest is evaluated before the operator is applied, so "*2+1" multiplies by three.
+// Text around the number, such as the "px" in "12px", is unchanged.
+func ApplyArithmeticToNumberUnderCursor(state *EditorState, operand string) error {
+	operand = strings.TrimSpace(operand)
+	if operand == "" || !strings.ContainsRune("+-*/%", rune(operand[0])) {
+		return errors.New("Operand must start with +, -, *, /, or %")
+	}
+
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return err
+	}
+
+	num, err := findNumberAtCursor(buffer)
+	if err != nil {
+		return err
+	}
+
+	expr := fmt.Sprintf("%s %c (%s)", num.text, operand[0], operand[1:])
+	result, err := arith.Eval(expr)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceWithArithmeticResult(state, num.startPos, num.numRunes, result); err != nil {
+		return err
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("%s %c %s = %s", num.text, operand[0], strings.TrimSpace(operand[1:]), result),
+	})
+	return nil
+}
+
+// EvaluateSelection replaces the selected arithmetic expression with its result,
+// then returns to normal mode. Whitespace around the expression is preserved.
+func EvaluateSelection(state *EditorState) error {
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return err
+	}
+
+	selectedText, r := copySelectionText(buffer)
+	expr := strings.TrimSpace(selectedText)
+	if expr == "" {
+		return errors.New("No expression selected")
+	}
+
+	result, err := arith.Eval(expr)
+	if err != nil {
+		return err
+	}
+
+	// Replace only the expression, not the whitespace around it.
+	leading := selectedText[:len(selectedText)-len(strings.TrimLeftFunc(selectedText, unicode.IsSpace))]
+	startPos := r.StartPos + uint64(utf8.RuneCountInString(leading))
+	numRunes := uint64(utf8.RuneCountInString(expr))
+
+	setInputMode(state, InputModeNormal)
+	if err := replaceWithArithmeticResult(state, startPos, numRunes, result); err != nil {
+		return err
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Evaluated %s", result),
+	})
+	return nil
+}
+
+func replaceWithArithmeticResult(state *EditorState, pos uint64, count uint64, result string) error {
+	BeginUndoEntry(state)
+	_, err := replaceRunes(state, pos, count, result, true)
+	CommitUndoEntry(state)
+	if err != nil {
+		return err
+	}
+
+	MoveCursor(state, func(LocatorParams) uint64 { return pos })
+	return nil
+}
+
+// findNumberAtCursor finds the number containing the cursor, or the first number after
+// the cursor on the same line. A minus sign is part of the number unless it follows
+// a word character, so "x-1" contains the number "1", not "-1".
+func findNumberAtCursor(buffer *BufferState) (numberAtCursor, error) {
+	tree := buffer.textTree
+	cursorPos := buffer.cursor.position
+	lineStartPos := locate.StartOfLineAtPos(tree, cursorPos)
+	lineEndPos := locate.NextLineBoundary(tree, true, cursorPos)
+	line := []rune(copyText(tree, lineStartPos, lineEndPos-lineStartPos))
+	cursorOffset := int(cursorPos - lineStartPos)
+
+	for i := 0; i < len(line); i++ {
+		if !isDigit(line[i]) {
+			continue
+		}
+
+		start, end := i, i
+		for end < len(line) && isDigit(line[end]) {
+			end++
+		}
+
+		if end+1 < len(line) && line[end] == '.' && isDigit(line[end+1]) {
+			end++
+			for end < len(line) && isDigit(line[end]) {
+				end++
+			}
+		}
+
+		if start > 0 && line[start-1] == '-' && (start < 2 || !isIdentifierRune(line[start-2])) {
+			start--
+		}
+
+		if end > cursorOffset {
+			return numberAtCursor{
+				text:     string(line[start:end]),
+				startPos: lineStartPos + uint64(start),
+				numRunes: uint64(end - start),
+			}, nil
+		}
+
+		i = end
+	}
+
+	return numberAtCursor{}, errNoNumberAtCursor
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,155 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/text"
+)
+
+func TestApplyArithmeticToNumberUnderCursor(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputString       string
+		cursorPos         uint64
+		operand           string
+		expectedText      string
+		expectedCursorPos uint64
+		expectedErr       string
+	}{
+		{
+			name:              "add to number under cursor",
+			inputString:       "width: 12px;",
+			cursorPos:         8,
+			operand:           "+4",
+			expectedText:      "width: 16px;",
+			expectedCursorPos: 7,
+		},
+		{
+			name:              "first number after cursor",
+			inputString:       "margin: 10px 20px;",
+			cursorPos:         0,
+			operand:           "* 1.5",
+			expectedText:      "margin: 15px 20px;",
+			expectedCursorPos: 8,
+		},
+		{
+			name:              "negative number",
+			inputString:       "x = -3",
+			cursorPos:         0,
+			operand:           "+5",
+			expectedText:      "x = 2",
+			expectedCursorPos: 4,
+		},
+		{
+			name:              "minus after word character is not a sign",
+			inputString:       "x-3",
+			cursorPos:         0,
+			operand:           "+1",
+			expectedText:      "x-4",
+			expectedCursorPos: 2,
+		},
+		{
+			name:              "decimal number",
+			inputString:       "opacity: 0.25",
+			cursorPos:         11,
+			operand:           "*2",
+			expectedText:      "opacity: 0.5",
+			expectedCursorPos: 9,
+		},
+		{
+			name:              "operand expression evaluated first",
+			inputString:       "5",
+			cursorPos:         0,
+			operand:           "*2+1",
+			expectedText:      "15",
+			expectedCursorPos: 0,
+		},
+		{
+			name:              "no number after cursor on line",
+			inputString:       "12 abc\n34",
+			cursorPos:         3,
+			operand:           "+1",
+			expectedText:      "12 abc\n34",
+			expectedCursorPos: 3,
+			expectedErr:       "No number under or after the cursor",
+		},
+		{
+			name:              "operand without operator",
+			inputString:       "12",
+			cursorPos:         0,
+			operand:           "3",
+			expectedText:      "12",
+			expectedCursorPos: 0,
+			expectedErr:       "Operand must start with +, -, *, /, or %",
+		},
+		{
+			name:              "division by zero",
+			inputString:       "12",
+			cursorPos:         0,
+			operand:           "/0",
+			expectedText:      "12",
+			expectedCursorPos: 0,
+			expectedErr:       "Division by zero",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			buffer := state.documentBuffer
+			buffer.textTree = textTree
+			buffer.cursor.position = tc.cursorPos
+
+			err = ApplyArithmeticToNumberUnderCursor(state, tc.operand)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursorPos, buffer.cursor.position)
+		})
+	}
+}
+
+func TestEvaluateSelection(t *testing.T) {
+	textTree, err := text.NewTreeFromString("total = 2 * (3 + 4) ;")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	buffer := state.documentBuffer
+	buffer.textTree = textTree
+	buffer.cursor.position = 7
+
+	ToggleVisualMode(state, selection.ModeChar)
+	buffer.cursor.position = 19
+	err = EvaluateSelection(state)
+	require.NoError(t, err)
+	assert.Equal(t, "total = 14 ;", textTree.String())
+	assert.Equal(t, uint64(8), buffer.cursor.position)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+
+	// A single undo restores the expression.
+	Undo(state)
+	assert.Equal(t, "total = 2 * (3 + 4) ;", textTree.String())
+}
+
+func TestEvaluateSelectionInvalidExpression(t *testing.T) {
+	textTree, err := text.NewTreeFromString("foo bar")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	buffer := state.documentBuffer
+	buffer.textTree = textTree
+
+	ToggleVisualMode(state, selection.ModeChar)
+	buffer.cursor.position = 2
+	err = EvaluateSelection(state)
+	assert.EqualError(t, err, "Unexpected 'f' in expression")
+	assert.Equal(t, "foo bar", textTree.String())
+	assert.Equal(t, InputModeVisual, state.InputMode())
+}
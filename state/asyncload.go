@@ -0,0 +1,129 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/undo"
+)
+
+// asyncLoadTimeout is how long to wait for a file to load before displaying a preview.
+var asyncLoadTimeout = 200 * time.Millisecond
+
+// asyncLoadPreviewBytes is the maximum size of the preview displayed while a file is loading.
+// This is enough to fill a screen with text, even for a large terminal.
+const asyncLoadPreviewBytes = 64 * 1024
+
+// fileLoadFunc loads a file from disk. Tests may replace this to simulate a slow load.
+var fileLoadFunc = file.Load
+
+// pendingLoadState represents a document loading in the background.
+type pendingLoadState struct {
+	path        string
+	cursorLoc   Locator
+	cursorPos   uint64 // Cursor position after loading the preview.
+	actionChan  chan func(*EditorState)
+	abandonChan chan struct{}
+}
+
+// startAsyncLoad displays a preview of the file while the rest of the file loads in the background.
+// The result of the load is received from resultChan.
+// It returns false if the preview could not be loaded, in which case the editor state is unchanged.
+func startAsyncLoad(state *EditorState, path string, resultChan <-chan loadFileResult, timelineState file.TimelineState, cursorLoc Locator) bool {
+	preview, err := file.LoadPreview(path, asyncLoadPreviewBytes)
+	if err != nil {
+		log.Printf("Could not load preview of %q: %v\n", path, err)
+		return false
+	}
+
+	log.Printf("Loading %q in the background\n", path)
+	resetStateForDocument(state, path, preview, file.NewInactiveWatcher(path))
+	state.documentBuffer.loading = true
+
+	if !timelineState.Empty() {
+		state.fileTimeline.TransitionFrom(timelineState)
+	}
+
+	setCursorAfterLoad(state, cursorLoc)
+
+	load := &pendingLoadState{
+		path:        path,
+		cursorLoc:   cursorLoc,
+		cursorPos:   state.documentBuffer.cursor.position,
+		actionChan:  make(chan func(*EditorState)),
+		abandonChan: make(chan struct{}),
+	}
+	state.pendingLoad = load
+
+	go func() {
+		result := <-resultChan
+		select {
+		case load.actionChan <- func(state *EditorState) { completeAsyncLoad(state, load, result) }:
+		case <-load.abandonChan:
+			log.Printf("Discarding abandoned load of %q\n", path)
+			if result.watcher != nil {
+				result.watcher.Stop()
+			}
+		}
+	}()
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Loading %s...", file.RelativePathCwd(path)),
+	})
+
+	return true
+}
+
+// completeAsyncLoad replaces the preview with the fully loaded document.
+// This preserves the input mode, view, and cursor position (if the user moved it).
+func completeAsyncLoad(state *EditorState, load *pendingLoadState, result loadFileResult) {
+	state.pendingLoad = nil
+
+	if result.err != nil {
+		// The buffer contains only the start of the file, so keep rejecting edits
+		// to avoid overwriting the file with the truncated text.
+		reportLoadError(state, result.err, load.path)
+		return
+	}
+
+	buffer := state.documentBuffer
+	cursorPos := buffer.cursor.position
+	buffer.textTree = result.tree
+	buffer.originalText = newTextSnapshot(result.tree)
+	setSavedText(buffer, buffer.originalText)
+	buffer.undoLog.Close()
+	buffer.undoLog = undo.NewLog()
+	buffer.loading = false
+	state.fileWatcher.Stop()
+	state.fileWatcher = result.watcher
+	setSyntaxAndRetokenize(buffer, buffer.syntaxLanguage)
+
+	if cursorPos == load.cursorPos {
+		setCursorAfterLoad(state, load.cursorLoc)
+	} else {
+		MoveCursor(state, func(LocatorParams) uint64 { return cursorPos })
+		ScrollViewToCursor(state)
+	}
+
+	if result.fileExists {
+		recordFileOpen(state, load.path)
+		reportOpenSuccess(state, load.path)
+	} else {
+		buffer.textFormat = textFormatForNewFile(state, load.path)
+		reportCreateSuccess(state, load.path)
+	}
+
+	checkLongLines(state, load.path)
+	checkEncryptedDocument(state)
+}
+
+// abandonPendingLoad discards the result of a document loading in the background, if any.
+func abandonPendingLoad(state *EditorState) {
+	if state.pendingLoad != nil {
+		close(state.pendingLoad.abandonChan)
+		state.pendingLoad = nil
+	}
+}
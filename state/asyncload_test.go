@@ -0,0 +1,127 @@
+package state
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/text"
+)
+
+// withBlockingFileLoad simulates a slow file load that completes only when the returned function is called.
+func withBlockingFileLoad(t *testing.T, loadErr error) (unblock func()) {
+	unblockChan := make(chan struct{})
+	origLoadFunc, origTimeout := fileLoadFunc, asyncLoadTimeout
+	fileLoadFunc = func(path string, watcherCfg file.WatcherConfig) (*text.Tree, *file.Watcher, error) {
+		<-unblockChan
+		if loadErr != nil {
+			return nil, nil, loadErr
+		}
+		return file.Load(path, watcherCfg)
+	}
+	asyncLoadTimeout = time.Millisecond
+	t.Cleanup(func() {
+		fileLoadFunc, asyncLoadTimeout = origLoadFunc, origTimeout
+	})
+	return func() { close(unblockChan) }
+}
+
+func completePendingLoad(t *testing.T, state *EditorState) {
+	select {
+	case action := <-state.PendingLoadResultChan():
+		action(state)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Timed out waiting for pending load")
+	}
+}
+
+func TestLoadDocumentAsync(t *testing.T) {
+	contents := strings.Repeat("abcdefg\n", asyncLoadPreviewBytes)
+	path, _ := createTestFile(t, contents)
+	unblock := withBlockingFileLoad(t, nil)
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	// Expect that the preview is displayed, and edits are disabled.
+	buffer := state.documentBuffer
+	assert.True(t, buffer.Loading())
+	assert.True(t, strings.HasPrefix(contents, buffer.textTree.String()))
+	assert.Less(t, buffer.textTree.NumChars(), uint64(len(contents)))
+	assert.Equal(t, path, state.FileWatcher().Path())
+	assert.Contains(t, state.StatusMsg().Text, "Loading")
+
+	InsertText(state, "xyz")
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "Document is still loading", state.StatusMsg().Text)
+
+	SaveDocument(state)
+	assert.Contains(t, state.StatusMsg().Text, "Document is still loading")
+
+	ShowChangesSinceLoad(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "Document is still loading", state.StatusMsg().Text)
+
+	// The user can still move the cursor while loading.
+	MoveCursor(state, func(LocatorParams) uint64 { return 16 })
+
+	// Finish loading.
+	unblock()
+	completePendingLoad(t, state)
+	assert.False(t, buffer.Loading())
+	assert.Nil(t, state.PendingLoadResultChan())
+	assert.Equal(t, strings.TrimSuffix(contents, "\n"), buffer.textTree.String())
+	assert.Equal(t, uint64(16), buffer.cursor.position)
+	assert.Contains(t, state.StatusMsg().Text, "Opened")
+
+	// Expect that edits are enabled.
+	InsertText(state, "xyz")
+	assert.True(t, strings.HasPrefix(buffer.textTree.String(), "abcdefg\nabcdefg\nxyzabcdefg"))
+}
+
+func TestLoadDocumentAsyncError(t *testing.T) {
+	contents := strings.Repeat("abcdefg\n", asyncLoadPreviewBytes)
+	path, _ := createTestFile(t, contents)
+	unblock := withBlockingFileLoad(t, errors.New("test error"))
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+	assert.True(t, state.documentBuffer.Loading())
+
+	unblock()
+	completePendingLoad(t, state)
+	assert.Contains(t, state.StatusMsg().Text, "test error")
+
+	// Expect that edits remain disabled, since the document contains only the preview.
+	assert.True(t, state.documentBuffer.Loading())
+	InsertText(state, "xyz")
+	assert.Equal(t, "Document is still loading", state.StatusMsg().Text)
+}
+
+func TestLoadDocumentAsyncAbandoned(t *testing.T) {
+	contents := strings.Repeat("abcdefg\n", asyncLoadPreviewBytes)
+	path, _ := createTestFile(t, contents)
+	unblock := withBlockingFileLoad(t, nil)
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	assert.True(t, state.documentBuffer.Loading())
+	unblock()
+
+	// Load a different document before the first one finishes loading.
+	asyncLoadTimeout = 5 * time.Second
+	otherPath, _ := createTestFile(t, "foo")
+	LoadDocument(state, otherPath, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+	assert.False(t, state.documentBuffer.Loading())
+	assert.Nil(t, state.PendingLoadResultChan())
+	assert.Equal(t, "foo", state.documentBuffer.textTree.String())
+	assert.Equal(t, otherPath, state.FileWatcher().Path())
+}
@@ -0,0 +1,217 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/text"
+)
+
+// maxAutocompleteScanRunes limits how far from the cursor to look for words,
+// so autocomplete stays fast in large documents.
+const maxAutocompleteScanRunes = 1 << 20
+
+// maxAutocompleteCandidates limits the number of words suggested for a prefix.
+const maxAutocompleteCandidates = 100
+
+// AutocompleteState represents the words suggested to complete the word before the cursor in insert mode.
+type AutocompleteState struct {
+	// prefix is the part of the word the user typed before starting autocomplete.
+	prefix string
+
+	// suffixPos is the position after the prefix, where the rest of the selected word is inserted.
+	suffixPos uint64
+
+	// candidates are words from the document that start with the prefix,
+	// ordered from the nearest word after the cursor, wrapping around to the start of the document.
+	candidates []string
+
+	// selectedIdx is the index of the inserted candidate, or -1 if none is inserted.
+	selectedIdx int
+}
+
+// Prefix returns the part of the word typed before starting autocomplete.
+func (a *AutocompleteState) Prefix() string {
+	return a.prefix
+}
+
+// Candidates returns the suggested words and the index of the selected word, or -1 if no word is selected.
+func (a *AutocompleteState) Candidates() (candidates []string, selectedIdx int) {
+	return a.candidates, a.selectedIdx
+}
+
+// StartAutocomplete suggests words from the document that complete the word before the cursor.
+// If forward is true, it inserts the nearest word after the cursor; otherwise, it inserts
+// the nearest word before the cursor. This should be called only in insert mode.
+func StartAutocomplete(state *EditorState, forward bool) {
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return
+	}
+
+	ClearInsertModeSelection(state)
+	trackInsertStart(state)
+
+	cursorPos := buffer.cursor.position
+	prefixPos := wordStartBeforePos(buffer.textTree, cursorPos)
+	prefix := copyText(buffer.textTree, prefixPos, cursorPos-prefixPos)
+	index := buildWordIndex(buffer.textTree, prefixPos, wordEndAfterPos(buffer.textTree, cursorPos))
+	candidates := index.wordsWithPrefix(prefix, maxAutocompleteCandidates)
+	if len(candidates) == 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("No completions for %q", prefix),
+		})
+		return
+	}
+
+	setInputMode(state, InputModeAutocomplete)
+	state.autocomplete = &AutocompleteState{
+		prefix:      prefix,
+		suffixPos:   cursorPos,
+		candidates:  candidates,
+		selectedIdx: -1,
+	}
+
+	if forward {
+		selectAutocompleteCandidate(state, 0)
+	} else {
+		selectAutocompleteCandidate(state, len(candidates)-1)
+	}
+}
+
+// MoveAutocompleteSelection replaces the inserted word with another candidate.
+// Moving past the first or last candidate restores the prefix the user typed.
+func MoveAutocompleteSelection(state *EditorState, delta int) {
+	ac := state.autocomplete
+	if ac == nil {
+		return
+	}
+
+	// Slot zero is the prefix without any candidate, so shift indices by one.
+	n := len(ac.candidates) + 1
+	slot := ((ac.selectedIdx+1+delta)%n + n) % n
+	selectAutocompleteCandidate(state, slot-1)
+}
+
+// AcceptAutocomplete keeps the inserted word and returns to insert mode.
+func AcceptAutocomplete(state *EditorState) {
+	setInputMode(state, InputModeInsert)
+}
+
+// CancelAutocomplete restores the prefix the user typed and returns to insert mode.
+func CancelAutocomplete(state *EditorState) {
+	selectAutocompleteCandidate(state, -1)
+	setInputMode(state, InputModeInsert)
+}
+
+// selectAutocompleteCandidate replaces the suffix after the prefix with the rest of the selected candidate.
+func selectAutocompleteCandidate(state *EditorState, idx int) {
+	ac := state.autocomplete
+	if ac == nil {
+		return
+	}
+
+	var suffix string
+	if idx >= 0 {
+		suffix = strings.TrimPrefix(ac.candidates[idx], ac.prefix)
+	}
+
+	buffer := state.documentBuffer
+	count := buffer.cursor.position - ac.suffixPos
+	if _, err := replaceRunes(state, ac.suffixPos, count, suffix, true); err != nil {
+		return
+	}
+	buffer.cursor.position = ac.suffixPos + uint64(utf8.RuneCountInString(suffix))
+	ac.selectedIdx = idx
+}
+
+// wordStartBeforePos returns the start of the word that ends at pos, or pos if there is no such word.
+func wordStartBeforePos(tree *text.Tree, pos uint64) uint64 {
+	reader := tree.ReverseReaderAtPosition(pos)
+	for pos > 0 {
+		r, _, err := reader.ReadRune()
+		if err != nil || !isIdentifierRune(r) {
+			break
+		}
+		pos--
+	}
+	return pos
+}
+
+// wordEndAfterPos returns the end of the word that starts at pos, or pos if there is no such word.
+func wordEndAfterPos(tree *text.Tree, pos uint64) uint64 {
+	reader := tree.ReaderAtPosition(pos)
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil || !isIdentifierRune(r) {
+			break
+		}
+		pos++
+	}
+	return pos
+}
+
+// wordIndex contains the distinct words in a document, ordered by distance after a position.
+type wordIndex struct {
+	words []string
+}
+
+// buildWordIndex finds the words in a document, excluding the word from startPos to endPos that the user is completing.
+// The words after endPos come first, followed by the words from the start of the document to startPos.
+// Only words within maxAutocompleteScanRunes of the excluded word are included.
+func buildWordIndex(tree *text.Tree, startPos uint64, endPos uint64) *wordIndex {
+	idx := &wordIndex{}
+	seen := make(map[string]struct{})
+	addWords := func(fromPos, toPos uint64) {
+		// If the range starts in the middle of a word, skip the partial word.
+		skipFirst := fromPos > 0 && wordStartBeforePos(tree, fromPos) < fromPos
+		var sb strings.Builder
+		reader := tree.ReaderAtPosition(fromPos)
+		for pos := fromPos; pos <= toPos; pos++ {
+			r, _, err := reader.ReadRune()
+			if pos < toPos && err == nil && isIdentifierRune(r) {
+				sb.WriteRune(r)
+				continue
+			}
+
+			if sb.Len() > 0 && !skipFirst {
+				word := sb.String()
+				if _, ok := seen[word]; !ok {
+					seen[word] = struct{}{}
+					idx.words = append(idx.words, word)
+				}
+			}
+			sb.Reset()
+			skipFirst = false
+
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	addWords(endPos, min(tree.NumChars(), endPos+maxAutocompleteScanRunes))
+	if startPos > maxAutocompleteScanRunes {
+		addWords(startPos-maxAutocompleteScanRunes, startPos)
+	} else {
+		addWords(0, startPos)
+	}
+	return idx
+}
+
+// wordsWithPrefix returns up to limit words that start with the prefix and are longer than the prefix.
+func (idx *wordIndex) wordsWithPrefix(prefix string, limit int) []string {
+	var result []string
+	for _, word := range idx.words {
+		if len(result) >= limit {
+			break
+		}
+		if len(word) > len(prefix) && strings.HasPrefix(word, prefix) {
+			result = append(result, word)
+		}
+	}
+	return result
+}
@@ -0,0 +1,225 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestAutocomplete(t *testing.T) {
+	testCases := []struct {
+		name               string
+		initialText        string
+		cursorPos          uint64
+		forward            bool
+		moves              []int
+		cancel             bool
+		expectedText       string
+		expectedCursorPos  uint64
+		expectedCandidates []string
+		expectedMode       InputMode
+	}{
+		{
+			name:               "forward selects nearest word after cursor",
+			initialText:        "foo fo foobar",
+			cursorPos:          6,
+			forward:            true,
+			expectedText:       "foo foobar foobar",
+			expectedCursorPos:  10,
+			expectedCandidates: []string{"foobar", "foo"},
+			expectedMode:       InputModeAutocomplete,
+		},
+		{
+			name:               "backward selects nearest word before cursor",
+			initialText:        "foo fo foobar",
+			cursorPos:          6,
+			forward:            false,
+			expectedText:       "foo foo foobar",
+			expectedCursorPos:  7,
+			expectedCandidates: []string{"foobar", "foo"},
+			expectedMode:       InputModeAutocomplete,
+		},
+		{
+			name:               "move to next candidate",
+			initialText:        "foo fo foobar",
+			cursorPos:          6,
+			forward:            true,
+			moves:              []int{1},
+			expectedText:       "foo foo foobar",
+			expectedCursorPos:  7,
+			expectedCandidates: []string{"foobar", "foo"},
+			expectedMode:       InputModeAutocomplete,
+		},
+		{
+			name:               "move past last candidate restores prefix",
+			initialText:        "foo fo foobar",
+			cursorPos:          6,
+			forward:            true,
+			moves:              []int{1, 1},
+			expectedText:       "foo fo foobar",
+			expectedCursorPos:  6,
+			expectedCandidates: []string{"foobar", "foo"},
+			expectedMode:       InputModeAutocomplete,
+		},
+		{
+			name:               "move past prefix wraps to first candidate",
+			initialText:        "foo fo foobar",
+			cursorPos:          6,
+			forward:            true,
+			moves:              []int{1, 1, 1},
+			expectedText:       "foo foobar foobar",
+			expectedCursorPos:  10,
+			expectedCandidates: []string{"foobar", "foo"},
+			expectedMode:       InputModeAutocomplete,
+		},
+		{
+			name:              "cancel restores prefix",
+			initialText:       "foo fo foobar",
+			cursorPos:         6,
+			forward:           true,
+			cancel:            true,
+			expectedText:      "foo fo foobar",
+			expectedCursorPos: 6,
+			expectedMode:      InputModeInsert,
+		},
+		{
+			name:              "no completions",
+			initialText:       "abc xy",
+			cursorPos:         6,
+			forward:           true,
+			expectedText:      "abc xy",
+			expectedCursorPos: 6,
+			expectedMode:      InputModeInsert,
+		},
+		{
+			name:               "completes word in the middle of a line",
+			initialText:        "bar\nba baz",
+			cursorPos:          6,
+			forward:            true,
+			expectedText:       "bar\nbaz baz",
+			expectedCursorPos:  7,
+			expectedCandidates: []string{"baz", "bar"},
+			expectedMode:       InputModeAutocomplete,
+		},
+		{
+			name:               "empty prefix suggests all words",
+			initialText:        "ab cd ",
+			cursorPos:          6,
+			forward:            true,
+			expectedText:       "ab cd ab",
+			expectedCursorPos:  8,
+			expectedCandidates: []string{"ab", "cd"},
+			expectedMode:       InputModeAutocomplete,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.initialText)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			buffer := state.documentBuffer
+			buffer.textTree = textTree
+			buffer.cursor.position = tc.cursorPos
+			EnterInsertMode(state)
+			StartAutocomplete(state, tc.forward)
+			for _, delta := range tc.moves {
+				MoveAutocompleteSelection(state, delta)
+			}
+			if tc.cancel {
+				CancelAutocomplete(state)
+			}
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursorPos, buffer.cursor.position)
+			assert.Equal(t, tc.expectedMode, state.InputMode())
+			if tc.expectedCandidates != nil {
+				require.NotNil(t, state.Autocomplete())
+				candidates, _ := state.Autocomplete().Candidates()
+				assert.Equal(t, tc.expectedCandidates, candidates)
+			} else {
+				assert.Nil(t, state.Autocomplete())
+			}
+		})
+	}
+}
+
+func TestAcceptAutocomplete(t *testing.T) {
+	textTree, err := text.NewTreeFromString("foobar fo")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	buffer := state.documentBuffer
+	buffer.textTree = textTree
+	buffer.cursor.position = 9
+	EnterInsertMode(state)
+	StartAutocomplete(state, true)
+	assert.Equal(t, InputModeAutocomplete, state.InputMode())
+	AcceptAutocomplete(state)
+	assert.Equal(t, InputModeInsert, state.InputMode())
+	assert.Nil(t, state.Autocomplete())
+	InsertText(state, "!")
+	assert.Equal(t, "foobar foobar!", textTree.String())
+	assert.Equal(t, uint64(14), buffer.cursor.position)
+}
+
+func TestAutocompleteNoCompletionsStatus(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc xy")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	state.documentBuffer.cursor.position = 6
+	EnterInsertMode(state)
+	StartAutocomplete(state, true)
+	assert.Equal(t, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  `No completions for "xy"`,
+	}, state.StatusMsg())
+}
+
+func TestBuildWordIndex(t *testing.T) {
+	testCases := []struct {
+		name          string
+		text          string
+		startPos      uint64
+		endPos        uint64
+		expectedWords []string
+	}{
+		{
+			name:          "empty document",
+			text:          "",
+			expectedWords: nil,
+		},
+		{
+			name:          "words after then before",
+			text:          "aa bb cc dd",
+			startPos:      3,
+			endPos:        5,
+			expectedWords: []string{"cc", "dd", "aa"},
+		},
+		{
+			name:          "duplicate words",
+			text:          "aa bb aa cc bb",
+			startPos:      6,
+			endPos:        8,
+			expectedWords: []string{"cc", "bb", "aa"},
+		},
+		{
+			name:          "punctuation separates words",
+			text:          "x foo.bar(baz_1)",
+			startPos:      0,
+			endPos:        1,
+			expectedWords: []string{"foo", "bar", "baz_1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.text)
+			require.NoError(t, err)
+			idx := buildWordIndex(textTree, tc.startPos, tc.endPos)
+			assert.Equal(t, tc.expectedWords, idx.words)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/nondet"
+)
+
+// SetBackupStore sets the store used to back up files before they are overwritten.
+func SetBackupStore(state *EditorState, store *file.BackupStore) {
+	state.backupStore = store
+}
+
+// backupBeforeSave copies the file on disk to the backup store before the document overwrites it.
+// Errors are logged, but do not prevent the save.
+func backupBeforeSave(state *EditorState, path string) {
+	if state.backupStore == nil {
+		return
+	}
+
+	if err := state.backupStore.BackupFile(path, nondet.Now()); err != nil {
+		log.Printf("Error backing up %q before save: %v\n", path, err)
+	}
+}
+
+// ShowBackupMenu displays a menu of backups of the current document, most recent first.
+// Selecting a backup shows how the document differs from it, with an option to restore it.
+func ShowBackupMenu(state *EditorState) error {
+	if state.backupStore == nil {
+		return errors.New("Backups are not available")
+	}
+
+	backups, err := state.backupStore.Backups(state.fileWatcher.Path())
+	if err != nil {
+		return err
+	} else if len(backups) == 0 {
+		return errors.New("No backups for this document")
+	}
+
+	items := make([]menu.Item, 0, len(backups))
+	for _, b := range backups {
+		items = append(items, menu.Item{
+			Name: fmt.Sprintf("backup from %s", formatBackupTime(b.Time)),
+			Action: func(s *EditorState) {
+				showBackupDiff(s, b)
+			},
+		})
+	}
+
+	ShowMenu(state, MenuStyleBackup, items)
+	return nil
+}
+
+// showBackupDiff displays a menu listing the changes to the document since the backup.
+// The first item restores the backup, and the other items move the cursor to a change.
+func showBackupDiff(state *EditorState, b file.Backup) {
+	backupText, err := b.Read()
+	if err != nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Could not read backup: %s", err),
+		})
+		return
+	}
+
+	hunks, origLines, newLines := diffHunksBetween(backupText, state.documentBuffer.textTree.String())
+	if len(hunks) == 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  fmt.Sprintf("Document matches backup from %s", formatBackupTime(b.Time)),
+		})
+		return
+	}
+
+	items := make([]menu.Item, 0, len(hunks)+1)
+	items = append(items, menu.Item{
+		Name: fmt.Sprintf("restore backup from %s (%d changes)", formatBackupTime(b.Time), len(hunks)),
+		Action: func(s *EditorState) {
+			restoreBackup(s, backupText, b.Time)
+		},
+	})
+	items = append(items, diffHunkMenuItems(hunks, origLines, newLines)...)
+	ShowMenu(state, MenuStyleBackup, items)
+}
+
+// restoreBackup replaces the document text with the backup.
+// This is a single undo entry and does not change the file on disk until the document is saved.
+func restoreBackup(state *EditorState, backupText string, backupTime time.Time) {
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return
+	}
+
+	lineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	BeginUndoEntry(state)
+	if _, err := replaceRunes(state, 0, buffer.textTree.NumChars(), backupText, true); err != nil {
+		log.Printf("Error restoring backup: %v\n", err)
+	}
+	CommitUndoEntry(state)
+
+	MoveCursor(state, func(p LocatorParams) uint64 {
+		return locate.StartOfLineNum(p.TextTree, lineNum)
+	})
+	ScrollViewToCursor(state)
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Restored backup from %s. Save the document to write it to disk", formatBackupTime(backupTime)),
+	})
+}
+
+// diffHunkMenuItems returns a menu item for each hunk that moves the cursor to the start of the change.
+func diffHunkMenuItems(hunks []diffHunk, origLines, newLines []string) []menu.Item {
+	menuItems := make([]menu.Item, 0, len(hunks))
+	for _, h := range hunks {
+		lineNum := h.newStartLine
+		menuItems = append(menuItems, menu.Item{
+			Name: formatDiffHunkName(h, origLines, newLines),
+			Action: func(s *EditorState) {
+				MoveCursor(s, func(p LocatorParams) uint64 {
+					return locate.StartOfLineNum(p.TextTree, lineNum)
+				})
+				ScrollViewToCursor(s)
+			},
+		})
+	}
+	return menuItems
+}
+
+func formatDiffHunkName(h diffHunk, origLines, newLines []string) string {
+	var snippet string
+	if h.newNumLines > 0 {
+		snippet = "+" + strings.TrimSpace(newLines[h.newStartLine])
+	} else {
+		snippet = "-" + strings.TrimSpace(origLines[h.origStartLine])
+	}
+	return fmt.Sprintf("%d: -%d +%d  %s", h.newStartLine+1, h.origNumLines, h.newNumLines, snippet)
+}
+
+func formatBackupTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/nondet"
+)
+
+func TestRestoreFromBackup(t *testing.T) {
+	restore := nondet.SetSource(nondet.NewDeterministicSource(time.Unix(1700000000, 0), time.Second, 0))
+	defer restore()
+
+	path, cleanup := createTestFile(t, "foo\nbar\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetBackupStore(state, file.NewBackupStore(t.TempDir()))
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	// Saving backs up the original contents of the file.
+	InsertText(state, "baz\n")
+	SaveDocument(state)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "baz\nfoo\nbar\n", string(data))
+
+	err = ShowBackupMenu(state)
+	require.NoError(t, err)
+	assert.Equal(t, MenuStyleBackup, state.Menu().Style())
+	results, _ := state.Menu().SearchResults()
+	require.Equal(t, 1, len(results))
+
+	// Selecting the backup shows the changes since the backup.
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, InputModeMenu, state.InputMode())
+	assert.Equal(t, MenuStyleBackup, state.Menu().Style())
+	results, _ = state.Menu().SearchResults()
+	require.Equal(t, 2, len(results))
+	assert.Contains(t, results[0].Name, "restore backup from")
+	assert.Equal(t, "1: -0 +1  +baz", results[1].Name)
+
+	// Restoring the backup changes the document, but not the file on disk.
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, "foo\nbar", state.documentBuffer.textTree.String())
+	assert.Contains(t, state.StatusMsg().Text, "Save the document to write it to disk")
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "baz\nfoo\nbar\n", string(data))
+
+	// Restoring is a single undoable edit.
+	Undo(state)
+	assert.Equal(t, "baz\nfoo\nbar", state.documentBuffer.textTree.String())
+}
+
+func TestShowBackupMenuNoBackups(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	assert.EqualError(t, ShowBackupMenu(state), "Backups are not available")
+
+	SetBackupStore(state, file.NewBackupStore(t.TempDir()))
+	assert.EqualError(t, ShowBackupMenu(state), "No backups for this document")
+}
@@ -0,0 +1,27 @@
+package state
+
+import (
+	"github.com/aretext/aretext/config"
+)
+
+// RingBell signals the user that the last input could not be handled,
+// for example because a key was not bound to any command.
+// The editor checks BellCount after each event to ring the terminal bell or flash the status bar,
+// depending on the configured bell. If the bell is disabled, this does nothing.
+func RingBell(state *EditorState) {
+	if state.bell == config.BellNone || state.bell == "" {
+		return
+	}
+	state.bellCount++
+}
+
+// Bell returns the configured bell ("none", "audible", or "visual").
+func (s *EditorState) Bell() string {
+	return s.bell
+}
+
+// BellCount returns the number of times the bell has rung.
+// This allows the editor to detect when it needs to ring the bell.
+func (s *EditorState) BellCount() int {
+	return s.bellCount
+}
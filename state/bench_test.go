@@ -0,0 +1,188 @@
+package state
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/text"
+)
+
+// benchCorpusSearchTerm appears rarely in generated corpora, so searching for it scans most of the document.
+const benchCorpusSearchTerm = "needle"
+
+// benchCorpusSeed makes the generated corpora and edit positions the same on every run,
+// so results can be compared between commits.
+const benchCorpusSeed = 1337
+
+var benchCorpusVocabulary = []string{
+	"the", "quick", "brown", "fox", "jumps", "over", "lazy", "dog", "lorem", "ipsum",
+	"dolor", "sit", "amet", "func", "return", "if", "else", "for", "range", "struct",
+	"αβγ", "日本語", "naïve", "café", "{", "}", "(", ")", "=", "+=",
+}
+
+type benchCorpus struct {
+	name       string
+	numLines   int
+	lineLength int
+}
+
+// benchCorpora are the generated documents used by the corpus benchmarks.
+var benchCorpora = []benchCorpus{
+	{name: "1k lines x 80", numLines: 1_000, lineLength: 80},
+	{name: "100k lines x 80", numLines: 100_000, lineLength: 80},
+	{name: "1k lines x 4000", numLines: 1_000, lineLength: 4_000},
+	{name: "1 line x 1M", numLines: 1, lineLength: 1_000_000},
+	{name: "1M lines x 8", numLines: 1_000_000, lineLength: 8},
+}
+
+// generate returns a document of words separated by spaces, with a blank line after every ten lines
+// so the document has paragraphs. The search term appears about once every 80,000 characters.
+func (c benchCorpus) generate() string {
+	rng := rand.New(rand.NewSource(benchCorpusSeed))
+	var sb strings.Builder
+	for i := 0; i < c.numLines; i++ {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+
+		if i%10 == 9 {
+			continue
+		}
+
+		var lineLen int
+		for lineLen < c.lineLength {
+			if lineLen > 0 {
+				sb.WriteByte(' ')
+				lineLen++
+			}
+			word := benchCorpusVocabulary[rng.Intn(len(benchCorpusVocabulary))]
+			if rng.Intn(16_000) == 0 {
+				word = benchCorpusSearchTerm
+			}
+			sb.WriteString(word)
+			lineLen += len([]rune(word))
+		}
+	}
+	return sb.String()
+}
+
+// writeFile writes the generated corpus to a temporary file and returns its path.
+func (c benchCorpus) writeFile(b *testing.B) string {
+	path := filepath.Join(b.TempDir(), "corpus.txt")
+	err := os.WriteFile(path, []byte(c.generate()+"\n"), 0644)
+	require.NoError(b, err)
+	return path
+}
+
+// loadBenchCorpus returns an editor state with the corpus loaded from a file.
+func loadBenchCorpus(b *testing.B, c benchCorpus) *EditorState {
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, c.writeFile(b), true, startOfDocLocator)
+	b.Cleanup(state.fileWatcher.Stop)
+	return state
+}
+
+func runCorpusBenchmarks(b *testing.B, f func(b *testing.B, c benchCorpus)) {
+	for _, c := range benchCorpora {
+		b.Run(c.name, func(b *testing.B) {
+			f(b, c)
+		})
+	}
+}
+
+func BenchmarkCorpusOpen(b *testing.B) {
+	runCorpusBenchmarks(b, func(b *testing.B, c benchCorpus) {
+		path := c.writeFile(b)
+		state := NewEditorState(100, 100, nil, nil)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			LoadDocument(state, path, true, startOfDocLocator)
+			state.fileWatcher.Stop()
+		}
+	})
+}
+
+func BenchmarkCorpusInsertAtRandomPositions(b *testing.B) {
+	runCorpusBenchmarks(b, func(b *testing.B, c benchCorpus) {
+		state := loadBenchCorpus(b, c)
+		rng := rand.New(rand.NewSource(benchCorpusSeed))
+		positions := make([]uint64, 1024)
+		for i := range positions {
+			positions[i] = uint64(rng.Int63n(int64(state.documentBuffer.textTree.NumChars())))
+		}
+
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			pos := positions[n%len(positions)]
+			MoveCursor(state, func(LocatorParams) uint64 { return pos })
+			InsertRune(state, 'x')
+		}
+	})
+}
+
+// BenchmarkCorpusJumpToStartAndEnd measures the work done on each keystroke for "gg" and "G",
+// including the cursor info shown in the status bar ruler.
+func BenchmarkCorpusJumpToStartAndEnd(b *testing.B) {
+	runCorpusBenchmarks(b, func(b *testing.B, c benchCorpus) {
+		state := loadBenchCorpus(b, c)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			MoveCursor(state, func(p LocatorParams) uint64 {
+				if n%2 == 0 {
+					return locate.StartOfLastLine(p.TextTree)
+				}
+				return locate.StartOfLineNum(p.TextTree, 0)
+			})
+			ScrollViewToCursor(state)
+			state.documentBuffer.CursorInfo()
+		}
+	})
+}
+
+func BenchmarkCorpusSearch(b *testing.B) {
+	runCorpusBenchmarks(b, func(b *testing.B, c benchCorpus) {
+		state := loadBenchCorpus(b, c)
+		query := parseQuery(benchCorpusSearchTerm)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			findAllMatches(state.documentBuffer.textTree, query)
+		}
+	})
+}
+
+func BenchmarkCorpusWrap(b *testing.B) {
+	runCorpusBenchmarks(b, func(b *testing.B, c benchCorpus) {
+		corpusText := c.generate()
+		for _, maxColumns := range []uint64{40, 120} {
+			b.Run(fmt.Sprintf("%d columns", maxColumns), func(b *testing.B) {
+				for n := 0; n < b.N; n++ {
+					b.StopTimer()
+					state := NewEditorState(100, 100, nil, nil)
+					textTree, err := text.NewTreeFromString(corpusText)
+					require.NoError(b, err)
+					state.documentBuffer.textTree = textTree
+					b.StartTimer()
+					WrapDocument(state, maxColumns)
+				}
+			})
+		}
+	})
+}
+
+func BenchmarkCorpusSave(b *testing.B) {
+	runCorpusBenchmarks(b, func(b *testing.B, c benchCorpus) {
+		state := loadBenchCorpus(b, c)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			InsertRune(state, 'x')
+			SaveDocument(state)
+		}
+	})
+}
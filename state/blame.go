@@ -0,0 +1,219 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/aretext/aretext/blame"
+	"github.com/aretext/aretext/nondet"
+)
+
+// blameFileFunc runs git blame on a file. Tests may replace this to avoid depending on a git repository.
+var blameFileFunc = blame.File
+
+// commitMessageFunc retrieves the full message of a commit. Tests may replace this as well.
+var commitMessageFunc = blame.CommitMessage
+
+// unsavedLineCommit is reported for lines edited since the document was last saved.
+// Like lines with uncommitted changes on disk, these have a hash of all zeros.
+var unsavedLineCommit = &blame.Commit{Hash: "0000000000000000000000000000000000000000"}
+
+// blameState caches the git blame annotations for a document.
+// Annotations describe the file on disk, so they are discarded whenever the document is saved or reloaded.
+type blameState struct {
+	loaded     bool
+	commits    []*blame.Commit         // Keyed by line number in the saved document.
+	resultChan chan func(*EditorState) // Non-nil while loading in the background.
+}
+
+// invalidateBlame discards the git blame annotations after the document is saved or reloaded.
+func invalidateBlame(buffer *BufferState) {
+	buffer.blame = blameState{}
+}
+
+// ShowBlame returns whether to show git blame annotations at the end of each line.
+func (s *BufferState) ShowBlame() bool {
+	return s.showBlame
+}
+
+// BlameAnnotation returns the short hash, author, and age of the commit that last changed a line.
+// It returns an empty string if the annotations are hidden, still loading, or unavailable for the line.
+func (s *BufferState) BlameAnnotation(lineNum uint64) string {
+	if !s.showBlame {
+		return ""
+	}
+	c := blameCommitForLine(s, lineNum)
+	if c == nil {
+		return ""
+	}
+	return blame.Annotation(c, nondet.Now())
+}
+
+// BlameResultChan returns a channel that receives an action when
+// the git blame annotations for the active document finish loading.
+// It returns nil if no annotations are loading.
+func (s *EditorState) BlameResultChan() chan func(*EditorState) {
+	return s.documentBuffer.blame.resultChan
+}
+
+// ToggleShowBlame shows or hides git blame annotations at the end of each line.
+func ToggleShowBlame(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.showBlame {
+		buffer.showBlame = false
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "Hiding git blame",
+		})
+		return
+	}
+
+	if state.fileWatcher.Path() == "" || buffer.scratch {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Cannot show git blame for a document without a file",
+		})
+		return
+	}
+
+	buffer.showBlame = true
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  "Showing git blame",
+	})
+	LoadBlameIfNeeded(state)
+}
+
+// LoadBlameIfNeeded starts loading git blame annotations in the background if they are shown
+// for the active document, but have not been loaded since the document was last saved or reloaded.
+// The main event loop calls this after every event, so annotations reload after the file changes.
+func LoadBlameIfNeeded(state *EditorState) {
+	buffer := state.documentBuffer
+	if !buffer.showBlame || buffer.loading || buffer.blame.loaded || buffer.blame.resultChan != nil {
+		return
+	}
+
+	path := state.fileWatcher.Path()
+	log.Printf("Loading git blame for %q in the background\n", path)
+
+	// The channel is buffered so the goroutine can exit even if the result is discarded.
+	resultChan := make(chan func(*EditorState), 1)
+	buffer.blame.resultChan = resultChan
+	go func() {
+		commits, err := blameFileFunc(context.Background(), path)
+		resultChan <- func(state *EditorState) {
+			completeBlameLoad(state, buffer, resultChan, path, commits, err)
+		}
+	}()
+}
+
+// completeBlameLoad stores the annotations for a document, unless the document changed on disk while they were loading.
+func completeBlameLoad(state *EditorState, buffer *BufferState, resultChan chan func(*EditorState), path string, commits []*blame.Commit, err error) {
+	if buffer.blame.resultChan != resultChan {
+		log.Printf("Discarding outdated git blame for %q\n", path)
+		return
+	}
+	buffer.blame.resultChan = nil
+
+	if err != nil {
+		log.Printf("Error loading git blame for %q: %v\n", path, err)
+		buffer.showBlame = false
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Could not load git blame: %s", err),
+		})
+		return
+	}
+
+	log.Printf("Loaded git blame for %d lines of %q\n", len(commits), path)
+	buffer.blame.loaded = true
+	buffer.blame.commits = commits
+}
+
+// blameCommitForLine returns the commit that last changed a line in the document,
+// or nil if the annotations haven't loaded or git reported no commit for the line.
+func blameCommitForLine(buffer *BufferState, lineNum uint64) *blame.Commit {
+	if !buffer.blame.loaded {
+		return nil
+	}
+
+	savedLineNum, ok := savedLineNumForLine(buffer, lineNum)
+	if !ok {
+		return unsavedLineCommit
+	} else if savedLineNum >= uint64(len(buffer.blame.commits)) {
+		return nil
+	}
+	return buffer.blame.commits[savedLineNum]
+}
+
+// savedLineNumForLine returns the line number in the saved document for a line in the document.
+// It returns false if the line was inserted or modified since the document was last saved.
+// Like the diff gutter, this uses the line changes from the last comparison with the saved document,
+// so annotations may lag behind edits until the next comparison completes.
+func savedLineNumForLine(buffer *BufferState, lineNum uint64) (uint64, bool) {
+	// Lines after each hunk shift by the difference between the number of lines deleted and inserted.
+	savedLineNum := lineNum
+	for _, h := range buffer.lineChanges.hunks {
+		if lineNum < h.newStartLine {
+			break
+		} else if lineNum < h.newStartLine+h.newNumLines {
+			return 0, false
+		}
+		savedLineNum = savedLineNum + h.origNumLines - h.newNumLines
+	}
+	return savedLineNum, true
+}
+
+// ShowCommitForCursorLine displays the full message of the commit that last changed the line under the cursor.
+// The commit opens in a read-only buffer, which can be closed to return to the document.
+func ShowCommitForCursorLine(state *EditorState) {
+	buffer := state.documentBuffer
+	if !buffer.showBlame || !buffer.blame.loaded {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  `Git blame is not loaded. Use "toggle git blame" to load it`,
+		})
+		return
+	}
+
+	updateLineChangesNow(buffer)
+	lineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	c := blameCommitForLine(buffer, lineNum)
+	if c == nil || c.Uncommitted() {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Line has not been committed",
+		})
+		return
+	}
+
+	dir := filepath.Dir(state.fileWatcher.Path())
+	hash, name := c.Hash, "commit "+c.ShortHash()
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		msg, err := commitMessageFunc(ctx, dir, hash)
+		return func(state *EditorState) {
+			if err != nil {
+				SetStatusMsg(state, StatusMsg{
+					Style: StatusMsgStyleError,
+					Text:  fmt.Sprintf("Could not show commit: %s", err),
+				})
+				return
+			}
+			showCommitMessage(state, name, msg)
+		}
+	})
+}
+
+// showCommitMessage opens a commit message in a read-only buffer, or switches to the buffer if it is already open.
+func showCommitMessage(state *EditorState, name string, msg string) {
+	startOfDoc := func(LocatorParams) uint64 { return 0 }
+	if !switchToOpenBufferForPath(state, name, startOfDoc) {
+		LoadPagerText(state, name, msg, startOfDoc)
+	}
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf(`Showing %s. Use "buffer close" to return to the document`, name),
+	})
+}
@@ -0,0 +1,189 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/blame"
+	"github.com/aretext/aretext/nondet"
+)
+
+// withFakeBlame replaces git blame with a function that returns the given commits and error,
+// counting the number of times blame runs.
+func withFakeBlame(t *testing.T, commits []*blame.Commit, err error) (numCalls *int) {
+	numCalls = new(int)
+	origBlameFunc := blameFileFunc
+	blameFileFunc = func(ctx context.Context, path string) ([]*blame.Commit, error) {
+		*numCalls++
+		return commits, err
+	}
+	t.Cleanup(func() { blameFileFunc = origBlameFunc })
+
+	restore := nondet.SetSource(nondet.NewDeterministicSource(time.Unix(1700000000, 0).Add(72*time.Hour), 0, 0))
+	t.Cleanup(restore)
+	return numCalls
+}
+
+func completeBlameLoadForTest(t *testing.T, state *EditorState) {
+	resultChan := state.BlameResultChan()
+	require.NotNil(t, resultChan)
+	select {
+	case action := <-resultChan:
+		action(state)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Timed out waiting for git blame")
+	}
+}
+
+func testBlameCommits() []*blame.Commit {
+	alice := &blame.Commit{Hash: "aaaaaaa111", Author: "Alice", AuthorTime: time.Unix(1700000000, 0)}
+	bob := &blame.Commit{Hash: "bbbbbbb222", Author: "Bob", AuthorTime: time.Unix(1700000000, 0).Add(48 * time.Hour)}
+	return []*blame.Commit{alice, bob, alice}
+}
+
+func TestToggleShowBlame(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\nbaz")
+	defer cleanup()
+	numCalls := withFakeBlame(t, testBlameCommits(), nil)
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	ToggleShowBlame(state)
+	assert.True(t, state.documentBuffer.ShowBlame())
+	assert.Equal(t, "Showing git blame", state.StatusMsg().Text)
+	assert.Equal(t, "", state.documentBuffer.BlameAnnotation(0))
+
+	completeBlameLoadForTest(t, state)
+	assert.Nil(t, state.BlameResultChan())
+	assert.Equal(t, "aaaaaaa Alice, 3 days ago", state.documentBuffer.BlameAnnotation(0))
+	assert.Equal(t, "bbbbbbb Bob, 1 day ago", state.documentBuffer.BlameAnnotation(1))
+	assert.Equal(t, "aaaaaaa Alice, 3 days ago", state.documentBuffer.BlameAnnotation(2))
+	assert.Equal(t, "", state.documentBuffer.BlameAnnotation(3))
+
+	// The annotations are cached.
+	LoadBlameIfNeeded(state)
+	assert.Nil(t, state.BlameResultChan())
+	assert.Equal(t, 1, *numCalls)
+
+	ToggleShowBlame(state)
+	assert.False(t, state.documentBuffer.ShowBlame())
+	assert.Equal(t, "Hiding git blame", state.StatusMsg().Text)
+	assert.Equal(t, "", state.documentBuffer.BlameAnnotation(0))
+}
+
+func TestBlameAnnotationAfterEdit(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\nbaz")
+	defer cleanup()
+	numCalls := withFakeBlame(t, testBlameCommits(), nil)
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	ToggleShowBlame(state)
+	completeBlameLoadForTest(t, state)
+
+	// Insert a line at the start of the document, so the saved lines shift down.
+	BeginUndoEntry(state)
+	InsertText(state, "new\n")
+	CommitUndoEntry(state)
+	updateLineChanges(t, state)
+	assert.Equal(t, "Not committed yet", state.documentBuffer.BlameAnnotation(0))
+	assert.Equal(t, "aaaaaaa Alice, 3 days ago", state.documentBuffer.BlameAnnotation(1))
+	assert.Equal(t, "bbbbbbb Bob, 1 day ago", state.documentBuffer.BlameAnnotation(2))
+
+	// Saving the document reloads the annotations.
+	SaveDocument(state)
+	assert.Equal(t, "", state.documentBuffer.BlameAnnotation(0))
+	LoadBlameIfNeeded(state)
+	completeBlameLoadForTest(t, state)
+	assert.Equal(t, 2, *numCalls)
+}
+
+func TestToggleShowBlameError(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo")
+	defer cleanup()
+	withFakeBlame(t, nil, errors.New("git blame: fatal: not a git repository"))
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	ToggleShowBlame(state)
+	completeBlameLoadForTest(t, state)
+
+	assert.False(t, state.documentBuffer.ShowBlame())
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "Could not load git blame: git blame: fatal: not a git repository", state.StatusMsg().Text)
+}
+
+func TestToggleShowBlameWithoutFile(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	ToggleShowBlame(state)
+	assert.False(t, state.documentBuffer.ShowBlame())
+	assert.Equal(t, "Cannot show git blame for a document without a file", state.StatusMsg().Text)
+}
+
+func TestShowCommitForCursorLine(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\nbaz")
+	defer cleanup()
+	withFakeBlame(t, testBlameCommits(), nil)
+
+	var requestedHash string
+	origCommitMessageFunc := commitMessageFunc
+	commitMessageFunc = func(ctx context.Context, dir string, hash string) (string, error) {
+		requestedHash = hash
+		return "commit bbbbbbb222\nAuthor: Bob\n\n    Fix bar\n", nil
+	}
+	defer func() { commitMessageFunc = origCommitMessageFunc }()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	ShowCommitForCursorLine(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Contains(t, state.StatusMsg().Text, "Git blame is not loaded")
+
+	ToggleShowBlame(state)
+	completeBlameLoadForTest(t, state)
+	MoveCursor(state, func(LocatorParams) uint64 { return 5 })
+	ShowCommitForCursorLine(state)
+	action := <-state.TaskResultChan()
+	action(state)
+
+	assert.Equal(t, "bbbbbbb222", requestedHash)
+	assert.Equal(t, "commit bbbbbbb", state.fileWatcher.Path())
+	assert.Equal(t, "commit bbbbbbb222\nAuthor: Bob\n\n    Fix bar\n", state.documentBuffer.textTree.String())
+	assert.True(t, state.documentBuffer.ReadOnly())
+	assert.Equal(t, `Showing commit bbbbbbb. Use "buffer close" to return to the document`, state.StatusMsg().Text)
+
+	// Closing the buffer returns to the document.
+	CloseBuffer(state)
+	assert.Equal(t, path, state.fileWatcher.Path())
+}
+
+func TestShowCommitForUnsavedLine(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo")
+	defer cleanup()
+	withFakeBlame(t, []*blame.Commit{{Hash: "aaaaaaa111", Author: "Alice"}}, nil)
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	ToggleShowBlame(state)
+	completeBlameLoadForTest(t, state)
+
+	BeginUndoEntry(state)
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+	ShowCommitForCursorLine(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "Line has not been committed", state.StatusMsg().Text)
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/menu"
+)
+
+// SetBookmarkStore sets the store used to save bookmarks for each project.
+func SetBookmarkStore(state *EditorState, store *file.BookmarkStore) {
+	state.bookmarkStore = store
+}
+
+// loadBookmarksForPath loads the bookmarks for a document in the current working directory.
+func loadBookmarksForPath(state *EditorState, path string) map[uint64]file.Bookmark {
+	if state.bookmarkStore == nil {
+		return nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Printf("Error getting working directory to load bookmarks: %v\n", err)
+		return nil
+	}
+
+	bookmarks, err := state.bookmarkStore.BookmarksForPath(dir, path)
+	if err != nil {
+		log.Printf("Error loading bookmarks for %q: %v\n", path, err)
+		return nil
+	}
+	return bookmarks
+}
+
+// BookmarkNoteOnCursorLine returns the note of the bookmark on the cursor's line, if any.
+// This allows the user to edit an existing note.
+func BookmarkNoteOnCursorLine(state *EditorState) (note string, lineNum uint64, ok bool) {
+	buffer := state.documentBuffer
+	lineNum = buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	b, ok := buffer.bookmarks[lineNum]
+	return b.Note, lineNum, ok
+}
+
+// BookmarkCursorLine bookmarks the cursor's line with an optional note.
+// If the line is already bookmarked, this replaces the note.
+func BookmarkCursorLine(state *EditorState, note string) error {
+	if state.bookmarkStore == nil {
+		return errors.New("Bookmarks are not available")
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("os.Getwd: %w", err)
+	}
+
+	buffer := state.documentBuffer
+	path := state.fileWatcher.Path()
+	lineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	note = strings.TrimSpace(note)
+	if err := state.bookmarkStore.SetBookmark(dir, path, lineNum, note); err != nil {
+		return err
+	}
+
+	buffer.bookmarks = loadBookmarksForPath(state, path)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Bookmarked line %d", lineNum+1),
+	})
+	return nil
+}
+
+// RemoveBookmarkOnCursorLine removes the bookmark on the cursor's line.
+func RemoveBookmarkOnCursorLine(state *EditorState) error {
+	if state.bookmarkStore == nil {
+		return errors.New("Bookmarks are not available")
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("os.Getwd: %w", err)
+	}
+
+	buffer := state.documentBuffer
+	path := state.fileWatcher.Path()
+	lineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	removed, err := state.bookmarkStore.RemoveBookmark(dir, path, lineNum)
+	if err != nil {
+		return err
+	} else if !removed {
+		return fmt.Errorf("No bookmark on line %d", lineNum+1)
+	}
+
+	buffer.bookmarks = loadBookmarksForPath(state, path)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Removed bookmark on line %d", lineNum+1),
+	})
+	return nil
+}
+
+// ShowBookmarkMenu displays a menu of bookmarks in the current working directory, with their notes.
+// Selecting a bookmark opens the file at the bookmarked line.
+func ShowBookmarkMenu(state *EditorState) error {
+	if state.bookmarkStore == nil {
+		return errors.New("Bookmarks are not available")
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("os.Getwd: %w", err)
+	}
+
+	bookmarks, err := state.bookmarkStore.Bookmarks(dir)
+	if err != nil {
+		return err
+	} else if len(bookmarks) == 0 {
+		return errors.New("No bookmarks")
+	}
+
+	items := make([]menu.Item, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		path, lineNum := b.AbsPath(dir), b.LineNum
+		items = append(items, menu.Item{
+			Name: formatBookmarkName(b),
+			Action: func(s *EditorState) {
+				goToBookmark(s, path, lineNum)
+			},
+		})
+	}
+
+	ShowMenu(state, MenuStyleBookmark, items)
+	return nil
+}
+
+func formatBookmarkName(b file.Bookmark) string {
+	if b.Note == "" {
+		return fmt.Sprintf("%s:%d", b.Path, b.LineNum+1)
+	}
+	return fmt.Sprintf("%s:%d  %s", b.Path, b.LineNum+1, b.Note)
+}
+
+func goToBookmark(state *EditorState, path string, lineNum uint64) {
+	moveToLine := func(p LocatorParams) uint64 {
+		return locate.StartOfLineNum(p.TextTree, lineNum)
+	}
+
+	if currentPath, err := filepath.Abs(state.fileWatcher.Path()); err == nil && currentPath == path {
+		MoveCursor(state, moveToLine)
+		ScrollViewToCursor(state)
+		return
+	}
+
+	LoadDocument(state, path, true, moveToLine)
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+func TestBookmarkCursorLine(t *testing.T) {
+	withTempDirPaths(t, []string{"foo.txt"}, func(dir string) {
+		state := NewEditorState(100, 100, nil, nil)
+		SetBookmarkStore(state, file.NewBookmarkStore(t.TempDir()))
+		LoadDocument(state, "foo.txt", true, startOfDocLocator)
+		defer state.fileWatcher.Stop()
+
+		assert.False(t, state.documentBuffer.IsLineBookmarked(0))
+
+		err := BookmarkCursorLine(state, "  check this  ")
+		require.NoError(t, err)
+		assert.True(t, state.documentBuffer.IsLineBookmarked(0))
+		assert.Equal(t, "Bookmarked line 1", state.StatusMsg().Text)
+
+		note, lineNum, ok := BookmarkNoteOnCursorLine(state)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(0), lineNum)
+		assert.Equal(t, "check this", note)
+
+		// Bookmarks are loaded again with the document.
+		ReloadDocument(state)
+		defer state.fileWatcher.Stop()
+		assert.True(t, state.documentBuffer.IsLineBookmarked(0))
+
+		err = RemoveBookmarkOnCursorLine(state)
+		require.NoError(t, err)
+		assert.False(t, state.documentBuffer.IsLineBookmarked(0))
+		assert.Equal(t, "Removed bookmark on line 1", state.StatusMsg().Text)
+
+		err = RemoveBookmarkOnCursorLine(state)
+		assert.EqualError(t, err, "No bookmark on line 1")
+	})
+}
+
+func TestBookmarksNotAvailable(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	assert.EqualError(t, BookmarkCursorLine(state, ""), "Bookmarks are not available")
+	assert.EqualError(t, RemoveBookmarkOnCursorLine(state), "Bookmarks are not available")
+	assert.EqualError(t, ShowBookmarkMenu(state), "Bookmarks are not available")
+}
+
+func TestShowBookmarkMenu(t *testing.T) {
+	withTempDirPaths(t, []string{"foo.txt", "bar.txt"}, func(dir string) {
+		state := NewEditorState(100, 100, nil, nil)
+		SetBookmarkStore(state, file.NewBookmarkStore(t.TempDir()))
+		LoadDocument(state, "foo.txt", true, startOfDocLocator)
+		defer state.fileWatcher.Stop()
+
+		err := ShowBookmarkMenu(state)
+		assert.EqualError(t, err, "No bookmarks")
+
+		err = BookmarkCursorLine(state, "")
+		require.NoError(t, err)
+
+		LoadDocument(state, "bar.txt", true, startOfDocLocator)
+		defer state.fileWatcher.Stop()
+		err = BookmarkCursorLine(state, "important")
+		require.NoError(t, err)
+
+		err = ShowBookmarkMenu(state)
+		require.NoError(t, err)
+		assert.Equal(t, InputModeMenu, state.InputMode())
+		assert.Equal(t, MenuStyleBookmark, state.Menu().Style())
+
+		var names []string
+		results, _ := state.Menu().SearchResults()
+		for _, item := range results {
+			names = append(names, item.Name)
+		}
+		assert.Equal(t, []string{"bar.txt:1  important", "foo.txt:1"}, names)
+
+		// Selecting a bookmark in another file opens that file.
+		MoveMenuSelection(state, 1)
+		ExecuteSelectedMenuItem(state)
+		defer state.fileWatcher.Stop()
+		assert.Equal(t, "foo.txt content", state.documentBuffer.textTree.String())
+		assert.True(t, state.documentBuffer.IsLineBookmarked(0))
+	})
+}
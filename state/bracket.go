@@ -0,0 +1,37 @@
+package state
+
+import (
+	"github.com/aretext/aretext/locate"
+)
+
+// maxMatchingBracketDistance limits how far to search for a matching bracket,
+// so drawing stays fast when the cursor is on an unmatched bracket in a large document.
+const maxMatchingBracketDistance = 1 << 16
+
+// MatchingBracketPositions returns the position of the bracket, paren, or brace at the cursor and the position of its match.
+// If the cursor is not on a bracket, this checks the character before the cursor, which is where a bracket was just typed in insert mode.
+// The last return value is false if the option is disabled or there is no matching bracket.
+func (s *BufferState) MatchingBracketPositions() (uint64, uint64, bool) {
+	if !s.showMatchingBracket {
+		return 0, 0, false
+	}
+
+	pos := s.cursor.position
+	if !isBracketAtPos(s, pos) {
+		if pos == 0 || !isBracketAtPos(s, pos-1) {
+			return 0, 0, false
+		}
+		pos--
+	}
+
+	matchPos, ok := locate.MatchingCodeBlockDelimiterWithinDistance(s.textTree, s.syntaxParser, pos, maxMatchingBracketDistance)
+	return pos, matchPos, ok
+}
+
+// isBracketAtPos returns whether there is a bracket, paren, or brace at a position.
+// Angle brackets are excluded because they are usually comparison operators.
+func isBracketAtPos(s *BufferState, pos uint64) bool {
+	reader := s.textTree.ReaderAtPosition(pos)
+	r, _, err := reader.ReadRune()
+	return err == nil && (locate.ParenPair.MatchRune(r) || locate.BracketPair.MatchRune(r) || locate.BracePair.MatchRune(r))
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchingBracketPositions(t *testing.T) {
+	testCases := []struct {
+		name          string
+		text          string
+		cursorPos     uint64
+		expectOk      bool
+		expectBracket uint64
+		expectMatch   uint64
+	}{
+		{
+			name:      "empty document",
+			text:      "",
+			cursorPos: 0,
+		},
+		{
+			name:      "not on or after bracket",
+			text:      "(abc)",
+			cursorPos: 2,
+		},
+		{
+			name:          "on open paren",
+			text:          "f(abc)",
+			cursorPos:     1,
+			expectOk:      true,
+			expectBracket: 1,
+			expectMatch:   5,
+		},
+		{
+			name:          "on close brace",
+			text:          "{abc}",
+			cursorPos:     4,
+			expectOk:      true,
+			expectBracket: 4,
+			expectMatch:   0,
+		},
+		{
+			name:          "after close bracket",
+			text:          "[abc] x",
+			cursorPos:     5,
+			expectOk:      true,
+			expectBracket: 4,
+			expectMatch:   0,
+		},
+		{
+			name:          "at end of document after close paren",
+			text:          "(abc)",
+			cursorPos:     5,
+			expectOk:      true,
+			expectBracket: 4,
+			expectMatch:   0,
+		},
+		{
+			name:          "bracket at cursor takes priority",
+			text:          "(a)(b)",
+			cursorPos:     3,
+			expectOk:      true,
+			expectBracket: 3,
+			expectMatch:   5,
+		},
+		{
+			name:      "unmatched bracket",
+			text:      "(abc",
+			cursorPos: 0,
+		},
+		{
+			name:      "angle brackets ignored",
+			text:      "<abc>",
+			cursorPos: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := NewEditorState(100, 100, nil, nil)
+			InsertText(state, tc.text)
+			state.documentBuffer.cursor = cursorState{position: tc.cursorPos}
+			bracketPos, matchPos, ok := state.documentBuffer.MatchingBracketPositions()
+			assert.Equal(t, tc.expectOk, ok)
+			if ok {
+				assert.Equal(t, tc.expectBracket, bracketPos)
+				assert.Equal(t, tc.expectMatch, matchPos)
+			}
+		})
+	}
+}
+
+func TestMatchingBracketPositionsDisabled(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "(abc)")
+	state.documentBuffer.cursor = cursorState{position: 0}
+	_, _, ok := state.documentBuffer.MatchingBracketPositions()
+	assert.True(t, ok)
+
+	ToggleShowMatchingBracket(state)
+	_, _, ok = state.documentBuffer.MatchingBracketPositions()
+	assert.False(t, ok)
+}
@@ -0,0 +1,333 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+	"github.com/aretext/aretext/undo"
+)
+
+// openBuffer is a document held open in the editor.
+// For the active document, EditorState.documentBuffer and EditorState.fileWatcher are authoritative,
+// and the entry is updated only when switching to another document.
+type openBuffer struct {
+	buffer  *BufferState
+	watcher *file.Watcher
+}
+
+// newBufferState returns an empty buffer with the given view size.
+func newBufferState(viewWidth, viewHeight uint64) *BufferState {
+	return &BufferState{
+		textTree: text.NewTree(),
+		cursor:   cursorState{},
+		selector: &selection.Selector{},
+		view: viewState{
+			textOrigin: 0,
+			width:      viewWidth,
+			height:     viewHeight,
+		},
+		search:              searchState{},
+		undoLog:             undo.NewLog(),
+		syntaxLanguage:      syntax.LanguagePlaintext,
+		syntaxBackend:       config.DefaultSyntaxBackend,
+		syntaxParser:        nil,
+		lineNumberMode:      config.DefaultLineNumberMode,
+		tabSize:             uint64(config.DefaultTabSize),
+		tabExpand:           config.DefaultTabExpand,
+		showSpaces:          config.DefaultShowSpaces,
+		showTabs:            config.DefaultShowTabs,
+		autoIndent:          config.DefaultAutoIndent,
+		showRuler:           config.DefaultShowRuler,
+		showDiffGutter:      config.DefaultShowDiffGutter,
+		showScrollbar:       config.DefaultShowScrollbar,
+		showMatchingBracket: config.DefaultShowMatchingBracket,
+		showAnsiColors:      config.DefaultShowAnsiColors,
+		showKeyHints:        config.DefaultShowKeyHints,
+		originalText:        textSnapshot{ok: true},
+		savedText:           textSnapshot{ok: true},
+		textFormat:          file.DefaultTextFormat,
+	}
+}
+
+// NumOpenBuffers returns the number of documents open in the editor, including the active document.
+func (s *EditorState) NumOpenBuffers() int {
+	return len(s.openBuffers)
+}
+
+// activeBufferIdx returns the index of the active document in the list of open buffers.
+func activeBufferIdx(state *EditorState) int {
+	for i, b := range state.openBuffers {
+		if b.buffer == state.documentBuffer {
+			return i
+		}
+	}
+	panic("Active document is not in the list of open buffers") // Should never happen.
+}
+
+// openBufferIdxForPath returns the index of the open buffer for a path, or -1 if no buffer has the path.
+func openBufferIdxForPath(state *EditorState, path string) int {
+	activeIdx := activeBufferIdx(state)
+	for i, b := range state.openBuffers {
+		watcher := b.watcher
+		if i == activeIdx {
+			watcher = state.fileWatcher
+		}
+		if watcher.Path() == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// prepareBufferForDocument ensures the active buffer can be replaced by a document at path.
+// If the path differs from the active document, and the active document is worth keeping,
+// the active document moves to the background and a new, empty buffer becomes active.
+func prepareBufferForDocument(state *EditorState, path string) {
+	if path == state.fileWatcher.Path() || isActiveBufferDisposable(state) {
+		return
+	}
+
+	idx := activeBufferIdx(state)
+	stashScratchBuffer(state, state.documentBuffer)
+	state.openBuffers[idx] = openBuffer{buffer: state.documentBuffer, watcher: state.fileWatcher}
+	oldView := state.documentBuffer.view
+	state.documentBuffer = newBufferState(oldView.width, oldView.height)
+	state.fileWatcher = file.NewEmptyWatcher()
+	state.openBuffers = append(state.openBuffers, openBuffer{buffer: state.documentBuffer})
+}
+
+// isActiveBufferDisposable returns whether the active document can be replaced without keeping it open.
+// This is true for the empty document when the editor starts, documents still loading,
+// and unedited documents whose file doesn't exist (for example, a new document that was never saved,
+// or a document whose file was moved).
+func isActiveBufferDisposable(state *EditorState) bool {
+	buffer := state.documentBuffer
+	path := state.fileWatcher.Path()
+	if path == "" || buffer.loading {
+		return true
+	} else if buffer.scratch || buffer.undoLog.HasUnsavedChanges() {
+		return false
+	}
+	_, err := os.Stat(path)
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// switchToOpenBufferForPath activates the open buffer for a path, returning false if no buffer has the path.
+// The cursor moves to cursorLoc, as if the document had been loaded.
+func switchToOpenBufferForPath(state *EditorState, path string, cursorLoc Locator) bool {
+	idx := openBufferIdxForPath(state, path)
+	if idx < 0 || idx == activeBufferIdx(state) {
+		return false
+	}
+
+	switchToBuffer(state, idx)
+	MoveCursor(state, cursorLoc)
+	ScrollViewToCursor(state)
+	return true
+}
+
+// switchToBuffer moves the active document to the background and activates another open buffer.
+// The switch is recorded in the timeline, so "open previous document" returns to the original document.
+func switchToBuffer(state *EditorState, idx int) {
+	if idx == activeBufferIdx(state) {
+		return
+	}
+
+	timelineState := currentTimelineState(state)
+	activateOpenBuffer(state, idx)
+	if !timelineState.Empty() {
+		state.fileTimeline.TransitionFrom(timelineState)
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Switched to %s", bufferName(state.fileWatcher.Path())),
+	})
+}
+
+// activateOpenBuffer moves the active document to the background and activates another open buffer.
+func activateOpenBuffer(state *EditorState, idx int) {
+	activeIdx := activeBufferIdx(state)
+	if idx == activeIdx {
+		return
+	}
+
+	CancelTaskIfRunning(state)
+	abandonPendingLoad(state)
+
+	// Keep the active document open, unless it was still loading, since loading cannot resume in the background.
+	oldBuffer := state.documentBuffer
+	if oldBuffer.loading {
+		state.fileWatcher.Stop()
+		oldBuffer.undoLog.Close()
+		state.openBuffers = append(state.openBuffers[:activeIdx], state.openBuffers[activeIdx+1:]...)
+		if idx > activeIdx {
+			idx--
+		}
+	} else {
+		stashScratchBuffer(state, state.documentBuffer)
+		oldBuffer.selector.Clear()
+		state.openBuffers[activeIdx] = openBuffer{buffer: oldBuffer, watcher: state.fileWatcher}
+	}
+
+	// If another split shows the buffer, move the focus to that split.
+	moveSplitFocusToBuffer(state, state.openBuffers[idx].buffer)
+	activateBuffer(state, state.openBuffers[idx], oldBuffer.view)
+}
+
+// activateBuffer makes an open buffer the active document, using the view size of the previous active document
+// or of the focused split.
+func activateBuffer(state *EditorState, b openBuffer, oldView viewState) {
+	state.documentBuffer = b.buffer
+	state.fileWatcher = b.watcher
+	state.documentBuffer.view.width = oldView.width
+	state.documentBuffer.view.height = oldView.height
+	layoutSplits(state)
+	state.inputMode = InputModeNormal
+	state.changesView = nil
+	state.textfield = &TextFieldState{}
+	state.documentLoadCount++
+	ScrollViewToCursor(state)
+}
+
+// closeActiveBuffer closes the active document, discarding any unsaved changes.
+// If another buffer is open, it becomes the active document and this returns true.
+// Otherwise, the active document is replaced by an empty document, and this returns false.
+func closeActiveBuffer(state *EditorState) bool {
+	CancelTaskIfRunning(state)
+	abandonPendingLoad(state)
+
+	activeIdx := activeBufferIdx(state)
+	oldBuffer := state.documentBuffer
+	cancelOutputCmdForBuffer(state, oldBuffer)
+	state.fileWatcher.Stop()
+	oldBuffer.undoLog.Close()
+	state.openBuffers = append(state.openBuffers[:activeIdx], state.openBuffers[activeIdx+1:]...)
+
+	if len(state.openBuffers) == 0 {
+		state.documentBuffer = newBufferState(oldBuffer.view.width, oldBuffer.view.height)
+		state.fileWatcher = file.NewEmptyWatcher()
+		state.openBuffers = []openBuffer{{buffer: state.documentBuffer}}
+		state.inputMode = InputModeNormal
+		state.changesView = nil
+		state.documentLoadCount++
+		return false
+	}
+
+	// Activate the buffer opened before the closed buffer, or the first buffer if the closed buffer was first.
+	idx := activeIdx - 1
+	if idx < 0 {
+		idx = 0
+	}
+	activateBuffer(state, state.openBuffers[idx], oldBuffer.view)
+	return true
+}
+
+// NextBuffer switches to the next open buffer, wrapping around to the first buffer.
+func NextBuffer(state *EditorState) {
+	cycleBuffer(state, 1)
+}
+
+// PrevBuffer switches to the previous open buffer, wrapping around to the last buffer.
+func PrevBuffer(state *EditorState) {
+	cycleBuffer(state, -1)
+}
+
+func cycleBuffer(state *EditorState, offset int) {
+	n := len(state.openBuffers)
+	if n < 2 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No other buffers are open",
+		})
+		return
+	}
+
+	idx := (activeBufferIdx(state) + offset + n) % n
+	switchToBuffer(state, idx)
+}
+
+// ShowBufferListMenu displays a menu of open buffers, in the order they were opened.
+// Buffers with unsaved changes are marked with "[+]".
+func ShowBufferListMenu(state *EditorState) {
+	activeIdx := activeBufferIdx(state)
+	items := make([]menu.Item, 0, len(state.openBuffers))
+	for i, b := range state.openBuffers {
+		buffer, watcher := b.buffer, b.watcher
+		if i == activeIdx {
+			buffer, watcher = state.documentBuffer, state.fileWatcher
+		}
+
+		idx := i
+		items = append(items, menu.Item{
+			Name: bufferDisplayName(buffer, watcher.Path()),
+			Action: func(s *EditorState) {
+				switchToBuffer(s, idx)
+			},
+		})
+	}
+	ShowMenu(state, MenuStyleBuffer, items)
+}
+
+// CloseBuffer closes the active document and switches to another open buffer.
+// It does nothing if the active document is the only open buffer.
+func CloseBuffer(state *EditorState) {
+	if len(state.openBuffers) < 2 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Cannot close the only open buffer",
+		})
+		return
+	}
+
+	path := state.fileWatcher.Path()
+	stashScratchBuffer(state, state.documentBuffer)
+	closeActiveBuffer(state)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Closed %s", bufferName(path)),
+	})
+}
+
+// AbortIfUnsavedChangesInBackground executes a function only if no document open in the background has unsaved changes.
+// Otherwise, it shows an error status message naming one of the documents.
+func AbortIfUnsavedChangesInBackground(state *EditorState, f func(*EditorState)) {
+	activeIdx := activeBufferIdx(state)
+	for i, b := range state.openBuffers {
+		if i != activeIdx && b.buffer.undoLog.HasUnsavedChanges() && !b.buffer.scratch {
+			log.Printf("Aborting operation because %q has unsaved changes\n", b.watcher.Path())
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleError,
+				Text:  fmt.Sprintf(`%s has unsaved changes. Switch to it with "buffer list" to save or close it`, bufferName(b.watcher.Path())),
+			})
+			return
+		}
+	}
+
+	f(state)
+}
+
+// bufferName returns the name of an open buffer for display.
+func bufferName(path string) string {
+	if path == "" {
+		return "[untitled]"
+	}
+	return file.RelativePathCwd(path)
+}
+
+// bufferDisplayName returns the name of an open buffer, marked with "[+]" if it has unsaved changes.
+func bufferDisplayName(buffer *BufferState, path string) string {
+	name := bufferName(path)
+	if buffer.undoLog.HasUnsavedChanges() && !buffer.scratch {
+		name += " [+]"
+	}
+	return name
+}
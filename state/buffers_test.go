@@ -0,0 +1,180 @@
+package state
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDocumentKeepsOtherBuffersOpen(t *testing.T) {
+	path1, cleanup1 := createTestFile(t, "abc")
+	defer cleanup1()
+	path2, cleanup2 := createTestFile(t, "def")
+	defer cleanup2()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path1, true, startOfDocLocator)
+	assert.Equal(t, 1, state.NumOpenBuffers())
+
+	// Edit the first document, then open the second document without saving.
+	BeginUndoEntry(state)
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+	LoadDocument(state, path2, true, startOfDocLocator)
+	assert.Equal(t, 2, state.NumOpenBuffers())
+	assert.Equal(t, path2, state.fileWatcher.Path())
+	assert.Equal(t, "def", state.documentBuffer.textTree.String())
+
+	// Opening the first document again switches to its buffer, keeping the unsaved changes.
+	LoadDocument(state, path1, true, startOfDocLocator)
+	assert.Equal(t, 2, state.NumOpenBuffers())
+	assert.Equal(t, path1, state.fileWatcher.Path())
+	assert.Equal(t, "xabc", state.documentBuffer.textTree.String())
+	assert.True(t, state.documentBuffer.undoLog.HasUnsavedChanges())
+
+	// The switch is recorded in the timeline.
+	LoadPrevDocument(state)
+	assert.Equal(t, path2, state.fileWatcher.Path())
+	assert.Equal(t, 2, state.NumOpenBuffers())
+}
+
+func TestNextAndPrevBuffer(t *testing.T) {
+	path1, cleanup1 := createTestFile(t, "abc")
+	defer cleanup1()
+	path2, cleanup2 := createTestFile(t, "def")
+	defer cleanup2()
+	path3, cleanup3 := createTestFile(t, "ghi")
+	defer cleanup3()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path1, true, startOfDocLocator)
+
+	NextBuffer(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "No other buffers are open", state.StatusMsg().Text)
+
+	LoadDocument(state, path2, true, startOfDocLocator)
+	LoadDocument(state, path3, true, startOfDocLocator)
+	require.Equal(t, 3, state.NumOpenBuffers())
+
+	NextBuffer(state)
+	assert.Equal(t, path1, state.fileWatcher.Path())
+	assert.Contains(t, state.StatusMsg().Text, "Switched to")
+
+	NextBuffer(state)
+	assert.Equal(t, path2, state.fileWatcher.Path())
+
+	PrevBuffer(state)
+	assert.Equal(t, path1, state.fileWatcher.Path())
+
+	PrevBuffer(state)
+	assert.Equal(t, path3, state.fileWatcher.Path())
+	assert.Equal(t, "ghi", state.documentBuffer.textTree.String())
+}
+
+func TestShowBufferListMenu(t *testing.T) {
+	path1, cleanup1 := createTestFile(t, "abc")
+	defer cleanup1()
+	path2, cleanup2 := createTestFile(t, "def")
+	defer cleanup2()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path1, true, startOfDocLocator)
+	BeginUndoEntry(state)
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+	LoadDocument(state, path2, true, startOfDocLocator)
+
+	ShowBufferListMenu(state)
+	assert.Equal(t, InputModeMenu, state.InputMode())
+	assert.Equal(t, MenuStyleBuffer, state.Menu().Style())
+
+	// Search for the first document, which has unsaved changes.
+	for _, r := range filepath.Base(path1) {
+		AppendRuneToMenuSearch(state, r)
+	}
+	results, selectedIdx := state.Menu().SearchResults()
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, 0, selectedIdx)
+	assert.True(t, strings.HasSuffix(results[0].Name, " [+]"))
+
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, path1, state.fileWatcher.Path())
+	assert.Equal(t, "xabc", state.documentBuffer.textTree.String())
+}
+
+func TestCloseBuffer(t *testing.T) {
+	path1, cleanup1 := createTestFile(t, "abc")
+	defer cleanup1()
+	path2, cleanup2 := createTestFile(t, "def")
+	defer cleanup2()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path1, true, startOfDocLocator)
+
+	CloseBuffer(state)
+	assert.Equal(t, "Cannot close the only open buffer", state.StatusMsg().Text)
+	assert.Equal(t, path1, state.fileWatcher.Path())
+
+	LoadDocument(state, path2, true, startOfDocLocator)
+	CloseBuffer(state)
+	assert.Equal(t, 1, state.NumOpenBuffers())
+	assert.Equal(t, path1, state.fileWatcher.Path())
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+	assert.Contains(t, state.StatusMsg().Text, "Closed")
+}
+
+func TestAbortIfUnsavedChangesInBackground(t *testing.T) {
+	path1, cleanup1 := createTestFile(t, "abc")
+	defer cleanup1()
+	path2, cleanup2 := createTestFile(t, "def")
+	defer cleanup2()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path1, true, startOfDocLocator)
+
+	var called bool
+	f := func(*EditorState) { called = true }
+
+	// Unsaved changes in the active document are ignored.
+	BeginUndoEntry(state)
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+	AbortIfUnsavedChangesInBackground(state, f)
+	assert.True(t, called)
+
+	// Unsaved changes in a background document abort.
+	called = false
+	LoadDocument(state, path2, true, startOfDocLocator)
+	AbortIfUnsavedChangesInBackground(state, f)
+	assert.False(t, called)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Contains(t, state.StatusMsg().Text, "has unsaved changes")
+}
+
+func TestOpenScratchBufferInBackground(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	require.NoError(t, OpenScratchBuffer(state, "notes"))
+	InsertText(state, "remember this")
+	LoadDocument(state, path, true, startOfDocLocator)
+	assert.Equal(t, 2, state.NumOpenBuffers())
+
+	// Opening the scratch buffer again switches to its buffer instead of opening another.
+	require.NoError(t, OpenScratchBuffer(state, "notes"))
+	assert.Equal(t, 2, state.NumOpenBuffers())
+	assert.Equal(t, "remember this", state.documentBuffer.textTree.String())
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+// undoCheckpointTimeout is how long after a large change the user can revert to the checkpoint before it.
+const undoCheckpointTimeout = 30 * time.Second
+
+// undoCheckpoint is the undo state before an operation that can change large amounts of text,
+// such as replacing every match in the document or applying a macro to a range of lines.
+// Checkpoints are kept only in memory for a short time after the operation.
+type undoCheckpoint struct {
+	label    string
+	stateNum int
+	time     time.Time
+}
+
+// beginUndoCheckpoint records the undo state before a large change.
+// It returns nil while replaying a user macro, because the macro replay has its own checkpoint.
+func beginUndoCheckpoint(state *EditorState, label string) *undoCheckpoint {
+	if state.macroState.isReplayingUserMacro {
+		return nil
+	}
+	return &undoCheckpoint{
+		label:    label,
+		stateNum: state.documentBuffer.undoLog.CurrentState(),
+	}
+}
+
+// commitUndoCheckpoint makes a checkpoint available to RevertToUndoCheckpoint if the operation changed the document,
+// and tells the user how to revert in the status bar. This should be called after setting the operation's status message.
+func commitUndoCheckpoint(state *EditorState, checkpoint *undoCheckpoint) {
+	buffer := state.documentBuffer
+	if checkpoint == nil || buffer.undoLog.CurrentState() == checkpoint.stateNum {
+		return
+	}
+
+	log.Printf("Created undo checkpoint before %s at undo state %d\n", checkpoint.label, checkpoint.stateNum)
+	checkpoint.time = nondet.Now()
+	buffer.undoCheckpoint = checkpoint
+
+	msg := state.statusMsg
+	msg.Text += ". Press U to revert"
+	SetStatusMsg(state, msg)
+}
+
+// RevertToUndoCheckpoint returns the document to its state before the last large change,
+// if the change happened recently. The change stays in the undo log, so moving to a newer undo state restores it.
+func RevertToUndoCheckpoint(state *EditorState) {
+	buffer := state.documentBuffer
+	checkpoint := buffer.undoCheckpoint
+	if checkpoint == nil || nondet.Now().Sub(checkpoint.time) > undoCheckpointTimeout {
+		buffer.undoCheckpoint = nil
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No recent checkpoint to revert to",
+		})
+		return
+	}
+
+	buffer.undoCheckpoint = nil
+	log.Printf("Reverting to undo checkpoint before %s at undo state %d\n", checkpoint.label, checkpoint.stateNum)
+	moveToUndoState(state, checkpoint.stateNum)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Reverted to checkpoint before %s", checkpoint.label),
+	})
+}
@@ -0,0 +1,83 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+func TestRevertToUndoCheckpoint(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "foo bar foo")
+	require.NoError(t, ReplaceAll(state, "foo", "baz", false))
+	assert.Equal(t, "baz bar baz", state.documentBuffer.textTree.String())
+	assert.Equal(t, `Replaced 2 occurrence(s) of "foo". Press U to revert`, state.StatusMsg().Text)
+
+	RevertToUndoCheckpoint(state)
+	assert.Equal(t, "foo bar foo", state.documentBuffer.textTree.String())
+	assert.Equal(t, "Reverted to checkpoint before replace all", state.StatusMsg().Text)
+
+	// The checkpoint can be used only once.
+	RevertToUndoCheckpoint(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "No recent checkpoint to revert to", state.StatusMsg().Text)
+
+	// The reverted change is still in the undo log.
+	MoveToNewerUndoState(state, 1)
+	assert.Equal(t, "baz bar baz", state.documentBuffer.textTree.String())
+}
+
+func TestRevertToUndoCheckpointIncludesLaterEdits(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "a b\nc d")
+	UnwrapDocument(state)
+	assert.Equal(t, "a b c d", state.documentBuffer.textTree.String())
+
+	BeginUndoEntry(state)
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+
+	RevertToUndoCheckpoint(state)
+	assert.Equal(t, "a b\nc d", state.documentBuffer.textTree.String())
+}
+
+func TestRevertToUndoCheckpointExpired(t *testing.T) {
+	restore := nondet.SetSource(nondet.NewDeterministicSource(time.Unix(1700000000, 0), 20*time.Second, 0))
+	defer restore()
+
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "foo")
+	require.NoError(t, ReplaceAll(state, "foo", "bar", false))
+
+	// The clock advances 20 seconds each time it is read, so this makes the revert happen 40 seconds after the checkpoint.
+	nondet.Now()
+	RevertToUndoCheckpoint(state)
+	assert.Equal(t, "No recent checkpoint to revert to", state.StatusMsg().Text)
+	assert.Equal(t, "bar", state.documentBuffer.textTree.String())
+}
+
+func TestNoUndoCheckpointWithoutChanges(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "foo")
+	WrapDocument(state, 80)
+	assert.Equal(t, "Wrapped 0 paragraph(s) at 80 columns", state.StatusMsg().Text)
+	assert.Nil(t, state.documentBuffer.undoCheckpoint)
+}
+
+func TestUndoCheckpointClearedOnLoad(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	require.NoError(t, ReplaceAll(state, "foo", "bar", false))
+	require.NotNil(t, state.documentBuffer.undoCheckpoint)
+
+	ReloadDocument(state)
+	assert.Nil(t, state.documentBuffer.undoCheckpoint)
+}
@@ -1,6 +1,10 @@
 package state
 
-import "github.com/aretext/aretext/config"
+import (
+	"fmt"
+
+	"github.com/aretext/aretext/config"
+)
 
 // ToggleShowTabs shows or hides tab characters in the document.
 func ToggleShowTabs(s *EditorState) {
@@ -14,6 +18,7 @@ func ToggleShowSpaces(s *EditorState) {
 
 // ToggleTabExpand toggles whether tabs should be expanded to spaces.
 func ToggleTabExpand(s *EditorState) {
+	s.documentBuffer.pasteMode.disabledTabExpand = false
 	toggleFlagAndSetStatus(s, &s.documentBuffer.tabExpand, "Enabled tab expand", "Disabled tab expand")
 }
 
@@ -22,6 +27,103 @@ func ToggleShowLineNumbers(s *EditorState) {
 	toggleFlagAndSetStatus(s, &s.documentBuffer.showLineNum, "Showing line numbers", "Hiding line numbers")
 }
 
+// ToggleShowRuler shows or hides the cursor position ruler in the status bar.
+func ToggleShowRuler(s *EditorState) {
+	toggleFlagAndSetStatus(s, &s.documentBuffer.showRuler, "Showing ruler", "Hiding ruler")
+}
+
+// ToggleShowDiffGutter shows or hides markers for lines changed since the document was last saved.
+func ToggleShowDiffGutter(s *EditorState) {
+	toggleFlagAndSetStatus(s, &s.documentBuffer.showDiffGutter, "Showing diff gutter", "Hiding diff gutter")
+}
+
+// ToggleShowScrollbar shows or hides the scrollbar at the right edge of the document.
+func ToggleShowScrollbar(s *EditorState) {
+	toggleFlagAndSetStatus(s, &s.documentBuffer.showScrollbar, "Showing scrollbar", "Hiding scrollbar")
+}
+
+// ToggleShowMatchingBracket toggles whether to highlight the bracket matching the one at the cursor.
+func ToggleShowMatchingBracket(s *EditorState) {
+	toggleFlagAndSetStatus(s, &s.documentBuffer.showMatchingBracket, "Showing matching bracket", "Hiding matching bracket")
+}
+
+// ToggleShowAnsiColors toggles whether to show colors from ANSI escape sequences in read-only documents.
+func ToggleShowAnsiColors(s *EditorState) {
+	toggleFlagAndSetStatus(s, &s.documentBuffer.showAnsiColors, "Showing ANSI colors", "Hiding ANSI colors")
+}
+
+// ToggleRtlVisualOrder toggles whether right-to-left text is displayed in visual order.
+func ToggleRtlVisualOrder(s *EditorState) {
+	toggleFlagAndSetStatus(s, &s.documentBuffer.rtlVisualOrder, "Showing right-to-left text in visual order", "Showing right-to-left text in logical order")
+}
+
+// ligatureBreakerRune returns the character to draw after each character for the configured ligature breaker.
+func ligatureBreakerRune(ligatureBreaker string) rune {
+	switch ligatureBreaker {
+	case config.LigatureBreakerZwnj:
+		return '\u200c'
+	case config.LigatureBreakerZwsp:
+		return '\u200b'
+	default:
+		return 0
+	}
+}
+
+// CycleLigatureBreaker switches to the next ligature breaker (none, then zero-width non-joiner,
+// then zero-width space). This lets the user find a breaker that their terminal and font
+// draw without ligatures and without shifting the cursor.
+func CycleLigatureBreaker(s *EditorState) {
+	var name string
+	switch s.documentBuffer.ligatureBreaker {
+	case 0:
+		name = config.LigatureBreakerZwnj
+	case ligatureBreakerRune(config.LigatureBreakerZwnj):
+		name = config.LigatureBreakerZwsp
+	default:
+		name = config.LigatureBreakerNone
+	}
+
+	s.documentBuffer.ligatureBreaker = ligatureBreakerRune(name)
+	SetStatusMsg(s, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Ligature breaker set to %q", name),
+	})
+}
+
+// SetTabDisplaySize changes how many columns tabs occupy when drawing the current document,
+// without changing the tab size used for edits or the document contents.
+// A size of zero restores the configured tab size.
+func SetTabDisplaySize(s *EditorState, tabDisplaySize uint64) {
+	buffer := s.documentBuffer
+	buffer.tabDisplaySize = tabDisplaySize
+	ScrollViewToCursor(s)
+
+	msg := fmt.Sprintf("Displaying tabs with width %d", tabDisplaySize)
+	if tabDisplaySize == 0 {
+		msg = fmt.Sprintf("Displaying tabs with tab size %d", buffer.tabSize)
+	}
+	SetStatusMsg(s, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  msg,
+	})
+}
+
+// SetMinLineNumMarginWidth sets the minimum number of columns in the line number margin
+// for the current document. A width of zero restores the default.
+func SetMinLineNumMarginWidth(s *EditorState, width uint64) {
+	s.documentBuffer.minLineNumMarginWidth = width
+	ScrollViewToCursor(s)
+
+	msg := fmt.Sprintf("Set minimum line number margin width to %d", width)
+	if width == 0 {
+		msg = "Reset line number margin width"
+	}
+	SetStatusMsg(s, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  msg,
+	})
+}
+
 // SetLineNumberMode sets the line number mode.
 func SetLineNumberMode(s *EditorState, mode config.LineNumberMode) {
 	switch mode {
@@ -65,9 +167,44 @@ func ToggleLineNumberMode(s *EditorState) {
 
 // ToggleAutoIndent enables or disables auto-indent.
 func ToggleAutoIndent(s *EditorState) {
+	s.documentBuffer.pasteMode.disabledAutoIndent = false
 	toggleFlagAndSetStatus(s, &s.documentBuffer.autoIndent, "Enabled auto-indent", "Disabled auto-indent")
 }
 
+// TogglePasteMode enables or disables paste mode.
+// Terminals without bracketed paste send pasted text as ordinary keypresses,
+// so paste mode disables auto-indent and tab expand to insert the text unchanged.
+// Disabling paste mode turns these settings back on, unless the user toggled them while paste mode was enabled.
+func TogglePasteMode(s *EditorState) {
+	buffer := s.documentBuffer
+	if buffer.pasteMode.enabled {
+		if buffer.pasteMode.disabledAutoIndent {
+			buffer.autoIndent = true
+		}
+		if buffer.pasteMode.disabledTabExpand {
+			buffer.tabExpand = true
+		}
+		buffer.pasteMode = pasteModeState{}
+		SetStatusMsg(s, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "Disabled paste mode",
+		})
+		return
+	}
+
+	buffer.pasteMode = pasteModeState{
+		enabled:            true,
+		disabledAutoIndent: buffer.autoIndent,
+		disabledTabExpand:  buffer.tabExpand,
+	}
+	buffer.autoIndent = false
+	buffer.tabExpand = false
+	SetStatusMsg(s, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  "Enabled paste mode",
+	})
+}
+
 func toggleFlagAndSetStatus(s *EditorState, flagValue *bool, enabledMsg string, disabledMsg string) {
 	*flagValue = !(*flagValue)
 
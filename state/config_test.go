@@ -142,3 +142,103 @@ func TestToggleShowLineNumbers(t *testing.T) {
 		})
 	}
 }
+
+func TestTogglePasteMode(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.autoIndent = true
+	state.documentBuffer.tabExpand = true
+
+	TogglePasteMode(state)
+	assert.True(t, state.documentBuffer.PasteMode())
+	assert.False(t, state.documentBuffer.autoIndent)
+	assert.False(t, state.documentBuffer.tabExpand)
+	assert.Equal(t, "Enabled paste mode", state.StatusMsg().Text)
+
+	TogglePasteMode(state)
+	assert.False(t, state.documentBuffer.PasteMode())
+	assert.True(t, state.documentBuffer.autoIndent)
+	assert.True(t, state.documentBuffer.tabExpand)
+	assert.Equal(t, "Disabled paste mode", state.StatusMsg().Text)
+}
+
+func TestTogglePasteModeRestoresDisabledSettings(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.autoIndent = false
+	state.documentBuffer.tabExpand = false
+
+	TogglePasteMode(state)
+	TogglePasteMode(state)
+	assert.False(t, state.documentBuffer.autoIndent)
+	assert.False(t, state.documentBuffer.tabExpand)
+}
+
+func TestTogglePasteModeKeepsSettingsToggledWhileEnabled(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.autoIndent = true
+	state.documentBuffer.tabExpand = false
+
+	// While paste mode is enabled, the user turns tab expand on and auto-indent on then off again.
+	TogglePasteMode(state)
+	ToggleTabExpand(state)
+	ToggleAutoIndent(state)
+	ToggleAutoIndent(state)
+	assert.True(t, state.documentBuffer.tabExpand)
+	assert.False(t, state.documentBuffer.autoIndent)
+
+	// Disabling paste mode keeps the user's choices instead of the settings from before paste mode.
+	TogglePasteMode(state)
+	assert.True(t, state.documentBuffer.tabExpand)
+	assert.False(t, state.documentBuffer.autoIndent)
+}
+
+func TestCycleLigatureBreaker(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	assert.Equal(t, rune(0), state.documentBuffer.LigatureBreaker())
+
+	CycleLigatureBreaker(state)
+	assert.Equal(t, '\u200c', state.documentBuffer.LigatureBreaker())
+	assert.Equal(t, `Ligature breaker set to "zwnj"`, state.StatusMsg().Text)
+
+	CycleLigatureBreaker(state)
+	assert.Equal(t, '\u200b', state.documentBuffer.LigatureBreaker())
+	assert.Equal(t, `Ligature breaker set to "zwsp"`, state.StatusMsg().Text)
+
+	CycleLigatureBreaker(state)
+	assert.Equal(t, rune(0), state.documentBuffer.LigatureBreaker())
+	assert.Equal(t, `Ligature breaker set to "none"`, state.StatusMsg().Text)
+}
+
+func TestSetTabDisplaySize(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.tabExpand = true
+	assert.Equal(t, uint64(4), state.documentBuffer.TabDisplaySize())
+
+	SetTabDisplaySize(state, 2)
+	assert.Equal(t, uint64(2), state.documentBuffer.TabDisplaySize())
+	assert.Equal(t, "Displaying tabs with width 2", state.StatusMsg().Text)
+	wrapConfig := state.documentBuffer.LineWrapConfig()
+	assert.Equal(t, uint64(2), wrapConfig.WidthFunc([]rune{'\t'}, 0))
+
+	// Edits still use the tab size.
+	assert.Equal(t, uint64(4), state.documentBuffer.TabSize())
+	InsertTab(state)
+	assert.Equal(t, "    ", state.documentBuffer.textTree.String())
+
+	SetTabDisplaySize(state, 0)
+	assert.Equal(t, uint64(4), state.documentBuffer.TabDisplaySize())
+	assert.Equal(t, "Displaying tabs with tab size 4", state.StatusMsg().Text)
+}
+
+func TestSetMinLineNumMarginWidth(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.showLineNum = true
+	assert.Equal(t, uint64(3), state.documentBuffer.LineNumMarginWidth())
+
+	SetMinLineNumMarginWidth(state, 6)
+	assert.Equal(t, uint64(6), state.documentBuffer.LineNumMarginWidth())
+	assert.Equal(t, uint64(94), state.documentBuffer.LineWrapConfig().MaxLineWidth)
+
+	SetMinLineNumMarginWidth(state, 0)
+	assert.Equal(t, uint64(3), state.documentBuffer.LineNumMarginWidth())
+	assert.Equal(t, "Reset line number margin width", state.StatusMsg().Text)
+}
@@ -0,0 +1,75 @@
+package state
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/menu"
+)
+
+// SetConfigPath sets the path to the config file, so the user can open it from the editor.
+// An empty path means that the config file location is unknown.
+func SetConfigPath(state *EditorState, path string) {
+	state.configPath = path
+}
+
+// OpenConfigFile loads the config file as a document.
+func OpenConfigFile(state *EditorState) {
+	if state.configPath == "" {
+		setConfigPathUnknownStatusMsg(state)
+		return
+	}
+
+	log.Printf("Opening config file at %q\n", state.configPath)
+	LoadDocument(state, state.configPath, true, func(LocatorParams) uint64 {
+		return 0
+	})
+}
+
+// ShowConfigDirMenu displays a menu for opening files in the directory containing the config file.
+// The files are loaded asynchronously as a task that the user can cancel.
+func ShowConfigDirMenu(s *EditorState) {
+	if s.configPath == "" {
+		setConfigPathUnknownStatusMsg(s)
+		return
+	}
+
+	dir := filepath.Dir(s.configPath)
+	log.Printf("Scheduling task to load config dir menu items...\n")
+	StartTask(s, func(ctx context.Context) func(*EditorState) {
+		log.Printf("Starting to load config dir menu items...\n")
+		items := loadConfigDirMenuItems(ctx, dir)
+		log.Printf("Successfully loaded %d config dir menu items\n", len(items))
+		return func(s *EditorState) {
+			ShowMenu(s, MenuStyleConfigDir, items)
+		}
+	})
+}
+
+func loadConfigDirMenuItems(ctx context.Context, dir string) []menu.Item {
+	paths := file.ListDir(ctx, dir, file.ListDirOptions{})
+	log.Printf("Listed %d paths for config dir %q\n", len(paths), dir)
+
+	items := make([]menu.Item, 0, len(paths))
+	for _, p := range paths {
+		menuPath := p // reference path in this iteration of the loop
+		items = append(items, menu.Item{
+			Name: file.RelativePath(menuPath, dir),
+			Action: func(s *EditorState) {
+				LoadDocument(s, menuPath, true, func(LocatorParams) uint64 {
+					return 0
+				})
+			},
+		})
+	}
+	return items
+}
+
+func setConfigPathUnknownStatusMsg(state *EditorState) {
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "Could not determine the config file location",
+	})
+}
@@ -0,0 +1,56 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenConfigFileUnknownPath(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	OpenConfigFile(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "Could not determine the config file location", state.StatusMsg().Text)
+}
+
+func TestOpenConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("- name: default\n"), 0644))
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer state.fileWatcher.Stop()
+	SetConfigPath(state, path)
+	OpenConfigFile(state)
+	assert.Equal(t, path, state.fileWatcher.Path())
+	assert.Equal(t, "- name: default", state.documentBuffer.textTree.String())
+}
+
+func TestShowConfigDirMenu(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte("foo"), 0644))
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer state.fileWatcher.Stop()
+	SetConfigPath(state, configPath)
+	ShowConfigDirMenu(state)
+
+	action := <-state.TaskResultChan()
+	action(state)
+
+	assert.Equal(t, InputModeMenu, state.InputMode())
+	assert.Equal(t, MenuStyleConfigDir, state.Menu().Style())
+	results, _ := state.Menu().SearchResults()
+	require.Equal(t, 2, len(results))
+	assert.Equal(t, "config.yaml", results[0].Name)
+	assert.Equal(t, "plugin.yaml", results[1].Name)
+
+	AppendRuneToMenuSearch(state, 'p')
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, filepath.Join(dir, "plugin.yaml"), state.fileWatcher.Path())
+	assert.Equal(t, "foo", state.documentBuffer.textTree.String())
+}
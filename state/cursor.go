@@ -90,13 +90,13 @@ func moveCursorToLine(buffer *BufferState, targetLineStartPos uint64) {
 		buffer.textTree,
 		lineStartPos,
 		buffer.cursor,
-		buffer.tabSize)
+		buffer.TabDisplaySize())
 
 	newPos, actualOffset := advanceToOffset(
 		buffer.textTree,
 		targetLineStartPos,
 		targetOffset,
-		buffer.tabSize)
+		buffer.TabDisplaySize())
 
 	buffer.cursor = cursorState{
 		position:      newPos,
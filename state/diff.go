@@ -0,0 +1,235 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/text"
+)
+
+// textSnapshotMaxChars is the maximum size of a document to compare with an earlier version.
+// Comparing larger documents would use too much memory and slow down the editor.
+const textSnapshotMaxChars = 1 << 20
+
+// textSnapshot is a copy of the document text to compare with later versions.
+// Documents larger than textSnapshotMaxChars are never compared, so they aren't copied either.
+type textSnapshot struct {
+	text string
+	ok   bool // False if the document was too large to copy.
+}
+
+func newTextSnapshot(tree *text.Tree) textSnapshot {
+	if tree.NumChars() > textSnapshotMaxChars {
+		return textSnapshot{}
+	}
+	return textSnapshot{text: tree.String(), ok: true}
+}
+
+// diffHunk represents a range of lines that differ between the original and current document.
+// Line numbers are zero-indexed.
+type diffHunk struct {
+	origStartLine uint64
+	origNumLines  uint64
+	newStartLine  uint64
+	newNumLines   uint64
+}
+
+// changesViewState is a read-only buffer showing changes to the document as a unified diff.
+type changesViewState struct {
+	buffer   *BufferState
+	lineNums []uint64 // Line number in the document for each line of the diff.
+}
+
+// ShowChangesSinceLoad shows changes to the document since it was loaded, in the format of a unified diff.
+// The user can move through the diff, then press enter to jump to a change in the document.
+func ShowChangesSinceLoad(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.loading {
+		// The buffer contains only the start of the file, so it can't be compared with the loaded document yet.
+		setNotEditableStatusMsg(state, errDocumentLoading)
+		return
+	}
+
+	if buffer.appendOnly {
+		// Follow mode only appends text, so nothing changed since the document was loaded.
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "No changes since document was loaded",
+		})
+		return
+	}
+
+	if !buffer.originalText.ok || buffer.textTree.NumChars() > textSnapshotMaxChars {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Document is too large to show changes since it was loaded",
+		})
+		return
+	}
+
+	hunks, origLines, newLines := diffHunksBetween(buffer.originalText.text, buffer.textTree.String())
+	log.Printf("Found %d changed hunks since document was loaded\n", len(hunks))
+	if len(hunks) == 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "No changes since document was loaded",
+		})
+		return
+	}
+
+	name := file.RelativePathCwd(state.fileWatcher.Path())
+	diffText, lineNums := formatUnifiedDiff(name, hunks, origLines, newLines)
+	diffTree, err := text.NewTreeFromString(diffText)
+	if err != nil {
+		panic(err) // Should never happen since the diff is valid UTF-8.
+	}
+
+	state.changesView = &changesViewState{
+		buffer: &BufferState{
+			textTree:                diffTree,
+			selector:                &selection.Selector{},
+			view:                    viewState{width: buffer.view.width, height: buffer.view.height},
+			tabSize:                 buffer.tabSize,
+			showTabs:                buffer.showTabs,
+			showSpaces:              buffer.showSpaces,
+			lineWrapAllowCharBreaks: buffer.lineWrapAllowCharBreaks,
+		},
+		lineNums: lineNums,
+	}
+	setInputMode(state, InputModeChanges)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Found %d change(s) since document was loaded. Press enter on a change to jump to it", len(hunks)),
+	})
+}
+
+// MoveChangesViewCursor moves the cursor in the changes view and scrolls the view to the cursor.
+func MoveChangesViewCursor(state *EditorState, loc Locator) {
+	if state.changesView == nil {
+		return
+	}
+	buffer := state.changesView.buffer
+	newPos := loc(locatorParamsForBuffer(buffer))
+	if n := buffer.textTree.NumChars(); newPos >= n && n > 0 {
+		newPos = n - 1
+	}
+	buffer.cursor = cursorState{position: newPos}
+	scrollViewToPosition(buffer, newPos)
+}
+
+// JumpToChange closes the changes view and moves the cursor to the change on the cursor's line of the diff.
+func JumpToChange(state *EditorState) {
+	cv := state.changesView
+	if cv == nil {
+		return
+	}
+	lineNum := cv.lineNums[cv.buffer.textTree.LineNumForPosition(cv.buffer.cursor.position)]
+	CloseChangesView(state)
+	MoveCursor(state, func(p LocatorParams) uint64 {
+		return locate.StartOfLineNum(p.TextTree, lineNum)
+	})
+	ScrollViewToCursor(state)
+}
+
+// CloseChangesView returns to normal mode without moving the cursor in the document.
+func CloseChangesView(state *EditorState) {
+	state.changesView = nil
+	setInputMode(state, InputModeNormal)
+}
+
+// formatUnifiedDiff formats hunks in the unified diff format, without context lines.
+// It returns the diff text and the line number in the new text for each line of the diff.
+func formatUnifiedDiff(name string, hunks []diffHunk, origLines, newLines []string) (string, []uint64) {
+	var sb strings.Builder
+	var lineNums []uint64
+	addLine := func(s string, lineNum uint64) {
+		sb.WriteString(strings.TrimSuffix(s, "\n"))
+		sb.WriteString("\n")
+		lineNums = append(lineNums, lineNum)
+	}
+
+	addLine(fmt.Sprintf("--- %s (loaded)", name), hunks[0].newStartLine)
+	addLine(fmt.Sprintf("+++ %s (current)", name), hunks[0].newStartLine)
+	for _, h := range hunks {
+		addLine(fmt.Sprintf("@@ -%s +%s @@", formatUnifiedDiffRange(h.origStartLine, h.origNumLines), formatUnifiedDiffRange(h.newStartLine, h.newNumLines)), h.newStartLine)
+		for i := uint64(0); i < h.origNumLines; i++ {
+			addLine("-"+origLines[h.origStartLine+i], h.newStartLine)
+		}
+		for i := uint64(0); i < h.newNumLines; i++ {
+			addLine("+"+newLines[h.newStartLine+i], h.newStartLine+i)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), lineNums
+}
+
+// formatUnifiedDiffRange formats a zero-indexed range of lines in a unified diff hunk header.
+// As in GNU diff, an empty range starts at the line before the change.
+func formatUnifiedDiffRange(startLine, numLines uint64) string {
+	switch numLines {
+	case 0:
+		return fmt.Sprintf("%d,0", startLine)
+	case 1:
+		return fmt.Sprintf("%d", startLine+1)
+	default:
+		return fmt.Sprintf("%d,%d", startLine+1, numLines)
+	}
+}
+
+// diffHunksBetween returns the ranges of lines that differ between two texts, along with the lines of each text.
+func diffHunksBetween(origText, newText string) (hunks []diffHunk, origLines, newLines []string) {
+	origText, newText = terminateLastLine(origText), terminateLastLine(newText)
+	lineMatches, err := text.Align(strings.NewReader(origText), strings.NewReader(newText))
+	if err != nil {
+		panic(err) // Should never happen since we're reading from in-memory strings.
+	}
+
+	origLines, newLines = splitLinesForDiff(origText), splitLinesForDiff(newText)
+	hunks = diffHunksFromLineMatches(lineMatches, uint64(len(origLines)), uint64(len(newLines)))
+	return hunks, origLines, newLines
+}
+
+// diffHunksFromLineMatches returns the ranges of lines not included in any line match.
+func diffHunksFromLineMatches(lineMatches []text.LineMatch, origNumLines, newNumLines uint64) []diffHunk {
+	var hunks []diffHunk
+	var i, j uint64
+	addHunk := func(origEnd, newEnd uint64) {
+		if origEnd > i || newEnd > j {
+			hunks = append(hunks, diffHunk{
+				origStartLine: i,
+				origNumLines:  origEnd - i,
+				newStartLine:  j,
+				newNumLines:   newEnd - j,
+			})
+		}
+	}
+
+	for _, m := range lineMatches {
+		addHunk(m.LeftLineNum, m.RightLineNum)
+		i, j = m.LeftLineNum+1, m.RightLineNum+1
+	}
+	addHunk(origNumLines, newNumLines)
+
+	return hunks
+}
+
+// splitLinesForDiff splits text into lines the same way as text.Align,
+// so an empty final line is not counted.
+func splitLinesForDiff(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// terminateLastLine appends a line feed to text that doesn't already end with one.
+func terminateLastLine(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
@@ -0,0 +1,145 @@
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/text"
+)
+
+func TestDiffHunksFromLineMatches(t *testing.T) {
+	testCases := []struct {
+		name         string
+		lineMatches  []text.LineMatch
+		origNumLines uint64
+		newNumLines  uint64
+		expected     []diffHunk
+	}{
+		{
+			name:         "no lines",
+			origNumLines: 0,
+			newNumLines:  0,
+			expected:     nil,
+		},
+		{
+			name: "all lines match",
+			lineMatches: []text.LineMatch{
+				{LeftLineNum: 0, RightLineNum: 0},
+				{LeftLineNum: 1, RightLineNum: 1},
+			},
+			origNumLines: 2,
+			newNumLines:  2,
+			expected:     nil,
+		},
+		{
+			name: "inserted lines",
+			lineMatches: []text.LineMatch{
+				{LeftLineNum: 0, RightLineNum: 0},
+				{LeftLineNum: 1, RightLineNum: 3},
+			},
+			origNumLines: 2,
+			newNumLines:  4,
+			expected: []diffHunk{
+				{origStartLine: 1, origNumLines: 0, newStartLine: 1, newNumLines: 2},
+			},
+		},
+		{
+			name: "deleted and changed lines",
+			lineMatches: []text.LineMatch{
+				{LeftLineNum: 1, RightLineNum: 0},
+			},
+			origNumLines: 3,
+			newNumLines:  2,
+			expected: []diffHunk{
+				{origStartLine: 0, origNumLines: 1, newStartLine: 0, newNumLines: 0},
+				{origStartLine: 2, origNumLines: 1, newStartLine: 1, newNumLines: 1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hunks := diffHunksFromLineMatches(tc.lineMatches, tc.origNumLines, tc.newNumLines)
+			assert.Equal(t, tc.expected, hunks)
+		})
+	}
+}
+
+func TestShowChangesSinceLoad(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\nbaz\nqux\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+	textTree, err := text.NewTreeFromString("foo\nabc\nbaz\nqux\ndef")
+	require.NoError(t, err)
+	state.documentBuffer.textTree = textTree
+
+	ShowChangesSinceLoad(state)
+	name := file.RelativePathCwd(path)
+	assert.Equal(t, InputModeChanges, state.InputMode())
+	assert.Equal(t, "--- "+name+" (loaded)\n+++ "+name+" (current)\n@@ -2 +2 @@\n-bar\n+abc\n@@ -4,0 +5 @@\n+def", state.ChangesViewBuffer().textTree.String())
+	assert.Equal(t, "Found 2 change(s) since document was loaded. Press enter on a change to jump to it", state.StatusMsg().Text)
+
+	// Jump from the deleted line to the line that replaced it.
+	MoveChangesViewCursor(state, func(p LocatorParams) uint64 { return p.TextTree.LineStartPosition(3) })
+	JumpToChange(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Nil(t, state.ChangesViewBuffer())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+
+	// Jump from the second hunk to the inserted line.
+	ShowChangesSinceLoad(state)
+	MoveChangesViewCursor(state, func(p LocatorParams) uint64 { return p.TextTree.LineStartPosition(6) })
+	JumpToChange(state)
+	assert.Equal(t, uint64(16), state.documentBuffer.cursor.position)
+}
+
+func TestCloseChangesView(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.originalText = textSnapshot{text: "foo\n", ok: true}
+	textTree, err := text.NewTreeFromString("foo\nbar\n")
+	require.NoError(t, err)
+	state.documentBuffer.textTree = textTree
+
+	ShowChangesSinceLoad(state)
+	MoveChangesViewCursor(state, func(p LocatorParams) uint64 { return p.TextTree.LineStartPosition(3) })
+	CloseChangesView(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Nil(t, state.ChangesViewBuffer())
+	assert.Equal(t, uint64(0), state.documentBuffer.cursor.position)
+}
+
+func TestShowChangesSinceLoadNoChanges(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	ShowChangesSinceLoad(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "No changes since document was loaded", state.StatusMsg().Text)
+}
+
+func TestShowChangesSinceLoadDocumentTooLarge(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	textTree, err := text.NewTreeFromString(strings.Repeat("a", textSnapshotMaxChars+1))
+	require.NoError(t, err)
+	state.documentBuffer.originalText = newTextSnapshot(textTree)
+	state.documentBuffer.textTree = textTree
+	assert.False(t, state.documentBuffer.originalText.ok)
+
+	ShowChangesSinceLoad(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "Document is too large to show changes since it was loaded",
+	}, state.StatusMsg())
+}
+
+func TestFormatUnifiedDiffRange(t *testing.T) {
+	assert.Equal(t, "3,0", formatUnifiedDiffRange(3, 0))
+	assert.Equal(t, "4", formatUnifiedDiffRange(3, 1))
+	assert.Equal(t, "4,2", formatUnifiedDiffRange(3, 2))
+}
@@ -0,0 +1,182 @@
+package state
+
+import "time"
+
+// lineChangeDelay is how long the user must stop editing before the document is compared with its saved version.
+// Comparing takes time proportional to the size of the document, so it runs in the background
+// once the user pauses instead of after every keystroke.
+var lineChangeDelay = 250 * time.Millisecond
+
+// LineChange describes how a line differs from the last saved version of the document.
+type LineChange int
+
+const (
+	LineChangeNone     = LineChange(iota)
+	LineChangeAdded    // The line was inserted.
+	LineChangeModified // The line replaced one or more lines.
+	LineChangeDeleted  // One or more lines were deleted immediately before this line.
+)
+
+// lineChangeIndex records which lines differ from the saved version of the document.
+// After an edit, the diff gutter keeps showing the previous changes until the next comparison completes.
+type lineChangeIndex struct {
+	changes        map[uint64]LineChange // Keyed by line number.
+	hunks          []diffHunk            // Also used to match lines to git blame annotations.
+	version        uint64                // Incremented whenever the document or its saved version changes.
+	updatedVersion uint64                // Version of the document when the changes were computed.
+}
+
+func (idx *lineChangeIndex) stale() bool {
+	return idx.version != idx.updatedVersion
+}
+
+// lineChangeUpdateState represents a comparison scheduled or running in the background.
+type lineChangeUpdateState struct {
+	actionChan chan func(*EditorState)
+}
+
+// invalidateLineChangeIndex records that the document changed, so the line changes must be computed again.
+func invalidateLineChangeIndex(buffer *BufferState) {
+	buffer.lineChanges.version++
+}
+
+// setSavedText records the text of the document as it exists on disk.
+// The document matches its saved version, so there are no line changes to compute.
+func setSavedText(buffer *BufferState, snapshot textSnapshot) {
+	buffer.savedText = snapshot
+	version := buffer.lineChanges.version + 1
+	buffer.lineChanges = lineChangeIndex{version: version, updatedVersion: version}
+	invalidateBlame(buffer)
+}
+
+// ShowDiffGutter returns whether to show markers for lines changed since the document was last saved.
+func (s *BufferState) ShowDiffGutter() bool {
+	return s.showDiffGutter
+}
+
+// DiffGutterWidth returns the number of columns in the left margin used for diff markers.
+// This is zero if the diff gutter is disabled or there isn't enough space to show any document text.
+func (s *BufferState) DiffGutterWidth() uint64 {
+	if !s.showDiffGutter || s.view.width <= 1 {
+		return 0
+	}
+	return 1
+}
+
+// LineChange returns how a line differs from the last saved version of the document,
+// as of the last comparison.
+func (s *BufferState) LineChange(lineNum uint64) LineChange {
+	return s.lineChanges.changes[lineNum]
+}
+
+// LineChangeResultChan returns a channel that receives an action when
+// a scheduled comparison is ready to run or has completed.
+// If no comparison is scheduled, this returns nil.
+func (s *EditorState) LineChangeResultChan() chan func(*EditorState) {
+	if s.lineChangeUpdate == nil {
+		return nil
+	}
+	return s.lineChangeUpdate.actionChan
+}
+
+// ScheduleLineChangeUpdate compares the document with its saved version in the background
+// once the user has stopped editing for lineChangeDelay.
+// The editor calls this after processing each event. It does nothing if the diff gutter, git blame,
+// and scrollbar are all hidden, the line changes are up to date, or a comparison is already scheduled.
+func ScheduleLineChangeUpdate(state *EditorState) {
+	buffer := state.documentBuffer
+	if !buffer.showDiffGutter && !buffer.showBlame && !buffer.showScrollbar {
+		return
+	} else if !buffer.lineChanges.stale() || state.lineChangeUpdate != nil {
+		return
+	}
+
+	update := &lineChangeUpdateState{actionChan: make(chan func(*EditorState))}
+	state.lineChangeUpdate = update
+	version := buffer.lineChanges.version
+	go func() {
+		time.Sleep(lineChangeDelay)
+		update.actionChan <- func(state *EditorState) { startLineChangeUpdate(state, update, buffer, version) }
+	}()
+}
+
+// startLineChangeUpdate compares the document with its saved version in the background,
+// unless the document changed during the delay.
+func startLineChangeUpdate(state *EditorState, update *lineChangeUpdateState, buffer *BufferState, version uint64) {
+	if buffer.lineChanges.version != version {
+		// The user is still editing, so the editor schedules another comparison after this event.
+		state.lineChangeUpdate = nil
+		return
+	}
+
+	if !canCompareWithSavedText(buffer) {
+		// Larger documents show no changes in the diff gutter, since comparing them would use too much memory.
+		completeLineChangeUpdate(state, buffer, version, nil, nil)
+		return
+	}
+
+	savedText, newText := buffer.savedText.text, buffer.textTree.String()
+	go func() {
+		changes, hunks := lineChangesBetween(savedText, newText)
+		update.actionChan <- func(state *EditorState) { completeLineChangeUpdate(state, buffer, version, changes, hunks) }
+	}()
+}
+
+// completeLineChangeUpdate records the result of a comparison, unless the document changed while it was running.
+func completeLineChangeUpdate(state *EditorState, buffer *BufferState, version uint64, changes map[uint64]LineChange, hunks []diffHunk) {
+	state.lineChangeUpdate = nil
+	if buffer.lineChanges.version == version {
+		buffer.lineChanges.changes = changes
+		buffer.lineChanges.hunks = hunks
+		buffer.lineChanges.updatedVersion = version
+	}
+}
+
+// updateLineChangesNow compares the document with its saved version immediately if the line changes are stale.
+// This is for commands that need the current line changes, not for drawing.
+func updateLineChangesNow(buffer *BufferState) {
+	idx := &buffer.lineChanges
+	if !idx.stale() {
+		return
+	}
+
+	idx.changes, idx.hunks = nil, nil
+	if canCompareWithSavedText(buffer) {
+		idx.changes, idx.hunks = lineChangesBetween(buffer.savedText.text, buffer.textTree.String())
+	}
+	idx.updatedVersion = idx.version
+}
+
+func canCompareWithSavedText(buffer *BufferState) bool {
+	return !buffer.appendOnly && buffer.savedText.ok && buffer.textTree.NumChars() <= textSnapshotMaxChars
+}
+
+// lineChangesBetween compares a document to its saved version and classifies each changed line.
+// In a hunk that replaces lines, the first lines are modified and any extra lines are added.
+// It also returns the hunks that differ between the document and its saved version.
+func lineChangesBetween(savedText, newText string) (map[uint64]LineChange, []diffHunk) {
+	hunks, _, newLines := diffHunksBetween(savedText, newText)
+	changes := make(map[uint64]LineChange)
+	for _, h := range hunks {
+		if h.newNumLines == 0 {
+			// Mark the line after the deletion, or the last line if the deletion is at the end of the document.
+			lineNum := h.newStartLine
+			if lineNum >= uint64(len(newLines)) && lineNum > 0 {
+				lineNum--
+			}
+			if _, ok := changes[lineNum]; !ok {
+				changes[lineNum] = LineChangeDeleted
+			}
+			continue
+		}
+
+		for i := uint64(0); i < h.newNumLines; i++ {
+			if i < h.origNumLines {
+				changes[h.newStartLine+i] = LineChangeModified
+			} else {
+				changes[h.newStartLine+i] = LineChangeAdded
+			}
+		}
+	}
+	return changes, hunks
+}
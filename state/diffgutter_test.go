@@ -0,0 +1,143 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateLineChanges runs the scheduled comparison of the document with its saved version, if any.
+func updateLineChanges(t *testing.T, state *EditorState) {
+	origDelay := lineChangeDelay
+	lineChangeDelay = 0
+	defer func() { lineChangeDelay = origDelay }()
+
+	ScheduleLineChangeUpdate(state)
+	for state.LineChangeResultChan() != nil {
+		select {
+		case action := <-state.LineChangeResultChan():
+			action(state)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "Timed out waiting for line changes")
+		}
+	}
+}
+
+func TestLineChangesBetween(t *testing.T) {
+	testCases := []struct {
+		name            string
+		savedText       string
+		text            string
+		expectedChanges map[uint64]LineChange
+	}{
+		{
+			name:            "no changes",
+			savedText:       "foo\nbar",
+			text:            "foo\nbar",
+			expectedChanges: map[uint64]LineChange{},
+		},
+		{
+			name:      "added line",
+			savedText: "foo\nbar",
+			text:      "foo\nbaz\nbar",
+			expectedChanges: map[uint64]LineChange{
+				1: LineChangeAdded,
+			},
+		},
+		{
+			name:      "modified line",
+			savedText: "foo\nbar\nbaz",
+			text:      "foo\nBAR\nbaz",
+			expectedChanges: map[uint64]LineChange{
+				1: LineChangeModified,
+			},
+		},
+		{
+			name:      "modified line with added line",
+			savedText: "foo\nbar\nbaz",
+			text:      "foo\nBAR\nBAR2\nbaz",
+			expectedChanges: map[uint64]LineChange{
+				1: LineChangeModified,
+				2: LineChangeAdded,
+			},
+		},
+		{
+			name:      "deleted lines",
+			savedText: "foo\nbar\nbaz\nqux",
+			text:      "foo\nqux",
+			expectedChanges: map[uint64]LineChange{
+				1: LineChangeDeleted,
+			},
+		},
+		{
+			name:      "deleted last line",
+			savedText: "foo\nbar\nbaz",
+			text:      "foo\nbar",
+			expectedChanges: map[uint64]LineChange{
+				1: LineChangeDeleted,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes, _ := lineChangesBetween(tc.savedText, tc.text)
+			assert.Equal(t, tc.expectedChanges, changes)
+		})
+	}
+}
+
+func TestLineChangeAfterEditAndSave(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	ToggleShowDiffGutter(state)
+	buffer := state.documentBuffer
+	updateLineChanges(t, state)
+	assert.Equal(t, LineChangeNone, buffer.LineChange(0))
+
+	// The changes are shown after the user stops editing.
+	BeginUndoEntry(state)
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+	assert.Equal(t, LineChangeNone, buffer.LineChange(0))
+	updateLineChanges(t, state)
+	assert.Equal(t, LineChangeModified, buffer.LineChange(0))
+	assert.Equal(t, LineChangeNone, buffer.LineChange(1))
+
+	// Saving the document clears the changes immediately.
+	SaveDocument(state)
+	assert.Equal(t, LineChangeNone, buffer.LineChange(0))
+	assert.Nil(t, state.LineChangeResultChan())
+
+	Undo(state)
+	updateLineChanges(t, state)
+	assert.Equal(t, LineChangeModified, buffer.LineChange(0))
+}
+
+func TestLineChangeUpdateSkippedAfterEdit(t *testing.T) {
+	origDelay := lineChangeDelay
+	lineChangeDelay = 0
+	defer func() { lineChangeDelay = origDelay }()
+
+	state := NewEditorState(100, 100, nil, nil)
+	ToggleShowDiffGutter(state)
+	InsertText(state, "foo")
+	ScheduleLineChangeUpdate(state)
+
+	// An edit during the delay postpones the comparison until the user stops editing again.
+	InsertText(state, "bar")
+	action := <-state.LineChangeResultChan()
+	action(state)
+	assert.Nil(t, state.LineChangeResultChan())
+	assert.Equal(t, LineChangeNone, state.documentBuffer.LineChange(0))
+
+	updateLineChanges(t, state)
+	assert.Equal(t, LineChangeAdded, state.documentBuffer.LineChange(0))
+}
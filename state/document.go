@@ -9,11 +9,13 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/locate"
 	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/selection"
 	"github.com/aretext/aretext/syntax"
 	"github.com/aretext/aretext/text"
 	"github.com/aretext/aretext/undo"
@@ -38,6 +40,10 @@ func NewDocument(state *EditorState, path string) error {
 // RenameDocument moves a document to a different file path.
 // Returns an error if the file already exists or the directory doesn't exist.
 func RenameDocument(state *EditorState, newPath string) error {
+	if state.documentBuffer.scratch {
+		return errScratchNotSaved
+	}
+
 	// Validate that we can create a file at the new path.
 	// This isn't 100% reliable, since some other process could create a file
 	// at the target path between this check and the rename below, but it at least
@@ -67,73 +73,161 @@ func RenameDocument(state *EditorState, newPath string) error {
 }
 
 // LoadDocument loads a file into the editor.
+// If the file takes a long time to load, the editor displays the start of the file
+// and continues loading the rest in the background. Edits are rejected until loading completes.
 func LoadDocument(state *EditorState, path string, requireExists bool, cursorLoc Locator) {
+	// If the document is already open, switch to it instead of loading it again,
+	// so unsaved changes are kept.
+	if path == state.fileWatcher.Path() && state.documentBuffer.undoLog.HasUnsavedChanges() {
+		MoveCursor(state, cursorLoc)
+		ScrollViewToCursor(state)
+		return
+	} else if switchToOpenBufferForPath(state, path, cursorLoc) {
+		return
+	}
+
 	timelineState := currentTimelineState(state)
-	fileExists, err := loadDocumentAndResetState(state, path, requireExists)
-	if err != nil {
+
+	watcherCfg := watcherConfigForPath(state, path)
+	resultChan := make(chan loadFileResult, 1)
+	go func() {
+		resultChan <- loadFile(path, requireExists, watcherCfg)
+	}()
+
+	var result loadFileResult
+	select {
+	case result = <-resultChan:
+	case <-time.After(asyncLoadTimeout):
+		if startAsyncLoad(state, path, resultChan, timelineState, cursorLoc) {
+			return
+		}
+		result = <-resultChan
+	}
+
+	if result.err != nil {
 		// If this is the first document loaded into the editor, set a watcher
 		// even if the load failed.  This retains the attempted path so the user
 		// can try saving or reloading the document later.
 		if state.fileWatcher.Path() == "" {
-			state.fileWatcher = file.NewWatcherForNewFile(file.DefaultPollInterval, path)
+			state.fileWatcher = file.NewWatcherForNewFile(watcherCfg, path)
 		}
 
-		reportLoadError(state, err, path)
+		reportLoadError(state, result.err, path)
 		return
 	}
 
+	resetStateForDocument(state, path, result.tree, result.watcher)
+
 	if !timelineState.Empty() {
 		state.fileTimeline.TransitionFrom(timelineState)
 	}
 
 	setCursorAfterLoad(state, cursorLoc)
 
-	if fileExists {
+	if result.fileExists {
+		recordFileOpen(state, path)
 		reportOpenSuccess(state, path)
 	} else {
+		state.documentBuffer.textFormat = textFormatForNewFile(state, path)
 		reportCreateSuccess(state, path)
 	}
+
+	checkLongLines(state, path)
+	checkEncryptedDocument(state)
 }
 
 // ReloadDocument reloads the current document.
 func ReloadDocument(state *EditorState) {
+	reloadDocument(state, true)
+}
+
+// ReloadDocumentFromStart reloads the current document, moving the cursor and view to the start of the document.
+// This is useful when the file was truncated, since positions in the old document no longer correspond to the file.
+// In follow mode, the cursor moves to the last line instead if it was on the last line.
+func ReloadDocumentFromStart(state *EditorState) {
+	reloadDocument(state, false)
+}
+
+// reloadDocument reloads the current document.
+// If alignPositions is true, the cursor, selection, and scroll positions move to the matching lines in the new document.
+func reloadDocument(state *EditorState, alignPositions bool) {
 	path := state.fileWatcher.Path()
 
+	if state.documentBuffer.scratch {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Scratch buffers cannot be reloaded because they are not backed by a file",
+		})
+		return
+	}
+
+	// In follow mode, load only the data appended to the file, if possible.
+	if alignPositions && state.documentBuffer.followTail.enabled && reloadAppendedText(state) {
+		return
+	}
+
 	// Store the configuration we want to preserve.
 	oldTextTree := state.documentBuffer.textTree
 	oldText := oldTextTree.String()
 	oldTextOriginLineNum := oldTextTree.LineNumForPosition(state.documentBuffer.view.textOrigin)
-	oldCursorLineNum, oldCursorCol := locate.PosToLineNumAndCol(oldTextTree, state.documentBuffer.cursor.position)
+	oldCursorPos := state.documentBuffer.cursor.position
+	oldCursorOnLastLine := isCursorOnLastLine(state.documentBuffer)
+	oldSelectionMode := state.documentBuffer.selector.Mode()
+	oldSelectionAnchorPos := state.documentBuffer.selector.AnchorPos()
 	oldSearch := state.documentBuffer.search
 	oldAutoIndent := state.documentBuffer.autoIndent
+	oldTabExpand := state.documentBuffer.tabExpand
+	oldPasteMode := state.documentBuffer.pasteMode
 	oldShowTabs := state.documentBuffer.showTabs
 	oldShowSpaces := state.documentBuffer.showSpaces
 	oldShowLineNum := state.documentBuffer.showLineNum
+	oldShowRuler := state.documentBuffer.showRuler
+	oldShowDiffGutter := state.documentBuffer.showDiffGutter
+	oldShowScrollbar := state.documentBuffer.showScrollbar
+	oldShowMatchingBracket := state.documentBuffer.showMatchingBracket
+	oldShowAnsiColors := state.documentBuffer.showAnsiColors
+	oldShowBlame := state.documentBuffer.showBlame
+	oldRtlVisualOrder := state.documentBuffer.rtlVisualOrder
 	oldLineNumberMode := state.documentBuffer.lineNumberMode
+	oldLigatureBreaker := state.documentBuffer.ligatureBreaker
+	oldTabDisplaySize := state.documentBuffer.tabDisplaySize
+	oldMinLineNumMarginWidth := state.documentBuffer.minLineNumMarginWidth
+	oldReadOnly := state.documentBuffer.readOnly
+	oldFollowTail := state.documentBuffer.followTail
+	oldTextFormat := state.documentBuffer.textFormat
 
 	// Reload the document.
-	_, err := loadDocumentAndResetState(state, path, true)
-	if err != nil {
-		reportLoadError(state, err, path)
+	result := loadFile(path, true, watcherConfigForPath(state, path))
+	if result.err != nil {
+		reportLoadError(state, result.err, path)
 		return
 	}
-
-	// Attempt to restore the original cursor and scroll positions, aligned to the new document.
-	newTextTree := state.documentBuffer.textTree
-	newTreeReader := newTextTree.ReaderAtPosition(0)
-	oldReader := strings.NewReader(oldText)
-	lineMatches, err := text.Align(oldReader, &newTreeReader)
-	if err != nil {
-		panic(err) // Should never happen since we're reading from in-memory strings.
-	}
-	state.documentBuffer.cursor.position = locate.LineNumAndColToPos(
-		newTextTree,
-		translateLineNum(lineMatches, oldCursorLineNum),
-		oldCursorCol,
-	)
-	state.documentBuffer.view.textOrigin = newTextTree.LineStartPosition(
-		translateLineNum(lineMatches, oldTextOriginLineNum),
-	)
+	interruptedInput := detachInterruptedInput(state)
+	resetStateForDocument(state, path, result.tree, result.watcher)
+
+	// Attempt to restore the original cursor, selection, and scroll positions, aligned to the new document.
+	// Otherwise, leave the cursor and view at the start of the document, as set when the document was reset.
+	if alignPositions {
+		newTextTree := state.documentBuffer.textTree
+		newTreeReader := newTextTree.ReaderAtPosition(0)
+		oldReader := strings.NewReader(oldText)
+		lineMatches, err := text.Align(oldReader, &newTreeReader)
+		if err != nil {
+			panic(err) // Should never happen since we're reading from in-memory strings.
+		}
+		translatePos := func(oldPos uint64) uint64 {
+			lineNum, col := locate.PosToLineNumAndCol(oldTextTree, oldPos)
+			return locate.LineNumAndColToPos(newTextTree, translateLineNum(lineMatches, lineNum), col)
+		}
+		state.documentBuffer.cursor.position = translatePos(oldCursorPos)
+		state.documentBuffer.view.textOrigin = newTextTree.LineStartPosition(
+			translateLineNum(lineMatches, oldTextOriginLineNum),
+		)
+		if interruptedInput.baseInputMode == InputModeVisual && oldSelectionMode != selection.ModeNone {
+			state.documentBuffer.selector.Start(oldSelectionMode, translatePos(oldSelectionAnchorPos))
+			setInputMode(state, InputModeVisual)
+		}
+	}
 	ScrollViewToCursor(state)
 
 	// Restore search query, direction, and history.
@@ -145,12 +239,38 @@ func ReloadDocument(state *EditorState) {
 
 	// Restore other configuration that might have been toggled with menu commands.
 	state.documentBuffer.autoIndent = oldAutoIndent
+	state.documentBuffer.tabExpand = oldTabExpand
+	state.documentBuffer.pasteMode = oldPasteMode
 	state.documentBuffer.showTabs = oldShowTabs
 	state.documentBuffer.showSpaces = oldShowSpaces
 	state.documentBuffer.showLineNum = oldShowLineNum
+	state.documentBuffer.showRuler = oldShowRuler
+	state.documentBuffer.showDiffGutter = oldShowDiffGutter
+	state.documentBuffer.showScrollbar = oldShowScrollbar
+	state.documentBuffer.showMatchingBracket = oldShowMatchingBracket
+	state.documentBuffer.showAnsiColors = oldShowAnsiColors
+	state.documentBuffer.showBlame = oldShowBlame
+	state.documentBuffer.rtlVisualOrder = oldRtlVisualOrder
 	state.documentBuffer.lineNumberMode = oldLineNumberMode
+	state.documentBuffer.ligatureBreaker = oldLigatureBreaker
+	state.documentBuffer.tabDisplaySize = oldTabDisplaySize
+	state.documentBuffer.minLineNumMarginWidth = oldMinLineNumMarginWidth
+	state.documentBuffer.readOnly = oldReadOnly
+	state.documentBuffer.followTail = oldFollowTail
+	state.documentBuffer.appendOnly = oldFollowTail.enabled
+	state.documentBuffer.textFormat = oldTextFormat
+
+	if oldFollowTail.enabled && oldCursorOnLastLine {
+		moveCursorToLastLine(state)
+	}
+
+	// Return the user to whatever they were doing when the reload happened.
+	inputNote := restoreInterruptedInput(state, interruptedInput)
 
-	reportReloadSuccess(state, path)
+	reportReloadSuccess(state, path, inputNote)
+	runEventHook(state, HookEventReload, state.statusMsg.Text)
+	checkLongLines(state, path)
+	checkEncryptedDocument(state)
 }
 
 func translateLineNum(lineMatches []text.LineMatch, lineNum uint64) uint64 {
@@ -162,10 +282,25 @@ func translateLineNum(lineMatches []text.LineMatch, lineNum uint64) uint64 {
 		alignedLineNum := lineMatches[matchIdx].RightLineNum
 		log.Printf("Aligned line %d in old document with line %d in new document\n", lineNum, alignedLineNum)
 		return lineMatches[matchIdx].RightLineNum
-	} else {
-		log.Printf("Could not find alignment for line number %d\n", lineNum)
-		return lineNum
 	}
+
+	// The line changed, so keep the same offset from the closest line that did not change.
+	if matchIdx > 0 {
+		prevMatch := lineMatches[matchIdx-1]
+		alignedLineNum := prevMatch.RightLineNum + (lineNum - prevMatch.LeftLineNum)
+		log.Printf("Aligned changed line %d in old document with line %d in new document\n", lineNum, alignedLineNum)
+		return alignedLineNum
+	} else if matchIdx < len(lineMatches) {
+		nextMatch := lineMatches[matchIdx]
+		if offset := nextMatch.LeftLineNum - lineNum; nextMatch.RightLineNum >= offset {
+			alignedLineNum := nextMatch.RightLineNum - offset
+			log.Printf("Aligned changed line %d in old document with line %d in new document\n", lineNum, alignedLineNum)
+			return alignedLineNum
+		}
+	}
+
+	log.Printf("Could not find alignment for line number %d\n", lineNum)
+	return lineNum
 }
 
 // LoadPrevDocument loads the previous document from the timeline in the editor.
@@ -180,7 +315,12 @@ func LoadPrevDocument(state *EditorState) {
 		return
 	}
 
-	timelineState := currentTimelineState(state)
+	loadPrevDocumentFrom(state, prev, currentTimelineState(state))
+}
+
+// loadPrevDocumentFrom loads the previous document from the timeline, recording timelineState as the next document.
+// If timelineState is empty, the current document is not recorded in the timeline.
+func loadPrevDocumentFrom(state *EditorState, prev file.TimelineState, timelineState file.TimelineState) {
 	path := prev.Path
 	_, err := loadDocumentAndResetState(state, path, false)
 	if err != nil {
@@ -224,6 +364,10 @@ func LoadNextDocument(state *EditorState) {
 
 func currentTimelineState(state *EditorState) file.TimelineState {
 	buffer := state.documentBuffer
+	if buffer.scratch {
+		// Scratch buffers can't be reopened from a path, so leave them out of the timeline.
+		return file.TimelineState{}
+	}
 	lineNum, col := locate.PosToLineNumAndCol(buffer.textTree, buffer.cursor.position)
 	return file.TimelineState{
 		Path:    state.fileWatcher.Path(),
@@ -233,43 +377,132 @@ func currentTimelineState(state *EditorState) file.TimelineState {
 }
 
 func loadDocumentAndResetState(state *EditorState, path string, requireExists bool) (fileExists bool, err error) {
-	cfg := state.configRuleSet.ConfigForPath(path)
-	tree, watcher, err := file.Load(path, file.DefaultPollInterval)
+	if idx := openBufferIdxForPath(state, path); idx >= 0 {
+		activateOpenBuffer(state, idx)
+		return true, nil
+	}
+
+	result := loadFile(path, requireExists, watcherConfigForPath(state, path))
+	if result.err != nil {
+		return false, result.err
+	}
+	resetStateForDocument(state, path, result.tree, result.watcher)
+	return result.fileExists, nil
+}
+
+// loadFileResult is the outcome of loading a file from disk.
+type loadFileResult struct {
+	tree       *text.Tree
+	watcher    *file.Watcher
+	fileExists bool
+	err        error
+}
+
+// loadFile loads a file from disk without modifying the editor state.
+// This is safe to call from a separate goroutine.
+func loadFile(path string, requireExists bool, watcherCfg file.WatcherConfig) loadFileResult {
+	tree, watcher, err := fileLoadFunc(path, watcherCfg)
 	if errors.Is(err, fs.ErrNotExist) && !requireExists {
 		tree = text.NewTree()
-		watcher = file.NewWatcherForNewFile(file.DefaultPollInterval, path)
+		watcher = file.NewWatcherForNewFile(watcherCfg, path)
+		return loadFileResult{tree: tree, watcher: watcher}
 	} else if err != nil {
-		return false, err
-	} else {
-		fileExists = true
+		return loadFileResult{err: err}
 	}
+	return loadFileResult{tree: tree, watcher: watcher, fileExists: true}
+}
 
+// watcherConfigForPath returns the configured file watcher settings for a path.
+func watcherConfigForPath(state *EditorState, path string) file.WatcherConfig {
+	cfg := state.configRuleSet.ConfigForPath(path)
+	return file.WatcherConfig{
+		PollInterval: time.Duration(cfg.FileWatchInterval) * time.Millisecond,
+		Debounce:     time.Duration(cfg.FileWatchDebounce) * time.Millisecond,
+	}
+}
+
+// resetStateForDocument replaces the current document with a newly loaded tree.
+func resetStateForDocument(state *EditorState, path string, tree *text.Tree, watcher *file.Watcher) {
+	cfg := state.configRuleSet.ConfigForPath(path)
+	samePath := path == state.fileWatcher.Path()
+	prepareBufferForDocument(state, path)
+	stashScratchBuffer(state, state.documentBuffer)
 	CancelTaskIfRunning(state)
+	cancelOutputCmdForBuffer(state, state.documentBuffer)
+	abandonPendingLoad(state)
 	state.documentLoadCount++
 	state.documentBuffer.textTree = tree
+	state.documentBuffer.originalText = newTextSnapshot(tree)
+	setSavedText(state.documentBuffer, state.documentBuffer.originalText)
 	state.fileWatcher.Stop()
 	state.fileWatcher = watcher
 	state.inputMode = InputModeNormal
+	state.textfield = &TextFieldState{}
 	state.documentBuffer.cursor = cursorState{}
 	state.documentBuffer.view.textOrigin = 0
+	state.documentBuffer.view.leftCol = 0
 	state.documentBuffer.selector.Clear()
 	state.documentBuffer.search = searchState{}
 	state.documentBuffer.tabSize = uint64(cfg.TabSize) // safe b/c we validated the config.
+	state.documentBuffer.tabDisplaySize = 0
 	state.documentBuffer.tabExpand = cfg.TabExpand
 	state.documentBuffer.showTabs = cfg.ShowTabs
 	state.documentBuffer.showSpaces = cfg.ShowSpaces
 	state.documentBuffer.autoIndent = cfg.AutoIndent
 	state.documentBuffer.showLineNum = cfg.ShowLineNumbers
+	state.documentBuffer.showRuler = cfg.ShowRuler
+	state.documentBuffer.showDiffGutter = cfg.ShowDiffGutter
+	state.documentBuffer.showScrollbar = cfg.ShowScrollbar
+	state.documentBuffer.showMatchingBracket = cfg.ShowMatchingBracket
+	state.documentBuffer.showAnsiColors = cfg.ShowAnsiColors
+	state.documentBuffer.showBlame = false
+	state.documentBuffer.textFormat = file.DefaultTextFormat
+	state.documentBuffer.rtlVisualOrder = cfg.RtlVisualOrder
+	state.documentBuffer.insertModeSelection = cfg.InsertModeSelection
+	state.documentBuffer.matchPasteIndent = cfg.MatchPasteIndent
+	state.documentBuffer.showKeyHints = cfg.ShowKeyHints
 	state.documentBuffer.lineNumberMode = config.LineNumberMode(cfg.LineNumberMode)
 	state.documentBuffer.lineWrapAllowCharBreaks = bool(cfg.LineWrap == config.LineWrapCharacter)
+	applyLineWrapChoice(state, path)
+	state.documentBuffer.ligatureBreaker = ligatureBreakerRune(cfg.LigatureBreaker)
+	state.documentBuffer.minLineNumMarginWidth = 0
+	state.documentBuffer.pasteMode = pasteModeState{}
+	state.documentBuffer.undoPreview = undoPreviewState{}
+	state.documentBuffer.undoCheckpoint = nil
+	state.documentBuffer.replaceMode = replaceModeState{}
+	state.documentBuffer.replaceConfirm = replaceConfirmState{}
+	state.documentBuffer.insertStart = insertStartState{}
+	state.documentBuffer.readOnly = state.pagerMode
+	state.documentBuffer.scratch = false
+	state.documentBuffer.scratchName = ""
+	state.documentBuffer.followTail = followTailState{}
+	state.documentBuffer.appendOnly = false
+	if !samePath {
+		// A reload keeps the user's response to the long line warning, but a different document is checked again.
+		state.documentBuffer.longLineChoice = longLineChoiceNone
+	}
+	state.documentBuffer.loading = false
+	state.documentBuffer.encryption = nil
+	state.documentBuffer.bookmarks = loadBookmarksForPath(state, path)
+	state.documentBuffer.undoLog.Close()
 	state.documentBuffer.undoLog = undo.NewLog()
 	state.menu = &MenuState{}
+	state.changesView = nil
+	state.fileExplorer = nil
 	state.customMenuItems = customMenuItems(cfg)
 	state.hidePatterns = cfg.HidePatternsAndHideDirectories()
 	state.styles = cfg.Styles
+	state.createParentDirs = cfg.CreateParentDirs
+	state.replaceSymlinks = bool(cfg.SymlinkSave == config.SymlinkSaveReplace)
+	state.longLineThreshold = uint64(cfg.LongLineThreshold) // safe b/c we validated the config.
+	state.eventHook = cfg.EventHook
+	state.bell = cfg.Bell
+	state.timestampFormats = cfg.TimestampFormats
+	state.menuPinnedCommands = cfg.MenuPinnedCommands
+	state.menuHiddenCommands = cfg.MenuHiddenCommands
+	state.documentBuffer.syntaxBackend = syntax.Backend(cfg.SyntaxBackend)
+	state.keyRemap = keyRemapForConfig(cfg)
 	setSyntaxAndRetokenize(state.documentBuffer, syntax.Language(cfg.SyntaxLanguage))
-
-	return fileExists, nil
 }
 
 func setCursorAfterLoad(state *EditorState, cursorLoc Locator) {
@@ -315,17 +548,23 @@ func customMenuItems(cfg config.Config) []menu.Item {
 }
 
 func actionForCustomMenuItem(cmd config.MenuCommandConfig) func(*EditorState) {
+	runCmd := func(state *EditorState) {
+		if cmd.Mode == config.CmdModeSubmenu {
+			RunShellCmdWithSubmenu(state, cmd.ShellCmd, cmd.SubmenuShellCmd, cmd.SubmenuMode)
+		} else {
+			RunShellCmd(state, cmd.ShellCmd, cmd.Mode)
+		}
+	}
+
 	if cmd.Save {
 		return func(state *EditorState) {
 			AbortIfFileChanged(state, func(state *EditorState) {
 				SaveDocumentIfUnsavedChanges(state)
-				RunShellCmd(state, cmd.ShellCmd, cmd.Mode)
+				runCmd(state)
 			})
 		}
 	} else {
-		return func(state *EditorState) {
-			RunShellCmd(state, cmd.ShellCmd, cmd.Mode)
-		}
+		return runCmd
 	}
 }
 
@@ -341,15 +580,21 @@ func reportOpenSuccess(state *EditorState, path string) {
 func reportCreateSuccess(state *EditorState, path string) {
 	log.Printf("Successfully created file at %q", path)
 	msg := fmt.Sprintf("New file %s", file.RelativePathCwd(path))
+	if format := state.documentBuffer.textFormat; format != file.DefaultTextFormat {
+		msg += fmt.Sprintf(" (%s)", format)
+	}
 	SetStatusMsg(state, StatusMsg{
 		Style: StatusMsgStyleSuccess,
 		Text:  msg,
 	})
 }
 
-func reportReloadSuccess(state *EditorState, path string) {
+func reportReloadSuccess(state *EditorState, path string, inputNote string) {
 	log.Printf("Successfully reloaded file from %q", path)
 	msg := fmt.Sprintf("Reloaded %s", file.RelativePathCwd(path))
+	if inputNote != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, inputNote)
+	}
 	SetStatusMsg(state, StatusMsg{
 		Style: StatusMsgStyleSuccess,
 		Text:  msg,
@@ -367,8 +612,27 @@ func reportLoadError(state *EditorState, err error, path string) {
 // SaveDocument saves the currently loaded document to disk.
 func SaveDocument(state *EditorState) {
 	path := state.fileWatcher.Path()
+	if err := checkEditable(state.documentBuffer); err != nil {
+		reportSaveError(state, err, path)
+		return
+	}
+
+	if state.documentBuffer.scratch {
+		reportSaveError(state, errScratchNotSaved, path)
+		return
+	}
+
+	if state.createParentDirs {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			reportSaveError(state, err, path)
+			return
+		}
+	}
+
+	backupBeforeSave(state, path)
+
 	tree := state.documentBuffer.textTree
-	newWatcher, err := file.Save(path, tree, file.DefaultPollInterval)
+	newWatcher, result, err := file.SaveWithFilter(path, tree, state.documentBuffer.textFormat, state.replaceSymlinks, watcherConfigForPath(state, path), preSaveFilter(state, path))
 	if err != nil {
 		reportSaveError(state, err, path)
 		return
@@ -377,12 +641,17 @@ func SaveDocument(state *EditorState) {
 	state.fileWatcher.Stop()
 	state.fileWatcher = newWatcher
 	state.documentBuffer.undoLog.TrackSave()
-	reportSaveSuccess(state, path)
+	setSavedText(state.documentBuffer, newTextSnapshot(tree))
+	reportSaveSuccess(state, path, result)
 }
 
 // SaveDocumentIfUnsavedChanges saves the document only if it has been edited
 // or the file does not exist on disk.
 func SaveDocumentIfUnsavedChanges(state *EditorState) {
+	if state.documentBuffer.scratch {
+		return
+	}
+
 	path := state.fileWatcher.Path()
 	_, err := os.Stat(path)
 	undoLog := state.documentBuffer.undoLog
@@ -397,21 +666,36 @@ func reportSaveError(state *EditorState, err error, path string) {
 		Style: StatusMsgStyleError,
 		Text:  fmt.Sprintf("Could not save %q: %s", file.RelativePathCwd(path), err),
 	})
+	runEventHook(state, HookEventSaveFailed, err.Error())
 }
 
-func reportSaveSuccess(state *EditorState, path string) {
-	log.Printf("Successfully wrote file to %q", path)
+func reportSaveSuccess(state *EditorState, path string, result file.SaveResult) {
+	log.Printf("Successfully wrote file to %q (target %q, method %s)", path, result.TargetPath, result.Method)
+
+	// Tell the user if the save did anything other than replace a regular file,
+	// since symlinks and hard links might be shared with other paths.
+	msg := fmt.Sprintf("Saved %s", path)
+	if result.ReplacedSymlink {
+		msg += " (replaced symlink)"
+	} else if result.TargetPath != path {
+		msg += fmt.Sprintf(" (through symlink to %s)", result.TargetPath)
+	}
+	if result.Method == file.SaveMethodInPlace {
+		msg += " (in place to preserve hard links)"
+	}
+
 	SetStatusMsg(state, StatusMsg{
 		Style: StatusMsgStyleSuccess,
-		Text:  fmt.Sprintf("Saved %s", path),
+		Text:  msg,
 	})
+	runEventHook(state, HookEventSave, msg)
 }
 
 const DefaultUnsavedChangesAbortMsg = `Document has unsaved changes. Either save them ("force save") or discard them ("force reload") and try again`
 
 // AbortIfUnsavedChanges executes a function only if the document does not have unsaved changes and shows an error status msg otherwise.
 func AbortIfUnsavedChanges(state *EditorState, abortMsg string, f func(*EditorState)) {
-	if state.documentBuffer.undoLog.HasUnsavedChanges() {
+	if state.documentBuffer.undoLog.HasUnsavedChanges() && !state.documentBuffer.scratch {
 		log.Printf("Aborting operation because document has unsaved changes\n")
 		if abortMsg != "" {
 			SetStatusMsg(state, StatusMsg{
@@ -429,6 +713,12 @@ func AbortIfUnsavedChanges(state *EditorState, abortMsg string, f func(*EditorSt
 // AbortIfFileChanged aborts with an error message if the file has changed on disk.
 // Specifically, abort if the file was moved/deleted or its content checksum has changed.
 func AbortIfFileChanged(state *EditorState, f func(*EditorState)) {
+	if state.documentBuffer.scratch {
+		// Scratch buffers have no file on disk to check.
+		f(state)
+		return
+	}
+
 	path := state.fileWatcher.Path()
 	filename := filepath.Base(path)
 
@@ -470,6 +760,15 @@ func AbortIfFileChanged(state *EditorState, f func(*EditorState)) {
 		return
 	}
 
+	if err := checkFileLockedByOtherInstance(state, path); err != nil {
+		log.Printf("Aborting operation because file has unsaved changes in another instance: %s\n", err)
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("%s. Use \"force save\" to overwrite.", err),
+		})
+		return
+	}
+
 	// All checks passed, so execute the action.
 	f(state)
 }
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"io"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/selection"
 	"github.com/aretext/aretext/syntax"
 )
 
@@ -211,6 +213,75 @@ func TestReloadDocumentAlignCursorAndScroll(t *testing.T) {
 	assert.Equal(t, uint64(31), state.documentBuffer.view.textOrigin)
 }
 
+func TestReloadDocumentPreserveSelection(t *testing.T) {
+	testCases := []struct {
+		name          string
+		selectionMode selection.Mode
+		expectedText  string
+	}{
+		{
+			name:          "charwise",
+			selectionMode: selection.ModeChar,
+			expectedText:  "fghi\njk",
+		},
+		{
+			name:          "linewise",
+			selectionMode: selection.ModeLine,
+			expectedText:  "efghi\njklmnop",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			initialText := "abcd\nefghi\njklmnop\nqrst"
+			path, cleanup := createTestFile(t, initialText)
+			defer cleanup()
+			state := NewEditorState(100, 100, nil, nil)
+			defer state.fileWatcher.Stop()
+			LoadDocument(state, path, true, startOfDocLocator)
+
+			// Select from "f" to "k".
+			state.documentBuffer.cursor.position = 6
+			ToggleVisualMode(state, tc.selectionMode)
+			state.documentBuffer.cursor.position = 12
+
+			// Insert lines at the start of the document and reload.
+			err := os.WriteFile(path, []byte("123\n456\n"+initialText), 0644)
+			require.NoError(t, err)
+			ReloadDocument(state)
+			defer state.fileWatcher.Stop()
+
+			// Expect the same text is selected in the new document.
+			assert.Equal(t, InputModeVisual, state.InputMode())
+			assert.Equal(t, tc.selectionMode, state.documentBuffer.selector.Mode())
+			region := state.documentBuffer.SelectedRegion()
+			selectedText := state.documentBuffer.textTree.String()[region.StartPos:region.EndPos]
+			assert.Equal(t, tc.expectedText, selectedText)
+		})
+	}
+}
+
+func TestReloadDocumentAlignChangedLine(t *testing.T) {
+	initialText := "abcd\nefghi\njklmnop\nqrst"
+	path, cleanup := createTestFile(t, initialText)
+	defer cleanup()
+	state := NewEditorState(100, 100, nil, nil)
+	defer state.fileWatcher.Stop()
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	// Move the cursor to the third line.
+	state.documentBuffer.cursor.position = 13
+
+	// Insert lines at the start of the document and change the cursor line.
+	err := os.WriteFile(path, []byte("123\n456\nabcd\nefghi\nJKLMNOP\nqrst"), 0644)
+	require.NoError(t, err)
+	ReloadDocument(state)
+	defer state.fileWatcher.Stop()
+
+	// Expect that the cursor is on the changed line, offset from the preceding unchanged line.
+	assert.Equal(t, uint64(21), state.documentBuffer.cursor.position)
+}
+
 func TestReloadDocumentWithMenuOpen(t *testing.T) {
 	// Load the initial document.
 	path, cleanup := createTestFile(t, "abcd\nefghi\njklmnop\nqrst")
@@ -232,6 +303,90 @@ func TestReloadDocumentWithMenuOpen(t *testing.T) {
 	// Expect that the input mode is normal and the menu is hidden.
 	assert.Equal(t, "ab", state.documentBuffer.textTree.String())
 	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Contains(t, state.StatusMsg().Text, "(menu closed)")
+}
+
+func TestReloadDocumentWithMenuOpenFromVisualMode(t *testing.T) {
+	path, cleanup := createTestFile(t, "abcd\nefghi")
+	defer cleanup()
+	state := NewEditorState(100, 100, nil, nil)
+	defer state.fileWatcher.Stop()
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	ToggleVisualMode(state, selection.ModeChar)
+	state.documentBuffer.cursor.position = 2
+	ShowMenu(state, MenuStyleCommand, nil)
+
+	err := os.WriteFile(path, []byte("abcd\nEFGHI"), 0644)
+	require.NoError(t, err)
+	ReloadDocument(state)
+	defer state.fileWatcher.Stop()
+
+	// Expect that the menu closed and the selection was restored.
+	assert.Equal(t, InputModeVisual, state.InputMode())
+	assert.Equal(t, selection.Region{StartPos: 0, EndPos: 3}, state.documentBuffer.SelectedRegion())
+}
+
+func TestReloadDocumentWithTextFieldOpen(t *testing.T) {
+	path, cleanup := createTestFile(t, "abcd")
+	defer cleanup()
+	state := NewEditorState(100, 100, nil, nil)
+	defer state.fileWatcher.Stop()
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	var submitted string
+	ShowTextField(state, "Prompt:", func(_ *EditorState, s string) error {
+		submitted = s
+		return nil
+	}, nil)
+	AppendRuneToTextField(state, 'x')
+	AppendRuneToTextField(state, 'y')
+
+	err := os.WriteFile(path, []byte("efgh"), 0644)
+	require.NoError(t, err)
+	ReloadDocument(state)
+	defer state.fileWatcher.Stop()
+
+	// Expect that the text field is still open with the same input.
+	assert.Equal(t, "efgh", state.documentBuffer.textTree.String())
+	assert.Equal(t, InputModeTextField, state.InputMode())
+	assert.Equal(t, "Prompt:", state.TextField().PromptText())
+	assert.Equal(t, "xy", state.TextField().InputText())
+	assert.Contains(t, state.StatusMsg().Text, "(prompt still open)")
+
+	ExecuteTextFieldAction(state)
+	assert.Equal(t, "xy", submitted)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+}
+
+func TestReloadDocumentWithTaskRunning(t *testing.T) {
+	path, cleanup := createTestFile(t, "abcd")
+	defer cleanup()
+	state := NewEditorState(100, 100, nil, nil)
+	defer state.fileWatcher.Stop()
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	unblockChan := make(chan struct{})
+	var taskCompleted bool
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		<-unblockChan
+		return func(*EditorState) { taskCompleted = true }
+	})
+
+	err := os.WriteFile(path, []byte("efgh"), 0644)
+	require.NoError(t, err)
+	ReloadDocument(state)
+	defer state.fileWatcher.Stop()
+
+	// Expect that the task is still running after the reload.
+	assert.Equal(t, InputModeTask, state.InputMode())
+	assert.Contains(t, state.StatusMsg().Text, "(task still running)")
+
+	close(unblockChan)
+	action := <-state.TaskResultChan()
+	action(state)
+	assert.True(t, taskCompleted)
+	assert.Equal(t, InputModeNormal, state.InputMode())
 }
 
 func TestReloadDocumentPreserveSearchQueryAndDirection(t *testing.T) {
@@ -286,15 +441,21 @@ func TestReloadDocumentPreserveSearchQueryAndDirection(t *testing.T) {
 			ReloadDocument(state)
 			defer state.fileWatcher.Stop()
 
-			// Expect we're in normal mode after reload.
-			assert.Equal(t, InputModeNormal, state.InputMode())
-
 			// Expect that the search query and direction are preserved.
-			expectedSearch := searchState{query: "efg", direction: tc.direction}
 			if tc.completeSearch {
-				expectedSearch.history = []string{"efg"}
+				assert.Equal(t, InputModeNormal, state.InputMode())
+				expectedSearch := searchState{query: "efg", direction: tc.direction, history: []string{"efg"}}
+				assert.Equal(t, expectedSearch, state.documentBuffer.search)
+			} else {
+				// An incomplete search continues after the reload, matching the new text.
+				assert.Equal(t, InputModeSearch, state.InputMode())
+				search := state.documentBuffer.search
+				assert.Equal(t, "efg", search.query)
+				assert.Equal(t, tc.direction, search.direction)
+				require.NotNil(t, search.match)
+				assert.Equal(t, SearchMatch{StartPos: 3, EndPos: 6}, *search.match)
+				assert.Contains(t, state.StatusMsg().Text, "(search still in progress)")
 			}
-			assert.Equal(t, expectedSearch, state.documentBuffer.search)
 		})
 	}
 }
@@ -323,6 +484,113 @@ func TestSaveDocument(t *testing.T) {
 	assert.Equal(t, "x\n", string(contents))
 }
 
+func TestSaveDocumentCreateParentDirs(t *testing.T) {
+	testCases := []struct {
+		name             string
+		createParentDirs bool
+		expectSaved      bool
+	}{
+		{name: "disabled", createParentDirs: false, expectSaved: false},
+		{name: "enabled", createParentDirs: true, expectSaved: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configRuleSet := config.RuleSet{
+				{
+					Name:    "test",
+					Pattern: "**",
+					Config:  map[string]any{"createParentDirs": tc.createParentDirs},
+				},
+			}
+			state := NewEditorState(100, 100, configRuleSet, nil)
+			defer state.fileWatcher.Stop()
+			path := filepath.Join(t.TempDir(), "foo", "bar", "test.txt")
+			LoadDocument(state, path, false, func(LocatorParams) uint64 { return 0 })
+			assert.True(t, state.fileWatcher.IsNewFile())
+
+			InsertRune(state, 'x')
+			SaveDocument(state)
+
+			contents, err := os.ReadFile(path)
+			if tc.expectSaved {
+				require.NoError(t, err)
+				assert.Equal(t, "x\n", string(contents))
+				assert.Equal(t, StatusMsgStyleSuccess, state.statusMsg.Style)
+				assert.False(t, state.fileWatcher.IsNewFile())
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, StatusMsgStyleError, state.statusMsg.Style)
+			}
+		})
+	}
+}
+
+func TestSaveDocumentSymlink(t *testing.T) {
+	testCases := []struct {
+		name              string
+		symlinkSave       string
+		expectTarget      string
+		expectStatusMsg   string
+		expectSymlinkKept bool
+	}{
+		{
+			name:              "write to target",
+			symlinkSave:       config.SymlinkSaveTarget,
+			expectTarget:      "x\n",
+			expectStatusMsg:   "(through symlink to ",
+			expectSymlinkKept: true,
+		},
+		{
+			name:              "replace symlink",
+			symlinkSave:       config.SymlinkSaveReplace,
+			expectTarget:      "",
+			expectStatusMsg:   "(replaced symlink)",
+			expectSymlinkKept: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			targetPath := filepath.Join(tmpDir, "target.txt")
+			symlinkPath := filepath.Join(tmpDir, "link.txt")
+			err := os.WriteFile(targetPath, nil, 0644)
+			require.NoError(t, err)
+			err = os.Symlink(targetPath, symlinkPath)
+			require.NoError(t, err)
+
+			configRuleSet := config.RuleSet{
+				{
+					Name:    "test",
+					Pattern: "**",
+					Config:  map[string]any{"symlinkSave": tc.symlinkSave},
+				},
+			}
+			state := NewEditorState(100, 100, configRuleSet, nil)
+			LoadDocument(state, symlinkPath, true, startOfDocLocator)
+			defer state.fileWatcher.Stop()
+
+			InsertRune(state, 'x')
+			SaveDocument(state)
+			assert.Equal(t, StatusMsgStyleSuccess, state.statusMsg.Style)
+			assert.Contains(t, state.statusMsg.Text, tc.expectStatusMsg)
+
+			contents, err := os.ReadFile(symlinkPath)
+			require.NoError(t, err)
+			assert.Equal(t, "x\n", string(contents))
+
+			contents, err = os.ReadFile(targetPath)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectTarget, string(contents))
+
+			fileInfo, err := os.Lstat(symlinkPath)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectSymlinkKept, fileInfo.Mode()&os.ModeSymlink != 0)
+		})
+	}
+}
+
 func TestSaveDocumentIfUnsavedChanges(t *testing.T) {
 	// Start with an empty document.
 	state := NewEditorState(100, 100, nil, nil)
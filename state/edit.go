@@ -1,10 +1,12 @@
 package state
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/aretext/aretext/cellwidth"
@@ -17,6 +19,30 @@ import (
 	"github.com/aretext/aretext/undo"
 )
 
+var (
+	errDocumentReadOnly = errors.New("Document is read-only")
+	errDocumentLoading  = errors.New("Document is still loading")
+)
+
+// checkEditable returns an error if the document cannot be edited,
+// either because it is read-only or because it has not finished loading.
+func checkEditable(buffer *BufferState) error {
+	if buffer.loading {
+		return errDocumentLoading
+	}
+	if buffer.readOnly {
+		return errDocumentReadOnly
+	}
+	return nil
+}
+
+func setNotEditableStatusMsg(state *EditorState, err error) {
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  err.Error(),
+	})
+}
+
 // InsertRune inserts a single rune at the current cursor location.
 func InsertRune(state *EditorState, r rune) {
 	InsertText(state, string(r))
@@ -24,13 +50,18 @@ func InsertRune(state *EditorState, r rune) {
 
 // InsertText inserts multiple runes at the current cursor location.
 func InsertText(state *EditorState, text string) {
+	trackInsertStart(state)
 	buffer := state.documentBuffer
 	startPos := buffer.cursor.position
 	if err := insertTextAtPosition(state, text, startPos, true); err != nil {
 		log.Printf("Error inserting text: %v\n", err)
 		return
 	}
-	buffer.cursor.position = startPos + uint64(utf8.RuneCountInString(text))
+	// Advance the cursor past the inserted text. If the inserted text combined with a following
+	// character to form a single grapheme cluster (for example, when composing Hangul syllables
+	// with an input method), move the cursor past the entire cluster so it stays on a boundary.
+	endPos := startPos + uint64(utf8.RuneCountInString(text))
+	buffer.cursor.position = locate.GraphemeClusterBoundary(buffer.textTree, endPos)
 }
 
 // insertTextAtPosition inserts text into the document.
@@ -38,15 +69,16 @@ func InsertText(state *EditorState, text string) {
 // It does NOT move the cursor.
 func insertTextAtPosition(state *EditorState, s string, pos uint64, updateUndoLog bool) error {
 	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return err
+	}
 
-	var n uint64
-	for _, r := range s {
-		if err := buffer.textTree.InsertAtPosition(pos+n, r); err != nil {
-			return fmt.Errorf("text.Tree.InsertAtPosition: %w", err)
-		}
-		n++
+	if err := buffer.textTree.ReplaceRange(pos, 0, s); err != nil {
+		return fmt.Errorf("text.Tree.ReplaceRange: %w", err)
 	}
 
+	n := uint64(utf8.RuneCountInString(s))
 	edit := parser.NewInsertEdit(pos, n)
 	retokenizeAfterEdit(buffer, edit)
 
@@ -71,6 +103,7 @@ func mustInsertRuneAtPosition(state *EditorState, r rune, pos uint64, updateUndo
 
 // InsertNewline inserts a newline at the current cursor position.
 func InsertNewline(state *EditorState) {
+	trackInsertStart(state)
 	cursorPos := state.documentBuffer.cursor.position
 	mustInsertRuneAtPosition(state, '\n', cursorPos, true)
 	cursorPos++
@@ -169,6 +202,7 @@ func ClearAutoIndentWhitespaceLine(state *EditorState, startOfLineLoc Locator) {
 
 // InsertTab inserts a tab at the current cursor position.
 func InsertTab(state *EditorState) {
+	trackInsertStart(state)
 	cursorPos := state.documentBuffer.cursor.position
 	newCursorPos := insertTabsAtPos(state, cursorPos, tabText(state, 1))
 	state.documentBuffer.cursor = cursorState{position: newCursorPos}
@@ -244,7 +278,7 @@ func DeleteToPos(state *EditorState, loc Locator, clipboardPage clipboard.PageId
 	}
 
 	if deletedText != "" {
-		state.clipboard.Set(clipboardPage, clipboard.PageContent{
+		state.clipboard.SetDeleted(clipboardPage, clipboard.PageContent{
 			Text:     deletedText,
 			Linewise: false,
 		})
@@ -312,7 +346,7 @@ func DeleteLines(state *EditorState, targetLineLoc Locator, abortIfTargetIsCurre
 	}
 
 	if len(deletedText) > 0 {
-		state.clipboard.Set(clipboardPage, clipboard.PageContent{
+		state.clipboard.SetDeleted(clipboardPage, clipboard.PageContent{
 			Text:     stripStartingAndTrailingNewlines(deletedText),
 			Linewise: true,
 		})
@@ -335,19 +369,20 @@ func stripStartingAndTrailingNewlines(s string) string {
 // It also updates the syntax token and undo log.
 // It does NOT move the cursor.
 func deleteRunes(state *EditorState, pos uint64, count uint64, updateUndoLog bool) string {
-	deletedRunes := make([]rune, 0, count)
 	buffer := state.documentBuffer
-	for i := uint64(0); i < count; i++ {
-		didDelete, r := buffer.textTree.DeleteAtPosition(pos)
-		if didDelete {
-			deletedRunes = append(deletedRunes, r)
-		}
+	if err := checkEditable(buffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return ""
+	}
+
+	deletedText := copyText(buffer.textTree, pos, count)
+	if err := buffer.textTree.ReplaceRange(pos, count, ""); err != nil {
+		panic(err) // should never happen because the replacement is empty.
 	}
 
 	edit := parser.NewDeleteEdit(pos, count)
 	retokenizeAfterEdit(buffer, edit)
 
-	deletedText := string(deletedRunes)
 	if updateUndoLog && deletedText != "" {
 		op := undo.DeleteOp(pos, deletedText)
 		buffer.undoLog.TrackOp(op)
@@ -356,36 +391,82 @@ func deleteRunes(state *EditorState, pos uint64, count uint64, updateUndoLog boo
 	return deletedText
 }
 
-// ReplaceChar replaces the character under the cursor.
-func ReplaceChar(state *EditorState, newChar rune) {
+// replaceRunes replaces count runes starting at pos with text in a single edit.
+// This is more efficient than deleteRunes followed by insertTextAtPosition for large edits.
+// It also updates the syntax tokens and unsaved changes flag, and returns the replaced text.
+// It does NOT move the cursor.
+func replaceRunes(state *EditorState, pos uint64, count uint64, s string, updateUndoLog bool) (string, error) {
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return "", err
+	}
+
+	replacedText := copyText(buffer.textTree, pos, count)
+	if err := buffer.textTree.ReplaceRange(pos, count, s); err != nil {
+		return "", fmt.Errorf("text.Tree.ReplaceRange: %w", err)
+	}
+
+	numReplaced := uint64(utf8.RuneCountInString(replacedText))
+	numInserted := uint64(utf8.RuneCountInString(s))
+	edit := parser.NewReplaceEdit(pos, numReplaced, numInserted)
+	retokenizeAfterEdit(buffer, edit)
+
+	if updateUndoLog {
+		if replacedText != "" {
+			buffer.undoLog.TrackOp(undo.DeleteOp(pos, replacedText))
+		}
+		if s != "" {
+			buffer.undoLog.TrackOp(undo.InsertOp(pos, s))
+		}
+	}
+
+	return replacedText, nil
+}
+
+// ReplaceChar replaces count characters, starting from the character under the cursor.
+// If there are fewer than count characters remaining on the line, the document is unchanged.
+// Replacing with a newline inserts a single line break, regardless of the count.
+func ReplaceChar(state *EditorState, newChar rune, count uint64) {
 	buffer := state.documentBuffer
 	pos := state.documentBuffer.cursor.position
-	nextCharPos := locate.NextCharInLine(buffer.textTree, 1, true, pos)
+	endPos := pos
+	for i := uint64(0); i < count; i++ {
+		nextCharPos := locate.NextCharInLine(buffer.textTree, 1, true, endPos)
+		if nextCharPos == endPos {
+			// Not enough characters on the current line, so abort.
+			return
+		}
+		endPos = nextCharPos
+	}
 
-	if nextCharPos == pos {
-		// No character under the cursor on the current line, so abort.
+	if endPos == pos {
+		// Nothing to replace.
 		return
 	}
 
-	numToDelete := nextCharPos - pos
+	numToDelete := endPos - pos
 	deleteRunes(state, pos, numToDelete, true)
 
 	switch newChar {
 	case '\n':
 		InsertNewline(state)
 	case '\t':
-		InsertTab(state)
+		for i := uint64(0); i < count; i++ {
+			InsertTab(state)
+		}
 		MoveCursor(state, func(p LocatorParams) uint64 {
 			return locate.PrevCharInLine(p.TextTree, 1, false, p.CursorPos)
 		})
 	default:
-		newText := string(newChar)
+		newText := strings.Repeat(string(newChar), int(count))
 		if err := insertTextAtPosition(state, newText, pos, true); err != nil {
 			// invalid UTF-8 rune; ignore it.
 			log.Printf("Error inserting text %q: %v\n", newText, err)
+			return
 		}
 		MoveCursor(state, func(p LocatorParams) uint64 {
-			return pos
+			return pos + count - 1
 		})
 	}
 }
@@ -493,15 +574,37 @@ func ToggleCaseAtCursor(state *EditorState) {
 // ToggleCaseInSelection toggles the case of all characters in the region
 // from the cursor position to the position found by selectionEndLoc.
 func ToggleCaseInSelection(state *EditorState, selectionEndLoc Locator) {
+	changeCaseInSelection(state, selectionEndLoc, text.ToggleRuneCase)
+}
+
+// UpperCaseInSelection changes all characters to uppercase in the region
+// from the cursor position to the position found by selectionEndLoc.
+func UpperCaseInSelection(state *EditorState, selectionEndLoc Locator) {
+	changeCaseInSelection(state, selectionEndLoc, unicode.ToUpper)
+}
+
+// LowerCaseInSelection changes all characters to lowercase in the region
+// from the cursor position to the position found by selectionEndLoc.
+func LowerCaseInSelection(state *EditorState, selectionEndLoc Locator) {
+	changeCaseInSelection(state, selectionEndLoc, unicode.ToLower)
+}
+
+func changeCaseInSelection(state *EditorState, selectionEndLoc Locator, caseFunc func(rune) rune) {
 	buffer := state.documentBuffer
 	cursorPos := buffer.cursor.position
 	endPos := selectionEndLoc(locatorParamsForBuffer(buffer))
-	toggleCaseForRange(state, cursorPos, endPos)
+	changeCaseForRange(state, cursorPos, endPos, caseFunc)
 }
 
 // toggleCaseForRange changes the case of all characters in the range [startPos, endPos)
 // It does NOT move the cursor.
 func toggleCaseForRange(state *EditorState, startPos uint64, endPos uint64) {
+	changeCaseForRange(state, startPos, endPos, text.ToggleRuneCase)
+}
+
+// changeCaseForRange replaces each character in the range [startPos, endPos) with the result of caseFunc.
+// It does NOT move the cursor.
+func changeCaseForRange(state *EditorState, startPos uint64, endPos uint64, caseFunc func(rune) rune) {
 	tree := state.documentBuffer.textTree
 	newRunes := make([]rune, 0, 1)
 	reader := tree.ReaderAtPosition(startPos)
@@ -512,10 +615,11 @@ func toggleCaseForRange(state *EditorState, startPos uint64, endPos uint64) {
 		} else if err != nil {
 			panic(err) // Should never happen because the document is valid UTF-8.
 		}
-		newRunes = append(newRunes, text.ToggleRuneCase(r))
+		newRunes = append(newRunes, caseFunc(r))
+	}
+	if _, err := replaceRunes(state, startPos, uint64(len(newRunes)), string(newRunes), true); err != nil {
+		log.Printf("Error changing case: %v\n", err)
 	}
-	deleteRunes(state, startPos, uint64(len(newRunes)), true)
-	mustInsertTextAtPosition(state, string(newRunes), startPos, true)
 }
 
 // IndentLines indents every line from the current cursor position to the position found by targetLineLoc.
@@ -588,7 +692,7 @@ func CopyRange(state *EditorState, page clipboard.PageId, loc RangeLocator) {
 		return
 	}
 	text := copyText(state.documentBuffer.textTree, startPos, endPos-startPos)
-	state.clipboard.Set(page, clipboard.PageContent{Text: text})
+	state.clipboard.SetYanked(page, clipboard.PageContent{Text: text})
 }
 
 // CopyLine copies the line under the cursor to the default page in the clipboard.
@@ -601,7 +705,7 @@ func CopyLine(state *EditorState, page clipboard.PageId) {
 		Text:     line,
 		Linewise: true,
 	}
-	state.clipboard.Set(page, content)
+	state.clipboard.SetYanked(page, content)
 }
 
 // CopySelection copies the current selection to the clipboard.
@@ -612,7 +716,7 @@ func CopySelection(state *EditorState, page clipboard.PageId) {
 	if buffer.selector.Mode() == selection.ModeLine {
 		content.Linewise = true
 	}
-	state.clipboard.Set(page, content)
+	state.clipboard.SetYanked(page, content)
 
 	MoveCursor(state, func(LocatorParams) uint64 { return r.StartPos })
 }
@@ -668,6 +668,7 @@ func TestReplaceChar(t *testing.T) {
 		inputString    string
 		initialCursor  cursorState
 		newChar        rune
+		count          uint64
 		autoIndent     bool
 		tabExpand      bool
 		expectedCursor cursorState
@@ -731,6 +732,51 @@ func TestReplaceChar(t *testing.T) {
 			expectedCursor: cursorState{position: 3},
 			expectedText:   "ab  d",
 		},
+		{
+			name:           "replace multiple chars",
+			inputString:    "abcdef",
+			newChar:        'x',
+			count:          3,
+			initialCursor:  cursorState{position: 1},
+			expectedCursor: cursorState{position: 3},
+			expectedText:   "axxxef",
+		},
+		{
+			name:           "replace to end of line",
+			inputString:    "abc\ndef",
+			newChar:        'x',
+			count:          2,
+			initialCursor:  cursorState{position: 1},
+			expectedCursor: cursorState{position: 2},
+			expectedText:   "axx\ndef",
+		},
+		{
+			name:           "count past end of line",
+			inputString:    "abc\ndef",
+			newChar:        'x',
+			count:          3,
+			initialCursor:  cursorState{position: 1},
+			expectedCursor: cursorState{position: 1},
+			expectedText:   "abc\ndef",
+		},
+		{
+			name:           "replace multiple chars with newline",
+			inputString:    "abcdef",
+			newChar:        '\n',
+			count:          3,
+			initialCursor:  cursorState{position: 1},
+			expectedCursor: cursorState{position: 2},
+			expectedText:   "a\nef",
+		},
+		{
+			name:           "replace multiple chars with tab",
+			inputString:    "abcdef",
+			newChar:        '\t',
+			count:          2,
+			initialCursor:  cursorState{position: 1},
+			expectedCursor: cursorState{position: 2},
+			expectedText:   "a\t\tdef",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -743,7 +789,11 @@ func TestReplaceChar(t *testing.T) {
 			state.documentBuffer.autoIndent = tc.autoIndent
 			state.documentBuffer.tabExpand = tc.tabExpand
 			state.documentBuffer.tabSize = 4
-			ReplaceChar(state, tc.newChar)
+			count := tc.count
+			if count == 0 {
+				count = 1
+			}
+			ReplaceChar(state, tc.newChar, count)
 			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor)
 			assert.Equal(t, tc.expectedText, textTree.String())
 		})
@@ -877,6 +927,68 @@ func TestToggleCaseInSelection(t *testing.T) {
 	}
 }
 
+func TestUpperAndLowerCaseInSelection(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputString       string
+		upper             bool
+		selectionStartPos uint64
+		selectionEndPos   uint64
+		expectedText      string
+	}{
+		{
+			name:              "uppercase empty",
+			inputString:       "",
+			upper:             true,
+			selectionStartPos: 0,
+			selectionEndPos:   0,
+			expectedText:      "",
+		},
+		{
+			name:              "uppercase mixed case",
+			inputString:       "aBcDeFgH",
+			upper:             true,
+			selectionStartPos: 1,
+			selectionEndPos:   6,
+			expectedText:      "aBCDEFgH",
+		},
+		{
+			name:              "lowercase mixed case",
+			inputString:       "aBcDeFgH",
+			upper:             false,
+			selectionStartPos: 1,
+			selectionEndPos:   6,
+			expectedText:      "abcdefgH",
+		},
+		{
+			name:              "uppercase non-letters unchanged",
+			inputString:       "a1-ß_ü",
+			upper:             true,
+			selectionStartPos: 0,
+			selectionEndPos:   6,
+			expectedText:      "A1-ß_Ü",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			state.documentBuffer.cursor = cursorState{position: tc.selectionStartPos}
+			selectionEndLoc := func(p LocatorParams) uint64 { return tc.selectionEndPos }
+			if tc.upper {
+				UpperCaseInSelection(state, selectionEndLoc)
+			} else {
+				LowerCaseInSelection(state, selectionEndLoc)
+			}
+			assert.Equal(t, cursorState{position: tc.selectionStartPos}, state.documentBuffer.cursor)
+			assert.Equal(t, tc.expectedText, textTree.String())
+		})
+	}
+}
+
 func TestIndentLines(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -0,0 +1,281 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/encryption"
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+	"github.com/aretext/aretext/undo"
+)
+
+// Number of characters at the start of a document to check for an encrypted file header.
+// This is enough for the armor header, optional armor headers like "Version: ...", and the first line of the message.
+const encryptionDetectPrefixLen = 1024
+
+// decryptFunc decrypts a file. Tests may replace this to avoid depending on gpg.
+var decryptFunc = encryption.Decrypt
+
+// encryptFunc encrypts a file. Tests may replace this to avoid depending on gpg.
+var encryptFunc = encryption.Encrypt
+
+// encryptionState is the passphrase for a decrypted document, used to encrypt the document again when it is saved.
+// The decrypted text is kept only in memory.
+type encryptionState struct {
+	format     encryption.Format
+	passphrase string
+}
+
+// Encrypted returns whether the document will be encrypted when it is saved.
+func (s *BufferState) Encrypted() bool {
+	return s.encryption != nil
+}
+
+// checkEncryptedDocument prompts for a passphrase if the document is an encrypted file.
+// This is called after loading a document, and the document shows the encrypted text until the user enters the passphrase.
+func checkEncryptedDocument(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.scratch || buffer.encryption != nil {
+		return
+	}
+
+	format, err := detectEncryption(buffer.textTree)
+	if format == encryption.FormatNone {
+		return
+	}
+
+	path := state.fileWatcher.Path()
+	log.Printf("Document at %q is encrypted with %s\n", path, format)
+	if err != nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Could not decrypt %s: %s", file.RelativePathCwd(path), err),
+		})
+		return
+	}
+
+	showDecryptTextField(state, format)
+}
+
+func detectEncryption(tree *text.Tree) (encryption.Format, error) {
+	n := tree.NumChars()
+	if n > encryptionDetectPrefixLen {
+		n = encryptionDetectPrefixLen
+	}
+	return encryption.Detect(copyText(tree, 0, n))
+}
+
+// DecryptDocument prompts for a passphrase to decrypt the document.
+// This allows the user to retry after dismissing the prompt shown when the document loaded.
+func DecryptDocument(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.encryption != nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Document is already decrypted",
+		})
+		return
+	}
+
+	format, err := detectEncryption(buffer.textTree)
+	if format == encryption.FormatNone {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Document is not encrypted",
+		})
+		return
+	} else if err != nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Could not decrypt document: %s", err),
+		})
+		return
+	}
+
+	showDecryptTextField(state, format)
+}
+
+func showDecryptTextField(state *EditorState, format encryption.Format) {
+	buffer := state.documentBuffer
+	prompt := fmt.Sprintf("Passphrase to decrypt %s:", filepath.Base(state.fileWatcher.Path()))
+	ShowSecretTextField(state, prompt, func(state *EditorState, passphrase string) error {
+		if passphrase == "" {
+			return errors.New("Passphrase cannot be empty")
+		} else if state.documentBuffer != buffer {
+			return errors.New("Document changed before it could be decrypted")
+		}
+
+		// Hide the text field first, so it doesn't return to the previous input mode after starting the task.
+		HideTextField(state)
+		startDecryptTask(state, format, passphrase)
+		return nil
+	})
+}
+
+func startDecryptTask(state *EditorState, format encryption.Format, passphrase string) {
+	buffer := state.documentBuffer
+	ciphertext := buffer.textTree.String()
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		// Add back the line feed at the end of the file, which was removed when the document was loaded.
+		plaintext, err := decryptFunc(ctx, format, []byte(ciphertext+"\n"), passphrase)
+		return func(state *EditorState) {
+			if state.documentBuffer != buffer || buffer.textTree.String() != ciphertext {
+				SetStatusMsg(state, StatusMsg{
+					Style: StatusMsgStyleError,
+					Text:  "Document changed before it could be decrypted",
+				})
+				return
+			}
+
+			if err == nil && !utf8.Valid(plaintext) {
+				err = errors.New("Decrypted file is not valid UTF-8")
+			}
+
+			if err == nil {
+				err = loadDecryptedText(state, &encryptionState{format: format, passphrase: passphrase}, string(plaintext))
+			}
+
+			if err != nil {
+				// Prompt again, since the most likely problem is a mistyped passphrase.
+				showDecryptTextField(state, format)
+				SetStatusMsg(state, StatusMsg{
+					Style: StatusMsgStyleError,
+					Text:  fmt.Sprintf("Could not decrypt document: %s", err),
+				})
+				return
+			}
+
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  "Decrypted document. It will be encrypted again when saved",
+			})
+		}
+	})
+}
+
+// loadDecryptedText replaces the encrypted text in the document with the decrypted text.
+// The file watcher is unchanged, since the file on disk still contains the encrypted text.
+func loadDecryptedText(state *EditorState, enc *encryptionState, plaintext string) error {
+	// Like loading a file, remove the POSIX end-of-file indicator. Saving the document adds it back before encrypting.
+	tree, err := text.NewTreeFromString(strings.TrimSuffix(plaintext, "\n"))
+	if err != nil {
+		return err
+	}
+
+	path := state.fileWatcher.Path()
+	log.Printf("Decrypted document at %q\n", path)
+
+	buffer := state.documentBuffer
+	state.documentLoadCount++
+	buffer.textTree = tree
+	buffer.originalText = newTextSnapshot(tree)
+	setSavedText(buffer, buffer.originalText)
+	buffer.cursor = cursorState{}
+	buffer.view.textOrigin = 0
+	buffer.view.leftCol = 0
+	buffer.selector.Clear()
+	buffer.search = searchState{}
+	buffer.showBlame = false
+	buffer.undoLog.Close()
+	buffer.undoLog = undo.NewLogWithSpillThreshold(undo.NoSpill) // Decrypted text must never be written to disk.
+	buffer.undoCheckpoint = nil
+	buffer.encryption = enc
+
+	// Choose the syntax language for the decrypted file, so "notes.md.gpg" is highlighted like "notes.md".
+	cfg := state.configRuleSet.ConfigForPath(pathWithoutEncryptionExt(path))
+	buffer.syntaxBackend = syntax.Backend(cfg.SyntaxBackend)
+	setSyntaxAndRetokenize(buffer, syntax.Language(cfg.SyntaxLanguage))
+	return nil
+}
+
+func pathWithoutEncryptionExt(path string) string {
+	for _, ext := range []string{".gpg", ".asc", ".pgp", ".age"} {
+		if strings.HasSuffix(path, ext) && len(path) > len(ext) {
+			return strings.TrimSuffix(path, ext)
+		}
+	}
+	return path
+}
+
+// EncryptDocument prompts for a passphrase, then for the same passphrase again,
+// and encrypts the document with that passphrase when it is saved.
+// If the document is already encrypted, this changes the passphrase.
+func EncryptDocument(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.scratch {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Cannot encrypt a scratch buffer",
+		})
+		return
+	} else if buffer.encryption == nil {
+		if format, _ := detectEncryption(buffer.textTree); format != encryption.FormatNone {
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleError,
+				Text:  `Document is already encrypted. Use "decrypt document" to edit it`,
+			})
+			return
+		}
+	}
+
+	ShowSecretTextField(state, "Passphrase to encrypt document:", func(state *EditorState, passphrase string) error {
+		if passphrase == "" {
+			return errors.New("Passphrase cannot be empty")
+		}
+
+		ShowSecretTextField(state, "Confirm passphrase:", func(state *EditorState, confirmed string) error {
+			if confirmed != passphrase {
+				// Start over, since the user can't see which passphrase was mistyped.
+				EncryptDocument(state)
+				return errors.New("Passphrases do not match")
+			} else if state.documentBuffer != buffer {
+				return errors.New("Document changed before it could be encrypted")
+			}
+
+			buffer.encryption = &encryptionState{format: encryptionFormatForSave(buffer, state.fileWatcher.Path()), passphrase: passphrase}
+
+			// Keep the undo history, but stop moving large edits to the spill file, which is not encrypted.
+			// Any text spilled already came from the unencrypted document.
+			buffer.undoLog.DisableSpill()
+			log.Printf("Document at %q will be encrypted when saved\n", state.fileWatcher.Path())
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  "Document will be encrypted when saved",
+			})
+			return nil
+		})
+		return nil
+	})
+}
+
+// encryptionFormatForSave chooses the format to encrypt a document.
+// Changing the passphrase keeps the document's format. Otherwise, files named like "notes.age" use age, and other files use gpg.
+func encryptionFormatForSave(buffer *BufferState, path string) encryption.Format {
+	if buffer.encryption != nil {
+		return buffer.encryption.format
+	} else if strings.HasSuffix(path, ".age") {
+		return encryption.FormatAge
+	}
+	return encryption.FormatGPG
+}
+
+// encryptionPreSaveFilter returns a pre-save filter that encrypts the document with its passphrase.
+func encryptionPreSaveFilter(buffer *BufferState) (namedPreSaveFilter, bool) {
+	enc := buffer.encryption
+	if enc == nil {
+		return namedPreSaveFilter{}, false
+	}
+	return namedPreSaveFilter{
+		name: "encrypt",
+		filter: func(path string, data []byte) ([]byte, error) {
+			return encryptFunc(context.Background(), enc.format, data, enc.passphrase)
+		},
+	}, true
+}
@@ -0,0 +1,319 @@
+package state
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/encryption"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/undo"
+)
+
+const fakePassphrase = "correct horse"
+
+// fakeCiphertext returns an armored gpg message that starts with a symmetric-key packet header
+// followed by the plaintext, so tests don't depend on gpg.
+func fakeCiphertext(plaintext string) string {
+	body := append([]byte{0x8C, 0x0D}, plaintext...)
+	return "-----BEGIN PGP MESSAGE-----\n\n" + base64.StdEncoding.EncodeToString(body) + "\n-----END PGP MESSAGE-----\n"
+}
+
+func withFakeEncryption(t *testing.T) {
+	origDecryptFunc, origEncryptFunc := decryptFunc, encryptFunc
+	decryptFunc = func(ctx context.Context, format encryption.Format, ciphertext []byte, passphrase string) ([]byte, error) {
+		if passphrase != fakePassphrase {
+			return nil, errors.New("gpg: decryption failed: Bad session key")
+		}
+		lines := strings.Split(string(ciphertext), "\n")
+		body, err := base64.StdEncoding.DecodeString(lines[2])
+		if err != nil {
+			return nil, err
+		}
+		return body[2:], nil
+	}
+	encryptFunc = func(ctx context.Context, format encryption.Format, plaintext []byte, passphrase string) ([]byte, error) {
+		if passphrase != fakePassphrase {
+			return nil, errors.New("unexpected passphrase")
+		}
+		return []byte(fakeCiphertext(string(plaintext))), nil
+	}
+	t.Cleanup(func() {
+		decryptFunc, encryptFunc = origDecryptFunc, origEncryptFunc
+	})
+}
+
+func submitTextField(state *EditorState, s string) {
+	for _, r := range s {
+		AppendRuneToTextField(state, r)
+	}
+	ExecuteTextFieldAction(state)
+}
+
+func decryptForTest(t *testing.T, state *EditorState, passphrase string) {
+	require.Equal(t, InputModeTextField, state.InputMode())
+	require.True(t, state.TextField().Secret())
+	submitTextField(state, passphrase)
+	require.Equal(t, InputModeTask, state.InputMode())
+	action := <-state.TaskResultChan()
+	action(state)
+}
+
+func TestLoadEncryptedDocument(t *testing.T) {
+	withFakeEncryption(t)
+	path, cleanup := createTestFile(t, fakeCiphertext("secret\ntext\n"))
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	assert.Contains(t, state.TextField().PromptText(), "Passphrase to decrypt")
+
+	decryptForTest(t, state, fakePassphrase)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "secret\ntext", state.documentBuffer.textTree.String())
+	assert.True(t, state.documentBuffer.Encrypted())
+	assert.False(t, state.documentBuffer.undoLog.HasUnsavedChanges())
+	assert.Equal(t, "Decrypted document. It will be encrypted again when saved", state.StatusMsg().Text)
+
+	// Saving encrypts the document again, and the file watcher does not report a change.
+	InsertText(state, "more ")
+	SaveDocument(state)
+	assert.Equal(t, StatusMsgStyleSuccess, state.StatusMsg().Style)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, fakeCiphertext("more secret\ntext\n"), string(data))
+	changed, err := state.fileWatcher.CheckFileContentsChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestLoadEncryptedDocumentWrongPassphrase(t *testing.T) {
+	withFakeEncryption(t)
+	ciphertext := fakeCiphertext("secret")
+	path, cleanup := createTestFile(t, ciphertext)
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	// A wrong passphrase shows the prompt again.
+	decryptForTest(t, state, "wrong")
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "Could not decrypt document: gpg: decryption failed: Bad session key", state.StatusMsg().Text)
+	assert.Equal(t, strings.TrimSuffix(ciphertext, "\n"), state.documentBuffer.textTree.String())
+	assert.False(t, state.documentBuffer.Encrypted())
+
+	decryptForTest(t, state, fakePassphrase)
+	assert.Equal(t, "secret", state.documentBuffer.textTree.String())
+}
+
+func TestDecryptDocumentAfterDismissingPrompt(t *testing.T) {
+	withFakeEncryption(t)
+	path, cleanup := createTestFile(t, fakeCiphertext("secret"))
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	HideTextField(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+
+	DecryptDocument(state)
+	decryptForTest(t, state, fakePassphrase)
+	assert.Equal(t, "secret", state.documentBuffer.textTree.String())
+
+	DecryptDocument(state)
+	assert.Equal(t, "Document is already decrypted", state.StatusMsg().Text)
+}
+
+func TestDecryptDocumentSyntaxLanguage(t *testing.T) {
+	withFakeEncryption(t)
+	dir := t.TempDir()
+	path := dir + "/notes.json.gpg"
+	require.NoError(t, os.WriteFile(path, []byte(fakeCiphertext(`{"a": 1}`)), 0644))
+
+	configRuleSet := config.RuleSet{
+		{
+			Name:    "json",
+			Pattern: "**/*.json",
+			Config:  map[string]any{"syntaxLanguage": string(syntax.LanguageJson)},
+		},
+	}
+	state := NewEditorState(100, 100, configRuleSet, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	decryptForTest(t, state, fakePassphrase)
+	assert.Equal(t, syntax.LanguageJson, state.documentBuffer.SyntaxLanguage())
+}
+
+func TestReloadEncryptedDocumentPromptsAgain(t *testing.T) {
+	withFakeEncryption(t)
+	path, cleanup := createTestFile(t, fakeCiphertext("secret"))
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	decryptForTest(t, state, fakePassphrase)
+
+	require.NoError(t, os.WriteFile(path, []byte(fakeCiphertext("changed")), 0644))
+	ReloadDocument(state)
+	assert.False(t, state.documentBuffer.Encrypted())
+	decryptForTest(t, state, fakePassphrase)
+	assert.Equal(t, "changed", state.documentBuffer.textTree.String())
+}
+
+func TestLoadUnsupportedEncryptedDocument(t *testing.T) {
+	withFakeEncryption(t)
+	header := base64.StdEncoding.EncodeToString([]byte("age-encryption.org/v1\n-> X25519 a2V5\n"))
+	path, cleanup := createTestFile(t, "-----BEGIN AGE ENCRYPTED FILE-----\n"+header+"\n-----END AGE ENCRYPTED FILE-----\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Contains(t, state.StatusMsg().Text, "Files encrypted to an age recipient are not supported")
+}
+
+func TestEncryptDocument(t *testing.T) {
+	withFakeEncryption(t)
+	path, cleanup := createTestFile(t, "plain")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	// Mismatched passphrases start over.
+	EncryptDocument(state)
+	submitTextField(state, fakePassphrase)
+	submitTextField(state, "typo")
+	assert.Equal(t, "Passphrases do not match", state.StatusMsg().Text)
+	assert.Equal(t, "Passphrase to encrypt document:", state.TextField().PromptText())
+	assert.False(t, state.documentBuffer.Encrypted())
+
+	submitTextField(state, fakePassphrase)
+	submitTextField(state, fakePassphrase)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "Document will be encrypted when saved", state.StatusMsg().Text)
+	assert.True(t, state.documentBuffer.Encrypted())
+
+	SaveDocument(state)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, fakeCiphertext("plain\n"), string(data))
+}
+
+func TestEncryptDocumentFormat(t *testing.T) {
+	testCases := []struct {
+		name           string
+		filename       string
+		expectedFormat encryption.Format
+	}{
+		{
+			name:           "gpg by default",
+			filename:       "notes.md",
+			expectedFormat: encryption.FormatGPG,
+		},
+		{
+			name:           "age extension",
+			filename:       "notes.md.age",
+			expectedFormat: encryption.FormatAge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withFakeEncryption(t)
+			path := t.TempDir() + "/" + tc.filename
+			require.NoError(t, os.WriteFile(path, []byte("plain"), 0644))
+
+			state := NewEditorState(100, 100, nil, nil)
+			defer Quit(state)
+			LoadDocument(state, path, true, startOfDocLocator)
+			EncryptDocument(state)
+			submitTextField(state, fakePassphrase)
+			submitTextField(state, fakePassphrase)
+			require.True(t, state.documentBuffer.Encrypted())
+			assert.Equal(t, tc.expectedFormat, state.documentBuffer.encryption.format)
+		})
+	}
+}
+
+func TestEncryptDocumentRunsAfterPluginFilters(t *testing.T) {
+	withFakeEncryption(t)
+	path, cleanup := createTestFile(t, fakeCiphertext("secret"))
+	defer cleanup()
+
+	registry := NewPluginRegistry()
+	registry.RegisterPreSaveFilter("upper", func(path string, data []byte) ([]byte, error) {
+		return []byte(strings.ToUpper(string(data))), nil
+	})
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	SetPluginRegistry(state, registry)
+	LoadDocument(state, path, true, startOfDocLocator)
+	decryptForTest(t, state, fakePassphrase)
+
+	SaveDocument(state)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, fakeCiphertext("SECRET\n"), string(data))
+}
+
+func TestEncryptedDocumentUndoNeverSpills(t *testing.T) {
+	testCases := []struct {
+		name        string
+		fileContent string
+		encrypt     func(t *testing.T, state *EditorState)
+	}{
+		{
+			name:        "decrypted document",
+			fileContent: fakeCiphertext("secret"),
+			encrypt: func(t *testing.T, state *EditorState) {
+				decryptForTest(t, state, fakePassphrase)
+			},
+		},
+		{
+			name:        "document encrypted in the editor",
+			fileContent: "plain",
+			encrypt: func(t *testing.T, state *EditorState) {
+				EncryptDocument(state)
+				submitTextField(state, fakePassphrase)
+				submitTextField(state, fakePassphrase)
+				require.True(t, state.documentBuffer.Encrypted())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withFakeEncryption(t)
+			path, cleanup := createTestFile(t, tc.fileContent)
+			defer cleanup()
+
+			state := NewEditorState(100, 100, nil, nil)
+			defer Quit(state)
+			LoadDocument(state, path, true, startOfDocLocator)
+			tc.encrypt(t, state)
+
+			// Text this large would be moved to the spill file in an unencrypted document.
+			BeginUndoEntry(state)
+			InsertText(state, strings.Repeat("a", undo.DefaultSpillThreshold))
+			CommitUndoEntry(state)
+			assert.False(t, state.documentBuffer.undoLog.HasSpillFile())
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/nondet"
+)
+
+// FileExplorerState is a tree of the files and directories in a directory,
+// which the user can browse to open, create, rename, and delete files.
+type FileExplorerState struct {
+	rootDir      string
+	entries      []FileExplorerEntry // Visible entries, in the order displayed.
+	expandedDirs map[string]struct{} // Paths of directories whose children are visible.
+	selectedIdx  int
+}
+
+// FileExplorerEntry is a file or directory shown in the file explorer.
+type FileExplorerEntry struct {
+	Path     string
+	Name     string
+	Depth    int // Number of directories between the root directory and the entry.
+	IsDir    bool
+	Expanded bool
+}
+
+// RootDir returns the absolute path of the directory shown in the file explorer.
+func (e *FileExplorerState) RootDir() string {
+	return e.rootDir
+}
+
+// Entries returns the visible entries and the index of the selected entry.
+func (e *FileExplorerState) Entries() ([]FileExplorerEntry, int) {
+	return e.entries, e.selectedIdx
+}
+
+// SelectedEntry returns the selected entry, or false if the directory is empty.
+func (e *FileExplorerState) SelectedEntry() (FileExplorerEntry, bool) {
+	if e.selectedIdx >= len(e.entries) {
+		return FileExplorerEntry{}, false
+	}
+	return e.entries[e.selectedIdx], true
+}
+
+// selectedDir returns the directory containing the selected entry, or the selected entry itself if it is a directory.
+func (e *FileExplorerState) selectedDir() string {
+	entry, ok := e.SelectedEntry()
+	if !ok {
+		return e.rootDir
+	} else if entry.IsDir {
+		return entry.Path
+	}
+	return filepath.Dir(entry.Path)
+}
+
+// selectPath selects the entry with the given path, returning false if no visible entry has the path.
+func (e *FileExplorerState) selectPath(path string) bool {
+	for i, entry := range e.entries {
+		if entry.Path == path {
+			e.selectedIdx = i
+			return true
+		}
+	}
+	return false
+}
+
+// refresh lists the root directory and its expanded subdirectories again, keeping the selected path if it still exists.
+func (e *FileExplorerState) refresh(hidePatterns []string) error {
+	selected, _ := e.SelectedEntry()
+	entries, err := fileExplorerEntriesInDir(e.rootDir, 0, e.expandedDirs, hidePatterns)
+	if err != nil {
+		return err
+	}
+	e.entries = entries
+	if !e.selectPath(selected.Path) && e.selectedIdx >= len(e.entries) {
+		e.selectedIdx = max(len(e.entries)-1, 0)
+	}
+	return nil
+}
+
+// fileExplorerEntriesInDir lists the entries in a directory, directories first, followed by the entries in expanded subdirectories.
+// Errors listing a subdirectory are logged, and the subdirectory is shown as empty.
+func fileExplorerEntriesInDir(dir string, depth int, expandedDirs map[string]struct{}, hidePatterns []string) ([]FileExplorerEntry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs, files []FileExplorerEntry
+	for _, d := range dirEntries {
+		path := filepath.Join(dir, d.Name())
+		if shouldHideFileExplorerPath(path, hidePatterns) {
+			continue
+		}
+
+		entry := FileExplorerEntry{
+			Path:  path,
+			Name:  d.Name(),
+			Depth: depth,
+			IsDir: isDirOrLinkToDir(d, path),
+		}
+		if entry.IsDir {
+			dirs = append(dirs, entry)
+		} else {
+			files = append(files, entry)
+		}
+	}
+
+	// os.ReadDir sorts entries by name, so each group is already sorted.
+	entries := make([]FileExplorerEntry, 0, len(dirs)+len(files))
+	for _, entry := range dirs {
+		_, entry.Expanded = expandedDirs[entry.Path]
+		entries = append(entries, entry)
+		if entry.Expanded {
+			children, err := fileExplorerEntriesInDir(entry.Path, depth+1, expandedDirs, hidePatterns)
+			if err != nil {
+				log.Printf("Error listing directory %q in file explorer: %v\n", entry.Path, err)
+			}
+			entries = append(entries, children...)
+		}
+	}
+	return append(entries, files...), nil
+}
+
+func isDirOrLinkToDir(d fs.DirEntry, path string) bool {
+	if d.Type()&fs.ModeSymlink == 0 {
+		return d.IsDir()
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func shouldHideFileExplorerPath(path string, hidePatterns []string) bool {
+	for _, pattern := range hidePatterns {
+		if file.GlobMatch(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShowFileExplorer opens the file explorer at a directory.
+// If the document is within the directory, the explorer expands the subdirectories containing it and selects it.
+func ShowFileExplorer(state *EditorState, dir string) {
+	rootDir, err := filepath.Abs(dir)
+	if err != nil {
+		reportFileExplorerError(state, fmt.Errorf("filepath.Abs: %w", err))
+		return
+	}
+
+	explorer := &FileExplorerState{
+		rootDir:      rootDir,
+		expandedDirs: make(map[string]struct{}),
+	}
+
+	docPath := state.fileWatcher.Path()
+	for d := filepath.Dir(docPath); isWithinDir(d, rootDir); d = filepath.Dir(d) {
+		explorer.expandedDirs[d] = struct{}{}
+	}
+
+	if err := explorer.refresh(state.hidePatterns); err != nil {
+		log.Printf("Error listing directory %q in file explorer: %v\n", rootDir, err)
+		reportFileExplorerError(state, err)
+		return
+	}
+	explorer.selectPath(docPath)
+
+	state.fileExplorer = explorer
+	setInputMode(state, InputModeFileExplorer)
+}
+
+// isWithinDir returns whether path is a subdirectory of dir, not dir itself.
+func isWithinDir(path string, dir string) bool {
+	relPath, err := filepath.Rel(dir, path)
+	return err == nil && relPath != "." && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+// HideFileExplorer closes the file explorer and returns to normal mode.
+func HideFileExplorer(state *EditorState) {
+	setInputMode(state, InputModeNormal)
+}
+
+// MoveFileExplorerSelection moves the selection up (negative delta) or down (positive delta),
+// stopping at the first and last entries.
+func MoveFileExplorerSelection(state *EditorState, delta int) {
+	explorer := state.fileExplorer
+	idx := explorer.selectedIdx + delta
+	if idx >= len(explorer.entries) {
+		idx = len(explorer.entries) - 1
+	}
+	explorer.selectedIdx = max(idx, 0)
+}
+
+// OpenFileExplorerEntry opens the selected file as the document, or expands or collapses the selected directory.
+func OpenFileExplorerEntry(state *EditorState) {
+	explorer := state.fileExplorer
+	entry, ok := explorer.SelectedEntry()
+	if !ok {
+		return
+	}
+
+	if entry.IsDir {
+		if entry.Expanded {
+			delete(explorer.expandedDirs, entry.Path)
+		} else {
+			explorer.expandedDirs[entry.Path] = struct{}{}
+		}
+		refreshFileExplorer(state)
+		return
+	}
+
+	HideFileExplorer(state)
+	LoadDocument(state, entry.Path, true, func(LocatorParams) uint64 { return 0 })
+}
+
+// CollapseFileExplorerEntry collapses the selected directory if it is expanded.
+// Otherwise, it selects the directory containing the selected entry.
+func CollapseFileExplorerEntry(state *EditorState) {
+	explorer := state.fileExplorer
+	entry, ok := explorer.SelectedEntry()
+	if !ok {
+		return
+	}
+
+	if entry.IsDir && entry.Expanded {
+		delete(explorer.expandedDirs, entry.Path)
+		refreshFileExplorer(state)
+		return
+	}
+
+	explorer.selectPath(filepath.Dir(entry.Path))
+}
+
+// MoveFileExplorerToParentDir shows the parent of the explorer's directory, selecting the directory shown before.
+func MoveFileExplorerToParentDir(state *EditorState) {
+	explorer := state.fileExplorer
+	oldRootDir := explorer.rootDir
+	newRootDir := filepath.Dir(oldRootDir)
+	if newRootDir == oldRootDir {
+		// Already at the root of the file system.
+		return
+	}
+
+	explorer.rootDir = newRootDir
+	explorer.expandedDirs[oldRootDir] = struct{}{}
+	if err := explorer.refresh(state.hidePatterns); err != nil {
+		explorer.rootDir = oldRootDir
+		reportFileExplorerError(state, err)
+		return
+	}
+	explorer.selectPath(oldRootDir)
+}
+
+// CreateFileExplorerPath creates a path relative to the selected directory (or the directory containing the selected file).
+// If the path ends with a slash, it creates a directory. Otherwise, it opens a new document at the path,
+// which is created on disk when the user saves it.
+func CreateFileExplorerPath(state *EditorState, name string) error {
+	explorer := state.fileExplorer
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("File path is empty")
+	}
+
+	dir := explorer.selectedDir()
+	path := filepath.Join(dir, name)
+	if !strings.HasSuffix(name, "/") && !strings.HasSuffix(name, string(filepath.Separator)) {
+		return NewDocument(state, path)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("File already exists: %s", file.RelativePathCwd(path))
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return fmt.Errorf("Could not create directory: %w", err)
+	}
+	log.Printf("Created directory %q from file explorer\n", path)
+
+	explorer.expandedDirs[dir] = struct{}{}
+	refreshFileExplorer(state)
+	explorer.selectPath(path)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Created directory %s", file.RelativePathCwd(path)),
+	})
+	return nil
+}
+
+// RenameFileExplorerEntry renames or moves the selected entry.
+// A relative path is relative to the directory containing the entry.
+func RenameFileExplorerEntry(state *EditorState, newName string) error {
+	explorer := state.fileExplorer
+	entry, ok := explorer.SelectedEntry()
+	if !ok {
+		return errors.New("No file selected")
+	} else if err := checkFileExplorerEntryNotOpen(state, entry, "rename"); err != nil {
+		return err
+	}
+
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return errors.New("File path is empty")
+	}
+
+	newPath := newName
+	if !filepath.IsAbs(newPath) {
+		newPath = filepath.Join(filepath.Dir(entry.Path), newName)
+	}
+
+	// Check that the rename won't overwrite another file.
+	// Some other process could create a file at the path before the rename,
+	// but this at least reduces the risk of overwriting it.
+	if err := file.ValidateCreate(newPath); err != nil {
+		return err
+	}
+
+	if err := os.Rename(entry.Path, newPath); err != nil {
+		return fmt.Errorf("Could not rename %s: %w", file.RelativePathCwd(entry.Path), err)
+	}
+	log.Printf("Renamed %q to %q from file explorer\n", entry.Path, newPath)
+
+	if _, ok := explorer.expandedDirs[entry.Path]; ok {
+		delete(explorer.expandedDirs, entry.Path)
+		explorer.expandedDirs[newPath] = struct{}{}
+	}
+	explorer.expandedDirs[filepath.Dir(newPath)] = struct{}{}
+	refreshFileExplorer(state)
+	explorer.selectPath(newPath)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Renamed %s to %s", file.RelativePathCwd(entry.Path), file.RelativePathCwd(newPath)),
+	})
+	return nil
+}
+
+// DeleteFileExplorerEntry moves the selected file to the trash, or deletes the selected directory if it is empty.
+// A trashed file can be restored with RestoreLastTrashedFile until the editor exits.
+func DeleteFileExplorerEntry(state *EditorState) error {
+	explorer := state.fileExplorer
+	entry, ok := explorer.SelectedEntry()
+	if !ok {
+		return errors.New("No file selected")
+	} else if err := checkFileExplorerEntryNotOpen(state, entry, "delete"); err != nil {
+		return err
+	}
+
+	relPath := file.RelativePathCwd(entry.Path)
+	var msg string
+	if entry.IsDir {
+		if err := os.Remove(entry.Path); err != nil {
+			log.Printf("Error deleting directory %q: %v\n", entry.Path, err)
+			return fmt.Errorf("Could not delete directory %s (only empty directories can be deleted)", relPath)
+		}
+		log.Printf("Deleted directory %q from file explorer\n", entry.Path)
+		delete(explorer.expandedDirs, entry.Path)
+		msg = fmt.Sprintf("Deleted directory %s", relPath)
+	} else {
+		if state.trash == nil {
+			return errors.New("Trash is not available")
+		}
+		trashedFile, err := state.trash.MoveToTrash(entry.Path, nondet.Now())
+		if err != nil {
+			log.Printf("Error moving %q to trash: %v\n", entry.Path, err)
+			return fmt.Errorf("Could not move %q to trash: %w", relPath, err)
+		}
+		log.Printf("Moved %q to trash from file explorer\n", entry.Path)
+		state.lastTrashedFile = &trashedFile
+		msg = fmt.Sprintf(`Moved %s to trash. Use "restore last trashed file" to undo`, relPath)
+	}
+
+	refreshFileExplorer(state)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  msg,
+	})
+	return nil
+}
+
+// checkFileExplorerEntryNotOpen returns an error if the entry is a file open in the editor,
+// since renaming or deleting it would leave the document pointing at a missing file.
+func checkFileExplorerEntryNotOpen(state *EditorState, entry FileExplorerEntry, verb string) error {
+	if !entry.IsDir && openBufferIdxForPath(state, entry.Path) >= 0 {
+		return fmt.Errorf("Cannot %s %s because it is open in the editor", verb, file.RelativePathCwd(entry.Path))
+	}
+	return nil
+}
+
+// refreshFileExplorer lists the explorer's directory again, reporting an error if the directory can no longer be listed.
+func refreshFileExplorer(state *EditorState) {
+	if err := state.fileExplorer.refresh(state.hidePatterns); err != nil {
+		log.Printf("Error refreshing file explorer: %v\n", err)
+		reportFileExplorerError(state, err)
+	}
+}
+
+func reportFileExplorerError(state *EditorState, err error) {
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("Could not list directory: %s", err),
+	})
+}
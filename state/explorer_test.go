@@ -0,0 +1,219 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+// setupFileExplorerTestDir creates a directory with the given files and directories (paths ending with "/").
+func setupFileExplorerTestDir(t *testing.T, paths ...string) string {
+	dir := t.TempDir()
+	for _, p := range paths {
+		path := filepath.Join(dir, filepath.FromSlash(p))
+		if strings.HasSuffix(p, "/") {
+			require.NoError(t, os.MkdirAll(path, 0755))
+		} else {
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(p), 0644))
+		}
+	}
+	return dir
+}
+
+// fileExplorerTree describes the visible entries, indented by depth, with a "*" before the selected entry.
+func fileExplorerTree(explorer *FileExplorerState) []string {
+	entries, selectedIdx := explorer.Entries()
+	var tree []string
+	for i, entry := range entries {
+		s := strings.Repeat("  ", entry.Depth) + entry.Name
+		if entry.IsDir {
+			s += "/"
+		}
+		if i == selectedIdx {
+			s = "*" + s
+		}
+		tree = append(tree, s)
+	}
+	return tree
+}
+
+func TestShowFileExplorer(t *testing.T) {
+	dir := setupFileExplorerTestDir(t, "b.txt", "a.txt", "dir/c.txt", "dir/sub/d.txt", "hidden/e.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	state.hidePatterns = []string{"**/hidden"}
+
+	ShowFileExplorer(state, dir)
+	require.NotNil(t, state.FileExplorer())
+	assert.Equal(t, InputModeFileExplorer, state.InputMode())
+	assert.Equal(t, dir, state.FileExplorer().RootDir())
+	assert.Equal(t, []string{"*dir/", "a.txt", "b.txt"}, fileExplorerTree(state.FileExplorer()))
+
+	HideFileExplorer(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Nil(t, state.FileExplorer())
+}
+
+func TestShowFileExplorerSelectsDocument(t *testing.T) {
+	dir := setupFileExplorerTestDir(t, "a.txt", "dir/c.txt", "dir/sub/d.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, filepath.Join(dir, "dir", "sub", "d.txt"), true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+
+	ShowFileExplorer(state, dir)
+	assert.Equal(t, []string{"dir/", "  sub/", "*    d.txt", "  c.txt", "a.txt"}, fileExplorerTree(state.FileExplorer()))
+}
+
+func TestShowFileExplorerMissingDir(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	ShowFileExplorer(state, filepath.Join(t.TempDir(), "missing"))
+	assert.Nil(t, state.FileExplorer())
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Contains(t, state.StatusMsg().Text, "Could not list directory")
+}
+
+func TestFileExplorerNavigation(t *testing.T) {
+	dir := setupFileExplorerTestDir(t, "a.txt", "dir/c.txt", "dir/sub/d.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	ShowFileExplorer(state, dir)
+	explorer := state.FileExplorer()
+
+	// Expand the directory.
+	OpenFileExplorerEntry(state)
+	assert.Equal(t, []string{"*dir/", "  sub/", "  c.txt", "a.txt"}, fileExplorerTree(explorer))
+
+	// The selection stops at the last entry.
+	MoveFileExplorerSelection(state, 10)
+	assert.Equal(t, []string{"dir/", "  sub/", "  c.txt", "*a.txt"}, fileExplorerTree(explorer))
+
+	// From a child, collapse selects the parent directory, then collapses it.
+	MoveFileExplorerSelection(state, -1)
+	CollapseFileExplorerEntry(state)
+	assert.Equal(t, []string{"*dir/", "  sub/", "  c.txt", "a.txt"}, fileExplorerTree(explorer))
+	CollapseFileExplorerEntry(state)
+	assert.Equal(t, []string{"*dir/", "a.txt"}, fileExplorerTree(explorer))
+
+	// The selection stops at the first entry.
+	MoveFileExplorerSelection(state, -10)
+	assert.Equal(t, []string{"*dir/", "a.txt"}, fileExplorerTree(explorer))
+
+	// Show the parent directory, with the previous directory expanded.
+	MoveFileExplorerToParentDir(state)
+	assert.Equal(t, filepath.Dir(dir), explorer.RootDir())
+	entry, ok := explorer.SelectedEntry()
+	require.True(t, ok)
+	assert.Equal(t, dir, entry.Path)
+	assert.True(t, entry.Expanded)
+}
+
+func TestFileExplorerOpenFile(t *testing.T) {
+	dir := setupFileExplorerTestDir(t, "a.txt", "b.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	ShowFileExplorer(state, dir)
+	defer func() { state.fileWatcher.Stop() }()
+
+	MoveFileExplorerSelection(state, 1)
+	OpenFileExplorerEntry(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Nil(t, state.FileExplorer())
+	assert.Equal(t, filepath.Join(dir, "b.txt"), state.fileWatcher.Path())
+	assert.Equal(t, "b.txt", state.documentBuffer.textTree.String())
+}
+
+func TestFileExplorerReloadDocument(t *testing.T) {
+	dir := setupFileExplorerTestDir(t, "a.txt", "b.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, filepath.Join(dir, "a.txt"), true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+
+	// The document reloads when its file changes, but the explorer stays open.
+	ShowFileExplorer(state, dir)
+	MoveFileExplorerSelection(state, 1)
+	ReloadDocument(state)
+	assert.Equal(t, InputModeFileExplorer, state.InputMode())
+	require.NotNil(t, state.FileExplorer())
+	assert.Equal(t, []string{"a.txt", "*b.txt"}, fileExplorerTree(state.FileExplorer()))
+}
+
+func TestFileExplorerCreate(t *testing.T) {
+	dir := setupFileExplorerTestDir(t, "dir/a.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	ShowFileExplorer(state, dir)
+	defer func() { state.fileWatcher.Stop() }()
+
+	// Create a directory within the selected directory.
+	err := CreateFileExplorerPath(state, "sub/")
+	require.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dir, "dir", "sub"))
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Equal(t, []string{"dir/", "*  sub/", "  a.txt"}, fileExplorerTree(state.FileExplorer()))
+	assert.Equal(t, InputModeFileExplorer, state.InputMode())
+
+	err = CreateFileExplorerPath(state, "../sub/")
+	assert.ErrorContains(t, err, "File already exists")
+
+	// Creating a file opens a new document at the path.
+	err = CreateFileExplorerPath(state, "new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "dir", "sub", "new.txt"), state.fileWatcher.Path())
+	assert.True(t, state.fileWatcher.IsNewFile())
+	assert.Nil(t, state.FileExplorer())
+	assert.Equal(t, InputModeNormal, state.InputMode())
+}
+
+func TestFileExplorerRename(t *testing.T) {
+	dir := setupFileExplorerTestDir(t, "a.txt", "b.txt", "open.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, filepath.Join(dir, "open.txt"), true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+	ShowFileExplorer(state, dir)
+
+	// Files open in the editor can't be renamed from the explorer.
+	err := RenameFileExplorerEntry(state, "other.txt")
+	assert.ErrorContains(t, err, "because it is open in the editor")
+
+	MoveFileExplorerSelection(state, -2)
+	err = RenameFileExplorerEntry(state, "b.txt")
+	assert.ErrorContains(t, err, "already exists")
+
+	err = RenameFileExplorerEntry(state, "c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.txt", "*c.txt", "open.txt"}, fileExplorerTree(state.FileExplorer()))
+	data, err := os.ReadFile(filepath.Join(dir, "c.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", string(data))
+}
+
+func TestFileExplorerDelete(t *testing.T) {
+	dir := setupFileExplorerTestDir(t, "empty/", "full/a.txt", "b.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	SetTrash(state, file.NewTrash(filepath.Join(t.TempDir(), "Trash")))
+	ShowFileExplorer(state, dir)
+	defer func() { state.fileWatcher.Stop() }()
+
+	// Only empty directories can be deleted.
+	err := DeleteFileExplorerEntry(state)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*full/", "b.txt"}, fileExplorerTree(state.FileExplorer()))
+	err = DeleteFileExplorerEntry(state)
+	assert.ErrorContains(t, err, "only empty directories can be deleted")
+
+	// Files move to the trash and can be restored.
+	MoveFileExplorerSelection(state, 1)
+	err = DeleteFileExplorerEntry(state)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*full/"}, fileExplorerTree(state.FileExplorer()))
+	assert.Contains(t, state.StatusMsg().Text, "to trash")
+
+	err = RestoreLastTrashedFile(state)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "b.txt"), state.fileWatcher.Path())
+}
@@ -0,0 +1,333 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/text"
+)
+
+// ReplaceAll replaces every occurrence of query in the document with replacement.
+// The query uses the same syntax as text search, including case-sensitivity rules and regular expressions.
+// If preserveCase is true, the query always matches case-insensitively, and each replacement
+// matches the case of the text it replaces: for example, replacing "foo" with "bar"
+// changes "Foo" to "Bar" and "FOO" to "BAR".
+// All replacements are grouped into a single undo entry.
+func ReplaceAll(state *EditorState, query string, replacement string, preserveCase bool) error {
+	return ReplaceInRegion(state, query, replacement, preserveCase, documentRegion(state.documentBuffer))
+}
+
+// ReplaceInRegion is like ReplaceAll, except it replaces only occurrences entirely within the region.
+func ReplaceInRegion(state *EditorState, query string, replacement string, preserveCase bool, region selection.Region) error {
+	parsedQuery, err := parseReplaceQuery(query, preserveCase)
+	if err != nil {
+		return err
+	}
+
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return err
+	}
+
+	matches := findMatchesInRegion(buffer.textTree, parsedQuery, region)
+	if len(matches) == 0 {
+		setNoReplaceMatchesStatusMsg(state, parsedQuery)
+		return nil
+	}
+
+	// Apply edits in reverse order so that the positions of earlier matches remain valid.
+	checkpoint := beginUndoCheckpoint(state, "replace all")
+	BeginUndoEntry(state)
+	for i := len(matches) - 1; i >= 0; i-- {
+		if _, err := replaceMatch(state, matches[i], replacement, preserveCase); err != nil {
+			log.Printf("Error inserting replacement text: %v\n", err)
+			break
+		}
+	}
+	CommitUndoEntry(state)
+
+	MoveCursor(state, func(LocatorParams) uint64 { return matches[0].StartPos })
+	ScrollViewToCursor(state)
+	setReplacedStatusMsg(state, len(matches), parsedQuery)
+	commitUndoCheckpoint(state, checkpoint)
+	return nil
+}
+
+// replaceConfirmState tracks a find and replace that asks the user to confirm each replacement.
+type replaceConfirmState struct {
+	parsedQuery  parsedQuery
+	replacement  string
+	preserveCase bool
+	match        SearchMatch // The match awaiting confirmation.
+	endPos       uint64      // End of the region to search, adjusted for replacements made so far.
+	numReplaced  int
+}
+
+// StartReplaceWithConfirmation finds the first occurrence of query within the region,
+// then asks the user whether to replace it and each occurrence after it.
+// The query and replacement work the same as ReplaceAll.
+// All replacements are grouped into a single undo entry, committed when the user stops
+// or there are no more matches.
+func StartReplaceWithConfirmation(state *EditorState, query string, replacement string, preserveCase bool, region selection.Region) error {
+	parsedQuery, err := parseReplaceQuery(query, preserveCase)
+	if err != nil {
+		return err
+	}
+
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return err
+	}
+
+	foundMatch, match := findNextMatch(buffer.textTree, parsedQuery, region.StartPos, region.EndPos)
+	if !foundMatch {
+		setNoReplaceMatchesStatusMsg(state, parsedQuery)
+		return nil
+	}
+
+	buffer.replaceConfirm = replaceConfirmState{
+		parsedQuery:  parsedQuery,
+		replacement:  replacement,
+		preserveCase: preserveCase,
+		match:        match,
+		endPos:       region.EndPos,
+	}
+	buffer.selector.Clear()
+	BeginUndoEntry(state)
+	setInputMode(state, InputModeReplaceConfirm)
+	showReplaceConfirmMatch(state)
+	return nil
+}
+
+// ConfirmReplace replaces the match awaiting confirmation, then moves to the next match.
+func ConfirmReplace(state *EditorState) {
+	if nextPos, ok := replaceConfirmMatch(state); ok {
+		advanceReplaceConfirm(state, nextPos)
+	}
+}
+
+// SkipReplace leaves the match awaiting confirmation unchanged, then moves to the next match.
+func SkipReplace(state *EditorState) {
+	rc := &state.documentBuffer.replaceConfirm
+	advanceReplaceConfirm(state, rc.match.EndPos)
+}
+
+// ReplaceAllRemaining replaces the match awaiting confirmation and every match after it,
+// without asking for confirmation.
+func ReplaceAllRemaining(state *EditorState) {
+	buffer := state.documentBuffer
+	rc := &buffer.replaceConfirm
+	for {
+		nextPos, ok := replaceConfirmMatch(state)
+		if !ok {
+			return
+		}
+
+		foundMatch, match := findNextMatch(buffer.textTree, rc.parsedQuery, nextPos, rc.endPos)
+		if !foundMatch {
+			break
+		}
+		rc.match = match
+	}
+
+	MoveCursor(state, func(LocatorParams) uint64 { return rc.match.StartPos })
+	ScrollViewToCursor(state)
+	StopReplace(state)
+}
+
+// StopReplace ends a find and replace with confirmation, keeping the replacements made so far.
+func StopReplace(state *EditorState) {
+	buffer := state.documentBuffer
+	rc := buffer.replaceConfirm
+	CommitUndoEntry(state)
+	buffer.replaceConfirm = replaceConfirmState{}
+	buffer.search.match = nil
+	setInputMode(state, InputModeNormal)
+	setReplacedStatusMsg(state, rc.numReplaced, rc.parsedQuery)
+}
+
+// replaceConfirmMatch replaces the match awaiting confirmation.
+// It returns the position after the replacement text, or false if the replacement failed,
+// in which case the find and replace stops.
+func replaceConfirmMatch(state *EditorState) (uint64, bool) {
+	rc := &state.documentBuffer.replaceConfirm
+	newText, err := replaceMatch(state, rc.match, rc.replacement, rc.preserveCase)
+	if err != nil {
+		log.Printf("Error inserting replacement text: %v\n", err)
+		StopReplace(state)
+		return 0, false
+	}
+
+	newLen := uint64(utf8.RuneCountInString(newText))
+	rc.endPos = rc.endPos - (rc.match.EndPos - rc.match.StartPos) + newLen
+	rc.numReplaced++
+	return rc.match.StartPos + newLen, true
+}
+
+// advanceReplaceConfirm moves to the next match at or after pos, or stops if there are no more matches.
+func advanceReplaceConfirm(state *EditorState, pos uint64) {
+	buffer := state.documentBuffer
+	rc := &buffer.replaceConfirm
+	foundMatch, match := findNextMatch(buffer.textTree, rc.parsedQuery, pos, rc.endPos)
+	if !foundMatch {
+		StopReplace(state)
+		return
+	}
+	rc.match = match
+	showReplaceConfirmMatch(state)
+}
+
+// showReplaceConfirmMatch highlights the match awaiting confirmation and moves the cursor to it.
+func showReplaceConfirmMatch(state *EditorState) {
+	buffer := state.documentBuffer
+	rc := buffer.replaceConfirm
+	match := rc.match
+	buffer.search.match = &match
+	MoveCursor(state, func(LocatorParams) uint64 { return rc.match.StartPos })
+	ScrollViewToCursor(state)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Replace with %q? Press y to replace, n to skip, a to replace all, or q to stop", rc.replacement),
+	})
+}
+
+// parseReplaceQuery parses the query for a find and replace.
+func parseReplaceQuery(query string, preserveCase bool) (parsedQuery, error) {
+	parsedQuery := parseQuery(query)
+	if parsedQuery.queryText == "" {
+		return parsedQuery, errors.New("Search text cannot be empty")
+	}
+
+	if preserveCase {
+		parsedQuery.caseSensitive = false
+		parsedQuery.compileRegexp()
+	}
+
+	if parsedQuery.regexpErr != nil {
+		return parsedQuery, parsedQuery.regexpErr
+	}
+
+	return parsedQuery, nil
+}
+
+// replaceMatch replaces the text of a match, returning the text that replaced it.
+func replaceMatch(state *EditorState, match SearchMatch, replacement string, preserveCase bool) (string, error) {
+	matchLen := match.EndPos - match.StartPos
+	newText := replacement
+	if preserveCase {
+		newText = matchCase(copyText(state.documentBuffer.textTree, match.StartPos, matchLen), replacement)
+	}
+	_, err := replaceRunes(state, match.StartPos, matchLen, newText, true)
+	return newText, err
+}
+
+func setNoReplaceMatchesStatusMsg(state *EditorState, parsedQuery parsedQuery) {
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("No matches found for %q", parsedQuery.queryText),
+	})
+}
+
+func setReplacedStatusMsg(state *EditorState, numReplaced int, parsedQuery parsedQuery) {
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Replaced %d occurrence(s) of %q", numReplaced, parsedQuery.queryText),
+	})
+}
+
+// documentRegion returns a region containing the entire document.
+func documentRegion(buffer *BufferState) selection.Region {
+	return selection.Region{StartPos: 0, EndPos: buffer.textTree.NumChars()}
+}
+
+// findAllMatches returns the start positions of non-overlapping matches for the query.
+func findAllMatches(tree *text.Tree, parsedQuery parsedQuery) []uint64 {
+	matches := findMatchesInRegion(tree, parsedQuery, selection.Region{StartPos: 0, EndPos: tree.NumChars()})
+	matchPositions := make([]uint64, 0, len(matches))
+	for _, m := range matches {
+		matchPositions = append(matchPositions, m.StartPos)
+	}
+	return matchPositions
+}
+
+// findMatchesInRegion returns the non-overlapping matches for the query that are entirely within the region.
+func findMatchesInRegion(tree *text.Tree, parsedQuery parsedQuery, region selection.Region) []SearchMatch {
+	var matches []SearchMatch
+	pos := region.StartPos
+	for {
+		foundMatch, match := findNextMatch(tree, parsedQuery, pos, region.EndPos)
+		if !foundMatch {
+			return matches
+		}
+		matches = append(matches, match)
+		pos = match.EndPos
+	}
+}
+
+// findNextMatch returns the first match for the query that starts at or after pos and ends at or before endPos.
+func findNextMatch(tree *text.Tree, parsedQuery parsedQuery, pos uint64, endPos uint64) (bool, SearchMatch) {
+	if parsedQuery.isRegexp {
+		foundMatch, match := nextRegexpMatch(tree, parsedQuery, pos)
+		if !foundMatch || match.EndPos > endPos {
+			return false, SearchMatch{}
+		}
+		return true, match
+	}
+
+	transformer := transformerForSearch(parsedQuery.caseSensitive)
+	transformedQuery, _, err := transform.String(transformer, parsedQuery.queryText)
+	if err != nil {
+		panic(err)
+	}
+
+	searcher := text.NewSearcher(transformedQuery)
+	treeReader := tree.ReaderAtPosition(pos)
+	transformedReader := transform.NewReader(&treeReader, transformer)
+	foundMatch, matchOffset, err := searcher.NextInReader(transformedReader)
+	if err != nil {
+		panic(err) // should never happen for text.Reader.
+	}
+
+	match := literalMatch(pos+matchOffset, parsedQuery)
+	if !foundMatch || match.EndPos > endPos {
+		return false, SearchMatch{}
+	}
+	return true, match
+}
+
+// matchCase changes the case of the replacement to match the case of the matched text.
+// If the matched text is all uppercase or all lowercase, so is the replacement.
+// If only the first letter is uppercase, the first letter of the replacement is uppercased.
+// Otherwise, the replacement is unchanged.
+func matchCase(matchedText string, replacement string) string {
+	var hasUpper, hasLower bool
+	for _, r := range matchedText {
+		if unicode.IsUpper(r) {
+			hasUpper = true
+		} else if unicode.IsLower(r) {
+			hasLower = true
+		}
+	}
+
+	switch {
+	case hasUpper && !hasLower:
+		return strings.ToUpper(replacement)
+	case hasLower && !hasUpper:
+		return strings.ToLower(replacement)
+	}
+
+	firstRune, _ := utf8.DecodeRuneInString(matchedText)
+	if unicode.IsUpper(firstRune) && replacement != "" {
+		r, size := utf8.DecodeRuneInString(replacement)
+		return string(unicode.ToUpper(r)) + replacement[size:]
+	}
+
+	return replacement
+}
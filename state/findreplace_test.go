@@ -0,0 +1,273 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/text"
+)
+
+func TestReplaceAll(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputString       string
+		query             string
+		replacement       string
+		preserveCase      bool
+		expectedText      string
+		expectedCursorPos uint64
+		expectedStatusMsg StatusMsg
+	}{
+		{
+			name:              "no matches",
+			inputString:       "abc",
+			query:             "xyz",
+			replacement:       "foo",
+			expectedText:      "abc",
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleError, Text: `No matches found for "xyz"`},
+		},
+		{
+			name:              "replace all matches",
+			inputString:       "a foo\nfoo b foo",
+			query:             "foo",
+			replacement:       "bar",
+			expectedText:      "a bar\nbar b bar",
+			expectedCursorPos: 2,
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 3 occurrence(s) of "foo". Press U to revert`},
+		},
+		{
+			name:              "replace with longer and shorter text",
+			inputString:       "xx ab ab",
+			query:             "ab",
+			replacement:       "c",
+			expectedText:      "xx c c",
+			expectedCursorPos: 3,
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 2 occurrence(s) of "ab". Press U to revert`},
+		},
+		{
+			name:              "non-overlapping matches",
+			inputString:       "aaaaa",
+			query:             "aa",
+			replacement:       "b",
+			expectedText:      "bba",
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 2 occurrence(s) of "aa". Press U to revert`},
+		},
+		{
+			name:              "lowercase query is case-insensitive",
+			inputString:       "foo Foo FOO",
+			query:             "foo",
+			replacement:       "bar",
+			expectedText:      "bar bar bar",
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 3 occurrence(s) of "foo". Press U to revert`},
+		},
+		{
+			name:              "force case-sensitive",
+			inputString:       "foo Foo FOO",
+			query:             `foo\C`,
+			replacement:       "bar",
+			expectedText:      "bar Foo FOO",
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 1 occurrence(s) of "foo". Press U to revert`},
+		},
+		{
+			name:              "preserve case",
+			inputString:       "foo Foo FOO fOO",
+			query:             "foo",
+			replacement:       "bar",
+			preserveCase:      true,
+			expectedText:      "bar Bar BAR bar",
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 4 occurrence(s) of "foo". Press U to revert`},
+		},
+		{
+			name:              "preserve case with mixed-case query and replacement",
+			inputString:       "fooBar FooBar FOOBAR foobar",
+			query:             "FooBar",
+			replacement:       "bazQux",
+			preserveCase:      true,
+			expectedText:      "bazQux BazQux BAZQUX bazqux",
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 4 occurrence(s) of "FooBar". Press U to revert`},
+		},
+		{
+			name:              "preserve case with empty replacement",
+			inputString:       "a Foo b",
+			query:             "foo ",
+			replacement:       "",
+			preserveCase:      true,
+			expectedText:      "a b",
+			expectedCursorPos: 2,
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 1 occurrence(s) of "foo ". Press U to revert`},
+		},
+		{
+			name:              "regexp with matches of different lengths",
+			inputString:       "x = 1\ny = 22\nz = 333",
+			query:             `\v\d+$`,
+			replacement:       "0",
+			expectedText:      "x = 0\ny = 0\nz = 0",
+			expectedCursorPos: 4,
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 3 occurrence(s) of "\\d+$". Press U to revert`},
+		},
+		{
+			name:              "regexp preserving case",
+			inputString:       "foo1 FOO22",
+			query:             `\vfoo\d+`,
+			replacement:       "bar",
+			preserveCase:      true,
+			expectedText:      "bar BAR",
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 2 occurrence(s) of "foo\\d+". Press U to revert`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			err = ReplaceAll(state, tc.query, tc.replacement, tc.preserveCase)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursorPos, state.documentBuffer.cursor.position)
+			assert.Equal(t, tc.expectedStatusMsg, state.StatusMsg())
+		})
+	}
+}
+
+func TestReplaceAllEmptyQuery(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	err := ReplaceAll(state, "", "foo", false)
+	assert.EqualError(t, err, "Search text cannot be empty")
+}
+
+func TestReplaceAllInvalidRegexp(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	err := ReplaceAll(state, `\vfoo(`, "bar", false)
+	assert.EqualError(t, err, "Invalid regular expression: error parsing regexp: missing closing ): `foo(`")
+}
+
+func TestReplaceAllUndo(t *testing.T) {
+	textTree, err := text.NewTreeFromString("foo Foo FOO")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	err = ReplaceAll(state, "foo", "bar", true)
+	require.NoError(t, err)
+	assert.Equal(t, "bar Bar BAR", textTree.String())
+	Undo(state)
+	assert.Equal(t, "foo Foo FOO", state.documentBuffer.textTree.String())
+}
+
+func TestReplaceInRegion(t *testing.T) {
+	textTree, err := text.NewTreeFromString("foo foo foo foo")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+
+	// The region includes the second match and only part of the third match.
+	err = ReplaceInRegion(state, "foo", "x", false, selection.Region{StartPos: 3, EndPos: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "foo x foo foo", textTree.String())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+	assert.Equal(t, `Replaced 1 occurrence(s) of "foo". Press U to revert`, state.StatusMsg().Text)
+}
+
+func TestReplaceWithConfirmation(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputString       string
+		region            selection.Region
+		actions           []func(*EditorState)
+		expectedText      string
+		expectedCursorPos uint64
+		expectedStatusMsg StatusMsg
+	}{
+		{
+			name:        "replace and skip matches",
+			inputString: "foo foo foo",
+			region:      selection.Region{StartPos: 0, EndPos: 11},
+			actions: []func(*EditorState){
+				ConfirmReplace,
+				SkipReplace,
+				ConfirmReplace,
+			},
+			expectedText:      "barbar foo barbar",
+			expectedCursorPos: 11,
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 2 occurrence(s) of "foo"`},
+		},
+		{
+			name:        "stop after first replacement",
+			inputString: "foo foo foo",
+			region:      selection.Region{StartPos: 0, EndPos: 11},
+			actions: []func(*EditorState){
+				ConfirmReplace,
+				StopReplace,
+			},
+			expectedText:      "barbar foo foo",
+			expectedCursorPos: 7,
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 1 occurrence(s) of "foo"`},
+		},
+		{
+			name:        "replace all remaining",
+			inputString: "foo foo foo",
+			region:      selection.Region{StartPos: 0, EndPos: 11},
+			actions: []func(*EditorState){
+				SkipReplace,
+				ReplaceAllRemaining,
+			},
+			expectedText:      "foo barbar barbar",
+			expectedCursorPos: 11,
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 2 occurrence(s) of "foo"`},
+		},
+		{
+			name:        "region end moves after replacement",
+			inputString: "foo foo foo",
+			region:      selection.Region{StartPos: 0, EndPos: 7},
+			actions: []func(*EditorState){
+				ConfirmReplace,
+				ConfirmReplace,
+			},
+			expectedText:      "barbar barbar foo",
+			expectedCursorPos: 7,
+			expectedStatusMsg: StatusMsg{Style: StatusMsgStyleSuccess, Text: `Replaced 2 occurrence(s) of "foo"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+
+			err = StartReplaceWithConfirmation(state, "foo", "barbar", false, tc.region)
+			require.NoError(t, err)
+			assert.Equal(t, InputModeReplaceConfirm, state.InputMode())
+			assert.Equal(t, &SearchMatch{StartPos: 0, EndPos: 3}, state.documentBuffer.SearchMatch())
+
+			for _, action := range tc.actions {
+				action(state)
+			}
+			assert.Equal(t, InputModeNormal, state.InputMode())
+			assert.Nil(t, state.documentBuffer.SearchMatch())
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursorPos, state.documentBuffer.cursor.position)
+			assert.Equal(t, tc.expectedStatusMsg, state.StatusMsg())
+
+			// Every replacement is undone at once.
+			Undo(state)
+			assert.Equal(t, tc.inputString, state.documentBuffer.textTree.String())
+		})
+	}
+}
+
+func TestReplaceWithConfirmationNoMatches(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	err = StartReplaceWithConfirmation(state, "xyz", "foo", false, selection.Region{StartPos: 0, EndPos: 3})
+	require.NoError(t, err)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: `No matches found for "xyz"`}, state.StatusMsg())
+}
@@ -0,0 +1,107 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// flagMarkers are the words that flag an item in the document for attention.
+var flagMarkers = []string{"TODO", "FIXME"}
+
+// flagIndexMaxChars is the maximum size of a document to index for flagged items.
+// The index is rebuilt after every edit, so skip it for large documents to keep typing responsive.
+const flagIndexMaxChars = 1 << 20
+
+// flaggedItem is an occurrence of a flag marker in the document.
+type flaggedItem struct {
+	pos    uint64
+	marker string
+}
+
+// flagIndex locates flagged items in the document.
+// It is rebuilt on demand after the document changes.
+type flagIndex struct {
+	valid bool
+	items []flaggedItem // Sorted ascending by position.
+}
+
+// invalidateFlagIndex discards the flagged items after the document changes.
+func invalidateFlagIndex(buffer *BufferState) {
+	buffer.flags = flagIndex{}
+}
+
+// flaggedItems returns the flagged items in the document, sorted by position.
+func (s *BufferState) flaggedItems() []flaggedItem {
+	if s.flags.valid {
+		return s.flags.items
+	}
+
+	var items []flaggedItem
+	if s.textTree.NumChars() <= flagIndexMaxChars {
+		for _, marker := range flagMarkers {
+			q := parsedQuery{queryText: marker, caseSensitive: true}
+			for _, pos := range findAllMatches(s.textTree, q) {
+				items = append(items, flaggedItem{pos: pos, marker: marker})
+			}
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].pos < items[j].pos
+		})
+	}
+
+	s.flags = flagIndex{valid: true, items: items}
+	return items
+}
+
+// FlagSummary returns a compact description of the flagged items in the document (for example "3 TODO, 1 FIXME").
+// Returns an empty string if the document has no flagged items.
+func (s *BufferState) FlagSummary() string {
+	items := s.flaggedItems()
+	if len(items) == 0 {
+		return ""
+	}
+
+	counts := make(map[string]int, len(flagMarkers))
+	for _, item := range items {
+		counts[item.marker]++
+	}
+
+	parts := make([]string, 0, len(flagMarkers))
+	for _, marker := range flagMarkers {
+		if n := counts[marker]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, marker))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// JumpToNextFlaggedItem moves the cursor to the next flagged item after the cursor,
+// wrapping around to the start of the document.
+func JumpToNextFlaggedItem(state *EditorState) {
+	buffer := state.documentBuffer
+	items := buffer.flaggedItems()
+	if len(items) == 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("No %s found", strings.Join(flagMarkers, " or ")),
+		})
+		return
+	}
+
+	cursorPos := buffer.cursor.position
+	i := sort.Search(len(items), func(i int) bool {
+		return items[i].pos > cursorPos
+	})
+	if i == len(items) {
+		i = 0
+	}
+
+	target := items[i].pos
+	MoveCursor(state, func(LocatorParams) uint64 { return target })
+	ScrollViewToCursor(state)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("%s %d of %d", items[i].marker, i+1, len(items)),
+	})
+}
@@ -0,0 +1,107 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestFlagSummary(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputString     string
+		expectedSummary string
+	}{
+		{
+			name:            "empty",
+			inputString:     "",
+			expectedSummary: "",
+		},
+		{
+			name:            "no flagged items",
+			inputString:     "abc todo def",
+			expectedSummary: "",
+		},
+		{
+			name:            "todo only",
+			inputString:     "// TODO: a\n// TODO: b\n",
+			expectedSummary: "2 TODO",
+		},
+		{
+			name:            "todo and fixme",
+			inputString:     "# FIXME\n# TODO\n# TODO\n",
+			expectedSummary: "2 TODO, 1 FIXME",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			assert.Equal(t, tc.expectedSummary, state.documentBuffer.FlagSummary())
+		})
+	}
+}
+
+func TestFlagSummaryAfterEdit(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	assert.Equal(t, "", state.documentBuffer.FlagSummary())
+
+	for _, r := range "TODO" {
+		InsertRune(state, r)
+	}
+	assert.Equal(t, "1 TODO", state.documentBuffer.FlagSummary())
+
+	DeleteToPos(state, func(LocatorParams) uint64 { return 0 }, 0)
+	assert.Equal(t, "", state.documentBuffer.FlagSummary())
+}
+
+func TestJumpToNextFlaggedItem(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputString       string
+		cursorPos         uint64
+		expectedCursorPos uint64
+		expectedStatus    string
+	}{
+		{
+			name:              "no flagged items",
+			inputString:       "abc",
+			cursorPos:         1,
+			expectedCursorPos: 1,
+			expectedStatus:    "No TODO or FIXME found",
+		},
+		{
+			name:              "next item after cursor",
+			inputString:       "TODO a\nFIXME b\nTODO c",
+			cursorPos:         0,
+			expectedCursorPos: 7,
+			expectedStatus:    "FIXME 2 of 3",
+		},
+		{
+			name:              "wrap to start of document",
+			inputString:       "TODO a\nFIXME b\nTODO c",
+			cursorPos:         16,
+			expectedCursorPos: 0,
+			expectedStatus:    "TODO 1 of 3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			state.documentBuffer.cursor.position = tc.cursorPos
+			JumpToNextFlaggedItem(state)
+			assert.Equal(t, tc.expectedCursorPos, state.documentBuffer.cursor.position)
+			assert.Equal(t, tc.expectedStatus, state.StatusMsg().Text)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package state
+
+import (
+	"log"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/syntax/parser"
+)
+
+// followTailState stores the settings changed by follow mode,
+// so they can be restored when follow mode is disabled.
+type followTailState struct {
+	enabled       bool
+	savedReadOnly bool
+}
+
+// ToggleFollowTail enables or disables follow mode, similar to "tail -f".
+// In follow mode, the document is read-only so the editor never overwrites data
+// written by another program, and data appended to the file is loaded automatically.
+// If the cursor is on the last line, it moves to the new last line after each reload;
+// otherwise, the cursor and view stay where the user left them.
+func ToggleFollowTail(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.followTail.enabled {
+		buffer.readOnly = buffer.followTail.savedReadOnly
+		buffer.followTail = followTailState{}
+		stopAppendOnly(buffer)
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "Stopped following end of file",
+		})
+		return
+	}
+
+	startFollowTail(buffer)
+	moveCursorToLastLine(state)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  "Following end of file",
+	})
+}
+
+func startFollowTail(buffer *BufferState) {
+	buffer.followTail = followTailState{
+		enabled:       true,
+		savedReadOnly: buffer.readOnly,
+	}
+	buffer.readOnly = true
+	buffer.appendOnly = true
+}
+
+// stopAppendOnly takes new snapshots of the document once text may be changed in other ways,
+// since appending text doesn't update the snapshots.
+func stopAppendOnly(buffer *BufferState) {
+	buffer.appendOnly = false
+	buffer.originalText = newTextSnapshot(buffer.textTree)
+	setSavedText(buffer, buffer.originalText)
+}
+
+// reloadAppendedText loads data appended to the file since it was loaded,
+// preserving the input mode, selection, search, and undo history.
+// It returns false if the file changed in some other way (for example, if it was truncated),
+// in which case the caller should reload the entire document.
+func reloadAppendedText(state *EditorState) bool {
+	path := state.fileWatcher.Path()
+	s, watcher, err := file.LoadAppended(state.fileWatcher, watcherConfigForPath(state, path))
+	if err != nil {
+		log.Printf("Could not load appended text from %q: %v\n", path, err)
+		return false
+	}
+
+	buffer := state.documentBuffer
+	onLastLine := isCursorOnLastLine(buffer)
+	pos := buffer.textTree.NumChars()
+	if err := buffer.textTree.ReplaceRange(pos, 0, s); err != nil {
+		// Should never happen since the text was validated when loaded.
+		panic(err)
+	}
+	n := uint64(utf8.RuneCountInString(s))
+	retokenizeAfterEdit(buffer, parser.NewInsertEdit(pos, n))
+
+	state.fileWatcher.Stop()
+	state.fileWatcher = watcher
+
+	if onLastLine {
+		moveCursorToLastLine(state)
+	}
+
+	log.Printf("Loaded %d appended characters from %q\n", n, path)
+	return true
+}
+
+func isCursorOnLastLine(buffer *BufferState) bool {
+	tree := buffer.textTree
+	return tree.LineNumForPosition(buffer.cursor.position)+1 >= tree.NumLines()
+}
@@ -0,0 +1,135 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToggleFollowTail(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	ToggleFollowTail(state)
+	assert.True(t, state.documentBuffer.ReadOnly())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+	assert.Equal(t, "Following end of file", state.StatusMsg().Text)
+
+	ToggleFollowTail(state)
+	assert.False(t, state.documentBuffer.ReadOnly())
+	assert.Equal(t, "Stopped following end of file", state.StatusMsg().Text)
+}
+
+func TestFollowTailReloadAppended(t *testing.T) {
+	testCases := []struct {
+		name              string
+		cursorPos         uint64
+		expectedCursorPos uint64
+	}{
+		{
+			name:              "cursor on last line",
+			cursorPos:         5,
+			expectedCursorPos: 8,
+		},
+		{
+			name:              "cursor moved off last line",
+			cursorPos:         1,
+			expectedCursorPos: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := createTestFile(t, "foo\nbar\n")
+			defer cleanup()
+
+			state := NewEditorState(100, 100, nil, nil)
+			LoadDocument(state, path, true, startOfDocLocator)
+			defer state.fileWatcher.Stop()
+			ToggleFollowTail(state)
+			MoveCursor(state, func(LocatorParams) uint64 { return tc.cursorPos })
+
+			// Start a search, which should be preserved when data is appended.
+			StartSearch(state, SearchDirectionForward, SearchCompleteMoveCursorToMatch)
+			AppendRuneToSearchQuery(state, 'o')
+			searchMatch := state.documentBuffer.search.match
+			require.NotNil(t, searchMatch)
+
+			err := os.WriteFile(path, []byte("foo\nbar\nbaz\n"), 0644)
+			require.NoError(t, err)
+			ReloadDocument(state)
+			defer state.fileWatcher.Stop()
+
+			assert.Equal(t, "foo\nbar\nbaz", state.documentBuffer.textTree.String())
+			assert.Equal(t, "o", state.documentBuffer.search.query)
+			assert.Equal(t, searchMatch, state.documentBuffer.search.match)
+			assert.Equal(t, InputModeSearch, state.InputMode())
+			assert.True(t, state.documentBuffer.ReadOnly())
+			assert.Equal(t, tc.expectedCursorPos, state.documentBuffer.cursor.position)
+
+			// The new watcher should not report a change for the appended data.
+			changed, err := state.fileWatcher.CheckFileContentsChanged()
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
+
+func TestFollowTailSkipsChangesSinceLoad(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+	ToggleFollowTail(state)
+
+	err := os.WriteFile(path, []byte("foo\nbar\nbaz\n"), 0644)
+	require.NoError(t, err)
+	ReloadDocument(state)
+
+	// Appended text doesn't grow the snapshot or show up as a change.
+	assert.True(t, state.documentBuffer.appendOnly)
+	assert.Equal(t, "foo\nbar", state.documentBuffer.originalText.text)
+	ShowChangesSinceLoad(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "No changes since document was loaded", state.StatusMsg().Text)
+
+	// Once follow mode stops, edits are compared to the text as it was when follow mode stopped.
+	ToggleFollowTail(state)
+	assert.False(t, state.documentBuffer.appendOnly)
+	assert.Equal(t, "foo\nbar\nbaz", state.documentBuffer.originalText.text)
+	ShowChangesSinceLoad(state)
+	assert.Equal(t, "No changes since document was loaded", state.StatusMsg().Text)
+}
+
+func TestFollowTailReloadTruncated(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\nbaz\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+	ToggleFollowTail(state)
+	assert.Equal(t, uint64(8), state.documentBuffer.cursor.position)
+
+	// Truncate the file, as if a log file had been rotated.
+	err := os.WriteFile(path, []byte("abc\n"), 0644)
+	require.NoError(t, err)
+	ReloadDocument(state)
+	defer state.fileWatcher.Stop()
+
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(0), state.documentBuffer.cursor.position)
+	assert.True(t, state.documentBuffer.ReadOnly())
+
+	// Disabling follow mode restores the original read-only setting.
+	ToggleFollowTail(state)
+	assert.False(t, state.documentBuffer.ReadOnly())
+}
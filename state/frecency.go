@@ -0,0 +1,61 @@
+package state
+
+import (
+	"log"
+	"os"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/nondet"
+)
+
+const (
+	// maxFrecencyBoost is the largest amount that frecency can add to a file's search score.
+	// This is about the score of two matching characters, so frecency breaks ties
+	// between similar matches without overriding a much better match.
+	maxFrecencyBoost = 2.0
+
+	// frecencyBoostHalfScore is the frecency score that receives half the maximum boost.
+	frecencyBoostHalfScore = 4.0
+)
+
+// SetFrecencyStore sets the store used to rank files in the file menu by how frequently and recently they were opened.
+func SetFrecencyStore(state *EditorState, store *file.FrecencyStore) {
+	state.frecencyStore = store
+}
+
+// recordFileOpen records that a file was opened in the current working directory.
+func recordFileOpen(state *EditorState, path string) {
+	if state.frecencyStore == nil {
+		return
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Printf("Error getting working directory to record file open: %v\n", err)
+		return
+	}
+
+	if err := state.frecencyStore.RecordOpen(dir, path, nondet.Now()); err != nil {
+		log.Printf("Error recording file open for %q: %v\n", path, err)
+	}
+}
+
+// frecencyBoosts returns the search boost for each file opened in a directory,
+// keyed by the path relative to the directory.
+func frecencyBoosts(store *file.FrecencyStore, dir string) map[string]float64 {
+	if store == nil {
+		return nil
+	}
+
+	scores, err := store.Scores(dir, nondet.Now())
+	if err != nil {
+		log.Printf("Error loading file frecency scores: %v\n", err)
+		return nil
+	}
+
+	boosts := make(map[string]float64, len(scores))
+	for relPath, score := range scores {
+		boosts[relPath] = maxFrecencyBoost * score / (score + frecencyBoostHalfScore)
+	}
+	return boosts
+}
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aretext/aretext/shellcmd"
+)
+
+// HookEvent identifies an event that triggers the event hook command.
+// The hook command receives the event in the environment variable $EVENT.
+type HookEvent string
+
+const (
+	HookEventSave          = HookEvent("save")          // The document was saved.
+	HookEventSaveFailed    = HookEvent("saveFailed")    // The document could not be saved.
+	HookEventReload        = HookEvent("reload")        // The document was reloaded from disk.
+	HookEventChangedOnDisk = HookEvent("changedOnDisk") // Another program modified the file on disk.
+	HookEventTaskComplete  = HookEvent("taskComplete")  // A task such as a shell command finished after a long time.
+)
+
+// longTaskThreshold is how long a task must run before its completion triggers the event hook.
+// Quick tasks finish while the user is still watching, so there's no reason to notify them.
+const longTaskThreshold = 10 * time.Second
+
+// eventHookTimeout limits how long a hook command can run, so a stuck command doesn't leak processes.
+const eventHookTimeout = 30 * time.Second
+
+// runEventHook runs the configured event hook command, if any, in the background.
+// The command receives the event, the document path, and a description of the event
+// in the environment variables $EVENT, $FILEPATH, and $MESSAGE.
+// The hook cannot change the editor state, and errors are only logged,
+// so a broken hook never interrupts the user.
+func runEventHook(state *EditorState, event HookEvent, msg string) {
+	hookCmd := state.eventHook
+	if hookCmd == "" {
+		return
+	}
+
+	env := append(os.Environ(),
+		fmt.Sprintf("EVENT=%s", event),
+		fmt.Sprintf("FILEPATH=%s", state.fileWatcher.Path()),
+		fmt.Sprintf("MESSAGE=%s", msg))
+
+	log.Printf("Running event hook for %q: %q\n", event, hookCmd)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), eventHookTimeout)
+		defer cancel()
+		if err := shellcmd.RunSilent(ctx, hookCmd, env); err != nil {
+			log.Printf("Error running event hook for %q: %v\n", event, err)
+		}
+	}()
+}
+
+// HandleFileChangedOnDisk responds to another program modifying the document's file.
+// It runs the event hook, then reloads the document unless it has unsaved changes.
+// If the file was truncated, see handleFileTruncated.
+func HandleFileChangedOnDisk(state *EditorState) {
+	// In follow mode, the file changes every time another line is appended,
+	// so notifying the hook would be too noisy.
+	if !state.documentBuffer.followTail.enabled {
+		runEventHook(state, HookEventChangedOnDisk, "File changed on disk")
+	}
+
+	truncated, err := state.fileWatcher.CheckFileTruncated()
+	if err != nil {
+		log.Printf("Error checking whether file was truncated: %v\n", err)
+	} else if truncated {
+		handleFileTruncated(state)
+		return
+	}
+
+	AbortIfUnsavedChanges(state, "", ReloadDocument)
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+// setEventHookForTest configures a hook that appends the event and message to a file, and returns the file path.
+func setEventHookForTest(t *testing.T, state *EditorState) string {
+	outPath := filepath.Join(t.TempDir(), "events.txt")
+	state.eventHook = `echo "$EVENT $MESSAGE" >> ` + outPath
+	return outPath
+}
+
+func assertEventHookOutput(t *testing.T, outPath string, expected string) {
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(outPath)
+		return err == nil && string(data) == expected
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEventHookOnSave(t *testing.T) {
+	path, cleanup := createTestFile(t, "")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	outPath := setEventHookForTest(t, state)
+	InsertText(state, "foo")
+	SaveDocument(state)
+	assertEventHookOutput(t, outPath, "save Saved "+path+"\n")
+}
+
+func TestEventHookOnSaveFailed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "test.txt")
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	outPath := setEventHookForTest(t, state)
+	SaveDocument(state)
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(outPath)
+		return err == nil && strings.HasPrefix(string(data), "saveFailed ")
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEventHookOnLongTaskComplete(t *testing.T) {
+	restore := nondet.SetSource(nondet.NewDeterministicSource(time.Unix(1700000000, 0), longTaskThreshold, 0))
+	defer restore()
+
+	state := NewEditorState(100, 100, nil, nil)
+	outPath := setEventHookForTest(t, state)
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		return func(s *EditorState) {
+			SetStatusMsg(s, StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  "task completed",
+			})
+		}
+	})
+
+	select {
+	case action := <-state.TaskResultChan():
+		action(state)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Timed out")
+	}
+	assertEventHookOutput(t, outPath, "taskComplete task completed\n")
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/syntax/parser"
+)
+
+// ConvertIndentationToSpaces replaces tabs in the indentation of every line with spaces, using the tab size.
+func ConvertIndentationToSpaces(state *EditorState) {
+	convertIndentation(state, false)
+}
+
+// ConvertIndentationToTabs replaces spaces in the indentation of every line with tabs, using the tab size.
+// Indentation that is not a multiple of the tab size ends with spaces.
+func ConvertIndentationToTabs(state *EditorState) {
+	convertIndentation(state, true)
+}
+
+// convertIndentation rewrites the leading whitespace of every line to use tabs or spaces.
+// Lines that start inside a multi-line string are skipped, since their leading whitespace is part of the string.
+// All edits are grouped into a single undo entry.
+func convertIndentation(state *EditorState, useTabs bool) {
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return
+	}
+
+	lines, err := readLinesForWrap(buffer)
+	if err != nil {
+		log.Printf("Error reading document lines: %v\n", err)
+		return
+	}
+
+	// Apply edits in reverse order so that the positions of earlier lines remain valid.
+	var numChanged int
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	BeginUndoEntry(state)
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if lineStartsInsideString(buffer.syntaxParser, line.pos) {
+			continue
+		}
+
+		oldIndent := leadingIndent(line.text)
+		newIndent := convertedIndent(oldIndent, buffer.tabSize, useTabs)
+		if newIndent == oldIndent {
+			continue
+		}
+
+		if _, err := replaceRunes(state, line.pos, uint64(utf8.RuneCountInString(oldIndent)), newIndent, true); err != nil {
+			log.Printf("Error replacing indentation: %v\n", err)
+			break
+		}
+		numChanged++
+	}
+	CommitUndoEntry(state)
+
+	if numChanged > 0 {
+		lineNum := locate.ClosestValidLineNum(buffer.textTree, cursorLineNum)
+		lineStartPos := locate.StartOfLineNum(buffer.textTree, lineNum)
+		buffer.cursor = cursorState{position: locate.NextNonWhitespaceOrNewline(buffer.textTree, lineStartPos)}
+		ScrollViewToCursor(state)
+	}
+
+	indentName := "spaces"
+	if useTabs {
+		indentName = "tabs"
+	}
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Converted indentation to %s on %d line(s)", indentName, numChanged),
+	})
+}
+
+// lineStartsInsideString returns whether the line starting at pos continues a string from a previous line.
+func lineStartsInsideString(syntaxParser *parser.P, pos uint64) bool {
+	if syntaxParser == nil {
+		return false
+	}
+	token := syntaxParser.TokenAtPosition(pos)
+	return token.Role == parser.TokenRoleString && token.StartPos < pos
+}
+
+// convertedIndent returns indentation with the same width as indent, using tabs or spaces.
+func convertedIndent(indent string, tabSize uint64, useTabs bool) string {
+	var width uint64
+	for _, r := range indent {
+		if r == '\t' {
+			width += tabSize - (width % tabSize)
+		} else {
+			width++
+		}
+	}
+
+	if !useTabs {
+		return strings.Repeat(" ", int(width))
+	}
+	return strings.Repeat("\t", int(width/tabSize)) + strings.Repeat(" ", int(width%tabSize))
+}
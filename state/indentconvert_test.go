@@ -0,0 +1,78 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+func TestConvertIndentation(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputString    string
+		syntaxLanguage syntax.Language
+		useTabs        bool
+		expectedText   string
+		expectedStatus string
+	}{
+		{
+			name:           "empty document to spaces",
+			inputString:    "",
+			expectedText:   "",
+			expectedStatus: "Converted indentation to spaces on 0 line(s)",
+		},
+		{
+			name:           "tabs to spaces",
+			inputString:    "a\n\tb\n\t\tc\n  \td\ne\t",
+			expectedText:   "a\n    b\n        c\n    d\ne\t",
+			expectedStatus: "Converted indentation to spaces on 3 line(s)",
+		},
+		{
+			name:           "spaces to tabs",
+			inputString:    "a\n    b\n        c\n      d\n  e",
+			useTabs:        true,
+			expectedText:   "a\n\tb\n\t\tc\n\t  d\n  e",
+			expectedStatus: "Converted indentation to tabs on 3 line(s)",
+		},
+		{
+			name:           "mixed to tabs",
+			inputString:    "  \t  a",
+			useTabs:        true,
+			expectedText:   "\t  a",
+			expectedStatus: "Converted indentation to tabs on 1 line(s)",
+		},
+		{
+			name:           "skip lines inside Go raw string",
+			inputString:    "func f() {\n\tx := `\n\tkeep\n`\n\treturn\n}",
+			syntaxLanguage: syntax.LanguageGo,
+			expectedText:   "func f() {\n    x := `\n\tkeep\n`\n    return\n}",
+			expectedStatus: "Converted indentation to spaces on 2 line(s)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			setSyntaxAndRetokenize(state.documentBuffer, tc.syntaxLanguage)
+
+			if tc.useTabs {
+				ConvertIndentationToTabs(state)
+			} else {
+				ConvertIndentationToSpaces(state)
+			}
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedStatus, state.StatusMsg().Text)
+
+			// All changes are reverted by a single undo.
+			Undo(state)
+			assert.Equal(t, tc.inputString, textTree.String())
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/selection"
+)
+
+// StartInsertModeSelection starts selecting text in insert mode from the current cursor position.
+// The selection extends to the cursor as it moves.
+// If text is already selected, the selection is unchanged.
+func StartInsertModeSelection(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.selector.Mode() != selection.ModeCharExclusive {
+		buffer.selector.Start(selection.ModeCharExclusive, buffer.cursor.position)
+	}
+}
+
+// ClearInsertModeSelection clears the text selected in insert mode, if any.
+func ClearInsertModeSelection(state *EditorState) {
+	selector := state.documentBuffer.selector
+	if selector.Mode() == selection.ModeCharExclusive {
+		selector.Clear()
+	}
+}
+
+// DeleteInsertModeSelection deletes the text selected in insert mode and moves the cursor to where the text was.
+// It returns false if no text was selected.
+func DeleteInsertModeSelection(state *EditorState) bool {
+	buffer := state.documentBuffer
+	if buffer.selector.Mode() != selection.ModeCharExclusive {
+		return false
+	}
+
+	r := buffer.SelectedRegion()
+	buffer.selector.Clear()
+	deleteRunes(state, r.StartPos, r.EndPos-r.StartPos, true)
+	buffer.cursor = cursorState{position: r.StartPos}
+	return true
+}
+
+// CopyInsertModeSelection copies the text selected in insert mode to the default clipboard page.
+func CopyInsertModeSelection(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.selector.Mode() != selection.ModeCharExclusive {
+		return
+	}
+
+	r := buffer.SelectedRegion()
+	text := copyText(buffer.textTree, r.StartPos, r.EndPos-r.StartPos)
+	state.clipboard.SetYanked(clipboard.PageDefault, clipboard.PageContent{Text: text})
+}
+
+// CutInsertModeSelection copies the text selected in insert mode to the default clipboard page, then deletes it.
+func CutInsertModeSelection(state *EditorState) {
+	CopyInsertModeSelection(state)
+	DeleteInsertModeSelection(state)
+}
+
+// PasteInInsertMode replaces the text selected in insert mode (if any)
+// with the contents of the default clipboard page.
+func PasteInInsertMode(state *EditorState) {
+	content := state.clipboard.Get(clipboard.PageDefault)
+	text := content.Text
+	if content.Linewise {
+		text += "\n"
+	}
+	DeleteInsertModeSelection(state)
+	InsertText(state, text)
+}
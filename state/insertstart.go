@@ -0,0 +1,52 @@
+package state
+
+import (
+	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/locate"
+)
+
+// insertStartState tracks where the user started typing in insert mode,
+// so that ctrl-u can delete only the inserted text.
+type insertStartState struct {
+	valid bool
+	pos   uint64
+}
+
+// trackInsertStart records the cursor position as the start of inserted text
+// if this is the first insertion since entering insert mode.
+// If the user deleted text before the start (for example, using backspace),
+// the start moves back to the cursor.
+func trackInsertStart(state *EditorState) {
+	if state.inputMode != InputModeInsert {
+		return
+	}
+
+	buffer := state.documentBuffer
+	if !buffer.insertStart.valid || buffer.cursor.position < buffer.insertStart.pos {
+		buffer.insertStart = insertStartState{
+			valid: true,
+			pos:   buffer.cursor.position,
+		}
+	}
+}
+
+// DeleteToInsertStartOrLineStart deletes text before the cursor, similar to ctrl-u in readline.
+// If the user inserted text on the current line since entering insert mode,
+// this deletes only the inserted text; otherwise, it deletes to the start of the line.
+// At the start of a line, it deletes the preceding line break.
+func DeleteToInsertStartOrLineStart(state *EditorState, clipboardPage clipboard.PageId) {
+	buffer := state.documentBuffer
+	insertStart := buffer.insertStart
+	DeleteToPos(state, func(params LocatorParams) uint64 {
+		lineStartPos := locate.StartOfLineAtPos(params.TextTree, params.CursorPos)
+		if params.CursorPos == lineStartPos {
+			return locate.PrevChar(params.TextTree, 1, params.CursorPos)
+		}
+
+		if insertStart.valid && insertStart.pos >= lineStartPos && insertStart.pos < params.CursorPos {
+			return insertStart.pos
+		}
+
+		return lineStartPos
+	}, clipboardPage)
+}
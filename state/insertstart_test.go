@@ -0,0 +1,101 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/text"
+)
+
+func TestDeleteToInsertStartOrLineStart(t *testing.T) {
+	testCases := []struct {
+		name              string
+		initialText       string
+		cursorPos         uint64
+		insertText        string
+		numBackspace      uint64
+		numDeletes        int
+		expectedText      string
+		expectedCursorPos uint64
+	}{
+		{
+			name:              "no inserted text",
+			initialText:       "abc def",
+			cursorPos:         4,
+			numDeletes:        1,
+			expectedText:      "def",
+			expectedCursorPos: 0,
+		},
+		{
+			name:              "delete inserted text",
+			initialText:       "abc def",
+			cursorPos:         4,
+			insertText:        "xyz",
+			numDeletes:        1,
+			expectedText:      "abc def",
+			expectedCursorPos: 4,
+		},
+		{
+			name:              "delete inserted text then to line start",
+			initialText:       "abc def",
+			cursorPos:         4,
+			insertText:        "xyz",
+			numDeletes:        2,
+			expectedText:      "def",
+			expectedCursorPos: 0,
+		},
+		{
+			name:              "insert after backspace past insert start",
+			initialText:       "abc def",
+			cursorPos:         4,
+			insertText:        "x",
+			numBackspace:      3,
+			numDeletes:        1,
+			expectedText:      "abdef",
+			expectedCursorPos: 2,
+		},
+		{
+			name:              "inserted text on previous line",
+			initialText:       "abc",
+			cursorPos:         3,
+			insertText:        "x\ny",
+			numDeletes:        1,
+			expectedText:      "abcx\n",
+			expectedCursorPos: 5,
+		},
+		{
+			name:              "start of line",
+			initialText:       "abc\ndef",
+			cursorPos:         4,
+			numDeletes:        1,
+			expectedText:      "abcdef",
+			expectedCursorPos: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.initialText)
+			assert.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			buffer := state.documentBuffer
+			buffer.textTree = textTree
+			buffer.cursor.position = tc.cursorPos
+			EnterInsertMode(state)
+			InsertText(state, tc.insertText)
+			for i := uint64(0); i < tc.numBackspace; i++ {
+				DeleteToPos(state, func(p LocatorParams) uint64 { return p.CursorPos - 1 }, clipboard.PageNull)
+			}
+			if tc.numBackspace > 0 {
+				InsertText(state, tc.insertText)
+			}
+			for i := 0; i < tc.numDeletes; i++ {
+				DeleteToInsertStartOrLineStart(state, clipboard.PageNull)
+			}
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursorPos, buffer.cursor.position)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/config"
+)
+
+// keyboardLayoutPresets remap the keys in normal and visual mode for non-QWERTY keyboard layouts.
+// Each preset moves the "hjkl" navigation keys back to their positions on a QWERTY keyboard,
+// and moves the commands displaced by them to the keys they replaced, so every command is still bound.
+var keyboardLayoutPresets = map[string]map[rune]rune{
+	config.KeyboardLayoutColemak: {
+		// Colemak has "hnei" where QWERTY has "hjkl".
+		'n': 'j', 'e': 'k', 'i': 'l',
+		'j': 'n', 'k': 'e', 'l': 'i',
+		'N': 'J', 'E': 'K', 'I': 'L',
+		'J': 'N', 'K': 'E', 'L': 'I',
+	},
+	config.KeyboardLayoutDvorak: {
+		// Dvorak has "dhtn" where QWERTY has "hjkl".
+		'd': 'h', 'h': 'j', 't': 'k', 'n': 'l',
+		'j': 'd', 'k': 't', 'l': 'n',
+		'D': 'H', 'H': 'J', 'T': 'K', 'N': 'L',
+		'J': 'D', 'K': 'T', 'L': 'N',
+	},
+}
+
+// keyRemapForConfig combines the keyboard layout preset with the user's own remapped keys.
+// The user's keys take precedence, and a key remapped to itself restores the default binding.
+// This returns nil if no keys are remapped.
+func keyRemapForConfig(cfg config.Config) map[rune]rune {
+	preset := keyboardLayoutPresets[cfg.KeyboardLayout]
+	if len(preset) == 0 && len(cfg.KeyRemap) == 0 {
+		return nil
+	}
+
+	keyRemap := make(map[rune]rune, len(preset)+len(cfg.KeyRemap))
+	for from, to := range preset {
+		keyRemap[from] = to
+	}
+
+	for fromStr, toStr := range cfg.KeyRemap {
+		// Validation ensures these are single characters.
+		from, _ := utf8.DecodeRuneInString(fromStr)
+		to, _ := utf8.DecodeRuneInString(toStr)
+		if from == to {
+			delete(keyRemap, from)
+		} else {
+			keyRemap[from] = to
+		}
+	}
+
+	return keyRemap
+}
+
+// KeyRemap returns the keys to remap in normal and visual mode, from the key typed to the key a command expects.
+func (s *EditorState) KeyRemap() map[rune]rune {
+	return s.keyRemap
+}
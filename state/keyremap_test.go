@@ -0,0 +1,71 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aretext/aretext/config"
+)
+
+func TestKeyRemapForConfig(t *testing.T) {
+	testCases := []struct {
+		name           string
+		keyboardLayout string
+		keyRemap       map[string]string
+		expected       map[rune]rune
+	}{
+		{
+			name:           "qwerty without remapped keys",
+			keyboardLayout: config.KeyboardLayoutQwerty,
+			expected:       nil,
+		},
+		{
+			name:           "qwerty with remapped keys",
+			keyboardLayout: config.KeyboardLayoutQwerty,
+			keyRemap:       map[string]string{"ö": ";"},
+			expected:       map[rune]rune{'ö': ';'},
+		},
+		{
+			name:           "remapped key overrides preset",
+			keyboardLayout: config.KeyboardLayoutColemak,
+			keyRemap:       map[string]string{"k": "x"},
+			expected: map[rune]rune{
+				'n': 'j', 'e': 'k', 'i': 'l',
+				'j': 'n', 'k': 'x', 'l': 'i',
+				'N': 'J', 'E': 'K', 'I': 'L',
+				'J': 'N', 'K': 'E', 'L': 'I',
+			},
+		},
+		{
+			name:           "key remapped to itself removes preset",
+			keyboardLayout: config.KeyboardLayoutColemak,
+			keyRemap:       map[string]string{"N": "N", "J": "J", "E": "E", "K": "K", "I": "I", "L": "L"},
+			expected: map[rune]rune{
+				'n': 'j', 'e': 'k', 'i': 'l',
+				'j': 'n', 'k': 'e', 'l': 'i',
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Config{KeyboardLayout: tc.keyboardLayout, KeyRemap: tc.keyRemap}
+			assert.Equal(t, tc.expected, keyRemapForConfig(cfg))
+		})
+	}
+}
+
+func TestKeyboardLayoutPresetsArePermutations(t *testing.T) {
+	for layout, preset := range keyboardLayoutPresets {
+		t.Run(layout, func(t *testing.T) {
+			targets := make(map[rune]struct{}, len(preset))
+			for from, to := range preset {
+				targets[to] = struct{}{}
+				_, ok := preset[to]
+				assert.True(t, ok, "Key %q is remapped to %q, which is not itself remapped", from, to)
+			}
+			assert.Equal(t, len(preset), len(targets), "Two keys are remapped to the same key")
+		})
+	}
+}
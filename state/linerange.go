@@ -0,0 +1,338 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/shellcmd"
+)
+
+// LineRange is an inclusive range of line numbers, starting from zero.
+type LineRange struct {
+	StartLine uint64
+	EndLine   uint64
+}
+
+func (r LineRange) String() string {
+	// Show one-based line numbers, the same as the line number margin.
+	if r.StartLine == r.EndLine {
+		return fmt.Sprintf("line %d", r.StartLine+1)
+	}
+	return fmt.Sprintf("lines %d-%d", r.StartLine+1, r.EndLine+1)
+}
+
+// rangedCommandMenuItem parses a command menu query that starts with a line range, like "10,20d" or "'<,'>!sort".
+// It returns a menu item that executes the command on the lines in the range.
+// The second return value is false if the query does not start with a line range.
+//
+// A line range is either "%" for every line, or one or two addresses separated by a comma.
+// Each address is a line number (starting from one), "." for the cursor line, "$" for the last line,
+// or "'<" and "'>" for the first and last line of the visual mode selection.
+// An address can be followed by offsets like "+2" or "-1".
+//
+// The supported commands are "d" (delete), "y" (yank), ">" (indent), "<" (outdent),
+// and "!" followed by a shell command to filter the lines through.
+// With no command, the cursor moves to the last line in the range.
+func rangedCommandMenuItem(state *EditorState, query string) (menu.Item, bool, error) {
+	lineRange, cmd, ok, err := parseLineRangePrefix(state, query)
+	if !ok || err != nil {
+		return menu.Item{}, ok, err
+	}
+
+	cmd = strings.TrimSpace(cmd)
+	switch {
+	case cmd == "":
+		return menu.Item{
+			Name: fmt.Sprintf("go to line %d", lineRange.EndLine+1),
+			Action: func(s *EditorState) {
+				goToLineRangeEnd(s, lineRange)
+			},
+		}, true, nil
+
+	case cmd == "d" || cmd == "delete":
+		return menu.Item{
+			Name: fmt.Sprintf("delete %s", lineRange),
+			Action: func(s *EditorState) {
+				DeleteLineRange(s, lineRange, clipboard.PageDefault)
+			},
+		}, true, nil
+
+	case cmd == "y" || cmd == "yank":
+		return menu.Item{
+			Name: fmt.Sprintf("yank %s", lineRange),
+			Action: func(s *EditorState) {
+				CopyLineRange(s, lineRange, clipboard.PageDefault)
+			},
+		}, true, nil
+
+	case strings.Trim(cmd, ">") == "":
+		count := uint64(len(cmd))
+		return menu.Item{
+			Name: fmt.Sprintf("indent %s", lineRange),
+			Action: func(s *EditorState) {
+				IndentLineRange(s, lineRange, count)
+			},
+		}, true, nil
+
+	case strings.Trim(cmd, "<") == "":
+		count := uint64(len(cmd))
+		return menu.Item{
+			Name: fmt.Sprintf("outdent %s", lineRange),
+			Action: func(s *EditorState) {
+				OutdentLineRange(s, lineRange, count)
+			},
+		}, true, nil
+
+	case strings.HasPrefix(cmd, "!"):
+		shellCmd := strings.TrimSpace(cmd[1:])
+		if shellCmd == "" {
+			return menu.Item{}, true, errors.New("Missing shell command to filter lines")
+		}
+		return menu.Item{
+			Name: fmt.Sprintf("filter %s through %s", lineRange, shellCmd),
+			Action: func(s *EditorState) {
+				FilterLineRange(s, lineRange, shellCmd)
+			},
+		}, true, nil
+
+	default:
+		return menu.Item{}, true, fmt.Errorf("Unrecognized command for line range: %q", cmd)
+	}
+}
+
+// parseLineRangePrefix parses the line range at the start of the query and returns the rest of the query.
+// The third return value is false if the query does not start with a line range.
+func parseLineRangePrefix(state *EditorState, query string) (LineRange, string, bool, error) {
+	query = strings.TrimLeft(query, " ")
+	if strings.HasPrefix(query, "%") {
+		numLines := state.documentBuffer.textTree.NumLines()
+		return LineRange{StartLine: 0, EndLine: numLines - 1}, query[1:], true, nil
+	}
+
+	start, rest, ok, err := parseLineAddress(state, query)
+	if !ok || err != nil {
+		return LineRange{}, "", ok, err
+	}
+
+	end := start
+	if strings.HasPrefix(rest, ",") {
+		end, rest, ok, err = parseLineAddress(state, rest[1:])
+		if err != nil {
+			return LineRange{}, "", true, err
+		} else if !ok {
+			return LineRange{}, "", true, errors.New("Missing end of line range")
+		}
+	}
+
+	if end < start {
+		start, end = end, start
+	}
+
+	return LineRange{StartLine: start, EndLine: end}, rest, true, nil
+}
+
+// parseLineAddress parses a line address with optional offsets and returns the zero-based line number.
+// The third return value is false if the string does not start with a line address.
+func parseLineAddress(state *EditorState, s string) (uint64, string, bool, error) {
+	buffer := state.documentBuffer
+	numLines := buffer.textTree.NumLines()
+
+	var lineNum int64
+	switch {
+	case strings.HasPrefix(s, "."):
+		lineNum = int64(buffer.textTree.LineNumForPosition(buffer.cursor.position))
+		s = s[1:]
+	case strings.HasPrefix(s, "$"):
+		lineNum = int64(numLines) - 1
+		s = s[1:]
+	case strings.HasPrefix(s, "'<"), strings.HasPrefix(s, "'>"):
+		if buffer.selector.Mode() == selection.ModeNone {
+			return 0, "", true, errors.New("No selection for line range")
+		}
+		r := buffer.SelectedRegion()
+		if s[1] == '<' {
+			lineNum = int64(buffer.textTree.LineNumForPosition(r.StartPos))
+		} else {
+			endPos := r.EndPos
+			if endPos > r.StartPos {
+				endPos-- // The region end is exclusive.
+			}
+			lineNum = int64(buffer.textTree.LineNumForPosition(endPos))
+		}
+		s = s[2:]
+	default:
+		n, rest := splitLeadingDigits(s)
+		if n == "" {
+			return 0, "", false, nil
+		}
+		parsed, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			return 0, "", true, fmt.Errorf("Invalid line number: %s", n)
+		}
+		lineNum = parsed - 1 // convert one-based line number to zero-based.
+		s = rest
+	}
+
+	// Apply offsets like "+2" or "-1". A sign without digits means an offset of one.
+	for len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		sign := int64(1)
+		if s[0] == '-' {
+			sign = -1
+		}
+		n, rest := splitLeadingDigits(s[1:])
+		offset := int64(1)
+		if n != "" {
+			parsed, err := strconv.ParseInt(n, 10, 64)
+			if err != nil {
+				return 0, "", true, fmt.Errorf("Invalid line offset: %s", n)
+			}
+			offset = parsed
+		}
+		lineNum += sign * offset
+		s = rest
+	}
+
+	if lineNum < 0 || lineNum >= int64(numLines) {
+		return 0, "", true, fmt.Errorf("Line number out of range: %d", lineNum+1)
+	}
+
+	return uint64(lineNum), s, true, nil
+}
+
+func splitLeadingDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func goToLineRangeEnd(state *EditorState, r LineRange) {
+	setInputMode(state, InputModeNormal)
+	MoveCursor(state, func(p LocatorParams) uint64 {
+		return locate.StartOfLineNum(p.TextTree, r.EndLine)
+	})
+}
+
+// DeleteLineRange deletes every line in the range and moves the cursor to the line after the deleted lines.
+func DeleteLineRange(state *EditorState, r LineRange, clipboardPage clipboard.PageId) {
+	setInputMode(state, InputModeNormal)
+	BeginUndoEntry(state)
+	moveCursorToStartOfLine(state, r.StartLine)
+	DeleteLines(state, startOfLineLocator(r.EndLine), false, false, clipboardPage)
+	CommitUndoEntry(state)
+}
+
+// CopyLineRange copies every line in the range to the clipboard.
+// The cursor does not move.
+func CopyLineRange(state *EditorState, r LineRange, clipboardPage clipboard.PageId) {
+	setInputMode(state, InputModeNormal)
+	text := lineRangeText(state.documentBuffer, r)
+	state.clipboard.SetYanked(clipboardPage, clipboard.PageContent{
+		Text:     text,
+		Linewise: true,
+	})
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Yanked %s", r),
+	})
+}
+
+// IndentLineRange indents every line in the range.
+func IndentLineRange(state *EditorState, r LineRange, count uint64) {
+	setInputMode(state, InputModeNormal)
+	BeginUndoEntry(state)
+	moveCursorToStartOfLine(state, r.StartLine)
+	IndentLines(state, startOfLineLocator(r.EndLine), count)
+	CommitUndoEntry(state)
+}
+
+// OutdentLineRange outdents every line in the range.
+func OutdentLineRange(state *EditorState, r LineRange, count uint64) {
+	setInputMode(state, InputModeNormal)
+	BeginUndoEntry(state)
+	moveCursorToStartOfLine(state, r.StartLine)
+	OutdentLines(state, startOfLineLocator(r.EndLine), count)
+	CommitUndoEntry(state)
+}
+
+// FilterLineRange replaces the lines in the range with the output of a shell command.
+// The lines are passed to the command's stdin.
+// The command runs as an asynchronous task that the user can cancel.
+func FilterLineRange(state *EditorState, r LineRange, shellCmd string) {
+	setInputMode(state, InputModeNormal)
+	if err := checkEditable(state.documentBuffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return
+	}
+
+	log.Printf("Filtering %s through shell command: %q\n", r, shellCmd)
+	input := lineRangeText(state.documentBuffer, r) + "\n"
+	env := envVars(state)
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		output, err := shellcmd.RunFilter(ctx, shellCmd, env, input)
+		return func(state *EditorState) {
+			if err != nil {
+				setStatusForShellCmdResult(state, err)
+				return
+			}
+			checkpoint := beginUndoCheckpoint(state, "shell command filter")
+			replaceLineRange(state, r, strings.TrimSuffix(output, "\n"))
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  fmt.Sprintf("Filtered %s through shell command", r),
+			})
+			commitUndoCheckpoint(state, checkpoint)
+		}
+	})
+}
+
+func replaceLineRange(state *EditorState, r LineRange, s string) {
+	buffer := state.documentBuffer
+	if r.EndLine >= buffer.textTree.NumLines() {
+		// The document changed while the task was running, so the range is no longer valid.
+		log.Printf("Skipping replace of %s because the document has fewer lines\n", r)
+		return
+	}
+
+	startPos, endPos := lineRangePositions(buffer, r)
+	BeginUndoEntry(state)
+	_, err := replaceRunes(state, startPos, endPos-startPos, s, true)
+	CommitUndoEntry(state)
+	if err != nil {
+		log.Printf("Error replacing %s: %v\n", r, err)
+		return
+	}
+	moveCursorToStartOfLine(state, r.StartLine)
+}
+
+// lineRangePositions returns the start position of the first line in the range
+// and the end position of the last line in the range, excluding its newline.
+func lineRangePositions(buffer *BufferState, r LineRange) (uint64, uint64) {
+	startPos := buffer.textTree.LineStartPosition(r.StartLine)
+	endPos := locate.NextLineBoundary(buffer.textTree, true, buffer.textTree.LineStartPosition(r.EndLine))
+	return startPos, endPos
+}
+
+func lineRangeText(buffer *BufferState, r LineRange) string {
+	startPos, endPos := lineRangePositions(buffer, r)
+	return copyText(buffer.textTree, startPos, endPos-startPos)
+}
+
+func moveCursorToStartOfLine(state *EditorState, lineNum uint64) {
+	MoveCursor(state, startOfLineLocator(lineNum))
+}
+
+func startOfLineLocator(lineNum uint64) Locator {
+	return func(p LocatorParams) uint64 {
+		return locate.StartOfLineNum(p.TextTree, lineNum)
+	}
+}
@@ -0,0 +1,255 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/selection"
+)
+
+func TestParseLineRangePrefix(t *testing.T) {
+	testCases := []struct {
+		name          string
+		cursorLine    uint64
+		query         string
+		expectOk      bool
+		expectErr     string
+		expectRange   LineRange
+		expectRestCmd string
+	}{
+		{
+			name:     "not a range",
+			query:    "save",
+			expectOk: false,
+		},
+		{
+			name:          "single line number",
+			query:         "3d",
+			expectOk:      true,
+			expectRange:   LineRange{StartLine: 2, EndLine: 2},
+			expectRestCmd: "d",
+		},
+		{
+			name:          "line number range",
+			query:         "2,4 y",
+			expectOk:      true,
+			expectRange:   LineRange{StartLine: 1, EndLine: 3},
+			expectRestCmd: " y",
+		},
+		{
+			name:          "backwards range",
+			query:         "4,2>",
+			expectOk:      true,
+			expectRange:   LineRange{StartLine: 1, EndLine: 3},
+			expectRestCmd: ">",
+		},
+		{
+			name:          "whole document",
+			query:         "%!sort",
+			expectOk:      true,
+			expectRange:   LineRange{StartLine: 0, EndLine: 4},
+			expectRestCmd: "!sort",
+		},
+		{
+			name:          "cursor line to last line",
+			cursorLine:    1,
+			query:         ".,$d",
+			expectOk:      true,
+			expectRange:   LineRange{StartLine: 1, EndLine: 4},
+			expectRestCmd: "d",
+		},
+		{
+			name:          "offsets",
+			cursorLine:    1,
+			query:         ".-1,.+2d",
+			expectOk:      true,
+			expectRange:   LineRange{StartLine: 0, EndLine: 3},
+			expectRestCmd: "d",
+		},
+		{
+			name:          "offset without digits",
+			cursorLine:    1,
+			query:         ".,.+",
+			expectOk:      true,
+			expectRange:   LineRange{StartLine: 1, EndLine: 2},
+			expectRestCmd: "",
+		},
+		{
+			name:      "line number zero",
+			query:     "0d",
+			expectOk:  true,
+			expectErr: "Line number out of range: 0",
+		},
+		{
+			name:      "line number past end of document",
+			query:     "1,6d",
+			expectOk:  true,
+			expectErr: "Line number out of range: 6",
+		},
+		{
+			name:      "missing end of range",
+			query:     "1,d",
+			expectOk:  true,
+			expectErr: "Missing end of line range",
+		},
+		{
+			name:      "selection marks without selection",
+			query:     "'<,'>d",
+			expectOk:  true,
+			expectErr: "No selection for line range",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := NewEditorState(100, 100, nil, nil)
+			InsertText(state, "a\nb\nc\nd\ne")
+			moveCursorToStartOfLine(state, tc.cursorLine)
+
+			r, rest, ok, err := parseLineRangePrefix(state, tc.query)
+			assert.Equal(t, tc.expectOk, ok)
+			if tc.expectErr != "" {
+				assert.EqualError(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			if ok {
+				assert.Equal(t, tc.expectRange, r)
+				assert.Equal(t, tc.expectRestCmd, rest)
+			}
+		})
+	}
+}
+
+func TestParseLineRangePrefixSelection(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "a\nb\nc\nd\ne")
+	moveCursorToStartOfLine(state, 1)
+	ToggleVisualMode(state, selection.ModeLine)
+	moveCursorToStartOfLine(state, 3)
+
+	r, rest, ok, err := parseLineRangePrefix(state, "'<,'>d")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, LineRange{StartLine: 1, EndLine: 3}, r)
+	assert.Equal(t, "d", rest)
+}
+
+func TestRangedCommandInMenu(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		expectItemName string
+		expectText     string
+		expectCursor   uint64
+		expectStatus   string
+	}{
+		{
+			name:           "go to line",
+			query:          "3",
+			expectItemName: "go to line 3",
+			expectText:     "a\nb\nc\nd\ne",
+			expectCursor:   4,
+		},
+		{
+			name:           "delete lines",
+			query:          "2,3d",
+			expectItemName: "delete lines 2-3",
+			expectText:     "a\nd\ne",
+			expectCursor:   2,
+		},
+		{
+			name:           "delete last lines",
+			query:          "4,$ delete",
+			expectItemName: "delete lines 4-5",
+			expectText:     "a\nb\nc",
+			expectCursor:   4,
+		},
+		{
+			name:           "indent lines",
+			query:          "1,2>>",
+			expectItemName: "indent lines 1-2",
+			expectText:     "\t\ta\n\t\tb\nc\nd\ne",
+			expectCursor:   2,
+		},
+		{
+			name:           "yank line",
+			query:          "2y",
+			expectItemName: "yank line 2",
+			expectText:     "a\nb\nc\nd\ne",
+			expectCursor:   9,
+			expectStatus:   "Yanked line 2",
+		},
+		{
+			name:         "unrecognized command",
+			query:        "2,3x",
+			expectText:   "a\nb\nc\nd\ne",
+			expectCursor: 9,
+			expectStatus: `Unrecognized command for line range: "x"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := NewEditorState(100, 100, nil, nil)
+			InsertText(state, "a\nb\nc\nd\ne")
+			ShowMenu(state, MenuStyleCommand, nil)
+			for _, r := range tc.query {
+				AppendRuneToMenuSearch(state, r)
+			}
+
+			results, _ := state.Menu().SearchResults()
+			if tc.expectItemName != "" {
+				require.Equal(t, 1, len(results))
+				assert.Equal(t, tc.expectItemName, results[0].Name)
+			} else {
+				assert.Equal(t, 0, len(results))
+			}
+
+			ExecuteSelectedMenuItem(state)
+			assert.Equal(t, InputModeNormal, state.InputMode())
+			assert.Equal(t, tc.expectText, state.documentBuffer.textTree.String())
+			assert.Equal(t, tc.expectCursor, state.documentBuffer.cursor.position)
+			if tc.expectStatus != "" {
+				assert.Equal(t, tc.expectStatus, state.StatusMsg().Text)
+			}
+		})
+	}
+}
+
+func TestRangedCommandYankAndUndo(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "a\nb\nc\nd\ne")
+	CopyLineRange(state, LineRange{StartLine: 1, EndLine: 2}, clipboard.PageDefault)
+	assert.Equal(t, clipboard.PageContent{Text: "b\nc", Linewise: true}, state.clipboard.Get(clipboard.PageDefault))
+
+	DeleteLineRange(state, LineRange{StartLine: 0, EndLine: 3}, clipboard.PageDefault)
+	assert.Equal(t, "e", state.documentBuffer.textTree.String())
+	Undo(state)
+	assert.Equal(t, "a\nb\nc\nd\ne", state.documentBuffer.textTree.String())
+}
+
+func TestFilterLineRange(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		InsertText(state, "x\nc\nb\na\ny")
+		FilterLineRange(state, LineRange{StartLine: 1, EndLine: 3}, "sort")
+
+		select {
+		case action := <-state.TaskResultChan():
+			action(state)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "Timed out")
+		}
+
+		assert.Equal(t, "x\na\nb\nc\ny", state.documentBuffer.textTree.String())
+		assert.Equal(t, uint64(2), state.documentBuffer.cursor.position)
+		assert.Equal(t, "Filtered lines 2-4 through shell command. Press U to revert", state.StatusMsg().Text)
+
+		Undo(state)
+		assert.Equal(t, "x\nc\nb\na\ny", state.documentBuffer.textTree.String())
+	})
+}
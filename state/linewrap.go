@@ -0,0 +1,37 @@
+package state
+
+// ToggleLineWrap enables or disables soft-wrapping of long lines.
+// When line wrap is disabled, each line occupies a single row, and the view
+// scrolls horizontally to keep the cursor visible.
+// The choice is remembered for the document path until the editor exits.
+func ToggleLineWrap(state *EditorState) {
+	buffer := state.documentBuffer
+	setLineWrapDisabled(state, !buffer.lineWrapDisabled)
+
+	msg := "Enabled line wrap"
+	if buffer.lineWrapDisabled {
+		msg = "Disabled line wrap"
+	}
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  msg,
+	})
+}
+
+// setLineWrapDisabled enables or disables line wrap, remembering the choice for the document path.
+func setLineWrapDisabled(state *EditorState, disabled bool) {
+	buffer := state.documentBuffer
+	buffer.lineWrapDisabled = disabled
+	buffer.view.leftCol = 0
+	state.lineWrapChoices[state.fileWatcher.Path()] = !disabled
+	ScrollViewToCursor(state)
+}
+
+// applyLineWrapChoice restores the line wrap setting the user chose for a document path, if any.
+func applyLineWrapChoice(state *EditorState, path string) {
+	buffer := state.documentBuffer
+	buffer.lineWrapDisabled = false
+	if enabled, ok := state.lineWrapChoices[path]; ok {
+		buffer.lineWrapDisabled = !enabled
+	}
+}
@@ -0,0 +1,53 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestToggleLineWrap(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abcdefghijklmnopqrstuvwxyz")
+	require.NoError(t, err)
+	state := NewEditorState(10, 10, nil, nil)
+	state.documentBuffer.textTree = textTree
+	state.documentBuffer.cursor.position = 20
+
+	ToggleLineWrap(state)
+	assert.False(t, state.documentBuffer.LineWrapEnabled())
+	assert.Equal(t, "Disabled line wrap", state.StatusMsg().Text)
+	assert.Equal(t, uint64(11), state.documentBuffer.view.leftCol)
+
+	ToggleLineWrap(state)
+	assert.True(t, state.documentBuffer.LineWrapEnabled())
+	assert.Equal(t, "Enabled line wrap", state.StatusMsg().Text)
+	assert.Equal(t, uint64(0), state.documentBuffer.view.leftCol)
+}
+
+func TestLineWrapChoiceRememberedForPath(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+	ToggleLineWrap(state)
+	assert.False(t, state.documentBuffer.LineWrapEnabled())
+
+	// Reloading the document preserves the choice.
+	ReloadDocument(state)
+	assert.False(t, state.documentBuffer.LineWrapEnabled())
+
+	// Opening another document uses the default.
+	otherPath, otherCleanup := createTestFile(t, "xyz")
+	defer otherCleanup()
+	LoadDocument(state, otherPath, true, startOfDocLocator)
+	assert.True(t, state.documentBuffer.LineWrapEnabled())
+
+	// Returning to the original document restores the choice.
+	LoadDocument(state, path, true, startOfDocLocator)
+	assert.False(t, state.documentBuffer.LineWrapEnabled())
+}
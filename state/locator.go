@@ -63,7 +63,7 @@ func SelectionEndLocator(textTree *text.Tree, cursorPos uint64, selector *select
 	switch selector.Mode() {
 	case selection.ModeNone:
 		return nil
-	case selection.ModeChar:
+	case selection.ModeChar, selection.ModeCharExclusive:
 		return charwiseSelectionEndLocator(textTree, r)
 	case selection.ModeLine:
 		return linewiseSelectionEndLocator(textTree, r)
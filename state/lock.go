@@ -0,0 +1,100 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aretext/aretext/file"
+)
+
+// SetLockStore sets the store used to tell other instances which files have unsaved changes.
+func SetLockStore(state *EditorState, store *file.LockStore) {
+	state.lockStore = store
+}
+
+// SyncFileLocks locks each open document with unsaved changes and unlocks the rest,
+// so other instances can warn before saving over the changes.
+// Only locks that changed since the last sync are written, so this is cheap to call after every event.
+func SyncFileLocks(state *EditorState) {
+	if state.lockStore == nil {
+		return
+	}
+
+	modifiedPaths := make(map[string]struct{})
+	activeIdx := activeBufferIdx(state)
+	for i, b := range state.openBuffers {
+		buffer, watcher := b.buffer, b.watcher
+		if i == activeIdx {
+			buffer, watcher = state.documentBuffer, state.fileWatcher
+		}
+		path := watcher.Path()
+		if path != "" && !buffer.scratch && buffer.undoLog.HasUnsavedChanges() {
+			modifiedPaths[path] = struct{}{}
+		}
+	}
+
+	for path := range modifiedPaths {
+		if _, ok := state.lockedPaths[path]; ok {
+			continue
+		}
+		if err := state.lockStore.Lock(path); err != nil {
+			log.Printf("Error locking %q: %v\n", path, err)
+			continue
+		}
+		if state.lockedPaths == nil {
+			state.lockedPaths = make(map[string]struct{})
+		}
+		state.lockedPaths[path] = struct{}{}
+	}
+
+	for path := range state.lockedPaths {
+		if _, ok := modifiedPaths[path]; ok {
+			continue
+		}
+		if err := state.lockStore.Unlock(path); err != nil {
+			log.Printf("Error unlocking %q: %v\n", path, err)
+		}
+		delete(state.lockedPaths, path)
+	}
+}
+
+// ReleaseFileLocks unlocks every file locked by this instance.
+// This should be called before the editor exits.
+func ReleaseFileLocks(state *EditorState) {
+	if state.lockStore == nil {
+		return
+	}
+
+	for path := range state.lockedPaths {
+		if err := state.lockStore.Unlock(path); err != nil {
+			log.Printf("Error unlocking %q: %v\n", path, err)
+		}
+	}
+	state.lockedPaths = nil
+}
+
+// checkFileLockedByOtherInstance returns an error if another instance has unsaved changes to the file at path.
+// Errors reading the locks are logged, but do not prevent the save, since the locks are only advisory.
+func checkFileLockedByOtherInstance(state *EditorState, path string) error {
+	if state.lockStore == nil {
+		return nil
+	}
+
+	pids, err := state.lockStore.OtherLockPids(path)
+	if err != nil {
+		log.Printf("Error checking locks for %q: %v\n", path, err)
+		return nil
+	} else if len(pids) == 0 {
+		return nil
+	}
+
+	pidStrs := make([]string, 0, len(pids))
+	for _, pid := range pids {
+		pidStrs = append(pidStrs, strconv.Itoa(pid))
+	}
+
+	return fmt.Errorf("Another aretext instance (pid %s) has unsaved changes to %s", strings.Join(pidStrs, ", "), filepath.Base(path))
+}
@@ -0,0 +1,109 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+func TestSyncFileLocks(t *testing.T) {
+	lockDir := t.TempDir()
+	otherStore := file.NewLockStore(lockDir, os.Getppid())
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetLockStore(state, file.NewLockStore(lockDir, os.Getpid()))
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+
+	// The file isn't locked until it has unsaved changes.
+	SyncFileLocks(state)
+	pids, err := otherStore.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(pids))
+
+	BeginUndoEntry(state)
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+	SyncFileLocks(state)
+	pids, err = otherStore.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, []int{os.Getpid()}, pids)
+
+	// Saving the document removes the lock.
+	SaveDocument(state)
+	SyncFileLocks(state)
+	pids, err = otherStore.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(pids))
+
+	// Releasing the locks removes the lock even if the document has unsaved changes.
+	BeginUndoEntry(state)
+	InsertText(state, "y")
+	CommitUndoEntry(state)
+	SyncFileLocks(state)
+	ReleaseFileLocks(state)
+	pids, err = otherStore.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(pids))
+}
+
+func TestSyncFileLocksBackgroundBuffer(t *testing.T) {
+	lockDir := t.TempDir()
+	otherStore := file.NewLockStore(lockDir, os.Getppid())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0644))
+	otherPath := filepath.Join(dir, "b.txt")
+	require.NoError(t, os.WriteFile(otherPath, []byte("b"), 0644))
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetLockStore(state, file.NewLockStore(lockDir, os.Getpid()))
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+	BeginUndoEntry(state)
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+
+	// The document stays locked while its unsaved changes are in the background.
+	LoadDocument(state, otherPath, true, startOfDocLocator)
+	SyncFileLocks(state)
+	pids, err := otherStore.OtherLockPids(path)
+	require.NoError(t, err)
+	assert.Equal(t, []int{os.Getpid()}, pids)
+	pids, err = otherStore.OtherLockPids(otherPath)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(pids))
+}
+
+func TestAbortIfFileChangedLockedByOtherInstance(t *testing.T) {
+	lockDir := t.TempDir()
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetLockStore(state, file.NewLockStore(lockDir, os.Getpid()))
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+
+	// Another instance has unsaved changes to the file, so saving should abort.
+	otherStore := file.NewLockStore(lockDir, os.Getppid())
+	require.NoError(t, otherStore.Lock(path))
+	AbortIfFileChanged(state, SaveDocument)
+	assert.Equal(t, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf(`Another aretext instance (pid %d) has unsaved changes to %s. Use "force save" to overwrite.`, os.Getppid(), filepath.Base(path)),
+	}, state.StatusMsg())
+
+	// Once the other instance saves its changes, the save succeeds.
+	require.NoError(t, otherStore.Unlock(path))
+	AbortIfFileChanged(state, SaveDocument)
+	assert.Equal(t, StatusMsgStyleSuccess, state.StatusMsg().Style)
+}
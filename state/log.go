@@ -0,0 +1,43 @@
+package state
+
+import (
+	"log"
+
+	"github.com/aretext/aretext/locate"
+)
+
+// SetLogPath sets the path to the log file, so the user can open it from the editor.
+// An empty path means that logging is disabled.
+func SetLogPath(state *EditorState, path string) {
+	state.logPath = path
+}
+
+// OpenLog loads the log file as a read-only document.
+// The cursor follows the end of the log as new output is written,
+// similar to "tail -f".
+func OpenLog(state *EditorState) {
+	if state.logPath == "" {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Logging is disabled. Start aretext with the -log flag to enable it",
+		})
+		return
+	}
+
+	log.Printf("Opening log file at %q\n", state.logPath)
+	LoadDocument(state, state.logPath, true, func(p LocatorParams) uint64 {
+		return locate.StartOfLastLine(p.TextTree)
+	})
+
+	if state.fileWatcher.Path() == state.logPath {
+		state.documentBuffer.readOnly = true
+		startFollowTail(state.documentBuffer)
+	}
+}
+
+func moveCursorToLastLine(state *EditorState) {
+	MoveCursor(state, func(p LocatorParams) uint64 {
+		return locate.StartOfLastLine(p.TextTree)
+	})
+	ScrollViewToCursor(state)
+}
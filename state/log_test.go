@@ -0,0 +1,48 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenLogDisabled(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	OpenLog(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Contains(t, state.StatusMsg().Text, "Logging is disabled")
+}
+
+func TestOpenLogReadOnlyAndFollow(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer state.fileWatcher.Stop()
+	SetLogPath(state, path)
+	OpenLog(state)
+	assert.True(t, state.documentBuffer.ReadOnly())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+
+	// Edits are rejected.
+	InsertText(state, "xyz")
+	assert.Equal(t, "foo\nbar", state.documentBuffer.textTree.String())
+	assert.Equal(t, "Document is read-only", state.StatusMsg().Text)
+
+	// After more output is written, reloading moves the cursor to the last line.
+	err := os.WriteFile(path, []byte("foo\nbar\nbaz\n"), 0644)
+	require.NoError(t, err)
+	ReloadDocument(state)
+	defer state.fileWatcher.Stop()
+	assert.True(t, state.documentBuffer.ReadOnly())
+	assert.Equal(t, uint64(8), state.documentBuffer.cursor.position)
+
+	// Loading another document resets read-only mode.
+	otherPath, otherCleanup := createTestFile(t, "abc")
+	defer otherCleanup()
+	LoadDocument(state, otherPath, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+	assert.False(t, state.documentBuffer.ReadOnly())
+}
@@ -0,0 +1,95 @@
+package state
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+// longLineChoice records how the user responded to the warning about a document with long lines.
+type longLineChoice int
+
+const (
+	longLineChoiceNone    = longLineChoice(iota) // The user hasn't been warned.
+	longLineChoiceKeep                           // The user kept syntax highlighting and line wrap.
+	longLineChoiceDisable                        // The user disabled syntax highlighting and line wrap.
+)
+
+// checkLongLines warns the user if the document contains lines longer than the configured threshold,
+// and offers to disable syntax highlighting and line wrap to keep the editor responsive.
+// The warning is shown at most once for each document loaded into the buffer. After that,
+// the user's choice is applied automatically whenever the document is reloaded.
+func checkLongLines(state *EditorState, path string) {
+	buffer := state.documentBuffer
+	switch buffer.longLineChoice {
+	case longLineChoiceKeep:
+		return
+	case longLineChoiceDisable:
+		// Line wrap stays as the user last chose, since the choice is remembered for the document path.
+		disableSyntaxHighlighting(buffer)
+		return
+	}
+
+	threshold := state.longLineThreshold
+	if threshold == 0 || !hasLineLongerThan(buffer.textTree, threshold) {
+		return
+	}
+
+	log.Printf("Document at %q has lines longer than %d characters\n", path, threshold)
+	buffer.longLineChoice = longLineChoiceKeep
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("Document has lines longer than %d characters, which may be slow to edit", threshold),
+	})
+
+	ShowMenu(state, MenuStyleLongLines, []menu.Item{
+		{
+			Name: "disable syntax highlighting and line wrap",
+			Action: func(state *EditorState) {
+				state.documentBuffer.longLineChoice = longLineChoiceDisable
+				disableSyntaxHighlighting(state.documentBuffer)
+				setLineWrapDisabled(state, true)
+				SetStatusMsg(state, StatusMsg{
+					Style: StatusMsgStyleSuccess,
+					Text:  "Disabled syntax highlighting and line wrap",
+				})
+			},
+		},
+		{
+			Name: "keep syntax highlighting and line wrap",
+			Action: func(state *EditorState) {
+				state.documentBuffer.longLineChoice = longLineChoiceKeep
+			},
+		},
+	})
+}
+
+// disableSyntaxHighlighting turns off syntax highlighting, which is expensive for very long lines.
+func disableSyntaxHighlighting(buffer *BufferState) {
+	setSyntaxAndRetokenize(buffer, syntax.LanguagePlaintext)
+}
+
+func hasLineLongerThan(tree *text.Tree, threshold uint64) bool {
+	if tree.NumChars() <= threshold {
+		return false
+	}
+
+	numLines := tree.NumLines()
+	lineStartPos := uint64(0)
+	for lineNum := uint64(1); lineNum <= numLines; lineNum++ {
+		nextLineStartPos := tree.LineStartPosition(lineNum)
+		lineLen := nextLineStartPos - lineStartPos
+		if lineNum < numLines {
+			lineLen-- // Exclude the newline at the end of the line.
+		}
+		if lineLen > threshold {
+			return true
+		}
+		lineStartPos = nextLineStartPos
+	}
+	return false
+}
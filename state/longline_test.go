@@ -0,0 +1,164 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+func TestHasLineLongerThan(t *testing.T) {
+	testCases := []struct {
+		name      string
+		text      string
+		threshold uint64
+		expected  bool
+	}{
+		{name: "empty", text: "", threshold: 3, expected: false},
+		{name: "short lines", text: "abc\ndef\nghi", threshold: 3, expected: false},
+		{name: "long first line", text: "abcd\nef", threshold: 3, expected: true},
+		{name: "long middle line", text: "ab\ncdef\ngh\n", threshold: 3, expected: true},
+		{name: "long last line", text: "ab\ncdef", threshold: 3, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := text.NewTreeFromString(tc.text)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, hasLineLongerThan(tree, tc.threshold))
+		})
+	}
+}
+
+func TestCheckLongLines(t *testing.T) {
+	testCases := []struct {
+		name              string
+		selectItemName    string
+		expectSyntax      syntax.Language
+		expectWrapOff     bool
+		expectStatusStyle StatusMsgStyle
+	}{
+		{
+			name:              "disable features",
+			selectItemName:    "disable syntax highlighting and line wrap",
+			expectSyntax:      syntax.LanguagePlaintext,
+			expectWrapOff:     true,
+			expectStatusStyle: StatusMsgStyleSuccess,
+		},
+		{
+			name:              "keep features",
+			selectItemName:    "keep syntax highlighting and line wrap",
+			expectSyntax:      syntax.LanguageJson,
+			expectWrapOff:     false,
+			expectStatusStyle: StatusMsgStyleError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := createTestFile(t, "[\n\""+strings.Repeat("a", 20)+"\"\n]")
+			defer cleanup()
+
+			configRuleSet := config.RuleSet{
+				{
+					Name:    "test",
+					Pattern: "**",
+					Config: map[string]any{
+						"syntaxLanguage":    "json",
+						"lineWrap":          "word",
+						"longLineThreshold": 10,
+					},
+				},
+			}
+			state := NewEditorState(100, 100, configRuleSet, nil)
+			defer state.fileWatcher.Stop()
+			LoadDocument(state, path, true, startOfDocLocator)
+			assert.Equal(t, InputModeMenu, state.InputMode())
+			assert.Equal(t, MenuStyleLongLines, state.Menu().Style())
+
+			results, _ := state.Menu().SearchResults()
+			require.Equal(t, 2, len(results))
+			for i, item := range results {
+				if item.Name == tc.selectItemName {
+					for j := 0; j < i; j++ {
+						MoveMenuSelection(state, 1)
+					}
+				}
+			}
+			ExecuteSelectedMenuItem(state)
+			assert.Equal(t, InputModeNormal, state.InputMode())
+			assert.Equal(t, tc.expectSyntax, state.documentBuffer.syntaxLanguage)
+			assert.Equal(t, tc.expectWrapOff, state.documentBuffer.lineWrapDisabled)
+			assert.Equal(t, tc.expectStatusStyle, state.StatusMsg().Style)
+
+			// Reloading applies the same choice without showing the warning again.
+			ReloadDocument(state)
+			defer state.fileWatcher.Stop()
+			assert.Equal(t, InputModeNormal, state.InputMode())
+			assert.Equal(t, tc.expectSyntax, state.documentBuffer.syntaxLanguage)
+			assert.Equal(t, tc.expectWrapOff, state.documentBuffer.lineWrapDisabled)
+		})
+	}
+}
+
+func TestCheckLongLinesOtherDocument(t *testing.T) {
+	dir := t.TempDir()
+	longText := []byte(strings.Repeat("a", 20))
+	path := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(path, longText, 0644))
+	otherPath := filepath.Join(dir, "b.txt")
+	require.NoError(t, os.WriteFile(otherPath, longText, 0644))
+
+	configRuleSet := config.RuleSet{
+		{
+			Name:    "test",
+			Pattern: "**",
+			Config:  map[string]any{"longLineThreshold": 10},
+		},
+	}
+	state := NewEditorState(100, 100, configRuleSet, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+	require.Equal(t, MenuStyleLongLines, state.Menu().Style())
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, longLineChoiceDisable, state.documentBuffer.longLineChoice)
+	assert.True(t, state.documentBuffer.lineWrapDisabled)
+
+	// Another document is checked again, with line wrap enabled until the user chooses.
+	LoadDocument(state, otherPath, true, startOfDocLocator)
+	assert.Equal(t, InputModeMenu, state.InputMode())
+	assert.Equal(t, MenuStyleLongLines, state.Menu().Style())
+	assert.False(t, state.documentBuffer.lineWrapDisabled)
+}
+
+func TestCheckLongLinesKeepsLineWrapToggle(t *testing.T) {
+	path, cleanup := createTestFile(t, strings.Repeat("a", 20))
+	defer cleanup()
+
+	configRuleSet := config.RuleSet{
+		{
+			Name:    "test",
+			Pattern: "**",
+			Config:  map[string]any{"longLineThreshold": 10},
+		},
+	}
+	state := NewEditorState(100, 100, configRuleSet, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+	require.Equal(t, MenuStyleLongLines, state.Menu().Style())
+	ExecuteSelectedMenuItem(state)
+	assert.True(t, state.documentBuffer.lineWrapDisabled)
+
+	// The user enables line wrap again, so reloading keeps it enabled.
+	ToggleLineWrap(state)
+	ReloadDocument(state)
+	assert.Equal(t, syntax.LanguagePlaintext, state.documentBuffer.syntaxLanguage)
+	assert.False(t, state.documentBuffer.lineWrapDisabled)
+}
@@ -23,12 +23,21 @@ const (
 	MenuStyleParentDir
 	MenuStyleInsertChoice
 	MenuStyleWorkingDir
+	MenuStyleKeyBindings
+	MenuStyleLongLines
+	MenuStyleSubmenu
+	MenuStyleOperator
+	MenuStyleBookmark
+	MenuStyleBackup
+	MenuStyleScratch
+	MenuStyleBuffer
+	MenuStyleConfigDir
 )
 
 // EmptyQueryShowAll returns whether an empty query should show all items.
 func (s MenuStyle) EmptyQueryShowAll() bool {
 	switch s {
-	case MenuStyleFilePath, MenuStyleFileLocation, MenuStyleChildDir, MenuStyleParentDir, MenuStyleInsertChoice, MenuStyleWorkingDir:
+	case MenuStyleFilePath, MenuStyleFileLocation, MenuStyleChildDir, MenuStyleParentDir, MenuStyleInsertChoice, MenuStyleWorkingDir, MenuStyleKeyBindings, MenuStyleLongLines, MenuStyleSubmenu, MenuStyleOperator, MenuStyleBookmark, MenuStyleBackup, MenuStyleScratch, MenuStyleBuffer, MenuStyleConfigDir:
 		return true
 	default:
 		return false
@@ -53,6 +62,13 @@ type MenuState struct {
 
 	// prevInputMode is the input mode to set after exiting menu mode.
 	prevInputMode InputMode
+
+	// rangedItem is the command for a query that starts with a line range, like "10,20d".
+	// If set, it replaces the search results.
+	rangedItem *menu.Item
+
+	// rangedErr is the error from parsing a query that starts with a line range.
+	rangedErr error
 }
 
 func (m *MenuState) Style() MenuStyle {
@@ -64,16 +80,25 @@ func (m *MenuState) SearchQuery() string {
 }
 
 func (m *MenuState) SearchResults() (results []menu.Item, selectedResultIdx int) {
-	if m.search == nil {
-		return nil, 0
+	return m.results(), m.selectedResultIdx
+}
+
+func (m *MenuState) results() []menu.Item {
+	if m.rangedItem != nil {
+		return []menu.Item{*m.rangedItem}
+	} else if m.rangedErr != nil || m.search == nil {
+		return nil
 	}
-	return m.search.Results(), m.selectedResultIdx
+	return m.search.Results()
 }
 
 // ShowMenu displays the menu with the specified style and items.
 func ShowMenu(state *EditorState, style MenuStyle, items []menu.Item) {
 	if style == MenuStyleCommand {
 		items = append(items, state.customMenuItems...)
+		if state.pluginRegistry != nil {
+			items = append(items, state.pluginRegistry.sortedMenuItems()...)
+		}
 	}
 
 	switch style {
@@ -82,9 +107,24 @@ func ShowMenu(state *EditorState, style MenuStyle, items []menu.Item) {
 		// This ensures that longer paths appear first when listing parent directory paths.
 		sort.SliceStable(items, func(i, j int) bool { return items[i].Name > items[j].Name })
 
-	case MenuStyleCommand, MenuStyleFilePath, MenuStyleChildDir:
+	case MenuStyleCommand:
+		// Sort lexicographic order ascending, then move pinned commands to the front.
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		arrangeCommandMenuItems(items, state.menuPinnedCommands, state.menuHiddenCommands)
+
+	case MenuStyleChildDir, MenuStyleConfigDir:
 		// Sort lexicographic order ascending.
 		sort.SliceStable(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	case MenuStyleFilePath:
+		// Show the most frequently and recently opened files first,
+		// then the rest in lexicographic order ascending.
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Boost != items[j].Boost {
+				return items[i].Boost > items[j].Boost
+			}
+			return items[i].Name < items[j].Name
+		})
 	}
 
 	search := menu.NewSearch(items, style.EmptyQueryShowAll())
@@ -97,13 +137,59 @@ func ShowMenu(state *EditorState, style MenuStyle, items []menu.Item) {
 	setInputMode(state, InputModeMenu)
 }
 
+// pinnedMenuCommandBoost increases the search score of pinned commands,
+// so they rank above other commands that match a query equally well.
+const pinnedMenuCommandBoost = 1.0
+
+// arrangeCommandMenuItems marks the commands pinned or hidden by the configuration
+// and moves pinned commands to the front of the items, in the configured order.
+// Built-in, custom, and plugin commands are all matched by name, and names that match no command are ignored.
+func arrangeCommandMenuItems(items []menu.Item, pinned []string, hidden []string) {
+	if len(pinned) == 0 && len(hidden) == 0 {
+		return
+	}
+
+	pinOrder := make(map[string]int, len(pinned))
+	for i, name := range pinned {
+		if _, ok := pinOrder[name]; !ok {
+			pinOrder[name] = i
+		}
+	}
+
+	hiddenSet := make(map[string]struct{}, len(hidden))
+	for _, name := range hidden {
+		hiddenSet[name] = struct{}{}
+	}
+
+	for i := range items {
+		if _, ok := pinOrder[items[i].Name]; ok {
+			items[i].Pinned = true
+			items[i].Boost += pinnedMenuCommandBoost
+		}
+		if _, ok := hiddenSet[items[i].Name]; ok {
+			items[i].Hidden = true
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		pi, iPinned := pinOrder[items[i].Name]
+		pj, jPinned := pinOrder[items[j].Name]
+		if iPinned && jPinned {
+			return pi < pj
+		}
+		return iPinned && !jPinned
+	})
+}
+
 // ShowFileMenu displays a menu for finding and loading files in the current working directory.
+// Files that were opened frequently and recently rank higher in the search results.
 // The files are loaded asynchronously as a task that the user can cancel.
 func ShowFileMenu(s *EditorState, hidePatterns []string) {
 	log.Printf("Scheduling task to load file menu items...\n")
+	frecencyStore := s.frecencyStore
 	StartTask(s, func(ctx context.Context) func(*EditorState) {
 		log.Printf("Starting to load file menu items...\n")
-		items := loadFileMenuItems(ctx, hidePatterns)
+		items := loadFileMenuItems(ctx, hidePatterns, frecencyStore)
 		log.Printf("Successfully loaded %d file menu items\n", len(items))
 		return func(s *EditorState) {
 			ShowMenu(s, MenuStyleFilePath, items)
@@ -111,7 +197,7 @@ func ShowFileMenu(s *EditorState, hidePatterns []string) {
 	})
 }
 
-func loadFileMenuItems(ctx context.Context, hidePatterns []string) []menu.Item {
+func loadFileMenuItems(ctx context.Context, hidePatterns []string, frecencyStore *file.FrecencyStore) []menu.Item {
 	dir, err := os.Getwd()
 	if err != nil {
 		log.Printf("Error loading menu items: %v\n", fmt.Errorf("os.GetCwd: %w", err))
@@ -123,11 +209,14 @@ func loadFileMenuItems(ctx context.Context, hidePatterns []string) []menu.Item {
 	})
 	log.Printf("Listed %d paths for dir %q\n", len(paths), dir)
 
+	boosts := frecencyBoosts(frecencyStore, dir)
 	items := make([]menu.Item, 0, len(paths))
 	for _, p := range paths {
 		menuPath := p // reference path in this iteration of the loop
+		name := file.RelativePath(menuPath, dir)
 		items = append(items, menu.Item{
-			Name: file.RelativePath(menuPath, dir),
+			Name:  name,
+			Boost: boosts[name],
 			Action: func(s *EditorState) {
 				LoadDocument(s, menuPath, true, func(LocatorParams) uint64 {
 					return 0
@@ -218,8 +307,16 @@ func HideMenu(state *EditorState) {
 
 // ExecuteSelectedMenuItem executes the action of the selected menu item and closes the menu.
 func ExecuteSelectedMenuItem(state *EditorState) {
-	search := state.menu.search
-	results := search.Results()
+	if err := state.menu.rangedErr; err != nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  err.Error(),
+		})
+		HideMenu(state)
+		return
+	}
+
+	results := state.menu.results()
 	if len(results) == 0 {
 		// If there are no results, then there is no action to perform.
 		SetStatusMsg(state, StatusMsg{
@@ -254,7 +351,7 @@ func executeMenuItemAction(state *EditorState, item menu.Item) {
 
 // MoveMenuSelection moves the menu selection up or down with wraparound.
 func MoveMenuSelection(state *EditorState, delta int) {
-	numResults := len(state.menu.search.Results())
+	numResults := len(state.menu.results())
 	if numResults == 0 {
 		return
 	}
@@ -267,12 +364,43 @@ func MoveMenuSelection(state *EditorState, delta int) {
 	state.menu.selectedResultIdx = newIdx
 }
 
+// MoveMenuSelectionWithoutWraparound moves the menu selection up or down,
+// stopping at the first or last result.
+func MoveMenuSelectionWithoutWraparound(state *EditorState, delta int) {
+	numResults := len(state.menu.results())
+	if numResults == 0 {
+		return
+	}
+
+	newIdx := state.menu.selectedResultIdx + delta
+	if newIdx < 0 {
+		newIdx = 0
+	} else if newIdx >= numResults {
+		newIdx = numResults - 1
+	}
+
+	state.menu.selectedResultIdx = newIdx
+}
+
+// MoveMenuSelectionToFirst selects the first menu result.
+func MoveMenuSelectionToFirst(state *EditorState) {
+	state.menu.selectedResultIdx = 0
+}
+
+// MoveMenuSelectionToLast selects the last menu result.
+func MoveMenuSelectionToLast(state *EditorState) {
+	numResults := len(state.menu.results())
+	if numResults == 0 {
+		return
+	}
+	state.menu.selectedResultIdx = numResults - 1
+}
+
 // AppendMenuSearch appends a rune to the menu search query.
 func AppendRuneToMenuSearch(state *EditorState, r rune) {
 	menu := state.menu
 	menu.query.Push(r)
-	menu.search.Execute(menu.query.String())
-	menu.selectedResultIdx = 0
+	executeMenuSearch(state)
 }
 
 // DeleteMenuSearch deletes a rune from the menu search query.
@@ -280,7 +408,24 @@ func DeleteRuneFromMenuSearch(state *EditorState) {
 	menu := state.menu
 	if menu.query.Len() > 0 {
 		menu.query.Pop()
-		menu.search.Execute(menu.query.String())
-		menu.selectedResultIdx = 0
+		executeMenuSearch(state)
 	}
 }
+
+// executeMenuSearch updates the search results for the current query.
+// In the command menu, a query that starts with a line range is parsed as a ranged command instead.
+func executeMenuSearch(state *EditorState) {
+	menu := state.menu
+	query := menu.query.String()
+	menu.rangedItem, menu.rangedErr = nil, nil
+	if menu.style == MenuStyleCommand {
+		item, ok, err := rangedCommandMenuItem(state, query)
+		if ok && err != nil {
+			menu.rangedErr = err
+		} else if ok {
+			menu.rangedItem = &item
+		}
+	}
+	menu.search.Execute(query)
+	menu.selectedResultIdx = 0
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/menu"
 	"github.com/aretext/aretext/selection"
 )
@@ -159,6 +161,38 @@ func TestMoveMenuSelection(t *testing.T) {
 	}
 }
 
+func TestMoveMenuSelectionWithoutWraparound(t *testing.T) {
+	items := []menu.Item{
+		{Name: "test1"},
+		{Name: "test2"},
+		{Name: "test3"},
+		{Name: "test4"},
+	}
+	state := NewEditorState(100, 100, nil, nil)
+	ShowMenu(state, MenuStyleCommand, items)
+	AppendRuneToMenuSearch(state, 't')
+
+	MoveMenuSelectionWithoutWraparound(state, -1)
+	_, selectedIdx := state.Menu().SearchResults()
+	assert.Equal(t, 0, selectedIdx)
+
+	MoveMenuSelectionWithoutWraparound(state, 2)
+	_, selectedIdx = state.Menu().SearchResults()
+	assert.Equal(t, 2, selectedIdx)
+
+	MoveMenuSelectionWithoutWraparound(state, 10)
+	_, selectedIdx = state.Menu().SearchResults()
+	assert.Equal(t, 3, selectedIdx)
+
+	MoveMenuSelectionToFirst(state)
+	_, selectedIdx = state.Menu().SearchResults()
+	assert.Equal(t, 0, selectedIdx)
+
+	MoveMenuSelectionToLast(state)
+	_, selectedIdx = state.Menu().SearchResults()
+	assert.Equal(t, 3, selectedIdx)
+}
+
 func TestAppendRuneToMenuSearch(t *testing.T) {
 	state := NewEditorState(100, 100, nil, nil)
 	ShowMenu(state, MenuStyleCommand, nil)
@@ -238,6 +272,46 @@ func TestShowFileMenu(t *testing.T) {
 	})
 }
 
+func TestShowFileMenuRanksFrecentFiles(t *testing.T) {
+	paths := []string{
+		"a/foo.txt",
+		"a/b/bar.txt",
+		"c/baz.txt",
+	}
+	withTempDirPaths(t, paths, func(dir string) {
+		state := NewEditorState(100, 100, nil, nil)
+		SetFrecencyStore(state, file.NewFrecencyStore(t.TempDir()))
+
+		showFileMenuAndSearch := func(query string) []string {
+			ShowFileMenu(state, nil)
+			completeTaskOrTimeout(t, state)
+			for _, r := range query {
+				AppendRuneToMenuSearch(state, r)
+			}
+			items, _ := state.Menu().SearchResults()
+			names := make([]string, 0, len(items))
+			for _, item := range items {
+				names = append(names, item.Name)
+			}
+			HideMenu(state)
+			return names
+		}
+
+		// Without any opened files, results are ranked by match score.
+		assert.Equal(t, []string{"a/b/bar.txt", "a/foo.txt", "c/baz.txt"}, showFileMenuAndSearch(""))
+		assert.Equal(t, []string{"a/foo.txt", "c/baz.txt", "a/b/bar.txt"}, showFileMenuAndSearch("txt"))
+
+		// Open a file, which should then rank higher.
+		LoadDocument(state, filepath.Join(dir, "c/baz.txt"), true, startOfDocLocator)
+		defer state.fileWatcher.Stop()
+		assert.Equal(t, []string{"c/baz.txt", "a/b/bar.txt", "a/foo.txt"}, showFileMenuAndSearch(""))
+		assert.Equal(t, []string{"c/baz.txt", "a/foo.txt", "a/b/bar.txt"}, showFileMenuAndSearch("txt"))
+
+		// A much better match still ranks above the opened file.
+		assert.Equal(t, "a/b/bar.txt", showFileMenuAndSearch("bar")[0])
+	})
+}
+
 func TestShowFileLocationsMenu(t *testing.T) {
 	// These are NOT in lexicographic order.
 	items := []menu.Item{
@@ -352,3 +426,72 @@ func completeTaskOrTimeout(t *testing.T, state *EditorState) {
 		require.Fail(t, "Timed out")
 	}
 }
+
+func TestShowCommandMenuWithPinnedAndHiddenCommands(t *testing.T) {
+	configRuleSet := config.RuleSet{
+		{
+			Name:    "test",
+			Pattern: "**",
+			Config: map[string]any{
+				"menuPinnedCommands": []any{"save", "quit", "missing"},
+				"menuHiddenCommands": []any{"toggle ruler", "save"},
+				"menuCommands": []any{
+					map[string]any{"name": "quit", "shellCmd": "true", "mode": "silent"},
+				},
+			},
+		},
+	}
+	state := NewEditorState(100, 100, configRuleSet, nil)
+	defer state.fileWatcher.Stop()
+	path, cleanup := createTestFile(t, "")
+	defer cleanup()
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	var toggled bool
+	items := []menu.Item{
+		{Name: "save"},
+		{Name: "toggle ruler", Aliases: []string{"ru"}, Action: func(*EditorState) { toggled = true }},
+		{Name: "toggle scrollbar"},
+	}
+	ShowMenu(state, MenuStyleCommand, items)
+
+	// With an empty query, the menu shows pinned commands that aren't hidden, including custom commands.
+	results, _ := state.Menu().SearchResults()
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, "quit", results[0].Name)
+
+	// Hidden commands don't appear in search results.
+	AppendRuneToMenuSearch(state, 't')
+	AppendRuneToMenuSearch(state, 'o')
+	results, _ = state.Menu().SearchResults()
+	var names []string
+	for _, item := range results {
+		names = append(names, item.Name)
+	}
+	assert.Contains(t, names, "toggle scrollbar")
+	assert.NotContains(t, names, "toggle ruler")
+
+	// But a hidden command can still be selected by its alias.
+	DeleteRuneFromMenuSearch(state)
+	DeleteRuneFromMenuSearch(state)
+	AppendRuneToMenuSearch(state, 'r')
+	AppendRuneToMenuSearch(state, 'u')
+	ExecuteSelectedMenuItem(state)
+	assert.True(t, toggled)
+}
+
+func TestArrangeCommandMenuItems(t *testing.T) {
+	items := []menu.Item{
+		{Name: "a"},
+		{Name: "b"},
+		{Name: "c"},
+		{Name: "d"},
+	}
+	arrangeCommandMenuItems(items, []string{"c", "a", "c"}, []string{"d"})
+	assert.Equal(t, []menu.Item{
+		{Name: "c", Pinned: true, Boost: pinnedMenuCommandBoost},
+		{Name: "a", Pinned: true, Boost: pinnedMenuCommandBoost},
+		{Name: "b"},
+		{Name: "d", Hidden: true},
+	}, items)
+}
@@ -15,6 +15,12 @@ const (
 	InputModeVisual
 	InputModeTask
 	InputModeTextField
+	InputModeUndoPreview
+	InputModeChanges
+	InputModeReplace
+	InputModeReplaceConfirm
+	InputModeAutocomplete
+	InputModeFileExplorer
 )
 
 func (im InputMode) String() string {
@@ -33,6 +39,18 @@ func (im InputMode) String() string {
 		return "task"
 	case InputModeTextField:
 		return "textfield"
+	case InputModeUndoPreview:
+		return "undo preview"
+	case InputModeChanges:
+		return "changes"
+	case InputModeReplace:
+		return "replace"
+	case InputModeReplaceConfirm:
+		return "replace confirm"
+	case InputModeAutocomplete:
+		return "autocomplete"
+	case InputModeFileExplorer:
+		return "file explorer"
 	default:
 		panic("invalid input mode")
 	}
@@ -49,11 +67,32 @@ func EnterInsertMode(state *EditorState) {
 }
 
 func setInputMode(state *EditorState, mode InputMode) {
-	if state.inputMode == InputModeVisual && (mode == InputModeNormal || mode == InputModeInsert) {
+	if state.inputMode == InputModeVisual && (mode == InputModeNormal || mode == InputModeInsert || mode == InputModeReplace) {
 		// Clear selection when exiting visual mode.
 		state.documentBuffer.selector.Clear()
 	}
 
+	// Autocomplete is part of insert mode, so switching between them keeps the insert mode state.
+	isInsertMode := func(m InputMode) bool { return m == InputModeInsert || m == InputModeAutocomplete }
+	if isInsertMode(state.inputMode) && !isInsertMode(mode) {
+		// Clear any text selected in insert mode.
+		ClearInsertModeSelection(state)
+		state.documentBuffer.insertStart = insertStartState{}
+	}
+
+	if state.inputMode == InputModeAutocomplete && mode != InputModeAutocomplete {
+		state.autocomplete = nil
+	}
+
+	// Text fields for creating, renaming, and deleting files return to the file explorer, so keep it open.
+	if state.inputMode == InputModeFileExplorer && mode != InputModeFileExplorer && mode != InputModeTextField {
+		state.fileExplorer = nil
+	}
+
+	if state.inputMode == InputModeReplace && mode != InputModeReplace {
+		state.documentBuffer.replaceMode = replaceModeState{}
+	}
+
 	state.inputMode = mode
 }
 
@@ -0,0 +1,142 @@
+package state
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/selection"
+)
+
+// ShowOperatorMenu displays a menu of user-defined operators to apply to a range of the document,
+// such as the range covered by a motion or text object.
+//
+// Operators are the custom menu commands from the config, plus the recorded macro (if any).
+// Selecting a custom menu command selects the range in visual mode, then executes the command,
+// so the command receives the range the same way it would receive a visual mode selection.
+// Selecting the macro replays it in normal mode once for each line in the range,
+// starting from the beginning of the range on the first line.
+func ShowOperatorMenu(state *EditorState, loc RangeLocator) {
+	startPos, endPos := loc(locatorParamsForBuffer(state.documentBuffer))
+	if startPos >= endPos {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Nothing to apply operator to",
+		})
+		return
+	}
+
+	showOperatorMenu(state, startPos, endPos, func(state *EditorState) {
+		selectRangeCharwise(state, startPos, endPos)
+	})
+}
+
+// ShowOperatorMenuForSelection displays a menu of user-defined operators to apply to the current visual mode selection.
+func ShowOperatorMenuForSelection(state *EditorState) {
+	r := state.documentBuffer.SelectedRegion()
+	showOperatorMenu(state, r.StartPos, r.EndPos, func(*EditorState) {
+		// The selection is restored when the menu closes, so there is nothing to do.
+	})
+}
+
+func showOperatorMenu(state *EditorState, startPos, endPos uint64, selectRange func(*EditorState)) {
+	var items []menu.Item
+	for _, item := range state.customMenuItems {
+		action, ok := item.Action.(func(*EditorState))
+		if !ok {
+			continue
+		}
+		items = append(items, menu.Item{
+			Name: item.Name,
+			Action: func(state *EditorState) {
+				selectRange(state)
+				action(state)
+			},
+		})
+	}
+
+	if len(state.macroState.userMacroActions) > 0 {
+		items = append(items, menu.Item{
+			Name: "replay macro on each line",
+			Action: func(state *EditorState) {
+				replayUserMacroOnLines(state, startPos, endPos)
+			},
+		})
+	}
+
+	if len(items) == 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No operators available. Add menu commands to the config or record a macro",
+		})
+		return
+	}
+
+	ShowMenu(state, MenuStyleOperator, items)
+}
+
+// selectRangeCharwise enters visual mode with the range [startPos, endPos) selected.
+func selectRangeCharwise(state *EditorState, startPos, endPos uint64) {
+	buffer := state.documentBuffer
+	setInputMode(state, InputModeNormal) // Clear any previous selection.
+	setInputMode(state, InputModeVisual)
+	buffer.selector.Start(selection.ModeChar, startPos)
+
+	// The selection includes the character under the cursor,
+	// so place the cursor on the last character in the range.
+	buffer.cursor = cursorState{position: locate.PrevChar(buffer.textTree, 1, endPos)}
+}
+
+// replayUserMacroOnLines replays the recorded user macro in normal mode for each line in the range.
+// On the first line, the macro starts at the beginning of the range; on other lines,
+// it starts at the beginning of the line. All the replays are grouped into a single undo entry.
+func replayUserMacroOnLines(state *EditorState, startPos, endPos uint64) {
+	m := &state.macroState
+	if m.isRecordingUserMacro {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Cannot replay a macro while recording a macro",
+		})
+		return
+	}
+
+	// User macros are recorded in normal mode, so they must be replayed in normal mode.
+	setInputMode(state, InputModeNormal)
+
+	buffer := state.documentBuffer
+	lineNum := buffer.textTree.LineNumForPosition(startPos)
+	endLineNum := buffer.textTree.LineNumForPosition(locate.PrevChar(buffer.textTree, 1, endPos))
+	numLines := endLineNum - lineNum + 1
+	pos := startPos
+
+	checkpoint := beginUndoCheckpoint(state, "macro replay")
+	BeginUndoEntry(state)
+	m.isReplayingUserMacro = true
+	log.Printf("Replaying user macro on %d line(s)\n", numLines)
+	for i := uint64(0); i < numLines; i++ {
+		if lineNum >= buffer.textTree.NumLines() {
+			break
+		}
+
+		MoveCursor(state, func(LocatorParams) uint64 { return pos })
+		numLinesBefore := buffer.textTree.NumLines()
+		for _, action := range m.userMacroActions {
+			action(state)
+		}
+		setInputMode(state, InputModeNormal)
+
+		// Skip any lines the macro inserted, so the next replay starts on the next line of the original range.
+		numLinesAfter := buffer.textTree.NumLines()
+		lineNum = lineNum + 1 + numLinesAfter - numLinesBefore
+		pos = buffer.textTree.LineStartPosition(lineNum)
+	}
+	m.isReplayingUserMacro = false
+	CommitUndoEntry(state)
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Replayed macro on %d line(s)", numLines),
+	})
+	commitUndoCheckpoint(state, checkpoint)
+}
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/text"
+)
+
+func TestShowOperatorMenuCustomCommand(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc def ghi")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+
+	var selectedText string
+	state.customMenuItems = []menu.Item{
+		{
+			Name: "test operator",
+			Action: func(state *EditorState) {
+				r := state.documentBuffer.SelectedRegion()
+				selectedText = state.documentBuffer.textTree.String()[r.StartPos:r.EndPos]
+			},
+		},
+	}
+
+	ShowOperatorMenu(state, func(LocatorParams) (uint64, uint64) { return 4, 7 })
+	assert.Equal(t, InputModeMenu, state.InputMode())
+	assert.Equal(t, MenuStyleOperator, state.Menu().Style())
+
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, "def", selectedText)
+	assert.Equal(t, InputModeVisual, state.InputMode())
+	assert.Equal(t, selection.ModeChar, state.documentBuffer.selector.Mode())
+}
+
+func TestShowOperatorMenuEmptyRange(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	state.customMenuItems = []menu.Item{
+		{Name: "test operator", Action: func(*EditorState) {}},
+	}
+	ShowOperatorMenu(state, func(LocatorParams) (uint64, uint64) { return 0, 0 })
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "Nothing to apply operator to", state.StatusMsg().Text)
+}
+
+func TestShowOperatorMenuNoOperators(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	ShowOperatorMenu(state, func(LocatorParams) (uint64, uint64) { return 0, 3 })
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+}
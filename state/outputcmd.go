@@ -0,0 +1,227 @@
+package state
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/nondet"
+	"github.com/aretext/aretext/shellcmd"
+	"github.com/aretext/aretext/syntax/parser"
+)
+
+// outputCmdState represents a shell command running in the background.
+// Unlike a task, the command does not block the editor: its output streams into a read-only
+// scratch buffer while the user continues editing, and switching documents does not cancel it.
+type outputCmdState struct {
+	shellCmd   string
+	buffer     *BufferState
+	writer     *outputCmdWriter
+	cancelFunc context.CancelFunc
+	startTime  time.Time
+
+	// resultChan receives actions to append output to the buffer and to report when the command exits.
+	resultChan chan func(*EditorState)
+}
+
+// OutputCmdResultChan returns a channel that receives actions from the shell command running in the background.
+// It returns nil if no command is running.
+func (s *EditorState) OutputCmdResultChan() chan func(*EditorState) {
+	if s.outputCmd == nil {
+		return nil
+	}
+	return s.outputCmd.resultChan
+}
+
+// runShellCmdInOutputBuffer starts a shell command in the background and opens a read-only scratch buffer for its output.
+// Only one command runs in the background at a time, so this cancels any command that is already running.
+func runShellCmdInOutputBuffer(state *EditorState, shellCmd string, env []string) {
+	stopOutputCmd(state)
+	LoadScratchText(state, shellCmd, "")
+	buffer := state.documentBuffer
+	buffer.readOnly = true
+	buffer.appendOnly = true
+
+	resultChan := make(chan func(*EditorState), 1)
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	oc := &outputCmdState{
+		shellCmd:   shellCmd,
+		buffer:     buffer,
+		cancelFunc: cancelFunc,
+		startTime:  nondet.Now(),
+		resultChan: resultChan,
+	}
+	oc.writer = &outputCmdWriter{
+		resultChan: resultChan,
+		flushAction: func(state *EditorState) {
+			if state.outputCmd == oc {
+				appendOutputCmdText(state, buffer, oc.writer.take(false))
+			}
+		},
+	}
+	state.outputCmd = oc
+
+	log.Printf("Starting shell command in the background: %q\n", shellCmd)
+	go func(ctx context.Context) {
+		err := shellcmd.RunWithOutputWriter(ctx, shellCmd, env, oc.writer)
+		action := func(state *EditorState) {
+			if state.outputCmd == oc {
+				finishOutputCmd(state, err)
+			}
+		}
+		select {
+		case resultChan <- action:
+		case <-ctx.Done():
+			// The command was cancelled, so nothing is receiving from the channel.
+		}
+	}(ctx)
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  "Running shell command in the background. Press escape in its output buffer to cancel",
+	})
+}
+
+// finishOutputCmd appends the remaining output once the background shell command exits.
+func finishOutputCmd(state *EditorState, err error) {
+	oc := state.outputCmd
+	appendOutputCmdText(state, oc.buffer, oc.writer.take(true))
+	oc.cancelFunc()
+	state.outputCmd = nil
+	log.Printf("Background shell command %q exited\n", oc.shellCmd)
+
+	setStatusForShellCmdResult(state, err)
+	if nondet.Now().Sub(oc.startTime) >= longTaskThreshold {
+		runEventHook(state, HookEventTaskComplete, state.statusMsg.Text)
+	}
+}
+
+// CancelOutputCmd cancels the shell command running in the background, keeping the output it produced so far.
+func CancelOutputCmd(state *EditorState) {
+	if state.outputCmd == nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No shell command is running in the background",
+		})
+		return
+	}
+
+	stopOutputCmd(state)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  "Cancelled shell command",
+	})
+}
+
+// CancelOutputCmdInActiveBuffer cancels the shell command running in the background
+// if its output buffer is the active document; otherwise, it does nothing.
+func CancelOutputCmdInActiveBuffer(state *EditorState) {
+	if state.outputCmd != nil && state.outputCmd.buffer == state.documentBuffer {
+		CancelOutputCmd(state)
+	}
+}
+
+// cancelOutputCmdForBuffer cancels the shell command running in the background if it writes to the buffer.
+// This is called before a buffer is closed or replaced by another document.
+func cancelOutputCmdForBuffer(state *EditorState, buffer *BufferState) {
+	if state.outputCmd != nil && state.outputCmd.buffer == buffer {
+		stopOutputCmd(state)
+	}
+}
+
+// stopOutputCmd cancels the shell command running in the background, if any.
+func stopOutputCmd(state *EditorState) {
+	oc := state.outputCmd
+	if oc == nil {
+		return
+	}
+
+	log.Printf("Cancelling background shell command %q\n", oc.shellCmd)
+	oc.cancelFunc()
+	appendOutputCmdText(state, oc.buffer, oc.writer.take(true))
+	state.outputCmd = nil
+}
+
+// appendOutputCmdText appends output to the end of a buffer, which may be open in the background.
+// Like follow mode, if the cursor is on the last line, it moves to the new last line.
+func appendOutputCmdText(state *EditorState, buffer *BufferState, s string) {
+	if s == "" {
+		return
+	}
+
+	onLastLine := isCursorOnLastLine(buffer)
+	pos := buffer.textTree.NumChars()
+	if err := buffer.textTree.ReplaceRange(pos, 0, s); err != nil {
+		// Should never happen because the writer replaces invalid UTF-8.
+		log.Printf("Error appending shell command output: %v\n", err)
+		return
+	}
+	n := uint64(utf8.RuneCountInString(s))
+	retokenizeAfterEdit(buffer, parser.NewInsertEdit(pos, n))
+
+	if !onLastLine {
+		return
+	} else if buffer == state.documentBuffer {
+		moveCursorToLastLine(state)
+	} else {
+		buffer.cursor = cursorState{position: locate.StartOfLastLine(buffer.textTree)}
+	}
+}
+
+// outputCmdWriter receives output from a shell command running in a separate goroutine.
+// Each write notifies the main event loop, which appends all output received so far,
+// so a command that writes faster than the editor can redraw does not queue a backlog of actions.
+type outputCmdWriter struct {
+	mu          sync.Mutex
+	buf         []byte
+	resultChan  chan func(*EditorState)
+	flushAction func(*EditorState)
+}
+
+func (w *outputCmdWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.buf = append(w.buf, p...)
+	w.mu.Unlock()
+
+	select {
+	case w.resultChan <- w.flushAction:
+	default:
+		// An action already in the channel will append this output too.
+	}
+	return len(p), nil
+}
+
+// take returns the output received so far as valid UTF-8 text.
+// Until the command exits, this holds back an incomplete UTF-8 sequence at the end,
+// since the rest of it may arrive in the next write, and a trailing newline,
+// so the buffer doesn't end with an empty line (like a scratch buffer loaded all at once).
+func (w *outputCmdWriter) take(final bool) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(w.buf)
+	if !final {
+		for i := n - 1; i >= 0 && i >= n-utf8.UTFMax; i-- {
+			if utf8.RuneStart(w.buf[i]) {
+				if !utf8.FullRune(w.buf[i:n]) {
+					n = i
+				}
+				break
+			}
+		}
+		if n > 0 && w.buf[n-1] == '\n' {
+			n--
+		}
+	}
+
+	s := strings.ToValidUTF8(string(w.buf[:n]), string(utf8.RuneError))
+	w.buf = append([]byte(nil), w.buf[n:]...)
+	if final {
+		s = strings.TrimSuffix(s, "\n")
+	}
+	return s
+}
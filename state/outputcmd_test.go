@@ -0,0 +1,140 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+)
+
+func TestRunShellCmdOutput(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		RunShellCmd(state, `printf "a\nb\n"; printf "c\n" >&2`, config.CmdModeOutput)
+		assert.Equal(t, InputModeNormal, state.InputMode())
+		assert.True(t, state.documentBuffer.IsScratch())
+		assert.True(t, state.documentBuffer.ReadOnly())
+		assert.Equal(t, `[scratch] printf "a\nb\n"; printf "c\n" >&2`, state.fileWatcher.Path())
+
+		applyOutputCmdActionsUntilExit(t, state)
+		assert.Equal(t, "a\nb\nc", state.documentBuffer.textTree.String())
+		assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+		assert.Equal(t, "Shell command completed successfully", state.StatusMsg().Text)
+
+		// The output is never compared to a snapshot, since it is only appended.
+		assert.True(t, state.documentBuffer.appendOnly)
+		assert.Equal(t, "", state.documentBuffer.originalText.text)
+		assert.Equal(t, "", state.documentBuffer.savedText.text)
+		assert.Equal(t, LineChangeNone, state.documentBuffer.LineChange(0))
+	})
+}
+
+func TestRunShellCmdOutputFailed(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		RunShellCmd(state, `echo "oops"; exit 1`, config.CmdModeOutput)
+		applyOutputCmdActionsUntilExit(t, state)
+		assert.Equal(t, "oops", state.documentBuffer.textTree.String())
+		assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+		assert.Contains(t, state.StatusMsg().Text, "Shell command failed")
+	})
+}
+
+func TestRunShellCmdOutputInBackgroundBuffer(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		path, cleanup := createTestFile(t, "doc")
+		defer cleanup()
+		LoadDocument(state, path, true, startOfDocLocator)
+		RunShellCmd(state, `printf "a\n"; sleep 0.2; printf "b\n"`, config.CmdModeOutput)
+		LoadPrevDocument(state)
+		assert.Equal(t, "doc", state.documentBuffer.textTree.String())
+
+		// Switching documents does not cancel the command, and output is appended to the buffer in the background.
+		applyOutputCmdActionsUntilExit(t, state)
+		assert.Equal(t, "doc", state.documentBuffer.textTree.String())
+		NextBuffer(state)
+		assert.Equal(t, "a\nb", state.documentBuffer.textTree.String())
+		assert.Equal(t, uint64(2), state.documentBuffer.cursor.position)
+	})
+}
+
+func TestCancelOutputCmd(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		RunShellCmd(state, `printf "a\n"; sleep 10`, config.CmdModeOutput)
+		deadline := time.After(5 * time.Second)
+		for state.documentBuffer.textTree.String() != "a" {
+			select {
+			case action := <-state.OutputCmdResultChan():
+				action(state)
+			case <-deadline:
+				require.Fail(t, "Timed out")
+			}
+		}
+
+		CancelOutputCmdInActiveBuffer(state)
+		assert.Nil(t, state.OutputCmdResultChan())
+		assert.Equal(t, "a", state.documentBuffer.textTree.String())
+		assert.Equal(t, "Cancelled shell command", state.StatusMsg().Text)
+
+		CancelOutputCmd(state)
+		assert.Equal(t, "No shell command is running in the background", state.StatusMsg().Text)
+	})
+}
+
+func TestCancelOutputCmdWhenBufferClosed(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		path, cleanup := createTestFile(t, "doc")
+		defer cleanup()
+		LoadDocument(state, path, true, startOfDocLocator)
+		RunShellCmd(state, `sleep 10`, config.CmdModeOutput)
+		require.NotNil(t, state.OutputCmdResultChan())
+		CloseBuffer(state)
+		assert.Nil(t, state.OutputCmdResultChan())
+		assert.Equal(t, "doc", state.documentBuffer.textTree.String())
+	})
+}
+
+func TestCancelOutputCmdInActiveBufferIgnoresOtherDocuments(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		path, cleanup := createTestFile(t, "doc")
+		defer cleanup()
+		LoadDocument(state, path, true, startOfDocLocator)
+		RunShellCmd(state, `sleep 10`, config.CmdModeOutput)
+		LoadPrevDocument(state)
+		CancelOutputCmdInActiveBuffer(state)
+		assert.NotNil(t, state.OutputCmdResultChan())
+		CancelOutputCmd(state)
+		assert.Nil(t, state.OutputCmdResultChan())
+	})
+}
+
+func TestOutputCmdWriterTake(t *testing.T) {
+	w := &outputCmdWriter{resultChan: make(chan func(*EditorState), 1)}
+
+	// Hold back the incomplete UTF-8 sequence and the trailing newline.
+	_, err := w.Write([]byte("abc\n\xe2\x82"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", w.take(false))
+
+	_, err = w.Write([]byte("\xac\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "\n€", w.take(false))
+
+	// Invalid UTF-8 is replaced once the command exits, and the final newline is dropped.
+	_, err = w.Write([]byte("\xff\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "\n�", w.take(true))
+}
+
+func applyOutputCmdActionsUntilExit(t *testing.T, state *EditorState) {
+	deadline := time.After(5 * time.Second)
+	for state.OutputCmdResultChan() != nil {
+		select {
+		case action := <-state.OutputCmdResultChan():
+			action(state)
+		case <-deadline:
+			require.Fail(t, "Timed out")
+		}
+	}
+}
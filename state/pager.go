@@ -0,0 +1,38 @@
+package state
+
+import (
+	"log"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/text"
+)
+
+// pagerHintMsg tells the user how to exit pager mode.
+const pagerHintMsg = `Viewing in pager mode. Press "q" to quit`
+
+// EnablePagerMode makes the editor act like a pager such as "less".
+// Every document is read-only, and "q" in normal mode quits.
+func EnablePagerMode(state *EditorState) {
+	state.pagerMode = true
+	state.documentBuffer.readOnly = true
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  pagerHintMsg,
+	})
+}
+
+// LoadPagerText displays text that did not come from a file, such as input read from stdin.
+// The name is displayed in place of the document path and is used to select the configuration.
+// The document is read-only and cannot be reloaded from disk.
+func LoadPagerText(state *EditorState, name string, s string, cursorLoc Locator) {
+	tree, err := text.NewTreeFromString(s)
+	if err != nil {
+		// Should never happen because the caller validates the text.
+		log.Printf("Error loading pager text: %v\n", err)
+		return
+	}
+
+	resetStateForDocument(state, name, tree, file.NewInactiveWatcher(name))
+	state.documentBuffer.readOnly = true
+	setCursorAfterLoad(state, cursorLoc)
+}
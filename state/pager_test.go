@@ -0,0 +1,38 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/locate"
+)
+
+func TestLoadPagerText(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	LoadPagerText(state, "stdin", "foo\nbar\nbaz", func(p LocatorParams) uint64 {
+		return locate.StartOfLineNum(p.TextTree, 1)
+	})
+	EnablePagerMode(state)
+
+	assert.True(t, state.PagerMode())
+	assert.True(t, state.documentBuffer.ReadOnly())
+	assert.Equal(t, "foo\nbar\nbaz", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+
+	InsertText(state, "x")
+	assert.Equal(t, "foo\nbar\nbaz", state.documentBuffer.textTree.String())
+}
+
+func TestPagerModeLoadDocumentReadOnly(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	EnablePagerMode(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+	require.Equal(t, "foo", state.documentBuffer.textTree.String())
+	assert.True(t, state.documentBuffer.ReadOnly())
+}
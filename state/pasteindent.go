@@ -0,0 +1,105 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/text"
+)
+
+// InsertPastedText inserts text from a bracketed paste at the cursor position.
+// If matchPasteIndent is enabled, the pasted lines are re-indented to match the current line.
+func InsertPastedText(state *EditorState, text string) {
+	buffer := state.documentBuffer
+	if buffer.matchPasteIndent {
+		text = matchIndentOfPastedText(text, currentLineIndent(buffer))
+	}
+	InsertText(state, text)
+}
+
+// currentLineIndent returns the whitespace at the start of the cursor's line, up to the cursor.
+func currentLineIndent(buffer *BufferState) string {
+	return lineIndentBeforePos(buffer.textTree, buffer.cursor.position)
+}
+
+// lineIndentBeforePos returns the whitespace at the start of the line containing pos, up to pos.
+func lineIndentBeforePos(tree *text.Tree, pos uint64) string {
+	lineStartPos := locate.StartOfLineAtPos(tree, pos)
+	reader := tree.ReaderAtPosition(lineStartPos)
+
+	var sb strings.Builder
+	for p := lineStartPos; p < pos; p++ {
+		r, _, err := reader.ReadRune()
+		if err != nil || (r != ' ' && r != '\t') {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// matchIndentOfPastedText removes the indentation shared by the pasted lines,
+// then indents every line after the first with the given indent.
+// The first line is pasted at the cursor, which is already indented.
+// If the first line has no leading whitespace, it is ignored when finding the shared indentation,
+// since the user probably copied it starting from the first non-whitespace character.
+// Lines containing only whitespace become empty, except the last line, which
+// is indented so the rest of the cursor's line keeps its indentation.
+func matchIndentOfPastedText(text string, indent string) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) < 2 {
+		return text
+	}
+
+	var commonIndent string
+	var foundIndent bool
+	for i, line := range lines {
+		lineIndent := leadingIndent(line)
+		if len(lineIndent) == len(line) || (i == 0 && lineIndent == "") {
+			continue
+		}
+
+		if !foundIndent {
+			commonIndent = lineIndent
+			foundIndent = true
+		} else {
+			commonIndent = commonPrefix(commonIndent, lineIndent)
+		}
+	}
+
+	var sb strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteRune('\n')
+		}
+
+		if i == len(lines)-1 && len(leadingIndent(line)) == len(line) {
+			// The rest of the cursor's line follows the last pasted line, so keep it indented.
+			sb.WriteString(indent)
+			continue
+		}
+
+		if len(leadingIndent(line)) == len(line) {
+			// Whitespace-only line, so omit the whitespace.
+			continue
+		}
+
+		if i > 0 {
+			sb.WriteString(indent)
+		}
+		sb.WriteString(strings.TrimPrefix(line, commonIndent))
+	}
+	return sb.String()
+}
+
+func leadingIndent(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
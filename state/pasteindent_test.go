@@ -0,0 +1,108 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestMatchIndentOfPastedText(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		indent   string
+		expected string
+	}{
+		{
+			name:     "single line",
+			text:     "    foo()",
+			indent:   "\t",
+			expected: "    foo()",
+		},
+		{
+			name:     "no indent",
+			text:     "foo()\nbar()",
+			indent:   "",
+			expected: "foo()\nbar()",
+		},
+		{
+			name:     "strip common indent",
+			text:     "        if x {\n            y()\n        }",
+			indent:   "",
+			expected: "if x {\n    y()\n}",
+		},
+		{
+			name:     "apply current line indent",
+			text:     "if x {\n    y()\n}",
+			indent:   "\t",
+			expected: "if x {\n\t    y()\n\t}",
+		},
+		{
+			name:     "first line copied without indent",
+			text:     "if x {\n            y()\n        }",
+			indent:   "    ",
+			expected: "if x {\n        y()\n    }",
+		},
+		{
+			name:     "blank lines",
+			text:     "    a()\n\n      \n    b()",
+			indent:   "  ",
+			expected: "a()\n\n\n  b()",
+		},
+		{
+			name:     "trailing newline keeps rest of line indented",
+			text:     "    a()\n    b()\n",
+			indent:   "  ",
+			expected: "a()\n  b()\n  ",
+		},
+		{
+			name:     "mixed tabs and spaces",
+			text:     "\ta()\n    b()",
+			indent:   "",
+			expected: "\ta()\n    b()",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, matchIndentOfPastedText(tc.text, tc.indent))
+		})
+	}
+}
+
+func TestInsertPastedText(t *testing.T) {
+	testCases := []struct {
+		name             string
+		matchPasteIndent bool
+		expectedText     string
+	}{
+		{
+			name:             "disabled",
+			matchPasteIndent: false,
+			expectedText:     "func f() {\n\t        x()\n        y()\n}",
+		},
+		{
+			name:             "enabled",
+			matchPasteIndent: true,
+			expectedText:     "func f() {\n\tx()\n\ty()\n}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString("func f() {\n\t\n}")
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			buffer := state.documentBuffer
+			buffer.textTree = textTree
+			buffer.cursor.position = 12
+			buffer.matchPasteIndent = tc.matchPasteIndent
+			EnterInsertMode(state)
+			InsertPastedText(state, "        x()\n        y()")
+			assert.Equal(t, tc.expectedText, textTree.String())
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/pluginapi"
+)
+
+// PluginRegistry stores the menu commands, key bindings, and pre-save filters registered by plugins.
+// It implements pluginapi.Registry.
+// Programs that embed the editor can also register pre-save filters directly, then pass the registry to SetPluginRegistry.
+type PluginRegistry struct {
+	menuItems      map[string]menu.Item
+	keyBindings    map[string]func(*EditorState)
+	preSaveFilters []namedPreSaveFilter // In the order they were registered.
+}
+
+type namedPreSaveFilter struct {
+	name   string
+	filter pluginapi.PreSaveFilter
+}
+
+var _ pluginapi.Registry = &PluginRegistry{}
+
+// NewPluginRegistry returns an empty plugin registry.
+func NewPluginRegistry() *PluginRegistry {
+	return &PluginRegistry{
+		menuItems:   make(map[string]menu.Item),
+		keyBindings: make(map[string]func(*EditorState)),
+	}
+}
+
+// RegisterMenuCommand implements pluginapi.Registry#RegisterMenuCommand.
+func (r *PluginRegistry) RegisterMenuCommand(name string, action pluginapi.Action) {
+	r.menuItems[name] = menu.Item{
+		Name:   name,
+		Action: actionForPlugin(name, action),
+	}
+}
+
+// RegisterKeyBinding implements pluginapi.Registry#RegisterKeyBinding.
+func (r *PluginRegistry) RegisterKeyBinding(key string, action pluginapi.Action) {
+	r.keyBindings[key] = actionForPlugin(key, action)
+}
+
+// RegisterPreSaveFilter implements pluginapi.Registry#RegisterPreSaveFilter.
+func (r *PluginRegistry) RegisterPreSaveFilter(name string, filter pluginapi.PreSaveFilter) {
+	for i, f := range r.preSaveFilters {
+		if f.name == name {
+			r.preSaveFilters[i].filter = filter
+			return
+		}
+	}
+	r.preSaveFilters = append(r.preSaveFilters, namedPreSaveFilter{name: name, filter: filter})
+}
+
+// NumMenuCommands returns the number of menu commands registered by plugins.
+func (r *PluginRegistry) NumMenuCommands() int {
+	return len(r.menuItems)
+}
+
+// NumKeyBindings returns the number of key bindings registered by plugins.
+func (r *PluginRegistry) NumKeyBindings() int {
+	return len(r.keyBindings)
+}
+
+// NumPreSaveFilters returns the number of pre-save filters registered by plugins.
+func (r *PluginRegistry) NumPreSaveFilters() int {
+	return len(r.preSaveFilters)
+}
+
+// Merge copies the registrations from another registry into this one.
+// Registrations from the other registry take priority, and its new pre-save filters run after the existing ones.
+func (r *PluginRegistry) Merge(other *PluginRegistry) {
+	for name, item := range other.menuItems {
+		r.menuItems[name] = item
+	}
+	for key, action := range other.keyBindings {
+		r.keyBindings[key] = action
+	}
+	for _, f := range other.preSaveFilters {
+		r.RegisterPreSaveFilter(f.name, f.filter)
+	}
+}
+
+func (r *PluginRegistry) sortedMenuItems() []menu.Item {
+	items := make([]menu.Item, 0, len(r.menuItems))
+	for _, item := range r.menuItems {
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// SetPluginRegistry sets the menu commands and key bindings provided by plugins.
+func SetPluginRegistry(state *EditorState, registry *PluginRegistry) {
+	state.pluginRegistry = registry
+}
+
+// PluginKeyBindingAction returns the plugin action bound to a key, if any.
+// Plugin key bindings apply only in normal mode.
+func PluginKeyBindingAction(state *EditorState, key string) (func(*EditorState), bool) {
+	if state.pluginRegistry == nil || state.inputMode != InputModeNormal {
+		return nil, false
+	}
+	action, ok := state.pluginRegistry.keyBindings[key]
+	return action, ok
+}
+
+func actionForPlugin(name string, action pluginapi.Action) func(*EditorState) {
+	return func(state *EditorState) {
+		log.Printf("Executing plugin action %q\n", name)
+		editor := &pluginEditor{state: state}
+		BeginUndoEntry(state)
+		defer func() {
+			editor.state = nil // Prevent the plugin from retaining access to the editor state.
+			CommitUndoEntry(state)
+			ScrollViewToCursor(state)
+
+			// A bug in a plugin should not crash the editor.
+			if r := recover(); r != nil {
+				log.Printf("Plugin action %q panicked: %v\n", name, r)
+				SetStatusMsg(state, StatusMsg{
+					Style: StatusMsgStyleError,
+					Text:  fmt.Sprintf("Plugin action %q failed: %v", name, r),
+				})
+			}
+		}()
+		action(editor)
+	}
+}
+
+// preSaveFilter returns a filter that runs every registered pre-save filter on a file, or nil if there are none.
+// If the document is encrypted, encryption runs last, so plugin filters receive the decrypted text.
+func preSaveFilter(state *EditorState, path string) file.SaveFilter {
+	var filters []namedPreSaveFilter
+	if state.pluginRegistry != nil {
+		filters = append(filters, state.pluginRegistry.preSaveFilters...)
+	}
+	if f, ok := encryptionPreSaveFilter(state.documentBuffer); ok {
+		filters = append(filters, f)
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+
+	return func(data []byte) ([]byte, error) {
+		for _, f := range filters {
+			var err error
+			data, err = runPreSaveFilter(f, path, data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	}
+}
+
+func runPreSaveFilter(f namedPreSaveFilter, path string, data []byte) (result []byte, err error) {
+	log.Printf("Running pre-save filter %q for %q\n", f.name, path)
+
+	// A bug in a plugin should not crash the editor, so a panic vetoes the save like an error.
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Pre-save filter %q panicked: %v\n", f.name, r)
+			result, err = nil, fmt.Errorf("Pre-save filter %q failed: %v", f.name, r)
+		}
+	}()
+
+	result, err = f.filter(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("Pre-save filter %q rejected the save: %w", f.name, err)
+	}
+	return result, nil
+}
+
+var errPluginActionDone = errors.New("Plugin action has already completed")
+
+// pluginEditor implements pluginapi.Editor.
+type pluginEditor struct {
+	state *EditorState
+}
+
+var _ pluginapi.Editor = &pluginEditor{}
+
+func (e *pluginEditor) mustState() *EditorState {
+	if e.state == nil {
+		panic(errPluginActionDone)
+	}
+	return e.state
+}
+
+func (e *pluginEditor) Path() string {
+	return e.mustState().fileWatcher.Path()
+}
+
+func (e *pluginEditor) Text() string {
+	return e.mustState().documentBuffer.textTree.String()
+}
+
+func (e *pluginEditor) NumChars() uint64 {
+	return e.mustState().documentBuffer.textTree.NumChars()
+}
+
+func (e *pluginEditor) CursorPosition() uint64 {
+	return e.mustState().documentBuffer.cursor.position
+}
+
+func (e *pluginEditor) SetCursorPosition(pos uint64) {
+	buffer := e.mustState().documentBuffer
+	if n := buffer.textTree.NumChars(); pos > n {
+		pos = n
+	}
+	buffer.cursor = cursorState{position: pos}
+}
+
+func (e *pluginEditor) InsertText(pos uint64, text string) error {
+	state := e.mustState()
+	if n := state.documentBuffer.textTree.NumChars(); pos > n {
+		return fmt.Errorf("Position %d is past the end of the document (%d characters)", pos, n)
+	}
+	return insertTextAtPosition(state, text, pos, true)
+}
+
+func (e *pluginEditor) DeleteText(pos uint64, count uint64) (string, error) {
+	state := e.mustState()
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return "", err
+	}
+
+	n := buffer.textTree.NumChars()
+	if pos > n {
+		return "", fmt.Errorf("Position %d is past the end of the document (%d characters)", pos, n)
+	}
+	if count > n-pos {
+		count = n - pos
+	}
+
+	deleted := deleteRunes(state, pos, count, true)
+	if buffer.cursor.position > buffer.textTree.NumChars() {
+		buffer.cursor = cursorState{position: buffer.textTree.NumChars()}
+	}
+	return deleted, nil
+}
+
+func (e *pluginEditor) SetStatus(msg string) {
+	SetStatusMsg(e.mustState(), StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  msg,
+	})
+}
+
+func (e *pluginEditor) SetError(msg string) {
+	SetStatusMsg(e.mustState(), StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  msg,
+	})
+}
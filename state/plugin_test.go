@@ -0,0 +1,228 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/pluginapi"
+	"github.com/aretext/aretext/text"
+)
+
+func TestPluginMenuCommand(t *testing.T) {
+	textTree, err := text.NewTreeFromString("hello world")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+
+	registry := NewPluginRegistry()
+	registry.RegisterMenuCommand("upper first word", func(e pluginapi.Editor) {
+		deleted, err := e.DeleteText(0, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", deleted)
+		err = e.InsertText(0, "HELLO")
+		require.NoError(t, err)
+		e.SetCursorPosition(6)
+		e.SetStatus("done")
+	})
+	SetPluginRegistry(state, registry)
+
+	ShowMenu(state, MenuStyleCommand, nil)
+	for _, r := range "upper" {
+		AppendRuneToMenuSearch(state, r)
+	}
+	ExecuteSelectedMenuItem(state)
+
+	assert.Equal(t, "HELLO world", textTree.String())
+	assert.Equal(t, uint64(6), state.documentBuffer.cursor.position)
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleSuccess, Text: "done"}, state.StatusMsg())
+
+	// All edits from the plugin action are undone together.
+	Undo(state)
+	assert.Equal(t, "hello world", state.documentBuffer.textTree.String())
+}
+
+func TestPluginEditorBounds(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	state.documentBuffer.cursor.position = 2
+
+	registry := NewPluginRegistry()
+	registry.RegisterKeyBinding("F5", func(e pluginapi.Editor) {
+		assert.Equal(t, "abc", e.Text())
+		assert.Equal(t, uint64(3), e.NumChars())
+		assert.Equal(t, uint64(2), e.CursorPosition())
+
+		err := e.InsertText(4, "x")
+		assert.Error(t, err)
+
+		deleted, err := e.DeleteText(1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, "bc", deleted)
+		assert.Equal(t, uint64(1), e.CursorPosition())
+
+		e.SetCursorPosition(100)
+		assert.Equal(t, uint64(1), e.CursorPosition())
+	})
+	SetPluginRegistry(state, registry)
+
+	action, ok := PluginKeyBindingAction(state, "F5")
+	require.True(t, ok)
+	action(state)
+	assert.Equal(t, "a", textTree.String())
+}
+
+func TestPluginKeyBindingOnlyInNormalMode(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	registry := NewPluginRegistry()
+	registry.RegisterKeyBinding("Ctrl+T", func(e pluginapi.Editor) {})
+	SetPluginRegistry(state, registry)
+
+	_, ok := PluginKeyBindingAction(state, "Ctrl+T")
+	assert.True(t, ok)
+
+	_, ok = PluginKeyBindingAction(state, "F6")
+	assert.False(t, ok)
+
+	setInputMode(state, InputModeInsert)
+	_, ok = PluginKeyBindingAction(state, "Ctrl+T")
+	assert.False(t, ok)
+}
+
+func TestPluginActionPanic(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+
+	var savedEditor pluginapi.Editor
+	registry := NewPluginRegistry()
+	registry.RegisterKeyBinding("F5", func(e pluginapi.Editor) {
+		savedEditor = e
+		err := e.InsertText(0, "x")
+		require.NoError(t, err)
+		panic("oops")
+	})
+	SetPluginRegistry(state, registry)
+
+	action, ok := PluginKeyBindingAction(state, "F5")
+	require.True(t, ok)
+	action(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, `Plugin action "F5" failed: oops`, state.StatusMsg().Text)
+
+	// The edit before the panic can be undone.
+	assert.Equal(t, "xabc", textTree.String())
+	Undo(state)
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+
+	// The editor cannot be used after the action completes.
+	assert.Panics(t, func() { savedEditor.Text() })
+}
+
+func TestPluginPreSaveFilters(t *testing.T) {
+	path, cleanup := createTestFile(t, "")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer state.fileWatcher.Stop()
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertText(state, "token=secret")
+
+	var filterPath string
+	registry := NewPluginRegistry()
+	registry.RegisterPreSaveFilter("redact", func(path string, data []byte) ([]byte, error) {
+		filterPath = path
+		return bytes.ReplaceAll(data, []byte("secret"), []byte("xxx")), nil
+	})
+	registry.RegisterPreSaveFilter("upper", func(path string, data []byte) ([]byte, error) {
+		return bytes.ToUpper(data), nil
+	})
+	SetPluginRegistry(state, registry)
+
+	// Filters run in the order they were registered, and the document is unchanged.
+	SaveDocument(state)
+	assert.Equal(t, StatusMsgStyleSuccess, state.StatusMsg().Style)
+	assert.Equal(t, path, filterPath)
+	assert.Equal(t, "token=secret", state.documentBuffer.textTree.String())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN=XXX\n", string(data))
+}
+
+func TestPluginPreSaveFilterVeto(t *testing.T) {
+	testCases := []struct {
+		name         string
+		filter       pluginapi.PreSaveFilter
+		expectStatus string
+	}{
+		{
+			name: "error",
+			filter: func(path string, data []byte) ([]byte, error) {
+				return nil, errors.New("document contains a secret")
+			},
+			expectStatus: `Pre-save filter "check" rejected the save: document contains a secret`,
+		},
+		{
+			name: "panic",
+			filter: func(path string, data []byte) ([]byte, error) {
+				panic("oops")
+			},
+			expectStatus: `Pre-save filter "check" failed: oops`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := createTestFile(t, "old")
+			defer cleanup()
+
+			state := NewEditorState(100, 100, nil, nil)
+			defer state.fileWatcher.Stop()
+			LoadDocument(state, path, true, startOfDocLocator)
+			InsertText(state, "new ")
+
+			registry := NewPluginRegistry()
+			registry.RegisterPreSaveFilter("check", tc.filter)
+			SetPluginRegistry(state, registry)
+
+			SaveDocument(state)
+			assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+			assert.Contains(t, state.StatusMsg().Text, tc.expectStatus)
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "old", string(data))
+		})
+	}
+}
+
+func TestPluginRegistryMergePreSaveFilters(t *testing.T) {
+	appendFilter := func(s string) pluginapi.PreSaveFilter {
+		return func(path string, data []byte) ([]byte, error) {
+			return append(data, s...), nil
+		}
+	}
+
+	registry := NewPluginRegistry()
+	registry.RegisterPreSaveFilter("a", appendFilter("a"))
+	registry.RegisterPreSaveFilter("b", appendFilter("b"))
+
+	other := NewPluginRegistry()
+	other.RegisterPreSaveFilter("c", appendFilter("c"))
+	other.RegisterPreSaveFilter("a", appendFilter("A"))
+	registry.Merge(other)
+	assert.Equal(t, 3, registry.NumPreSaveFilters())
+
+	// A filter with the same name replaces the earlier one without changing the order.
+	state := NewEditorState(100, 100, nil, nil)
+	SetPluginRegistry(state, registry)
+	data, err := preSaveFilter(state, "test.txt")([]byte(">"))
+	require.NoError(t, err)
+	assert.Equal(t, ">Abc", string(data))
+}
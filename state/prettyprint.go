@@ -0,0 +1,69 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/prettyprint"
+)
+
+// FormatSelectionAsJSON reformats the selected JSON value.
+// Nested values are indented using the document's tab settings, relative to the indentation of the first selected line.
+// Syntax errors are reported with line and column numbers relative to the start of the selection.
+func FormatSelectionAsJSON(state *EditorState) error {
+	return formatSelection(state, "JSON", prettyprint.JSON)
+}
+
+// FormatSelectionAsXML reformats the selected XML, the same way as FormatSelectionAsJSON.
+func FormatSelectionAsXML(state *EditorState) error {
+	return formatSelection(state, "XML", prettyprint.XML)
+}
+
+func formatSelection(state *EditorState, formatName string, format func(src, prefix, indent string) (string, error)) error {
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return err
+	}
+
+	selectedText, r := copySelectionText(buffer)
+	trimmedText := strings.TrimSpace(selectedText)
+	if trimmedText == "" {
+		return fmt.Errorf("No %s selected", formatName)
+	}
+
+	// Replace only the formatted text, not the whitespace around it.
+	leading := selectedText[:len(selectedText)-len(strings.TrimLeftFunc(selectedText, unicode.IsSpace))]
+	startPos := r.StartPos + uint64(utf8.RuneCountInString(leading))
+	numRunes := uint64(utf8.RuneCountInString(trimmedText))
+
+	prefix := lineIndentBeforePos(buffer.textTree, startPos)
+	formattedText, err := format(selectedText, prefix, tabText(state, 1))
+	if err != nil {
+		var syntaxErr *prettyprint.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return fmt.Errorf("Invalid %s at line %d, column %d of selection: %s", formatName, syntaxErr.Line, syntaxErr.Column, syntaxErr.Msg)
+		}
+		return err
+	}
+
+	setInputMode(state, InputModeNormal)
+	checkpoint := beginUndoCheckpoint(state, fmt.Sprintf("%s formatting", formatName))
+	BeginUndoEntry(state)
+	_, err = replaceRunes(state, startPos, numRunes, formattedText, true)
+	CommitUndoEntry(state)
+	if err != nil {
+		return err
+	}
+
+	MoveCursor(state, func(LocatorParams) uint64 { return startPos })
+	ScrollViewToCursor(state)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Formatted selection as %s", formatName),
+	})
+	commitUndoCheckpoint(state, checkpoint)
+	return nil
+}
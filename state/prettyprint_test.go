@@ -0,0 +1,65 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/text"
+)
+
+func TestFormatSelectionAsJSON(t *testing.T) {
+	textTree, err := text.NewTreeFromString("func() {\n    x := `{\"a\": [1, 2],\n\"b\": {}}`\n}")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	buffer := state.documentBuffer
+	buffer.textTree = textTree
+	buffer.tabExpand = true
+	buffer.tabSize = 2
+	buffer.cursor.position = 19
+
+	ToggleVisualMode(state, selection.ModeChar)
+	buffer.cursor.position = 40
+	err = FormatSelectionAsJSON(state)
+	require.NoError(t, err)
+	assert.Equal(t, "func() {\n    x := `{\n      \"a\": [\n        1,\n        2\n      ],\n      \"b\": {}\n    }`\n}", textTree.String())
+	assert.Equal(t, uint64(19), buffer.cursor.position)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "Formatted selection as JSON. Press U to revert", state.StatusMsg().Text)
+
+	// A single undo restores the original text.
+	Undo(state)
+	assert.Equal(t, "func() {\n    x := `{\"a\": [1, 2],\n\"b\": {}}`\n}", textTree.String())
+}
+
+func TestFormatSelectionAsXML(t *testing.T) {
+	textTree, err := text.NewTreeFromString("<a><b>1</b></a>\n")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	buffer := state.documentBuffer
+	buffer.textTree = textTree
+	buffer.tabExpand = false
+
+	ToggleVisualMode(state, selection.ModeLine)
+	err = FormatSelectionAsXML(state)
+	require.NoError(t, err)
+	assert.Equal(t, "<a>\n\t<b>1</b>\n</a>\n", textTree.String())
+}
+
+func TestFormatSelectionSyntaxError(t *testing.T) {
+	textTree, err := text.NewTreeFromString("x\n\n{\n  \"a\": ,\n}")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	buffer := state.documentBuffer
+	buffer.textTree = textTree
+	buffer.cursor.position = 2
+
+	ToggleVisualMode(state, selection.ModeLine)
+	buffer.cursor.position = 13
+	err = FormatSelectionAsJSON(state)
+	assert.EqualError(t, err, "Invalid JSON at line 3, column 8 of selection: invalid character ',' looking for beginning of value")
+	assert.Equal(t, "x\n\n{\n  \"a\": ,\n}", textTree.String())
+	assert.Equal(t, InputModeVisual, state.InputMode())
+}
@@ -2,6 +2,15 @@ package state
 
 // Quit sets a flag that terminates the program.
 func Quit(state *EditorState) {
+	activeIdx := activeBufferIdx(state)
+	for i, b := range state.openBuffers {
+		if i != activeIdx {
+			stashScratchBuffer(state, b.buffer)
+			b.watcher.Stop()
+		}
+	}
+	stashScratchBuffer(state, state.documentBuffer)
 	state.fileWatcher.Stop()
+	stopOutputCmd(state)
 	state.quitFlag = true
 }
@@ -0,0 +1,124 @@
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+
+	"github.com/aretext/aretext/text"
+)
+
+// searchRegexpForward finds the first match for the regular expression that starts after the start position.
+// If there is no such match, it wraps around to the first match in the document.
+func searchRegexpForward(startPos uint64, tree *text.Tree, parsedQuery parsedQuery) (bool, SearchMatch) {
+	if foundMatch, match := nextRegexpMatch(tree, parsedQuery, startPos+1); foundMatch {
+		return true, match
+	}
+	return nextRegexpMatch(tree, parsedQuery, 0)
+}
+
+// searchRegexpBackward finds the last match for the regular expression that starts before the start position.
+// If there is no such match, it wraps around to the last match in the document after the start position.
+func searchRegexpBackward(startPos uint64, tree *text.Tree, parsedQuery parsedQuery) (bool, SearchMatch) {
+	// The regexp package cannot search backward, so scan forward from the start of the document,
+	// stopping at the first match after the start position unless we need to wrap around.
+	var prevMatch, lastMatch *SearchMatch
+	var pos uint64
+	for {
+		foundMatch, match := nextRegexpMatch(tree, parsedQuery, pos)
+		if !foundMatch {
+			break
+		}
+
+		if match.StartPos < startPos {
+			prevMatch = &match
+		} else if prevMatch != nil {
+			break
+		} else if match.StartPos > startPos {
+			lastMatch = &match
+		}
+		pos = match.EndPos
+	}
+
+	if prevMatch != nil {
+		return true, *prevMatch
+	} else if lastMatch != nil {
+		return true, *lastMatch
+	}
+	return false, SearchMatch{}
+}
+
+// nextRegexpMatch returns the first non-empty match for a regular expression query that starts at or after pos.
+// If the query's regular expression is nil (for example, because the query is invalid), there are no matches.
+//
+// This reads the document from pos rather than copying it, so the cost is proportional
+// to the distance from pos to the match, not the size of the document.
+func nextRegexpMatch(tree *text.Tree, parsedQuery parsedQuery, pos uint64) (bool, SearchMatch) {
+	if parsedQuery.regexp == nil {
+		return false, SearchMatch{}
+	}
+
+	for pos < tree.NumChars() {
+		// Start one rune early so that anchors like "^" and word boundaries
+		// see the rune before pos. The regexp skips that rune before matching the query.
+		re, readerPos := parsedQuery.regexp, pos
+		if pos > 0 {
+			re, readerPos = parsedQuery.regexpAfterRune, pos-1
+		}
+
+		reader := tree.ReaderAtPosition(readerPos)
+		loc := re.FindReaderIndex(&reader)
+		if loc == nil {
+			return false, SearchMatch{}
+		}
+
+		match := matchForByteOffsets(tree, readerPos, loc[0], loc[1])
+		if pos > 0 {
+			match.StartPos++
+		}
+
+		if match.StartPos < match.EndPos {
+			return true, match
+		}
+
+		// Skip empty matches.
+		pos = match.StartPos + 1
+	}
+
+	return false, SearchMatch{}
+}
+
+// matchForByteOffsets converts byte offsets from the reader position to a match with rune positions.
+func matchForByteOffsets(tree *text.Tree, pos uint64, startOffset int, endOffset int) SearchMatch {
+	reader := tree.ReaderAtPosition(pos)
+	var byteOffset int
+	advanceToOffset := func(offset int) {
+		for byteOffset < offset {
+			_, size, err := reader.ReadRune()
+			if err != nil {
+				return
+			}
+			byteOffset += size
+			pos++
+		}
+	}
+
+	advanceToOffset(startOffset)
+	startPos := pos
+	advanceToOffset(endOffset)
+	return SearchMatch{StartPos: startPos, EndPos: pos}
+}
+
+// compileRegexpAfterRune compiles a regular expression that matches any rune followed by expr.
+func compileRegexpAfterRune(expr string) (*regexp.Regexp, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("syntax.Parse: %w", err)
+	}
+
+	anyRune := &syntax.Regexp{Op: syntax.OpAnyChar}
+	return regexp.Compile((&syntax.Regexp{
+		Op:  syntax.OpConcat,
+		Sub: []*syntax.Regexp{anyRune, re},
+	}).String())
+}
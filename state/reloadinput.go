@@ -0,0 +1,125 @@
+package state
+
+import (
+	"log"
+
+	"github.com/aretext/aretext/selection"
+)
+
+// interruptedInputState is the input the user was in the middle of when the document reloaded,
+// such as typing a menu search query or text field value.
+type interruptedInputState struct {
+	// inputMode is the input mode when the reload started.
+	inputMode InputMode
+
+	// baseInputMode is the mode to return to after the menu, text field, or task completes.
+	// This is the same as inputMode unless the user opened a menu, text field, or task.
+	baseInputMode InputMode
+
+	textfield    *TextFieldState
+	task         *TaskState
+	search       searchState
+	fileExplorer *FileExplorerState
+}
+
+// detachInterruptedInput saves the user's input before the document reloads.
+// Running tasks are detached from the editor state so that reloading the document doesn't cancel them.
+func detachInterruptedInput(state *EditorState) interruptedInputState {
+	s := interruptedInputState{
+		inputMode:     state.inputMode,
+		baseInputMode: state.inputMode,
+		fileExplorer:  state.fileExplorer,
+	}
+
+	switch state.inputMode {
+	case InputModeMenu:
+		s.baseInputMode = state.menu.prevInputMode
+	case InputModeTextField:
+		s.textfield = state.textfield
+		s.baseInputMode = state.textfield.prevInputMode
+	case InputModeTask:
+		s.task = state.task
+		s.baseInputMode = state.task.prevInputMode
+		state.task = nil
+	case InputModeSearch:
+		s.search = state.documentBuffer.search
+		s.baseInputMode = InputModeNormal
+	}
+
+	return s
+}
+
+// restoreInterruptedInput returns the user to the text field, search, or task
+// that was open when the document reloaded. Menus are closed instead, returning to
+// the mode from before the menu opened. The document's cursor and selection
+// must be restored before calling this.
+// It returns a short description of what happened to the input for the status message,
+// or an empty string if the user was in normal or visual mode.
+func restoreInterruptedInput(state *EditorState, s interruptedInputState) string {
+	// If the selection could not be restored, return to normal mode instead of visual mode.
+	prevInputMode := s.baseInputMode
+	if prevInputMode == InputModeVisual && state.documentBuffer.selector.Mode() == selection.ModeNone {
+		prevInputMode = InputModeNormal
+	}
+
+	log.Printf("Restoring input mode %s after reload\n", s.inputMode)
+
+	// The file explorer lists files on disk, not the document, so it stays open.
+	state.fileExplorer = s.fileExplorer
+
+	switch s.inputMode {
+	case InputModeMenu:
+		// Menu items may refer to positions in the old document (for example, long lines),
+		// so close the menu and return to the mode from before the menu opened.
+		setInputMode(state, prevInputMode)
+		return "menu closed"
+
+	case InputModeTextField:
+		s.textfield.prevInputMode = prevInputMode
+		state.textfield = s.textfield
+		setInputMode(state, InputModeTextField)
+		return "prompt still open"
+
+	case InputModeTask:
+		s.task.prevInputMode = prevInputMode
+		state.task = s.task
+		setInputMode(state, InputModeTask)
+		return "task still running"
+
+	case InputModeSearch:
+		// Search again, since the match may have moved or disappeared.
+		search := &state.documentBuffer.search
+		*search = s.search
+		search.match = nil
+		setInputMode(state, InputModeSearch)
+		runTextSearchQuery(state, search.query)
+		return "search still in progress"
+
+	case InputModeInsert, InputModeReplace:
+		// The undo log was reset, so start a new entry for the text the user inserts.
+		setInputMode(state, s.inputMode)
+		BeginUndoEntry(state)
+		return ""
+
+	case InputModeAutocomplete:
+		// The suggested words may have changed, so close the popup and keep inserting.
+		setInputMode(state, InputModeInsert)
+		BeginUndoEntry(state)
+		return "autocomplete closed"
+
+	case InputModeFileExplorer:
+		setInputMode(state, InputModeFileExplorer)
+		return ""
+
+	case InputModeUndoPreview:
+		// The undo log was reset, so there are no changes left to preview.
+		return "undo preview cancelled"
+
+	case InputModeReplaceConfirm:
+		// The undo log was reset, and the remaining matches may have moved.
+		return "find and replace stopped"
+
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,184 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/text"
+)
+
+var errNoIdentifierAtCursor = errors.New("No identifier under the cursor")
+
+// identifierOccurrences represents the occurrences of an identifier within a block of code.
+type identifierOccurrences struct {
+	identifier string
+	positions  []uint64 // start position of each occurrence, in ascending order.
+	cursorIdx  int      // index of the occurrence under the cursor.
+}
+
+// CountIdentifierInBlock returns the identifier under the cursor and the number of times it occurs
+// in the innermost brace block enclosing the cursor.
+// This allows the user to preview a rename before applying it.
+func CountIdentifierInBlock(state *EditorState) (string, int, error) {
+	occurrences, err := findIdentifierInBlock(state.documentBuffer)
+	if err != nil {
+		return "", 0, err
+	}
+	return occurrences.identifier, len(occurrences.positions), nil
+}
+
+// RenameIdentifierInBlock replaces every occurrence of the identifier under the cursor
+// within the innermost brace block enclosing the cursor.
+// Occurrences match only whole identifiers, so renaming "x" does not change "xy".
+// If the cursor is not within a brace block, this renames occurrences in the entire document.
+// All replacements are grouped into a single undo entry.
+func RenameIdentifierInBlock(state *EditorState, newName string) error {
+	if !isIdentifier(newName) {
+		return fmt.Errorf("Invalid identifier %q", newName)
+	}
+
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return err
+	}
+
+	occurrences, err := findIdentifierInBlock(buffer)
+	if err != nil {
+		return err
+	}
+
+	// Apply edits in reverse order so that the positions of earlier occurrences remain valid.
+	identifierLen := uint64(utf8.RuneCountInString(occurrences.identifier))
+	BeginUndoEntry(state)
+	for i := len(occurrences.positions) - 1; i >= 0; i-- {
+		pos := occurrences.positions[i]
+		if _, err := replaceRunes(state, pos, identifierLen, newName, true); err != nil {
+			log.Printf("Error replacing identifier: %v\n", err)
+			break
+		}
+	}
+	CommitUndoEntry(state)
+
+	// Move the cursor to the start of the renamed identifier it was on.
+	newNameLen := uint64(utf8.RuneCountInString(newName))
+	cursorIdx := occurrences.cursorIdx
+	newCursorPos := occurrences.positions[cursorIdx] + uint64(cursorIdx)*newNameLen - uint64(cursorIdx)*identifierLen
+	MoveCursor(state, func(LocatorParams) uint64 { return newCursorPos })
+	ScrollViewToCursor(state)
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Renamed %d occurrence(s) of %q to %q", len(occurrences.positions), occurrences.identifier, newName),
+	})
+	return nil
+}
+
+func findIdentifierInBlock(buffer *BufferState) (identifierOccurrences, error) {
+	tree := buffer.textTree
+	cursorPos := buffer.cursor.position
+	identifierStart, identifierEnd := identifierAtPos(tree, cursorPos)
+	if identifierStart == identifierEnd {
+		return identifierOccurrences{}, errNoIdentifierAtCursor
+	}
+	identifier := copyText(tree, identifierStart, identifierEnd-identifierStart)
+	if !isIdentifier(identifier) {
+		return identifierOccurrences{}, errNoIdentifierAtCursor
+	}
+
+	blockStart, blockEnd := locate.DelimitedBlock(locate.BracePair, tree, buffer.syntaxParser, true, identifierStart)
+	if blockStart == blockEnd {
+		// Not within a brace block, so use the entire document.
+		blockStart, blockEnd = 0, tree.NumChars()
+	}
+
+	occurrences := identifierOccurrences{identifier: identifier}
+	identifierRunes := []rune(identifier)
+	blockRunes := []rune(copyText(tree, blockStart, blockEnd-blockStart))
+	for i := 0; i+len(identifierRunes) <= len(blockRunes); i++ {
+		if !matchIdentifierAt(blockRunes, i, identifierRunes) {
+			continue
+		}
+		pos := blockStart + uint64(i)
+		if pos == identifierStart {
+			occurrences.cursorIdx = len(occurrences.positions)
+		}
+		occurrences.positions = append(occurrences.positions, pos)
+		i += len(identifierRunes) - 1
+	}
+
+	return occurrences, nil
+}
+
+// identifierAtPos returns the start and end positions of the identifier containing a position.
+// If there is no identifier at the position, the start and end positions are equal.
+func identifierAtPos(tree *text.Tree, pos uint64) (uint64, uint64) {
+	startPos, endPos := pos, pos
+
+	reader := tree.ReaderAtPosition(pos)
+	for {
+		r, _, err := reader.ReadRune()
+		if err == io.EOF || !isIdentifierRune(r) {
+			break
+		} else if err != nil {
+			panic(err) // should never happen because the tree is valid utf-8.
+		}
+		endPos++
+	}
+
+	if startPos == endPos {
+		return pos, pos
+	}
+
+	reverseReader := tree.ReverseReaderAtPosition(pos)
+	for {
+		r, _, err := reverseReader.ReadRune()
+		if err == io.EOF || !isIdentifierRune(r) {
+			break
+		} else if err != nil {
+			panic(err) // should never happen because the tree is valid utf-8.
+		}
+		startPos--
+	}
+
+	return startPos, endPos
+}
+
+// matchIdentifierAt returns whether the identifier occurs at an offset, not as part of a longer identifier.
+func matchIdentifierAt(runes []rune, offset int, identifier []rune) bool {
+	if offset > 0 && isIdentifierRune(runes[offset-1]) {
+		return false
+	}
+
+	end := offset + len(identifier)
+	if end < len(runes) && isIdentifierRune(runes[end]) {
+		return false
+	}
+
+	for i, r := range identifier {
+		if runes[offset+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !isIdentifierRune(r) || (i == 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,133 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestRenameIdentifierInBlock(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputString       string
+		cursorPos         uint64
+		newName           string
+		expectedCount     int
+		expectedText      string
+		expectedCursorPos uint64
+		expectedErr       string
+	}{
+		{
+			name:              "rename within block",
+			inputString:       "func f() {\n\tx := 1\n\treturn x + 1\n}\nfunc g() { return x }",
+			cursorPos:         12,
+			newName:           "count",
+			expectedCount:     2,
+			expectedText:      "func f() {\n\tcount := 1\n\treturn count + 1\n}\nfunc g() { return x }",
+			expectedCursorPos: 12,
+		},
+		{
+			name:              "cursor on later occurrence",
+			inputString:       "{ x = x + 1 }",
+			cursorPos:         6,
+			newName:           "abc",
+			expectedCount:     2,
+			expectedText:      "{ abc = abc + 1 }",
+			expectedCursorPos: 8,
+		},
+		{
+			name:              "cursor in middle of identifier",
+			inputString:       "{ foo(); foo() }",
+			cursorPos:         4,
+			newName:           "bar",
+			expectedCount:     2,
+			expectedText:      "{ bar(); bar() }",
+			expectedCursorPos: 2,
+		},
+		{
+			name:              "whole identifiers only",
+			inputString:       "{ x; xy; yx; x_1; x }",
+			cursorPos:         2,
+			newName:           "z",
+			expectedCount:     2,
+			expectedText:      "{ z; xy; yx; x_1; z }",
+			expectedCursorPos: 2,
+		},
+		{
+			name:              "nested block uses innermost",
+			inputString:       "{ x; if (a) { x = 2 } x }",
+			cursorPos:         14,
+			newName:           "y",
+			expectedCount:     1,
+			expectedText:      "{ x; if (a) { y = 2 } x }",
+			expectedCursorPos: 14,
+		},
+		{
+			name:              "no enclosing block renames in document",
+			inputString:       "x = 1\nprint(x)",
+			cursorPos:         0,
+			newName:           "y",
+			expectedCount:     2,
+			expectedText:      "y = 1\nprint(y)",
+			expectedCursorPos: 0,
+		},
+		{
+			name:        "cursor not on identifier",
+			inputString: "{ x + y }",
+			cursorPos:   4,
+			newName:     "z",
+			expectedErr: "No identifier under the cursor",
+		},
+		{
+			name:        "invalid new name",
+			inputString: "{ x }",
+			cursorPos:   2,
+			newName:     "a b",
+			expectedErr: "Invalid identifier \"a b\"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			buffer := state.documentBuffer
+			buffer.textTree = textTree
+			buffer.cursor.position = tc.cursorPos
+
+			_, count, countErr := CountIdentifierInBlock(state)
+			err = RenameIdentifierInBlock(state, tc.newName)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				assert.Equal(t, tc.inputString, textTree.String())
+				return
+			}
+
+			require.NoError(t, countErr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedCount, count)
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursorPos, buffer.cursor.position)
+		})
+	}
+}
+
+func TestRenameIdentifierInBlockUndo(t *testing.T) {
+	textTree, err := text.NewTreeFromString("{ x = x + x }")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	state.documentBuffer.cursor.position = 2
+
+	err = RenameIdentifierInBlock(state, "y")
+	require.NoError(t, err)
+	assert.Equal(t, "{ y = y + y }", textTree.String())
+	assert.Equal(t, `Renamed 3 occurrence(s) of "x" to "y"`, state.StatusMsg().Text)
+
+	Undo(state)
+	assert.Equal(t, "{ x = x + x }", textTree.String())
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"log"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/locate"
+)
+
+// replaceModeState tracks the characters overwritten in replace mode,
+// so that backspace can restore them.
+type replaceModeState struct {
+	edits []replaceModeEdit
+}
+
+// replaceModeEdit represents a single character typed in replace mode.
+type replaceModeEdit struct {
+	pos          uint64 // Position of the typed character.
+	numInserted  uint64 // Number of runes inserted at pos.
+	originalText string // Text overwritten by the typed character, if any.
+}
+
+// EnterReplaceMode sets the editor to replace mode, in which typed characters overwrite existing text.
+func EnterReplaceMode(state *EditorState) {
+	state.documentBuffer.replaceMode = replaceModeState{}
+	setInputMode(state, InputModeReplace)
+}
+
+// ReplaceModeInsertText overwrites characters at the cursor with the text.
+// At the end of a line, the text is inserted instead.
+// A newline in the text starts a new line without overwriting anything.
+func ReplaceModeInsertText(state *EditorState, text string) {
+	for _, r := range text {
+		if r == '\n' {
+			replaceModeInsert(state, "\n", false)
+		} else {
+			replaceModeInsert(state, string(r), true)
+		}
+	}
+}
+
+func replaceModeInsert(state *EditorState, s string, overwrite bool) {
+	buffer := state.documentBuffer
+	pos := buffer.cursor.position
+
+	var originalText string
+	if overwrite {
+		nextCharPos := locate.NextCharInLine(buffer.textTree, 1, true, pos)
+		if nextCharPos > pos {
+			originalText = deleteRunes(state, pos, nextCharPos-pos, true)
+		}
+	}
+
+	if err := insertTextAtPosition(state, s, pos, true); err != nil {
+		log.Printf("Error inserting text %q in replace mode: %v\n", s, err)
+		return
+	}
+
+	numInserted := uint64(utf8.RuneCountInString(s))
+	buffer.cursor = cursorState{position: pos + numInserted}
+	buffer.replaceMode.edits = append(buffer.replaceMode.edits, replaceModeEdit{
+		pos:          pos,
+		numInserted:  numInserted,
+		originalText: originalText,
+	})
+}
+
+// ReplaceModeDeletePrevChar undoes the last character typed in replace mode, restoring the original text.
+// If the cursor moved since the last character was typed, it moves the cursor back one character instead.
+func ReplaceModeDeletePrevChar(state *EditorState) {
+	buffer := state.documentBuffer
+	edits := buffer.replaceMode.edits
+	if len(edits) == 0 || edits[len(edits)-1].pos+edits[len(edits)-1].numInserted != buffer.cursor.position {
+		buffer.replaceMode.edits = nil
+		MoveCursor(state, func(p LocatorParams) uint64 {
+			return locate.PrevCharInLine(p.TextTree, 1, true, p.CursorPos)
+		})
+		return
+	}
+
+	lastEdit := edits[len(edits)-1]
+	buffer.replaceMode.edits = edits[:len(edits)-1]
+	deleteRunes(state, lastEdit.pos, lastEdit.numInserted, true)
+	if err := insertTextAtPosition(state, lastEdit.originalText, lastEdit.pos, true); err != nil {
+		log.Printf("Error restoring text %q in replace mode: %v\n", lastEdit.originalText, err)
+	}
+	buffer.cursor = cursorState{position: lastEdit.pos}
+}
@@ -0,0 +1,82 @@
+package state
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/text/segment"
+)
+
+// CursorInfo describes the location of the cursor and the character under it.
+type CursorInfo struct {
+	// LineNum is the zero-indexed line number of the cursor.
+	LineNum uint64
+
+	// Col is the zero-indexed offset in grapheme clusters from the start of the line.
+	Col uint64
+
+	// ByteOffset is the offset in bytes from the start of the document.
+	ByteOffset uint64
+
+	// GraphemeCluster contains the code points of the grapheme cluster under the cursor.
+	// This is empty if the cursor is positioned at the end of the document.
+	GraphemeCluster []rune
+}
+
+// CodePointsString formats the code points under the cursor (for example "U+0065 U+0301").
+func (ci CursorInfo) CodePointsString() string {
+	var sb strings.Builder
+	for i, r := range ci.GraphemeCluster {
+		if i > 0 {
+			sb.WriteRune(' ')
+		}
+		fmt.Fprintf(&sb, "U+%04X", r)
+	}
+	return sb.String()
+}
+
+// CursorInfo returns information about the cursor's location and the character under the cursor.
+func (s *BufferState) CursorInfo() CursorInfo {
+	pos := s.cursor.position
+	lineNum, col := locate.PosToLineNumAndCol(s.textTree, pos)
+	byteOffset := s.textTree.ByteOffsetForPosition(pos)
+
+	seg := segment.Empty()
+	gcIter := segment.NewGraphemeClusterIter(s.textTree.ReaderAtPosition(pos))
+	if err := gcIter.NextSegment(seg); err != nil && err != io.EOF {
+		panic(err)
+	}
+
+	return CursorInfo{
+		LineNum:         lineNum,
+		Col:             col,
+		ByteOffset:      byteOffset,
+		GraphemeCluster: append([]rune(nil), seg.Runes()...),
+	}
+}
+
+// ShowCursorInfo displays the code points and byte offset of the character under the cursor in the status bar.
+func ShowCursorInfo(state *EditorState) {
+	ci := state.documentBuffer.CursorInfo()
+
+	var msg string
+	if len(ci.GraphemeCluster) == 0 {
+		msg = fmt.Sprintf("End of document, line %d, col %d, byte %d", ci.LineNum+1, ci.Col+1, ci.ByteOffset)
+	} else {
+		msg = fmt.Sprintf(
+			"%q %s, line %d, col %d, byte %d",
+			string(ci.GraphemeCluster),
+			ci.CodePointsString(),
+			ci.LineNum+1,
+			ci.Col+1,
+			ci.ByteOffset,
+		)
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  msg,
+	})
+}
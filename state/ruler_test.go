@@ -0,0 +1,63 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestShowCursorInfo(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputString    string
+		cursorPos      uint64
+		expectedStatus string
+	}{
+		{
+			name:           "empty document",
+			inputString:    "",
+			cursorPos:      0,
+			expectedStatus: "End of document, line 1, col 1, byte 0",
+		},
+		{
+			name:           "ascii character",
+			inputString:    "abc",
+			cursorPos:      1,
+			expectedStatus: `"b" U+0062, line 1, col 2, byte 1`,
+		},
+		{
+			name:           "multi-byte character on second line",
+			inputString:    "£\nôx",
+			cursorPos:      3,
+			expectedStatus: `"x" U+0078, line 2, col 2, byte 5`,
+		},
+		{
+			name:           "grapheme cluster with combining character",
+			inputString:    "e\u0301",
+			cursorPos:      0,
+			expectedStatus: "\"e\u0301\" U+0065 U+0301, line 1, col 1, byte 0",
+		},
+		{
+			name:           "newline",
+			inputString:    "a\nb",
+			cursorPos:      1,
+			expectedStatus: `"\n" U+000A, line 1, col 2, byte 1`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			state.documentBuffer.cursor.position = tc.cursorPos
+			ShowCursorInfo(state)
+			assert.Equal(t, StatusMsgStyleSuccess, state.StatusMsg().Style)
+			assert.Equal(t, tc.expectedStatus, state.StatusMsg().Text)
+		})
+	}
+}
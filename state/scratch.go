@@ -0,0 +1,221 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/text"
+)
+
+// scratchNamePrefix marks the name of a scratch buffer, so it can't be mistaken for a file path.
+const scratchNamePrefix = "[scratch] "
+
+var errScratchNotSaved = errors.New("Scratch buffers are not backed by a file")
+
+// LoadScratchText replaces the document with a scratch buffer containing the text.
+// A scratch buffer can be searched, copied, and edited like any document, but it is not backed by a file:
+// the editor never watches it for changes on disk, and saving it reports an error instead of writing a file.
+// Since its contents can be recreated, edits to a scratch buffer never block quitting the editor.
+func LoadScratchText(state *EditorState, name string, s string) {
+	tree, err := text.NewTreeFromString(strings.TrimSuffix(s, "\n"))
+	if err != nil {
+		// Should never happen because the caller validates the text.
+		log.Printf("Error loading scratch text: %v\n", err)
+		return
+	}
+
+	// Record the current document so "open previous document" returns to it.
+	timelineState := currentTimelineState(state)
+
+	// If a scratch buffer with the same name is open in the background, replace its contents
+	// instead of opening another buffer with the same name.
+	scratchName := scratchNamePrefix + name
+	if idx := openBufferIdxForPath(state, scratchName); idx >= 0 {
+		activateOpenBuffer(state, idx)
+	}
+
+	resetStateForDocument(state, scratchName, tree, file.NewInactiveWatcher(scratchName))
+	state.documentBuffer.scratch = true
+	setCursorAfterLoad(state, func(LocatorParams) uint64 { return 0 })
+
+	if !timelineState.Empty() {
+		state.fileTimeline.TransitionFrom(timelineState)
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Opened scratch buffer with %d line(s)", tree.NumLines()),
+	})
+}
+
+// IsScratch returns whether the document is a scratch buffer that is not backed by a file.
+func (s *BufferState) IsScratch() bool {
+	return s.scratch
+}
+
+// SetScratchStore sets the store used to save named scratch buffers between sessions.
+func SetScratchStore(state *EditorState, store *file.ScratchStore) {
+	state.scratchStore = store
+}
+
+// OpenScratchBuffer replaces the document with the named scratch buffer, creating it if it doesn't exist.
+// The contents of a named scratch buffer are kept when switching to another document,
+// and saved to the scratch store if the persistScratch config option is enabled.
+func OpenScratchBuffer(state *EditorState, name string) error {
+	if err := file.ValidateScratchName(name); err != nil {
+		return err
+	}
+
+	if state.documentBuffer.scratchName == name {
+		return nil
+	} else if switchToOpenBufferForPath(state, scratchNamePrefix+name, func(p LocatorParams) uint64 { return p.CursorPos }) {
+		return nil
+	}
+
+	s, err := scratchBufferText(state, name)
+	if err != nil {
+		return err
+	}
+
+	LoadScratchText(state, name, s)
+	state.documentBuffer.scratchName = name
+	state.scratchBuffers[name] = s
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Opened scratch buffer %q", name),
+	})
+	return nil
+}
+
+// ShowScratchBufferMenu displays a menu of named scratch buffers, including those saved in previous sessions.
+func ShowScratchBufferMenu(state *EditorState) error {
+	names, err := scratchBufferNames(state)
+	if err != nil {
+		return err
+	} else if len(names) == 0 {
+		return errors.New(`No scratch buffers. Use "open scratch buffer" to create one`)
+	}
+
+	items := make([]menu.Item, 0, len(names))
+	for _, name := range names {
+		items = append(items, menu.Item{
+			Name: name,
+			Action: func(s *EditorState) {
+				if err := OpenScratchBuffer(s, name); err != nil {
+					SetStatusMsg(s, StatusMsg{
+						Style: StatusMsgStyleError,
+						Text:  err.Error(),
+					})
+				}
+			},
+		})
+	}
+
+	ShowMenu(state, MenuStyleScratch, items)
+	return nil
+}
+
+// SaveScratchBufferAs writes the scratch buffer to a new file, then opens the file as the document.
+// A named scratch buffer is removed once it has been saved to a file.
+func SaveScratchBufferAs(state *EditorState, path string) error {
+	buffer := state.documentBuffer
+	if !buffer.scratch {
+		return errors.New("Document is not a scratch buffer")
+	}
+
+	if err := file.ValidateCreate(path); err != nil {
+		return err
+	}
+
+	watcher, _, err := file.SaveWithFilter(path, buffer.textTree, textFormatForNewFile(state, path), false, watcherConfigForPath(state, path), preSaveFilter(state, path))
+	if err != nil {
+		return err
+	}
+	watcher.Stop()
+
+	if name := buffer.scratchName; name != "" {
+		delete(state.scratchBuffers, name)
+		if state.scratchStore != nil {
+			if err := state.scratchStore.Delete(name); err != nil {
+				log.Printf("Error deleting scratch buffer %q from store: %v\n", name, err)
+			}
+		}
+		buffer.scratchName = ""
+	}
+
+	// Close the scratch buffer, since the document replaces it.
+	cursorPos := buffer.cursor.position
+	closeActiveBuffer(state)
+	LoadDocument(state, path, true, func(LocatorParams) uint64 { return cursorPos })
+	return nil
+}
+
+// scratchBufferText returns the contents of a named scratch buffer, or an empty string if it doesn't exist.
+func scratchBufferText(state *EditorState, name string) (string, error) {
+	if s, ok := state.scratchBuffers[name]; ok {
+		return s, nil
+	}
+
+	if !state.persistScratch || state.scratchStore == nil {
+		return "", nil
+	}
+
+	s, err := state.scratchStore.Load(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("Could not load scratch buffer %q: %w", name, err)
+	}
+	return s, nil
+}
+
+// scratchBufferNames returns the names of scratch buffers opened in this session or saved to the store, sorted alphabetically.
+func scratchBufferNames(state *EditorState) ([]string, error) {
+	nameSet := make(map[string]struct{}, len(state.scratchBuffers))
+	for name := range state.scratchBuffers {
+		nameSet[name] = struct{}{}
+	}
+
+	if state.persistScratch && state.scratchStore != nil {
+		storedNames, err := state.scratchStore.Names()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range storedNames {
+			nameSet[name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// stashScratchBuffer remembers the contents of a buffer if it is a named scratch buffer,
+// so they can be restored when the scratch buffer is opened again.
+// Errors saving to the store are logged, but do not prevent switching documents.
+func stashScratchBuffer(state *EditorState, buffer *BufferState) {
+	name := buffer.scratchName
+	if name == "" {
+		return
+	}
+
+	s := buffer.textTree.String()
+	state.scratchBuffers[name] = s
+
+	if state.persistScratch && state.scratchStore != nil {
+		if err := state.scratchStore.Save(name, s); err != nil {
+			log.Printf("Error saving scratch buffer %q to store: %v\n", name, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+func TestLoadScratchText(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	LoadScratchText(state, "grep results", "foo\nbar\n")
+	assert.True(t, state.documentBuffer.IsScratch())
+	assert.Equal(t, "foo\nbar", state.documentBuffer.textTree.String())
+	assert.Equal(t, "[scratch] grep results", state.fileWatcher.Path())
+
+	// Scratch buffers can be edited, but not saved or reloaded.
+	InsertText(state, "x")
+	assert.Equal(t, "xfoo\nbar", state.documentBuffer.textTree.String())
+	AbortIfFileChanged(state, SaveDocument)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Contains(t, state.StatusMsg().Text, "Scratch buffers are not backed by a file")
+	_, err := os.Stat(state.fileWatcher.Path())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	ReloadDocument(state)
+	assert.Equal(t, "xfoo\nbar", state.documentBuffer.textTree.String())
+
+	// Edits to a scratch buffer do not block opening the previous document.
+	AbortIfUnsavedChanges(state, DefaultUnsavedChangesAbortMsg, LoadPrevDocument)
+	assert.False(t, state.documentBuffer.IsScratch())
+	assert.Equal(t, path, state.fileWatcher.Path())
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+
+	// The scratch buffer is not recorded in the timeline.
+	LoadNextDocument(state)
+	assert.Equal(t, "No next document to open", state.StatusMsg().Text)
+}
+
+func TestRenameScratchBuffer(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	LoadScratchText(state, "notes", "abc")
+	err := RenameDocument(state, "notes.txt")
+	require.Error(t, err)
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+}
+
+func TestOpenScratchBuffer(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.fileWatcher.Stop()
+
+	assert.EqualError(t, ShowScratchBufferMenu(state), `No scratch buffers. Use "open scratch buffer" to create one`)
+	assert.Error(t, OpenScratchBuffer(state, "my notes"))
+
+	err := OpenScratchBuffer(state, "notes")
+	require.NoError(t, err)
+	assert.True(t, state.documentBuffer.IsScratch())
+	assert.Equal(t, "", state.documentBuffer.textTree.String())
+	assert.Equal(t, `Opened scratch buffer "notes"`, state.StatusMsg().Text)
+	InsertText(state, "remember this")
+
+	// Switching to another document keeps the contents of the scratch buffer.
+	LoadDocument(state, path, true, startOfDocLocator)
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+
+	err = ShowScratchBufferMenu(state)
+	require.NoError(t, err)
+	assert.Equal(t, MenuStyleScratch, state.Menu().Style())
+	results, _ := state.Menu().SearchResults()
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, "notes", results[0].Name)
+
+	ExecuteSelectedMenuItem(state)
+	assert.True(t, state.documentBuffer.IsScratch())
+	assert.Equal(t, "remember this", state.documentBuffer.textTree.String())
+}
+
+func TestPersistScratchBuffers(t *testing.T) {
+	store := file.NewScratchStore(t.TempDir())
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetScratchStore(state, store)
+	err := OpenScratchBuffer(state, "notes")
+	require.NoError(t, err)
+	InsertText(state, "not persisted")
+	Quit(state)
+	names, err := store.Names()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(names))
+
+	state = NewEditorState(100, 100, nil, nil)
+	SetScratchStore(state, store)
+	state.persistScratch = true
+	err = OpenScratchBuffer(state, "notes")
+	require.NoError(t, err)
+	InsertText(state, "persisted")
+	Quit(state)
+
+	// The scratch buffer is restored in the next session.
+	state = NewEditorState(100, 100, nil, nil)
+	SetScratchStore(state, store)
+	state.persistScratch = true
+	err = ShowScratchBufferMenu(state)
+	require.NoError(t, err)
+	results, _ := state.Menu().SearchResults()
+	require.Equal(t, 1, len(results))
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, "persisted", state.documentBuffer.textTree.String())
+}
+
+func TestSaveScratchBufferAs(t *testing.T) {
+	store := file.NewScratchStore(t.TempDir())
+	require.NoError(t, store.Save("notes", "foo"))
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetScratchStore(state, store)
+	state.persistScratch = true
+	err := OpenScratchBuffer(state, "notes")
+	require.NoError(t, err)
+	InsertText(state, "bar")
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	err = SaveScratchBufferAs(state, path)
+	require.NoError(t, err)
+	defer state.fileWatcher.Stop()
+	assert.False(t, state.documentBuffer.IsScratch())
+	assert.Equal(t, path, state.fileWatcher.Path())
+	assert.Equal(t, "barfoo", state.documentBuffer.textTree.String())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "barfoo\n", string(data))
+
+	// The scratch buffer is removed once saved to a file.
+	assert.EqualError(t, ShowScratchBufferMenu(state), `No scratch buffers. Use "open scratch buffer" to create one`)
+
+	// Documents backed by a file can't be saved as a scratch buffer.
+	err = SaveScratchBufferAs(state, filepath.Join(t.TempDir(), "other.txt"))
+	assert.EqualError(t, err, "Document is not a scratch buffer")
+}
@@ -0,0 +1,156 @@
+package state
+
+// scrollbarMatchBatchSize is the maximum number of search matches to find each time the scrollbar is drawn.
+// Finding matches in batches keeps the editor responsive in large documents;
+// the rest of the matches appear as the scrollbar is redrawn.
+const scrollbarMatchBatchSize = 1000
+
+// ScrollbarMarker describes why a row of the scrollbar is marked.
+// Markers with larger values take priority when several lines map to the same row.
+type ScrollbarMarker int
+
+const (
+	ScrollbarMarkerNone        = ScrollbarMarker(iota)
+	ScrollbarMarkerLineChange  // A line changed since the document was last saved.
+	ScrollbarMarkerFlag        // A line contains a flag marker like TODO or FIXME.
+	ScrollbarMarkerSearchMatch // A line matches the search query.
+)
+
+// scrollbarIndex records the lines matching the search query, found progressively as the scrollbar is drawn.
+// It is discarded whenever the document or the search query changes.
+type scrollbarIndex struct {
+	query      string   // The search query for the matched lines.
+	matchLines []uint64 // Line numbers with at least one match, sorted ascending.
+	nextPos    uint64   // Position to resume finding matches.
+	complete   bool     // Whether every match in the document has been found.
+}
+
+// invalidateScrollbarIndex discards the search matches after the document changes.
+func invalidateScrollbarIndex(buffer *BufferState) {
+	buffer.scrollbar = scrollbarIndex{}
+}
+
+// ShowScrollbar returns whether to show the scrollbar at the right edge of the document.
+func (s *BufferState) ShowScrollbar() bool {
+	return s.showScrollbar
+}
+
+// ScrollbarWidth returns the number of columns at the right edge used for the scrollbar.
+// This is zero if the scrollbar is disabled or there isn't enough space to show any document text.
+func (s *BufferState) ScrollbarWidth() uint64 {
+	if !s.showScrollbar || s.view.width <= s.LineNumMarginWidth()+s.DiffGutterWidth()+1 {
+		return 0
+	}
+	return 1
+}
+
+// ScrollbarThumb returns the rows of the scrollbar representing the lines in view,
+// from startRow (inclusive) to endRow (exclusive).
+func (s *BufferState) ScrollbarThumb(numRows uint64) (startRow, endRow uint64) {
+	if numRows == 0 {
+		return 0, 0
+	}
+
+	numLines := s.textTree.NumLines()
+	firstLine := s.textTree.LineNumForPosition(s.view.textOrigin)
+	lastLine := firstLine
+	if s.view.height > 0 {
+		lastLine += s.view.height - 1
+	}
+	if numLines > 0 && lastLine >= numLines {
+		lastLine = numLines - 1
+	}
+
+	startRow = scrollbarRowForLine(firstLine, numLines, numRows)
+	endRow = scrollbarRowForLine(lastLine, numLines, numRows) + 1
+	return startRow, endRow
+}
+
+// ScrollbarMarkers returns the marker for each row of the scrollbar.
+// Lines are distributed evenly across the rows, so each row represents a range of lines in the document.
+// Search matches are found in batches, so the markers may be incomplete until ScrollbarPending returns false.
+func (s *BufferState) ScrollbarMarkers(numRows uint64) []ScrollbarMarker {
+	if numRows == 0 {
+		return nil
+	}
+
+	markers := make([]ScrollbarMarker, numRows)
+	numLines := s.textTree.NumLines()
+	mark := func(lineNum uint64, marker ScrollbarMarker) {
+		row := scrollbarRowForLine(lineNum, numLines, numRows)
+		if markers[row] < marker {
+			markers[row] = marker
+		}
+	}
+
+	for lineNum := range s.lineChanges.changes {
+		mark(lineNum, ScrollbarMarkerLineChange)
+	}
+
+	for _, item := range s.flaggedItems() {
+		mark(s.textTree.LineNumForPosition(item.pos), ScrollbarMarkerFlag)
+	}
+
+	s.findScrollbarMatches()
+	for _, lineNum := range s.scrollbar.matchLines {
+		mark(lineNum, ScrollbarMarkerSearchMatch)
+	}
+
+	return markers
+}
+
+// ScrollbarPending returns whether the scrollbar has more search matches to find.
+// The scrollbar should be redrawn until this returns false.
+func (s *BufferState) ScrollbarPending() bool {
+	return s.ScrollbarWidth() > 0 && !(s.scrollbar.complete && s.scrollbar.query == s.search.query)
+}
+
+// findScrollbarMatches finds the next batch of lines matching the search query.
+func (s *BufferState) findScrollbarMatches() {
+	idx := &s.scrollbar
+	if idx.query != s.search.query {
+		*idx = scrollbarIndex{query: s.search.query}
+	}
+
+	if idx.complete {
+		return
+	}
+
+	if idx.query == "" {
+		idx.complete = true
+		return
+	}
+
+	q := parseQuery(idx.query)
+	for i := 0; i < scrollbarMatchBatchSize; i++ {
+		found, m := findNextMatch(s.textTree, q, idx.nextPos, s.textTree.NumChars())
+		if !found || m.EndPos <= m.StartPos {
+			idx.complete = true
+			return
+		}
+		idx.appendMatchLine(s.textTree.LineNumForPosition(m.StartPos))
+		idx.nextPos = m.EndPos
+	}
+}
+
+func (idx *scrollbarIndex) appendMatchLine(lineNum uint64) {
+	if n := len(idx.matchLines); n > 0 && idx.matchLines[n-1] == lineNum {
+		return
+	}
+	idx.matchLines = append(idx.matchLines, lineNum)
+}
+
+// scrollbarRowForLine maps a line in the document to a row of the scrollbar.
+// Documents with fewer lines than the scrollbar has rows map each line to its own row.
+func scrollbarRowForLine(lineNum, numLines, numRows uint64) uint64 {
+	var row uint64
+	if numLines <= numRows {
+		row = lineNum
+	} else {
+		row = lineNum * numRows / numLines
+	}
+	if row >= numRows {
+		row = numRows - 1
+	}
+	return row
+}
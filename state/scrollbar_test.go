@@ -0,0 +1,108 @@
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrollbarMarkers(t *testing.T) {
+	path, cleanup := createTestFile(t, "a\nfoo\nb\nTODO\nc\nd\ne\nf")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	ToggleShowScrollbar(state)
+	assert.Equal(t, "Showing scrollbar", state.StatusMsg().Text)
+	assert.Equal(t, uint64(1), state.documentBuffer.ScrollbarWidth())
+
+	BeginUndoEntry(state)
+	MoveCursor(state, func(LocatorParams) uint64 { return 14 })
+	InsertText(state, "x")
+	CommitUndoEntry(state)
+	updateLineChanges(t, state)
+	state.documentBuffer.search.query = "foo"
+
+	// Each row represents two lines, and markers with higher priority replace others in the same row.
+	markers := state.documentBuffer.ScrollbarMarkers(4)
+	assert.Equal(t, []ScrollbarMarker{
+		ScrollbarMarkerSearchMatch,
+		ScrollbarMarkerFlag,
+		ScrollbarMarkerLineChange,
+		ScrollbarMarkerNone,
+	}, markers)
+	assert.False(t, state.documentBuffer.ScrollbarPending())
+
+	// Each line has its own row if the scrollbar has enough rows.
+	markers = state.documentBuffer.ScrollbarMarkers(10)
+	assert.Equal(t, []ScrollbarMarker{
+		ScrollbarMarkerNone,
+		ScrollbarMarkerSearchMatch,
+		ScrollbarMarkerNone,
+		ScrollbarMarkerFlag,
+		ScrollbarMarkerLineChange,
+		ScrollbarMarkerNone,
+		ScrollbarMarkerNone,
+		ScrollbarMarkerNone,
+		ScrollbarMarkerNone,
+		ScrollbarMarkerNone,
+	}, markers)
+
+	ToggleShowScrollbar(state)
+	assert.Equal(t, "Hiding scrollbar", state.StatusMsg().Text)
+	assert.Equal(t, uint64(0), state.documentBuffer.ScrollbarWidth())
+}
+
+func TestScrollbarFindsMatchesProgressively(t *testing.T) {
+	numLines := 2*scrollbarMatchBatchSize + 1
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	InsertText(state, strings.Repeat("foo\n", numLines))
+	ToggleShowScrollbar(state)
+	state.documentBuffer.search.query = "foo"
+
+	var numDraws int
+	for state.documentBuffer.ScrollbarPending() {
+		state.documentBuffer.ScrollbarMarkers(10)
+		numDraws++
+		require.LessOrEqual(t, numDraws, 10)
+	}
+	assert.Equal(t, 3, numDraws)
+	assert.Equal(t, numLines, len(state.documentBuffer.scrollbar.matchLines))
+
+	// Changing the query restarts the search.
+	state.documentBuffer.search.query = "bar"
+	assert.True(t, state.documentBuffer.ScrollbarPending())
+	state.documentBuffer.ScrollbarMarkers(10)
+	assert.False(t, state.documentBuffer.ScrollbarPending())
+	assert.Empty(t, state.documentBuffer.scrollbar.matchLines)
+
+	// Editing the document restarts the search.
+	state.documentBuffer.search.query = "foo"
+	state.documentBuffer.ScrollbarMarkers(10)
+	InsertText(state, "x")
+	assert.Empty(t, state.documentBuffer.scrollbar.matchLines)
+	assert.True(t, state.documentBuffer.ScrollbarPending())
+}
+
+func TestScrollbarThumb(t *testing.T) {
+	state := NewEditorState(100, 12, nil, nil)
+	defer Quit(state)
+	InsertText(state, strings.Repeat("a\n", 99))
+	MoveCursor(state, func(LocatorParams) uint64 { return 0 })
+	ScrollViewToCursor(state)
+
+	// The view shows 11 of the 100 lines.
+	startRow, endRow := state.documentBuffer.ScrollbarThumb(10)
+	assert.Equal(t, uint64(0), startRow)
+	assert.Equal(t, uint64(2), endRow)
+
+	MoveCursor(state, func(p LocatorParams) uint64 { return p.TextTree.NumChars() })
+	ScrollViewToCursor(state)
+	startRow, endRow = state.documentBuffer.ScrollbarThumb(10)
+	assert.Equal(t, uint64(9), startRow)
+	assert.Equal(t, uint64(10), endRow)
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -63,6 +64,7 @@ func (sm *SearchMatch) ContainsPosition(pos uint64) bool {
 // StartSearch initiates a new text search.
 func StartSearch(state *EditorState, direction SearchDirection, completeAction SearchCompleteAction) {
 	search := &state.documentBuffer.search
+	loadSessionSearchHistory(state, search)
 	prevQuery, prevDirection := search.query, search.direction
 	*search = searchState{
 		direction:      direction,
@@ -85,6 +87,7 @@ func CompleteSearch(state *EditorState, commit bool) {
 		if len(search.history) == 0 || search.history[len(search.history)-1] != search.query {
 			search.history = append(search.history, search.query)
 		}
+		storeSessionSearchQuery(state, search.query)
 	}
 
 	// Return to normal mode.
@@ -177,15 +180,15 @@ func SearchWordUnderCursor(state *EditorState, direction SearchDirection, comple
 func runTextSearchQuery(state *EditorState, q string) {
 	buffer := state.documentBuffer
 	buffer.search.query = q
-	foundMatch, matchStartPos := false, uint64(0)
+	foundMatch, match := false, SearchMatch{}
 	parsedQuery := parseQuery(q)
 	if buffer.search.direction == SearchDirectionForward {
-		foundMatch, matchStartPos = searchTextForward(
+		foundMatch, match = searchForward(
 			buffer.cursor.position,
 			buffer.textTree,
 			parsedQuery)
 	} else {
-		foundMatch, matchStartPos = searchTextBackward(
+		foundMatch, match = searchBackward(
 			buffer.cursor.position,
 			buffer.textTree,
 			parsedQuery)
@@ -197,11 +200,36 @@ func runTextSearchQuery(state *EditorState, q string) {
 		return
 	}
 
-	buffer.search.match = &SearchMatch{
-		StartPos: matchStartPos,
-		EndPos:   matchStartPos + uint64(utf8.RuneCountInString(parsedQuery.queryText)),
+	buffer.search.match = &match
+	scrollViewToPosition(buffer, match.StartPos)
+}
+
+// SelectNextOccurrence selects the next occurrence of the selected text after the selection,
+// wrapping around to the start of the document. Matches are case-sensitive.
+// This makes it quick to select and edit each occurrence in turn.
+func SelectNextOccurrence(state *EditorState) {
+	buffer := state.documentBuffer
+	selectedText, r := copySelectionText(buffer)
+	if selectedText == "" {
+		return
 	}
-	scrollViewToPosition(buffer, matchStartPos)
+
+	q := parsedQuery{queryText: selectedText, caseSensitive: true}
+	foundMatch, matchStartPos := searchTextForward(r.EndPos-1, buffer.textTree, q)
+	if !foundMatch || matchStartPos == r.StartPos {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No other occurrences of the selected text",
+		})
+		return
+	}
+
+	// The selection includes the character under the cursor,
+	// so place the cursor on the last character of the match.
+	matchEndPos := matchStartPos + uint64(utf8.RuneCountInString(selectedText))
+	buffer.selector.Start(buffer.selector.Mode(), matchStartPos)
+	buffer.cursor = cursorState{position: locate.PrevChar(buffer.textTree, 1, matchEndPos)}
+	ScrollViewToCursor(state)
 }
 
 // FindNextMatch moves the cursor to the next position matching the search query.
@@ -214,27 +242,34 @@ func FindNextMatch(state *EditorState, reverse bool) {
 		direction = direction.Reverse()
 	}
 
-	foundMatch, newCursorPos := false, uint64(0)
+	foundMatch, match := false, SearchMatch{}
 	if direction == SearchDirectionForward {
-		foundMatch, newCursorPos = searchTextForward(
+		foundMatch, match = searchForward(
 			buffer.cursor.position,
 			buffer.textTree,
 			parsedQuery)
 	} else {
-		foundMatch, newCursorPos = searchTextBackward(
+		foundMatch, match = searchBackward(
 			buffer.cursor.position,
 			buffer.textTree,
 			parsedQuery)
 	}
 
 	if foundMatch {
-		buffer.cursor = cursorState{position: newCursorPos}
+		buffer.cursor = cursorState{position: match.StartPos}
 	}
 }
 
 type parsedQuery struct {
 	queryText     string
 	caseSensitive bool
+	isRegexp      bool
+	regexp        *regexp.Regexp // Nil if the query is not a regular expression or is invalid.
+	regexpErr     error
+
+	// Matches any rune followed by the query, so a search can start one rune early
+	// and still respect anchors and word boundaries.
+	regexpAfterRune *regexp.Regexp
 }
 
 // parseQuery interprets the user's search query.
@@ -242,34 +277,99 @@ type parsedQuery struct {
 // otherwise, it's case-sensitive (equivalent to vim's smartcase option).
 // Users can override this by setting the suffix to "\c" for case-insensitive
 // and "\C" for case-sensitive.
+// If the query starts with "\v", the rest of the query is a regular expression.
+// Escape sequences in a regular expression, such as "\S", do not make it case-sensitive.
 func parseQuery(rawQuery string) parsedQuery {
+	var q parsedQuery
+	if strings.HasPrefix(rawQuery, `\v`) {
+		q.isRegexp = true
+		rawQuery = rawQuery[2:]
+	}
+
 	if strings.HasSuffix(rawQuery, `\c`) {
-		return parsedQuery{
-			queryText:     rawQuery[0 : len(rawQuery)-2],
-			caseSensitive: false,
-		}
+		q.queryText = rawQuery[0 : len(rawQuery)-2]
+		q.caseSensitive = false
+	} else if strings.HasSuffix(rawQuery, `\C`) {
+		q.queryText = rawQuery[0 : len(rawQuery)-2]
+		q.caseSensitive = true
+	} else {
+		q.queryText = rawQuery
+		q.caseSensitive = hasUpperCase(rawQuery, q.isRegexp)
 	}
 
-	if strings.HasSuffix(rawQuery, `\C`) {
-		return parsedQuery{
-			queryText:     rawQuery[0 : len(rawQuery)-2],
-			caseSensitive: true,
+	q.compileRegexp()
+	return q
+}
+
+// hasUpperCase returns whether the query contains an uppercase letter.
+// If skipEscapes is true, letters following a backslash are ignored.
+func hasUpperCase(query string, skipEscapes bool) bool {
+	var escaped bool
+	for _, r := range query {
+		if escaped {
+			escaped = false
+			continue
+		}
+		if skipEscapes && r == '\\' {
+			escaped = true
+		} else if unicode.IsUpper(r) {
+			return true
 		}
 	}
+	return false
+}
 
-	var caseSensitive bool
-	for _, r := range rawQuery {
-		if unicode.IsUpper(r) {
-			caseSensitive = true
-			break
-		}
+// compileRegexp compiles a regular expression query using the query's case sensitivity.
+// The "^" and "$" anchors match at the start and end of each line.
+func (q *parsedQuery) compileRegexp() {
+	if !q.isRegexp {
+		return
+	}
+
+	// Check the query without flags first, so errors show the query as the user typed it.
+	if _, err := regexp.Compile(q.queryText); err != nil {
+		q.regexp, q.regexpErr = nil, fmt.Errorf("Invalid regular expression: %w", err)
+		return
+	}
+
+	flags := "(?m)"
+	if !q.caseSensitive {
+		flags = "(?mi)"
+	}
+	q.regexp, q.regexpErr = regexp.Compile(flags + q.queryText)
+	if q.regexpErr != nil {
+		return
 	}
 
-	return parsedQuery{
-		queryText:     rawQuery,
-		caseSensitive: caseSensitive,
+	q.regexpAfterRune, q.regexpErr = compileRegexpAfterRune(flags + q.queryText)
+	if q.regexpErr != nil {
+		q.regexp = nil
 	}
+}
+
+// searchForward finds the next match for the query after the start position, wrapping around to the start of the document.
+func searchForward(startPos uint64, tree *text.Tree, parsedQuery parsedQuery) (bool, SearchMatch) {
+	if parsedQuery.isRegexp {
+		return searchRegexpForward(startPos, tree, parsedQuery)
+	}
+	foundMatch, matchStartPos := searchTextForward(startPos, tree, parsedQuery)
+	return foundMatch, literalMatch(matchStartPos, parsedQuery)
+}
 
+// searchBackward finds the previous match for the query before the start position, wrapping around to the end of the document.
+func searchBackward(startPos uint64, tree *text.Tree, parsedQuery parsedQuery) (bool, SearchMatch) {
+	if parsedQuery.isRegexp {
+		return searchRegexpBackward(startPos, tree, parsedQuery)
+	}
+	foundMatch, matchStartPos := searchTextBackward(startPos, tree, parsedQuery)
+	return foundMatch, literalMatch(matchStartPos, parsedQuery)
+}
+
+func literalMatch(startPos uint64, parsedQuery parsedQuery) SearchMatch {
+	return SearchMatch{
+		StartPos: startPos,
+		EndPos:   startPos + uint64(utf8.RuneCountInString(parsedQuery.queryText)),
+	}
 }
 
 func transformerForSearch(caseSensitive bool) transform.Transformer {
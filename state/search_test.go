@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/selection"
 	"github.com/aretext/aretext/text"
 )
 
@@ -174,6 +175,18 @@ func TestSearchCaseSensitivity(t *testing.T) {
 			query:            "FOO\\c",
 			expectedMatchPos: 4,
 		},
+		{
+			name:             "regexp with escape sequence, case-insensitive search",
+			text:             "abc FOO1 xyz",
+			query:            `\vf\w+\d`,
+			expectedMatchPos: 4,
+		},
+		{
+			name:             "regexp with uppercase letter, case-sensitive search",
+			text:             "abc foo1 Foo2",
+			query:            `\vF\w+`,
+			expectedMatchPos: 9,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -342,6 +355,78 @@ func TestFindNextMatch(t *testing.T) {
 			direction:         SearchDirectionForward,
 			expectedCursorPos: 32,
 		},
+		{
+			name:              "regexp forward search",
+			text:              "foo bar\nbaz foo bat",
+			cursorPos:         4,
+			query:             `\vba[rt]`,
+			direction:         SearchDirectionForward,
+			expectedCursorPos: 16,
+		},
+		{
+			name:              "regexp forward search with wraparound",
+			text:              "foo bar\nbaz foo bat",
+			cursorPos:         16,
+			query:             `\vba[rt]`,
+			direction:         SearchDirectionForward,
+			expectedCursorPos: 4,
+		},
+		{
+			name:              "regexp backward search with wraparound",
+			text:              "foo bar\nbaz foo bat",
+			cursorPos:         4,
+			query:             `\vba[rt]`,
+			direction:         SearchDirectionBackward,
+			expectedCursorPos: 16,
+		},
+		{
+			name:              "regexp anchored at start of line",
+			text:              "foo\n  return x\n\treturn y",
+			cursorPos:         0,
+			query:             `\v^\s+return`,
+			direction:         SearchDirectionForward,
+			expectedCursorPos: 4,
+		},
+		{
+			name:              "regexp anchor does not match at cursor in middle of line",
+			text:              "foo bar\nbar",
+			cursorPos:         3,
+			query:             `\v^bar`,
+			direction:         SearchDirectionForward,
+			expectedCursorPos: 8,
+		},
+		{
+			name:              "regexp word boundary uses character before cursor",
+			text:              "foobar bar",
+			cursorPos:         2,
+			query:             `\v\bbar`,
+			direction:         SearchDirectionForward,
+			expectedCursorPos: 7,
+		},
+		{
+			name:              "regexp skips empty matches",
+			text:              "foo bar",
+			cursorPos:         0,
+			query:             `\vb*`,
+			direction:         SearchDirectionForward,
+			expectedCursorPos: 4,
+		},
+		{
+			name:              "regexp positions count characters, not bytes",
+			text:              "ü ü foo",
+			cursorPos:         0,
+			query:             `\vf.o`,
+			direction:         SearchDirectionForward,
+			expectedCursorPos: 4,
+		},
+		{
+			name:              "invalid regexp",
+			text:              "foo(",
+			cursorPos:         1,
+			query:             `\vfoo(`,
+			direction:         SearchDirectionForward,
+			expectedCursorPos: 1,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -545,6 +630,24 @@ func TestSearchForDelete(t *testing.T) {
 			expectedText: "abyz 456",
 			expectedPos:  2,
 		},
+		{
+			name:         "regexp match, forward search",
+			inputText:    "foo := bar(1, 2)",
+			direction:    SearchDirectionForward,
+			pos:          0,
+			query:        `\v\w+\(`,
+			expectedText: "bar(1, 2)",
+			expectedPos:  0,
+		},
+		{
+			name:         "regexp match, backward search",
+			inputText:    "a1 b22 c333",
+			direction:    SearchDirectionBackward,
+			pos:          10,
+			query:        `\v\d{2}`,
+			expectedText: "a1 b22 c3",
+			expectedPos:  8,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -972,3 +1075,44 @@ func TestSearchQueryHistoryExcludesDuplicateQueries(t *testing.T) {
 	require.NotNil(t, buffer.search.match)
 	assert.Equal(t, uint64(2), buffer.search.match.StartPos)
 }
+
+func TestSelectNextOccurrence(t *testing.T) {
+	textTree, err := text.NewTreeFromString("foo bar Foo foo\nfoofoo")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	buffer := state.documentBuffer
+	buffer.textTree = textTree
+
+	ToggleVisualMode(state, selection.ModeChar)
+	buffer.cursor.position = 2
+
+	// Matches are case-sensitive, so this skips "Foo".
+	expectedRegions := []selection.Region{
+		{StartPos: 12, EndPos: 15},
+		{StartPos: 16, EndPos: 19},
+		{StartPos: 19, EndPos: 22},
+		{StartPos: 0, EndPos: 3}, // Wrap around to the start of the document.
+	}
+	for _, expected := range expectedRegions {
+		SelectNextOccurrence(state)
+		assert.Equal(t, expected, buffer.SelectedRegion())
+		assert.Equal(t, expected.EndPos-1, buffer.cursor.position)
+		assert.Equal(t, InputModeVisual, state.InputMode())
+	}
+}
+
+func TestSelectNextOccurrenceNoOtherMatches(t *testing.T) {
+	textTree, err := text.NewTreeFromString("foo bar baz")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	buffer := state.documentBuffer
+	buffer.textTree = textTree
+	buffer.cursor.position = 4
+
+	ToggleVisualMode(state, selection.ModeChar)
+	buffer.cursor.position = 6
+	SelectNextOccurrence(state)
+	assert.Equal(t, selection.Region{StartPos: 4, EndPos: 7}, buffer.SelectedRegion())
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "No other occurrences of the selected text", state.StatusMsg().Text)
+}
@@ -0,0 +1,38 @@
+package state
+
+import (
+	"log"
+
+	"github.com/aretext/aretext/session"
+)
+
+// JoinSession shares named clipboard pages and search history with other editor instances in the same session.
+func JoinSession(state *EditorState, s *session.Session) {
+	state.session = s
+	state.clipboard.Share(s)
+}
+
+// loadSessionSearchHistory replaces the search history with the latest history from the session, if any.
+func loadSessionSearchHistory(state *EditorState, search *searchState) {
+	if state.session == nil {
+		return
+	}
+
+	history, err := state.session.SearchHistory()
+	if err != nil {
+		log.Printf("Error loading session search history: %v\n", err)
+		return
+	}
+	search.history = history
+}
+
+// storeSessionSearchQuery adds a query to the session's search history, if any.
+func storeSessionSearchQuery(state *EditorState, query string) {
+	if state.session == nil {
+		return
+	}
+
+	if err := state.session.AddSearchQuery(query); err != nil {
+		log.Printf("Error storing session search history: %v\n", err)
+	}
+}
@@ -0,0 +1,44 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/session"
+	"github.com/aretext/aretext/text"
+)
+
+func TestJoinSessionSharesClipboardAndSearchHistory(t *testing.T) {
+	dir := t.TempDir()
+	newStateInSession := func() *EditorState {
+		s, err := session.Open(dir, "test")
+		require.NoError(t, err)
+		textTree, err := text.NewTreeFromString("foo bar baz")
+		require.NoError(t, err)
+		state := NewEditorState(100, 100, nil, nil)
+		state.documentBuffer.textTree = textTree
+		JoinSession(state, s)
+		return state
+	}
+
+	state1, state2 := newStateInSession(), newStateInSession()
+
+	// Yank a word to page "a" in the first editor, then paste it in the second.
+	CopyRange(state1, clipboard.PageLetterA, func(LocatorParams) (uint64, uint64) { return 0, 3 })
+	MoveCursor(state2, func(LocatorParams) uint64 { return 10 })
+	PasteAfterCursor(state2, clipboard.PageLetterA)
+	assert.Equal(t, "foo bar bazfoo", state2.documentBuffer.textTree.String())
+
+	// Search in the first editor, then recall the query from history in the second.
+	StartSearch(state1, SearchDirectionForward, SearchCompleteMoveCursorToMatch)
+	AppendRuneToSearchQuery(state1, 'b')
+	AppendRuneToSearchQuery(state1, 'a')
+	CompleteSearch(state1, true)
+
+	StartSearch(state2, SearchDirectionForward, SearchCompleteMoveCursorToMatch)
+	SetSearchQueryToPrevInHistory(state2)
+	assert.Equal(t, "ba", state2.documentBuffer.search.query)
+}
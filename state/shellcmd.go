@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -26,9 +27,32 @@ type SuspendScreenFunc func(func() error) error
 // All modes run as an asynchronous task that the user can cancel,
 // except for CmdModeTerminal which takes over stdin/stdout.
 func RunShellCmd(state *EditorState, shellCmd string, mode string) {
-	log.Printf("Running shell command: %q\n", shellCmd)
-
 	env := envVars(state) // Read-only copy of env vars is safe to pass to other goroutines.
+	runShellCmdWithEnv(state, shellCmd, mode, env)
+}
+
+// RunShellCmdWithSubmenu executes a shell command, then shows a menu with each line of its output.
+// When the user selects a line, this executes the submenu command in a shell
+// with the selected line in the environment variable $ITEM.
+// Mode must be a valid command mode for the submenu command, as defined in config.
+func RunShellCmdWithSubmenu(state *EditorState, shellCmd string, submenuShellCmd string, submenuMode string) {
+	log.Printf("Running shell command for submenu: %q\n", shellCmd)
+	env := envVars(state)
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		output, err := shellcmd.RunAndCaptureOutput(ctx, shellCmd, env)
+		return func(state *EditorState) {
+			if err == nil {
+				err = showSubmenuForShellCmdOutput(state, output, submenuShellCmd, submenuMode)
+			}
+			if err != nil {
+				setStatusForShellCmdResult(state, err)
+			}
+		}
+	})
+}
+
+func runShellCmdWithEnv(state *EditorState, shellCmd string, mode string, env []string) {
+	log.Printf("Running shell command: %q\n", shellCmd)
 
 	switch mode {
 	case config.CmdModeTerminal:
@@ -91,6 +115,23 @@ func RunShellCmd(state *EditorState, shellCmd string, mode string) {
 			}
 		})
 
+	case config.CmdModeScratch:
+		StartTask(state, func(ctx context.Context) func(*EditorState) {
+			output, err := shellcmd.RunAndCaptureOutput(ctx, shellCmd, env)
+			return func(state *EditorState) {
+				if err != nil {
+					setStatusForShellCmdResult(state, err)
+					return
+				}
+				LoadScratchText(state, shellCmd, output)
+			}
+		})
+
+	case config.CmdModeOutput:
+		// Run in the background instead of as a task, since tasks block input
+		// and are cancelled whenever the user switches documents.
+		runShellCmdInOutputBuffer(state, shellCmd, env)
+
 	default:
 		// This should never happen because the config validates the mode.
 		panic("Unrecognized shell cmd mode")
@@ -172,8 +213,77 @@ func countBytesBetweenPositions(textTree *text.Tree, startPos, endPos uint64) ui
 	return byteCount
 }
 
+// FilterRegion replaces the text in a region with the output of a shell command.
+// The text is passed to the command's stdin. If the text does not end with a newline,
+// a single trailing newline is removed from the output.
+// The command runs as an asynchronous task that the user can cancel.
+func FilterRegion(state *EditorState, region selection.Region, shellCmd string) {
+	setInputMode(state, InputModeNormal)
+	if err := checkEditable(state.documentBuffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return
+	}
+
+	log.Printf("Filtering region %v through shell command: %q\n", region, shellCmd)
+	input := copyText(state.documentBuffer.textTree, region.StartPos, region.EndPos-region.StartPos)
+	env := envVars(state)
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		output, err := shellcmd.RunFilter(ctx, shellCmd, env, input)
+		return func(state *EditorState) {
+			if err != nil {
+				setStatusForShellCmdResult(state, err)
+				return
+			}
+
+			if !strings.HasSuffix(input, "\n") {
+				output = strings.TrimSuffix(output, "\n")
+			}
+
+			checkpoint := beginUndoCheckpoint(state, "shell command filter")
+			if err := replaceFilteredRegion(state, region, input, output); err != nil {
+				SetStatusMsg(state, StatusMsg{
+					Style: StatusMsgStyleError,
+					Text:  err.Error(),
+				})
+				return
+			}
+
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  "Filtered selection through shell command",
+			})
+			commitUndoCheckpoint(state, checkpoint)
+		}
+	})
+}
+
+// replaceFilteredRegion replaces the text in the region with the output of a filter.
+// The replacement is a single undo entry, so undo restores the original text.
+func replaceFilteredRegion(state *EditorState, region selection.Region, input string, output string) error {
+	buffer := state.documentBuffer
+	numChars := region.EndPos - region.StartPos
+	if region.EndPos > buffer.textTree.NumChars() || copyText(buffer.textTree, region.StartPos, numChars) != input {
+		// The document was reloaded while the command was running, so the region no longer contains the input.
+		return errors.New("Document changed while the shell command was running")
+	}
+
+	BeginUndoEntry(state)
+	_, err := replaceRunes(state, region.StartPos, numChars, output, true)
+	CommitUndoEntry(state)
+	if err != nil {
+		return fmt.Errorf("Could not replace selection: %w", err)
+	}
+
+	MoveCursor(state, func(LocatorParams) uint64 { return region.StartPos })
+	return nil
+}
+
 func insertShellCmdOutput(state *EditorState, shellCmdOutput string) {
 	page := clipboard.PageContent{Text: shellCmdOutput}
+	if state.documentBuffer.selector.Mode() == selection.ModeLine {
+		// The output replaces whole lines, so a final newline would leave an extra blank line.
+		page.Text = strings.TrimSuffix(page.Text, "\n")
+	}
 	state.clipboard.Set(clipboard.PageShellCmdOutput, page)
 
 	BeginUndoEntry(state)
@@ -247,6 +357,31 @@ func showWorkingDirMenuForShellCmdOutput(state *EditorState, shellCmdOutput stri
 	return nil
 }
 
+func showSubmenuForShellCmdOutput(state *EditorState, shellCmdOutput string, submenuShellCmd string, submenuMode string) error {
+	var menuItems []menu.Item
+	for _, line := range strings.Split(shellCmdOutput, "\n") {
+		item := strings.TrimRight(line, "\r") // If output is CRLF, strip the CR as well.
+		if len(item) == 0 {
+			continue
+		}
+
+		menuItems = append(menuItems, menu.Item{
+			Name: item,
+			Action: func(s *EditorState) {
+				env := append(envVars(s), fmt.Sprintf("ITEM=%s", item))
+				runShellCmdWithEnv(s, submenuShellCmd, submenuMode, env)
+			},
+		})
+	}
+
+	if len(menuItems) == 0 {
+		return fmt.Errorf("No lines in command output")
+	}
+
+	ShowMenu(state, MenuStyleSubmenu, menuItems)
+	return nil
+}
+
 func showFileLocationsMenuForShellCmdOutput(state *EditorState, shellCmdOutput string) error {
 	locations, err := shellcmd.FileLocationsFromLines(strings.NewReader(shellCmdOutput))
 	if err != nil {
@@ -280,11 +415,8 @@ func menuItemsFromFileLocations(locations []shellcmd.FileLocation) ([]menu.Item,
 		menuItems = append(menuItems, menu.Item{
 			Name: name,
 			Action: func(s *EditorState) {
-				abortMsg := "Document has unsaved changes"
-				AbortIfUnsavedChanges(s, abortMsg, func(s *EditorState) {
-					LoadDocument(s, path, true, func(p LocatorParams) uint64 {
-						return locate.StartOfLineNum(p.TextTree, lineNum)
-					})
+				LoadDocument(s, path, true, func(p LocatorParams) uint64 {
+					return locate.StartOfLineNum(p.TextTree, lineNum)
 				})
 			},
 		})
@@ -306,6 +306,16 @@ func TestRunShellCmdInsertIntoDocumentWithSelection(t *testing.T) {
 			expectedCursorPos: 14,
 			expectedText:      "foo\nhello world\nbat",
 		},
+		{
+			name:              "linewise selection with trailing newline in output",
+			documentText:      "foo\nbar\nbaz\nbat",
+			insertedText:      "hello world\n",
+			selectionMode:     selection.ModeLine,
+			cursorStartPos:    5,
+			cursorEndPos:      9,
+			expectedCursorPos: 14,
+			expectedText:      "foo\nhello world\nbat",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -411,6 +421,125 @@ func TestRunShellCmdWorkingDirMenu(t *testing.T) {
 	})
 }
 
+func TestRunShellCmdWithSubmenu(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		// Run a command that outputs two lines.
+		RunShellCmdWithSubmenu(state, "printf 'abc\nxyz'", `printf "item: $ITEM"`, config.CmdModeInsert)
+		select {
+		case action := <-state.TaskResultChan():
+			action(state)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "Timed out")
+		}
+
+		// Verify that the submenu loads with the two lines.
+		assert.Equal(t, InputModeMenu, state.InputMode())
+		assert.Equal(t, MenuStyleSubmenu, state.Menu().Style())
+		menuItems, _ := state.Menu().SearchResults()
+		require.Equal(t, 2, len(menuItems))
+		assert.Equal(t, "abc", menuItems[0].Name)
+		assert.Equal(t, "xyz", menuItems[1].Name)
+
+		// Select the second item, which runs the submenu command with $ITEM set.
+		MoveMenuSelection(state, 1)
+		ExecuteSelectedMenuItem(state)
+		select {
+		case action := <-state.TaskResultChan():
+			action(state)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "Timed out")
+		}
+		assert.Equal(t, "item: xyz", state.documentBuffer.textTree.String())
+	})
+}
+
+func TestRunShellCmdScratch(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		runShellCmdAndApplyAction(t, state, `printf "foo.go:1:abc\nbar.go:2:def\n"`, config.CmdModeScratch)
+		assert.True(t, state.documentBuffer.IsScratch())
+		assert.Equal(t, "foo.go:1:abc\nbar.go:2:def", state.documentBuffer.textTree.String())
+		assert.Equal(t, `[scratch] printf "foo.go:1:abc\nbar.go:2:def\n"`, state.fileWatcher.Path())
+		assert.Equal(t, "Opened scratch buffer with 2 line(s)", state.StatusMsg().Text)
+	})
+}
+
+func TestFilterRegion(t *testing.T) {
+	testCases := []struct {
+		name           string
+		initialText    string
+		region         selection.Region
+		shellCmd       string
+		expectedText   string
+		expectedCursor uint64
+	}{
+		{
+			name:           "replace part of a line",
+			initialText:    "abc def ghi",
+			region:         selection.Region{StartPos: 4, EndPos: 7},
+			shellCmd:       "tr a-z A-Z",
+			expectedText:   "abc DEF ghi",
+			expectedCursor: 4,
+		},
+		{
+			name:           "keep trailing newline when input ends with newline",
+			initialText:    "c\nb\na\nx",
+			region:         selection.Region{StartPos: 0, EndPos: 6},
+			shellCmd:       "sort",
+			expectedText:   "a\nb\nc\nx",
+			expectedCursor: 0,
+		},
+		{
+			name:           "output with multiple lines",
+			initialText:    "[1,2]",
+			region:         selection.Region{StartPos: 0, EndPos: 5},
+			shellCmd:       "tr -d '[]' | tr , '\\n'",
+			expectedText:   "1\n2",
+			expectedCursor: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupShellCmdTest(t, func(state *EditorState, dir string) {
+				InsertText(state, tc.initialText)
+				FilterRegion(state, tc.region, tc.shellCmd)
+
+				select {
+				case action := <-state.TaskResultChan():
+					action(state)
+				case <-time.After(5 * time.Second):
+					require.Fail(t, "Timed out")
+				}
+
+				assert.Equal(t, tc.expectedText, state.documentBuffer.textTree.String())
+				assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor.position)
+				assert.Equal(t, InputModeNormal, state.InputMode())
+
+				// Undo restores the original text in a single step.
+				Undo(state)
+				assert.Equal(t, tc.initialText, state.documentBuffer.textTree.String())
+			})
+		})
+	}
+}
+
+func TestFilterRegionCommandFailed(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		InsertText(state, "abc")
+		FilterRegion(state, selection.Region{StartPos: 0, EndPos: 3}, "exit 1")
+
+		select {
+		case action := <-state.TaskResultChan():
+			action(state)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "Timed out")
+		}
+
+		assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+		assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	})
+}
+
 func setupShellCmdTest(t *testing.T, f func(*EditorState, string)) {
 	oldShellEnv := os.Getenv("SHELL")
 	defer os.Setenv("SHELL", oldShellEnv)
@@ -0,0 +1,589 @@
+package state
+
+import (
+	"slices"
+)
+
+// minSplitSize is the smallest number of rows or columns for a split,
+// which is enough for one row or column of the document and the split's title bar or border.
+const minSplitSize = 2
+
+// splitAxis is the direction in which a split container arranges its children.
+type splitAxis int
+
+const (
+	splitAxisRows = splitAxis(iota) // Children are stacked from top to bottom.
+	splitAxisCols                   // Children are placed side by side from left to right.
+)
+
+// splitNode is a node in the tree of splits that divide the screen.
+// A leaf shows one open buffer, and a container divides its area among its children.
+// Each open buffer is shown in at most one split, since the cursor and view belong to the buffer.
+type splitNode struct {
+	parent   *splitNode
+	axis     splitAxis    // Direction of the children, for containers only.
+	children []*splitNode // Empty for leaves.
+	size     uint64       // Rows or columns along the parent's axis, from the last layout.
+	buffer   *BufferState // Buffer shown in a leaf, or nil for the focused leaf, which shows the active document.
+}
+
+func (n *splitNode) isLeaf() bool {
+	return len(n.children) == 0
+}
+
+// leaves returns the leaves under a node, from top to bottom and left to right.
+func (n *splitNode) leaves() []*splitNode {
+	if n.isLeaf() {
+		return []*splitNode{n}
+	}
+	var leaves []*splitNode
+	for _, child := range n.children {
+		leaves = append(leaves, child.leaves()...)
+	}
+	return leaves
+}
+
+// splitRect is the area of the screen occupied by a split, including its title bar and border.
+type splitRect struct {
+	leaf                *splitNode
+	x, y, width, height uint64
+}
+
+// SplitView describes a split for display.
+type SplitView struct {
+	Buffer              *BufferState
+	Name                string // Name of the buffer, marked with "[+]" if it has unsaved changes.
+	X, Y, Width, Height uint64 // Area of the screen for the split, including the title bar in the last row.
+	Focused             bool
+	Zoomed              bool
+}
+
+// SplitViews returns the splits to display, or nil if the active document fills the screen.
+// The document view of each split starts at the top left corner of its area.
+// If the document view is narrower than the split, the last column is a border.
+func (s *EditorState) SplitViews() []SplitView {
+	if s.splitRoot == nil {
+		return nil
+	}
+
+	var openBufferPaths map[*BufferState]string
+	views := make([]SplitView, 0, len(s.splitLayout))
+	for _, rect := range s.splitLayout {
+		var buffer *BufferState
+		var path string
+		if rect.leaf == s.focusedSplit {
+			buffer, path = s.documentBuffer, s.fileWatcher.Path()
+		} else {
+			if openBufferPaths == nil {
+				openBufferPaths = make(map[*BufferState]string, len(s.openBuffers))
+				for _, b := range s.openBuffers {
+					openBufferPaths[b.buffer] = b.watcher.Path()
+				}
+			}
+			buffer, path = rect.leaf.buffer, openBufferPaths[rect.leaf.buffer]
+		}
+
+		name := bufferDisplayName(buffer, path)
+		if rect.leaf == s.focusedSplit && s.changesView != nil {
+			// The changes view replaces the active document in the focused split.
+			buffer = s.changesView.buffer
+		}
+
+		views = append(views, SplitView{
+			Buffer:  buffer,
+			Name:    name,
+			X:       rect.x,
+			Y:       rect.y,
+			Width:   rect.width,
+			Height:  rect.height,
+			Focused: rect.leaf == s.focusedSplit,
+			Zoomed:  s.splitZoomed,
+		})
+	}
+	return views
+}
+
+// SplitHorizontal divides the focused split into two splits, one above the other.
+func SplitHorizontal(state *EditorState) {
+	splitFocused(state, splitAxisRows)
+}
+
+// SplitVertical divides the focused split into two splits, side by side.
+func SplitVertical(state *EditorState) {
+	splitFocused(state, splitAxisCols)
+}
+
+// splitFocused divides the focused split along an axis.
+// The new split shows the next open buffer that isn't already shown in a split, and receives the focus.
+func splitFocused(state *EditorState, axis splitAxis) {
+	idx := nextHiddenBufferIdx(state)
+	if idx < 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No other buffers are open to show in a new split",
+		})
+		return
+	}
+
+	rect := focusedSplitRect(state)
+	total := rect.height
+	if axis == splitAxisCols {
+		total = rect.width
+	}
+	if total < 2*minSplitSize {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Not enough room for another split",
+		})
+		return
+	}
+
+	if state.splitRoot == nil {
+		state.splitRoot = &splitNode{}
+		state.focusedSplit = state.splitRoot
+	}
+	state.splitZoomed = false
+
+	focused := state.focusedSplit
+	parent := focused.parent
+	if parent == nil || parent.axis != axis {
+		// Replace the focused leaf with a container, so the new split can be its sibling.
+		container := &splitNode{parent: parent, axis: axis, size: focused.size}
+		replaceSplitNode(state, focused, container)
+		container.children = []*splitNode{focused}
+		focused.parent = container
+		focused.size = total
+		parent = container
+	}
+
+	newLeaf := &splitNode{
+		parent: parent,
+		size:   focused.size / 2,
+		buffer: state.openBuffers[idx].buffer,
+	}
+	focused.size -= newLeaf.size
+	i := slices.Index(parent.children, focused)
+	parent.children = slices.Insert(parent.children, i+1, newLeaf)
+
+	activateOpenBuffer(state, idx)
+}
+
+// nextHiddenBufferIdx returns the index of the next open buffer after the active document
+// that isn't shown in a split, or -1 if every open buffer is shown.
+func nextHiddenBufferIdx(state *EditorState) int {
+	n := len(state.openBuffers)
+	activeIdx := activeBufferIdx(state)
+	for offset := 1; offset < n; offset++ {
+		idx := (activeIdx + offset) % n
+		if splitShowingBuffer(state, state.openBuffers[idx].buffer) == nil {
+			return idx
+		}
+	}
+	return -1
+}
+
+// splitShowingBuffer returns the unfocused split that shows a buffer, or nil if no split shows it.
+func splitShowingBuffer(state *EditorState, buffer *BufferState) *splitNode {
+	if state.splitRoot == nil {
+		return nil
+	}
+	for _, leaf := range state.splitRoot.leaves() {
+		if leaf != state.focusedSplit && leaf.buffer == buffer {
+			return leaf
+		}
+	}
+	return nil
+}
+
+// focusedSplitRect returns the area of the focused split, or the whole screen if the screen isn't split.
+func focusedSplitRect(state *EditorState) splitRect {
+	for _, rect := range state.splitLayout {
+		if rect.leaf == state.focusedSplit {
+			return rect
+		}
+	}
+	return splitRect{width: state.screenWidth, height: documentAreaHeight(state)}
+}
+
+// documentAreaHeight returns the number of rows for documents, leaving one row for the status bar.
+func documentAreaHeight(state *EditorState) uint64 {
+	if state.screenHeight == 0 {
+		return 0
+	}
+	return state.screenHeight - 1
+}
+
+// replaceSplitNode puts a new node in the place of an old node in the split tree.
+func replaceSplitNode(state *EditorState, oldNode, newNode *splitNode) {
+	parent := oldNode.parent
+	newNode.parent = parent
+	if parent == nil {
+		state.splitRoot = newNode
+		return
+	}
+	i := slices.Index(parent.children, oldNode)
+	parent.children[i] = newNode
+}
+
+// removeSplitLeaf removes a leaf from the split tree, giving its rows or columns to a neighboring split.
+// If only one split remains, the screen is no longer split.
+func removeSplitLeaf(state *EditorState, leaf *splitNode) {
+	parent := leaf.parent
+	if parent == nil {
+		state.splitRoot = nil
+		state.focusedSplit = nil
+		state.splitZoomed = false
+		return
+	}
+
+	i := slices.Index(parent.children, leaf)
+	parent.children = slices.Delete(parent.children, i, i+1)
+	neighbor := parent.children[max(i-1, 0)]
+	neighbor.size += leaf.size
+
+	if len(parent.children) == 1 {
+		// The container is no longer needed, so the remaining child takes its place.
+		child := parent.children[0]
+		child.size = parent.size
+		replaceSplitNode(state, parent, child)
+		if grandparent := child.parent; grandparent != nil && !child.isLeaf() && child.axis == grandparent.axis {
+			// Merge the child's children into the grandparent, since they are arranged along the same axis.
+			j := slices.Index(grandparent.children, child)
+			for _, c := range child.children {
+				c.parent = grandparent
+			}
+			grandparent.children = slices.Replace(grandparent.children, j, j+1, child.children...)
+		}
+	}
+
+	if state.splitRoot.isLeaf() {
+		state.splitRoot = nil
+		state.focusedSplit = nil
+		state.splitZoomed = false
+	}
+}
+
+// pruneSplits removes splits that show a buffer that was closed or that became the active document,
+// so each open buffer is shown in at most one split.
+func pruneSplits(state *EditorState) {
+	if state.splitRoot == nil {
+		return
+	}
+
+	open := make(map[*BufferState]struct{}, len(state.openBuffers))
+	for _, b := range state.openBuffers {
+		open[b.buffer] = struct{}{}
+	}
+
+	for _, leaf := range state.splitRoot.leaves() {
+		if leaf == state.focusedSplit {
+			continue
+		}
+		if _, ok := open[leaf.buffer]; !ok || leaf.buffer == state.documentBuffer {
+			removeSplitLeaf(state, leaf)
+			if state.splitRoot == nil {
+				return
+			}
+		}
+	}
+}
+
+// layoutSplits assigns an area of the screen to each split and resizes the view of each buffer shown in a split.
+// If the screen isn't split, the active document fills the screen, except for the status bar.
+func layoutSplits(state *EditorState) {
+	pruneSplits(state)
+	defer resizeChangesView(state)
+
+	width, height := state.screenWidth, documentAreaHeight(state)
+	if state.splitRoot == nil {
+		state.splitLayout = nil
+		state.documentBuffer.view.width = width
+		state.documentBuffer.view.height = height
+		return
+	}
+
+	if state.splitZoomed {
+		state.splitLayout = []splitRect{{leaf: state.focusedSplit, width: width, height: height}}
+	} else {
+		state.splitLayout = layoutSplitNode(nil, state.splitRoot, 0, 0, width, height)
+	}
+
+	for _, rect := range state.splitLayout {
+		buffer := rect.leaf.buffer
+		if rect.leaf == state.focusedSplit {
+			buffer = state.documentBuffer
+		}
+
+		// Leave the last row for the title bar, and the last column for a border if another split is to the right.
+		viewWidth, viewHeight := rect.width, uint64(0)
+		if rect.height > 0 {
+			viewHeight = rect.height - 1
+		}
+		if rect.x+rect.width < width && viewWidth > 0 {
+			viewWidth--
+		}
+
+		if buffer.view.width != viewWidth || buffer.view.height != viewHeight {
+			buffer.view.width = viewWidth
+			buffer.view.height = viewHeight
+			scrollViewToPosition(buffer, buffer.cursor.position)
+		}
+	}
+}
+
+// resizeChangesView makes the changes view the same size as the active document, which it replaces on the screen.
+func resizeChangesView(state *EditorState) {
+	if state.changesView != nil {
+		state.changesView.buffer.view.width = state.documentBuffer.view.width
+		state.changesView.buffer.view.height = state.documentBuffer.view.height
+	}
+}
+
+// layoutSplitNode appends the area of each leaf under a node to rects.
+// The size of each child is updated to the rows or columns it occupies.
+func layoutSplitNode(rects []splitRect, node *splitNode, x, y, width, height uint64) []splitRect {
+	if node.isLeaf() {
+		return append(rects, splitRect{leaf: node, x: x, y: y, width: width, height: height})
+	}
+
+	total := height
+	if node.axis == splitAxisCols {
+		total = width
+	}
+
+	sizes := distributeSplitSizes(node.children, total)
+	for i, child := range node.children {
+		child.size = sizes[i]
+		if node.axis == splitAxisRows {
+			rects = layoutSplitNode(rects, child, x, y, width, child.size)
+			y += child.size
+		} else {
+			rects = layoutSplitNode(rects, child, x, y, child.size, height)
+			x += child.size
+		}
+	}
+	return rects
+}
+
+// distributeSplitSizes divides total rows or columns among splits in proportion to their sizes.
+// Each split receives at least minSplitSize if there is enough room.
+func distributeSplitSizes(splits []*splitNode, total uint64) []uint64 {
+	var sum uint64
+	for _, s := range splits {
+		sum += s.size
+	}
+
+	sizes := make([]uint64, len(splits))
+	var used uint64
+	for i, s := range splits {
+		if sum > 0 {
+			sizes[i] = s.size * total / sum
+		} else {
+			sizes[i] = total / uint64(len(splits))
+		}
+		used += sizes[i]
+	}
+
+	// Give any rows or columns left over from rounding to the last split.
+	sizes[len(sizes)-1] += total - used
+
+	// Take from the largest splits to bring small splits up to the minimum size.
+	for i := range sizes {
+		for sizes[i] < minSplitSize {
+			j := 0
+			for k := range sizes {
+				if sizes[k] > sizes[j] {
+					j = k
+				}
+			}
+			if sizes[j] <= minSplitSize {
+				return sizes
+			}
+			sizes[j]--
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+// FocusNextSplit moves the focus to the next split, wrapping around to the first split.
+func FocusNextSplit(state *EditorState) {
+	cycleSplitFocus(state, 1)
+}
+
+// FocusPrevSplit moves the focus to the previous split, wrapping around to the last split.
+func FocusPrevSplit(state *EditorState) {
+	cycleSplitFocus(state, -1)
+}
+
+func cycleSplitFocus(state *EditorState, offset int) {
+	if !requireSplitScreen(state) {
+		return
+	}
+	leaves := state.splitRoot.leaves()
+	i := slices.Index(leaves, state.focusedSplit)
+	n := len(leaves)
+	focusSplit(state, leaves[(i+offset+n)%n])
+}
+
+// focusSplit moves the focus to another split, which activates the buffer shown in the split.
+func focusSplit(state *EditorState, leaf *splitNode) {
+	if leaf == state.focusedSplit {
+		return
+	}
+	idx := slices.IndexFunc(state.openBuffers, func(b openBuffer) bool {
+		return b.buffer == leaf.buffer
+	})
+	activateOpenBuffer(state, idx)
+}
+
+// moveSplitFocusToBuffer moves the focus to the split showing a buffer, if any.
+// The previously focused split keeps showing the active document.
+func moveSplitFocusToBuffer(state *EditorState, buffer *BufferState) {
+	leaf := splitShowingBuffer(state, buffer)
+	if leaf == nil {
+		return
+	}
+	state.focusedSplit.buffer = state.documentBuffer
+	leaf.buffer = nil
+	state.focusedSplit = leaf
+	state.splitZoomed = false
+}
+
+// CloseSplit closes the focused split and moves the focus to a neighboring split.
+// The document shown in the closed split remains open in a buffer.
+func CloseSplit(state *EditorState) {
+	if !requireSplitScreen(state) {
+		return
+	}
+
+	leaf := state.focusedSplit
+	leaves := state.splitRoot.leaves()
+	next := slices.Index(leaves, leaf) - 1
+	if next < 0 {
+		next = 1
+	}
+	focusSplit(state, leaves[next])
+	removeSplitLeaf(state, leaf)
+	layoutSplits(state)
+}
+
+// CloseOtherSplits closes every split except the focused split, so the active document fills the screen.
+func CloseOtherSplits(state *EditorState) {
+	if !requireSplitScreen(state) {
+		return
+	}
+	state.splitRoot = nil
+	state.focusedSplit = nil
+	state.splitZoomed = false
+	layoutSplits(state)
+}
+
+// ToggleSplitZoom temporarily expands the focused split to fill the screen, or restores the other splits.
+func ToggleSplitZoom(state *EditorState) {
+	if !requireSplitScreen(state) {
+		return
+	}
+	state.splitZoomed = !state.splitZoomed
+	layoutSplits(state)
+}
+
+// EqualizeSplits gives every split the same number of rows or columns as its siblings.
+func EqualizeSplits(state *EditorState) {
+	if !requireSplitScreen(state) {
+		return
+	}
+	state.splitZoomed = false
+	equalizeSplitNode(state.splitRoot)
+	layoutSplits(state)
+}
+
+func equalizeSplitNode(node *splitNode) {
+	for _, child := range node.children {
+		child.size = 1
+		equalizeSplitNode(child)
+	}
+}
+
+// ResizeSplitHeight grows the focused split by delta rows, or shrinks it if delta is negative.
+// Rows are taken from or given to the splits above or below it.
+func ResizeSplitHeight(state *EditorState, delta int) {
+	resizeSplit(state, splitAxisRows, delta)
+}
+
+// ResizeSplitWidth grows the focused split by delta columns, or shrinks it if delta is negative.
+// Columns are taken from or given to the splits left or right of it.
+func ResizeSplitWidth(state *EditorState, delta int) {
+	resizeSplit(state, splitAxisCols, delta)
+}
+
+func resizeSplit(state *EditorState, axis splitAxis, delta int) {
+	if !requireSplitScreen(state) {
+		return
+	}
+
+	// Find the nearest container that arranges the focused split and its neighbors along the axis.
+	node := state.focusedSplit
+	for node.parent != nil && node.parent.axis != axis {
+		node = node.parent
+	}
+	parent := node.parent
+	if parent == nil {
+		msg := "There are no splits above or below this split"
+		if axis == splitAxisCols {
+			msg = "There are no splits left or right of this split"
+		}
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  msg,
+		})
+		return
+	}
+
+	state.splitZoomed = false
+	i := slices.Index(parent.children, node)
+	if delta > 0 {
+		// Take rows or columns from the following splits first, then the preceding splits.
+		need := uint64(delta)
+		order := make([]int, 0, len(parent.children)-1)
+		for j := i + 1; j < len(parent.children); j++ {
+			order = append(order, j)
+		}
+		for j := i - 1; j >= 0; j-- {
+			order = append(order, j)
+		}
+		for _, j := range order {
+			sibling := parent.children[j]
+			if sibling.size <= minSplitSize {
+				continue
+			}
+			take := min(sibling.size-minSplitSize, need)
+			sibling.size -= take
+			node.size += take
+			need -= take
+		}
+	} else if delta < 0 && node.size > minSplitSize {
+		// Give rows or columns to the following split, or the preceding split if this is the last.
+		give := min(uint64(-delta), node.size-minSplitSize)
+		node.size -= give
+		neighbor := i + 1
+		if neighbor == len(parent.children) {
+			neighbor = i - 1
+		}
+		parent.children[neighbor].size += give
+	}
+
+	layoutSplits(state)
+}
+
+// requireSplitScreen returns whether the screen is split, showing an error if it isn't.
+func requireSplitScreen(state *EditorState) bool {
+	if state.splitRoot == nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "The screen is not split",
+		})
+		return false
+	}
+	return true
+}
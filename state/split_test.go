@@ -0,0 +1,319 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// splitRectsForTest returns the area of each visible split as {x, y, width, height}.
+func splitRectsForTest(state *EditorState) [][4]uint64 {
+	var rects [][4]uint64
+	for _, v := range state.SplitViews() {
+		rects = append(rects, [4]uint64{v.X, v.Y, v.Width, v.Height})
+	}
+	return rects
+}
+
+// loadSplitTestDocuments opens a buffer for each text, leaving the first buffer active.
+func loadSplitTestDocuments(t *testing.T, state *EditorState, texts ...string) []string {
+	var paths []string
+	for _, s := range texts {
+		path, cleanup := createTestFile(t, s)
+		t.Cleanup(cleanup)
+		LoadDocument(state, path, true, startOfDocLocator)
+		paths = append(paths, path)
+	}
+	LoadDocument(state, paths[0], true, startOfDocLocator)
+	return paths
+}
+
+func TestSplitHorizontalAndVertical(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	paths := loadSplitTestDocuments(t, state, "abc", "def", "ghi")
+	assert.Nil(t, state.SplitViews())
+
+	// The new split is below, and shows the next open buffer.
+	SplitHorizontal(state)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 12}, {0, 12, 80, 12}}, splitRectsForTest(state))
+	assert.Equal(t, paths[1], state.fileWatcher.Path())
+	views := state.SplitViews()
+	assert.False(t, views[0].Focused)
+	assert.True(t, views[1].Focused)
+	assert.Equal(t, "abc", views[0].Buffer.textTree.String())
+	assert.Equal(t, "def", views[1].Buffer.textTree.String())
+
+	// Each view leaves a row for the title bar.
+	width, height := state.documentBuffer.ViewSize()
+	assert.Equal(t, uint64(80), width)
+	assert.Equal(t, uint64(11), height)
+
+	// Splitting vertically divides the focused split, leaving a column for the border.
+	SplitVertical(state)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 12}, {0, 12, 40, 12}, {40, 12, 40, 12}}, splitRectsForTest(state))
+	assert.Equal(t, paths[2], state.fileWatcher.Path())
+	width, _ = state.SplitViews()[1].Buffer.ViewSize()
+	assert.Equal(t, uint64(39), width)
+	width, _ = state.documentBuffer.ViewSize()
+	assert.Equal(t, uint64(40), width)
+
+	// Every buffer is shown, so there's nothing to show in another split.
+	SplitVertical(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "No other buffers are open to show in a new split", state.StatusMsg().Text)
+}
+
+func TestSplitWithOnlyOneBuffer(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	SplitHorizontal(state)
+	assert.Nil(t, state.SplitViews())
+	assert.Equal(t, "No other buffers are open to show in a new split", state.StatusMsg().Text)
+}
+
+func TestFocusNextAndPrevSplit(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	paths := loadSplitTestDocuments(t, state, "abc", "def", "ghi")
+	SplitVertical(state)
+	SplitVertical(state)
+	assert.Equal(t, paths[2], state.fileWatcher.Path())
+
+	FocusNextSplit(state)
+	assert.Equal(t, paths[0], state.fileWatcher.Path())
+	assert.True(t, state.SplitViews()[0].Focused)
+
+	FocusPrevSplit(state)
+	assert.Equal(t, paths[2], state.fileWatcher.Path())
+	assert.True(t, state.SplitViews()[2].Focused)
+
+	// The buffers stay in their splits when the focus moves.
+	views := state.SplitViews()
+	assert.Equal(t, "abc", views[0].Buffer.textTree.String())
+	assert.Equal(t, "def", views[1].Buffer.textTree.String())
+	assert.Equal(t, "ghi", views[2].Buffer.textTree.String())
+}
+
+func TestSwitchToBufferShownInSplit(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	paths := loadSplitTestDocuments(t, state, "abc", "def", "ghi")
+	SplitHorizontal(state)
+
+	// Opening a document shown in another split moves the focus to that split.
+	LoadDocument(state, paths[0], true, startOfDocLocator)
+	views := state.SplitViews()
+	require.Equal(t, 2, len(views))
+	assert.True(t, views[0].Focused)
+	assert.Equal(t, "abc", views[0].Buffer.textTree.String())
+	assert.Equal(t, "def", views[1].Buffer.textTree.String())
+
+	// Switching to a buffer that isn't shown replaces the document in the focused split.
+	LoadDocument(state, paths[2], true, startOfDocLocator)
+	views = state.SplitViews()
+	require.Equal(t, 2, len(views))
+	assert.True(t, views[0].Focused)
+	assert.Equal(t, "ghi", views[0].Buffer.textTree.String())
+	assert.Equal(t, "def", views[1].Buffer.textTree.String())
+}
+
+func TestCloseBufferShownInSplit(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	loadSplitTestDocuments(t, state, "abc", "def")
+	SplitHorizontal(state)
+
+	// The buffer that replaces the closed buffer was shown in the other split, so that split closes.
+	CloseBuffer(state)
+	assert.Nil(t, state.SplitViews())
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+	width, height := state.documentBuffer.ViewSize()
+	assert.Equal(t, uint64(80), width)
+	assert.Equal(t, uint64(24), height)
+}
+
+func TestCloseSplit(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	paths := loadSplitTestDocuments(t, state, "abc", "def", "ghi")
+	SplitHorizontal(state)
+	SplitVertical(state)
+
+	// The focus moves to the previous split, and the closed split's rows or columns go to its neighbor.
+	CloseSplit(state)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 12}, {0, 12, 80, 12}}, splitRectsForTest(state))
+	assert.Equal(t, paths[1], state.fileWatcher.Path())
+	assert.Equal(t, 3, state.NumOpenBuffers())
+
+	// Closing the last split leaves the active document filling the screen.
+	CloseSplit(state)
+	assert.Nil(t, state.SplitViews())
+	assert.Equal(t, paths[0], state.fileWatcher.Path())
+	_, height := state.documentBuffer.ViewSize()
+	assert.Equal(t, uint64(24), height)
+
+	CloseSplit(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "The screen is not split", state.StatusMsg().Text)
+}
+
+func TestCloseSplitMergesSameAxis(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	loadSplitTestDocuments(t, state, "abc", "def", "ghi", "jkl")
+
+	// Split into top and bottom, split the bottom into left and right,
+	// then split the bottom right into top and bottom.
+	SplitHorizontal(state)
+	SplitVertical(state)
+	SplitHorizontal(state)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 12}, {0, 12, 40, 12}, {40, 12, 40, 6}, {40, 18, 40, 6}}, splitRectsForTest(state))
+
+	// Closing the bottom left split leaves three rows in the same container.
+	FocusPrevSplit(state)
+	FocusPrevSplit(state)
+	CloseSplit(state)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 12}, {0, 12, 80, 6}, {0, 18, 80, 6}}, splitRectsForTest(state))
+	assert.Equal(t, splitAxisRows, state.splitRoot.axis)
+	assert.Equal(t, 3, len(state.splitRoot.children))
+	for _, child := range state.splitRoot.children {
+		assert.True(t, child.isLeaf())
+		assert.Equal(t, state.splitRoot, child.parent)
+	}
+}
+
+func TestCloseOtherSplits(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	paths := loadSplitTestDocuments(t, state, "abc", "def", "ghi")
+	SplitHorizontal(state)
+	SplitHorizontal(state)
+
+	CloseOtherSplits(state)
+	assert.Nil(t, state.SplitViews())
+	assert.Equal(t, paths[2], state.fileWatcher.Path())
+	assert.Equal(t, 3, state.NumOpenBuffers())
+	width, height := state.documentBuffer.ViewSize()
+	assert.Equal(t, uint64(80), width)
+	assert.Equal(t, uint64(24), height)
+}
+
+func TestResizeSplit(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	loadSplitTestDocuments(t, state, "abc", "def", "ghi")
+	SplitHorizontal(state)
+	SplitVertical(state)
+
+	// The bottom row is arranged in columns, so its height changes with the top split.
+	ResizeSplitHeight(state, 3)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 9}, {0, 9, 40, 15}, {40, 9, 40, 15}}, splitRectsForTest(state))
+
+	ResizeSplitHeight(state, -5)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 14}, {0, 14, 40, 10}, {40, 14, 40, 10}}, splitRectsForTest(state))
+
+	// The focused split is last, so its columns are taken from the split to its left.
+	ResizeSplitWidth(state, 10)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 14}, {0, 14, 30, 10}, {30, 14, 50, 10}}, splitRectsForTest(state))
+
+	ResizeSplitWidth(state, -20)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 14}, {0, 14, 50, 10}, {50, 14, 30, 10}}, splitRectsForTest(state))
+
+	// Splits keep a minimum size.
+	ResizeSplitHeight(state, 100)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 2}, {0, 2, 50, 22}, {50, 2, 30, 22}}, splitRectsForTest(state))
+	ResizeSplitWidth(state, -100)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 2}, {0, 2, 78, 22}, {78, 2, 2, 22}}, splitRectsForTest(state))
+}
+
+func TestResizeSplitWithoutNeighbor(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	loadSplitTestDocuments(t, state, "abc", "def")
+
+	ResizeSplitHeight(state, 1)
+	assert.Equal(t, "The screen is not split", state.StatusMsg().Text)
+
+	SplitHorizontal(state)
+	ResizeSplitWidth(state, 1)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, "There are no splits left or right of this split", state.StatusMsg().Text)
+}
+
+func TestEqualizeSplits(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	loadSplitTestDocuments(t, state, "abc", "def", "ghi")
+	SplitHorizontal(state)
+	SplitHorizontal(state)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 12}, {0, 12, 80, 6}, {0, 18, 80, 6}}, splitRectsForTest(state))
+
+	EqualizeSplits(state)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 8}, {0, 8, 80, 8}, {0, 16, 80, 8}}, splitRectsForTest(state))
+}
+
+func TestToggleSplitZoom(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	loadSplitTestDocuments(t, state, "abc", "def", "ghi")
+	SplitHorizontal(state)
+	SplitVertical(state)
+	ResizeSplitWidth(state, 5)
+
+	ToggleSplitZoom(state)
+	views := state.SplitViews()
+	require.Equal(t, 1, len(views))
+	assert.True(t, views[0].Focused)
+	assert.True(t, views[0].Zoomed)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 24}}, splitRectsForTest(state))
+	width, height := state.documentBuffer.ViewSize()
+	assert.Equal(t, uint64(80), width)
+	assert.Equal(t, uint64(23), height)
+
+	// Restoring the splits keeps the sizes from before the zoom.
+	ToggleSplitZoom(state)
+	assert.Equal(t, [][4]uint64{{0, 0, 80, 12}, {0, 12, 35, 12}, {35, 12, 45, 12}}, splitRectsForTest(state))
+
+	// Moving the focus to another split ends the zoom.
+	ToggleSplitZoom(state)
+	FocusNextSplit(state)
+	assert.Equal(t, 3, len(state.SplitViews()))
+}
+
+func TestResizeViewWithSplits(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	loadSplitTestDocuments(t, state, "abc", "def")
+	SplitVertical(state)
+	ResizeSplitWidth(state, 20)
+	assert.Equal(t, [][4]uint64{{0, 0, 20, 24}, {20, 0, 60, 24}}, splitRectsForTest(state))
+
+	// The splits keep their proportions.
+	ResizeView(state, 40, 11)
+	assert.Equal(t, [][4]uint64{{0, 0, 10, 10}, {10, 0, 30, 10}}, splitRectsForTest(state))
+	width, height := state.documentBuffer.ViewSize()
+	assert.Equal(t, uint64(30), width)
+	assert.Equal(t, uint64(9), height)
+}
+
+func TestChangesViewInFocusedSplit(t *testing.T) {
+	state := NewEditorState(80, 25, nil, nil)
+	defer Quit(state)
+	loadSplitTestDocuments(t, state, "abc", "def")
+	SplitVertical(state)
+	InsertText(state, "x")
+
+	ShowChangesSinceLoad(state)
+	require.Equal(t, InputModeChanges, state.InputMode())
+	ResizeView(state, 100, 30)
+	changesBuffer := state.ChangesViewBuffer()
+	for _, v := range state.SplitViews() {
+		if v.Focused {
+			assert.Equal(t, changesBuffer, v.Buffer)
+			assert.Equal(t, state.documentBuffer.view, changesBuffer.view)
+		} else {
+			assert.NotEqual(t, changesBuffer, v.Buffer)
+		}
+	}
+}
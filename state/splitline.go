@@ -0,0 +1,90 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/selection"
+)
+
+// SplitLineOnDelimiter breaks the cursor's line into one item per line, splitting after each occurrence of the delimiter.
+// This is the inverse of JoinLines: whitespace after each delimiter is removed,
+// and each new line is indented to match the original line.
+// If the delimiter ends with whitespace (for example, ", " or " "), that whitespace is removed from the end of each line.
+func SplitLineOnDelimiter(state *EditorState, delim string) error {
+	tree := state.documentBuffer.textTree
+	startPos := locate.StartOfLineAtPos(tree, state.documentBuffer.cursor.position)
+	endPos := locate.NextLineBoundary(tree, true, startPos)
+	return splitOnDelimiter(state, selection.Region{StartPos: startPos, EndPos: endPos}, delim)
+}
+
+// SplitRegionOnDelimiter splits the text in a region, such as a selection, the same way as SplitLineOnDelimiter.
+func SplitRegionOnDelimiter(state *EditorState, region selection.Region, delim string) error {
+	return splitOnDelimiter(state, region, delim)
+}
+
+func splitOnDelimiter(state *EditorState, region selection.Region, delim string) error {
+	buffer := state.documentBuffer
+	if err := checkEditable(buffer); err != nil {
+		return err
+	} else if delim == "" {
+		return errors.New("Missing delimiter")
+	}
+
+	// A linewise selection includes the newline at the end of the last line, which should stay where it is.
+	s := copyText(buffer.textTree, region.StartPos, region.EndPos-region.StartPos)
+	s = strings.TrimSuffix(s, "\n")
+	items := strings.Split(s, delim)
+	if len(items) < 2 {
+		return fmt.Errorf("Delimiter %q not found", delim)
+	}
+
+	lineStartPos := locate.StartOfLineAtPos(buffer.textTree, region.StartPos)
+	indent := lineIndentBeforePos(buffer.textTree, locate.NextLineBoundary(buffer.textTree, true, lineStartPos))
+
+	// Trailing whitespace in the delimiter would end up at the end of each line, so drop it.
+	lineEnd := strings.TrimRightFunc(delim, unicode.IsSpace)
+
+	var sb strings.Builder
+	numLines := 1
+	for i, item := range items {
+		if i > 0 {
+			item = strings.TrimLeftFunc(item, unicode.IsSpace)
+			if item == "" && i == len(items)-1 {
+				// Keep a trailing delimiter on the last item, instead of adding an empty line after it.
+				break
+			}
+			sb.WriteString("\n")
+			sb.WriteString(indent)
+			numLines++
+		}
+		sb.WriteString(item)
+		if i < len(items)-1 {
+			sb.WriteString(lineEnd)
+		}
+	}
+
+	if numLines < 2 {
+		return fmt.Errorf("Nothing to split after delimiter %q", delim)
+	}
+
+	setInputMode(state, InputModeNormal)
+	BeginUndoEntry(state)
+	_, err := replaceRunes(state, region.StartPos, uint64(utf8.RuneCountInString(s)), sb.String(), true)
+	CommitUndoEntry(state)
+	if err != nil {
+		return err
+	}
+
+	MoveCursor(state, func(LocatorParams) uint64 { return region.StartPos })
+	ScrollViewToCursor(state)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Split into %d lines", numLines),
+	})
+	return nil
+}
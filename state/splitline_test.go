@@ -0,0 +1,127 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/selection"
+)
+
+func TestSplitLineOnDelimiter(t *testing.T) {
+	testCases := []struct {
+		name           string
+		initialText    string
+		cursorPos      uint64
+		delim          string
+		expectedText   string
+		expectedCursor uint64
+		expectedStatus string
+	}{
+		{
+			name:           "comma-separated items",
+			initialText:    "a, b, c",
+			delim:          ",",
+			expectedText:   "a,\nb,\nc",
+			expectedStatus: "Split into 3 lines",
+		},
+		{
+			name:           "indented line",
+			initialText:    "x\n\tf(a, b)\ny",
+			cursorPos:      5,
+			delim:          ",",
+			expectedText:   "x\n\tf(a,\n\tb)\ny",
+			expectedCursor: 2,
+			expectedStatus: "Split into 2 lines",
+		},
+		{
+			name:           "trailing delimiter",
+			initialText:    "a;b;",
+			delim:          ";",
+			expectedText:   "a;\nb;",
+			expectedStatus: "Split into 2 lines",
+		},
+		{
+			name:           "multi-character delimiter",
+			initialText:    "a && b && c",
+			delim:          " &&",
+			expectedText:   "a &&\nb &&\nc",
+			expectedStatus: "Split into 3 lines",
+		},
+		{
+			name:           "delimiter with trailing space",
+			initialText:    "a, b, c",
+			delim:          ", ",
+			expectedText:   "a,\nb,\nc",
+			expectedStatus: "Split into 3 lines",
+		},
+		{
+			name:           "delimiter not found",
+			initialText:    "a b c",
+			delim:          ",",
+			expectedText:   "a b c",
+			expectedStatus: `Delimiter "," not found`,
+		},
+		{
+			name:           "nothing after delimiter",
+			initialText:    "a,",
+			delim:          ",",
+			expectedText:   "a,",
+			expectedStatus: `Nothing to split after delimiter ","`,
+		},
+		{
+			name:           "missing delimiter",
+			initialText:    "a,b",
+			delim:          "",
+			expectedText:   "a,b",
+			expectedStatus: "Missing delimiter",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := NewEditorState(100, 100, nil, nil)
+			InsertText(state, tc.initialText)
+			MoveCursor(state, func(LocatorParams) uint64 { return tc.cursorPos })
+
+			err := SplitLineOnDelimiter(state, tc.delim)
+			if err != nil {
+				assert.EqualError(t, err, tc.expectedStatus)
+			} else {
+				assert.Equal(t, tc.expectedStatus, state.StatusMsg().Text)
+			}
+			assert.Equal(t, tc.expectedText, state.documentBuffer.textTree.String())
+			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor.position)
+		})
+	}
+}
+
+func TestSplitRegionOnDelimiter(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "  call(a, b, c)\nz")
+	err := SplitRegionOnDelimiter(state, selection.Region{StartPos: 7, EndPos: 14}, ",")
+	require.NoError(t, err)
+	assert.Equal(t, "  call(a,\n  b,\n  c)\nz", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(7), state.documentBuffer.cursor.position)
+
+	// Joining the lines restores the original text.
+	JoinLines(state)
+	JoinLines(state)
+	assert.Equal(t, "  call(a, b, c)\nz", state.documentBuffer.textTree.String())
+}
+
+func TestSplitLinewiseSelectionThenUndo(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, "a b c\nd")
+	MoveCursor(state, func(LocatorParams) uint64 { return 0 })
+	ToggleVisualMode(state, selection.ModeLine)
+
+	err := SplitRegionOnDelimiter(state, state.documentBuffer.SelectedRegion(), " ")
+	require.NoError(t, err)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "a\nb\nc\nd", state.documentBuffer.textTree.String())
+
+	Undo(state)
+	assert.Equal(t, "a b c\nd", state.documentBuffer.textTree.String())
+}
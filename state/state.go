@@ -1,12 +1,15 @@
 package state
 
 import (
+	"math"
+
 	"github.com/aretext/aretext/cellwidth"
 	"github.com/aretext/aretext/clipboard"
 	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/menu"
 	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/session"
 	"github.com/aretext/aretext/syntax"
 	"github.com/aretext/aretext/syntax/parser"
 	"github.com/aretext/aretext/text"
@@ -18,19 +21,59 @@ import (
 type EditorState struct {
 	screenWidth, screenHeight uint64
 	configRuleSet             config.RuleSet
+	configLoader              ConfigLoaderFunc // Reloads the config rule set, or nil if reloading is not supported.
+	configApplyCount          int
+	bellCount                 int
 	documentLoadCount         int
 	inputMode                 InputMode
 	documentBuffer            *BufferState
+	openBuffers               []openBuffer // All open documents, including the active document, in the order they were opened.
+	splitRoot                 *splitNode   // Splits dividing the screen, or nil if the active document fills the screen.
+	focusedSplit              *splitNode   // Split showing the active document, or nil if the screen isn't split.
+	splitLayout               []splitRect  // Area of each visible split, from the last layout.
+	splitZoomed               bool         // If true, the focused split temporarily fills the screen.
 	clipboard                 *clipboard.C
 	fileWatcher               *file.Watcher
 	fileTimeline              *file.Timeline
 	menu                      *MenuState
+	changesView               *changesViewState
 	textfield                 *TextFieldState
 	task                      *TaskState
+	autocomplete              *AutocompleteState // Nil unless in autocomplete mode.
+	fileExplorer              *FileExplorerState // Nil unless the file explorer is open.
+	outputCmd                 *outputCmdState    // Shell command running in the background, or nil.
+	pendingLoad               *pendingLoadState
+	lineChangeUpdate          *lineChangeUpdateState
 	macroState                MacroState
 	customMenuItems           []menu.Item
+	pluginRegistry            *PluginRegistry
 	hidePatterns              []string
 	styles                    map[string]config.StyleConfig
+	createParentDirs          bool
+	replaceSymlinks           bool // If true, saving a document opened through a symlink replaces the link.
+	logPath                   string
+	configPath                string
+	longLineThreshold         uint64
+	eventHook                 string // Shell command to run on events, or empty to disable.
+	bell                      string
+	timestampFormats          []string          // Go time layouts for inserted timestamps.
+	menuPinnedCommands        []string          // Names of commands shown first in the command menu.
+	menuHiddenCommands        []string          // Names of commands hidden from the command menu.
+	keyRemap                  map[rune]rune     // Keys remapped in normal and visual mode, or nil.
+	persistScratch            bool              // If true, named scratch buffers are saved to the scratch store.
+	lineWrapChoices           map[string]bool   // Whether the user enabled line wrap for a document path.
+	scratchBuffers            map[string]string // Contents of named scratch buffers, updated when switching away from them.
+	pagerMode                 bool              // If true, every document is read-only and "q" quits.
+	session                   *session.Session  // Shared with other editor instances, or nil if not in a session.
+	frecencyStore             *file.FrecencyStore
+	bookmarkStore             *file.BookmarkStore
+	textFormatStore           *file.TextFormatStore
+	backupStore               *file.BackupStore
+	scratchStore              *file.ScratchStore
+	trash                     *file.Trash
+	lastTrashedFile           *file.TrashedFile // Most recently trashed file that can be restored, or nil.
+	lockStore                 *file.LockStore
+	lockedPaths               map[string]struct{} // Paths locked in the lock store because they have unsaved changes.
 	statusMsg                 StatusMsg
 	suspendScreenFunc         SuspendScreenFunc
 	quitFlag                  bool
@@ -43,32 +86,14 @@ func NewEditorState(screenWidth, screenHeight uint64, configRuleSet config.RuleS
 		documentBufferHeight = screenHeight - 1
 	}
 
-	buffer := &BufferState{
-		textTree: text.NewTree(),
-		cursor:   cursorState{},
-		selector: &selection.Selector{},
-		view: viewState{
-			textOrigin: 0,
-			width:      screenWidth,
-			height:     documentBufferHeight,
-		},
-		search:         searchState{},
-		undoLog:        undo.NewLog(),
-		syntaxLanguage: syntax.LanguagePlaintext,
-		syntaxParser:   nil,
-		lineNumberMode: config.DefaultLineNumberMode,
-		tabSize:        uint64(config.DefaultTabSize),
-		tabExpand:      config.DefaultTabExpand,
-		showSpaces:     config.DefaultShowSpaces,
-		showTabs:       config.DefaultShowTabs,
-		autoIndent:     config.DefaultAutoIndent,
-	}
+	buffer := newBufferState(screenWidth, documentBufferHeight)
 
 	return &EditorState{
 		screenWidth:       screenWidth,
 		screenHeight:      screenHeight,
 		configRuleSet:     configRuleSet,
 		documentBuffer:    buffer,
+		openBuffers:       []openBuffer{{buffer: buffer}},
 		clipboard:         clipboard.New(),
 		fileWatcher:       file.NewEmptyWatcher(),
 		fileTimeline:      file.NewTimeline(),
@@ -76,6 +101,8 @@ func NewEditorState(screenWidth, screenHeight uint64, configRuleSet config.RuleS
 		textfield:         &TextFieldState{},
 		customMenuItems:   nil,
 		hidePatterns:      nil,
+		lineWrapChoices:   make(map[string]bool),
+		scratchBuffers:    make(map[string]string),
 		statusMsg:         StatusMsg{},
 		styles:            nil,
 		suspendScreenFunc: suspendScreenFunc,
@@ -90,6 +117,11 @@ func (s *EditorState) DocumentLoadCount() int {
 	return s.documentLoadCount
 }
 
+// ConfigApplyCount is incremented each time a new configuration is applied to the open document.
+func (s *EditorState) ConfigApplyCount() int {
+	return s.configApplyCount
+}
+
 func (s *EditorState) SetScreenSize(width, height uint64) {
 	s.screenWidth = width
 	s.screenHeight = height
@@ -103,6 +135,15 @@ func (s *EditorState) DocumentBuffer() *BufferState {
 	return s.documentBuffer
 }
 
+// ChangesViewBuffer returns the buffer showing changes to the document.
+// This is nil unless the editor is in changes mode.
+func (s *EditorState) ChangesViewBuffer() *BufferState {
+	if s.changesView == nil {
+		return nil
+	}
+	return s.changesView.buffer
+}
+
 func (s *EditorState) Menu() *MenuState {
 	return s.menu
 }
@@ -111,6 +152,16 @@ func (s *EditorState) TextField() *TextFieldState {
 	return s.textfield
 }
 
+func (s *EditorState) Autocomplete() *AutocompleteState {
+	return s.autocomplete
+}
+
+// FileExplorer returns the file explorer, or nil if it is not open.
+// The explorer stays open while a text field prompts for a file path from it.
+func (s *EditorState) FileExplorer() *FileExplorerState {
+	return s.fileExplorer
+}
+
 func (s *EditorState) TaskResultChan() chan func(*EditorState) {
 	if s.task == nil {
 		return nil
@@ -118,6 +169,16 @@ func (s *EditorState) TaskResultChan() chan func(*EditorState) {
 	return s.task.resultChan
 }
 
+// PendingLoadResultChan returns a channel that receives an action when
+// a document loading in the background finishes.
+// It returns nil if no document is loading.
+func (s *EditorState) PendingLoadResultChan() chan func(*EditorState) {
+	if s.pendingLoad == nil {
+		return nil
+	}
+	return s.pendingLoad.actionChan
+}
+
 func (s *EditorState) IsRecordingUserMacro() bool {
 	return s.macroState.isRecordingUserMacro
 }
@@ -142,6 +203,10 @@ func (s *EditorState) QuitFlag() bool {
 	return s.quitFlag
 }
 
+func (s *EditorState) PagerMode() bool {
+	return s.pagerMode
+}
+
 // BufferState represents the current state of a text buffer.
 type BufferState struct {
 	textTree                *text.Tree
@@ -151,21 +216,80 @@ type BufferState struct {
 	search                  searchState
 	undoLog                 *undo.Log
 	syntaxLanguage          syntax.Language
+	syntaxBackend           syntax.Backend
 	syntaxParser            *parser.P
 	lineNumberMode          config.LineNumberMode
 	tabSize                 uint64
+	tabDisplaySize          uint64 // Zero to display tabs using tabSize.
+	minLineNumMarginWidth   uint64 // Zero to use the default minimum width.
 	tabExpand               bool
 	showTabs                bool
 	showSpaces              bool
 	autoIndent              bool
 	showLineNum             bool
+	showRuler               bool
+	showDiffGutter          bool
+	showScrollbar           bool
+	showMatchingBracket     bool
+	showAnsiColors          bool
+	showBlame               bool
+	showKeyHints            bool
+	rtlVisualOrder          bool
+	insertModeSelection     bool
+	matchPasteIndent        bool
 	lineWrapAllowCharBreaks bool
+	lineWrapDisabled        bool
+	ligatureBreaker         rune // Zero if ligatures are not broken.
+	pasteMode               pasteModeState
+	undoPreview             undoPreviewState
+	undoCheckpoint          *undoCheckpoint // Nil if there is no checkpoint to revert to.
+	replaceMode             replaceModeState
+	replaceConfirm          replaceConfirmState
+	insertStart             insertStartState
+	followTail              followTailState
+	flags                   flagIndex
+	lineChanges             lineChangeIndex
+	scrollbar               scrollbarIndex
+	blame                   blameState
+	bookmarks               map[uint64]file.Bookmark // Keyed by line number.
+	originalText            textSnapshot             // Snapshot of the document when it was loaded.
+	savedText               textSnapshot             // Snapshot of the document when it was last loaded or saved.
+	appendOnly              bool                     // If true, text is only appended to the document, so the snapshot is not kept up to date.
+	textFormat              file.TextFormat          // Line endings and final newline written when saving.
+	readOnly                bool                     // If true, edits to the document are rejected.
+	longLineChoice          longLineChoice           // Whether the user disabled expensive features for the document's long lines.
+	scratch                 bool                     // If true, the document is not backed by a file.
+	scratchName             string                   // Name of a named scratch buffer, or empty for other documents.
+	loading                 bool                     // If true, the document is still loading, so edits are rejected.
+	encryption              *encryptionState         // Set if the document was decrypted, so it is encrypted again when saved.
+}
+
+// pasteModeState records which settings were turned off by paste mode,
+// so they can be turned on again when paste mode is disabled.
+type pasteModeState struct {
+	enabled            bool
+	disabledAutoIndent bool // Cleared if the user toggles auto-indent while paste mode is enabled.
+	disabledTabExpand  bool // Cleared if the user toggles tab expand while paste mode is enabled.
 }
 
 func (s *BufferState) TextTree() *text.Tree {
 	return s.textTree
 }
 
+func (s *BufferState) ReadOnly() bool {
+	return s.readOnly
+}
+
+// Loading returns whether the document is still loading in the background.
+// While loading, the buffer contains only the start of the file.
+func (s *BufferState) Loading() bool {
+	return s.loading
+}
+
+func (s *BufferState) SyntaxLanguage() syntax.Language {
+	return s.syntaxLanguage
+}
+
 func (s *BufferState) SyntaxTokensIntersectingRange(startPos, endPos uint64) []parser.Token {
 	if s.syntaxParser == nil {
 		return nil
@@ -197,6 +321,14 @@ func (s *BufferState) ViewSize() (uint64, uint64) {
 	return s.view.width, s.view.height
 }
 
+func (s *BufferState) ViewLeftCol() uint64 {
+	return s.view.leftCol
+}
+
+func (s *BufferState) LineWrapEnabled() bool {
+	return !s.lineWrapDisabled
+}
+
 func (s *BufferState) SearchQueryAndDirection() (string, SearchDirection) {
 	return s.search.query, s.search.direction
 }
@@ -214,6 +346,15 @@ func (s *BufferState) TabSize() uint64 {
 	return s.tabSize
 }
 
+// TabDisplaySize returns the number of columns between tab stops when drawing the document.
+// This is the tab size unless the user overrode it for the current document.
+func (s *BufferState) TabDisplaySize() uint64 {
+	if s.tabDisplaySize > 0 {
+		return s.tabDisplaySize
+	}
+	return s.tabSize
+}
+
 func (s *BufferState) ShowTabs() bool {
 	return s.showTabs
 }
@@ -222,6 +363,50 @@ func (s *BufferState) ShowSpaces() bool {
 	return s.showSpaces
 }
 
+// LigatureBreaker returns the zero-width character to draw after each character
+// to prevent the terminal from rendering ligatures, or zero if ligatures are allowed.
+func (s *BufferState) LigatureBreaker() rune {
+	return s.ligatureBreaker
+}
+
+func (s *BufferState) RtlVisualOrder() bool {
+	return s.rtlVisualOrder
+}
+
+func (s *BufferState) InsertModeSelection() bool {
+	return s.insertModeSelection
+}
+
+func (s *BufferState) ShowRuler() bool {
+	return s.showRuler
+}
+
+// ShowAnsiColors returns whether to show colors from ANSI escape sequences instead of the escape characters.
+// This applies only to read-only documents, so the raw text is always visible while editing.
+func (s *BufferState) ShowAnsiColors() bool {
+	return s.showAnsiColors && s.readOnly
+}
+
+func (s *BufferState) ShowKeyHints() bool {
+	return s.showKeyHints
+}
+
+// UndoPreviewRegion returns the region changed by the last step of the undo preview.
+// This is empty if the undo preview is not active.
+func (s *BufferState) UndoPreviewRegion() selection.Region {
+	return s.undoPreview.changedRegion
+}
+
+// IsLineBookmarked returns whether the line has a bookmark.
+func (s *BufferState) IsLineBookmarked(lineNum uint64) bool {
+	_, ok := s.bookmarks[lineNum]
+	return ok
+}
+
+func (s *BufferState) PasteMode() bool {
+	return s.pasteMode.enabled
+}
+
 func (s *BufferState) LineNumberMode() config.LineNumberMode {
 	return s.lineNumberMode
 }
@@ -232,7 +417,8 @@ func (s *BufferState) LineNumMarginWidth() uint64 {
 	}
 
 	// One column for each digit in the last line number,
-	// plus one space, with a minimum of three cols.
+	// plus one space, with a minimum of three cols
+	// (or the width the user chose, if larger).
 	width := uint64(1)
 	n := s.textTree.NumLines()
 	for n > 0 {
@@ -242,6 +428,9 @@ func (s *BufferState) LineNumMarginWidth() uint64 {
 	if width < 3 {
 		width = 3
 	}
+	if width < s.minLineNumMarginWidth {
+		width = s.minLineNumMarginWidth
+	}
 
 	// Collapse the line margin column if there isn't enough
 	// space for at least one column of document text.
@@ -253,8 +442,18 @@ func (s *BufferState) LineNumMarginWidth() uint64 {
 }
 
 func (s *BufferState) LineWrapConfig() segment.LineWrapConfig {
-	width := s.view.width - s.LineNumMarginWidth()
-	tabSize := s.tabSize
+	width := s.view.width - s.LineNumMarginWidth() - s.DiffGutterWidth() - s.ScrollbarWidth()
+	if width == 0 {
+		// The terminal can briefly report zero columns (for example, while tmux rearranges panes).
+		// Wrap as if there were one column so layout calculations still make progress.
+		width = 1
+	}
+	if s.lineWrapDisabled {
+		// Each line occupies a single row, no matter how long it is.
+		// This is small enough to convert safely to int on any platform.
+		width = math.MaxInt32
+	}
+	tabSize := s.TabDisplaySize()
 	gcWidthFunc := func(gc []rune, offsetInLine uint64) uint64 {
 		return cellwidth.GraphemeClusterWidth(gc, offsetInLine, tabSize)
 	}
@@ -272,4 +471,8 @@ type viewState struct {
 
 	// width and height are the visible width (in columns) and height (in rows) of the document.
 	width, height uint64
+
+	// leftCol is the number of columns scrolled horizontally past the start of each line.
+	// This is always zero when line wrap is enabled.
+	leftCol uint64
 }
@@ -1,6 +1,10 @@
 package state
 
 import (
+	"fmt"
+	"log"
+
+	"github.com/aretext/aretext/menu"
 	"github.com/aretext/aretext/syntax"
 	"github.com/aretext/aretext/syntax/parser"
 )
@@ -10,10 +14,36 @@ func SetSyntax(state *EditorState, language syntax.Language) {
 	setSyntaxAndRetokenize(state.documentBuffer, language)
 }
 
+// ShowSyntaxLanguageMenu displays a menu for choosing the syntax language of the current document.
+// The menu includes every registered language, including languages registered by other packages.
+func ShowSyntaxLanguageMenu(state *EditorState) {
+	languages := syntax.AllLanguages()
+	items := make([]menu.Item, 0, len(languages))
+	for _, language := range languages {
+		items = append(items, menu.Item{
+			Name: string(language),
+			Action: func(s *EditorState) {
+				SetSyntax(s, language)
+				SetStatusMsg(s, StatusMsg{
+					Style: StatusMsgStyleSuccess,
+					Text:  fmt.Sprintf("Set syntax language to %s", s.documentBuffer.syntaxLanguage),
+				})
+			},
+		})
+	}
+	ShowMenu(state, MenuStyleSubmenu, items)
+}
+
 // setSyntaxAndRetokenize changes the syntax language of the buffer and updates the tokens.
 func setSyntaxAndRetokenize(buffer *BufferState, language syntax.Language) {
+	invalidateFlagIndex(buffer)
+	invalidateScrollbarIndex(buffer)
 	buffer.syntaxLanguage = language
-	buffer.syntaxParser = syntax.ParserForLanguage(language)
+	syntaxParser, ok := syntax.ParserForLanguageAndBackend(language, buffer.syntaxBackend)
+	if !ok {
+		log.Printf("Syntax backend %s is not available for %s, so using the built-in parser\n", buffer.syntaxBackend, language)
+	}
+	buffer.syntaxParser = syntaxParser
 
 	if buffer.syntaxParser == nil {
 		buffer.syntaxLanguage = syntax.LanguagePlaintext
@@ -24,7 +54,12 @@ func setSyntaxAndRetokenize(buffer *BufferState, language syntax.Language) {
 }
 
 // retokenizeAfterEdit updates syntax tokens after an edit to the text (insert or delete).
+// This also invalidates the index of flagged items, the line changes shown in the diff gutter,
+// and the search matches shown in the scrollbar.
 func retokenizeAfterEdit(buffer *BufferState, edit parser.Edit) {
+	invalidateFlagIndex(buffer)
+	invalidateLineChangeIndex(buffer)
+	invalidateScrollbarIndex(buffer)
 	if buffer.syntaxParser == nil {
 		return
 	}
@@ -0,0 +1,65 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/syntax/parser"
+)
+
+func TestShowSyntaxLanguageMenu(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertText(state, `{"a": 1}`)
+
+	ShowSyntaxLanguageMenu(state)
+	assert.Equal(t, InputModeMenu, state.InputMode())
+	assert.Equal(t, MenuStyleSubmenu, state.Menu().Style())
+	results, _ := state.Menu().SearchResults()
+	assert.Equal(t, len(syntax.AllLanguages()), len(results))
+
+	for _, r := range "json" {
+		AppendRuneToMenuSearch(state, r)
+	}
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, syntax.LanguageJson, state.documentBuffer.SyntaxLanguage())
+	assert.Equal(t, "Set syntax language to json", state.StatusMsg().Text)
+}
+
+func TestSyntaxBackendFromConfig(t *testing.T) {
+	if _, ok := syntax.ParserForLanguageAndBackend(syntax.LanguageGo, syntax.BackendTreeSitter); !ok {
+		t.Skip("Tree-sitter grammars require cgo")
+	}
+
+	path, cleanup := createTestFile(t, "len := len(x)")
+	defer cleanup()
+
+	tokenRoleAt := func(state *EditorState, pos uint64) parser.TokenRole {
+		return state.documentBuffer.syntaxParser.TokenAtPosition(pos).Role
+	}
+	builtinRole, _ := syntax.TokenRoleForStyleName(syntax.LanguageGo, "tokenBuiltin")
+
+	state := NewEditorState(100, 100, ruleSetWithConfig(map[string]any{
+		"syntaxLanguage": "go",
+		"syntaxBackend":  "treesitter",
+	}), nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	// Tree-sitter highlights "len" only where it calls the predeclared function.
+	assert.Equal(t, parser.TokenRoleNone, tokenRoleAt(state, 0))
+	assert.Equal(t, builtinRole, tokenRoleAt(state, 7))
+
+	// Edits reparse the document with tree-sitter.
+	MoveCursor(state, func(LocatorParams) uint64 { return 0 })
+	InsertText(state, "// ")
+	assert.Equal(t, parser.TokenRoleComment, tokenRoleAt(state, 10))
+
+	// Switching back to the built-in backend highlights every "len".
+	changed := ApplyConfigRuleSet(state, ruleSetWithConfig(map[string]any{"syntaxLanguage": "go"}))
+	assert.Equal(t, []string{"syntaxBackend"}, changed)
+	DeleteToPos(state, func(LocatorParams) uint64 { return 0 }, clipboard.PageDefault)
+	assert.Equal(t, builtinRole, tokenRoleAt(state, 0))
+}
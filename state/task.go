@@ -3,6 +3,9 @@ package state
 import (
 	"context"
 	"log"
+	"time"
+
+	"github.com/aretext/aretext/nondet"
 )
 
 // TaskFunc is a task that runs asynchronously.
@@ -21,6 +24,9 @@ type TaskState struct {
 
 	// prevInputMode is the input mode to set once the task completes or is cancelled.
 	prevInputMode InputMode
+
+	// startTime is when the task started, used to decide whether to notify the event hook on completion.
+	startTime time.Time
 }
 
 // StartTask starts a task executing asynchronously in a separate goroutine.
@@ -37,6 +43,7 @@ func StartTask(state *EditorState, task TaskFunc) {
 		resultChan:    resultChan,
 		cancelFunc:    cancelFunc,
 		prevInputMode: state.inputMode,
+		startTime:     nondet.Now(),
 	}
 	setInputMode(state, InputModeTask)
 
@@ -45,9 +52,13 @@ func StartTask(state *EditorState, task TaskFunc) {
 		action := task(ctx)
 		resultChan <- func(state *EditorState) {
 			prevInputMode := state.task.prevInputMode
+			startTime := state.task.startTime
 			state.task = nil
 			setInputMode(state, prevInputMode) // from InputModeTask -> prevInputMode
 			action(state)
+			if nondet.Now().Sub(startTime) >= longTaskThreshold {
+				runEventHook(state, HookEventTaskComplete, state.statusMsg.Text)
+			}
 		}
 	}(ctx)
 }
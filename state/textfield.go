@@ -28,6 +28,7 @@ type TextFieldState struct {
 	autocompleteFunc      TextFieldAutocompleteFunc // Set to nil to disable autocompletion.
 	autocompleteSuffixes  []string
 	autocompleteSuffixIdx int
+	secret                bool // Hide the input text, for example when typing a passphrase.
 }
 
 func (s *TextFieldState) PromptText() string {
@@ -38,6 +39,11 @@ func (s *TextFieldState) InputText() string {
 	return s.inputText.String()
 }
 
+// Secret returns whether the input text should be hidden from the screen and logs.
+func (s *TextFieldState) Secret() bool {
+	return s.secret
+}
+
 func (s *TextFieldState) AutocompleteSuffix() string {
 	if s.autocompleteSuffixIdx < len(s.autocompleteSuffixes) {
 		return s.autocompleteSuffixes[s.autocompleteSuffixIdx]
@@ -54,16 +60,30 @@ func (s *TextFieldState) applyAutocomplete() {
 	s.autocompleteSuffixIdx = 0
 }
 
+// ShowTextField prompts the user to input text.
+// If a text field action shows another text field (for example, to prompt for a second value),
+// hiding the new text field returns to the input mode from before the first text field.
 func ShowTextField(state *EditorState, promptText string, action TextFieldAction, autocompleteFunc TextFieldAutocompleteFunc) {
+	prevInputMode := state.inputMode
+	if prevInputMode == InputModeTextField {
+		prevInputMode = state.textfield.prevInputMode
+	}
 	state.textfield = &TextFieldState{
 		promptText:       promptText,
 		action:           action,
-		prevInputMode:    state.inputMode,
+		prevInputMode:    prevInputMode,
 		autocompleteFunc: autocompleteFunc,
 	}
 	setInputMode(state, InputModeTextField)
 }
 
+// ShowSecretTextField prompts the user to input text that should not be displayed or logged, such as a passphrase.
+// Like other text fields, the input is never recorded in a macro.
+func ShowSecretTextField(state *EditorState, promptText string, action TextFieldAction) {
+	ShowTextField(state, promptText, action, nil)
+	state.textfield.secret = true
+}
+
 func HideTextField(state *EditorState) {
 	prevInputMode := state.textfield.prevInputMode
 	state.textfield = &TextFieldState{}
@@ -86,9 +106,10 @@ func DeleteRuneFromTextField(state *EditorState) {
 }
 
 func ExecuteTextFieldAction(state *EditorState) {
-	state.textfield.applyAutocomplete()
-	action := state.textfield.action
-	inputText := state.textfield.InputText()
+	tf := state.textfield
+	tf.applyAutocomplete()
+	action := tf.action
+	inputText := tf.InputText()
 	err := action(state, inputText)
 	if err != nil {
 		// If the action failed, show the error as a status message,
@@ -100,6 +121,11 @@ func ExecuteTextFieldAction(state *EditorState) {
 		return
 	}
 
+	if state.textfield != tf {
+		// The action showed another text field, so keep it open.
+		return
+	}
+
 	// The action completed successfully, so hide the text field.
 	HideTextField(state)
 }
@@ -132,6 +132,33 @@ func TestExecuteTextFieldActionError(t *testing.T) {
 	assert.Equal(t, "TEST ERROR", state.StatusMsg().Text)
 }
 
+func TestExecuteTextFieldActionShowsAnotherTextField(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	var firstInput, secondInput string
+	secondAction := func(_ *EditorState, inputText string) error {
+		secondInput = inputText
+		return nil
+	}
+	firstAction := func(state *EditorState, inputText string) error {
+		firstInput = inputText
+		ShowTextField(state, "second prompt", secondAction, nil)
+		return nil
+	}
+
+	ShowTextField(state, "first prompt", firstAction, nil)
+	AppendRuneToTextField(state, 'a')
+	ExecuteTextFieldAction(state)
+	assert.Equal(t, InputModeTextField, state.InputMode())
+	assert.Equal(t, "second prompt", state.TextField().PromptText())
+	assert.Equal(t, "", state.TextField().InputText())
+
+	AppendRuneToTextField(state, 'b')
+	ExecuteTextFieldAction(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "a", firstInput)
+	assert.Equal(t, "b", secondInput)
+}
+
 func TestAutocompleteTextField(t *testing.T) {
 	state := NewEditorState(100, 100, nil, nil)
 	fakeAction := func(state *EditorState, inputText string) error {
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/menu"
+)
+
+// SetTextFormatStore sets the store used to save the line endings and final newline policy for each project.
+func SetTextFormatStore(state *EditorState, store *file.TextFormatStore) {
+	state.textFormatStore = store
+}
+
+// TextFormat returns how line endings are written when the document is saved.
+func (s *BufferState) TextFormat() file.TextFormat {
+	return s.textFormat
+}
+
+// textFormatForNewFile returns the text format for a new file in the project (the current working directory).
+// If the project has no stored format, the format is detected from existing files in the project
+// and stored, so the detection happens only once for each project.
+func textFormatForNewFile(state *EditorState, path string) file.TextFormat {
+	if state.textFormatStore == nil {
+		return file.DefaultTextFormat
+	}
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		log.Printf("Error getting working directory to load text format: %v\n", err)
+		return file.DefaultTextFormat
+	}
+
+	format, ok, err := state.textFormatStore.TextFormatForPath(projectDir, path)
+	if err != nil {
+		log.Printf("Error loading text format for %q: %v\n", path, err)
+		return file.DefaultTextFormat
+	} else if ok {
+		return format
+	}
+
+	if !file.IsPathInProject(projectDir, path) {
+		return file.DefaultTextFormat
+	}
+
+	format, ok = file.DetectTextFormat(projectDir)
+	if !ok {
+		return file.DefaultTextFormat
+	}
+
+	log.Printf("Detected text format %q for project %q\n", format, projectDir)
+	if err := state.textFormatStore.SetTextFormat(projectDir, format); err != nil {
+		log.Printf("Error storing text format for project %q: %v\n", projectDir, err)
+	}
+	return format
+}
+
+// ShowTextFormatMenu displays a menu to choose the line endings and final newline policy for new files in the project.
+func ShowTextFormatMenu(state *EditorState) {
+	formats := []file.TextFormat{
+		{CRLF: false, FinalNewline: true},
+		{CRLF: false, FinalNewline: false},
+		{CRLF: true, FinalNewline: true},
+		{CRLF: true, FinalNewline: false},
+	}
+
+	items := make([]menu.Item, 0, len(formats)+1)
+	for _, format := range formats {
+		items = append(items, menu.Item{
+			Name: format.String(),
+			Action: func(s *EditorState) {
+				setProjectTextFormat(s, format, "Set")
+			},
+		})
+	}
+
+	items = append(items, menu.Item{
+		Name: "detect from project files",
+		Action: func(s *EditorState) {
+			dir, err := os.Getwd()
+			if err != nil {
+				reportTextFormatError(s, err)
+				return
+			}
+
+			format, ok := file.DetectTextFormat(dir)
+			if !ok {
+				reportTextFormatError(s, errors.New("No files with line endings found in the project"))
+				return
+			}
+			setProjectTextFormat(s, format, "Detected")
+		},
+	})
+
+	ShowMenu(state, MenuStyleSubmenu, items)
+}
+
+// setProjectTextFormat stores the text format for the project and applies it to the document if it is a new file.
+func setProjectTextFormat(state *EditorState, format file.TextFormat, verb string) {
+	if state.textFormatStore == nil {
+		reportTextFormatError(state, errors.New("Project settings are not available"))
+		return
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		reportTextFormatError(state, err)
+		return
+	}
+
+	if err := state.textFormatStore.SetTextFormat(dir, format); err != nil {
+		reportTextFormatError(state, err)
+		return
+	}
+
+	// Existing files keep their line endings, but a new file hasn't been written yet.
+	buffer := state.documentBuffer
+	path := state.fileWatcher.Path()
+	if file.IsPathInProject(dir, path) && !buffer.scratch {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			buffer.textFormat = format
+		}
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("%s %s for new files in the project", verb, format),
+	})
+}
+
+func reportTextFormatError(state *EditorState, err error) {
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("Could not set project line endings: %s", err),
+	})
+}
@@ -0,0 +1,102 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+func TestNewFileUsesDetectedProjectTextFormat(t *testing.T) {
+	withTempDirPaths(t, nil, func(dir string) {
+		require.NoError(t, os.WriteFile("a.txt", []byte("foo\r\nbar"), 0644))
+		require.NoError(t, os.WriteFile("b.txt", []byte("baz\r\nqux"), 0644))
+
+		store := file.NewTextFormatStore(t.TempDir())
+		state := NewEditorState(100, 100, nil, nil)
+		defer Quit(state)
+		SetTextFormatStore(state, store)
+
+		LoadDocument(state, "new.txt", false, startOfDocLocator)
+		assert.Equal(t, file.TextFormat{CRLF: true}, state.documentBuffer.TextFormat())
+		assert.Equal(t, "New file new.txt (CRLF without final newline)", state.StatusMsg().Text)
+
+		InsertText(state, "hello\nworld")
+		SaveDocument(state)
+		data, err := os.ReadFile("new.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "hello\r\nworld", string(data))
+
+		// The detected format is stored, so it applies to new files even after the project changes.
+		require.NoError(t, os.WriteFile("a.txt", []byte("foo\nbar\n"), 0644))
+		require.NoError(t, os.WriteFile("b.txt", []byte("baz\nqux\n"), 0644))
+		LoadDocument(state, "another.txt", false, startOfDocLocator)
+		assert.Equal(t, file.TextFormat{CRLF: true}, state.documentBuffer.TextFormat())
+	})
+}
+
+func TestExistingFileKeepsDefaultTextFormat(t *testing.T) {
+	withTempDirPaths(t, nil, func(dir string) {
+		require.NoError(t, os.WriteFile("a.txt", []byte("foo\r\nbar"), 0644))
+
+		store := file.NewTextFormatStore(t.TempDir())
+		require.NoError(t, store.SetTextFormat(dir, file.TextFormat{CRLF: true}))
+		state := NewEditorState(100, 100, nil, nil)
+		defer Quit(state)
+		SetTextFormatStore(state, store)
+
+		LoadDocument(state, "a.txt", true, startOfDocLocator)
+		assert.Equal(t, file.DefaultTextFormat, state.documentBuffer.TextFormat())
+		SaveDocument(state)
+		data, err := os.ReadFile("a.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "foo\r\nbar\n", string(data))
+	})
+}
+
+func TestNewFileWithoutProjectFilesUsesDefaultTextFormat(t *testing.T) {
+	withTempDirPaths(t, nil, func(dir string) {
+		state := NewEditorState(100, 100, nil, nil)
+		defer Quit(state)
+		SetTextFormatStore(state, file.NewTextFormatStore(t.TempDir()))
+
+		LoadDocument(state, "new.txt", false, startOfDocLocator)
+		assert.Equal(t, file.DefaultTextFormat, state.documentBuffer.TextFormat())
+		assert.Equal(t, "New file new.txt", state.StatusMsg().Text)
+	})
+}
+
+func TestSetProjectTextFormat(t *testing.T) {
+	withTempDirPaths(t, nil, func(dir string) {
+		require.NoError(t, os.WriteFile("existing.txt", []byte("foo\nbar\n"), 0644))
+
+		store := file.NewTextFormatStore(t.TempDir())
+		state := NewEditorState(100, 100, nil, nil)
+		defer Quit(state)
+		SetTextFormatStore(state, store)
+
+		// Existing files are unaffected.
+		LoadDocument(state, "existing.txt", true, startOfDocLocator)
+		setProjectTextFormat(state, file.TextFormat{CRLF: true, FinalNewline: true}, "Set")
+		assert.Equal(t, "Set CRLF with final newline for new files in the project", state.StatusMsg().Text)
+		assert.Equal(t, file.DefaultTextFormat, state.documentBuffer.TextFormat())
+
+		// The active document is a new file, so it uses the format when saved.
+		LoadDocument(state, "new.txt", false, startOfDocLocator)
+		setProjectTextFormat(state, file.TextFormat{CRLF: false, FinalNewline: false}, "Set")
+		assert.Equal(t, file.TextFormat{}, state.documentBuffer.TextFormat())
+		InsertText(state, "a\nb")
+		SaveDocument(state)
+		data, err := os.ReadFile("new.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb", string(data))
+
+		format, ok, err := store.TextFormatForPath(dir, "other.txt")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, file.TextFormat{}, format)
+	})
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"log"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/nondet"
+	"github.com/aretext/aretext/selection"
+)
+
+// InsertTimestamp inserts the current time at the cursor position in insert mode,
+// formatted using the first configured timestamp format.
+func InsertTimestamp(state *EditorState) {
+	layout := timestampFormats(state)[0]
+	InsertText(state, nondet.Now().Format(layout))
+}
+
+// ShowTimestampMenu displays a menu for inserting the current time in each of the configured formats.
+// If there is only one format, this inserts the timestamp immediately.
+func ShowTimestampMenu(state *EditorState) {
+	layouts := timestampFormats(state)
+	if len(layouts) == 1 {
+		InsertTimestampAfterCursor(state, layouts[0])
+		return
+	}
+
+	now := nondet.Now()
+	items := make([]menu.Item, 0, len(layouts))
+	for _, layout := range layouts {
+		items = append(items, menu.Item{
+			Name: now.Format(layout),
+			Action: func(s *EditorState) {
+				InsertTimestampAfterCursor(s, layout)
+			},
+		})
+	}
+	ShowMenu(state, MenuStyleInsertChoice, items)
+}
+
+// InsertTimestampAfterCursor inserts the current time after the cursor in normal mode,
+// or replaces the selection in visual mode.
+//
+// Menu items are not recorded in macros, so this adds itself to the "last action" macro
+// and the recording user macro. When replayed, the macro inserts the time of the replay,
+// not the time of the original insertion.
+func InsertTimestampAfterCursor(state *EditorState, layout string) {
+	action := func(s *EditorState) {
+		insertTimestampAfterCursor(s, layout)
+	}
+	action(state)
+	ClearLastActionMacro(state)
+	AddToLastActionMacro(state, action)
+	AddToRecordingUserMacro(state, action)
+}
+
+func insertTimestampAfterCursor(state *EditorState, layout string) {
+	text := nondet.Now().Format(layout)
+	buffer := state.documentBuffer
+
+	BeginUndoEntry(state)
+	pos := buffer.cursor.position
+	if buffer.selector.Mode() == selection.ModeNone {
+		pos = locate.NextCharInLine(buffer.textTree, 1, true, pos)
+	} else {
+		deleteCurrentSelection(state)
+		pos = buffer.cursor.position
+	}
+	err := insertTextAtPosition(state, text, pos, true)
+	CommitUndoEntry(state)
+	setInputMode(state, InputModeNormal)
+	if err != nil {
+		log.Printf("Error inserting timestamp: %v\n", err)
+		return
+	}
+
+	// Move the cursor to the last character of the timestamp, like a paste.
+	MoveCursor(state, func(p LocatorParams) uint64 {
+		posAfterInsert := pos + uint64(utf8.RuneCountInString(text))
+		return locate.PrevCharInLine(p.TextTree, 1, false, posAfterInsert)
+	})
+}
+
+// timestampFormats returns the configured timestamp formats, or the default format if none are configured.
+func timestampFormats(state *EditorState) []string {
+	if len(state.timestampFormats) == 0 {
+		return []string{config.DefaultTimestampFormat}
+	}
+	return state.timestampFormats
+}
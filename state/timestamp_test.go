@@ -0,0 +1,71 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+func TestInsertTimestamp(t *testing.T) {
+	start := time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)
+	restore := nondet.SetSource(nondet.NewDeterministicSource(start, 0, 0))
+	defer restore()
+
+	state := NewEditorState(100, 100, nil, nil)
+	setInputMode(state, InputModeInsert)
+	InsertText(state, "> ")
+	InsertTimestamp(state)
+	assert.Equal(t, "> 2024-03-15T09:30:00Z", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(22), state.documentBuffer.cursor.position)
+}
+
+func TestShowTimestampMenu(t *testing.T) {
+	start := time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)
+	restore := nondet.SetSource(nondet.NewDeterministicSource(start, 0, 0))
+	defer restore()
+
+	state := NewEditorState(100, 100, nil, nil)
+	state.timestampFormats = []string{"2006-01-02", "15:04"}
+	InsertText(state, "ab")
+	MoveCursor(state, func(LocatorParams) uint64 { return 0 })
+
+	ShowTimestampMenu(state)
+	assert.Equal(t, InputModeMenu, state.InputMode())
+	assert.Equal(t, MenuStyleInsertChoice, state.Menu().Style())
+	results, _ := state.Menu().SearchResults()
+	require.Equal(t, 2, len(results))
+	assert.Equal(t, "2024-03-15", results[0].Name)
+	assert.Equal(t, "09:30", results[1].Name)
+
+	MoveMenuSelection(state, 1)
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "a09:30b", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(5), state.documentBuffer.cursor.position)
+
+	// Repeating the last action inserts the current time again in the same format.
+	restore2 := nondet.SetSource(nondet.NewDeterministicSource(start.Add(time.Hour), 0, 0))
+	defer restore2()
+	ReplayLastActionMacro(state, 1)
+	assert.Equal(t, "a09:3010:30b", state.documentBuffer.textTree.String())
+
+	// The insertion can be undone.
+	Undo(state)
+	assert.Equal(t, "a09:30b", state.documentBuffer.textTree.String())
+}
+
+func TestShowTimestampMenuSingleFormat(t *testing.T) {
+	start := time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)
+	restore := nondet.SetSource(nondet.NewDeterministicSource(start, 0, 0))
+	defer restore()
+
+	state := NewEditorState(100, 100, nil, nil)
+	state.timestampFormats = []string{"2006-01-02"}
+	ShowTimestampMenu(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "2024-03-15", state.documentBuffer.textTree.String())
+}
@@ -0,0 +1,70 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/nondet"
+)
+
+// SetTrash sets the trash used to delete files.
+func SetTrash(state *EditorState, trash *file.Trash) {
+	state.trash = trash
+}
+
+// MoveDocumentToTrash moves the document's file to the trash, then closes the document.
+// If no other buffer is open, it opens the previous document (or an empty scratch buffer if there is no previous document).
+// Unsaved changes to the document are discarded.
+// The file can be restored with RestoreLastTrashedFile until the editor exits.
+func MoveDocumentToTrash(state *EditorState) error {
+	if state.trash == nil {
+		return errors.New("Trash is not available")
+	} else if state.documentBuffer.scratch {
+		return errScratchNotSaved
+	}
+
+	path := state.fileWatcher.Path()
+	trashedFile, err := state.trash.MoveToTrash(path, nondet.Now())
+	if err != nil {
+		log.Printf("Error moving %q to trash: %v\n", path, err)
+		return fmt.Errorf("Could not move %q to trash: %w", file.RelativePathCwd(path), err)
+	}
+	log.Printf("Moved %q to trash\n", path)
+	state.lastTrashedFile = &trashedFile
+
+	// The file no longer exists, so leave it out of the timeline.
+	if !closeActiveBuffer(state) {
+		if prev := state.fileTimeline.PeekBackward(); !prev.Empty() {
+			loadPrevDocumentFrom(state, prev, file.TimelineState{})
+		} else {
+			LoadScratchText(state, "untitled", "")
+		}
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf(`Moved %s to trash. Use "restore last trashed file" to undo`, file.RelativePathCwd(path)),
+	})
+	return nil
+}
+
+// RestoreLastTrashedFile moves the file most recently moved to the trash in this session back to its original path,
+// then opens it as the document.
+func RestoreLastTrashedFile(state *EditorState) error {
+	trashedFile := state.lastTrashedFile
+	if trashedFile == nil {
+		return errors.New("No file has been moved to trash")
+	}
+
+	if err := state.trash.Restore(*trashedFile); err != nil {
+		log.Printf("Error restoring %q from trash: %v\n", trashedFile.OrigPath, err)
+		return fmt.Errorf("Could not restore %q from trash: %w", file.RelativePathCwd(trashedFile.OrigPath), err)
+	}
+	log.Printf("Restored %q from trash\n", trashedFile.OrigPath)
+	state.lastTrashedFile = nil
+
+	LoadDocument(state, trashedFile.OrigPath, true, func(LocatorParams) uint64 { return 0 })
+	return nil
+}
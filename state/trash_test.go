@@ -0,0 +1,76 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+func TestMoveDocumentToTrashAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	prevPath := filepath.Join(dir, "prev.txt")
+	path := filepath.Join(dir, "foo.txt")
+	require.NoError(t, os.WriteFile(prevPath, []byte("prev\n"), 0644))
+	require.NoError(t, os.WriteFile(path, []byte("foo\n"), 0644))
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetTrash(state, file.NewTrash(filepath.Join(dir, "Trash")))
+	LoadDocument(state, prevPath, true, startOfDocLocator)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+
+	// Trashing the file opens the previous document.
+	err := MoveDocumentToTrash(state)
+	require.NoError(t, err)
+	assert.Equal(t, prevPath, state.fileWatcher.Path())
+	assert.Equal(t, "prev", state.documentBuffer.textTree.String())
+	assert.Contains(t, state.StatusMsg().Text, "to trash")
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// The trashed file isn't in the timeline.
+	LoadNextDocument(state)
+	assert.Equal(t, "No next document to open", state.StatusMsg().Text)
+
+	// Restoring the file opens it.
+	err = RestoreLastTrashedFile(state)
+	require.NoError(t, err)
+	assert.Equal(t, path, state.fileWatcher.Path())
+	assert.Equal(t, "foo", state.documentBuffer.textTree.String())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\n", string(data))
+
+	err = RestoreLastTrashedFile(state)
+	assert.EqualError(t, err, "No file has been moved to trash")
+}
+
+func TestMoveDocumentToTrashWithoutPrevDocument(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetTrash(state, file.NewTrash(t.TempDir()))
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+
+	err := MoveDocumentToTrash(state)
+	require.NoError(t, err)
+	assert.True(t, state.documentBuffer.IsScratch())
+	assert.Equal(t, "", state.documentBuffer.textTree.String())
+
+	// Scratch buffers have no file to move to the trash.
+	err = MoveDocumentToTrash(state)
+	assert.EqualError(t, err, "Scratch buffers are not backed by a file")
+}
+
+func TestMoveDocumentToTrashNotAvailable(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	err := MoveDocumentToTrash(state)
+	assert.EqualError(t, err, "Trash is not available")
+}
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aretext/aretext/file"
+)
+
+// handleFileTruncated responds to another program truncating the document's file,
+// for example when a log rotation tool empties a log file.
+// Positions in the document no longer correspond to the file, so aligning the old document
+// with the new file would move the cursor and view to unrelated lines.
+//
+// In follow mode, the document reloads from the start of the file and continues following.
+// Otherwise, the user chooses whether to reload from the start of the file or keep the document as it is.
+func handleFileTruncated(state *EditorState) {
+	path := state.fileWatcher.Path()
+	log.Printf("File %q was truncated\n", path)
+
+	if state.documentBuffer.followTail.enabled {
+		ReloadDocumentFromStart(state)
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  fmt.Sprintf("%s was truncated, reloaded from start", file.RelativePathCwd(path)),
+		})
+		return
+	}
+
+	prompt := fmt.Sprintf("%s was truncated on disk. Reload from start? [y/N]", file.RelativePathCwd(path))
+	if state.documentBuffer.undoLog.HasUnsavedChanges() {
+		prompt = fmt.Sprintf("%s was truncated on disk. Reload from start, discarding unsaved changes? [y/N]", file.RelativePathCwd(path))
+	}
+	ShowTextField(state, prompt, func(state *EditorState, inputText string) error {
+		if !isTruncatedReloadConfirmed(inputText) {
+			log.Printf("Keeping document after %q was truncated\n", path)
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  `Kept document. Use "force save document" to overwrite the file or "force reload" to reload it`,
+			})
+			return nil
+		}
+
+		// Hide the prompt before reloading, so the reload doesn't restore it.
+		HideTextField(state)
+		ReloadDocumentFromStart(state)
+		return nil
+	}, nil)
+}
+
+func isTruncatedReloadConfirmed(inputText string) bool {
+	switch strings.ToLower(strings.TrimSpace(inputText)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,109 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleFileTruncatedFollowTail(t *testing.T) {
+	path, cleanup := createTestFile(t, "foo\nbar\nbaz\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer func() { state.fileWatcher.Stop() }()
+	ToggleFollowTail(state)
+
+	// Simulate log rotation truncating the file, then writing new data.
+	require.NoError(t, os.WriteFile(path, []byte("qux\n"), 0644))
+	HandleFileChangedOnDisk(state)
+	assert.Equal(t, "qux", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(0), state.documentBuffer.cursor.position)
+	assert.Equal(t, uint64(0), state.documentBuffer.view.textOrigin)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Contains(t, state.StatusMsg().Text, "was truncated, reloaded from start")
+
+	// Follow mode continues after the reload.
+	assert.True(t, state.documentBuffer.followTail.enabled)
+	assert.True(t, state.documentBuffer.ReadOnly())
+	require.NoError(t, os.WriteFile(path, []byte("qux\nquux\n"), 0644))
+	HandleFileChangedOnDisk(state)
+	assert.Equal(t, "qux\nquux", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+}
+
+func TestHandleFileTruncatedWhileEditing(t *testing.T) {
+	testCases := []struct {
+		name            string
+		unsavedChanges  bool
+		inputText       string
+		expectedText    string
+		expectedCursor  uint64
+		expectedMsgPart string
+	}{
+		{
+			name:            "reload from start",
+			inputText:       "y",
+			expectedText:    "ab",
+			expectedCursor:  0,
+			expectedMsgPart: "Reloaded",
+		},
+		{
+			name:            "keep document",
+			inputText:       "",
+			expectedText:    "foo\nbar\nbaz",
+			expectedCursor:  8,
+			expectedMsgPart: "Kept document",
+		},
+		{
+			name:            "reload from start with unsaved changes",
+			unsavedChanges:  true,
+			inputText:       "yes",
+			expectedText:    "ab",
+			expectedCursor:  0,
+			expectedMsgPart: "Reloaded",
+		},
+		{
+			name:            "keep document with unsaved changes",
+			unsavedChanges:  true,
+			inputText:       "n",
+			expectedText:    "foo\nbar\nxbaz",
+			expectedCursor:  9,
+			expectedMsgPart: "Kept document",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := createTestFile(t, "foo\nbar\nbaz\n")
+			defer cleanup()
+
+			state := NewEditorState(100, 100, nil, nil)
+			LoadDocument(state, path, true, startOfDocLocator)
+			defer func() { state.fileWatcher.Stop() }()
+			MoveCursor(state, func(LocatorParams) uint64 { return 8 })
+			if tc.unsavedChanges {
+				BeginUndoEntry(state)
+				InsertText(state, "x")
+				CommitUndoEntry(state)
+			}
+
+			require.NoError(t, os.WriteFile(path, []byte("ab\n"), 0644))
+			HandleFileChangedOnDisk(state)
+			require.Equal(t, InputModeTextField, state.InputMode())
+			assert.Contains(t, state.TextField().PromptText(), "was truncated on disk")
+
+			for _, r := range tc.inputText {
+				AppendRuneToTextField(state, r)
+			}
+			ExecuteTextFieldAction(state)
+			assert.Equal(t, InputModeNormal, state.InputMode())
+			assert.Equal(t, tc.expectedText, state.documentBuffer.textTree.String())
+			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor.position)
+			assert.Contains(t, state.StatusMsg().Text, tc.expectedMsgPart)
+		})
+	}
+}
@@ -1,8 +1,15 @@
 package state
 
 import (
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/nondet"
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/syntax/parser"
 	"github.com/aretext/aretext/undo"
 )
 
@@ -40,7 +47,7 @@ func Undo(state *EditorState) {
 	}
 
 	for _, op := range undoOps {
-		log.Printf("Undo operation: %#v\n", op)
+		log.Printf("Undo operation: %v\n", op)
 		if err := applyOpFromUndoLog(state, op); err != nil {
 			log.Printf("Could not apply undo op %v: %v\n", op, err)
 			continue
@@ -60,7 +67,7 @@ func Redo(state *EditorState) {
 	}
 
 	for _, op := range redoOps {
-		log.Printf("Redo operation: %#v\n", op)
+		log.Printf("Redo operation: %v\n", op)
 		if err := applyOpFromUndoLog(state, op); err != nil {
 			log.Printf("Could not apply redo op %v: %v\n", op, err)
 			continue
@@ -72,12 +79,311 @@ func Redo(state *EditorState) {
 	})
 }
 
+// RevertChangesSince undoes every change made to the open documents in the last number of minutes.
+// This recovers from mistakes that made many changes at once, such as a runaway macro or a replace across several documents.
+// The changes stay in each document's undo log, so redo restores them one at a time.
+func RevertChangesSince(state *EditorState, minutes uint64) {
+	cutoff := nondet.Now().Add(-time.Duration(minutes) * time.Minute)
+	total := 0
+	var bufferCounts []string
+	activeIdx := activeBufferIdx(state)
+	for i, b := range state.openBuffers {
+		buffer, watcher := b.buffer, b.watcher
+		if i == activeIdx {
+			buffer, watcher = state.documentBuffer, state.fileWatcher
+		}
+
+		n := revertBufferChangesSince(buffer, cutoff)
+		if n == 0 {
+			continue
+		}
+		total += n
+		bufferCounts = append(bufferCounts, fmt.Sprintf("%s (%d)", bufferName(watcher.Path()), n))
+
+		if i == activeIdx {
+			MoveCursor(state, func(p LocatorParams) uint64 {
+				return p.CursorPos
+			})
+			ScrollViewToCursor(state)
+		}
+	}
+
+	msg := fmt.Sprintf("Reverted %d change(s) from the last %d minute(s)", total, minutes)
+	if len(bufferCounts) > 0 {
+		msg += ": " + strings.Join(bufferCounts, ", ")
+	}
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  msg,
+	})
+}
+
+// revertBufferChangesSince undoes the changes committed to a buffer after the cutoff, returning the number of changes undone.
+// Buffers that cannot be edited, such as read-only documents, are unchanged.
+func revertBufferChangesSince(buffer *BufferState, cutoff time.Time) int {
+	if checkEditable(buffer) != nil {
+		return 0
+	}
+
+	numEntries := buffer.undoLog.NumEntriesSince(cutoff)
+	for i := 0; i < numEntries; i++ {
+		hasEntry, undoOps, cursor := buffer.undoLog.UndoToLastCommitted()
+		if !hasEntry {
+			return i
+		}
+
+		for _, op := range undoOps {
+			log.Printf("Revert operation: %v\n", op)
+			if err := applyOpToBuffer(buffer, op); err != nil {
+				log.Printf("Could not apply revert op %v: %v\n", op, err)
+				continue
+			}
+		}
+		buffer.cursor = cursorState{position: cursor}
+	}
+	return numEntries
+}
+
+// MoveToOlderUndoState moves the document back by up to count states in the order they were committed.
+// Unlike undo, this can move between branches of the undo tree, so it reaches changes made before an undo.
+func MoveToOlderUndoState(state *EditorState, count uint64) {
+	current := state.documentBuffer.undoLog.CurrentState()
+	moveToUndoState(state, current-int(min(count, uint64(current))))
+}
+
+// MoveToNewerUndoState moves the document forward by up to count states in the order they were committed.
+func MoveToNewerUndoState(state *EditorState, count uint64) {
+	undoLog := state.documentBuffer.undoLog
+	numNewer := undoLog.NumStates() - 1 - undoLog.CurrentState()
+	moveToUndoState(state, undoLog.CurrentState()+int(min(count, uint64(numNewer))))
+}
+
+// ShowUndoBranchesMenu displays a menu of the branches in the undo tree.
+// Selecting a branch moves the document to the last state in that branch.
+func ShowUndoBranchesMenu(state *EditorState) {
+	branches := state.documentBuffer.undoLog.Branches()
+	if len(branches) == 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No changes to undo",
+		})
+		return
+	}
+
+	// List the most recent branch first.
+	items := make([]menu.Item, 0, len(branches))
+	for i := len(branches) - 1; i >= 0; i-- {
+		b := branches[i]
+		name := fmt.Sprintf("state %d: %d change(s), %s", b.Num, b.NumChanges, formatUndoTime(b.Time))
+		if b.Current {
+			name += " (current)"
+		}
+		items = append(items, menu.Item{
+			Name: name,
+			Action: func(s *EditorState) {
+				moveToUndoState(s, b.Num)
+			},
+		})
+	}
+	ShowMenu(state, MenuStyleSubmenu, items)
+}
+
+func moveToUndoState(state *EditorState, stateNum int) {
+	undoLog := state.documentBuffer.undoLog
+	hasEntry, ops, cursor := undoLog.MoveToState(stateNum)
+	if hasEntry {
+		for _, op := range ops {
+			log.Printf("Undo tree operation: %v\n", op)
+			if err := applyOpFromUndoLog(state, op); err != nil {
+				log.Printf("Could not apply undo tree op %v: %v\n", op, err)
+				continue
+			}
+		}
+
+		MoveCursor(state, func(LocatorParams) uint64 {
+			return cursor
+		})
+		ScrollViewToCursor(state)
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Undo state %d of %d", undoLog.CurrentState(), undoLog.NumStates()-1),
+	})
+}
+
+func formatUndoTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
 func applyOpFromUndoLog(state *EditorState, op undo.Op) error {
+	if err := checkEditable(state.documentBuffer); err != nil {
+		setNotEditableStatusMsg(state, err)
+		return err
+	}
+	return applyOpToBuffer(state.documentBuffer, op)
+}
+
+// applyOpToBuffer changes the text of a buffer without tracking the change in its undo log.
+// The buffer does not need to be the active document.
+func applyOpToBuffer(buffer *BufferState, op undo.Op) error {
 	pos := op.Position()
-	if s := op.TextToInsert(); len(s) > 0 {
-		return insertTextAtPosition(state, s, pos, false)
-	} else if n := op.NumRunesToDelete(); n > 0 {
-		deleteRunes(state, pos, uint64(n), false)
+	if op.NumRunesToInsert() > 0 {
+		s, err := op.TextToInsert()
+		if err != nil {
+			return err
+		}
+		if err := buffer.textTree.ReplaceRange(pos, 0, s); err != nil {
+			return fmt.Errorf("text.Tree.ReplaceRange: %w", err)
+		}
+		retokenizeAfterEdit(buffer, parser.NewInsertEdit(pos, uint64(op.NumRunesToInsert())))
+	} else if n := uint64(op.NumRunesToDelete()); n > 0 {
+		if err := buffer.textTree.ReplaceRange(pos, n, ""); err != nil {
+			return fmt.Errorf("text.Tree.ReplaceRange: %w", err)
+		}
+		retokenizeAfterEdit(buffer, parser.NewDeleteEdit(pos, n))
 	}
 	return nil
 }
+
+// undoPreviewState tracks the changes undone to preview an earlier document state.
+type undoPreviewState struct {
+	numSteps       uint64
+	changedRegion  selection.Region
+	origCursorPos  uint64
+	origTextOrigin uint64
+}
+
+// EnterUndoPreviewMode undoes up to count changes to preview an earlier document state.
+// The changes can be committed with CommitUndoPreview or reverted with CancelUndoPreview.
+func EnterUndoPreviewMode(state *EditorState, count uint64) {
+	buffer := state.documentBuffer
+	buffer.undoPreview = undoPreviewState{
+		origCursorPos:  buffer.cursor.position,
+		origTextOrigin: buffer.view.textOrigin,
+	}
+	buffer.selector.Clear()
+	setInputMode(state, InputModeUndoPreview)
+	PreviewEarlierChange(state, count)
+}
+
+// PreviewEarlierChange moves the undo preview back by up to count changes.
+func PreviewEarlierChange(state *EditorState, count uint64) {
+	buffer := state.documentBuffer
+	for i := uint64(0); i < count; i++ {
+		hasEntry, undoOps, cursor := buffer.undoLog.UndoToLastCommitted()
+		if !hasEntry {
+			break
+		}
+		applyUndoPreviewOps(state, undoOps, cursor)
+		buffer.undoPreview.numSteps++
+	}
+	setUndoPreviewStatusMsg(state)
+}
+
+// PreviewLaterChange moves the undo preview forward by up to count changes.
+// This cannot move past the document state from before the preview started.
+func PreviewLaterChange(state *EditorState, count uint64) {
+	buffer := state.documentBuffer
+	for i := uint64(0); i < count && buffer.undoPreview.numSteps > 0; i++ {
+		hasEntry, redoOps, cursor := buffer.undoLog.RedoToNextCommitted()
+		if !hasEntry {
+			break
+		}
+		applyUndoPreviewOps(state, redoOps, cursor)
+		buffer.undoPreview.numSteps--
+	}
+	setUndoPreviewStatusMsg(state)
+}
+
+// CommitUndoPreview keeps the previewed document state and returns to normal mode.
+func CommitUndoPreview(state *EditorState) {
+	numSteps := state.documentBuffer.undoPreview.numSteps
+	state.documentBuffer.undoPreview = undoPreviewState{}
+	setInputMode(state, InputModeNormal)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Undid %d change(s)", numSteps),
+	})
+}
+
+// CancelUndoPreview restores the document state from before the preview and returns to normal mode.
+func CancelUndoPreview(state *EditorState) {
+	buffer := state.documentBuffer
+	PreviewLaterChange(state, buffer.undoPreview.numSteps)
+	buffer.cursor = cursorState{position: buffer.undoPreview.origCursorPos}
+	buffer.view.textOrigin = buffer.undoPreview.origTextOrigin
+	buffer.undoPreview = undoPreviewState{}
+	setInputMode(state, InputModeNormal)
+	SetStatusMsg(state, StatusMsg{})
+}
+
+func applyUndoPreviewOps(state *EditorState, ops []undo.Op, cursor uint64) {
+	region := selection.EmptyRegion
+	for _, op := range ops {
+		if err := applyOpFromUndoLog(state, op); err != nil {
+			log.Printf("Could not apply undo preview op %v: %v\n", op, err)
+			continue
+		}
+		region = regionAfterOp(region, op)
+	}
+
+	state.documentBuffer.undoPreview.changedRegion = region
+	MoveCursor(state, func(LocatorParams) uint64 {
+		return cursor
+	})
+	ScrollViewToCursor(state)
+}
+
+// regionAfterOp expands a region of changed text to include an op,
+// adjusting the region's positions to account for inserted or deleted text.
+// Deletions are represented by the character after the deleted text.
+func regionAfterOp(r selection.Region, op undo.Op) selection.Region {
+	pos := op.Position()
+	var opRegion selection.Region
+	if n := uint64(op.NumRunesToInsert()); n > 0 {
+		if r != selection.EmptyRegion {
+			if pos < r.StartPos {
+				r.StartPos += n
+			}
+			if pos < r.EndPos {
+				r.EndPos += n
+			}
+		}
+		opRegion = selection.Region{StartPos: pos, EndPos: pos + n}
+	} else if n := uint64(op.NumRunesToDelete()); n > 0 {
+		if r != selection.EmptyRegion {
+			r.StartPos = positionAfterDelete(r.StartPos, pos, n)
+			r.EndPos = positionAfterDelete(r.EndPos, pos, n)
+		}
+		opRegion = selection.Region{StartPos: pos, EndPos: pos + 1}
+	} else {
+		return r
+	}
+
+	if r == selection.EmptyRegion {
+		return opRegion
+	}
+	return selection.Region{
+		StartPos: min(r.StartPos, opRegion.StartPos),
+		EndPos:   max(r.EndPos, opRegion.EndPos),
+	}
+}
+
+func positionAfterDelete(x, pos, n uint64) uint64 {
+	if x <= pos {
+		return x
+	} else if x <= pos+n {
+		return pos
+	} else {
+		return x - n
+	}
+}
+
+func setUndoPreviewStatusMsg(state *EditorState) {
+	numSteps := state.documentBuffer.undoPreview.numSteps
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Previewing %d change(s) back. Press enter to undo or escape to cancel", numSteps),
+	})
+}
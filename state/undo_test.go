@@ -1,13 +1,22 @@
 package state
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/aretext/aretext/clipboard"
 	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/nondet"
+	"github.com/aretext/aretext/selection"
 	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/undo"
 )
 
 func TestUndoAndRedo(t *testing.T) {
@@ -129,3 +138,251 @@ func TestUnsavedChanges(t *testing.T) {
 	// Now there are unsaved changes.
 	assert.True(t, state.documentBuffer.undoLog.HasUnsavedChanges())
 }
+
+func TestUndoPreview(t *testing.T) {
+	setup := func() *EditorState {
+		state := NewEditorState(100, 100, nil, nil)
+
+		BeginUndoEntry(state)
+		InsertText(state, "abc")
+		CommitUndoEntry(state)
+
+		BeginUndoEntry(state)
+		InsertText(state, "def")
+		CommitUndoEntry(state)
+
+		BeginUndoEntry(state)
+		InsertText(state, "ghi")
+		CommitUndoEntry(state)
+		return state
+	}
+
+	t.Run("preview and cancel", func(t *testing.T) {
+		state := setup()
+		EnterUndoPreviewMode(state, 2)
+		assert.Equal(t, InputModeUndoPreview, state.InputMode())
+		assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+		assert.Equal(t, selection.Region{StartPos: 3, EndPos: 4}, state.documentBuffer.UndoPreviewRegion())
+
+		PreviewLaterChange(state, 1)
+		assert.Equal(t, "abcdef", state.documentBuffer.textTree.String())
+		assert.Equal(t, selection.Region{StartPos: 3, EndPos: 6}, state.documentBuffer.UndoPreviewRegion())
+
+		CancelUndoPreview(state)
+		assert.Equal(t, InputModeNormal, state.InputMode())
+		assert.Equal(t, "abcdefghi", state.documentBuffer.textTree.String())
+		assert.Equal(t, uint64(9), state.documentBuffer.cursor.position)
+		assert.Equal(t, selection.EmptyRegion, state.documentBuffer.UndoPreviewRegion())
+	})
+
+	t.Run("preview and commit", func(t *testing.T) {
+		state := setup()
+		EnterUndoPreviewMode(state, 1)
+		PreviewEarlierChange(state, 1)
+		assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+
+		CommitUndoPreview(state)
+		assert.Equal(t, InputModeNormal, state.InputMode())
+		assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+		assert.Equal(t, "Undid 2 change(s)", state.StatusMsg().Text)
+
+		// The undone changes can still be redone.
+		Redo(state)
+		assert.Equal(t, "abcdef", state.documentBuffer.textTree.String())
+	})
+
+	t.Run("preview past first change", func(t *testing.T) {
+		state := setup()
+		EnterUndoPreviewMode(state, 10)
+		assert.Equal(t, "", state.documentBuffer.textTree.String())
+
+		// Cannot move later than the document state before the preview.
+		PreviewLaterChange(state, 10)
+		assert.Equal(t, "abcdefghi", state.documentBuffer.textTree.String())
+		PreviewLaterChange(state, 1)
+		assert.Equal(t, "abcdefghi", state.documentBuffer.textTree.String())
+	})
+}
+
+func TestRegionAfterOp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		region   selection.Region
+		op       undo.Op
+		expected selection.Region
+	}{
+		{
+			name:     "insert into empty region",
+			region:   selection.EmptyRegion,
+			op:       undo.InsertOp(2, "abc"),
+			expected: selection.Region{StartPos: 2, EndPos: 5},
+		},
+		{
+			name:     "delete from empty region",
+			region:   selection.EmptyRegion,
+			op:       undo.DeleteOp(2, "abc"),
+			expected: selection.Region{StartPos: 2, EndPos: 3},
+		},
+		{
+			name:     "insert before region",
+			region:   selection.Region{StartPos: 5, EndPos: 7},
+			op:       undo.InsertOp(1, "ab"),
+			expected: selection.Region{StartPos: 1, EndPos: 9},
+		},
+		{
+			name:     "insert after region",
+			region:   selection.Region{StartPos: 1, EndPos: 3},
+			op:       undo.InsertOp(5, "ab"),
+			expected: selection.Region{StartPos: 1, EndPos: 7},
+		},
+		{
+			name:     "delete overlapping region",
+			region:   selection.Region{StartPos: 2, EndPos: 6},
+			op:       undo.DeleteOp(4, "abcd"),
+			expected: selection.Region{StartPos: 2, EndPos: 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, regionAfterOp(tc.region, tc.op))
+		})
+	}
+}
+
+func TestRevertChangesSince(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	restore := nondet.SetSource(nondet.NewDeterministicSource(start, time.Minute, 0))
+	defer restore()
+
+	state := NewEditorState(100, 100, nil, nil)
+	for _, s := range []string{"a", "b", "c", "d"} {
+		BeginUndoEntry(state)
+		InsertText(state, s)
+		CommitUndoEntry(state)
+	}
+	assert.Equal(t, "abcd", state.documentBuffer.textTree.String())
+
+	// The clock advances one minute each time it is read, so the changes were committed
+	// at minutes 0 through 3, and the revert happens at minute 4.
+	RevertChangesSince(state, 2)
+	assert.Equal(t, "ab", state.documentBuffer.textTree.String())
+	assert.Equal(t, "Reverted 2 change(s) from the last 2 minute(s): [untitled] (2)", state.StatusMsg().Text)
+
+	// Redo restores the reverted changes.
+	Redo(state)
+	Redo(state)
+	assert.Equal(t, "abcd", state.documentBuffer.textTree.String())
+}
+
+func TestRevertChangesSinceAllBuffers(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	setClock := func(now time.Time) {
+		t.Cleanup(nondet.SetSource(nondet.NewDeterministicSource(now, 0, 0)))
+	}
+
+	dir := t.TempDir()
+	pathA := filepath.Join(dir, "a.txt")
+	pathB := filepath.Join(dir, "b.txt")
+	require.NoError(t, os.WriteFile(pathA, []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(pathB, []byte("b"), 0644))
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer func() { state.fileWatcher.Stop() }()
+	insertAtEnd := func(s string) {
+		MoveCursor(state, func(p LocatorParams) uint64 { return p.TextTree.NumChars() })
+		BeginUndoEntry(state)
+		InsertText(state, s)
+		CommitUndoEntry(state)
+	}
+
+	// An hour ago, edit the first document.
+	setClock(start)
+	LoadDocument(state, pathA, true, startOfDocLocator)
+	insertAtEnd("1")
+
+	// Recently, edit both documents, leaving the first in the background.
+	setClock(start.Add(60 * time.Minute))
+	insertAtEnd("2")
+	LoadDocument(state, pathB, true, startOfDocLocator)
+	insertAtEnd("3")
+	insertAtEnd("4")
+
+	setClock(start.Add(70 * time.Minute))
+	RevertChangesSince(state, 30)
+	expectedMsg := fmt.Sprintf("Reverted 3 change(s) from the last 30 minute(s): %s (1), %s (2)", bufferName(pathA), bufferName(pathB))
+	assert.Equal(t, expectedMsg, state.StatusMsg().Text)
+	assert.Equal(t, "b", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(1), state.documentBuffer.cursor.position)
+
+	// The first document keeps the change from before the cutoff.
+	LoadDocument(state, pathA, true, startOfDocLocator)
+	assert.Equal(t, "a1", state.documentBuffer.textTree.String())
+	assert.True(t, state.documentBuffer.undoLog.HasUnsavedChanges())
+
+	// Redo restores the reverted change in the background document.
+	Redo(state)
+	assert.Equal(t, "a12", state.documentBuffer.textTree.String())
+}
+
+func TestMoveToOlderAndNewerUndoState(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	for _, s := range []string{"a", "b"} {
+		BeginUndoEntry(state)
+		InsertText(state, s)
+		CommitUndoEntry(state)
+	}
+
+	// Undo, then edit, so "b" is only on the first branch.
+	Undo(state)
+	BeginUndoEntry(state)
+	InsertText(state, "c")
+	CommitUndoEntry(state)
+	assert.Equal(t, "ac", state.documentBuffer.textTree.String())
+
+	// Redo can't reach "b", but moving to an older state can.
+	Redo(state)
+	assert.Equal(t, "ac", state.documentBuffer.textTree.String())
+	MoveToOlderUndoState(state, 1)
+	assert.Equal(t, "ab", state.documentBuffer.textTree.String())
+	assert.Equal(t, "Undo state 2 of 3", state.StatusMsg().Text)
+
+	MoveToOlderUndoState(state, 5)
+	assert.Equal(t, "", state.documentBuffer.textTree.String())
+	assert.Equal(t, "Undo state 0 of 3", state.StatusMsg().Text)
+
+	MoveToNewerUndoState(state, 2)
+	assert.Equal(t, "ab", state.documentBuffer.textTree.String())
+	MoveToNewerUndoState(state, 5)
+	assert.Equal(t, "ac", state.documentBuffer.textTree.String())
+	assert.Equal(t, "Undo state 3 of 3", state.StatusMsg().Text)
+}
+
+func TestShowUndoBranchesMenu(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	ShowUndoBranchesMenu(state)
+	assert.Equal(t, "No changes to undo", state.StatusMsg().Text)
+
+	for _, s := range []string{"a", "b"} {
+		BeginUndoEntry(state)
+		InsertText(state, s)
+		CommitUndoEntry(state)
+	}
+	Undo(state)
+	BeginUndoEntry(state)
+	InsertText(state, "c")
+	CommitUndoEntry(state)
+
+	ShowUndoBranchesMenu(state)
+	results, _ := state.Menu().SearchResults()
+	assert.Equal(t, 2, len(results))
+	assert.Contains(t, results[0].Name, "state 3: 2 change(s), ")
+	assert.True(t, strings.HasSuffix(results[0].Name, " (current)"))
+	assert.Contains(t, results[1].Name, "state 2: 2 change(s), ")
+
+	// Selecting the older branch restores the undone change.
+	MoveMenuSelection(state, 1)
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, "ab", state.documentBuffer.textTree.String())
+	assert.Equal(t, "Undo state 2 of 3", state.StatusMsg().Text)
+}
@@ -1,7 +1,11 @@
 package state
 
 import (
+	"io"
+
+	"github.com/aretext/aretext/cellwidth"
 	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/text/segment"
 )
 
 // Scroll direction represents the direction of the scroll (forward or backward).
@@ -16,12 +20,7 @@ const (
 func ResizeView(state *EditorState, width, height uint64) {
 	state.screenWidth = width
 	state.screenHeight = height
-	state.documentBuffer.view.width = state.screenWidth
-	state.documentBuffer.view.height = 0
-	if height > 0 {
-		// Leave one line for the status bar at the bottom.
-		state.documentBuffer.view.height = height - 1
-	}
+	layoutSplits(state)
 }
 
 // ScrollViewToCursor moves the view origin so that the cursor is visible.
@@ -37,6 +36,7 @@ func scrollViewToPosition(buffer *BufferState, pos uint64) {
 		buffer.LineWrapConfig(),
 		buffer.view.textOrigin,
 		buffer.view.height)
+	scrollViewHorizontallyToPosition(buffer, pos)
 }
 
 // ScrollViewByNumLines moves the view origin up or down by the specified number of lines.
@@ -68,3 +68,53 @@ func ScrollViewByNumLines(state *EditorState, direction ScrollDirection, numLine
 
 	buffer.view.textOrigin = buffer.textTree.LineStartPosition(lineNum)
 }
+
+// scrollViewHorizontallyToPosition adjusts the horizontal scroll offset so the position is visible.
+func scrollViewHorizontallyToPosition(buffer *BufferState, pos uint64) {
+	if !buffer.lineWrapDisabled {
+		buffer.view.leftCol = 0
+		return
+	}
+
+	textWidth := buffer.view.width - buffer.LineNumMarginWidth() - buffer.DiffGutterWidth() - buffer.ScrollbarWidth()
+	if textWidth == 0 {
+		return
+	}
+
+	startCol, endCol := columnRangeForPosition(buffer, pos)
+	if startCol < buffer.view.leftCol {
+		buffer.view.leftCol = startCol
+	} else if endCol > buffer.view.leftCol+textWidth {
+		buffer.view.leftCol = endCol - textWidth
+		if buffer.view.leftCol > startCol {
+			// The grapheme cluster is wider than the view, so show its start.
+			buffer.view.leftCol = startCol
+		}
+	}
+}
+
+// columnRangeForPosition returns the cells occupied by the grapheme cluster at a position,
+// measured from the start of the line.
+// At the end of a line, this is the single cell where the cursor would be displayed.
+func columnRangeForPosition(buffer *BufferState, pos uint64) (uint64, uint64) {
+	tree := buffer.textTree
+	tabSize := buffer.TabDisplaySize()
+	lineStartPos := tree.LineStartPosition(tree.LineNumForPosition(pos))
+	gcIter := segment.NewGraphemeClusterIter(tree.ReaderAtPosition(lineStartPos))
+	seg := segment.Empty()
+	var col uint64
+	for p := lineStartPos; ; p += seg.NumRunes() {
+		err := gcIter.NextSegment(seg)
+		if err == io.EOF || (err == nil && p == pos && seg.HasNewline()) {
+			return col, col + 1
+		} else if err != nil {
+			panic(err) // Should never happen because the document is valid UTF-8.
+		}
+
+		gcWidth := cellwidth.GraphemeClusterWidth(seg.Runes(), col, tabSize)
+		if p+seg.NumRunes() > pos {
+			return col, col + gcWidth
+		}
+		col += gcWidth
+	}
+}
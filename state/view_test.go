@@ -78,3 +78,102 @@ func TestScrollViewByNumLines(t *testing.T) {
 		})
 	}
 }
+
+func TestScrollViewHorizontallyToPosition(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputString     string
+		initialLeftCol  uint64
+		pos             uint64
+		expectedLeftCol uint64
+	}{
+		{
+			name:            "visible",
+			inputString:     "abcdefghijklmnopqrstuvwxyz",
+			initialLeftCol:  0,
+			pos:             5,
+			expectedLeftCol: 0,
+		},
+		{
+			name:            "right of view",
+			inputString:     "abcdefghijklmnopqrstuvwxyz",
+			initialLeftCol:  0,
+			pos:             15,
+			expectedLeftCol: 6,
+		},
+		{
+			name:            "left of view",
+			inputString:     "abcdefghijklmnopqrstuvwxyz",
+			initialLeftCol:  12,
+			pos:             3,
+			expectedLeftCol: 3,
+		},
+		{
+			name:            "end of line",
+			inputString:     "abcdefghijklmnopqrstuvwxyz",
+			initialLeftCol:  0,
+			pos:             26,
+			expectedLeftCol: 17,
+		},
+		{
+			name:            "wide characters",
+			inputString:     "界界界界界界界界",
+			initialLeftCol:  0,
+			pos:             6,
+			expectedLeftCol: 4,
+		},
+		{
+			name:            "tab",
+			inputString:     "\t\t\tab",
+			initialLeftCol:  0,
+			pos:             4,
+			expectedLeftCol: 4,
+		},
+		{
+			name:            "second line",
+			inputString:     "abcdefghijklmnopqrstuvwxyz\nab",
+			initialLeftCol:  10,
+			pos:             28,
+			expectedLeftCol: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			buffer := &BufferState{
+				textTree:         textTree,
+				tabSize:          4,
+				lineWrapDisabled: true,
+				view:             viewState{width: 10, height: 10, leftCol: tc.initialLeftCol},
+			}
+			scrollViewHorizontallyToPosition(buffer, tc.pos)
+			assert.Equal(t, tc.expectedLeftCol, buffer.view.leftCol)
+		})
+	}
+}
+
+func TestResizeViewToZeroSize(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc\ndef\nghi")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	state.documentBuffer.showLineNum = true
+
+	for _, size := range [][2]uint64{{0, 0}, {0, 10}, {10, 0}, {1, 1}, {2, 2}} {
+		ResizeView(state, size[0], size[1])
+		ScrollViewToCursor(state)
+		MoveCursor(state, func(p LocatorParams) uint64 { return 6 })
+		MoveCursorToLineAbove(state, 1)
+		ScrollViewByNumLines(state, ScrollDirectionForward, 1)
+		InsertText(state, "x")
+	}
+
+	// The view recovers once the screen is large enough.
+	ResizeView(state, 100, 100)
+	ScrollViewToCursor(state)
+	assert.Equal(t, uint64(100), state.documentBuffer.view.width)
+	assert.Equal(t, uint64(0), state.documentBuffer.view.textOrigin)
+	assert.Equal(t, uint64(97), state.documentBuffer.LineWrapConfig().MaxLineWidth)
+}
@@ -0,0 +1,324 @@
+package state
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/cellwidth"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/syntax"
+)
+
+// WrapDocument hard-wraps every paragraph in the document so no line exceeds maxColumns cells.
+// Paragraphs are separated by blank lines. In markdown documents, code blocks, headings,
+// tables, and quotes are left unchanged, and list items are wrapped separately.
+// Words longer than maxColumns are placed on their own line.
+func WrapDocument(state *EditorState, maxColumns uint64) {
+	tabSize := state.documentBuffer.tabSize
+	checkpoint := beginUndoCheckpoint(state, "document wrap")
+	numChanged := reformatParagraphs(state, func(p wrapParagraph) string {
+		return p.wrapped(maxColumns, tabSize)
+	})
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Wrapped %d paragraph(s) at %d columns", numChanged, maxColumns),
+	})
+	commitUndoCheckpoint(state, checkpoint)
+}
+
+// UnwrapDocument joins the lines of every paragraph in the document into a single line.
+// It uses the same rules as WrapDocument to find paragraph boundaries.
+func UnwrapDocument(state *EditorState) {
+	checkpoint := beginUndoCheckpoint(state, "document unwrap")
+	numChanged := reformatParagraphs(state, func(p wrapParagraph) string {
+		return p.unwrapped()
+	})
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Unwrapped %d paragraph(s)", numChanged),
+	})
+	commitUndoCheckpoint(state, checkpoint)
+}
+
+// reformatParagraphs replaces the text of each paragraph in the document with the output of formatFunc.
+// Only paragraphs whose text changes are edited, and all edits are grouped into a single undo entry.
+// It returns the number of paragraphs that changed.
+func reformatParagraphs(state *EditorState, formatFunc func(wrapParagraph) string) int {
+	buffer := state.documentBuffer
+	markdown := (buffer.syntaxLanguage == syntax.LanguageMarkdown ||
+		buffer.syntaxLanguage == syntax.LanguageCriticMarkup)
+
+	lines, err := readLinesForWrap(buffer)
+	if err != nil {
+		log.Printf("Error reading document lines: %v\n", err)
+		return 0
+	}
+
+	paragraphs := wrapParagraphsFromLines(lines, markdown)
+
+	// Apply edits in reverse order so that the positions of earlier paragraphs remain valid.
+	var numChanged int
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	BeginUndoEntry(state)
+	for i := len(paragraphs) - 1; i >= 0; i-- {
+		p := paragraphs[i]
+		newText := formatFunc(p)
+		if newText == p.text() {
+			continue
+		}
+		if _, err := replaceRunes(state, p.startPos(), p.numRunes(), newText, true); err != nil {
+			log.Printf("Error inserting reformatted paragraph: %v\n", err)
+			break
+		}
+		numChanged++
+	}
+	CommitUndoEntry(state)
+
+	if numChanged > 0 {
+		lineNum := locate.ClosestValidLineNum(buffer.textTree, cursorLineNum)
+		buffer.cursor = cursorState{position: locate.StartOfLineNum(buffer.textTree, lineNum)}
+		ScrollViewToCursor(state)
+	}
+
+	return numChanged
+}
+
+// wrapLine is a line in the document, excluding the line feed.
+type wrapLine struct {
+	text string
+	pos  uint64
+}
+
+func (line wrapLine) isBlank() bool {
+	return strings.TrimSpace(line.text) == ""
+}
+
+func (line wrapLine) indentation() string {
+	return line.text[:len(line.text)-len(strings.TrimLeftFunc(line.text, unicode.IsSpace))]
+}
+
+func readLinesForWrap(buffer *BufferState) ([]wrapLine, error) {
+	var lines []wrapLine
+	var pos uint64
+	treeReader := buffer.textTree.ReaderAtPosition(0)
+	reader := bufio.NewReader(&treeReader)
+	for {
+		s, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if len(s) > 0 || err == nil {
+			line := wrapLine{text: strings.TrimSuffix(s, "\n"), pos: pos}
+			lines = append(lines, line)
+			pos += uint64(utf8.RuneCountInString(s))
+		}
+
+		if err == io.EOF {
+			return lines, nil
+		}
+	}
+}
+
+// wrapParagraph is a sequence of consecutive lines that can be wrapped or unwrapped together.
+type wrapParagraph struct {
+	lines []wrapLine
+
+	// hangingIndent is the indentation for lines after the first, if the paragraph is wrapped.
+	hangingIndent string
+}
+
+func (p wrapParagraph) startPos() uint64 {
+	return p.lines[0].pos
+}
+
+func (p wrapParagraph) numRunes() uint64 {
+	return uint64(utf8.RuneCountInString(p.text()))
+}
+
+func (p wrapParagraph) text() string {
+	var sb strings.Builder
+	for i, line := range p.lines {
+		if i > 0 {
+			sb.WriteRune('\n')
+		}
+		sb.WriteString(line.text)
+	}
+	return sb.String()
+}
+
+func (p wrapParagraph) words() []string {
+	var words []string
+	for _, line := range p.lines {
+		words = append(words, strings.Fields(line.text)...)
+	}
+	return words
+}
+
+func (p wrapParagraph) unwrapped() string {
+	return p.lines[0].indentation() + strings.Join(p.words(), " ")
+}
+
+func (p wrapParagraph) wrapped(maxColumns uint64, tabSize uint64) string {
+	var sb strings.Builder
+	indent := p.lines[0].indentation()
+	sb.WriteString(indent)
+	col := stringWidthForWrap(indent, 0, tabSize)
+	lineHasWord := false
+	for _, word := range p.words() {
+		wordWidth := stringWidthForWrap(word, col+1, tabSize)
+		if lineHasWord && col+1+wordWidth > maxColumns {
+			sb.WriteRune('\n')
+			sb.WriteString(p.hangingIndent)
+			col = stringWidthForWrap(p.hangingIndent, 0, tabSize)
+			lineHasWord = false
+		}
+		if lineHasWord {
+			sb.WriteRune(' ')
+			col++
+		}
+		sb.WriteString(word)
+		col += stringWidthForWrap(word, col, tabSize)
+		lineHasWord = true
+	}
+	return sb.String()
+}
+
+func stringWidthForWrap(s string, offset uint64, tabSize uint64) uint64 {
+	var w uint64
+	for _, r := range s {
+		w += cellwidth.GraphemeClusterWidth([]rune{r}, offset+w, tabSize)
+	}
+	return w
+}
+
+// wrapParagraphsFromLines groups lines into paragraphs.
+// Blank lines separate paragraphs and are never changed.
+// For markdown, fenced and indented code blocks, headings, tables, quotes, html,
+// and thematic breaks are excluded, and each list item starts a new paragraph.
+func wrapParagraphsFromLines(lines []wrapLine, markdown bool) []wrapParagraph {
+	var paragraphs []wrapParagraph
+	var current *wrapParagraph
+	var fence string
+
+	endParagraph := func() {
+		if current != nil {
+			paragraphs = append(paragraphs, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range lines {
+		if markdown {
+			trimmed := strings.TrimSpace(line.text)
+			if fence != "" {
+				if strings.HasPrefix(trimmed, fence) {
+					fence = ""
+				}
+				continue
+			}
+
+			if f := markdownCodeFence(trimmed); f != "" {
+				endParagraph()
+				fence = f
+				continue
+			}
+
+			if current == nil && isMarkdownIndentedCode(line.text) {
+				continue
+			}
+
+			if isMarkdownPreservedLine(trimmed) {
+				endParagraph()
+				continue
+			}
+
+			if markerLen := markdownListMarkerLen(trimmed); markerLen > 0 {
+				endParagraph()
+				indent := line.indentation()
+				current = &wrapParagraph{
+					lines:         []wrapLine{line},
+					hangingIndent: indent + strings.Repeat(" ", markerLen),
+				}
+				continue
+			}
+		}
+
+		if line.isBlank() {
+			endParagraph()
+			continue
+		}
+
+		if current == nil {
+			current = &wrapParagraph{
+				lines:         []wrapLine{line},
+				hangingIndent: line.indentation(),
+			}
+			continue
+		}
+
+		if len(current.lines) == 1 && current.hangingIndent == current.lines[0].indentation() {
+			// Use the indentation of the second line for wrapped lines,
+			// which preserves hanging indents in the original text.
+			current.hangingIndent = line.indentation()
+		}
+		current.lines = append(current.lines, line)
+	}
+	endParagraph()
+
+	return paragraphs
+}
+
+func markdownCodeFence(trimmed string) string {
+	for _, f := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, f) {
+			return f
+		}
+	}
+	return ""
+}
+
+func isMarkdownIndentedCode(s string) bool {
+	return strings.HasPrefix(s, "\t") || strings.HasPrefix(s, "    ")
+}
+
+func isMarkdownPreservedLine(trimmed string) bool {
+	if trimmed == "" {
+		return false
+	}
+
+	switch trimmed[0] {
+	case '#', '|', '>', '<':
+		return true
+	}
+
+	// Thematic breaks like "---", "***", or "___".
+	if len(trimmed) >= 3 && strings.Trim(trimmed, "-*_ ") == "" {
+		return true
+	}
+
+	return false
+}
+
+// markdownListMarkerLen returns the length of the list item marker at the start of the line,
+// including the following space, or zero if the line is not a list item.
+func markdownListMarkerLen(trimmed string) int {
+	if len(trimmed) >= 2 && strings.ContainsRune("-*+", rune(trimmed[0])) && trimmed[1] == ' ' {
+		return 2
+	}
+
+	i := 0
+	for i < len(trimmed) && trimmed[i] >= '0' && trimmed[i] <= '9' {
+		i++
+	}
+	if i > 0 && i+1 < len(trimmed) && (trimmed[i] == '.' || trimmed[i] == ')') && trimmed[i+1] == ' ' {
+		return i + 2
+	}
+
+	return 0
+}
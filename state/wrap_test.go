@@ -0,0 +1,170 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+func TestWrapDocument(t *testing.T) {
+	testCases := []struct {
+		name         string
+		language     syntax.Language
+		inputString  string
+		maxColumns   uint64
+		expectedText string
+	}{
+		{
+			name:         "empty document",
+			inputString:  "",
+			maxColumns:   10,
+			expectedText: "",
+		},
+		{
+			name:         "short paragraph unchanged",
+			inputString:  "foo bar\n",
+			maxColumns:   10,
+			expectedText: "foo bar\n",
+		},
+		{
+			name:         "wrap long line",
+			inputString:  "the quick brown fox jumps over the lazy dog\n",
+			maxColumns:   10,
+			expectedText: "the quick\nbrown fox\njumps over\nthe lazy\ndog\n",
+		},
+		{
+			name:         "rewrap short lines",
+			inputString:  "the\nquick\nbrown\nfox\n",
+			maxColumns:   10,
+			expectedText: "the quick\nbrown fox\n",
+		},
+		{
+			name:         "preserve blank lines between paragraphs",
+			inputString:  "aaa bbb ccc\n\n\nddd eee fff",
+			maxColumns:   8,
+			expectedText: "aaa bbb\nccc\n\n\nddd eee\nfff",
+		},
+		{
+			name:         "word longer than max columns",
+			inputString:  "a abcdefghijkl b",
+			maxColumns:   5,
+			expectedText: "a\nabcdefghijkl\nb",
+		},
+		{
+			name:         "preserve indentation",
+			inputString:  "  aaa bbb ccc ddd",
+			maxColumns:   10,
+			expectedText: "  aaa bbb\n  ccc ddd",
+		},
+		{
+			name:         "preserve hanging indentation",
+			inputString:  "aaa bbb\n    ccc ddd eee",
+			maxColumns:   12,
+			expectedText: "aaa bbb ccc\n    ddd eee",
+		},
+		{
+			name:         "markdown code block unchanged",
+			language:     syntax.LanguageMarkdown,
+			inputString:  "aaa\nbbb\n\n```\nfoo bar baz\nqux\n```\n\nccc\nddd",
+			maxColumns:   8,
+			expectedText: "aaa bbb\n\n```\nfoo bar baz\nqux\n```\n\nccc ddd",
+		},
+		{
+			name:         "markdown indented code unchanged",
+			language:     syntax.LanguageMarkdown,
+			inputString:  "aaa\n\n    foo bar baz\n    qux",
+			maxColumns:   8,
+			expectedText: "aaa\n\n    foo bar baz\n    qux",
+		},
+		{
+			name:         "markdown heading unchanged",
+			language:     syntax.LanguageMarkdown,
+			inputString:  "# heading text here\naaa\nbbb",
+			maxColumns:   8,
+			expectedText: "# heading text here\naaa bbb",
+		},
+		{
+			name:         "markdown list items",
+			language:     syntax.LanguageMarkdown,
+			inputString:  "- aaa bbb ccc\n- ddd\n1. eee fff ggg",
+			maxColumns:   9,
+			expectedText: "- aaa bbb\n  ccc\n- ddd\n1. eee\n   fff\n   ggg",
+		},
+		{
+			name:         "plaintext fence wrapped",
+			inputString:  "```\nfoo bar\n```",
+			maxColumns:   20,
+			expectedText: "``` foo bar ```",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			if tc.language != "" {
+				state.documentBuffer.syntaxLanguage = tc.language
+			}
+			WrapDocument(state, tc.maxColumns)
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, StatusMsgStyleSuccess, state.StatusMsg().Style)
+		})
+	}
+}
+
+func TestUnwrapDocument(t *testing.T) {
+	testCases := []struct {
+		name         string
+		language     syntax.Language
+		inputString  string
+		expectedText string
+	}{
+		{
+			name:         "join paragraph lines",
+			inputString:  "aaa\nbbb  ccc\nddd\n\n\neee\n  fff\n",
+			expectedText: "aaa bbb ccc ddd\n\n\neee fff\n",
+		},
+		{
+			name:         "preserve indentation of first line",
+			inputString:  "  aaa\n  bbb",
+			expectedText: "  aaa bbb",
+		},
+		{
+			name:         "markdown",
+			language:     syntax.LanguageMarkdown,
+			inputString:  "# heading\naaa\nbbb\n\n~~~\nfoo\nbar\n~~~\n- ccc\n  ddd\n- eee\n> quote\n> text",
+			expectedText: "# heading\naaa bbb\n\n~~~\nfoo\nbar\n~~~\n- ccc ddd\n- eee\n> quote\n> text",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			if tc.language != "" {
+				state.documentBuffer.syntaxLanguage = tc.language
+			}
+			UnwrapDocument(state)
+			assert.Equal(t, tc.expectedText, textTree.String())
+		})
+	}
+}
+
+func TestWrapDocumentUndo(t *testing.T) {
+	textTree, err := text.NewTreeFromString("aaa bbb\n\nccc ddd")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	WrapDocument(state, 4)
+	assert.Equal(t, "aaa\nbbb\n\nccc\nddd", textTree.String())
+	Undo(state)
+	assert.Equal(t, "aaa bbb\n\nccc ddd", state.documentBuffer.textTree.String())
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/aretext/aretext/syntax/parser"
 )
 
+const golangTokenRoleBuiltin = parser.TokenRoleCustom1
+
 // GolangParseFunc returns a parse func for Go.
 // See "The Go Programming Language Specification"
 // https://golang.org/ref/spec
@@ -52,7 +54,8 @@ func golangIdentifierOrKeywordParseFunc() parser.Func {
 	}
 	return consumeSingleRuneLike(isLetter).
 		ThenMaybe(consumeRunesLike(isLetterOrDigit)).
-		MapWithInput(recognizeKeywordOrConsume(append(keywords, predeclaredIdentifiers...)))
+		MapWithInput(recognizeKeywordOrConsume(keywords)).
+		MapWithInput(recognizeWordWithRoleOrConsume(golangTokenRoleBuiltin, predeclaredIdentifiers))
 }
 
 func golangOperatorParseFunc() parser.Func {
@@ -40,7 +40,7 @@ func TestGolangParseFunc(t *testing.T) {
 			text: `var foo []int`,
 			expected: []TokenWithText{
 				{Text: "var", Role: parser.TokenRoleKeyword},
-				{Text: "int", Role: parser.TokenRoleKeyword},
+				{Text: "int", Role: golangTokenRoleBuiltin},
 			},
 		},
 		{
@@ -533,8 +533,8 @@ interface {
 			expected: []TokenWithText{
 				{Text: "interface", Role: parser.TokenRoleKeyword},
 				{Text: "~", Role: parser.TokenRoleOperator},
-				{Text: "int", Role: parser.TokenRoleKeyword},
-				{Text: "string", Role: parser.TokenRoleKeyword},
+				{Text: "int", Role: golangTokenRoleBuiltin},
+				{Text: "string", Role: golangTokenRoleBuiltin},
 			},
 		},
 	}
@@ -263,19 +263,25 @@ func consumeLongestMatchingOption(options []string) parser.Func {
 // recognizeKeywordOrConsume recognizes a keyword from the list of `keywords`.
 // If no keywords match, the result is returned unmodified.
 func recognizeKeywordOrConsume(keywords []string) parser.MapWithInputFn {
-	// Calculate the length of the longest keyword to limit how much
+	return recognizeWordWithRoleOrConsume(parser.TokenRoleKeyword, keywords)
+}
+
+// recognizeWordWithRoleOrConsume recognizes a word from the list of `words` as a token with the given role.
+// If no words match, the result is returned unmodified.
+func recognizeWordWithRoleOrConsume(role parser.TokenRole, words []string) parser.MapWithInputFn {
+	// Calculate the length of the longest word to limit how much
 	// of the input needs to be reprocessed.
-	maxLength := maxStrLen(keywords)
+	maxLength := maxStrLen(words)
 	return func(result parser.Result, iter parser.TrackingRuneIter, state parser.State) parser.Result {
 		if result.NumConsumed > maxLength {
 			return result
 		}
 
 		s := readInputString(iter, result.NumConsumed)
-		for _, kw := range keywords {
-			if kw == s {
+		for _, w := range words {
+			if w == s {
 				token := parser.ComputedToken{
-					Role:   parser.TokenRoleKeyword,
+					Role:   role,
 					Length: result.NumConsumed,
 				}
 				return parser.Result{
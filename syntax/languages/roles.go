@@ -0,0 +1,99 @@
+package languages
+
+import "github.com/aretext/aretext/syntax/parser"
+
+// These functions name the language-specific token roles produced by each parser.
+// The names can be used in the config to set the style for a token role in a language.
+
+func BashTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenVariable":           bashTokenRoleVariable,
+		"tokenBackquoteExpansion": bashTokenRoleBackquoteExpansion,
+	}
+}
+
+func CTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenPreprocessorDirective": cTokenRolePreprocessorDirective,
+	}
+}
+
+func CriticMarkupTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenAddition":     criticMarkupAddRole,
+		"tokenDeletion":     criticMarkupDelRole,
+		"tokenSubstitution": criticMarkupSubRole,
+		"tokenHighlight":    criticMarkupHighlightRole,
+	}
+}
+
+func GolangTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenBuiltin": golangTokenRoleBuiltin,
+	}
+}
+
+func JsonTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenKey": jsonTokenRoleKey,
+	}
+}
+
+func MakefileTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenVariable": makefileTokenRoleVariable,
+		"tokenPattern":  makefileTokenRolePattern,
+	}
+}
+
+func MarkdownTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenHeading":        markdownHeadingRole,
+		"tokenEmphasis":       markdownEmphasisRole,
+		"tokenStrongEmphasis": markdownStrongEmphasisRole,
+		"tokenLink":           markdownLinkRole,
+	}
+}
+
+func P4TokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenPreprocessorDirective": p4TokenRolePreprocessorDirective,
+		"tokenAnnotation":            p4TokenRoleAnnotation,
+	}
+}
+
+func RustTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenLifetime":  rustTokenRoleLifetime,
+		"tokenAttribute": rustTokenRoleAttribute,
+	}
+}
+
+func TodoTxtTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenCompletedTask": todoTxtCompletedTaskRole,
+		"tokenPriority":      todoTxtPriorityRole,
+		"tokenDate":          todoTxtDateRole,
+		"tokenProjectTag":    todoTxtProjectTagRole,
+		"tokenContextTag":    todoTxtContextTagRole,
+		"tokenKeyTag":        todoTxtKeyTagRole,
+		"tokenValTag":        todoTxtValTagRole,
+	}
+}
+
+func XmlTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenAttrKey":         xmlTokenRoleAttrKey,
+		"tokenCharacterEntity": xmlTokenRoleCharacterEntity,
+		"tokenCData":           xmlTokenRoleCData,
+		"tokenTag":             xmlTokenRoleTag,
+		"tokenPrologue":        xmlTokenRolePrologue,
+	}
+}
+
+func YamlTokenRoleNames() map[string]parser.TokenRole {
+	return map[string]parser.TokenRole{
+		"tokenKey":           yamlTokenRoleKey,
+		"tokenAliasOrAnchor": yamlTokenRoleAliasOrAnchor,
+	}
+}
@@ -6,13 +6,17 @@ import (
 	"github.com/aretext/aretext/syntax/parser"
 )
 
-const rustTokenRoleLifetime = parser.TokenRoleCustom1
+const (
+	rustTokenRoleLifetime  = parser.TokenRoleCustom1
+	rustTokenRoleAttribute = parser.TokenRoleCustom2
+)
 
 // RustParseFunc returns a parse func for Rust.
 // See "The Rust Reference"
 // https://doc.rust-lang.org/stable/reference/
 func RustParseFunc() parser.Func {
 	return rustCommentParseFunc().
+		Or(rustAttributeParseFunc()).
 		Or(rustOperatorParseFunc()).
 		Or(rustLifetimeParseFunc()).
 		Or(rustStringLiteralParseFunc()).
@@ -24,13 +28,100 @@ func rustCommentParseFunc() parser.Func {
 	// These rules implicitly covers the doc forms ("//!", "/*!", ...)
 	consumeLineComment := consumeString("//").
 		ThenMaybe(consumeToNextLineFeed)
-	consumeBlockComment := consumeString("/*").
-		Then(consumeToString("*/"))
 	return consumeLineComment.
-		Or(consumeBlockComment).
+		Or(rustConsumeBlockComment).
 		Map(recognizeToken(parser.TokenRoleComment))
 }
 
+// rustConsumeBlockComment consumes a block comment.
+// Unlike C, block comments in Rust can be nested, so "/* a /* b */ c */" is a single comment.
+func rustConsumeBlockComment(iter parser.TrackingRuneIter, state parser.State) parser.Result {
+	var n uint64
+	var depth int
+	var prev rune
+	for {
+		r, err := iter.NextRune()
+		if err != nil {
+			return parser.FailedResult
+		}
+
+		n++
+		if (n == 1 && r != '/') || (n == 2 && r != '*') {
+			return parser.FailedResult
+		}
+
+		if prev == '/' && r == '*' {
+			depth++
+			prev = 0 // Don't treat "/*/" as an opening and closing delimiter.
+		} else if prev == '*' && r == '/' {
+			depth--
+			if depth == 0 {
+				return parser.Result{
+					NumConsumed: n,
+					NextState:   state,
+				}
+			}
+			prev = 0
+		} else {
+			prev = r
+		}
+	}
+}
+
+func rustAttributeParseFunc() parser.Func {
+	return consumeString("#").
+		ThenMaybe(consumeString("!")).
+		Then(rustConsumeAttributeBrackets).
+		Map(recognizeToken(rustTokenRoleAttribute))
+}
+
+// rustConsumeAttributeBrackets consumes the brackets of an outer attribute ("#[derive(Debug)]")
+// or inner attribute ("#![allow(unused)]"), which may span multiple lines.
+// Brackets within the attribute must be balanced, except in string literals like `#[doc = "]"]`.
+func rustConsumeAttributeBrackets(iter parser.TrackingRuneIter, state parser.State) parser.Result {
+	r, err := iter.NextRune()
+	if err != nil || r != '[' {
+		return parser.FailedResult
+	}
+
+	n := uint64(1)
+	depth := 1
+	var inString, inEscapeSeq bool
+	for {
+		r, err := iter.NextRune()
+		if err != nil {
+			return parser.FailedResult
+		}
+		n++
+
+		if inString {
+			if inEscapeSeq {
+				inEscapeSeq = false
+			} else if r == '\\' {
+				inEscapeSeq = true
+			} else if r == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch r {
+		case '"':
+			inString = true
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return parser.Result{
+					NumConsumed: n,
+					NextState:   state,
+				}
+			}
+		}
+	}
+}
+
 func rustOperatorParseFunc() parser.Func {
 	return consumeLongestMatchingOption([]string{
 		"@", "#", "$", "?", ":", "::",
@@ -70,6 +70,82 @@ func TestRustParseFunc(t *testing.T) {
 				{Text: "/*! foo \n bar */", Role: parser.TokenRoleComment},
 			},
 		},
+		{
+			name: "nested block comment",
+			text: "/* a /* b */ c */ x",
+			expected: []TokenWithText{
+				{Text: "/* a /* b */ c */", Role: parser.TokenRoleComment},
+			},
+		},
+		{
+			name: "multi-line nested block comment",
+			text: "/*\n/* inner\n*/\nlet x = 1;\n*/",
+			expected: []TokenWithText{
+				{Text: "/*\n/* inner\n*/\nlet x = 1;\n*/", Role: parser.TokenRoleComment},
+			},
+		},
+		{
+			name: "block comment with slash after opening delimiter",
+			text: "/*/ a */",
+			expected: []TokenWithText{
+				{Text: "/*/ a */", Role: parser.TokenRoleComment},
+			},
+		},
+		{
+			name: "unterminated nested block comment",
+			text: "/* a /* b */",
+			expected: []TokenWithText{
+				{Text: "/", Role: parser.TokenRoleOperator},
+				{Text: "*", Role: parser.TokenRoleOperator},
+				{Text: "/* b */", Role: parser.TokenRoleComment},
+			},
+		},
+		{
+			name: "outer attribute",
+			text: "#[derive(Debug, Clone)]\nstruct Foo;",
+			expected: []TokenWithText{
+				{Text: "#[derive(Debug, Clone)]", Role: rustTokenRoleAttribute},
+				{Text: "struct", Role: parser.TokenRoleKeyword},
+			},
+		},
+		{
+			name: "inner attribute",
+			text: "#![allow(unused)]",
+			expected: []TokenWithText{
+				{Text: "#![allow(unused)]", Role: rustTokenRoleAttribute},
+			},
+		},
+		{
+			name: "attribute with nested brackets and string",
+			text: `#[doc = "a ] b"] #[cfg(any(x, y = ["z"]))]`,
+			expected: []TokenWithText{
+				{Text: `#[doc = "a ] b"]`, Role: rustTokenRoleAttribute},
+				{Text: `#[cfg(any(x, y = ["z"]))]`, Role: rustTokenRoleAttribute},
+			},
+		},
+		{
+			name: "multi-line attribute",
+			text: "#[cfg_attr(\n    test,\n    derive(Debug)\n)]",
+			expected: []TokenWithText{
+				{Text: "#[cfg_attr(\n    test,\n    derive(Debug)\n)]", Role: rustTokenRoleAttribute},
+			},
+		},
+		{
+			name: "unterminated attribute",
+			text: "#[derive",
+			expected: []TokenWithText{
+				{Text: "#", Role: parser.TokenRoleOperator},
+			},
+		},
+		{
+			name: "character escape",
+			text: `'\n' '\'' '\u{1F600}'`,
+			expected: []TokenWithText{
+				{Text: `'\n'`, Role: parser.TokenRoleString},
+				{Text: `'\''`, Role: parser.TokenRoleString},
+				{Text: `'\u{1F600}'`, Role: parser.TokenRoleString},
+			},
+		},
 		{
 			name: "character",
 			text: "'H'",
@@ -14,3 +14,23 @@ func NewInsertEdit(pos, numInserted uint64) Edit {
 func NewDeleteEdit(pos, numDeleted uint64) Edit {
 	return Edit{pos: pos, numDeleted: numDeleted}
 }
+
+// Pos returns the position of the first character inserted or deleted.
+func (e Edit) Pos() uint64 {
+	return e.pos
+}
+
+// NumInserted returns the number of characters inserted.
+func (e Edit) NumInserted() uint64 {
+	return e.numInserted
+}
+
+// NumDeleted returns the number of characters deleted.
+func (e Edit) NumDeleted() uint64 {
+	return e.numDeleted
+}
+
+// NewReplaceEdit represents deleting numDeleted characters at pos, then inserting numInserted characters at pos.
+func NewReplaceEdit(pos, numDeleted, numInserted uint64) Edit {
+	return Edit{pos: pos, numInserted: numInserted, numDeleted: numDeleted}
+}
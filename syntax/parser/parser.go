@@ -2,6 +2,7 @@ package parser
 
 import (
 	"math"
+	"sort"
 
 	"github.com/aretext/aretext/text"
 )
@@ -56,12 +57,30 @@ func (r Result) ShiftForward(n uint64) Result {
 	return r
 }
 
+// Tokenizer parses a whole document into tokens.
+//
+// This is an alternative to a parse func for parsers that keep their own
+// incremental state, such as a tree-sitter grammar.
+// The returned tokens must be sorted by position, non-overlapping, and have non-zero length.
+type Tokenizer interface {
+	// TokenizeAll parses the entire document.
+	TokenizeAll(tree *text.Tree) []Token
+
+	// TokenizeAfterEdit parses the document after an edit.
+	// This is called for every edit after the first call to TokenizeAll.
+	TokenizeAfterEdit(tree *text.Tree, edit Edit) []Token
+}
+
 // P parses a document into tokens.
 // It caches the results from the last parse so it can efficiently
 // reparse a document after an edit (insertion/deletion).
 type P struct {
 	parseFunc       Func
 	lastComputation *computation
+
+	// If set, the tokenizer replaces the parse func, and tokens are the tokens from its last parse.
+	tokenizer Tokenizer
+	tokens    []Token
 }
 
 // New constructs a new parser for the language recognized by parseFunc.
@@ -71,14 +90,34 @@ func New(f Func) *P {
 	return &P{parseFunc: f}
 }
 
+// NewFromTokenizer constructs a new parser that delegates parsing to a tokenizer.
+func NewFromTokenizer(tokenizer Tokenizer) *P {
+	return &P{tokenizer: tokenizer}
+}
+
 // TokenAtPosition returns the token containing a position.
 // If no such token exists, it returns the Token zero value.
 func (p *P) TokenAtPosition(pos uint64) Token {
+	if p.tokenizer != nil {
+		i := sort.Search(len(p.tokens), func(i int) bool { return p.tokens[i].EndPos > pos })
+		if i < len(p.tokens) && p.tokens[i].StartPos <= pos {
+			return p.tokens[i]
+		}
+		return Token{}
+	}
 	return p.lastComputation.TokenAtPosition(pos)
 }
 
 // TokensIntersectingRange returns tokens that overlap the interval [startPos, endPos)
 func (p *P) TokensIntersectingRange(startPos, endPos uint64) []Token {
+	if p.tokenizer != nil {
+		var result []Token
+		i := sort.Search(len(p.tokens), func(i int) bool { return p.tokens[i].EndPos > startPos })
+		for ; i < len(p.tokens) && p.tokens[i].StartPos < endPos; i++ {
+			result = append(result, p.tokens[i])
+		}
+		return result
+	}
 	return p.lastComputation.TokensIntersectingRange(startPos, endPos)
 }
 
@@ -87,6 +126,11 @@ const minInitialConsumedLen = 1024
 
 // ParseAll parses the entire document.
 func (p *P) ParseAll(tree *text.Tree) {
+	if p.tokenizer != nil {
+		p.tokens = p.tokenizer.TokenizeAll(tree)
+		return
+	}
+
 	var pos uint64
 	var prevComputation *computation
 	state := State(EmptyState{})
@@ -126,6 +170,11 @@ func combineLeaves(prev, next *computation) {
 // It must be called for *every* edit to the document, otherwise the
 // tokens may not match the current state of the document.
 func (p *P) ReparseAfterEdit(tree *text.Tree, edit Edit) {
+	if p.tokenizer != nil {
+		p.tokens = p.tokenizer.TokenizeAfterEdit(tree, edit)
+		return
+	}
+
 	var pos uint64
 	var c *computation
 	state := State(EmptyState{})
@@ -166,23 +215,12 @@ func (p *P) findReusableComputation(pos uint64, edit Edit, state State) *computa
 		)
 	}
 
-	if edit.numInserted > 0 && pos >= edit.pos+edit.numInserted {
+	if (edit.numInserted > 0 || edit.numDeleted > 0) && pos >= edit.pos+edit.numInserted {
 		// If the parser is past the last character inserted,
 		// translate the position to the previous document by subtracting
-		// the number of inserted characters.
-		return p.lastComputation.LargestMatchingSubComputation(
-			pos-edit.numInserted,
-			math.MaxUint64,
-			state,
-		)
-	}
-
-	if edit.numDeleted > 0 && pos >= edit.pos {
-		// If the parser is past a deletion,
-		// translate the position to the previous document by adding
-		// the number of deleted characters.
+		// the number of inserted characters and adding the number of deleted characters.
 		return p.lastComputation.LargestMatchingSubComputation(
-			pos+edit.numDeleted,
+			pos-edit.numInserted+edit.numDeleted,
 			math.MaxUint64,
 			state,
 		)
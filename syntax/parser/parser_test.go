@@ -278,6 +278,65 @@ func TestReparseAfterEditDeletion(t *testing.T) {
 	}
 }
 
+func TestReparseAfterEditReplace(t *testing.T) {
+	testCases := []struct {
+		name        string
+		text        string
+		editPos     uint64
+		numDeleted  uint64
+		replacement string
+	}{
+		{
+			name:        "replace within token",
+			text:        `"foo" "bar" "baz"`,
+			editPos:     7,
+			numDeleted:  3,
+			replacement: "x",
+		},
+		{
+			name:        "replace with longer text",
+			text:        `"foo" "bar" "baz"`,
+			editPos:     7,
+			numDeleted:  1,
+			replacement: "abcdef",
+		},
+		{
+			name:        "replace token boundary",
+			text:        `"foo" "bar" "baz"`,
+			editPos:     4,
+			numDeleted:  3,
+			replacement: `"" "`,
+		},
+		{
+			name:        "replace quote",
+			text:        `"foo" "bar" "baz"`,
+			editPos:     6,
+			numDeleted:  1,
+			replacement: "x",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := text.NewTreeFromString(tc.text)
+			require.NoError(t, err)
+			p := New(simpleParseFunc)
+			p.ParseAll(tree)
+
+			err = tree.ReplaceRange(tc.editPos, tc.numDeleted, tc.replacement)
+			require.NoError(t, err)
+			edit := NewReplaceEdit(tc.editPos, tc.numDeleted, uint64(len(tc.replacement)))
+			p.ReparseAfterEdit(tree, edit)
+			tokens := p.TokensIntersectingRange(0, math.MaxUint64)
+
+			expectedParser := New(simpleParseFunc)
+			expectedParser.ParseAll(tree)
+			expectedTokens := expectedParser.TokensIntersectingRange(0, math.MaxUint64)
+			assert.Equal(t, expectedTokens, tokens)
+		})
+	}
+}
+
 func TestReparseIndividualInsertionsAtEndOfDocument(t *testing.T) {
 	tree := text.NewTree()
 	p := New(simpleParseFunc)
@@ -296,3 +355,53 @@ func TestReparseIndividualInsertionsAtEndOfDocument(t *testing.T) {
 	}
 	assert.Equal(t, expectedTokens, tokens)
 }
+
+// digitTokenizer produces a number token for each run of digits, and records the last edit.
+type digitTokenizer struct {
+	lastEdit Edit
+}
+
+func (dt *digitTokenizer) TokenizeAll(tree *text.Tree) []Token {
+	var tokens []Token
+	var pos uint64
+	for _, r := range tree.String() {
+		if r >= '0' && r <= '9' {
+			if len(tokens) > 0 && tokens[len(tokens)-1].EndPos == pos {
+				tokens[len(tokens)-1].EndPos++
+			} else {
+				tokens = append(tokens, Token{Role: TokenRoleNumber, StartPos: pos, EndPos: pos + 1})
+			}
+		}
+		pos++
+	}
+	return tokens
+}
+
+func (dt *digitTokenizer) TokenizeAfterEdit(tree *text.Tree, edit Edit) []Token {
+	dt.lastEdit = edit
+	return dt.TokenizeAll(tree)
+}
+
+func TestParserFromTokenizer(t *testing.T) {
+	tree, err := text.NewTreeFromString("ab 12 c 345")
+	require.NoError(t, err)
+
+	tokenizer := &digitTokenizer{}
+	p := NewFromTokenizer(tokenizer)
+	p.ParseAll(tree)
+	assert.Equal(t, Token{Role: TokenRoleNumber, StartPos: 3, EndPos: 5}, p.TokenAtPosition(4))
+	assert.Equal(t, Token{}, p.TokenAtPosition(6))
+	assert.Equal(t, []Token{
+		{Role: TokenRoleNumber, StartPos: 3, EndPos: 5},
+		{Role: TokenRoleNumber, StartPos: 8, EndPos: 11},
+	}, p.TokensIntersectingRange(4, 9))
+	assert.Empty(t, p.TokensIntersectingRange(5, 8))
+
+	err = tree.InsertAtPosition(0, '9')
+	require.NoError(t, err)
+	edit := NewInsertEdit(0, 1)
+	p.ReparseAfterEdit(tree, edit)
+	assert.Equal(t, edit, tokenizer.lastEdit)
+	assert.Equal(t, Token{Role: TokenRoleNumber, StartPos: 0, EndPos: 1}, p.TokenAtPosition(0))
+	assert.Equal(t, Token{Role: TokenRoleNumber, StartPos: 4, EndPos: 6}, p.TokenAtPosition(4))
+}
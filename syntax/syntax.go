@@ -1,16 +1,17 @@
 package syntax
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/aretext/aretext/syntax/languages"
 	"github.com/aretext/aretext/syntax/parser"
+	"github.com/aretext/aretext/syntax/treesitter"
 )
 
 // Language is an enum of languages that we can parse.
 type Language string
 
-// AllLanguages lists every available language.
-var AllLanguages []Language
-
 const (
 	LanguagePlaintext    = Language("plaintext")
 	LanguageJson         = Language("json")
@@ -32,42 +33,109 @@ const (
 	LanguageP4           = Language("p4")
 )
 
-// languageToParseFunc maps each language to its parse func.
-var languageToParseFunc map[Language]parser.Func
+// Backend is a kind of parser used for syntax highlighting.
+type Backend string
+
+const (
+	BackendBuiltin    = Backend("builtin")    // Incremental parsers written for aretext.
+	BackendTreeSitter = Backend("treesitter") // Tree-sitter grammars, available only in builds with cgo.
+)
+
+// registeredLanguage is a language that can be selected for syntax highlighting.
+type registeredLanguage struct {
+	parseFunc      parser.Func
+	tokenRoleNames map[string]parser.TokenRole
+}
+
+// registry maps each language to its parse func and language-specific token role names.
+var registry = make(map[Language]registeredLanguage)
 
 func init() {
-	languageToParseFunc = map[Language]parser.Func{
-		LanguagePlaintext:    nil,
-		LanguageJson:         languages.JsonParseFunc(),
-		LanguageYaml:         languages.YamlParseFunc(),
-		LanguageGo:           languages.GolangParseFunc(),
-		LanguageGoTemplate:   languages.GoTemplateParseFunc(),
-		LanguagePython:       languages.PythonParseFunc(),
-		LanguageRust:         languages.RustParseFunc(),
-		LanguageC:            languages.CParseFunc(),
-		LanguageBash:         languages.BashParseFunc(),
-		LanguageXml:          languages.XmlParseFunc(),
-		LanguageGitCommit:    languages.GitCommitParseFunc(),
-		LanguageGitRebase:    languages.GitRebaseParseFunc(),
-		LanguageProtobuf:     languages.ProtobufParseFunc(),
-		LanguageTodoTxt:      languages.TodoTxtParseFunc(),
-		LanguageMarkdown:     languages.MarkdownParseFunc(),
-		LanguageCriticMarkup: languages.CriticMarkupParseFunc(),
-		LanguageMakefile:     languages.MakefileParseFunc(),
-		LanguageP4:           languages.P4ParseFunc(),
+	RegisterLanguage(LanguagePlaintext, nil, nil)
+	RegisterLanguage(LanguageJson, languages.JsonParseFunc(), languages.JsonTokenRoleNames())
+	RegisterLanguage(LanguageYaml, languages.YamlParseFunc(), languages.YamlTokenRoleNames())
+	RegisterLanguage(LanguageGo, languages.GolangParseFunc(), languages.GolangTokenRoleNames())
+	RegisterLanguage(LanguageGoTemplate, languages.GoTemplateParseFunc(), nil)
+	RegisterLanguage(LanguagePython, languages.PythonParseFunc(), nil)
+	RegisterLanguage(LanguageRust, languages.RustParseFunc(), languages.RustTokenRoleNames())
+	RegisterLanguage(LanguageC, languages.CParseFunc(), languages.CTokenRoleNames())
+	RegisterLanguage(LanguageBash, languages.BashParseFunc(), languages.BashTokenRoleNames())
+	RegisterLanguage(LanguageXml, languages.XmlParseFunc(), languages.XmlTokenRoleNames())
+	RegisterLanguage(LanguageGitCommit, languages.GitCommitParseFunc(), nil)
+	RegisterLanguage(LanguageGitRebase, languages.GitRebaseParseFunc(), nil)
+	RegisterLanguage(LanguageProtobuf, languages.ProtobufParseFunc(), nil)
+	RegisterLanguage(LanguageTodoTxt, languages.TodoTxtParseFunc(), languages.TodoTxtTokenRoleNames())
+	RegisterLanguage(LanguageMarkdown, languages.MarkdownParseFunc(), languages.MarkdownTokenRoleNames())
+	RegisterLanguage(LanguageCriticMarkup, languages.CriticMarkupParseFunc(), languages.CriticMarkupTokenRoleNames())
+	RegisterLanguage(LanguageMakefile, languages.MakefileParseFunc(), languages.MakefileTokenRoleNames())
+	RegisterLanguage(LanguageP4, languages.P4ParseFunc(), languages.P4TokenRoleNames())
+}
+
+// RegisterLanguage makes a language available for syntax highlighting.
+// The language can then be selected with the "syntaxLanguage" config key and the "set syntax language" menu command.
+//
+// Packages outside aretext can call this from an init function to ship additional languages,
+// then be linked into a custom build with a blank import in the main package.
+// Registration is not safe for concurrent use, so it must happen before the editor starts.
+//
+// The parse func may be nil to disable syntax highlighting for the language.
+// The token role names map style names in the config (for example "tokenKey") to language-specific token roles,
+// and may be nil if the language uses only the built-in token roles.
+// This panics if the language is already registered.
+func RegisterLanguage(language Language, parseFunc parser.Func, tokenRoleNames map[string]parser.TokenRole) {
+	if _, ok := registry[language]; ok {
+		panic(fmt.Sprintf("Syntax language %q is already registered", language))
 	}
+	registry[language] = registeredLanguage{parseFunc, tokenRoleNames}
+}
 
-	for language := range languageToParseFunc {
-		AllLanguages = append(AllLanguages, language)
+// AllLanguages returns every registered language, sorted by name.
+func AllLanguages() []Language {
+	languages := make([]Language, 0, len(registry))
+	for language := range registry {
+		languages = append(languages, language)
 	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i] < languages[j] })
+	return languages
+}
+
+// TokenRoleForStyleName returns the language-specific token role for a style name (for example, "tokenKey" in YAML).
+// The second return value is false if the language does not define a token role with the name.
+func TokenRoleForStyleName(language Language, name string) (parser.TokenRole, bool) {
+	role, ok := registry[language].tokenRoleNames[name]
+	return role, ok
+}
+
+// IsTokenRoleStyleName returns whether any language defines a token role with the style name.
+func IsTokenRoleStyleName(name string) bool {
+	for _, registered := range registry {
+		if _, ok := registered.tokenRoleNames[name]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 // ParseForLanguage creates a parser for a syntax language.
 // If no parser is available (e.g. for LanguagePlaintext) this returns nil.
 func ParserForLanguage(language Language) *parser.P {
-	parseFunc := languageToParseFunc[language]
+	parseFunc := registry[language].parseFunc
 	if parseFunc == nil {
 		return nil
 	}
 	return parser.New(parseFunc)
 }
+
+// ParserForLanguageAndBackend creates a parser for a syntax language using a backend.
+// If the backend has no parser for the language, this returns the built-in parser (or nil for LanguagePlaintext)
+// and false, so the document is still highlighted.
+func ParserForLanguageAndBackend(language Language, backend Backend) (*parser.P, bool) {
+	if backend == BackendTreeSitter {
+		tokenizer, ok := treesitter.NewTokenizer(string(language), registry[language].tokenRoleNames)
+		if !ok {
+			return ParserForLanguage(language), false
+		}
+		return parser.NewFromTokenizer(tokenizer), true
+	}
+	return ParserForLanguage(language), true
+}
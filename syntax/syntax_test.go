@@ -0,0 +1,68 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/syntax/parser"
+	"github.com/aretext/aretext/text"
+)
+
+func TestAllLanguagesSorted(t *testing.T) {
+	languages := AllLanguages()
+	assert.Contains(t, languages, LanguagePlaintext)
+	assert.Contains(t, languages, LanguageGo)
+	assert.IsIncreasing(t, languages)
+}
+
+func TestRegisterLanguage(t *testing.T) {
+	language := Language("testlang")
+	defer delete(registry, language)
+
+	parseFunc := parser.Func(func(parser.TrackingRuneIter, parser.State) parser.Result {
+		return parser.FailedResult
+	})
+	tokenRoleNames := map[string]parser.TokenRole{"tokenTestLang": parser.TokenRoleCustom1}
+	RegisterLanguage(language, parseFunc, tokenRoleNames)
+
+	assert.Contains(t, AllLanguages(), language)
+	assert.NotNil(t, ParserForLanguage(language))
+	assert.True(t, IsTokenRoleStyleName("tokenTestLang"))
+	role, ok := TokenRoleForStyleName(language, "tokenTestLang")
+	require.True(t, ok)
+	assert.Equal(t, parser.TokenRoleCustom1, role)
+}
+
+func TestRegisterLanguageTwicePanics(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterLanguage(LanguageGo, nil, nil)
+	})
+}
+
+func TestParserForLanguageAndBackend(t *testing.T) {
+	p, ok := ParserForLanguageAndBackend(LanguageJson, BackendBuiltin)
+	assert.True(t, ok)
+	assert.NotNil(t, p)
+
+	// Languages without a tree-sitter grammar use the built-in parser.
+	p, ok = ParserForLanguageAndBackend(LanguageYaml, BackendTreeSitter)
+	assert.False(t, ok)
+	assert.NotNil(t, p)
+
+	p, ok = ParserForLanguageAndBackend(LanguagePlaintext, BackendTreeSitter)
+	assert.False(t, ok)
+	assert.Nil(t, p)
+
+	p, ok = ParserForLanguageAndBackend(LanguageJson, BackendTreeSitter)
+	if !ok {
+		t.Skip("Tree-sitter grammars require cgo")
+	}
+	tree, err := text.NewTreeFromString(`{"a": 1}`)
+	require.NoError(t, err)
+	p.ParseAll(tree)
+	keyRole, ok := TokenRoleForStyleName(LanguageJson, "tokenKey")
+	require.True(t, ok)
+	assert.Equal(t, parser.Token{StartPos: 1, EndPos: 4, Role: keyRole}, p.TokenAtPosition(1))
+}
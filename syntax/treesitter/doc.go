@@ -0,0 +1,9 @@
+// Package treesitter tokenizes documents with tree-sitter grammars.
+//
+// It is an alternative to the hand-written parsers in the languages package, selected for each language
+// with the "syntaxBackend" config. Tree-sitter parses the whole document into a syntax tree, so tokens
+// can depend on context that the built-in parsers don't track, like whether an identifier is called as a function.
+//
+// Tree-sitter grammars are C libraries, so this package requires cgo. In builds without cgo,
+// no grammars are available and every language uses its built-in parser.
+package treesitter
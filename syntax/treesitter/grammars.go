@@ -0,0 +1,57 @@
+//go:build cgo
+
+package treesitter
+
+import (
+	_ "embed"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+	sittergo "github.com/tree-sitter/tree-sitter-go/bindings/go"
+	sitterjson "github.com/tree-sitter/tree-sitter-json/bindings/go"
+	sitterpython "github.com/tree-sitter/tree-sitter-python/bindings/go"
+)
+
+var (
+	//go:embed queries/go.scm
+	goHighlightsQuery string
+
+	//go:embed queries/json.scm
+	jsonHighlightsQuery string
+
+	//go:embed queries/python.scm
+	pythonHighlightsQuery string
+)
+
+// grammar is a tree-sitter grammar and a query that captures the tokens to highlight.
+type grammar struct {
+	languagePtr     func() unsafe.Pointer
+	highlightsQuery string
+
+	// Compiled once, on first use, and shared by every tokenizer for the language.
+	once     sync.Once
+	language *sitter.Language
+	query    *sitter.Query
+}
+
+// grammars maps each syntax language name to its tree-sitter grammar.
+var grammars = map[string]*grammar{
+	"go":     {languagePtr: sittergo.Language, highlightsQuery: goHighlightsQuery},
+	"json":   {languagePtr: sitterjson.Language, highlightsQuery: jsonHighlightsQuery},
+	"python": {languagePtr: sitterpython.Language, highlightsQuery: pythonHighlightsQuery},
+}
+
+func (g *grammar) load() (*sitter.Language, *sitter.Query) {
+	g.once.Do(func() {
+		g.language = sitter.NewLanguage(g.languagePtr())
+		query, err := sitter.NewQuery(g.language, g.highlightsQuery)
+		if err != nil {
+			// The queries are embedded in the binary, so this is a bug.
+			panic(fmt.Sprintf("Could not compile tree-sitter highlights query: %s", err))
+		}
+		g.query = query
+	})
+	return g.language, g.query
+}
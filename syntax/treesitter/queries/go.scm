@@ -0,0 +1,100 @@
+; Highlights for the tree-sitter-go grammar.
+; Capture names are aretext style names, so each capture uses the same token role as the built-in Go parser.
+
+(comment) @tokenComment
+
+[
+  (interpreted_string_literal)
+  (raw_string_literal)
+  (rune_literal)
+] @tokenString
+
+[
+  (int_literal)
+  (float_literal)
+  (imaginary_literal)
+] @tokenNumber
+
+[
+  "break"
+  "case"
+  "chan"
+  "const"
+  "continue"
+  "default"
+  "defer"
+  "else"
+  "fallthrough"
+  "for"
+  "func"
+  "go"
+  "goto"
+  "if"
+  "import"
+  "interface"
+  "map"
+  "package"
+  "range"
+  "return"
+  "select"
+  "struct"
+  "switch"
+  "type"
+  "var"
+] @tokenKeyword
+
+; Unlike the built-in parser, predeclared names are highlighted only where they refer to the predeclared
+; type, constant, or function, so a local variable named "len" is not highlighted.
+[
+  (true)
+  (false)
+  (nil)
+  (iota)
+] @tokenBuiltin
+
+((type_identifier) @tokenBuiltin
+  (#match? @tokenBuiltin "^(any|bool|byte|comparable|complex64|complex128|error|float32|float64|int|int8|int16|int32|int64|rune|string|uint|uint8|uint16|uint32|uint64|uintptr)$"))
+
+(call_expression
+  function: (identifier) @tokenBuiltin
+  (#match? @tokenBuiltin "^(append|cap|clear|close|complex|copy|delete|imag|len|make|max|min|new|panic|print|println|real|recover)$"))
+
+[
+  "+"
+  "&"
+  "+="
+  "&="
+  "&&"
+  "=="
+  "!="
+  "-"
+  "|"
+  "-="
+  "|="
+  "||"
+  "<"
+  "<="
+  "*"
+  "^"
+  "*="
+  "^="
+  "<-"
+  ">"
+  ">="
+  "/"
+  "<<"
+  "/="
+  "<<="
+  "++"
+  "="
+  ":="
+  "%"
+  ">>"
+  "%="
+  ">>="
+  "--"
+  "!"
+  "&^"
+  "&^="
+  "~"
+] @tokenOperator
@@ -0,0 +1,18 @@
+; Highlights for the tree-sitter-json grammar.
+; Capture names are aretext style names, so each capture uses the same token role as the built-in JSON parser.
+
+; Keys come before strings, so a key is highlighted as a key rather than a string.
+(pair
+  key: (string) @tokenKey)
+
+(string) @tokenString
+
+(number) @tokenNumber
+
+[
+  (null)
+  (true)
+  (false)
+] @tokenKeyword
+
+(comment) @tokenComment
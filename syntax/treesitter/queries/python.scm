@@ -0,0 +1,94 @@
+; Highlights for the tree-sitter-python grammar.
+; Capture names are aretext style names, so each capture uses the same token role as the built-in Python parser.
+
+(comment) @tokenComment
+
+(string) @tokenString
+
+[
+  (integer)
+  (float)
+] @tokenNumber
+
+[
+  (none)
+  (true)
+  (false)
+] @tokenKeyword
+
+; Unlike the built-in parser, this recognizes the soft keywords "match" and "case" only in match statements.
+[
+  "and"
+  "as"
+  "assert"
+  "async"
+  "await"
+  "break"
+  "case"
+  "class"
+  "continue"
+  "def"
+  "del"
+  "elif"
+  "else"
+  "except"
+  "finally"
+  "for"
+  "from"
+  "global"
+  "if"
+  "import"
+  "in"
+  "is"
+  "lambda"
+  "match"
+  "nonlocal"
+  "not"
+  "or"
+  "pass"
+  "raise"
+  "return"
+  "try"
+  "while"
+  "with"
+  "yield"
+] @tokenKeyword
+
+[
+  "+"
+  "+="
+  "-"
+  "->"
+  "-="
+  "*"
+  "*="
+  "**"
+  "**="
+  "/"
+  "/="
+  "//"
+  "//="
+  "%"
+  "%="
+  "@"
+  "@="
+  "<"
+  "<="
+  "<<"
+  "<<="
+  ">"
+  ">="
+  ">>"
+  ">>="
+  "&"
+  "&="
+  "|"
+  "|="
+  "^"
+  "^="
+  "~"
+  ":="
+  "=="
+  "!="
+  "="
+] @tokenOperator
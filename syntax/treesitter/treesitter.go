@@ -0,0 +1,199 @@
+//go:build cgo
+
+package treesitter
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"unicode/utf8"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/aretext/aretext/syntax/parser"
+	"github.com/aretext/aretext/text"
+)
+
+// tokenRolesByCaptureName maps capture names in highlights queries to the token roles shared by every language.
+var tokenRolesByCaptureName = map[string]parser.TokenRole{
+	"tokenOperator": parser.TokenRoleOperator,
+	"tokenKeyword":  parser.TokenRoleKeyword,
+	"tokenNumber":   parser.TokenRoleNumber,
+	"tokenString":   parser.TokenRoleString,
+	"tokenComment":  parser.TokenRoleComment,
+}
+
+// tokenizer parses a document with a tree-sitter grammar, then runs the grammar's highlights query to find tokens.
+// After an edit, tree-sitter reuses the unchanged parts of the previous syntax tree.
+type tokenizer struct {
+	parser       *sitter.Parser
+	query        *sitter.Query
+	captureRoles []parser.TokenRole // Token role for each capture in the query, by capture index.
+	tree         *sitter.Tree       // Syntax tree from the last parse, or nil before the first parse.
+	source       []byte             // Document text from the last parse, used to translate edits to byte offsets.
+}
+
+// NewTokenizer returns a tokenizer for a syntax language, or false if there is no tree-sitter grammar for the language.
+// The token role names map style names in the language's highlights query (for example "tokenKey" in JSON)
+// to language-specific token roles, the same as the names registered for the language's built-in parser.
+func NewTokenizer(language string, tokenRoleNames map[string]parser.TokenRole) (parser.Tokenizer, bool) {
+	g, ok := grammars[language]
+	if !ok {
+		return nil, false
+	}
+
+	sitterLanguage, query := g.load()
+	sitterParser := sitter.NewParser()
+	if err := sitterParser.SetLanguage(sitterLanguage); err != nil {
+		log.Printf("Could not load tree-sitter grammar for %s: %s\n", language, err)
+		sitterParser.Close()
+		return nil, false
+	}
+
+	captureNames := query.CaptureNames()
+	captureRoles := make([]parser.TokenRole, len(captureNames))
+	for i, name := range captureNames {
+		role, ok := tokenRolesByCaptureName[name]
+		if !ok {
+			role, ok = tokenRoleNames[name]
+		}
+		if !ok {
+			// The queries are embedded in the binary, so this is a bug.
+			panic(fmt.Sprintf("Unrecognized capture %q in tree-sitter highlights query for %s", name, language))
+		}
+		captureRoles[i] = role
+	}
+
+	t := &tokenizer{
+		parser:       sitterParser,
+		query:        query,
+		captureRoles: captureRoles,
+	}
+
+	// The parser and syntax tree are allocated by C, so free them once the editor stops using the tokenizer.
+	runtime.SetFinalizer(t, (*tokenizer).close)
+	return t, true
+}
+
+// TokenizeAll implements parser.Tokenizer#TokenizeAll.
+func (t *tokenizer) TokenizeAll(tree *text.Tree) []parser.Token {
+	t.source = []byte(tree.String())
+	t.replaceTree(t.parser.Parse(t.source, nil))
+	return t.highlight()
+}
+
+// TokenizeAfterEdit implements parser.Tokenizer#TokenizeAfterEdit.
+func (t *tokenizer) TokenizeAfterEdit(tree *text.Tree, edit parser.Edit) []parser.Token {
+	oldSource, newSource := t.source, []byte(tree.String())
+	if t.tree == nil {
+		t.source = newSource
+		t.replaceTree(t.parser.Parse(t.source, nil))
+		return t.highlight()
+	}
+
+	// Text before the edit is unchanged, so the start of the edit has the same byte offset in both versions.
+	startByte := byteOffsetAfterRunes(oldSource, 0, edit.Pos())
+	oldEndByte := byteOffsetAfterRunes(oldSource, startByte, edit.NumDeleted())
+	newEndByte := byteOffsetAfterRunes(newSource, startByte, edit.NumInserted())
+	t.tree.Edit(&sitter.InputEdit{
+		StartByte:      startByte,
+		OldEndByte:     oldEndByte,
+		NewEndByte:     newEndByte,
+		StartPosition:  pointAtByteOffset(oldSource, startByte),
+		OldEndPosition: pointAtByteOffset(oldSource, oldEndByte),
+		NewEndPosition: pointAtByteOffset(newSource, newEndByte),
+	})
+
+	t.source = newSource
+	t.replaceTree(t.parser.Parse(t.source, t.tree))
+	return t.highlight()
+}
+
+func (t *tokenizer) replaceTree(tree *sitter.Tree) {
+	if t.tree != nil {
+		t.tree.Close()
+	}
+	t.tree = tree
+}
+
+func (t *tokenizer) close() {
+	t.replaceTree(nil)
+	t.parser.Close()
+}
+
+// highlight runs the highlights query on the syntax tree.
+// If captures overlap, the capture from the first pattern in the query wins, then the capture that starts first.
+func (t *tokenizer) highlight() []parser.Token {
+	if t.tree == nil {
+		return nil
+	}
+
+	type capture struct {
+		startByte, endByte uint
+		patternIndex       uint
+		role               parser.TokenRole
+	}
+
+	cursor := sitter.NewQueryCursor()
+	defer cursor.Close()
+
+	var captures []capture
+	matches := cursor.Matches(t.query, t.tree.RootNode(), t.source)
+	for match := matches.Next(); match != nil; match = matches.Next() {
+		for _, c := range match.Captures {
+			captures = append(captures, capture{
+				startByte:    c.Node.StartByte(),
+				endByte:      c.Node.EndByte(),
+				patternIndex: match.PatternIndex,
+				role:         t.captureRoles[c.Index],
+			})
+		}
+	}
+
+	sort.Slice(captures, func(i, j int) bool {
+		if captures[i].startByte != captures[j].startByte {
+			return captures[i].startByte < captures[j].startByte
+		}
+		return captures[i].patternIndex < captures[j].patternIndex
+	})
+
+	// Tokens are positioned by rune, so count runes while walking forward through the captures.
+	var tokens []parser.Token
+	var pos uint64
+	var byteOffset uint
+	for _, c := range captures {
+		if c.startByte < byteOffset || c.startByte == c.endByte {
+			continue
+		}
+		startPos := pos + uint64(utf8.RuneCount(t.source[byteOffset:c.startByte]))
+		endPos := startPos + uint64(utf8.RuneCount(t.source[c.startByte:c.endByte]))
+		tokens = append(tokens, parser.Token{
+			Role:     c.role,
+			StartPos: startPos,
+			EndPos:   endPos,
+		})
+		pos, byteOffset = endPos, c.endByte
+	}
+	return tokens
+}
+
+// byteOffsetAfterRunes returns the byte offset n runes after the start offset.
+func byteOffsetAfterRunes(source []byte, start uint, n uint64) uint {
+	offset := start
+	for i := uint64(0); i < n && offset < uint(len(source)); i++ {
+		_, size := utf8.DecodeRune(source[offset:])
+		offset += uint(size)
+	}
+	return offset
+}
+
+// pointAtByteOffset returns the row and column of a byte offset.
+// Like tree-sitter, the column is measured in bytes.
+func pointAtByteOffset(source []byte, offset uint) sitter.Point {
+	before := source[:offset]
+	row := uint(bytes.Count(before, []byte{'\n'}))
+	col := offset - uint(bytes.LastIndexByte(before, '\n')+1)
+	return sitter.NewPoint(row, col)
+}
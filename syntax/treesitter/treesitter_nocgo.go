@@ -0,0 +1,10 @@
+//go:build !cgo
+
+package treesitter
+
+import "github.com/aretext/aretext/syntax/parser"
+
+// NewTokenizer returns false, since tree-sitter grammars are not available in builds without cgo.
+func NewTokenizer(language string, tokenRoleNames map[string]parser.TokenRole) (parser.Tokenizer, bool) {
+	return nil, false
+}
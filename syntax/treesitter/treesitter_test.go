@@ -0,0 +1,167 @@
+//go:build cgo
+
+package treesitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/syntax/parser"
+	"github.com/aretext/aretext/text"
+)
+
+const (
+	testTokenRoleBuiltin = parser.TokenRoleCustom1
+	testTokenRoleKey     = parser.TokenRoleCustom1
+)
+
+func testTokenizer(t *testing.T, language string) parser.Tokenizer {
+	tokenizer, ok := NewTokenizer(language, map[string]parser.TokenRole{
+		"tokenBuiltin": testTokenRoleBuiltin,
+		"tokenKey":     testTokenRoleKey,
+	})
+	require.True(t, ok)
+	return tokenizer
+}
+
+func TestNewTokenizerUnsupportedLanguage(t *testing.T) {
+	_, ok := NewTokenizer("plaintext", nil)
+	assert.False(t, ok)
+}
+
+func TestHighlightsQueriesCompile(t *testing.T) {
+	for language := range grammars {
+		t.Run(language, func(t *testing.T) {
+			testTokenizer(t, language)
+		})
+	}
+}
+
+func TestTokenizeAll(t *testing.T) {
+	testCases := []struct {
+		name           string
+		language       string
+		text           string
+		expectedTokens []parser.Token
+	}{
+		{
+			name:     "go predeclared function",
+			language: "go",
+			text:     "len := len(x) // ok",
+			expectedTokens: []parser.Token{
+				{StartPos: 4, EndPos: 6, Role: parser.TokenRoleOperator},
+				{StartPos: 7, EndPos: 10, Role: testTokenRoleBuiltin},
+				{StartPos: 14, EndPos: 19, Role: parser.TokenRoleComment},
+			},
+		},
+		{
+			name:     "go keywords and literals",
+			language: "go",
+			text:     "var s string = \"ü\" + `x`",
+			expectedTokens: []parser.Token{
+				{StartPos: 0, EndPos: 3, Role: parser.TokenRoleKeyword},
+				{StartPos: 6, EndPos: 12, Role: testTokenRoleBuiltin},
+				{StartPos: 13, EndPos: 14, Role: parser.TokenRoleOperator},
+				{StartPos: 15, EndPos: 18, Role: parser.TokenRoleString},
+				{StartPos: 19, EndPos: 20, Role: parser.TokenRoleOperator},
+				{StartPos: 21, EndPos: 24, Role: parser.TokenRoleString},
+			},
+		},
+		{
+			name:     "json key and string",
+			language: "json",
+			text:     `{"a": "b", "c": [1, true]}`,
+			expectedTokens: []parser.Token{
+				{StartPos: 1, EndPos: 4, Role: testTokenRoleKey},
+				{StartPos: 6, EndPos: 9, Role: parser.TokenRoleString},
+				{StartPos: 11, EndPos: 14, Role: testTokenRoleKey},
+				{StartPos: 17, EndPos: 18, Role: parser.TokenRoleNumber},
+				{StartPos: 20, EndPos: 24, Role: parser.TokenRoleKeyword},
+			},
+		},
+		{
+			name:     "python soft keyword",
+			language: "python",
+			text:     "match = 1\nmatch match:\n    case None: pass",
+			expectedTokens: []parser.Token{
+				{StartPos: 6, EndPos: 7, Role: parser.TokenRoleOperator},
+				{StartPos: 8, EndPos: 9, Role: parser.TokenRoleNumber},
+				{StartPos: 10, EndPos: 15, Role: parser.TokenRoleKeyword},
+				{StartPos: 27, EndPos: 31, Role: parser.TokenRoleKeyword},
+				{StartPos: 32, EndPos: 36, Role: parser.TokenRoleKeyword},
+				{StartPos: 38, EndPos: 42, Role: parser.TokenRoleKeyword},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := text.NewTreeFromString(tc.text)
+			require.NoError(t, err)
+			tokens := testTokenizer(t, tc.language).TokenizeAll(tree)
+			assert.Equal(t, tc.expectedTokens, tokens)
+		})
+	}
+}
+
+func TestTokenizeAfterEdit(t *testing.T) {
+	testCases := []struct {
+		name       string
+		text       string
+		pos        uint64
+		numDeleted uint64
+		insertText string
+	}{
+		{
+			name:       "insert in string",
+			text:       "x := \"ab\"\ny := 1",
+			pos:        7,
+			insertText: "ü\n",
+		},
+		{
+			name:       "insert comment start",
+			text:       "a := 1\nb := 2\n",
+			pos:        7,
+			insertText: "// ",
+		},
+		{
+			name:       "delete multibyte characters",
+			text:       "s := \"日本語\" + len(t)",
+			pos:        6,
+			numDeleted: 2,
+		},
+		{
+			name:       "replace across lines",
+			text:       "a := 1\n/* b\nc */ d := 2",
+			pos:        5,
+			numDeleted: 6,
+			insertText: "\"😀\"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := text.NewTreeFromString(tc.text)
+			require.NoError(t, err)
+			tokenizer := testTokenizer(t, "go")
+			tokenizer.TokenizeAll(tree)
+
+			for i := uint64(0); i < tc.numDeleted; i++ {
+				tree.DeleteAtPosition(tc.pos)
+			}
+			var numInserted uint64
+			for _, r := range tc.insertText {
+				require.NoError(t, tree.InsertAtPosition(tc.pos+numInserted, r))
+				numInserted++
+			}
+			edit := parser.NewReplaceEdit(tc.pos, tc.numDeleted, numInserted)
+			tokens := tokenizer.TokenizeAfterEdit(tree, edit)
+
+			// Reparsing incrementally should produce the same tokens as parsing from scratch.
+			expectedTokens := testTokenizer(t, "go").TokenizeAll(tree)
+			assert.Equal(t, expectedTokens, tokens)
+		})
+	}
+}
@@ -146,11 +146,72 @@ func (t *Tree) NumLines() uint64 {
 // If charPos is past the end of the text, it will be appended at the end.
 // Returns an error if c is not a valid UTF-8 character.
 func (t *Tree) InsertAtPosition(charPos uint64, c rune) error {
-	invalidateKeys, splitNode, err := t.root.insertAtPosition(charPos, c)
-	if err != nil {
-		return err
+	var buf [utf8.UTFMax]byte
+	if !utf8.ValidRune(c) {
+		return ErrInvalidUtf8
+	}
+	n := utf8.EncodeRune(buf[:], c)
+	key := indexKey{numChars: 1, numBytes: uint64(n)}
+	if c == '\n' {
+		key.numNewlines = 1
+	}
+	t.insertChunk(charPos, buf[:n], key)
+	return nil
+}
+
+// ReplaceRange replaces numChars characters starting at charPos (0-indexed) with the UTF-8 string s.
+// This is more efficient than deleting and inserting one character at a time,
+// because it deletes the range in a single pass and inserts the text in multi-character chunks.
+// If the range extends past the end of the text, only the characters up to the end are replaced.
+// Returns an error if s is not valid UTF-8, in which case the tree is unchanged.
+func (t *Tree) ReplaceRange(charPos uint64, numChars uint64, s string) error {
+	if !utf8.ValidString(s) {
+		return ErrInvalidUtf8
+	}
+
+	if numChars > 0 {
+		t.root.deleteRange(charPos, numChars)
+	}
+
+	if len(s) == 0 {
+		return nil
+	}
+
+	if n := t.NumChars(); charPos > n {
+		charPos = n
+	}
+
+	var buf [maxInsertChunkBytes]byte
+	for len(s) > 0 {
+		var chunkLen int
+		var key indexKey
+		for chunkLen < len(s) {
+			_, w := utf8.DecodeRuneInString(s[chunkLen:])
+			if chunkLen+w > maxInsertChunkBytes {
+				break
+			}
+			if s[chunkLen] == '\n' {
+				key.numNewlines++
+			}
+			key.numChars++
+			chunkLen += w
+		}
+		key.numBytes = uint64(chunkLen)
+
+		copy(buf[:], s[:chunkLen])
+		t.insertChunk(charPos, buf[:chunkLen], key)
+		charPos += key.numChars
+		s = s[chunkLen:]
 	}
 
+	return nil
+}
+
+// insertChunk inserts valid UTF-8 bytes at the specified position.
+// The chunk must be at most maxInsertChunkBytes long, and key must match its contents.
+func (t *Tree) insertChunk(charPos uint64, chunk []byte, key indexKey) {
+	invalidateKeys, splitNode := t.root.insertAtPosition(charPos, chunk, key)
+
 	if invalidateKeys {
 		t.root.recalculateChildKeys()
 	}
@@ -163,8 +224,6 @@ func (t *Tree) InsertAtPosition(charPos uint64, c rune) error {
 		t.root = &innerNode{child: &newGroup}
 		t.root.recalculateChildKeys()
 	}
-
-	return nil
 }
 
 // DeleteAtPosition removes the UTF-8 character at the specified position (0-indexed).
@@ -201,6 +260,12 @@ func (t *Tree) LineNumForPosition(charPos uint64) uint64 {
 	return t.root.numNewlinesBeforePosition(charPos)
 }
 
+// ByteOffsetForPosition returns the offset in bytes of the UTF-8 character at the specified position (0-indexed).
+// If the position is past the end of the text, returns the total number of bytes in the tree.
+func (t *Tree) ByteOffsetForPosition(charPos uint64) uint64 {
+	return t.root.byteOffsetForPosition(charPos)
+}
+
 // String returns the text in the tree as a string.
 func (t *Tree) String() string {
 	reader := t.ReaderAtPosition(0)
@@ -215,15 +280,22 @@ const maxKeysPerNode = 64
 const maxNodesPerGroup = maxKeysPerNode
 const maxBytesPerLeaf = 63
 
+// maxInsertChunkBytes is the maximum number of bytes inserted into a leaf at once.
+// This is less than half of maxBytesPerLeaf, so splitting a full leaf at the insertion point
+// always leaves room for the chunk on one side of the split.
+const maxInsertChunkBytes = maxBytesPerLeaf / 2
+
 // nodeGroup is either an inner node group or a leaf node group.
 type nodeGroup interface {
 	keys() []indexKey
-	insertAtPosition(nodeIdx uint64, charPos uint64, c rune) (invalidateKeys bool, splitNodeGroup nodeGroup, err error)
+	insertAtPosition(nodeIdx uint64, charPos uint64, chunk []byte, chunkKey indexKey) (invalidateKeys bool, splitNodeGroup nodeGroup)
 	deleteAtPosition(nodeIdx uint64, charPos uint64) (didDelete, wasNewline bool, r rune)
+	deleteRange(nodeIdx uint64, charPos uint64, numChars uint64) indexKey
 	readerAtPosition(nodeIdx uint64, charPos uint64) Reader
 	reverseReaderAtPosition(nodeIdx uint64, charPos uint64) ReverseReader
 	positionAfterNewline(nodeIdx uint64, newlineIdx uint64) uint64
 	numNewlinesBeforePosition(nodeIdx uint64, charPos uint64) uint64
+	byteOffsetForPosition(nodeIdx uint64, charPos uint64) uint64
 }
 
 // indexKey is used to navigate from an inner node to the child node containing a particular line or character offset.
@@ -234,6 +306,10 @@ type indexKey struct {
 
 	// Number of newline characters in a subtree.
 	numNewlines uint64
+
+	// Number of UTF-8 bytes in a subtree.
+	// This allows the tree to convert a character offset to a byte offset without scanning every leaf before it.
+	numBytes uint64
 }
 
 // innerNodeGroup is a group of inner nodes referenced by a parent inner node.
@@ -250,20 +326,16 @@ func (g *innerNodeGroup) keys() []indexKey {
 	return keys
 }
 
-func (g *innerNodeGroup) insertAtPosition(nodeIdx uint64, charPos uint64, c rune) (invalidateKeys bool, splitNodeGroup nodeGroup, err error) {
-	_, splitNode, err := g.nodes[nodeIdx].insertAtPosition(charPos, c)
-	if err != nil {
-		return false, nil, err
-	}
-
+func (g *innerNodeGroup) insertAtPosition(nodeIdx uint64, charPos uint64, chunk []byte, chunkKey indexKey) (invalidateKeys bool, splitNodeGroup nodeGroup) {
+	_, splitNode := g.nodes[nodeIdx].insertAtPosition(charPos, chunk, chunkKey)
 	if splitNode == nil {
-		return false, nil, nil
+		return false, nil
 	}
 
 	splitIdx := nodeIdx + 1
 	if g.numNodes < maxNodesPerGroup {
 		g.insertNode(splitIdx, splitNode)
-		return true, nil, nil
+		return true, nil
 	}
 
 	splitGroup := g.split()
@@ -273,7 +345,7 @@ func (g *innerNodeGroup) insertAtPosition(nodeIdx uint64, charPos uint64, c rune
 		splitGroup.insertNode(splitIdx-g.numNodes, splitNode)
 	}
 
-	return true, splitGroup, nil
+	return true, splitGroup
 }
 
 func (g *innerNodeGroup) insertNode(nodeIdx uint64, node *innerNode) {
@@ -298,6 +370,10 @@ func (g *innerNodeGroup) deleteAtPosition(nodeIdx uint64, charPos uint64) (didDe
 	return g.nodes[nodeIdx].deleteAtPosition(charPos)
 }
 
+func (g *innerNodeGroup) deleteRange(nodeIdx uint64, charPos uint64, numChars uint64) indexKey {
+	return g.nodes[nodeIdx].deleteRange(charPos, numChars)
+}
+
 func (g *innerNodeGroup) readerAtPosition(nodeIdx uint64, charPos uint64) Reader {
 	return g.nodes[nodeIdx].readerAtPosition(charPos)
 }
@@ -314,13 +390,17 @@ func (g *innerNodeGroup) numNewlinesBeforePosition(nodeIdx uint64, charPos uint6
 	return g.nodes[nodeIdx].numNewlinesBeforePosition(charPos)
 }
 
+func (g *innerNodeGroup) byteOffsetForPosition(nodeIdx uint64, charPos uint64) uint64 {
+	return g.nodes[nodeIdx].byteOffsetForPosition(charPos)
+}
+
 // innerNode is used to navigate to the leaf node containing a character offset or line number.
 //
 // +-----------------------------+
 // | child | numKeys |  keys[64] |
 // +-----------------------------+
 //
-//	16 + 8 + 1024 = 1048 bytes
+//	16 + 8 + 1536 = 1560 bytes
 type innerNode struct {
 	child   nodeGroup
 	numKeys uint64
@@ -335,6 +415,7 @@ func (n *innerNode) key() indexKey {
 		key := n.keys[i]
 		nodeKey.numChars += key.numChars
 		nodeKey.numNewlines += key.numNewlines
+		nodeKey.numBytes += key.numBytes
 	}
 	return nodeKey
 }
@@ -361,31 +442,26 @@ func (n *innerNode) recalculateChildKeys() {
 	n.numKeys = uint64(len(childKeys))
 }
 
-func (n *innerNode) insertAtPosition(charPos uint64, c rune) (invalidateKeys bool, splitNode *innerNode, err error) {
+func (n *innerNode) insertAtPosition(charPos uint64, chunk []byte, chunkKey indexKey) (invalidateKeys bool, splitNode *innerNode) {
 	nodeIdx, adjustedCharPos := n.locatePosition(charPos)
 
-	invalidateKeys, splitGroup, err := n.child.insertAtPosition(nodeIdx, adjustedCharPos, c)
-	if err != nil {
-		return false, nil, err
-	}
-
+	invalidateKeys, splitGroup := n.child.insertAtPosition(nodeIdx, adjustedCharPos, chunk, chunkKey)
 	if invalidateKeys {
 		n.recalculateChildKeys()
 	} else {
 		key := &n.keys[nodeIdx]
-		key.numChars++
-		if c == '\n' {
-			key.numNewlines++
-		}
+		key.numChars += chunkKey.numChars
+		key.numNewlines += chunkKey.numNewlines
+		key.numBytes += chunkKey.numBytes
 	}
 
 	if splitGroup == nil {
-		return false, nil, nil
+		return false, nil
 	}
 
 	splitNode = &innerNode{child: splitGroup}
 	splitNode.recalculateChildKeys()
-	return true, splitNode, nil
+	return true, splitNode
 }
 
 func (n *innerNode) deleteAtPosition(charPos uint64) (didDelete, wasNewline bool, r rune) {
@@ -393,6 +469,7 @@ func (n *innerNode) deleteAtPosition(charPos uint64) (didDelete, wasNewline bool
 	didDelete, wasNewline, r = n.child.deleteAtPosition(nodeIdx, adjustedCharPos)
 	if didDelete {
 		n.keys[nodeIdx].numChars--
+		n.keys[nodeIdx].numBytes -= uint64(utf8.RuneLen(r))
 		if wasNewline {
 			n.keys[nodeIdx].numNewlines--
 		}
@@ -400,6 +477,23 @@ func (n *innerNode) deleteAtPosition(charPos uint64) (didDelete, wasNewline bool
 	return
 }
 
+// deleteRange deletes up to numChars characters starting at charPos, and returns the key of the deleted text.
+func (n *innerNode) deleteRange(charPos uint64, numChars uint64) indexKey {
+	var deleted indexKey
+	nodeIdx, adjustedCharPos := n.locatePosition(charPos)
+	for i := nodeIdx; i < n.numKeys && deleted.numChars < numChars; i++ {
+		d := n.child.deleteRange(i, adjustedCharPos, numChars-deleted.numChars)
+		n.keys[i].numChars -= d.numChars
+		n.keys[i].numNewlines -= d.numNewlines
+		n.keys[i].numBytes -= d.numBytes
+		deleted.numChars += d.numChars
+		deleted.numNewlines += d.numNewlines
+		deleted.numBytes += d.numBytes
+		adjustedCharPos = 0
+	}
+	return deleted
+}
+
 func (n *innerNode) readerAtPosition(charPos uint64) Reader {
 	nodeIdx, adjustedCharPos := n.locatePosition(charPos)
 	return n.child.readerAtPosition(nodeIdx, adjustedCharPos)
@@ -436,6 +530,19 @@ func (n *innerNode) numNewlinesBeforePosition(charPos uint64) uint64 {
 	return newlinesBefore + n.child.numNewlinesBeforePosition(n.numKeys-1, charPos-charsBefore)
 }
 
+func (n *innerNode) byteOffsetForPosition(charPos uint64) uint64 {
+	var charsBefore, bytesBefore uint64
+	for i := uint64(0); i < n.numKeys-1; i++ {
+		numChars := n.keys[i].numChars
+		if charPos < charsBefore+numChars {
+			return bytesBefore + n.child.byteOffsetForPosition(i, charPos-charsBefore)
+		}
+		charsBefore += numChars
+		bytesBefore += n.keys[i].numBytes
+	}
+	return bytesBefore + n.child.byteOffsetForPosition(n.numKeys-1, charPos-charsBefore)
+}
+
 func (n *innerNode) locatePosition(charPos uint64) (nodeIdx, adjustedCharPos uint64) {
 	c := uint64(0)
 	for i := uint64(0); i < n.numKeys; i++ {
@@ -465,20 +572,16 @@ func (g *leafNodeGroup) keys() []indexKey {
 	return keys
 }
 
-func (g *leafNodeGroup) insertAtPosition(nodeIdx uint64, charPos uint64, c rune) (invalidateKeys bool, splitNodeGroup nodeGroup, err error) {
-	splitNode, err := g.nodes[nodeIdx].insertAtPosition(charPos, c)
-	if err != nil {
-		return false, nil, err
-	}
-
+func (g *leafNodeGroup) insertAtPosition(nodeIdx uint64, charPos uint64, chunk []byte, chunkKey indexKey) (invalidateKeys bool, splitNodeGroup nodeGroup) {
+	splitNode := g.nodes[nodeIdx].insertAtPosition(charPos, chunk)
 	if splitNode == nil {
-		return false, nil, nil
+		return false, nil
 	}
 
 	splitNodeIdx := nodeIdx + 1
 	if g.numNodes < maxNodesPerGroup {
 		g.insertNode(splitNodeIdx, splitNode)
-		return true, nil, nil
+		return true, nil
 	}
 
 	splitGroup := g.split()
@@ -487,7 +590,7 @@ func (g *leafNodeGroup) insertAtPosition(nodeIdx uint64, charPos uint64, c rune)
 	} else {
 		splitGroup.insertNode(splitNodeIdx-g.numNodes, splitNode)
 	}
-	return true, splitGroup, nil
+	return true, splitGroup
 }
 
 func (g *leafNodeGroup) insertNode(nodeIdx uint64, node *leafNode) {
@@ -520,6 +623,10 @@ func (g *leafNodeGroup) deleteAtPosition(nodeIdx uint64, charPos uint64) (didDel
 	return g.nodes[nodeIdx].deleteAtPosition(charPos)
 }
 
+func (g *leafNodeGroup) deleteRange(nodeIdx uint64, charPos uint64, numChars uint64) indexKey {
+	return g.nodes[nodeIdx].deleteRange(charPos, numChars)
+}
+
 func (g *leafNodeGroup) readerAtPosition(nodeIdx uint64, charPos uint64) Reader {
 	textByteOffset := g.nodes[nodeIdx].byteOffsetForPosition(charPos)
 	return Reader{
@@ -548,6 +655,10 @@ func (g *leafNodeGroup) numNewlinesBeforePosition(nodeIdx uint64, charPos uint64
 	return g.nodes[nodeIdx].numNewlinesBeforePosition(charPos)
 }
 
+func (g *leafNodeGroup) byteOffsetForPosition(nodeIdx uint64, charPos uint64) uint64 {
+	return g.nodes[nodeIdx].byteOffsetForPosition(charPos)
+}
+
 // leafNode is a node that stores UTF-8 text as a byte array.
 //
 // Multi-byte UTF-8 characters are never split between leaf nodes.
@@ -563,8 +674,12 @@ type leafNode struct {
 }
 
 func (l *leafNode) key() indexKey {
-	key := indexKey{}
-	for _, b := range l.textBytes[:l.numBytes] {
+	return keyForBytes(l.textBytes[:l.numBytes])
+}
+
+func keyForBytes(textBytes []byte) indexKey {
+	key := indexKey{numBytes: uint64(len(textBytes))}
+	for _, b := range textBytes {
 		key.numChars += uint64(textUtf8.StartByteIndicator[b])
 		if b == '\n' {
 			key.numNewlines++
@@ -573,46 +688,51 @@ func (l *leafNode) key() indexKey {
 	return key
 }
 
-func (l *leafNode) insertAtPosition(charPos uint64, c rune) (*leafNode, error) {
-	w := utf8.RuneLen(c)
-	if w < 0 {
-		return nil, ErrInvalidUtf8
-	}
-
-	charWidth := uint64(w)
-
-	if uint64(l.numBytes)+charWidth <= maxBytesPerLeaf {
-		l.insertAtPositionNoSplit(charPos, charWidth, c)
-		return nil, nil
+func (l *leafNode) insertAtPosition(charPos uint64, chunk []byte) *leafNode {
+	chunkLen := len(chunk)
+	if int(l.numBytes)+chunkLen <= maxBytesPerLeaf {
+		l.insertAtPositionNoSplit(charPos, chunk)
+		return nil
 	}
 
-	splitNode, numCharsRemaining := l.split()
-	if charPos < numCharsRemaining {
-		l.insertAtPositionNoSplit(charPos, charWidth, c)
+	splitIdx, numCharsBeforeSplit := l.splitIdx()
+	offset := byte(l.byteOffsetForPosition(charPos))
+	if (offset < splitIdx && int(splitIdx)+chunkLen > maxBytesPerLeaf) ||
+		(offset >= splitIdx && int(l.numBytes-splitIdx)+chunkLen > maxBytesPerLeaf) {
+		// The chunk won't fit in the half containing the insertion point,
+		// so split at the insertion point instead.
+		splitIdx = offset
+		numCharsBeforeSplit = byte(keyForBytes(l.textBytes[:offset]).numChars)
+	}
+
+	splitNode := l.split(splitIdx)
+	numCharsRemaining := uint64(numCharsBeforeSplit)
+	if charPos < numCharsRemaining || (charPos == numCharsRemaining && int(l.numBytes)+chunkLen <= maxBytesPerLeaf) {
+		l.insertAtPositionNoSplit(charPos, chunk)
 	} else {
-		splitNode.insertAtPositionNoSplit(charPos-numCharsRemaining, charWidth, c)
+		splitNode.insertAtPositionNoSplit(charPos-numCharsRemaining, chunk)
 	}
 
-	return splitNode, nil
+	return splitNode
 }
 
-func (l *leafNode) insertAtPositionNoSplit(charPos uint64, charWidth uint64, c rune) {
+func (l *leafNode) insertAtPositionNoSplit(charPos uint64, chunk []byte) {
 	offset := l.byteOffsetForPosition(charPos)
-	l.numBytes += byte(charWidth)
-	for i := int(l.numBytes) - 1; i >= int(offset+charWidth); i-- {
-		l.textBytes[i] = l.textBytes[i-int(charWidth)]
+	chunkLen := uint64(len(chunk))
+	l.numBytes += byte(chunkLen)
+	for i := int(l.numBytes) - 1; i >= int(offset+chunkLen); i-- {
+		l.textBytes[i] = l.textBytes[i-int(chunkLen)]
 	}
-	utf8.EncodeRune(l.textBytes[offset:], c)
+	copy(l.textBytes[offset:], chunk)
 }
 
-func (l *leafNode) split() (*leafNode, uint64) {
-	splitIdx, numCharsBeforeSplit := l.splitIdx()
+func (l *leafNode) split(splitIdx byte) *leafNode {
 	splitNode := leafNode{numBytes: l.numBytes - splitIdx}
 	for i := byte(0); i < splitNode.numBytes; i++ {
 		splitNode.textBytes[i] = l.textBytes[i+splitIdx]
 	}
 	l.numBytes = splitIdx
-	return &splitNode, uint64(numCharsBeforeSplit)
+	return &splitNode
 }
 
 func (l *leafNode) splitIdx() (splitIdx, numCharsBeforeSplit byte) {
@@ -645,6 +765,16 @@ func (l *leafNode) deleteAtPosition(charPos uint64) (didDelete, wasNewline bool,
 	return
 }
 
+// deleteRange deletes up to numChars characters starting at charPos, and returns the key of the deleted text.
+func (l *leafNode) deleteRange(charPos uint64, numChars uint64) indexKey {
+	startOffset := l.byteOffsetForPosition(charPos)
+	endOffset := l.byteOffsetForPosition(charPos + numChars)
+	deleted := keyForBytes(l.textBytes[startOffset:endOffset])
+	copy(l.textBytes[startOffset:], l.textBytes[endOffset:l.numBytes])
+	l.numBytes -= byte(endOffset - startOffset)
+	return deleted
+}
+
 func (l *leafNode) byteOffsetForPosition(charPos uint64) uint64 {
 	n := uint64(0)
 	for i, b := range l.textBytes[:l.numBytes] {
@@ -1,7 +1,9 @@
 package text
 
 import (
+	"fmt"
 	"io"
+	"math/rand"
 	"strings"
 	"testing"
 	"unicode/utf8"
@@ -353,6 +355,67 @@ func TestLineNumForPosition(t *testing.T) {
 	}
 }
 
+func TestByteOffsetForPosition(t *testing.T) {
+	testCases := []struct {
+		name             string
+		text             string
+		position         uint64
+		expectByteOffset uint64
+	}{
+		{
+			name:             "empty",
+			text:             "",
+			position:         0,
+			expectByteOffset: 0,
+		},
+		{
+			name:             "ascii, start",
+			text:             "abcd",
+			position:         0,
+			expectByteOffset: 0,
+		},
+		{
+			name:             "ascii, middle",
+			text:             "abcd",
+			position:         2,
+			expectByteOffset: 2,
+		},
+		{
+			name:             "ascii, past end",
+			text:             "abcd",
+			position:         10,
+			expectByteOffset: 4,
+		},
+		{
+			name:             "multi-byte characters",
+			text:             "£ôƊ፴ऴஅ",
+			position:         4,
+			expectByteOffset: 9,
+		},
+		{
+			name:             "many lines",
+			text:             lines(4096, 1024),
+			position:         1025 * 100,
+			expectByteOffset: 1025 * 100,
+		},
+		{
+			name:             "many lines with multi-byte characters",
+			text:             strings.Repeat("£ôƊ፴\n", 4096),
+			position:         5 * 1000,
+			expectByteOffset: 10 * 1000,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := NewTreeFromString(tc.text)
+			require.NoError(t, err)
+			byteOffset := tree.ByteOffsetForPosition(tc.position)
+			assert.Equal(t, tc.expectByteOffset, byteOffset)
+		})
+	}
+}
+
 func TestReaderPastLastLine(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -1161,6 +1224,166 @@ func TestNodeSplit(t *testing.T) {
 	assert.Equal(t, 1341, len(tree.String()))
 }
 
+func TestReplaceRange(t *testing.T) {
+	testCases := []struct {
+		name         string
+		text         string
+		pos          uint64
+		numChars     uint64
+		replacement  string
+		expectedText string
+	}{
+		{
+			name:         "empty tree, empty replacement",
+			text:         "",
+			pos:          0,
+			numChars:     0,
+			replacement:  "",
+			expectedText: "",
+		},
+		{
+			name:         "empty tree, insert",
+			text:         "",
+			pos:          0,
+			numChars:     0,
+			replacement:  "abc",
+			expectedText: "abc",
+		},
+		{
+			name:         "delete only",
+			text:         "abcdef",
+			pos:          1,
+			numChars:     3,
+			replacement:  "",
+			expectedText: "aef",
+		},
+		{
+			name:         "replace with shorter text",
+			text:         "abcdef",
+			pos:          1,
+			numChars:     3,
+			replacement:  "x",
+			expectedText: "axef",
+		},
+		{
+			name:         "replace with longer text",
+			text:         "abcdef",
+			pos:          1,
+			numChars:     1,
+			replacement:  "xyz\n123",
+			expectedText: "axyz\n123cdef",
+		},
+		{
+			name:         "replace past end of text",
+			text:         "abcdef",
+			pos:          4,
+			numChars:     10,
+			replacement:  "xy",
+			expectedText: "abcdxy",
+		},
+		{
+			name:         "insert past end of text",
+			text:         "abc",
+			pos:          10,
+			numChars:     0,
+			replacement:  "xy",
+			expectedText: "abcxy",
+		},
+		{
+			name:         "replace multi-byte chars",
+			text:         "£ôƊ፴ऴஅ",
+			pos:          2,
+			numChars:     2,
+			replacement:  "\U0010AAAA\U0010BBBB\U0010CCCC",
+			expectedText: "£ô\U0010AAAA\U0010BBBB\U0010CCCCऴஅ",
+		},
+		{
+			name:         "replace across many leaves",
+			text:         Repeat('a', 4096),
+			pos:          10,
+			numChars:     4000,
+			replacement:  Repeat('Ɗ', 2048),
+			expectedText: Repeat('a', 10) + Repeat('Ɗ', 2048) + Repeat('a', 86),
+		},
+		{
+			name:         "insert many lines into middle",
+			text:         Repeat('a', 1024),
+			pos:          512,
+			numChars:     0,
+			replacement:  strings.Repeat("line\n", 1000),
+			expectedText: Repeat('a', 512) + strings.Repeat("line\n", 1000) + Repeat('a', 512),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := NewTreeFromString(tc.text)
+			require.NoError(t, err)
+			err = tree.ReplaceRange(tc.pos, tc.numChars, tc.replacement)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedText, tree.String())
+			assert.Equal(t, uint64(utf8.RuneCountInString(tc.expectedText)), tree.NumChars())
+			assert.Equal(t, uint64(strings.Count(tc.expectedText, "\n")+1), tree.NumLines())
+		})
+	}
+}
+
+func TestReplaceRangeInvalidUtf8(t *testing.T) {
+	tree, err := NewTreeFromString("abc")
+	require.NoError(t, err)
+	err = tree.ReplaceRange(1, 1, "\xff")
+	assert.ErrorIs(t, err, ErrInvalidUtf8)
+	assert.Equal(t, "abc", tree.String())
+}
+
+func TestReplaceRangeMatchesRuneByRune(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	chars := []rune{'a', 'b', '\n', 'Ɗ', '፴', '\U0010AAAA'}
+	randomString := func(n int) string {
+		runes := make([]rune, n)
+		for i := range runes {
+			runes[i] = chars[rng.Intn(len(chars))]
+		}
+		return string(runes)
+	}
+
+	tree := NewTree()
+	expectedTree := NewTree()
+	for i := 0; i < 500; i++ {
+		n := tree.NumChars()
+		pos := uint64(rng.Int63n(int64(n + 1)))
+		numChars := uint64(rng.Int63n(int64(n-pos+1))) / 2
+		replacement := randomString(rng.Intn(200))
+
+		err := tree.ReplaceRange(pos, numChars, replacement)
+		require.NoError(t, err)
+
+		for j := uint64(0); j < numChars; j++ {
+			expectedTree.DeleteAtPosition(pos)
+		}
+		for j, r := range []rune(replacement) {
+			err := expectedTree.InsertAtPosition(pos+uint64(j), r)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, expectedTree.String(), tree.String())
+		require.Equal(t, expectedTree.NumChars(), tree.NumChars())
+		require.Equal(t, expectedTree.NumLines(), tree.NumLines())
+	}
+
+	for lineNum := uint64(0); lineNum < tree.NumLines(); lineNum++ {
+		assert.Equal(t, expectedTree.LineStartPosition(lineNum), tree.LineStartPosition(lineNum))
+	}
+
+	// Byte offsets are tracked in the index keys, so check that they stayed consistent with the text after every edit.
+	var charPos uint64
+	for byteOffset := range tree.String() {
+		require.Equal(t, uint64(byteOffset), tree.ByteOffsetForPosition(charPos))
+		require.Equal(t, uint64(byteOffset), expectedTree.ByteOffsetForPosition(charPos))
+		charPos++
+	}
+}
+
 func BenchmarkLoad(b *testing.B) {
 	benchmarks := []struct {
 		name     string
@@ -1251,6 +1474,49 @@ func BenchmarkReadRune(b *testing.B) {
 	}
 }
 
+func BenchmarkManyShortLines(b *testing.B) {
+	benchmarks := []struct {
+		name     string
+		numLines int
+	}{
+		{name: "thousand", numLines: 1000},
+		{name: "million", numLines: 1000000},
+	}
+
+	for _, bm := range benchmarks {
+		tree, err := NewTreeFromString(strings.Repeat("1,2,3\n", bm.numLines))
+		if err != nil {
+			b.Fatalf("err = %v", err)
+		}
+		lastLine := tree.NumLines() - 1
+		endPos := tree.NumChars()
+
+		b.Run(fmt.Sprintf("%s/line start position", bm.name), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				tree.LineStartPosition(lastLine)
+			}
+		})
+
+		b.Run(fmt.Sprintf("%s/line num for position", bm.name), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				tree.LineNumForPosition(endPos)
+			}
+		})
+
+		b.Run(fmt.Sprintf("%s/byte offset for position", bm.name), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				tree.ByteOffsetForPosition(endPos)
+			}
+		})
+
+		b.Run(fmt.Sprintf("%s/num lines", bm.name), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				tree.NumLines()
+			}
+		})
+	}
+}
+
 func BenchmarkInsert(b *testing.B) {
 	benchmarks := []struct {
 		name           string
@@ -1282,3 +1548,61 @@ func BenchmarkInsert(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkReplaceRange(b *testing.B) {
+	benchmarks := []struct {
+		name            string
+		numBytesInTree  int
+		numCharsDeleted uint64
+		numBytesInsert  int
+	}{
+		{name: "small edit", numBytesInTree: 1048576, numCharsDeleted: 16, numBytesInsert: 16},
+		{name: "large insert", numBytesInTree: 1048576, numCharsDeleted: 0, numBytesInsert: 65536},
+		{name: "large delete", numBytesInTree: 1048576, numCharsDeleted: 65536, numBytesInsert: 0},
+		{name: "large replace", numBytesInTree: 1048576, numCharsDeleted: 65536, numBytesInsert: 65536},
+	}
+
+	for _, bm := range benchmarks {
+		replacement := Repeat('x', bm.numBytesInsert)
+		pos := uint64(bm.numBytesInTree / 2)
+
+		b.Run(fmt.Sprintf("%s/replace range", bm.name), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				tree, err := NewTreeFromString(Repeat('a', bm.numBytesInTree))
+				if err != nil {
+					b.Fatalf("err = %v", err)
+				}
+				b.StartTimer()
+
+				err = tree.ReplaceRange(pos, bm.numCharsDeleted, replacement)
+				if err != nil {
+					b.Fatalf("err = %v", err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("%s/rune by rune", bm.name), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				tree, err := NewTreeFromString(Repeat('a', bm.numBytesInTree))
+				if err != nil {
+					b.Fatalf("err = %v", err)
+				}
+				b.StartTimer()
+
+				for i := uint64(0); i < bm.numCharsDeleted; i++ {
+					tree.DeleteAtPosition(pos)
+				}
+				for i, r := range replacement {
+					err = tree.InsertAtPosition(pos+uint64(i), r)
+					if err != nil {
+						b.Fatalf("err = %v", err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,30 +1,95 @@
 package undo
 
+import (
+	"log"
+	"time"
+
+	"github.com/aretext/aretext/nondet"
+)
+
 // LogEntry represents an entry in the undo log.
 type LogEntry struct {
 	Ops         []Op
 	CursorBegin uint64
 	CursorEnd   uint64
+	Time        time.Time // When the entry was committed.
 }
 
 // Log tracks changes to a document and generates undo/redo operations.
+//
+// The log is a tree of document states. Each state after the first is reached from its parent
+// by applying a committed entry. Committing an entry after an undo starts a new branch,
+// so the undone changes are still available from the earlier branch.
 type Log struct {
-	stagedEntry          LogEntry
-	committedEntries     []LogEntry
-	numUndoEntries       int
-	numEntriesAtLastSave int
+	stagedEntry LogEntry
+	nodes       []logNode // Indexed by state number, in the order committed. The first node is the original document.
+	current     int       // The state of the document.
+	savedState  int       // The state when the document was last saved.
+
+	// Text larger than the spill threshold is moved to the spill file.
+	// The spill file is created when first needed. If it cannot be created
+	// or written, large text stays in memory.
+	spillThreshold int
+	spill          *spillFile
+	spillFailed    bool
+}
+
+// logNode is a state of the document in the undo tree.
+type logNode struct {
+	entry     LogEntry // Transforms the parent state to this state. Empty for the original document.
+	parent    int      // -1 for the original document.
+	redoChild int      // The child state reached by redo, or -1 if there are no children.
+	depth     int      // The number of entries applied to the original document to reach this state.
+}
+
+// StateInfo describes a state in the undo tree.
+type StateInfo struct {
+	Num        int       // Identifies the state, counting from zero for the original document in the order committed.
+	NumChanges int       // The number of entries applied to the original document to reach the state.
+	Time       time.Time // When the last entry in the state was committed.
+	Current    bool      // Whether the document is in this state.
 }
 
 // NewLog constructs a new, empty undo log.
 func NewLog() *Log {
+	return NewLogWithSpillThreshold(DefaultSpillThreshold)
+}
+
+// NewLogWithSpillThreshold constructs a new, empty undo log that moves text of at least spillThreshold bytes to a spill file.
+// If spillThreshold is NoSpill, all text stays in memory. This is used for documents that must never be written to disk unencrypted.
+func NewLogWithSpillThreshold(spillThreshold int) *Log {
 	return &Log{
-		stagedEntry:          LogEntry{},
-		committedEntries:     nil,
-		numUndoEntries:       0,
-		numEntriesAtLastSave: 0,
+		stagedEntry:    LogEntry{},
+		nodes:          []logNode{{parent: -1, redoChild: -1}},
+		current:        0,
+		savedState:     0,
+		spillThreshold: spillThreshold,
 	}
 }
 
+// DisableSpill keeps text from later operations in memory.
+// Text already moved to the spill file stays there.
+func (l *Log) DisableSpill() {
+	l.spillThreshold = NoSpill
+}
+
+// HasSpillFile returns whether the log has moved any text to a spill file.
+func (l *Log) HasSpillFile() bool {
+	return l.spill != nil
+}
+
+// Close releases the spill file, if any.
+// The log must not be used after it is closed.
+func (l *Log) Close() {
+	if l == nil || l.spill == nil {
+		return
+	}
+	if err := l.spill.close(); err != nil {
+		log.Printf("Error closing undo spill file: %v\n", err)
+	}
+	l.spill = nil
+}
+
 // BeginEntry starts a new undo entry.
 // This should be called before tracking any operations.
 func (l *Log) BeginEntry(cursorPos uint64) {
@@ -34,74 +99,191 @@ func (l *Log) BeginEntry(cursorPos uint64) {
 // CommitEntry completes an undo entry.
 // This should be called after BeginEntry.
 // If no operations were tracked, this does nothing.
+// If the current state has undone changes, the entry starts a new branch of the undo tree.
 func (l *Log) CommitEntry(cursorPos uint64) {
 	if len(l.stagedEntry.Ops) == 0 {
 		return
 	}
 
-	if len(l.committedEntries) > l.numUndoEntries {
-		// Invalidate future changes.
-		l.committedEntries = l.committedEntries[0:l.numUndoEntries]
-	}
-
-	if l.numEntriesAtLastSave > l.numUndoEntries {
-		// Invalidate a save point in the future.
-		l.numEntriesAtLastSave = -1
-	}
-
 	l.stagedEntry.CursorEnd = cursorPos
-	l.committedEntries = append(l.committedEntries, l.stagedEntry)
+	l.stagedEntry.Time = nondet.Now()
+	l.nodes = append(l.nodes, logNode{
+		entry:     l.stagedEntry,
+		parent:    l.current,
+		redoChild: -1,
+		depth:     l.nodes[l.current].depth + 1,
+	})
 	l.stagedEntry = LogEntry{}
-	l.numUndoEntries++
+
+	newState := len(l.nodes) - 1
+	l.nodes[l.current].redoChild = newState
+	l.current = newState
 }
 
 // TrackOp tracks a change to the document.
 // This appends a new, uncommitted change and invalidates any future changes.
 func (l *Log) TrackOp(op Op) {
 	// Stage a new undo entry.
-	l.stagedEntry.Ops = append(l.stagedEntry.Ops, op)
+	l.stagedEntry.Ops = append(l.stagedEntry.Ops, l.maybeSpillOp(op))
+}
+
+// maybeSpillOp moves the op's text to the spill file if it exceeds the spill threshold.
+func (l *Log) maybeSpillOp(op Op) Op {
+	size := op.textSize()
+	if l.spillThreshold == NoSpill || size < l.spillThreshold || l.spillFailed {
+		return op
+	}
+
+	if l.spill == nil {
+		sf, err := newSpillFile()
+		if err != nil {
+			log.Printf("Error creating undo spill file, keeping %d bytes in memory: %v\n", size, err)
+			l.spillFailed = true
+			return op
+		}
+		l.spill = sf
+	}
+
+	spilledOp, err := op.spill(l.spill)
+	if err != nil {
+		log.Printf("Error writing to undo spill file, keeping %d bytes in memory: %v\n", size, err)
+		l.spillFailed = true
+		return op
+	}
+
+	log.Printf("Moved %d bytes of undo text to spill file (total %d bytes from %d op(s))\n", size, l.spill.size, l.spill.numSpills)
+	return spilledOp
 }
 
 // TrackSave moves the savepoint to the current entry.
 func (l *Log) TrackSave() {
-	l.numEntriesAtLastSave = l.numUndoEntries
+	l.savedState = l.current
 }
 
 // UndoToLastCommitted returns operations to transform the document back to its state before the last entry.
 // It also moves the current position backwards in the log.
 func (l *Log) UndoToLastCommitted() (hasEntry bool, ops []Op, cursor uint64) {
-	if l.numUndoEntries == 0 {
+	node := l.nodes[l.current]
+	if node.parent < 0 {
 		return false, nil, 0
 	}
 
-	entry := l.committedEntries[l.numUndoEntries-1]
-	ops = make([]Op, 0, len(entry.Ops))
-	for i := len(entry.Ops) - 1; i >= 0; i-- {
-		ops = append(ops, entry.Ops[i].Inverse())
-	}
+	// Redo returns to the undone state, even if it's on an older branch.
+	l.nodes[node.parent].redoChild = l.current
+	l.current = node.parent
 
-	l.numUndoEntries--
-
-	return true, ops, entry.CursorBegin
+	return true, inverseOps(node.entry.Ops), node.entry.CursorBegin
 }
 
 // RedoToNextCommitted returns operations to to transform the document to its state after the next entry.
 // It also moves the current position forward in the log.
 func (l *Log) RedoToNextCommitted() (hasEntry bool, ops []Op, cursor uint64) {
-	if l.numUndoEntries == len(l.committedEntries) {
+	child := l.nodes[l.current].redoChild
+	if child < 0 {
 		return false, nil, 0
 	}
 
-	entry := l.committedEntries[l.numUndoEntries]
+	entry := l.nodes[child].entry
 	ops = make([]Op, 0, len(entry.Ops))
 	ops = append(ops, entry.Ops...)
 
-	l.numUndoEntries++
+	l.current = child
 
 	return true, ops, entry.CursorEnd
 }
 
+// MoveToState returns operations to transform the document to another state in the undo tree,
+// undoing changes back to the branch containing the state, then redoing changes along that branch.
+// It also moves the current position in the log to the state.
+func (l *Log) MoveToState(stateNum int) (hasEntry bool, ops []Op, cursor uint64) {
+	if stateNum < 0 || stateNum >= len(l.nodes) || stateNum == l.current {
+		return false, nil, 0
+	}
+
+	// Find the states to redo, from the target up to the closest state shared with the current branch.
+	ancestor := l.current
+	var redoStates []int
+	for target := stateNum; target != ancestor; {
+		if l.nodes[target].depth >= l.nodes[ancestor].depth {
+			redoStates = append(redoStates, target)
+			target = l.nodes[target].parent
+		} else {
+			ancestor = l.nodes[ancestor].parent
+		}
+	}
+
+	for l.current != ancestor {
+		_, undoOps, undoCursor := l.UndoToLastCommitted()
+		ops = append(ops, undoOps...)
+		cursor = undoCursor
+	}
+
+	for i := len(redoStates) - 1; i >= 0; i-- {
+		l.nodes[l.current].redoChild = redoStates[i]
+		_, redoOps, redoCursor := l.RedoToNextCommitted()
+		ops = append(ops, redoOps...)
+		cursor = redoCursor
+	}
+
+	return true, ops, cursor
+}
+
+// CurrentState returns the number of the state of the document.
+func (l *Log) CurrentState() int {
+	return l.current
+}
+
+// NumStates returns the number of states in the undo tree, including the original document.
+func (l *Log) NumStates() int {
+	return len(l.nodes)
+}
+
+// Branches returns the last state in each branch of the undo tree, in the order committed.
+// This is empty if no entries have been committed.
+func (l *Log) Branches() []StateInfo {
+	var branches []StateInfo
+	for i, node := range l.nodes {
+		if i == 0 || node.redoChild >= 0 {
+			continue
+		}
+		branches = append(branches, l.stateInfo(i))
+	}
+	return branches
+}
+
+func (l *Log) stateInfo(stateNum int) StateInfo {
+	node := l.nodes[stateNum]
+	return StateInfo{
+		Num:        stateNum,
+		NumChanges: node.depth,
+		Time:       node.entry.Time,
+		Current:    stateNum == l.current,
+	}
+}
+
+// NumEntriesSince returns the number of entries committed at or after t that have not been undone.
+// Undoing this many entries returns the document to its state at time t.
+func (l *Log) NumEntriesSince(t time.Time) int {
+	n := 0
+	for i := l.current; l.nodes[i].parent >= 0; i = l.nodes[i].parent {
+		// Each state is committed after its parent, so entries on the path to the original document are in reverse order.
+		if l.nodes[i].entry.Time.Before(t) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
 // HasUnsavedChanges returns whether the log has unsaved changes.
 func (l *Log) HasUnsavedChanges() bool {
-	return l.numUndoEntries != l.numEntriesAtLastSave
+	return l.current != l.savedState
+}
+
+func inverseOps(ops []Op) []Op {
+	inverse := make([]Op, 0, len(ops))
+	for i := len(ops) - 1; i >= 0; i-- {
+		inverse = append(inverse, ops[i].Inverse())
+	}
+	return inverse
 }
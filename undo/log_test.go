@@ -1,9 +1,16 @@
 package undo
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/nondet"
 )
 
 func TestUndoToLastCommitted(t *testing.T) {
@@ -172,3 +179,179 @@ func TestHasUnsavedChanges(t *testing.T) {
 	log.TrackSave()
 	assert.False(t, log.HasUnsavedChanges())
 }
+
+func TestSpillLargeOps(t *testing.T) {
+	log := NewLog()
+	log.spillThreshold = 4
+	defer log.Close()
+
+	log.BeginEntry(0)
+	log.TrackOp(InsertOp(0, "ab"))
+	log.TrackOp(DeleteOp(2, "cdéfg"))
+	log.TrackOp(InsertOp(2, "hijkl"))
+	log.CommitEntry(2)
+	require.NotNil(t, log.spill)
+	assert.Equal(t, 2, log.spill.numSpills)
+
+	// Undo reads the spilled text back from disk.
+	hasEntry, ops, _ := log.UndoToLastCommitted()
+	require.True(t, hasEntry)
+	require.Equal(t, 3, len(ops))
+
+	assert.Equal(t, 5, ops[0].NumRunesToDelete())
+	assert.Equal(t, 0, ops[0].NumRunesToInsert())
+
+	assert.Equal(t, 5, ops[1].NumRunesToInsert())
+	s, err := ops[1].TextToInsert()
+	require.NoError(t, err)
+	assert.Equal(t, "cdéfg", s)
+
+	assert.Equal(t, DeleteOp(0, "ab"), ops[2])
+
+	// Redo returns the original spilled insert.
+	hasEntry, ops, _ = log.RedoToNextCommitted()
+	require.True(t, hasEntry)
+	require.Equal(t, 3, len(ops))
+	s, err = ops[2].TextToInsert()
+	require.NoError(t, err)
+	assert.Equal(t, "hijkl", s)
+}
+
+func TestSmallOpsNotSpilled(t *testing.T) {
+	log := NewLog()
+	defer log.Close()
+
+	log.BeginEntry(0)
+	log.TrackOp(InsertOp(0, "abc"))
+	log.CommitEntry(0)
+	assert.Nil(t, log.spill)
+}
+
+func TestSpillFileNamedWithNondetID(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	restore := nondet.SetSource(nondet.NewDeterministicSource(time.Unix(1700000000, 0), 0, 0))
+	defer restore()
+
+	// Occupy the first name, so the spill file uses the next ID.
+	takenPath := filepath.Join(dir, "aretext-undo-78fc2ffac2fd9401")
+	require.NoError(t, os.WriteFile(takenPath, nil, 0600))
+
+	sf, err := newSpillFile()
+	require.NoError(t, err)
+	defer sf.close()
+	assert.Equal(t, filepath.Join(dir, "aretext-undo-1f5b0412ffd341c0"), sf.f.Name())
+
+	// The spill file is removed from the directory as soon as it is created.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestNumEntriesSince(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	restore := nondet.SetSource(nondet.NewDeterministicSource(start, time.Minute, 0))
+	defer restore()
+
+	log := NewLog()
+	for i := 0; i < 3; i++ {
+		log.BeginEntry(uint64(i))
+		log.TrackOp(InsertOp(uint64(i), "a"))
+		log.CommitEntry(uint64(i + 1))
+	}
+
+	assert.Equal(t, 3, log.NumEntriesSince(start))
+	assert.Equal(t, 1, log.NumEntriesSince(log.nodes[3].entry.Time))
+	assert.Equal(t, 0, log.NumEntriesSince(log.nodes[3].entry.Time.Add(time.Second)))
+
+	// Undone entries are not counted.
+	log.UndoToLastCommitted()
+	assert.Equal(t, 2, log.NumEntriesSince(start))
+	assert.Equal(t, 0, log.NumEntriesSince(log.nodes[3].entry.Time))
+}
+
+func TestUndoTreeKeepsBranches(t *testing.T) {
+	log := NewLog()
+	log.BeginEntry(0)
+	log.TrackOp(InsertOp(0, "a"))
+	log.CommitEntry(1)
+	log.BeginEntry(1)
+	log.TrackOp(InsertOp(1, "b"))
+	log.CommitEntry(2)
+
+	// Undo "b", then insert "c" to start a new branch.
+	log.UndoToLastCommitted()
+	log.BeginEntry(1)
+	log.TrackOp(InsertOp(1, "c"))
+	log.CommitEntry(2)
+	assert.Equal(t, 3, log.CurrentState())
+	assert.Equal(t, 4, log.NumStates())
+
+	branches := log.Branches()
+	require.Equal(t, 2, len(branches))
+	assert.Equal(t, 2, branches[0].Num)
+	assert.Equal(t, 2, branches[0].NumChanges)
+	assert.False(t, branches[0].Current)
+	assert.Equal(t, 3, branches[1].Num)
+	assert.Equal(t, 2, branches[1].NumChanges)
+	assert.True(t, branches[1].Current)
+
+	// Move to the end of the first branch.
+	hasEntry, ops, cursor := log.MoveToState(2)
+	assert.True(t, hasEntry)
+	assert.Equal(t, []Op{DeleteOp(1, "c"), InsertOp(1, "b")}, ops)
+	assert.Equal(t, uint64(2), cursor)
+	assert.Equal(t, 2, log.CurrentState())
+
+	// Undo and redo stay on the first branch.
+	hasEntry, ops, _ = log.UndoToLastCommitted()
+	assert.True(t, hasEntry)
+	assert.Equal(t, []Op{DeleteOp(1, "b")}, ops)
+	hasEntry, ops, _ = log.RedoToNextCommitted()
+	assert.True(t, hasEntry)
+	assert.Equal(t, []Op{InsertOp(1, "b")}, ops)
+
+	// Move back to the original document.
+	hasEntry, ops, cursor = log.MoveToState(0)
+	assert.True(t, hasEntry)
+	assert.Equal(t, []Op{DeleteOp(1, "b"), DeleteOp(0, "a")}, ops)
+	assert.Equal(t, uint64(0), cursor)
+
+	// Moving to the current state or a state that doesn't exist does nothing.
+	hasEntry, _, _ = log.MoveToState(0)
+	assert.False(t, hasEntry)
+	hasEntry, _, _ = log.MoveToState(4)
+	assert.False(t, hasEntry)
+}
+
+func TestHasUnsavedChangesOnOtherBranch(t *testing.T) {
+	log := NewLog()
+	log.BeginEntry(0)
+	log.TrackOp(InsertOp(0, "a"))
+	log.CommitEntry(1)
+	log.TrackSave()
+
+	log.UndoToLastCommitted()
+	log.BeginEntry(0)
+	log.TrackOp(InsertOp(0, "b"))
+	log.CommitEntry(1)
+	assert.True(t, log.HasUnsavedChanges())
+
+	// Returning to the saved state on the other branch has no unsaved changes.
+	log.MoveToState(1)
+	assert.False(t, log.HasUnsavedChanges())
+}
+
+func TestOpStringOmitsText(t *testing.T) {
+	assert.Equal(t, "insert 3 rune(s) at 5", InsertOp(5, "a£c").String())
+	assert.Equal(t, "delete 6 rune(s) at 0", DeleteOp(0, "secret").String())
+	assert.Equal(t, "delete 6 rune(s) at 0", fmt.Sprintf("%v", DeleteOp(0, "secret")))
+}
+
+func TestNoSpillThreshold(t *testing.T) {
+	log := NewLogWithSpillThreshold(NoSpill)
+	log.BeginEntry(0)
+	log.TrackOp(InsertOp(0, "abcdefgh"))
+	log.CommitEntry(0)
+	assert.False(t, log.HasSpillFile())
+}
@@ -1,6 +1,7 @@
 package undo
 
 import (
+	"fmt"
 	"unicode/utf8"
 )
 
@@ -9,6 +10,11 @@ type Op struct {
 	pos        uint64
 	insertText string
 	deleteText string
+
+	// If the text is large, the log may move it to a spill file.
+	// In that case, the text field is empty and the spill reference is set.
+	insertSpill *spillRef
+	deleteSpill *spillRef
 }
 
 // InsertOp constructs a new operation to insert text at a position.
@@ -30,12 +36,25 @@ func DeleteOp(pos uint64, text string) Op {
 // Inverse returns an op that reverses the effect of the op.
 func (op Op) Inverse() Op {
 	return Op{
-		pos:        op.pos,
-		insertText: op.deleteText,
-		deleteText: op.insertText,
+		pos:         op.pos,
+		insertText:  op.deleteText,
+		deleteText:  op.insertText,
+		insertSpill: op.deleteSpill,
+		deleteSpill: op.insertSpill,
 	}
 }
 
+// String describes the kind and position of the op, but not its text,
+// so the op can be logged without revealing the contents of the document.
+func (op Op) String() string {
+	if n := op.NumRunesToInsert(); n > 0 {
+		return fmt.Sprintf("insert %d rune(s) at %d", n, op.pos)
+	} else if n := op.NumRunesToDelete(); n > 0 {
+		return fmt.Sprintf("delete %d rune(s) at %d", n, op.pos)
+	}
+	return fmt.Sprintf("no-op at %d", op.pos)
+}
+
 // Position returns the position at which the operation occurred.
 func (op Op) Position() uint64 {
 	return op.pos
@@ -43,12 +62,54 @@ func (op Op) Position() uint64 {
 
 // TextToInsert returns the text inserted by the op.
 // This will be an empty string if NumRunesToDelete is greater than zero.
-func (op Op) TextToInsert() string {
-	return op.insertText
+// If the text was moved to a spill file, this reads it from disk, which can fail.
+func (op Op) TextToInsert() (string, error) {
+	if op.insertSpill != nil {
+		return op.insertSpill.read()
+	}
+	return op.insertText, nil
+}
+
+// NumRunesToInsert returns the number of runes inserted at the position.
+// Unlike TextToInsert, this never reads from the spill file.
+func (op Op) NumRunesToInsert() int {
+	if op.insertSpill != nil {
+		return op.insertSpill.numRunes
+	}
+	return utf8.RuneCountInString(op.insertText)
 }
 
 // NumRunesToDelete returns the number of runes deleted at the position.
 // This will be zero if TextToInsert is a non-empty string.
 func (op Op) NumRunesToDelete() int {
+	if op.deleteSpill != nil {
+		return op.deleteSpill.numRunes
+	}
 	return utf8.RuneCountInString(op.deleteText)
 }
+
+// spill moves large text in the op to the spill file.
+func (op Op) spill(sf *spillFile) (Op, error) {
+	if op.insertText != "" {
+		ref, err := sf.write(op.insertText, utf8.RuneCountInString(op.insertText))
+		if err != nil {
+			return op, err
+		}
+		op.insertText, op.insertSpill = "", ref
+	}
+
+	if op.deleteText != "" {
+		ref, err := sf.write(op.deleteText, utf8.RuneCountInString(op.deleteText))
+		if err != nil {
+			return op, err
+		}
+		op.deleteText, op.deleteSpill = "", ref
+	}
+
+	return op, nil
+}
+
+// textSize returns the size in bytes of the text stored in memory for the op.
+func (op Op) textSize() int {
+	return len(op.insertText) + len(op.deleteText)
+}
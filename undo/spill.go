@@ -0,0 +1,81 @@
+package undo
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aretext/aretext/nondet"
+)
+
+// DefaultSpillThreshold is the minimum size in bytes of text moved from memory to the spill file.
+// Most edits are much smaller than this, so usually the spill file is never created.
+const DefaultSpillThreshold = 16 * 1024 * 1024 // 16 MiB
+
+// NoSpill is a spill threshold that keeps all text in memory.
+const NoSpill = 0
+
+// spillFile stores large text from undo operations on disk, so that undoing a huge
+// delete does not require keeping a second copy of the document in memory.
+// The file is removed as soon as it is created, so the operating system deletes it
+// when it is closed or the process exits.
+type spillFile struct {
+	f         *os.File
+	size      int64
+	numSpills int
+}
+
+// spillRef references text stored in a spill file.
+type spillRef struct {
+	file     *spillFile
+	offset   int64
+	numBytes int
+	numRunes int
+}
+
+func newSpillFile() (*spillFile, error) {
+	f, err := nondet.CreateTemp("", "aretext-undo-")
+	if err != nil {
+		return nil, fmt.Errorf("nondet.CreateTemp: %w", err)
+	}
+
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("os.Remove: %w", err)
+	}
+
+	log.Printf("Created undo spill file %s\n", f.Name())
+	return &spillFile{f: f}, nil
+}
+
+// write appends text to the spill file and returns a reference to it.
+func (sf *spillFile) write(s string, numRunes int) (*spillRef, error) {
+	offset := sf.size
+	n, err := sf.f.WriteAt([]byte(s), offset)
+	if err != nil {
+		return nil, fmt.Errorf("os.File.WriteAt: %w", err)
+	}
+	sf.size += int64(n)
+	sf.numSpills++
+	return &spillRef{
+		file:     sf,
+		offset:   offset,
+		numBytes: n,
+		numRunes: numRunes,
+	}, nil
+}
+
+func (sf *spillFile) close() error {
+	log.Printf("Closing undo spill file after storing %d bytes from %d op(s)\n", sf.size, sf.numSpills)
+	return sf.f.Close()
+}
+
+// read loads the referenced text from the spill file.
+func (ref *spillRef) read() (string, error) {
+	buf := make([]byte, ref.numBytes)
+	if _, err := ref.file.f.ReadAt(buf, ref.offset); err != nil {
+		return "", fmt.Errorf("os.File.ReadAt: %w", err)
+	}
+	log.Printf("Read %d bytes from undo spill file at offset %d\n", ref.numBytes, ref.offset)
+	return string(buf), nil
+}